@@ -224,7 +224,8 @@ func (x *BlobRef) GetId() string {
 type ArtifactAttachmentInput struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// A supported sidecar suffix appended to the primary input name, such as
-	// ".meta.json" or ".typetree.json".
+	// ".meta.json", ".typetree.json", the ".png"/".dds" image of a TEX editing
+	// JSON, or the ".csv" table of a NEI editing JSON.
 	Suffix string `protobuf:"bytes,1,opt,name=suffix,proto3" json:"suffix,omitempty"`
 	// Types that are valid to be assigned to Location:
 	//
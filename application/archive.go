@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
 	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
@@ -302,6 +303,30 @@ func (e *Engine) ExtractArchiveEntry(ctx context.Context, source Source, formatI
 	}
 }
 
+// VerifyArc 逐条目校验 COM3D2 .arc 源并返回问题报告，ARC 损坏本身不算错误，只有读取失败或取消时才返回错误
+// VerifyArc verifies a COM3D2 .arc source entry by entry and returns a problem report; a damaged ARC is not itself an error, only read failures and cancellation are
+func (e *Engine) VerifyArc(ctx context.Context, source Source) (*arc.VerifyReport, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if source == nil {
+		return nil, opError("verify ARC", CodeInvalidArgument, fmt.Errorf("source is required"))
+	}
+	workspace, path, err := e.materialize(ctx, source, source.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workspace)
+	report, err := (&COM3D2Service.ArcService{}).VerifyArc(ctx, path)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, opError("verify ARC", CodeCanceled, ctxErr)
+		}
+		return nil, opError("verify ARC", CodeInternal, err)
+	}
+	return report, nil
+}
+
 // readKCESUnityFSArchive 按独立扩展名格式选择对应的 UnityFS service 并返回已解析资源包
 // readKCESUnityFSArchive selects the extension-specific UnityFS service and returns the parsed bundle
 func readKCESUnityFSArchive(formatID string, path string) (*aba.Aba, *os.File, error) {
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/ct"
 )
@@ -100,6 +101,34 @@ func TestEngineStreamsAbaEntryAcrossRanges(t *testing.T) {
 	}
 }
 
+func TestEngineVerifyArcReportsDamagedEntry(t *testing.T) {
+	fs := arc.NewArc("sample")
+	fs.CreateFile("menu/dress.menu", bytes.Repeat([]byte("menu"), 100))
+	arcPath := filepath.Join(t.TempDir(), "sample.arc")
+	if err := fs.Dump(arcPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(EngineOptions{})
+	report, err := engine.VerifyArc(context.Background(), NewBytesSource("sample.arc", data))
+	if err != nil || !report.OK() || report.Files != 1 {
+		t.Fatalf("VerifyArc clean: report=%+v err=%v", report, err)
+	}
+	// 第一个条目紧跟在 28 字节头部之后，改写其记录的原始大小
+	// The first entry follows the 28-byte header; rewrite its recorded raw size
+	data[28+8]++
+	report, err = engine.VerifyArc(context.Background(), NewBytesSource("sample.arc", data))
+	if err != nil {
+		t.Fatalf("VerifyArc damaged: %v", err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Path != "menu/dress.menu" || report.Issues[0].Kind != arc.VerifySize {
+		t.Fatalf("issues: %+v", report.Issues)
+	}
+}
+
 func TestEngineRejectsOversizedContentTableEntryBeforeWriting(t *testing.T) {
 	table := &ct.ContentTable{Version: 1000, Raw: make([]byte, ct.HeaderSize), Files: map[string]ct.VirtualFile{}}
 	table.AddFile("large.bin", bytes.Repeat([]byte{'x'}, 65))
@@ -16,6 +16,7 @@ import (
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/internal/conversionio"
 	serializationCOM3D2 "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/nei"
 	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
 )
@@ -227,6 +228,12 @@ func (e *Engine) detectPath(ctx context.Context, path string) (Detection, error)
 	if dance, matched := detectDanceFile(path); matched {
 		return dance, nil
 	}
+	if nei, matched, err := detectNeiFile(path); matched {
+		if err != nil {
+			return Detection{}, opError("detect COM3D2 NEI", CodeInvalidArgument, err)
+		}
+		return nei, nil
+	}
 	if strings.EqualFold(filepath.Ext(path), ".arc") {
 		arcFile, closer, arcErr := (&COM3D2Service.ArcService{}).ReadArcLazy(path)
 		if arcErr != nil {
@@ -304,6 +311,49 @@ func detectDanceFile(path string) (Detection, bool) {
 	}, true
 }
 
+// detectNeiFile 按扩展名识别 COM3D2 .nei 表格或其编辑 JSON，原生文件只检查尺寸和解密后第一个分组中的签名
+// .nei 没有明文签名，因此只在扩展名相符时探测，完整解密留给转换；matched 为 true 且 error 非空表示损坏的候选文件
+// detectNeiFile identifies a COM3D2 .nei table or its editing JSON by extension, checking only the size and the signature in the first decrypted block of native files
+// .nei carries no plaintext signature, so it is probed only when the extension matches and full decryption is left to conversion; a true match with a non-nil error reports a malformed candidate
+func detectNeiFile(path string) (Detection, bool, error) {
+	name := filepath.Base(path)
+	lowerName := strings.ToLower(name)
+	detection := Detection{FormatID: "com3d2.nei", Game: "COM3D2", FileType: "nei", Name: name}
+	switch {
+	case strings.HasSuffix(lowerName, ".nei.json"):
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Detection{}, true, err
+		}
+		var info serializationCOM3D2.NeiCSVInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			return Detection{}, true, fmt.Errorf("parse .nei editing JSON: %w", err)
+		}
+		detection.Representation = RepresentationEditingJSON
+		detection.StorageFormat = "json"
+		detection.Size = int64(len(data))
+	case strings.HasSuffix(lowerName, ".nei"):
+		f, err := os.Open(path)
+		if err != nil {
+			return Detection{}, true, err
+		}
+		defer f.Close()
+		stat, err := f.Stat()
+		if err != nil {
+			return Detection{}, true, err
+		}
+		if err := nei.Probe(f, stat.Size(), nil); err != nil {
+			return Detection{}, true, err
+		}
+		detection.Representation = RepresentationNative
+		detection.StorageFormat = "binary"
+		detection.Size = stat.Size()
+	default:
+		return Detection{}, false, nil
+	}
+	return detection, true, nil
+}
+
 // detectionFromFileInfo 将服务层文件信息转换为应用层检测结果
 // detectionFromFileInfo converts service-layer file information into an application detection result
 func detectionFromFileInfo(info COM3D2Service.FileInfo) Detection {
@@ -631,7 +681,7 @@ func (e *Engine) copyFileArtifact(ctx context.Context, path, name, formatID stri
 // readArtifactAttachments reads managed conversion companion files and checks their aggregate size
 func readArtifactAttachments(ctx context.Context, path, name string, remaining int64) ([]ArtifactAttachment, error) {
 	var result []ArtifactAttachment
-	for _, suffix := range artifactAttachmentSuffixes {
+	for _, suffix := range attachmentSuffixesFor(name) {
 		attachmentPath := path + suffix
 		info, err := os.Stat(attachmentPath)
 		if os.IsNotExist(err) {
@@ -692,7 +742,7 @@ func inspectArtifactFiles(ctx context.Context, path string, limit int64) (int64,
 		return 0, opError("inspect conversion output", CodeResourceExhausted, fmt.Errorf("output size %d exceeds limit %d", info.Size(), limit))
 	}
 	total := info.Size()
-	for _, suffix := range artifactAttachmentSuffixes {
+	for _, suffix := range attachmentSuffixesFor(filepath.Base(path)) {
 		if err := ctx.Err(); err != nil {
 			return 0, opError("inspect conversion output", CodeCanceled, err)
 		}
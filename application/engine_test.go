@@ -4,13 +4,18 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"image"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	serializationCOM3D2 "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
 	serializationKCES "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
 )
 
@@ -412,6 +417,149 @@ func TestEnginePreservesRawUnityInputAndOutputAttachments(t *testing.T) {
 	}
 }
 
+func TestEngineConvertsTexThroughImageAttachment(t *testing.T) {
+	ctx := context.Background()
+	engine := NewEngine(EngineOptions{})
+	native := syntheticTexBytes(t)
+
+	jsonArtifact, editingJSON, err := engine.ConvertBytes(ctx, ConvertRequest{
+		Source: NewBytesSource("face.tex", native), To: RepresentationEditingJSON,
+	})
+	if err != nil {
+		t.Fatalf("tex to editing JSON: %v", err)
+	}
+	var info serializationCOM3D2.TexImageInfo
+	if err := json.Unmarshal(editingJSON, &info); err != nil {
+		t.Fatal(err)
+	}
+	if jsonArtifact.Name != "face.tex.json" || jsonArtifact.FormatID != "com3d2.tex" || info.TextureName != "face" || len(info.Rects) != 1 {
+		t.Fatalf("editing artifact = %+v, info = %+v", jsonArtifact, info)
+	}
+	attachments := jsonArtifact.AttachmentFiles()
+	if len(attachments) != 1 || attachments[0].Suffix != ".dds" || attachments[0].Name != "face.tex.json.dds" {
+		t.Fatalf("editing attachments = %+v", attachments)
+	}
+	digest := sha256.Sum256(attachments[0].Data)
+	if attachments[0].SHA256 != hex.EncodeToString(digest[:]) || attachments[0].Size != int64(len(attachments[0].Data)) {
+		t.Fatalf("attachment digest = %+v", attachments[0])
+	}
+
+	source, err := NewBundleSource(NewBytesSource(jsonArtifact.Name, editingJSON), []SourceAttachment{
+		{Suffix: ".dds", Source: NewBytesSource("ignored", attachments[0].Data)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nativeArtifact, back, err := engine.ConvertBytes(ctx, ConvertRequest{Source: source, To: RepresentationNative})
+	if err != nil {
+		t.Fatalf("editing JSON to tex: %v", err)
+	}
+	if nativeArtifact.Name != "face.tex" || !bytes.Equal(back, native) {
+		t.Fatalf("tex round trip changed: artifact %+v, %d bytes", nativeArtifact, len(back))
+	}
+
+	if _, _, err := engine.ConvertBytes(ctx, ConvertRequest{Source: NewBytesSource(jsonArtifact.Name, editingJSON), To: RepresentationNative}); CodeOf(err) != CodeInvalidArgument {
+		t.Fatalf("missing image attachment error = %v", err)
+	}
+	limited := NewEngine(EngineOptions{MaxOutputBytes: int64(len(editingJSON)) + 16})
+	if _, _, err := limited.ConvertBytes(ctx, ConvertRequest{Source: NewBytesSource("face.tex", native), To: RepresentationEditingJSON}); CodeOf(err) != CodeResourceExhausted {
+		t.Fatalf("image attachment over output limit error = %v", err)
+	}
+}
+
+func TestEngineConvertsNeiThroughCSVAttachment(t *testing.T) {
+	ctx := context.Background()
+	engine := NewEngine(EngineOptions{})
+	table := serializationKCES.NewNei(2, 2, [][]string{{"id", "name"}, {"1", "メイド"}})
+	var native bytes.Buffer
+	if err := table.Dump(&native); err != nil {
+		t.Fatal(err)
+	}
+
+	detection, err := engine.Detect(ctx, NewBytesSource("list.nei", native.Bytes()))
+	if err != nil || detection.FormatID != "com3d2.nei" || detection.Representation != RepresentationNative {
+		t.Fatalf("detect native nei = %+v, %v", detection, err)
+	}
+	jsonArtifact, editingJSON, err := engine.ConvertBytes(ctx, ConvertRequest{
+		Source: NewBytesSource("list.nei", native.Bytes()), To: RepresentationEditingJSON,
+	})
+	if err != nil {
+		t.Fatalf("nei to editing JSON: %v", err)
+	}
+	var info serializationCOM3D2.NeiCSVInfo
+	if err := json.Unmarshal(editingJSON, &info); err != nil {
+		t.Fatal(err)
+	}
+	if jsonArtifact.Name != "list.nei.json" || jsonArtifact.FormatID != "com3d2.nei" || info.TextEncoding != serializationCOM3D2.NeiTextEncodingUTF8 {
+		t.Fatalf("editing artifact = %+v, info = %+v", jsonArtifact, info)
+	}
+	attachments := jsonArtifact.AttachmentFiles()
+	if len(attachments) != 1 || attachments[0].Suffix != ".csv" || attachments[0].Name != "list.nei.json.csv" {
+		t.Fatalf("editing attachments = %+v", attachments)
+	}
+	if want := "\ufeffid,name\n1,メイド\n"; string(attachments[0].Data) != want {
+		t.Fatalf("CSV attachment = %q, want %q", attachments[0].Data, want)
+	}
+
+	// 改写编码选择器后写回的表格应按 Shift-JIS 编码单元格 / Rewriting the encoding selector should write the table back with Shift-JIS cells
+	shiftJIS, err := json.Marshal(serializationCOM3D2.NeiCSVInfo{TextEncoding: serializationCOM3D2.NeiTextEncodingShiftJIS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := NewBundleSource(NewBytesSource(jsonArtifact.Name, shiftJIS), []SourceAttachment{
+		{Suffix: ".csv", Source: NewBytesSource("ignored", attachments[0].Data)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nativeArtifact, back, err := engine.ConvertBytes(ctx, ConvertRequest{Source: source, To: RepresentationNative})
+	if err != nil {
+		t.Fatalf("editing JSON to nei: %v", err)
+	}
+	decoded, err := serializationCOM3D2.ReadNei(bytes.NewReader(back), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nativeArtifact.Name != "list.nei" || decoded.TextEncoding != serializationCOM3D2.NeiTextEncodingShiftJIS || !reflect.DeepEqual(decoded.Data, table.Data) {
+		t.Fatalf("nei round trip changed: artifact %+v, table %+v", nativeArtifact, decoded)
+	}
+
+	if _, _, err := engine.ConvertBytes(ctx, ConvertRequest{Source: NewBytesSource(jsonArtifact.Name, editingJSON), To: RepresentationNative}); CodeOf(err) != CodeInvalidArgument {
+		t.Fatalf("missing CSV attachment error = %v", err)
+	}
+	unknown, err := NewBundleSource(NewBytesSource(jsonArtifact.Name, []byte(`{"TextEncoding":"EUC-JP"}`)), []SourceAttachment{
+		{Suffix: ".csv", Source: NewBytesSource("ignored", attachments[0].Data)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := engine.ConvertBytes(ctx, ConvertRequest{Source: unknown, To: RepresentationNative}); CodeOf(err) != CodeInvalidArgument {
+		t.Fatalf("unknown encoding error = %v", err)
+	}
+}
+
+func syntheticTexBytes(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 5)
+	}
+	blocks, err := bcn.Encode(bcn.FormatDXT5, img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := &serializationCOM3D2.Tex{
+		Signature: serializationCOM3D2.TexSignature, Version: 1011, TextureName: "face",
+		Rects: []serializationCOM3D2.TexRect{{W: 1, H: 1}}, Width: 8, Height: 8,
+		TextureFormat: serializationCOM3D2.DXT5, Data: blocks,
+	}
+	var output bytes.Buffer
+	if err := tex.Dump(&output); err != nil {
+		t.Fatalf("dump synthetic tex: %v", err)
+	}
+	return output.Bytes()
+}
+
 func syntheticMenuBytes(t *testing.T) []byte {
 	t.Helper()
 	menu := &serializationCOM3D2.Menu{
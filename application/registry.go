@@ -6,6 +6,7 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	editingv1 "github.com/MeidoPromotionAssociation/MeidoSerialization/schemas/editing/v1"
 	knowledgev1 "github.com/MeidoPromotionAssociation/MeidoSerialization/schemas/knowledge/v1"
@@ -45,6 +46,8 @@ type Format struct {
 	FileType string
 	// NativeSuffixes 是该格式接受的原生文件后缀 / NativeSuffixes contains the native file suffixes accepted for the format
 	NativeSuffixes []string
+	// AttachmentSuffixes 是该格式编辑 JSON 在通用伴随文件之外专用的伴随文件后缀 / AttachmentSuffixes contains companion suffixes used only by this format's editing JSON, beyond the common companions
+	AttachmentSuffixes []string
 	// DefaultName 是缺少可用输入名称时采用的原生文件名 / DefaultName is the native filename used when no suitable input name is available
 	DefaultName string
 	// Capability 描述该格式支持的应用操作 / Capability describes the application operations supported by the format
@@ -134,6 +137,7 @@ func NewRegistry(formats []Format) (*Registry, error) {
 			return nil, fmt.Errorf("duplicate format ID %q", format.ID)
 		}
 		format.NativeSuffixes = append([]string(nil), format.NativeSuffixes...)
+		format.AttachmentSuffixes = append([]string(nil), format.AttachmentSuffixes...)
 		format.SchemaVersion = ""
 		format.SchemaID = ""
 		format.SchemaSHA256 = ""
@@ -176,6 +180,7 @@ func (r *Registry) Lookup(id string) (Format, bool) {
 	}
 	f, ok := r.formats[strings.ToLower(strings.TrimSpace(id))]
 	f.NativeSuffixes = append([]string(nil), f.NativeSuffixes...)
+	f.AttachmentSuffixes = append([]string(nil), f.AttachmentSuffixes...)
 	return f, ok
 }
 
@@ -189,6 +194,7 @@ func (r *Registry) Formats() []Format {
 	for _, f := range r.formats {
 		f.convert = pathConverter{}
 		f.NativeSuffixes = append([]string(nil), f.NativeSuffixes...)
+		f.AttachmentSuffixes = append([]string(nil), f.AttachmentSuffixes...)
 		result = append(result, f)
 	}
 	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
@@ -234,10 +240,39 @@ func detectOnlyFormat(game, fileType, defaultName string, suffixes []string) For
 	return f
 }
 
+// withAttachmentSuffixes 为格式的编辑 JSON 声明专用伴随文件后缀
+// withAttachmentSuffixes declares the companion suffixes used only by a format's editing JSON
+func withAttachmentSuffixes(f Format, suffixes ...string) Format {
+	f.AttachmentSuffixes = suffixes
+	return f
+}
+
 // DefaultRegistry 返回包含 COM3D2 与 KCES 转换器及受支持归档容器的默认注册表
 // DefaultRegistry returns the default registry containing COM3D2 and KCES converters and supported archive containers
 func DefaultRegistry() *Registry {
-	formats := []Format{
+	r, err := NewRegistry(defaultFormats())
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// defaultFormatsWithAttachments 缓存默认格式中声明了专用伴随文件后缀的格式
+// defaultFormatsWithAttachments caches the default formats that declare their own companion suffixes
+var defaultFormatsWithAttachments = sync.OnceValue(func() []Format {
+	var result []Format
+	for _, f := range defaultFormats() {
+		if len(f.AttachmentSuffixes) != 0 {
+			result = append(result, f)
+		}
+	}
+	return result
+})
+
+// defaultFormats 返回默认注册表的格式定义
+// defaultFormats returns the format definitions of the default registry
+func defaultFormats() []Format {
+	return []Format{
 		format("COM3D2", "menu", "input.menu", []string{".menu"}, pathConverter{(&COM3D2Service.MenuService{}).ConvertMenuToJson, (&COM3D2Service.MenuService{}).ConvertJsonToMenu}),
 		format("COM3D2", "mate", "input.mate", []string{".mate", ".mat"}, pathConverter{(&COM3D2Service.MateService{}).ConvertMateToJson, (&COM3D2Service.MateService{}).ConvertJsonToMate}),
 		format("COM3D2", "pmat", "input.pmat", []string{".pmat"}, pathConverter{(&COM3D2Service.PMatService{}).ConvertPMatToJson, (&COM3D2Service.PMatService{}).ConvertJsonToPMat}),
@@ -249,7 +284,8 @@ func DefaultRegistry() *Registry {
 		format("COM3D2", "preset", "input.preset", []string{".preset"}, pathConverter{(&COM3D2Service.PresetService{}).ConvertPresetToJson, (&COM3D2Service.PresetService{}).ConvertJsonToPreset}),
 		format("COM3D2", "timeline", "timeline_data.bytes", []string{".bytes"}, pathConverter{(&COM3D2Service.DanceService{}).ConvertTimelineDataToJson, (&COM3D2Service.DanceService{}).ConvertJsonToTimelineData}),
 		format("COM3D2", "object_data", "maid_data.bytes", []string{".bytes"}, pathConverter{(&COM3D2Service.DanceService{}).ConvertDanceObjectDataToJson, (&COM3D2Service.DanceService{}).ConvertJsonToDanceObjectData}),
-		detectOnlyFormat("COM3D2", "tex", "input.tex", []string{".tex"}),
+		withAttachmentSuffixes(format("COM3D2", "tex", "input.tex", []string{".tex"}, pathConverter{(&COM3D2Service.TexService{}).ConvertTexToJson, (&COM3D2Service.TexService{}).ConvertJsonToTex}), ".png", ".dds"),
+		withAttachmentSuffixes(format("COM3D2", "nei", "input.nei", []string{".nei"}, pathConverter{(&COM3D2Service.NeiService{}).ConvertNeiToJson, (&COM3D2Service.NeiService{}).ConvertJsonToNei}), ".csv"),
 		detectOnlyFormat("COM3D2", "save", "input.save", []string{".save"}),
 		archiveFormat("COM3D2", "arc", "input.arc", []string{".arc"}),
 		format("KCES", "bridge_session", "bridge_session.vd", []string{".vd"}, pathConverter{(&KCESService.BridgeSessionService{}).ConvertBridgeSessionToJSON, (&KCESService.BridgeSessionService{}).ConvertJSONToBridgeSession}),
@@ -286,10 +322,4 @@ func DefaultRegistry() *Registry {
 		format("KCES", "ikcol.bytes", "input.ikcol.bytes", []string{".ikcol.bytes"}, pathConverter{(&KCESService.IKColBytesService{}).ConvertIKColBytesToJson, (&KCESService.IKColBytesService{}).ConvertJsonToIKColBytes}),
 		format("KCES", "limbcol", "input.limbcol", []string{".limbcol"}, pathConverter{(&KCESService.LimbColService{}).ConvertLimbColToJson, (&KCESService.LimbColService{}).ConvertJsonToLimbCol}),
 	}
-
-	r, err := NewRegistry(formats)
-	if err != nil {
-		panic(err)
-	}
-	return r
 }
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -46,14 +47,38 @@ type SourceAttachment struct {
 	Source Source
 }
 
+// artifactAttachmentSuffixes 是所有格式通用的伴随文件后缀，格式专用后缀由 Format.AttachmentSuffixes 声明
+// artifactAttachmentSuffixes holds the companion suffixes common to every format, while format-specific ones are declared by Format.AttachmentSuffixes
 var artifactAttachmentSuffixes = []string{".meta.json", ".typetree.json"}
 
-// ArtifactAttachmentSuffixes 返回作为单个应用制品管理的伴随文件后缀副本
-// ArtifactAttachmentSuffixes returns a copy of companion suffixes managed as part of one application artifact
+// ArtifactAttachmentSuffixes 返回所有格式都作为单个应用制品管理的通用伴随文件后缀副本
+// ArtifactAttachmentSuffixes returns a copy of the common companion suffixes managed as part of one application artifact for every format
 func ArtifactAttachmentSuffixes() []string {
 	return append([]string(nil), artifactAttachmentSuffixes...)
 }
 
+// attachmentSuffixesFor 返回名为 name 的制品受管理的伴随文件后缀，即通用后缀加上以其编辑 JSON 命名的格式声明的后缀
+// attachmentSuffixesFor returns the companion suffixes managed for an artifact named name: the common suffixes plus those declared by a format whose editing JSON it is named as
+func attachmentSuffixesFor(name string) []string {
+	suffixes := ArtifactAttachmentSuffixes()
+	lower := strings.ToLower(name)
+	if !strings.HasSuffix(lower, ".json") {
+		return suffixes
+	}
+	lower = strings.TrimSuffix(lower, ".json")
+	for _, f := range defaultFormatsWithAttachments() {
+		if !slices.ContainsFunc(f.NativeSuffixes, func(native string) bool { return strings.HasSuffix(lower, native) }) {
+			continue
+		}
+		for _, suffix := range f.AttachmentSuffixes {
+			if !slices.Contains(suffixes, suffix) {
+				suffixes = append(suffixes, suffix)
+			}
+		}
+	}
+	return suffixes
+}
+
 // attachmentSource 定义能够公开伴随输入文件的源 / attachmentSource defines a source capable of exposing companion input files
 type attachmentSource interface {
 	// Attachments 返回与主要输入关联的伴随文件
@@ -93,7 +118,7 @@ func NewBundleSource(primary Source, attachments []SourceAttachment) (Source, er
 		if all[i].Source == nil {
 			return nil, opError("create source bundle", CodeInvalidArgument, fmt.Errorf("attachment %d has no source", i))
 		}
-		suffix, err := normalizeAttachmentSuffix(all[i].Suffix)
+		suffix, err := normalizeAttachmentSuffix(primary.Name(), all[i].Suffix)
 		if err != nil {
 			return nil, opError("create source bundle", CodeInvalidArgument, err)
 		}
@@ -116,11 +141,11 @@ func sourceAttachments(source Source) []SourceAttachment {
 	return provider.Attachments()
 }
 
-// normalizeAttachmentSuffix 校验并规范化受管理的伴随文件后缀
-// normalizeAttachmentSuffix validates and normalizes a managed companion-file suffix
-func normalizeAttachmentSuffix(value string) (string, error) {
+// normalizeAttachmentSuffix 校验并规范化名为 name 的制品所管理的伴随文件后缀
+// normalizeAttachmentSuffix validates and normalizes a companion-file suffix managed for an artifact named name
+func normalizeAttachmentSuffix(name, value string) (string, error) {
 	suffix := strings.ToLower(strings.TrimSpace(value))
-	for _, supported := range artifactAttachmentSuffixes {
+	for _, supported := range attachmentSuffixesFor(name) {
 		if suffix == supported {
 			return supported, nil
 		}
@@ -222,7 +247,7 @@ func (s *fileSource) Open(ctx context.Context) (ReadSeekCloser, error) {
 // discoverFileAttachments finds managed regular companion files beside a primary local file
 func discoverFileAttachments(path string) ([]SourceAttachment, error) {
 	var result []SourceAttachment
-	for _, suffix := range artifactAttachmentSuffixes {
+	for _, suffix := range attachmentSuffixesFor(filepath.Base(path)) {
 		attachmentPath := path + suffix
 		info, err := os.Stat(attachmentPath)
 		if os.IsNotExist(err) {
@@ -363,7 +388,7 @@ func (r *RootSet) Resolve(id, relativePath string) (Source, error) {
 	}
 	primary := &rootSource{name: filepath.Base(rel), root: entry.root, rel: rel, size: info.Size()}
 	var attachments []SourceAttachment
-	for _, suffix := range artifactAttachmentSuffixes {
+	for _, suffix := range attachmentSuffixesFor(filepath.Base(rel)) {
 		attachmentRel := rel + suffix
 		attachmentInfo, attachmentErr := entry.root.Stat(attachmentRel)
 		if os.IsNotExist(attachmentErr) {
@@ -574,7 +599,7 @@ func (r *RootSet) WriteBundle(ctx context.Context, id, relativePath string, file
 			}
 			hasPrimary = true
 		} else {
-			suffix, err = normalizeAttachmentSuffix(suffix)
+			suffix, err = normalizeAttachmentSuffix(filepath.Base(rel), suffix)
 			if err != nil {
 				return nil, opError("write rooted bundle", CodeInvalidArgument, err)
 			}
@@ -604,8 +629,9 @@ func (r *RootSet) WriteBundle(ctx context.Context, id, relativePath string, file
 		return nil, opError("write rooted bundle", CodeInvalidArgument, fmt.Errorf("bundle primary file is required"))
 	}
 
-	managedTargets := make([]string, 0, len(artifactAttachmentSuffixes)+1)
-	for _, suffix := range artifactAttachmentSuffixes {
+	managedSuffixes := attachmentSuffixesFor(filepath.Base(rel))
+	managedTargets := make([]string, 0, len(managedSuffixes)+1)
+	for _, suffix := range managedSuffixes {
 		managedTargets = append(managedTargets, rel+suffix)
 	}
 	managedTargets = append(managedTargets, rel)
@@ -135,6 +135,63 @@ func TestRootSetResolveIncludesRawUnityAttachments(t *testing.T) {
 	}
 }
 
+func TestFormatAttachmentsDoNotClaimStrayFiles(t *testing.T) {
+	directory := t.TempDir()
+	for name, data := range map[string]string{
+		"foo.menu":           "menu",
+		"foo.menu.png":       "user image",
+		"face.tex.json":      "{}",
+		"face.tex.json.dds":  "dds",
+		"face.tex.json.csv":  "not a tex companion",
+		"table.nei.json":     "{}",
+		"table.nei.json.csv": "csv",
+		"table.nei.json.png": "not a nei companion",
+	} {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	roots := NewRootSet()
+	defer roots.Close()
+	if err := roots.AddWritable("work", directory); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"foo.menu": "", "face.tex.json": ".dds", "table.nei.json": ".csv"} {
+		fileSource, err := NewFileSource(filepath.Join(directory, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rootedSource, err := roots.Resolve("work", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, source := range []Source{fileSource, rootedSource} {
+			var got []string
+			for _, attachment := range sourceAttachments(source) {
+				got = append(got, attachment.Suffix)
+			}
+			if strings.Join(got, " ") != want {
+				t.Errorf("%s attachments = %v, want %q", name, got, want)
+			}
+		}
+	}
+	if _, err := NewBundleSource(NewBytesSource("foo.menu", []byte("menu")), []SourceAttachment{{Suffix: ".png", Source: NewBytesSource("foo.menu.png", nil)}}); CodeOf(err) != CodeInvalidArgument {
+		t.Errorf("menu with .png attachment error = %v", err)
+	}
+
+	if _, err := roots.WriteBundle(context.Background(), "work", "foo.menu", []BundleFile{{Reader: bytes.NewBufferString("new menu")}}, 64); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(directory, "foo.menu.png")); err != nil || string(got) != "user image" {
+		t.Fatalf("stray image after rooted write = %q, err=%v", got, err)
+	}
+	if _, err := roots.WriteBundle(context.Background(), "work", "foo.menu", []BundleFile{
+		{Reader: bytes.NewBufferString("menu")}, {Suffix: ".png", Reader: bytes.NewBufferString("image")},
+	}, 64); CodeOf(err) != CodeInvalidArgument {
+		t.Errorf("rooted menu write with .png attachment error = %v", err)
+	}
+}
+
 func TestRootSetWriteBundleInstallsAndPrunesSidecars(t *testing.T) {
 	directory := t.TempDir()
 	primary := filepath.Join(directory, "hair.mmesh.bytes")
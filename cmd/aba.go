@@ -2,12 +2,27 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
 )
 
+var (
+	packAbaCompressionFlag        string
+	packAbaCompressionLevel       int
+	packAbaManifestPath           string
+	packAbaCheckOnly              bool
+	recompressAbaCompressionFlag  string
+	recompressAbaCompressionLevel int
+	replaceAbaPathID              int64
+	replaceAbaName                string
+	replaceAbaStreamPath          string
+)
+
 var listAbaCmd = &cobra.Command{
 	Use:   "listAba [file/directory]",
 	Short: "List assets inside a .aba file",
@@ -82,13 +97,39 @@ all lowercase and must match the container names inside the package. A warning i
 mismatch and for .materialassets entries whose fileName does not end in a lowercase .mate, since
 the game can never look those materials up.
 
+Data blocks are compressed with LZ4 by default. --compression selects none, lz4, lz4hc, or lzma,
+and --level sets the lz4hc or lzma level from 1 to 9.
+
+--manifest packs from a JSON manifest (schema kces.mod_manifest.schema.json) instead of filename
+conventions. It sets the name, package type, priority, explicit asset names and kinds, compression,
+and Unity version. Asset paths are relative to the directory argument, or to the manifest's own
+directory when no directory is given; the output is written next to the manifest in that case.
+-o, --compression, and --level override the manifest when given, and --check validates the
+manifest and every asset without writing anything.
+
 Examples:
   MeidoSerialization packAba ./my_folder
-  MeidoSerialization packAba ./my_folder -o output_name`,
-	Args: cobra.ExactArgs(1),
+  MeidoSerialization packAba ./my_folder -o output_name
+  MeidoSerialization packAba ./my_folder --compression lzma
+  MeidoSerialization packAba --manifest ./my_mod/mod.json
+  MeidoSerialization packAba --manifest ./my_mod/mod.json --check`,
+	Args: cobra.RangeArgs(0, 1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if packAbaManifestPath != "" {
+			return packAbaFromManifest(cmd, args)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("packAba needs a directory unless --manifest is given")
+		}
+		if packAbaCheckOnly {
+			return fmt.Errorf("--check requires --manifest")
+		}
+		compression, err := aba.ParseAbaCompression(packAbaCompressionFlag)
+		if err != nil {
+			return err
+		}
 		service := &KCESService.PackService{}
-		if err := service.PackToAbaAndCt(args[0], outputPathFlag); err != nil {
+		if err := service.PackToAbaAndCtWithCompression(args[0], outputPathFlag, compression, packAbaCompressionLevel); err != nil {
 			return err
 		}
 		fmt.Printf("Packed %s\n", args[0])
@@ -96,6 +137,93 @@ Examples:
 	},
 }
 
+var recompressAbaCmd = &cobra.Command{
+	Use:   "recompressAba [file]",
+	Short: "Rewrite a .aba file with a different compression",
+	Long: `Rewrite a .aba (Unity AssetBundle) file with a different compression without touching its contents.
+Every entry, including serialized files, is copied byte for byte in its original order; only the
+data blocks and the block/directory header are compressed again. The file is replaced in place
+unless -o names another output file.
+
+--compression selects none, lz4, lz4hc, or lzma (default lzma, as used by official KCES bundles),
+and --level sets the lz4hc or lzma level from 1 to 9.
+
+Examples:
+  MeidoSerialization recompressAba example.aba
+  MeidoSerialization recompressAba example.aba --compression lz4hc -o example_lz4hc.aba`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		compression, err := aba.ParseAbaCompression(recompressAbaCompressionFlag)
+		if err != nil {
+			return err
+		}
+		if compression == "" {
+			return fmt.Errorf("--compression must not be empty")
+		}
+		path := args[0]
+		before, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		outPath := outputPathFlag
+		if outPath == "" {
+			outPath = path
+		}
+		service := &KCESService.AbaService{}
+		if err := service.RecompressAba(path, outPath, compression, recompressAbaCompressionLevel); err != nil {
+			return err
+		}
+		after, err := os.Stat(outPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recompressed %s to %s with %s (%d -> %d bytes)\n", path, outPath, compression, before.Size(), after.Size())
+		return nil
+	},
+}
+
+var replaceAbaObjectCmd = &cobra.Command{
+	Use:   "replaceAbaObject [file] [object]",
+	Short: "Replace one object inside a .aba file without unpacking",
+	Long: `Replace the data of one object inside a .aba (Unity AssetBundle) file without unpacking it.
+The object is selected by --path-id or by its AssetBundle container name (--name). Only that
+object's table entry and the offsets of the objects after it change; every other object, all
+PathIDs, the .ct and the data-block compression are kept. The file is replaced in place unless
+-o names another output file.
+
+[object] is a standalone Unity object file as written by unpackAba or convert2texture2d, or a raw
+object payload encoded with the type tree the bundle uses for that object. A streamed Texture2D
+picks up its <object>.resS companion automatically; --stream appends any other payload to the
+bundle's .resS entry and points the object's m_StreamData at it.
+
+Examples:
+  MeidoSerialization replaceAbaObject example.aba new_body.tex --name body.tex
+  MeidoSerialization replaceAbaObject example.aba object.bin --path-id -4211336853148512145 -o patched.aba
+  MeidoSerialization replaceAbaObject example.aba texture.bin --path-id 2 --stream pixels.bin`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (replaceAbaPathID == 0) == (replaceAbaName == "") {
+			return fmt.Errorf("exactly one of --path-id and --name is required")
+		}
+		path := args[0]
+		outPath := outputPathFlag
+		if outPath == "" {
+			outPath = path
+		}
+		service := &KCESService.AbaService{}
+		result, err := service.ReplaceAbaObject(path, outPath, replaceAbaPathID, replaceAbaName, args[1], replaceAbaStreamPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Replaced PathID %d (ClassID %d) in %s/%s (%d -> %d bytes), wrote %s\n",
+			result.PathID, result.ClassID, path, result.Entry, result.OldSize, result.NewSize, outPath)
+		if result.StreamEntry != "" {
+			fmt.Printf("Stream data appended to %s at offset %d\n", result.StreamEntry, result.StreamOffset)
+		}
+		return nil
+	},
+}
+
 // listAbaFile 读取 ABA 资源条目并将其摘要打印到标准输出
 // listAbaFile reads ABA asset entries and prints their summary to standard output
 func listAbaFile(path string) error {
@@ -112,9 +240,58 @@ func listAbaFile(path string) error {
 	return nil
 }
 
-// init 注册 ABA 解包目录和打包基础名称参数
-// init registers the ABA unpack directory and pack base-name flags
+// packAbaFromManifest 读取 JSON 清单，应用命令行覆盖后校验或打包
+// packAbaFromManifest reads a JSON manifest, applies command-line overrides, and validates or packs it
+func packAbaFromManifest(cmd *cobra.Command, args []string) error {
+	manifest, err := KCESService.ReadModManifest(packAbaManifestPath)
+	if err != nil {
+		return err
+	}
+	if outputPathFlag != "" {
+		manifest.Name = outputPathFlag
+	}
+	if cmd.Flags().Changed("compression") {
+		manifest.Compression = packAbaCompressionFlag
+	}
+	if cmd.Flags().Changed("level") {
+		manifest.CompressionLevel = int32(packAbaCompressionLevel)
+	}
+	baseDir := filepath.Dir(packAbaManifestPath)
+	outputDir := baseDir
+	if len(args) == 1 {
+		baseDir = args[0]
+		outputDir = filepath.Dir(args[0])
+	}
+
+	service := &KCESService.PackService{}
+	if packAbaCheckOnly {
+		if err := service.ValidateModManifest(manifest, baseDir); err != nil {
+			return err
+		}
+		fmt.Printf("Manifest %s is valid (%d assets)\n", packAbaManifestPath, len(manifest.Assets))
+		return nil
+	}
+	if err := service.PackModManifest(manifest, baseDir, outputDir); err != nil {
+		return err
+	}
+	fmt.Printf("Packed %s into %s\n", packAbaManifestPath, filepath.Join(outputDir, manifest.Name+".aba"))
+	return nil
+}
+
+// init 注册 ABA 解包目录、打包基础名称、压缩、清单和对象替换参数
+// init registers the ABA unpack directory, pack base-name, compression, manifest, and object-replacement flags
 func init() {
 	unpackAbaCmd.Flags().StringVarP(&outputPathFlag, "output", "o", "", "Output directory path")
 	packAbaCmd.Flags().StringVarP(&outputPathFlag, "output", "o", "", "Output base name")
+	packAbaCmd.Flags().StringVar(&packAbaCompressionFlag, "compression", "lz4", "Data-block compression: none, lz4, lz4hc, or lzma")
+	packAbaCmd.Flags().IntVar(&packAbaCompressionLevel, "level", 0, "lz4hc or lzma level from 1 to 9 (0 uses the default)")
+	packAbaCmd.Flags().StringVar(&packAbaManifestPath, "manifest", "", "JSON mod manifest to pack instead of inferring assets from file names")
+	packAbaCmd.Flags().BoolVar(&packAbaCheckOnly, "check", false, "With --manifest, validate the manifest and its assets without writing output")
+	recompressAbaCmd.Flags().StringVarP(&outputPathFlag, "output", "o", "", "Output .aba file path (default: replace the input)")
+	recompressAbaCmd.Flags().StringVar(&recompressAbaCompressionFlag, "compression", "lzma", "Data-block compression: none, lz4, lz4hc, or lzma")
+	recompressAbaCmd.Flags().IntVar(&recompressAbaCompressionLevel, "level", 0, "lz4hc or lzma level from 1 to 9 (0 uses the default)")
+	replaceAbaObjectCmd.Flags().StringVarP(&outputPathFlag, "output", "o", "", "Output .aba file path (default: replace the input)")
+	replaceAbaObjectCmd.Flags().Int64Var(&replaceAbaPathID, "path-id", 0, "PathID of the object to replace")
+	replaceAbaObjectCmd.Flags().StringVar(&replaceAbaName, "name", "", "AssetBundle container name of the object to replace")
+	replaceAbaObjectCmd.Flags().StringVar(&replaceAbaStreamPath, "stream", "", "File whose content becomes the object's .resS stream data")
 }
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/ct"
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	"github.com/spf13/cobra"
+)
+
+var conflictCtJSON bool
+
+// conflictCtCmd represents the conflictCt command
+var conflictCtCmd = &cobra.Command{
+	Use:   "conflictCt [file/directory...]",
+	Short: "Report resources provided by more than one KCES mod catalog",
+	Long: `Read the AssetBundleCatalog of every .ct file and group the catalog items by resource hash.
+Directories are searched recursively, so a whole mod folder can be checked at once.
+
+A conflict is a resource provided by more than one catalog. The expected winner is the catalog with
+the highest priority; at equal priority a patch package type (PluginPatch, BasePatch, ExtraPatch)
+wins over the other package types. When the winner ties the runner-up the conflict is marked
+ambiguous, because the result then depends on the order in which the game loads the catalogs.
+
+A hash collision is two different resource names with the same FNV-1a hash. The game looks resources
+up by hash, so only one of them can be found.
+
+.ct files that cannot be read are listed as errors and do not stop the analysis.
+
+Examples:
+  MeidoSerialization conflictCt ./mods
+  MeidoSerialization conflictCt outfit.ct ./mods --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return conflictCts(args, conflictCtJSON)
+	},
+}
+
+// conflictCts 分析 catalog 冲突并按参数输出摘要或 JSON
+// conflictCts analyzes catalog conflicts and prints a summary or JSON
+func conflictCts(paths []string, asJSON bool) error {
+	service := &KCESService.CtService{}
+	report, err := service.CatalogConflicts(paths)
+	if err != nil {
+		return fmt.Errorf("failed to analyze catalogs: %w", err)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printCatalogConflicts(report)
+	return nil
+}
+
+// printCatalogConflicts 打印冲突报告的文本摘要 / printCatalogConflicts prints a text summary of the conflict report
+func printCatalogConflicts(report *ct.CatalogConflictReport) {
+	for _, conflict := range report.Conflicts {
+		status := "wins"
+		if conflict.Ambiguous {
+			status = "ambiguous"
+		}
+		fmt.Printf("%s (hash %d)\n", conflict.Name, conflict.Hash)
+		for i, provider := range conflict.Providers {
+			marker := "   "
+			if i == 0 {
+				marker = " * "
+			}
+			fmt.Printf(" %s%s [%s, priority %d]", marker, provider.Source, provider.PackageType, provider.Priority)
+			if provider.Resource != "" {
+				fmt.Printf(" %s", provider.Resource)
+			}
+			if i == 0 {
+				fmt.Printf(" (%s)", status)
+			}
+			fmt.Println()
+		}
+	}
+	if len(report.HashCollisions) > 0 {
+		fmt.Println("Hash collisions:")
+		for _, collision := range report.HashCollisions {
+			fmt.Printf("  %d:", collision.Hash)
+			for _, name := range collision.Names {
+				fmt.Printf(" %s", name)
+			}
+			fmt.Println()
+			for _, provider := range collision.Providers {
+				fmt.Printf("    %s %s\n", provider.Source, provider.Name)
+			}
+		}
+	}
+	if len(report.Errors) > 0 {
+		fmt.Println("Errors:")
+		for _, loadError := range report.Errors {
+			fmt.Printf("  %s: %s\n", loadError.Source, loadError.Message)
+		}
+	}
+	fmt.Printf("%d catalogs, %d conflicts, %d hash collisions, %d errors\n",
+		len(report.Catalogs), len(report.Conflicts), len(report.HashCollisions), len(report.Errors))
+}
+
+// init 注册冲突分析命令的输出参数
+// init registers the output flag for the conflict analysis command
+func init() {
+	conflictCtCmd.Flags().BoolVar(&conflictCtJSON, "json", false, "Print the full report as JSON")
+}
@@ -8,11 +8,13 @@ import (
 	"sync/atomic"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/application"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
 	"github.com/spf13/cobra"
 )
 
 var gltfOutputFormat string
+var gltfRestPoseModel string
 
 // meshOnlyGLTFNoticeLines 说明直接导出 .mmesh 只得到几何体，完整模型应转换引用它的 .model
 // meshOnlyGLTFNoticeLines explains that exporting a .mmesh directly yields geometry alone and that a complete model comes from the .model referencing it
@@ -23,9 +25,16 @@ var meshOnlyGLTFNoticeLines = []string{
 
 var convert2gltfCmd = &cobra.Command{
 	Use:   "convert2gltf [file/directory]",
-	Short: "Export KCES Model and native Mesh or AnimationClip files to glTF",
-	Long: `Export KCES .model files or standalone Mesh and AnimationClip primary files to glTF 2.0.
-A .model input also loads the .mmesh referenced by meshFileName and produces a complete skinned
+	Short: "Export COM3D2 Model and animation or KCES Model and native Mesh or AnimationClip files to glTF",
+	Long: `Export COM3D2 .model and .anm files, KCES .model files, or standalone Mesh and AnimationClip primary files to glTF 2.0.
+A COM3D2 .model (CM3D2_MESH) becomes a skinned glTF with every bone, the bind poses, bone weights,
+one primitive per submesh, tangents, morph targets, and materials; the remaining fields are kept
+in com3d2Model extras so gltf2model restores a byte-identical .model.
+A COM3D2 .anm (CM3D2_ANIM) becomes a CUBICSPLINE animation whose tangents are the Hermite keyframe
+tangents, targeting nodes named after the bone path such as Bip01/Bip01 Spine. Pass --model with a
+COM3D2 .model to export its skeleton and mesh as the rest pose and animate its matching bones.
+The com3d2Anm animation extras keep the bust-animation switches so gltf2anm restores a byte-identical .anm.
+A KCES .model input also loads the .mmesh referenced by meshFileName and produces a complete skinned
 glTF with the skeleton, bone weights, morph targets, material names, and KCES extras for gltf2model.
 Convert the .model rather than the .mmesh it references: a .mmesh stores geometry alone, so exporting
 one directly gives a glTF without the skeleton, bone weights, morph targets, material names, or
@@ -47,7 +56,7 @@ Animation export supports explicit Rotation, Position, Scale, and Euler curves w
 		if isDirectory(path) {
 			fmt.Printf("Processing directory: %s\n", path)
 			err = processDirectoryConcurrent(path, processor, func(candidate string) bool {
-				return fileTypeFilter(candidate) && (KCESService.IsKCESModelFile(candidate) || KCESService.IsKCESNativeMeshFile(candidate) || KCESService.IsKCESNativeAnimationClipFile(candidate))
+				return fileTypeFilter(candidate) && (isCOM3D2BinaryModelFile(candidate) || isCOM3D2BinaryAnmFile(candidate) || KCESService.IsKCESModelFile(candidate) || KCESService.IsKCESNativeMeshFile(candidate) || KCESService.IsKCESNativeAnimationClipFile(candidate))
 			})
 		} else {
 			err = processFile(path, processor)
@@ -69,6 +78,10 @@ func convertNativeUnityToGLTF(path string, format string) (bool, error) {
 	var err error
 	meshOnly := false
 	switch {
+	case isCOM3D2BinaryModelFile(path):
+		err = (&COM3D2Service.ModelService{}).ConvertModelToGLTF(context.Background(), path, outputPath, format, application.DefaultMaxOutputBytes)
+	case isCOM3D2BinaryAnmFile(path):
+		err = (&COM3D2Service.AnmService{}).ConvertAnmToGLTF(context.Background(), path, outputPath, format, gltfRestPoseModel, application.DefaultMaxOutputBytes)
 	case KCESService.IsKCESModelFile(path):
 		err = (&KCESService.ModelService{}).ConvertModelToGLTF(context.Background(), path, outputPath, format, application.DefaultMaxOutputBytes)
 	case KCESService.IsKCESNativeMeshFile(path):
@@ -77,7 +90,7 @@ func convertNativeUnityToGLTF(path string, format string) (bool, error) {
 	case KCESService.IsKCESNativeAnimationClipFile(path):
 		err = service.ConvertAnimationClipToGLTF(context.Background(), path, outputPath, format, application.DefaultMaxOutputBytes)
 	default:
-		return false, fmt.Errorf("not a KCES Model or native Mesh or AnimationClip file: %s", path)
+		return false, fmt.Errorf("not a COM3D2 Model or animation, or a KCES Model or native Mesh or AnimationClip file: %s", path)
 	}
 	if err != nil {
 		return false, err
@@ -86,6 +99,20 @@ func convertNativeUnityToGLTF(path string, format string) (bool, error) {
 	return meshOnly, nil
 }
 
+// isCOM3D2BinaryModelFile 通过 CM3D2_MESH 签名识别二进制 COM3D2 .model
+// isCOM3D2BinaryModelFile recognizes a binary COM3D2 .model through its CM3D2_MESH signature
+func isCOM3D2BinaryModelFile(path string) bool {
+	info, matched, err := (&COM3D2Service.CommonService{}).TryFileTypeDetermine(path)
+	return err == nil && matched && info.FileType == "model" && info.StorageFormat == COM3D2Service.FormatBinary
+}
+
+// isCOM3D2BinaryAnmFile 通过 CM3D2_ANIM 签名识别二进制 COM3D2 .anm
+// isCOM3D2BinaryAnmFile recognizes a binary COM3D2 .anm through its CM3D2_ANIM signature
+func isCOM3D2BinaryAnmFile(path string) bool {
+	info, matched, err := (&COM3D2Service.CommonService{}).TryFileTypeDetermine(path)
+	return err == nil && matched && info.FileType == "anm" && info.StorageFormat == COM3D2Service.FormatBinary
+}
+
 // printMeshOnlyGLTFNotice 在导出过独立 Mesh 后打印一次改用 .model 的提示，count 为零时不打印
 // printMeshOnlyGLTFNotice prints the guidance to use the .model input once after standalone Mesh exports and stays silent when count is zero
 func printMeshOnlyGLTFNotice(count int64) {
@@ -98,8 +125,9 @@ func printMeshOnlyGLTFNotice(count int64) {
 	}
 }
 
-// init 注册 glTF 输出格式参数
-// init registers the glTF output format flag
+// init 注册 glTF 输出格式和静止姿势模型参数
+// init registers the glTF output format and rest-pose model flags
 func init() {
 	convert2gltfCmd.Flags().StringVarP(&gltfOutputFormat, "format", "f", "glb", "Output format: glb or gltf")
+	convert2gltfCmd.Flags().StringVar(&gltfRestPoseModel, "model", "", "COM3D2 .model whose skeleton and mesh are exported as the rest pose of .anm inputs")
 }
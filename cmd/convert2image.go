@@ -8,7 +8,8 @@ import (
 )
 
 var (
-	outputFormat string
+	outputFormat  string
+	halfFloatBC6H bool
 )
 
 // convert2imageCmd represents the convert2image command
@@ -20,8 +21,13 @@ This command can process a single file or all files in a directory.
 
 Default output format is .png
 
-PNG output is upright. DDS output passes the Unity block payload through unchanged, so it keeps
-Unity's bottom-up row order and appears vertically flipped in DirectX tools.
+PNG output is upright and decoded natively for every supported Texture2D format, including
+DXT1/DXT5/BC4/BC5/BC6H/BC7, so ImageMagick is not required. BC5 normal maps get a reconstructed
+blue channel and BC6H HDR textures are tone-mapped to sRGB.
+
+DDS output passes the Unity block payload through unchanged, so it keeps Unity's bottom-up row
+order and appears vertically flipped in DirectX tools. Add --half-float to decode BC6H textures
+into an upright R16G16B16A16_FLOAT DDS that keeps the full HDR range.
 
 Use convert2texture2d to convert an edited PNG or JPEG back to a native KCES Texture2D,
 and convert2tex to convert an image to a COM3D2 .tex file.
@@ -29,6 +35,7 @@ and convert2tex to convert an image to a COM3D2 .tex file.
 Examples:
   MeidoSerialization convert2image example.tex
   MeidoSerialization convert2image example.tex --format jpg
+  MeidoSerialization convert2image skybox.tex --format dds --half-float
   MeidoSerialization convert2image ./textures_directory
   MeidoSerialization convert2image ./textures_directory --format webp`,
 	Args: cobra.ExactArgs(1),
@@ -36,7 +43,7 @@ Examples:
 		path := args[0]
 
 		processor := func(filePath string) error {
-			return convertToImage(filePath, outputFormat)
+			return convertToImageWithOptions(filePath, KCESService.Texture2DImageOptions{Format: outputFormat, HalfFloatBC6H: halfFloatBC6H})
 		}
 
 		if isDirectory(path) {
@@ -50,8 +57,9 @@ Examples:
 	},
 }
 
-// init 注册图像输出格式参数
-// init registers the image output format flag
+// init 注册图像输出格式和 BC6H 半精度导出参数
+// init registers the image output format and BC6H half-float export flags
 func init() {
 	convert2imageCmd.Flags().StringVarP(&outputFormat, "format", "f", "png", "Output image format (png or dds for native Texture2D; png for native Sprite)")
+	convert2imageCmd.Flags().BoolVar(&halfFloatBC6H, "half-float", false, "Export native BC6H Texture2D as upright half-float DDS instead of raw blocks (with --format dds)")
 }
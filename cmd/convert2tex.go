@@ -3,12 +3,16 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 	"github.com/spf13/cobra"
 )
 
 var (
-	compressTex bool
-	forcePng    bool
+	compressTex  bool
+	forcePng     bool
+	texQuality   string
+	texUseMagick bool
 )
 
 // convert2texCmd represents the convert2tex command
@@ -20,9 +24,14 @@ This command can process a single file or all files in a directory.
 
 Use --compress for DXT compression.
 
+PNG, JPEG, GIF, and DXT1/DXT5 DDS input is handled by the built-in codec, so ImageMagick
+is only needed for other input formats or when --magick is given.
+--quality selects the DXT encoder effort: fast, normal, or best.
+
 Examples:
   MeidoSerialization convert2tex example.png
   MeidoSerialization convert2tex example.jpg --compress
+  MeidoSerialization convert2tex example.png --compress --quality best
   MeidoSerialization convert2tex example.png --forcePng false
   MeidoSerialization convert2tex ./images_directory
   MeidoSerialization convert2tex ./images_directory --compress --forcePng false`,
@@ -30,8 +39,19 @@ Examples:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
 
+		quality, err := bcn.ParseQuality(texQuality)
+		if err != nil {
+			return err
+		}
+		opts := COM3D2.TexEncodeOptions{
+			Compress:       compressTex,
+			ForcePNG:       forcePng,
+			Quality:        quality,
+			UseImageMagick: texUseMagick,
+		}
+
 		processor := func(filePath string) error {
-			return convertToTex(filePath, compressTex, forcePng)
+			return convertToTexWithOptions(filePath, opts)
 		}
 
 		if isDirectory(path) {
@@ -51,4 +71,6 @@ func init() {
 	// Add command-specific flags here
 	convert2texCmd.Flags().BoolVarP(&compressTex, "compress", "c", false, "Enable DXT compression for .tex files")
 	convert2texCmd.Flags().BoolVarP(&forcePng, "forcePng", "f", true, "Force use of png (lossless) for data in .tex")
+	convert2texCmd.Flags().StringVarP(&texQuality, "quality", "q", "normal", "DXT encoder quality: fast, normal, or best")
+	convert2texCmd.Flags().BoolVar(&texUseMagick, "magick", false, "Use ImageMagick instead of the built-in codec")
 }
@@ -11,27 +11,56 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	texture2DFormat    string
+	texture2DMipMaps   bool
+	texture2DMipFilter string
+	texture2DLinear    bool
+	texture2DQuality   string
+	texture2DStream    bool
+)
+
 var convert2texture2dCmd = &cobra.Command{
 	Use:   "convert2texture2d [file/directory]",
 	Short: "Convert image files to native KCES Texture2D",
 	Long: `Convert PNG or JPEG images back to native KCES Texture2D primary files.
-The texture is rebuilt from scratch as inline single-mip RGBA32 data.
+By default the texture is rebuilt from scratch as inline single-mip RGBA32 data.
 When a native Texture2D file with the same base name (.tex or .texture2d) exists
 in the same directory, it is overwritten so packAba picks up the change.
 This command can process a single file or all matching images in a directory.
 
+Encoding options:
+  --format      RGBA32 (default), RGB24, Alpha8, DXT1, DXT5, BC4, BC5, or BC7
+  --mipmaps     generate a full mip chain so distant textures do not shimmer
+  --mip-filter  box (default), triangle, or kaiser
+  --linear      store linear data (normal maps, masks) instead of sRGB color
+  --quality     block-compression effort: fast, normal, or best
+  --stream      write the image data to a <output>.resS companion; packAba moves it
+                into the bundle's .resS entry like the game does for large textures
+
 Typical texture editing workflow:
   unpackAba mod.aba -> convert2image Texture2D\foo.tex -> edit foo.png ->
   convert2texture2d foo.png -> packAba
 
 Examples:
   MeidoSerialization convert2texture2d example.png
+  MeidoSerialization convert2texture2d example.png --format BC7 --mipmaps
+  MeidoSerialization convert2texture2d normal.png --format BC5 --linear --mipmaps --mip-filter kaiser
+  MeidoSerialization convert2texture2d body.png --format DXT5 --mipmaps --stream
   MeidoSerialization convert2texture2d ./mod_unpacked/Texture2D`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
+		opts := KCESService.Texture2DEncodeOptions{
+			Format:    texture2DFormat,
+			MipMaps:   texture2DMipMaps,
+			MipFilter: texture2DMipFilter,
+			Linear:    texture2DLinear,
+			Quality:   texture2DQuality,
+			Stream:    texture2DStream,
+		}
 		processor := func(filePath string) error {
-			return convertImageToNativeTexture2D(filePath)
+			return convertImageToNativeTexture2DWithOptions(filePath, opts)
 		}
 		if isDirectory(path) {
 			fmt.Printf("Processing directory: %s\n", path)
@@ -43,15 +72,26 @@ Examples:
 	},
 }
 
-// convertImageToNativeTexture2D 将 PNG 或 JPEG 图像重建为 KCES 原生 Texture2D 独立对象文件
-// convertImageToNativeTexture2D rebuilds a PNG or JPEG image into a native KCES Texture2D standalone object file
-func convertImageToNativeTexture2D(path string) error {
+// init 注册 Texture2D 格式、mip 链、色彩空间和流式输出参数
+// init registers the Texture2D format, mip chain, color space, and streamed output flags
+func init() {
+	convert2texture2dCmd.Flags().StringVar(&texture2DFormat, "format", "RGBA32", "Target TextureFormat: RGBA32, RGB24, Alpha8, DXT1, DXT5, BC4, BC5, or BC7")
+	convert2texture2dCmd.Flags().BoolVar(&texture2DMipMaps, "mipmaps", false, "Generate a full mip chain")
+	convert2texture2dCmd.Flags().StringVar(&texture2DMipFilter, "mip-filter", "box", "Mip downsampling filter: box, triangle, or kaiser")
+	convert2texture2dCmd.Flags().BoolVar(&texture2DLinear, "linear", false, "Store linear data such as normal maps or masks instead of sRGB color")
+	convert2texture2dCmd.Flags().StringVar(&texture2DQuality, "quality", "normal", "Block-compression quality: fast, normal, or best")
+	convert2texture2dCmd.Flags().BoolVar(&texture2DStream, "stream", false, "Write image data to a .resS companion that packAba streams out of line")
+}
+
+// convertImageToNativeTexture2DWithOptions 按编码选项将 PNG 或 JPEG 图像重建为 KCES 原生 Texture2D 独立对象文件
+// convertImageToNativeTexture2DWithOptions rebuilds a PNG or JPEG image into a native KCES Texture2D standalone object file using encoding options
+func convertImageToNativeTexture2DWithOptions(path string, opts KCESService.Texture2DEncodeOptions) error {
 	if !isRGBA32DecodableImageFile(path) {
 		return fmt.Errorf("not a PNG or JPEG image file: %s", path)
 	}
 	outputPath := nativeTexture2DOutputPath(path)
 	service := &KCESService.NativeUnityMediaService{}
-	if err := service.ConvertImageToTexture2D(context.Background(), path, outputPath, application.DefaultMaxOutputBytes); err != nil {
+	if err := service.ConvertImageToTexture2DWithOptions(context.Background(), path, outputPath, opts, application.DefaultMaxOutputBytes); err != nil {
 		return fmt.Errorf("failed to convert %s to native Texture2D: %w", path, err)
 	}
 	fmt.Printf("Converted %s to %s\n", path, outputPath)
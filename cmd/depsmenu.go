@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	depsMenuSources []string
+	depsMenuJSON    bool
+)
+
+// depsMenuCmd represents the depsMenu command
+var depsMenuCmd = &cobra.Command{
+	Use:   "depsMenu <menu>",
+	Short: "List every file the game loads for a .menu across .arc files and mod folders",
+	Long: `Resolve the dependency closure of a .menu: models from additem, materials from マテリアル変更, textures from
+tex, テクスチャ合成 and icon, animations from anime and menus from アイテム, then the textures of every model and
+.mate material, the .pmat named after each material, the .phy and .psk named after each model and the .col
+referenced by each .phy.
+
+Files are looked up the way the game does: by case-insensitive bare file name, with later --source values
+overriding earlier ones. <menu> is read from disk when it is an existing file and otherwise looked up in the
+sources. .pmat, .phy and .psk files are optional and only listed when found; every other unresolved
+reference is reported as missing. Files that are found but cannot be parsed are listed as errors.
+
+Examples:
+  MeidoSerialization depsMenu Mod/dress/dress.menu --source GameData/parts.arc --source Mod
+  MeidoSerialization depsMenu dress.menu --source GameData/parts.arc --source GameData/parts_2.arc --source Mod --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(depsMenuSources) == 0 {
+			return fmt.Errorf("at least one --source must be provided")
+		}
+		return depsMenu(args[0], depsMenuSources, depsMenuJSON)
+	},
+}
+
+// depsMenu 解析菜单依赖闭包并按参数输出摘要或 JSON
+// depsMenu resolves the menu dependency closure and prints a summary or JSON
+func depsMenu(menu string, sources []string, asJSON bool) error {
+	service := &COM3D2Service.MenuService{}
+	closure, err := service.ResolveDependencies(context.Background(), menu, sources)
+	if err != nil {
+		return fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(closure, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printDependencyClosure(closure)
+	return nil
+}
+
+// printDependencyClosure 打印依赖闭包的文本摘要 / printDependencyClosure prints a text summary of the dependency closure
+func printDependencyClosure(closure *COM3D2.DependencyClosure) {
+	for _, file := range closure.Files {
+		fmt.Printf("%-9s %s  (%s:%s)\n", file.Kind, file.Name, file.Source, file.Path)
+	}
+	if len(closure.Missing) > 0 {
+		fmt.Println("Missing:")
+		for _, ref := range closure.Missing {
+			fmt.Printf("  %s  (%s, %s)\n", ref.Name, ref.From, ref.Via)
+		}
+	}
+	if len(closure.Errors) > 0 {
+		fmt.Println("Errors:")
+		for _, dependencyError := range closure.Errors {
+			fmt.Printf("  %s: %s\n", dependencyError.Name, dependencyError.Message)
+		}
+	}
+	fmt.Printf("%d files, %d missing, %d errors\n", len(closure.Files), len(closure.Missing), len(closure.Errors))
+}
+
+// init 注册菜单依赖命令的来源和输出参数
+// init registers the source and output flags for the menu dependency command
+func init() {
+	depsMenuCmd.Flags().StringArrayVar(&depsMenuSources, "source", nil, ".arc file or directory to mount; later sources override earlier ones (repeatable)")
+	depsMenuCmd.Flags().BoolVar(&depsMenuJSON, "json", false, "Print the full report as JSON")
+}
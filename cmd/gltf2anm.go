@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/application"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	"github.com/spf13/cobra"
+)
+
+var gltf2anmOutputDir string
+var gltf2anmGame string
+var gltf2anmAnimation string
+var gltf2anmSampleRate float32
+var gltf2anmLoop bool
+var gltf2anmEuler bool
+
+var gltf2anmCmd = &cobra.Command{
+	Use:   "gltf2anm [file/directory]",
+	Short: "Convert a glTF or GLB animation to a COM3D2 .anm or a KCES AnimationClip .anm",
+	Long: `Convert the animation in a glTF 2.0 or GLB file into a COM3D2 .anm, or into a KCES native
+AnimationClip .anm that packAba can ship. The target game is detected from the extras written by
+convert2gltf: com3d2Anm in the animation extras selects COM3D2 and anything else selects KCES.
+Use --game COM3D2 or --game KCES to force it.
+
+COM3D2: the animation carrying com3d2Anm extras is used, otherwise the first animation. Rotation and
+translation channels become the localRotation and localPosition curves of the targeted node, whose
+bone path joins the node names from Bip01 downward, or from the scene root when there is no Bip01.
+Scale and morph-weight channels are ignored.
+CUBICSPLINE tangents become the keyframe tangents directly, LINEAR samplers take the slope of the
+adjacent segments, and STEP samplers become stepped keys with infinite tangents.
+The com3d2Anm extras written by convert2gltf restore the version, bust-animation switches, curve
+order, and keyframe times so an unedited file converts back byte-identically. Without them the
+file is written as version 1001 with both bust-animation switches off.
+
+KCES: the animation named by --animation is used, otherwise the first animation. The output is a
+legacy AnimationClip with an embedded Unity 2022.3 TypeTree. Translation, rotation, and scale
+channels become Transform curves whose path comes from the kcesTransformPath node extras written by
+convert2gltf, or joins the node names from the scene root. Tangents follow the same rules as
+COM3D2. Rotations are quaternion curves; --euler writes Unity ZXY Euler-angle curves instead, with
+segment-slope tangents. The clip length is the last keyframe time. The sample rate and wrap mode
+come from the kcesAnimationClip extras, defaulting to 60 fps and the default wrap mode; use
+--sample-rate and --loop to override them.
+Output files are written next to the input by default; use --output to select a directory.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		game := strings.ToUpper(strings.TrimSpace(gltf2anmGame))
+		if game != "" && game != COM3D2Service.GameCOM3D2 && game != COM3D2Service.GameKCES {
+			return fmt.Errorf("unsupported --game %q; use COM3D2 or KCES", gltf2anmGame)
+		}
+		if gltf2anmSampleRate < 0 {
+			return fmt.Errorf("--sample-rate must not be negative")
+		}
+		opts := KCESService.AnimationClipImportOptions{
+			Animation:  gltf2anmAnimation,
+			SampleRate: gltf2anmSampleRate,
+			Loop:       gltf2anmLoop,
+			Euler:      gltf2anmEuler,
+		}
+		processor := func(filePath string) error {
+			return convertGLTFToAnm(filePath, gltf2anmOutputDir, game, opts)
+		}
+		if isDirectory(path) {
+			fmt.Printf("Processing directory: %s\n", path)
+			return processDirectoryConcurrent(path, processor, func(candidate string) bool {
+				return fileTypeFilter(candidate) && KCESService.IsKCESGLTFFile(candidate)
+			})
+		}
+		return processFile(path, processor)
+	},
+}
+
+// convertGLTFToAnm 将一个 glTF 或 GLB 文件中的动画转换为 COM3D2 .anm 或 KCES AnimationClip .anm，game 为空时按 extras 自动选择
+// convertGLTFToAnm converts the animation in one glTF or GLB file into a COM3D2 .anm or a KCES AnimationClip .anm, choosing by extras when game is empty
+func convertGLTFToAnm(path string, outputDir string, game string, opts KCESService.AnimationClipImportOptions) error {
+	if !KCESService.IsKCESGLTFFile(path) {
+		return fmt.Errorf("not a glTF or GLB file: %s", path)
+	}
+	if outputDir == "" {
+		outputDir = filepath.Dir(path)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("create output directory %q: %w", outputDir, err)
+	}
+	outputPath := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".anm")
+	if game == COM3D2Service.GameCOM3D2 || (game == "" && COM3D2Service.IsCOM3D2AnmGLTFDocument(path)) {
+		if err := (&COM3D2Service.AnmService{}).ConvertGLTFToAnm(context.Background(), path, outputPath, application.DefaultMaxOutputBytes); err != nil {
+			return err
+		}
+		fmt.Printf("Converted %s to %s\n", path, outputPath)
+		return nil
+	}
+	if err := (&KCESService.NativeUnityMediaService{}).ConvertGLTFToAnimationClip(context.Background(), path, outputPath, opts, application.DefaultMaxOutputBytes); err != nil {
+		return err
+	}
+	fmt.Printf("Converted %s to KCES AnimationClip %s\n", path, outputPath)
+	return nil
+}
+
+// init 注册 glTF 转动画的输出目录、目标游戏和 KCES 剪辑设置参数
+// init registers the output directory, target game, and KCES clip setting flags for glTF-to-animation conversion
+func init() {
+	gltf2anmCmd.Flags().StringVarP(&gltf2anmOutputDir, "output", "o", "", "Output directory (defaults to the input directory)")
+	gltf2anmCmd.Flags().StringVar(&gltf2anmGame, "game", "", "Target game: COM3D2 or KCES (auto-detected from the glTF extras when empty)")
+	gltf2anmCmd.Flags().StringVar(&gltf2anmAnimation, "animation", "", "KCES: name of the glTF animation to convert (defaults to the first animation)")
+	gltf2anmCmd.Flags().Float32Var(&gltf2anmSampleRate, "sample-rate", 0, "KCES: AnimationClip sample rate in frames per second (defaults to the extras value or 60)")
+	gltf2anmCmd.Flags().BoolVar(&gltf2anmLoop, "loop", false, "KCES: write the Loop wrap mode")
+	gltf2anmCmd.Flags().BoolVar(&gltf2anmEuler, "euler", false, "KCES: write rotations as Unity ZXY Euler-angle curves")
+}
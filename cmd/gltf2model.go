@@ -3,21 +3,37 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/application"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
 	"github.com/spf13/cobra"
 )
 
 var gltf2modelOutputDir string
+var gltf2modelGame string
 
 var gltf2modelCmd = &cobra.Command{
 	Use:   "gltf2model [file/directory]",
-	Short: "Convert glTF or GLB files to KCES .model and .mmesh",
-	Long: `Convert glTF 2.0 or GLB files into KCES .model and .mmesh files.
+	Short: "Convert glTF or GLB files to a COM3D2 .model or KCES .model and .mmesh",
+	Long: `Convert glTF 2.0 or GLB files into a COM3D2 .model, or into KCES .model and .mmesh files.
+The target game is detected from the extras written by convert2gltf: com3d2Model in the scene
+extras selects COM3D2 and anything else selects KCES. Use --game COM3D2 or --game KCES to force it.
 The scene needs exactly one triangle mesh node; its skeleton, skin, morph targets, and material
-names become the Model data, and the geometry becomes an official Unity 2022.3 native Mesh.
+names become the Model data.
+
+COM3D2: each primitive becomes one submesh and glTF material slot i becomes Materials[i]. Material
+data comes from the com3d2Material extras; a material without them reuses a same-named material
+that has them, ignoring Blender's .001 suffixes. TEXCOORD_0 is UV and the following sets are the
+extended UV channels recorded in the extras, or UV2 to UV4 in order without them. Only the first
+four bone influences are kept. A file without com3d2Model extras is written as version 2001, or
+2101 when extended UV channels are present. Fields saved by convert2gltf are restored so an
+unedited file converts back byte-identically.
+
+KCES: the geometry becomes an official Unity 2022.3 native Mesh.
 An unskinned scene is bound rigidly to the mesh node with a synthesized single-bone skin.
 Material appearance is not converted: glTF PBR parameters and textures are ignored, and each
 material's name must match the KCES material the game should load for that sub-mesh.
@@ -30,8 +46,12 @@ Output files are written next to the input by default; use --output to select a
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
+		game := strings.ToUpper(strings.TrimSpace(gltf2modelGame))
+		if game != "" && game != COM3D2Service.GameCOM3D2 && game != COM3D2Service.GameKCES {
+			return fmt.Errorf("unsupported --game %q; use COM3D2 or KCES", gltf2modelGame)
+		}
 		processor := func(filePath string) error {
-			return convertGLTFToKCESModel(filePath, gltf2modelOutputDir)
+			return convertGLTFToModel(filePath, gltf2modelOutputDir, game)
 		}
 		if isDirectory(path) {
 			fmt.Printf("Processing directory: %s\n", path)
@@ -43,15 +63,26 @@ Output files are written next to the input by default; use --output to select a
 	},
 }
 
-// convertGLTFToKCESModel 将一个 glTF 或 GLB 文件转换为 .model 与 .mmesh
-// convertGLTFToKCESModel converts one glTF or GLB file into .model and .mmesh files
-func convertGLTFToKCESModel(path string, outputDir string) error {
+// convertGLTFToModel 将一个 glTF 或 GLB 文件转换为 COM3D2 .model 或 KCES .model 与 .mmesh，game 为空时按 extras 自动选择
+// convertGLTFToModel converts one glTF or GLB file into a COM3D2 .model or KCES .model and .mmesh files, choosing by extras when game is empty
+func convertGLTFToModel(path string, outputDir string, game string) error {
 	if !KCESService.IsKCESGLTFFile(path) {
 		return fmt.Errorf("not a glTF or GLB file: %s", path)
 	}
 	if outputDir == "" {
 		outputDir = filepath.Dir(path)
 	}
+	if game == COM3D2Service.GameCOM3D2 || (game == "" && COM3D2Service.IsCOM3D2ModelGLTFDocument(path)) {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("create output directory %q: %w", outputDir, err)
+		}
+		outputPath := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".model")
+		if err := (&COM3D2Service.ModelService{}).ConvertGLTFToModel(context.Background(), path, outputPath, application.DefaultMaxOutputBytes); err != nil {
+			return err
+		}
+		fmt.Printf("Converted %s to %s\n", path, outputPath)
+		return nil
+	}
 	if err := (&KCESService.ModelService{}).ConvertGLTFToModel(context.Background(), path, outputDir, application.DefaultMaxOutputBytes); err != nil {
 		return err
 	}
@@ -59,8 +90,9 @@ func convertGLTFToKCESModel(path string, outputDir string) error {
 	return nil
 }
 
-// init 注册 glTF 转模型的输出目录参数
-// init registers the output directory flag for glTF-to-model conversion
+// init 注册 glTF 转模型的输出目录和目标游戏参数
+// init registers the output directory and target game flags for glTF-to-model conversion
 func init() {
 	gltf2modelCmd.Flags().StringVarP(&gltf2modelOutputDir, "output", "o", "", "Output directory (defaults to the input directory)")
+	gltf2modelCmd.Flags().StringVar(&gltf2modelGame, "game", "", "Target game: COM3D2 or KCES (auto-detected from the glTF extras when empty)")
 }
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphAbaJSON bool
+	graphAbaDOT  string
+)
+
+// graphAbaCmd represents the graphAba command
+var graphAbaCmd = &cobra.Command{
+	Use:   "graphAba [file/directory...]",
+	Short: "Report the object dependency graph of Unity bundles",
+	Long: `Read every object of .aba, .asset_bg, and .asset_scene files and follow its PPtr references.
+Directories are searched recursively. References into other SerializedFiles are resolved against every
+bundle given on the command line, so a mod can be checked together with the bundles it depends on.
+
+The summary lists the CAB files each bundle depends on and which bundle provides them, references
+that cannot be resolved (dangling), and objects nothing refers to (unreferenced). References to Unity
+built-in resources are reported separately and are not dangling.
+
+Use --json to print the full graph, or --dot to also write a Graphviz DOT file.
+
+Examples:
+  MeidoSerialization graphAba mod.aba shared.aba
+  MeidoSerialization graphAba ./bundles --json
+  MeidoSerialization graphAba ./bundles --dot graph.dot`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return graphAbas(args, graphAbaJSON, graphAbaDOT)
+	},
+}
+
+// graphAbas 构建依赖图并按参数输出摘要、JSON 或 DOT
+// graphAbas builds the dependency graph and prints a summary or JSON, optionally writing DOT
+func graphAbas(paths []string, asJSON bool, dotPath string) error {
+	service := &KCESService.AbaService{}
+	graph, err := service.DependencyGraph(paths)
+	if err != nil {
+		return fmt.Errorf("failed to build dependency graph: %w", err)
+	}
+
+	if dotPath != "" {
+		f, err := os.Create(dotPath)
+		if err != nil {
+			return err
+		}
+		if err := graph.WriteDOT(f); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to write %s: %w", dotPath, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printDependencyGraph(graph)
+	if dotPath != "" {
+		fmt.Printf("DOT graph written to %s\n", dotPath)
+	}
+	return nil
+}
+
+// printDependencyGraph 打印依赖图的文本摘要 / printDependencyGraph prints a text summary of the dependency graph
+func printDependencyGraph(graph *aba.DependencyGraph) {
+	for _, bundle := range graph.Bundles {
+		fmt.Printf("%s\n", bundle.Name)
+		for _, dep := range bundle.Dependencies {
+			switch {
+			case dep.Builtin:
+				fmt.Printf("  -> %s (builtin)\n", dep.File)
+			case dep.Missing:
+				fmt.Printf("  -> %s (missing)\n", dep.File)
+			default:
+				fmt.Printf("  -> %s (%s)\n", dep.File, dep.Bundle)
+			}
+		}
+		for _, message := range bundle.Errors {
+			fmt.Printf("  error: %s\n", message)
+		}
+	}
+
+	if len(graph.Dangling) > 0 {
+		fmt.Println("Dangling references:")
+		for _, edge := range graph.Dangling {
+			target := edge.ToFile
+			if target == "" {
+				target = fmt.Sprintf("fileID %d", edge.FileID)
+			}
+			fmt.Printf("  %s %s:%d %s -> %s:%d (%s)\n", edge.FromBundle, edge.FromFile, edge.FromPathID, edge.Field, target, edge.ToPathID, edge.Status)
+		}
+	}
+	if len(graph.Unreferenced) > 0 {
+		fmt.Println("Unreferenced objects:")
+		for _, object := range graph.Unreferenced {
+			name := object.Name
+			if object.LoadName != "" {
+				name = object.LoadName
+			}
+			fmt.Printf("  %s %s:%d %s %s\n", object.Bundle, object.File, object.PathID, object.Type, name)
+		}
+	}
+	fmt.Printf("%d bundles, %d objects, %d references, %d dangling, %d unreferenced\n",
+		len(graph.Bundles), len(graph.Objects), len(graph.Edges), len(graph.Dangling), len(graph.Unreferenced))
+}
+
+// init 注册依赖图命令的输出参数
+// init registers the output flags for the dependency graph command
+func init() {
+	graphAbaCmd.Flags().BoolVar(&graphAbaJSON, "json", false, "Print the full graph as JSON")
+	graphAbaCmd.Flags().StringVar(&graphAbaDOT, "dot", "", "Also write the graph to this Graphviz DOT file")
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/application"
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	"github.com/spf13/cobra"
+)
+
+var model2kcesOutputDir string
+var model2kcesBoneMap string
+
+var model2kcesCmd = &cobra.Command{
+	Use:   "model2kces [file/directory]",
+	Short: "Port COM3D2 .model files to KCES .model and .mmesh files",
+	Long: `Port a COM3D2 .model (or .model.json) into a KCES .model and an official Unity 2022.3 native .mmesh
+for COM3D2.5 and CRC. The bone hierarchy, bind poses, skin weights, submeshes, UV to UV4, tangents, morphs,
+skin thickness, and material names carry over unchanged, since both games use Unity coordinates.
+
+--bone-map names a JSON object mapping COM3D2 bone names to KCES bone names, for example
+{"Bip01 Spine0a": "Bip01 Spine1"}. It renames the skeleton, the skin bones, the model name, and the
+skin-thickness bones alike.
+
+Anything KCES cannot represent is reported as a warning: material shaders and properties (only the names
+remain, so each must match an entry in the .materialassets container), the unknown vertex channels,
+shadow casting modes other than On and Off, a root bone that is not a hierarchy root, and bone map
+entries that matched no bone.
+Output file names are the lowercased input name. Files are written to a kces directory beside the input
+by default so they never replace the COM3D2 file; use --output to select a directory.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		boneNameMap, err := readBoneNameMap(model2kcesBoneMap)
+		if err != nil {
+			return err
+		}
+		processor := func(filePath string) error {
+			return portCOM3D2ModelToKCES(filePath, model2kcesOutputDir, boneNameMap)
+		}
+		if isDirectory(path) {
+			fmt.Printf("Processing directory: %s\n", path)
+			return processDirectoryConcurrent(path, processor, func(candidate string) bool {
+				lower := strings.ToLower(candidate)
+				return fileTypeFilter(candidate) && (strings.HasSuffix(lower, ".model") || strings.HasSuffix(lower, ".model.json"))
+			})
+		}
+		return processFile(path, processor)
+	},
+}
+
+// readBoneNameMap 读取旧骨骼名到新骨骼名的 JSON 映射表，路径为空时返回 nil
+// readBoneNameMap reads a JSON table mapping old bone names to new ones, returning nil for an empty path
+func readBoneNameMap(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read bone map %q: %w", path, err)
+	}
+	var boneNameMap map[string]string
+	if err := json.Unmarshal(data, &boneNameMap); err != nil {
+		return nil, fmt.Errorf("parse bone map %q: expected a JSON object of bone names: %w", path, err)
+	}
+	return boneNameMap, nil
+}
+
+// portCOM3D2ModelToKCES 将一个 COM3D2 模型移植为 KCES .model 与 .mmesh，并把无法表达的内容打印为警告
+// portCOM3D2ModelToKCES ports one COM3D2 model into KCES .model and .mmesh files and prints what cannot be represented as warnings
+func portCOM3D2ModelToKCES(path string, outputDir string, boneNameMap map[string]string) error {
+	if outputDir == "" {
+		outputDir = filepath.Join(filepath.Dir(path), "kces")
+	}
+	report, err := (&KCESService.ModelService{}).ConvertCOM3D2ModelToModel(context.Background(), path, outputDir, boneNameMap, application.DefaultMaxOutputBytes)
+	if err != nil {
+		return err
+	}
+	for _, item := range report.Unrepresentable {
+		fmt.Fprintf(os.Stderr, "warning: %s: %s\n", path, item)
+	}
+	fmt.Printf("Ported %s to KCES .model and .mmesh in %s\n", path, outputDir)
+	return nil
+}
+
+// init 注册模型移植的输出目录和骨骼名映射参数
+// init registers the output directory and bone-name map flags for model porting
+func init() {
+	model2kcesCmd.Flags().StringVarP(&model2kcesOutputDir, "output", "o", "", "Output directory (defaults to a kces directory beside the input)")
+	model2kcesCmd.Flags().StringVar(&model2kcesBoneMap, "bone-map", "", "JSON file mapping COM3D2 bone names to KCES bone names")
+}
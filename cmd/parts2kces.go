@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/application"
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	"github.com/spf13/cobra"
+)
+
+var parts2kcesOutputDir string
+var parts2kcesName string
+var parts2kcesPortMap string
+
+var parts2kcesCmd = &cobra.Command{
+	Use:   "parts2kces [file/directory]...",
+	Short: "Port COM3D2 .menu, .mate and .pmat files to KCES .menuassets, .materialassets and .pmatassets",
+	Long: `Port COM3D2 .menu, .mate and .pmat files (or their .json forms) into KCES asset containers for COM3D2.5
+and CRC. Directories are searched recursively and every input goes into one container per kind, named
+<name>.menuassets, <name>.materialassets and <name>.pmatassets. Entry file names are the lowercased input
+names, and the menu and material IDs are recalculated from them; the .pmat ID and target material ID are
+computed the same way.
+
+Menu name, setumei, category, color_set, icon and priority fill the matching KCES fields. Other menu
+commands, material properties and shader keywords need a KCES enum value, which --map supplies as a JSON file
+of the form {"commands": {"<keyword>": <type>}, "properties": {"<name>": <type>}, "keywords": {"<keyword>": <type>}}.
+Without --map only _MainTex, _BumpMap, _Color, _ShadowColor, _Shininess and _OutlineWidth are known.
+
+Anything without a KCES equivalent is reported as a warning and dropped: unmapped commands, properties and
+keywords, render textures, and fractional priorities (which are rounded). Textures keep their COM3D2 names
+and must be converted separately. By default files go to a kces folder inside the first input directory, or
+beside the first input file; use --output to select a directory.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		portMap, err := readPartsPortMap(parts2kcesPortMap)
+		if err != nil {
+			return err
+		}
+		var inputs []string
+		for _, path := range args {
+			if !isDirectory(path) {
+				inputs = append(inputs, path)
+				continue
+			}
+			err := filepath.Walk(path, func(candidate string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() && fileTypeFilter(candidate) && isCOM3D2PartsPath(candidate) {
+					inputs = append(inputs, candidate)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return portCOM3D2PartsToKCES(args[0], inputs, parts2kcesOutputDir, parts2kcesName, portMap)
+	},
+}
+
+// isCOM3D2PartsPath 判断路径是否为可移植的 COM3D2 .menu、.mate、.pmat 或其 .json
+// isCOM3D2PartsPath reports whether a path is a portable COM3D2 .menu, .mate, or .pmat file or its .json form
+func isCOM3D2PartsPath(path string) bool {
+	lower := strings.TrimSuffix(strings.ToLower(path), ".json")
+	return strings.HasSuffix(lower, ".menu") || strings.HasSuffix(lower, ".mate") || strings.HasSuffix(lower, ".pmat")
+}
+
+// readPartsPortMap 读取 JSON 移植映射并合并到默认映射上，路径为空时返回默认映射
+// readPartsPortMap reads a JSON port map merged over the default map, returning the default map for an empty path
+func readPartsPortMap(path string) (*KCESService.PartsPortMap, error) {
+	portMap := KCESService.DefaultPartsPortMap()
+	if path == "" {
+		return portMap, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read port map %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, portMap); err != nil {
+		return nil, fmt.Errorf("parse port map %q: expected a JSON object of commands, properties and keywords: %w", path, err)
+	}
+	return portMap, nil
+}
+
+// portCOM3D2PartsToKCES 将 COM3D2 部件移植为 KCES 资源容器，并把无法表达的内容打印为警告
+// portCOM3D2PartsToKCES ports COM3D2 parts into KCES asset containers and prints what cannot be represented as warnings
+func portCOM3D2PartsToKCES(firstArg string, inputs []string, outputDir string, name string, portMap *KCESService.PartsPortMap) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no COM3D2 .menu, .mate, or .pmat files found in %s", firstArg)
+	}
+	base := firstArg
+	if !isDirectory(firstArg) {
+		base = filepath.Dir(firstArg)
+	}
+	if outputDir == "" {
+		outputDir = filepath.Join(base, "kces")
+	}
+	if name == "" {
+		name = strings.ToLower(filepath.Base(firstArg))
+		if !isDirectory(firstArg) {
+			name = strings.TrimSuffix(name, ".json")
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+	}
+	report, err := (&KCESService.PartsService{}).ConvertCOM3D2PartsToAssets(context.Background(), inputs, outputDir, name, portMap, application.DefaultMaxOutputBytes)
+	if err != nil {
+		return err
+	}
+	for _, item := range report.Unrepresentable {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", item)
+	}
+	for _, output := range report.Outputs {
+		fmt.Printf("Wrote %s\n", output)
+	}
+	fmt.Printf("Ported %d files to KCES asset containers in %s\n", len(inputs), outputDir)
+	return nil
+}
+
+// init 注册部件移植的输出目录、容器名和映射参数
+// init registers the output directory, container name, and port map flags for parts porting
+func init() {
+	parts2kcesCmd.Flags().StringVarP(&parts2kcesOutputDir, "output", "o", "", "Output directory (defaults to a kces folder in or beside the first input)")
+	parts2kcesCmd.Flags().StringVar(&parts2kcesName, "name", "", "Container base name (defaults to the lowercased name of the first input)")
+	parts2kcesCmd.Flags().StringVar(&parts2kcesPortMap, "map", "", "JSON file mapping command keywords, property names and shader keywords to KCES enum values")
+}
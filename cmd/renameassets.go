@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renameAssetsRenames []string
+	renameAssetsWrite   bool
+	renameAssetsJSON    bool
+)
+
+// renameAssetsCmd represents the renameAssets command
+var renameAssetsCmd = &cobra.Command{
+	Use:   "renameAssets <directory>",
+	Short: "Rename textures, models, materials and menus in a mod folder and rewrite every reference",
+	Long: `Rename assets in a mod folder and update every reference to them: .menu command arguments, .mate and .model
+material names and texture names, .pmat material names, .phy collider names and the menu file names selected
+by .preset properties. The .pmat hash and the .menu BodySize are recalculated.
+
+Each --rename takes old=new. Names with an extension rename files, which keep their directory, and the
+extension must not change; names without an extension rename materials. A material is renamed together with
+its .pmat and a .model together with its .phy and .psk unless another --rename says otherwise.
+
+Without --write nothing is changed and the planned renames and reference edits are printed as a diff. If any
+.menu, .mate, .model, .pmat, .phy or .preset file cannot be parsed, or a new name collides with an existing
+file, nothing is written. Preset RIDs are game-computed hashes and are not updated, so per-slot preset data
+for a renamed menu is ignored by the game.
+
+Examples:
+  MeidoSerialization renameAssets Mod/dress --rename dress_body.tex=gown_body.tex --rename dress.model=gown.model
+  MeidoSerialization renameAssets Mod/dress --rename dress_mat=gown_mat --write`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isDirectory(args[0]) {
+			return fmt.Errorf("%s is not a directory", args[0])
+		}
+		if len(renameAssetsRenames) == 0 {
+			return fmt.Errorf("at least one --rename must be provided")
+		}
+		renames := make(map[string]string, len(renameAssetsRenames))
+		for _, spec := range renameAssetsRenames {
+			oldName, newName, ok := strings.Cut(spec, "=")
+			if !ok {
+				return fmt.Errorf("invalid --rename %q; expected old=new", spec)
+			}
+			renames[oldName] = newName
+		}
+		return renameAssets(args[0], renames, renameAssetsWrite, renameAssetsJSON)
+	},
+}
+
+// renameAssets 生成重命名计划，按参数写入并输出差异或 JSON
+// renameAssets builds the rename plan, writes it when requested, and prints a diff or JSON
+func renameAssets(dir string, renames map[string]string, write bool, asJSON bool) error {
+	service := &COM3D2Service.RenameService{}
+	plan, err := service.RenameAssets(context.Background(), dir, renames, write)
+	if err != nil {
+		return fmt.Errorf("failed to rename assets: %w", err)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, file := range plan.Files {
+		newPath := file.NewPath
+		if newPath == "" {
+			newPath = file.Path
+		}
+		fmt.Printf("--- %s\n+++ %s\n", file.Path, newPath)
+		for _, change := range file.Changes {
+			fmt.Printf("@@ %s\n-%s\n+%s\n", change.Field, change.Old, change.New)
+		}
+	}
+	for _, name := range plan.Unmatched {
+		fmt.Printf("Warning: no file named %s in %s; only its references are rewritten\n", name, dir)
+	}
+	if plan.Applied {
+		fmt.Printf("Updated %d files\n", len(plan.Files))
+	} else {
+		fmt.Printf("%d files would change; rerun with --write to apply\n", len(plan.Files))
+	}
+	return nil
+}
+
+// init 注册资源重命名命令的映射、写入和输出参数
+// init registers the mapping, write, and output flags for the asset rename command
+func init() {
+	renameAssetsCmd.Flags().StringArrayVar(&renameAssetsRenames, "rename", nil, "Rename in old=new form, a file name with extension or a material name (repeatable)")
+	renameAssetsCmd.Flags().BoolVar(&renameAssetsWrite, "write", false, "Apply the changes instead of printing a dry-run diff")
+	renameAssetsCmd.Flags().BoolVar(&renameAssetsJSON, "json", false, "Print the plan as JSON")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resolveArcSources  []string
+	resolveArcShadowed bool
+)
+
+// resolveArcCmd represents the resolveArc command
+var resolveArcCmd = &cobra.Command{
+	Use:   "resolveArc [name...]",
+	Short: "Show which .arc file or mod folder provides a file name",
+	Long: `Layer .arc files and loose directories such as the Mod folder, then resolve file names the way the game
+does: by case-insensitive bare file name, with later --source values overriding earlier ones.
+For each name the winning source and path are printed, followed by the entries it shadows.
+--shadowed lists every file name that more than one entry provides.
+
+Examples:
+  MeidoSerialization resolveArc body001.tex --source GameData/parts.arc --source Mod
+  MeidoSerialization resolveArc --shadowed --source GameData/parts.arc --source GameData/parts_2.arc --source Mod`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(resolveArcSources) == 0 {
+			return fmt.Errorf("at least one --source must be provided")
+		}
+		if len(args) == 0 && !resolveArcShadowed {
+			return fmt.Errorf("provide file names to resolve or --shadowed")
+		}
+		return resolveArcNames(resolveArcSources, args, resolveArcShadowed)
+	},
+}
+
+// resolveArcNames 挂载来源并打印每个文件名的胜出条目及被遮蔽的条目
+// resolveArcNames mounts the sources and prints the winning and shadowed entries for each file name
+func resolveArcNames(sources []string, names []string, listShadowed bool) error {
+	service := &COM3D2Service.ArcService{}
+	vfs, err := service.OpenVFS(context.Background(), sources)
+	if err != nil {
+		return fmt.Errorf("failed to mount sources: %w", err)
+	}
+	defer vfs.Close()
+
+	for _, name := range names {
+		candidates := vfs.Candidates(name)
+		if len(candidates) == 0 {
+			fmt.Printf("%s: not found\n", name)
+			continue
+		}
+		fmt.Printf("%s: %s\n", name, formatVFSEntry(candidates[0]))
+		for _, entry := range candidates[1:] {
+			fmt.Printf("  shadows %s\n", formatVFSEntry(entry))
+		}
+	}
+	if listShadowed {
+		shadowed := vfs.Shadowed()
+		for _, s := range shadowed {
+			fmt.Printf("%s: %s\n", s.Name, formatVFSEntry(s.Winner))
+			for _, entry := range s.Shadowed {
+				fmt.Printf("  shadows %s\n", formatVFSEntry(entry))
+			}
+		}
+		fmt.Printf("\nTotal: %d shadowed names\n", len(shadowed))
+	}
+	return nil
+}
+
+// formatVFSEntry 以“来源:路径”格式描述条目
+// formatVFSEntry describes an entry as source:path
+func formatVFSEntry(entry *arc.VFSEntry) string {
+	return entry.Source.Name + ":" + entry.Path
+}
+
+// init 注册分层解析命令的来源和遮蔽列表参数
+// init registers the source and shadowed-list flags for the layered resolve command
+func init() {
+	resolveArcCmd.Flags().StringArrayVar(&resolveArcSources, "source", nil, ".arc file or directory to mount; later sources override earlier ones (repeatable)")
+	resolveArcCmd.Flags().BoolVar(&resolveArcShadowed, "shadowed", false, "List every file name provided by more than one entry")
+}
@@ -64,18 +64,32 @@ func init() {
 	RootCmd.AddCommand(convert2texture2dCmd)
 	RootCmd.AddCommand(convert2gltfCmd)
 	RootCmd.AddCommand(gltf2modelCmd)
+	RootCmd.AddCommand(gltf2anmCmd)
+	RootCmd.AddCommand(model2kcesCmd)
+	RootCmd.AddCommand(parts2kcesCmd)
 	RootCmd.AddCommand(convert2audioCmd)
 	RootCmd.AddCommand(convert2neiCmd)
 	RootCmd.AddCommand(convert2csvCmd)
 	RootCmd.AddCommand(unpackArcCmd)
 	RootCmd.AddCommand(packArcCmd)
+	RootCmd.AddCommand(updateArcCmd)
+	RootCmd.AddCommand(diffArcCmd)
+	RootCmd.AddCommand(resolveArcCmd)
+	RootCmd.AddCommand(depsMenuCmd)
+	RootCmd.AddCommand(exportMenuCmd)
+	RootCmd.AddCommand(renameAssetsCmd)
+	RootCmd.AddCommand(verifyArcCmd)
 	RootCmd.AddCommand(listArcCmd)
 	RootCmd.AddCommand(extractArcCmd)
 	RootCmd.AddCommand(listAbaCmd)
 	RootCmd.AddCommand(unpackAbaCmd)
 	RootCmd.AddCommand(packAbaCmd)
+	RootCmd.AddCommand(recompressAbaCmd)
+	RootCmd.AddCommand(replaceAbaObjectCmd)
+	RootCmd.AddCommand(graphAbaCmd)
 	RootCmd.AddCommand(listCtCmd)
 	RootCmd.AddCommand(genCtCmd)
+	RootCmd.AddCommand(conflictCtCmd)
 	RootCmd.AddCommand(inspectKcesCatalogCmd)
 	RootCmd.AddCommand(serveCmd)
 	RootCmd.AddCommand(mcpCmd)
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateArcFrom    string
+	updateArcDelete  []string
+	updateArcCompact bool
+)
+
+// updateArcCmd represents the updateArc command
+var updateArcCmd = &cobra.Command{
+	Use:   "updateArc [file]",
+	Short: "Add, replace, or delete entries in a .arc file without a full repack",
+	Long: `Update a .arc file in place.
+Files under --from are added at their relative paths, replacing existing entries with the same path.
+--delete removes an entry by its path inside the ARC and may be repeated.
+
+Unchanged entries keep their existing data. New and replaced data, followed by new directory tables, is
+appended to the end of the ARC, so a small patch takes seconds even for a very large ARC. The ARC stays
+valid until the update finishes; a failed update leaves it unchanged.
+Replaced and deleted data remains as dead space. Use --compact, alone or with an update, to rewrite the
+ARC without it.
+
+Examples:
+  MeidoSerialization updateArc game.arc --from ./patch
+  MeidoSerialization updateArc game.arc --delete menu/old.menu --delete menu/old_i_.tex
+  MeidoSerialization updateArc game.arc --from ./patch --compact
+  MeidoSerialization updateArc game.arc --compact`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		if updateArcFrom == "" && len(updateArcDelete) == 0 && !updateArcCompact {
+			return fmt.Errorf("at least one of --from, --delete, or --compact must be provided")
+		}
+		if updateArcFrom != "" && !isDirectory(updateArcFrom) {
+			return fmt.Errorf("%s is not a directory", updateArcFrom)
+		}
+		return updateArc(path, updateArcFrom, updateArcDelete, updateArcCompact)
+	},
+}
+
+// updateArc 删除并添加 ARC 条目后原地写回，需要时再压实文件
+// updateArc deletes and adds ARC entries, writes them back in place, and compacts the file when requested
+func updateArc(path string, fromDir string, deletes []string, compact bool) error {
+	service := &COM3D2Service.ArcService{}
+	ctx := context.Background()
+	if fromDir != "" || len(deletes) > 0 {
+		result, err := service.UpdateArc(ctx, path, func(fs *arc.Arc) error {
+			for _, p := range deletes {
+				if !fs.DeleteFile(p) {
+					return fmt.Errorf("file not found in ARC: %s", p)
+				}
+			}
+			if fromDir == "" {
+				return nil
+			}
+			_, err := fs.AddFromDir(ctx, fromDir)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", path, err)
+		}
+		fmt.Printf("Updated %s: %d entries written, %d unchanged, %d bytes of dead space\n", path, result.AppendedFiles, result.ReusedFiles, result.DeadBytes)
+	}
+	if compact {
+		if err := service.CompactArc(ctx, path); err != nil {
+			return fmt.Errorf("failed to compact %s: %w", path, err)
+		}
+		fmt.Printf("Compacted %s\n", path)
+	}
+	return nil
+}
+
+// init 注册 ARC 原地更新命令的添加目录、删除路径和压实参数
+// init registers the add-directory, delete-path, and compact flags for the in-place ARC update command
+func init() {
+	updateArcCmd.Flags().StringVar(&updateArcFrom, "from", "", "Directory whose files are added or replaced at their relative paths")
+	updateArcCmd.Flags().StringArrayVar(&updateArcDelete, "delete", nil, "Path inside the ARC to delete (repeatable)")
+	updateArcCmd.Flags().BoolVar(&updateArcCompact, "compact", false, "Rewrite the ARC afterwards to reclaim dead space")
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/application"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
 	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
@@ -298,10 +299,10 @@ func isTexFile(path string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ".tex")
 }
 
-// isImageFile 判断路径是否使用工具层支持的图像类型
-// isImageFile reports whether a path uses an image type supported by the tools layer
+// isImageFile 判断路径是否为内置编解码器或 ImageMagick 支持的图像类型
+// isImageFile reports whether a path uses an image type supported by the built-in codec or ImageMagick
 func isImageFile(path string) bool {
-	return tools.IsSupportedImageType(path) == nil
+	return tools.IsNativeImageType(path) || tools.IsSupportedImageType(path) == nil
 }
 
 // isArcFile 不区分大小写地判断路径是否以 ARC 后缀结尾
@@ -659,6 +660,13 @@ func convertToMod(path string) error {
 // convertToImage 将 COM3D2 TEX 或 KCES Texture2D 和 Sprite 主文件转换为图像
 // convertToImage converts a COM3D2 TEX or KCES Texture2D and Sprite primary file to an image
 func convertToImage(path string, format string) error {
+	return convertToImageWithOptions(path, KCESService.Texture2DImageOptions{Format: format})
+}
+
+// convertToImageWithOptions 按 Texture2D 导出选项将 TEX、Texture2D 或 Sprite 主文件转换为图像，选项中的格式同时决定输出扩展名
+// convertToImageWithOptions converts a TEX, Texture2D, or Sprite primary file to an image using Texture2D export options, with the option format also selecting the output extension
+func convertToImageWithOptions(path string, opts KCESService.Texture2DImageOptions) error {
+	format := opts.Format
 	isNativeTexture := KCESService.IsKCESNativeTexture2DFile(path)
 	isNativeSprite := KCESService.IsKCESNativeSpriteFile(path)
 	if !isTexFile(path) && !isNativeTexture && !isNativeSprite {
@@ -668,6 +676,7 @@ func convertToImage(path string, format string) error {
 	if format == "" {
 		format = "png"
 	}
+	opts.Format = format
 
 	outputPath := strings.TrimSuffix(path, filepath.Ext(path)) + "." + format
 	if isNativeSprite {
@@ -682,7 +691,7 @@ func convertToImage(path string, format string) error {
 		return nil
 	}
 	if isNativeTexture {
-		err := (&KCESService.NativeUnityMediaService{}).ConvertTexture2DToImage(context.Background(), path, outputPath, format, application.DefaultMaxOutputBytes)
+		err := (&KCESService.NativeUnityMediaService{}).ConvertTexture2DToImageWithOptions(context.Background(), path, outputPath, opts, application.DefaultMaxOutputBytes)
 		if err != nil {
 			return fmt.Errorf("failed to convert %s to image: %w", path, err)
 		}
@@ -703,19 +712,25 @@ func convertToImage(path string, format string) error {
 // convertToTex 将受支持图像转换为 COM3D2 TEX 并应用所选载荷压缩策略
 // convertToTex converts a supported image to COM3D2 TEX using the selected payload compression policy
 func convertToTex(path string, compress bool, forcePng bool) error {
+	return convertToTexWithOptions(path, COM3D2.TexEncodeOptions{Compress: compress, ForcePNG: forcePng, Quality: bcn.QualityNormal})
+}
+
+// convertToTexWithOptions 按完整编码选项将受支持图像转换为 COM3D2 TEX
+// convertToTexWithOptions converts a supported image to COM3D2 TEX using the full encoding options
+func convertToTexWithOptions(path string, opts COM3D2.TexEncodeOptions) error {
 	if !isImageFile(path) {
 		return fmt.Errorf("not a supported image file: %s", path)
 	}
 
-	if compress {
-		forcePng = false
+	if opts.Compress {
+		opts.ForcePNG = false
 	}
 
 	ext := filepath.Ext(path)
 	outputPath := strings.TrimSuffix(path, ext) + ".tex"
 
 	service := &COM3D2Service.TexService{}
-	err := service.ConvertAnyToAnyAndWrite(path, "", compress, forcePng, outputPath)
+	err := service.ConvertImageToTexWithOptionsAndWrite(path, "", opts, outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to convert %s to TEX: %w", path, err)
 	}
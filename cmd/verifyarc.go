@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+	"github.com/spf13/cobra"
+)
+
+var verifyArcJSON bool
+
+// verifyArcCmd represents the verifyArc command
+var verifyArcCmd = &cobra.Command{
+	Use:   "verifyArc [file...]",
+	Short: "Check that every entry of .arc files is readable and consistent",
+	Long: `Walk each .arc file and report every problem instead of stopping at the first one.
+Every entry is decompressed and its size checked against its header. The UTF-16 and UTF-8 hash tables
+are cross-checked against the name table, duplicate paths and different paths whose unique ID hashes
+collide are reported, and entry offsets are checked for data outside the data area or overlapping another
+entry.
+
+Each problem is printed as the archive, the problem kind, the entry path, the file offset, and a
+message. The command fails when any archive has a problem.
+
+Examples:
+  MeidoSerialization verifyArc broken.arc
+  MeidoSerialization verifyArc parts.arc parts_2.arc --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return verifyArcs(args, verifyArcJSON)
+	},
+}
+
+// verifyArcResult 是一个 ARC 的 JSON 校验结果
+// verifyArcResult is the JSON verification result of one ARC
+type verifyArcResult struct {
+	File string `json:"file"`
+	*arc.VerifyReport
+}
+
+// verifyArcs 依次校验每个 ARC 并打印问题，任一 ARC 有问题时返回错误
+// verifyArcs verifies each ARC in turn and prints its problems, returning an error when any ARC has a problem
+func verifyArcs(paths []string, asJSON bool) error {
+	service := &COM3D2Service.ArcService{}
+	var results []verifyArcResult
+	failed := 0
+	for _, path := range paths {
+		report, err := service.VerifyArc(context.Background(), path)
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", path, err)
+		}
+		if !report.OK() {
+			failed++
+		}
+		if asJSON {
+			results = append(results, verifyArcResult{File: path, VerifyReport: report})
+			continue
+		}
+		for _, issue := range report.Issues {
+			entry := issue.Path
+			if entry == "" {
+				entry = "-"
+			}
+			fmt.Printf("%s: %s %s @%d: %s\n", path, issue.Kind, entry, issue.Offset, issue.Message)
+		}
+		if report.OK() {
+			fmt.Printf("%s: OK (%d files, %d directories)\n", path, report.Files, report.Dirs)
+		} else {
+			fmt.Printf("%s: %d problems (%d files, %d directories)\n", path, len(report.Issues), report.Files, report.Dirs)
+		}
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d .arc files failed verification", failed, len(paths))
+	}
+	return nil
+}
+
+// init 注册 ARC 校验命令的 JSON 输出参数
+// init registers the JSON-output flag for the ARC verify command
+func init() {
+	verifyArcCmd.Flags().BoolVar(&verifyArcJSON, "json", false, "Print the reports as JSON")
+}
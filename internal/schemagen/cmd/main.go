@@ -12,14 +12,34 @@ import (
 
 func main() {
 	output := flag.String("out", "schemas/editing/v1", "output directory")
+	manifest := flag.Bool("manifest", false, "write only the KCES mod manifest schema")
 	flag.Parse()
+	run := runEditing
+	if *manifest {
+		run = runManifest
+	}
 	if err := run(*output); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func run(output string) error {
+func runManifest(output string) error {
+	document, err := schemagen.GenerateModManifest()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return fmt.Errorf("create schema directory: %w", err)
+	}
+	path := filepath.Join(output, schemagen.ModManifestSchemaFileName)
+	if err := os.WriteFile(path, document.JSON, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+func runEditing(output string) error {
 	documents, err := schemagen.GenerateAll()
 	if err != nil {
 		return err
@@ -185,6 +185,8 @@ func formatSpecs() []spec {
 		{id: "com3d2.preset", root: typeOf[serializationCOM3D2.Preset]()},
 		{id: "com3d2.timeline", root: typeOf[serializationCOM3D2.TimelineData]()},
 		{id: "com3d2.object_data", root: typeOf[serializationCOM3D2.DanceObjectData]()},
+		{id: "com3d2.tex", root: typeOf[serializationCOM3D2.TexImageInfo]()},
+		{id: "com3d2.nei", root: typeOf[serializationCOM3D2.NeiCSVInfo](), customize: enumCustomizer("TextEncoding", string(serializationCOM3D2.NeiTextEncodingShiftJIS), string(serializationCOM3D2.NeiTextEncodingUTF8))},
 		{id: "kces.bridge_session", root: typeOf[serializationKCES.KCESBridgeSession]()},
 		{id: "kces.brd", root: typeOf[KCESService.GP03BridgeEditing]()},
 		{id: "kces.enm", root: typeOf[serializationKCES.KCESExportNameMap]()},
@@ -332,6 +334,22 @@ func markerCustomizer(property, value string) func(*jsonschema.Schema) error {
 	}
 }
 
+func enumCustomizer(property string, values ...string) func(*jsonschema.Schema) error {
+	return func(root *jsonschema.Schema) error {
+		if root.Properties == nil || root.Properties[property] == nil {
+			return fmt.Errorf("required enum property %q is missing", property)
+		}
+		field := root.Properties[property]
+		field.Type = "string"
+		field.Types = nil
+		field.Enum = make([]any, 0, len(values))
+		for _, value := range values {
+			field.Enum = append(field.Enum, value)
+		}
+		return nil
+	}
+}
+
 func anyPtr(value any) *any { return &value }
 
 func falseSchema() *jsonschema.Schema { return &jsonschema.Schema{Not: &jsonschema.Schema{}} }
@@ -340,7 +358,7 @@ func nativeSuffixes(id string) []string {
 	values := map[string][]string{
 		"com3d2.menu": {".menu"}, "com3d2.mate": {".mate", ".mat"}, "com3d2.pmat": {".pmat"}, "com3d2.col": {".col"},
 		"com3d2.phy": {".phy"}, "com3d2.psk": {".psk"}, "com3d2.anm": {".anm"}, "com3d2.model": {".model"},
-		"com3d2.preset": {".preset"}, "com3d2.timeline": {".bytes"}, "com3d2.object_data": {".bytes"},
+		"com3d2.preset": {".preset"}, "com3d2.timeline": {".bytes"}, "com3d2.object_data": {".bytes"}, "com3d2.tex": {".tex"}, "com3d2.nei": {".nei"},
 		"kces.bridge_session": {".vd"}, "kces.brd": {".brd"}, "kces.enm": {".enm"}, "kces.sad": {".sad"},
 		"kces.system": {"system.dat"}, "kces.paths": {"paths.dat"}, "kces.maid_collider": {".bytes"},
 		"kces.menuassets": {".menuassets"}, "kces.materialassets": {".materialassets"}, "kces.pmatassets": {".pmatassets"}, "kces.model": {".model"},
@@ -0,0 +1,103 @@
+package schemagen
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+const (
+	ModManifestSchemaID       = "urn:meido-serialization:kces-mod-manifest:v1"
+	ModManifestSchemaFileName = "kces.mod_manifest.schema.json"
+)
+
+// GenerateModManifest 生成 packAba --manifest 读取的 KCES MOD 清单模式
+// GenerateModManifest generates the schema of the KCES MOD manifest read by packAba --manifest
+func GenerateModManifest() (Document, error) {
+	rootType := typeOf[KCESService.ModManifest]()
+	if err := validateFixedWidthIntegerTypes(rootType); err != nil {
+		return Document{}, fmt.Errorf("validate fixed-width integer types for the mod manifest: %w", err)
+	}
+	root, definitions := buildReflectSchema(rootType)
+	if err := describeModManifest(root, definitions); err != nil {
+		return Document{}, fmt.Errorf("describe mod manifest schema: %w", err)
+	}
+	if len(definitions) != 0 {
+		root.Defs = definitions
+	}
+	root.Schema = SchemaDialect
+	root.ID = ModManifestSchemaID
+	root.Title = "KCES mod manifest"
+	root.Description = "Declarative input for packAba --manifest. Asset paths are relative to the manifest directory unless a source directory is given on the command line."
+	root.Extra = map[string]any{
+		"x-meido-representation": "mod_manifest",
+		"x-meido-schema-version": SchemaVersion,
+	}
+
+	data, err := marshalSchemaWithExactIntegerBounds(root)
+	if err != nil {
+		return Document{}, fmt.Errorf("marshal mod manifest schema: %w", err)
+	}
+	data = append(data, '\n')
+	digest := sha256.Sum256(data)
+	return Document{
+		FormatID: "kces.mod_manifest", Version: SchemaVersion, ID: root.ID, Dialect: SchemaDialect,
+		MediaType: SchemaMediaType, JSON: data, SHA256: fmt.Sprintf("%x", digest[:]),
+	}, nil
+}
+
+func describeModManifest(root *jsonschema.Schema, definitions map[string]*jsonschema.Schema) error {
+	descriptions := map[string]string{
+		"$schema":          "Schema location for editors; ignored when packing.",
+		"name":             "MOD name and output base name of name.aba and name.ct. KCES reads parts only from <name>.menuassets and <name>.materialassets, so keep it lowercase.",
+		"subName":          "Dependency sub-name; required for PluginPatch and ExtraPatch.",
+		"catalogType":      "Catalog category flags such as Parts or Parts|PartsMeta. Defaults to Parts.",
+		"packageType":      "Catalog package type. Defaults to Plugin.",
+		"priority":         "Catalog load priority.",
+		"compression":      "ABA data-block compression. Defaults to lz4.",
+		"compressionLevel": "lz4hc or lzma level from 1 to 9; 0 selects the default level.",
+		"unityVersion":     "Unity version written to the SerializedFile and UnityFS header, 2020.2 or later. Defaults to 2022.3.35f1.",
+		"assets":           "Resource files packed into the ABA and listed in the CT catalog.",
+	}
+	for name, description := range descriptions {
+		field := root.Properties[name]
+		if field == nil {
+			return fmt.Errorf("manifest property %q is missing", name)
+		}
+		field.Description = description
+	}
+	if err := enumCustomizer("packageType", "Base", "Plugin", "PluginPatch", "BasePatch", "ExtraBase", "ExtraPatch")(root); err != nil {
+		return err
+	}
+	if err := enumCustomizer("compression", "none", "lz4", "lz4hc", "lzma")(root); err != nil {
+		return err
+	}
+	level := root.Properties["compressionLevel"]
+	level.Minimum = floatPtr(0)
+	level.Maximum = floatPtr(9)
+	root.Properties["name"].MinLength = intPtr(1)
+	root.Properties["assets"].Types = nil
+	root.Properties["assets"].Type = "array"
+	root.Properties["assets"].MinItems = intPtr(1)
+
+	asset := definitions[definitionName(typeOf[KCESService.ModAsset]())]
+	if asset == nil {
+		return fmt.Errorf("ModAsset definition is missing")
+	}
+	assetDescriptions := map[string]string{
+		"name": "Short resource name used as m_Name, the m_Container key, and the CT name. Inferred from the file name when omitted.",
+		"path": "Source file path relative to the asset root. Absolute paths and . or .. components are rejected.",
+		"kind": "Unity object kind such as textasset, texture2d, rawtexture2d, mesh, sprite, spriteatlas, animationclip, or another lowercase Unity class name. Inferred from the path when omitted.",
+	}
+	for name, description := range assetDescriptions {
+		field := asset.Properties[name]
+		if field == nil {
+			return fmt.Errorf("asset property %q is missing", name)
+		}
+		field.Description = description
+	}
+	asset.Properties["path"].MinLength = intPtr(1)
+	return nil
+}
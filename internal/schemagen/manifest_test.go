@@ -0,0 +1,77 @@
+package schemagen
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	manifestv1 "github.com/MeidoPromotionAssociation/MeidoSerialization/schemas/manifest/v1"
+	KCESService "github.com/MeidoPromotionAssociation/MeidoSerialization/service/KCES"
+	strictschema "github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+func TestPublishedModManifestSchemaMatchesGenerated(t *testing.T) {
+	expected, err := GenerateModManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := manifestv1.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(published.JSON) != string(expected.JSON) || published.SHA256 != expected.SHA256 || published.ID != expected.ID {
+		t.Fatal("published mod manifest schema differs from generator; run go generate ./schemas/manifest/v1")
+	}
+}
+
+func TestModManifestSchemaValidatesManifests(t *testing.T) {
+	document, err := GenerateModManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaDocument, err := strictschema.UnmarshalJSON(bytes.NewReader(document.JSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiler := strictschema.NewCompiler()
+	compiler.DefaultDraft(strictschema.Draft2020)
+	if err := compiler.AddResource(document.ID, schemaDocument); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := compiler.Compile(document.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := KCESService.ModManifest{
+		Name: "valid", PackageType: "PluginPatch", SubName: "base", Priority: 3, Compression: "lzma", CompressionLevel: 9,
+		Assets: []KCESService.ModAsset{{Name: "valid.menuassets", Path: "menu/valid.menuassets", Kind: "textasset"}, {Path: "body.tex"}},
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := strictschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(valid); err != nil {
+		t.Fatalf("manifest %s does not match schema: %v", data, err)
+	}
+
+	for name, content := range map[string]string{
+		"unknown_field":      `{"name": "bad", "assets": [{"path": "a.bin"}], "priorty": 1}`,
+		"empty_assets":       `{"name": "bad", "assets": []}`,
+		"asset_without_path": `{"name": "bad", "assets": [{"name": "a.menuassets"}]}`,
+		"compression":        `{"name": "bad", "compression": "zstd", "assets": [{"path": "a.bin"}]}`,
+		"compression_level":  `{"name": "bad", "compressionLevel": 12, "assets": [{"path": "a.bin"}]}`,
+	} {
+		instance, err := strictschema.UnmarshalJSON(bytes.NewReader([]byte(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := schema.Validate(instance); err == nil {
+			t.Errorf("%s: invalid manifest passed schema validation", name)
+		}
+	}
+}
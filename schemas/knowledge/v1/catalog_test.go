@@ -2,6 +2,11 @@ package knowledgev1_test
 
 import (
 	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -497,3 +502,83 @@ func containsHan(text string) bool {
 	}
 	return false
 }
+
+func TestImplementationSourceLineRangesMatchDeclarations(t *testing.T) {
+	formats, err := knowledgev1.Formats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := map[string]bool{}
+	for _, formatID := range formats {
+		document, _, err := knowledgev1.Lookup(formatID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var profile knowledgev1.Guide
+		if err := json.Unmarshal(document.JSON, &profile); err != nil {
+			t.Fatal(err)
+		}
+		for _, evidence := range profile.Sources {
+			key := evidence.Path + "#" + evidence.Symbol
+			if evidence.Kind != knowledgev1.SourceKindImplementation || !strings.HasSuffix(evidence.Path, ".go") || checked[key] {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join("..", "..", "..", filepath.FromSlash(evidence.Path))); os.IsNotExist(err) {
+				continue
+			}
+			checked[key] = true
+			start, end, ok := implementationDeclarationSpan(t, evidence.Path, evidence.Symbol)
+			if ok && (evidence.LineStart != start || evidence.LineEnd != end) {
+				t.Errorf("profile %s cites %s %s at lines %d-%d, but the declarations span %d-%d", formatID, evidence.Path, evidence.Symbol, evidence.LineStart, evidence.LineEnd, start, end)
+			}
+		}
+	}
+}
+
+// implementationDeclarationSpan 返回来源符号中列出的顶层声明所占的行范围，有符号无法对应到声明时 ok 为 false
+// implementationDeclarationSpan returns the line span of the top-level declarations named by a source symbol, with ok false when a name does not resolve to a declaration
+func implementationDeclarationSpan(t *testing.T, path, symbol string) (start, end int, ok bool) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join("..", "..", "..", filepath.FromSlash(path)), nil, 0)
+	if err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+	declarations := map[string][2]int{}
+	record := func(name string, node ast.Node) {
+		declarations[name] = [2]int{fset.Position(node.Pos()).Line, fset.Position(node.End()).Line}
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) == 1 {
+				receiver := d.Recv.List[0].Type
+				if star, isStar := receiver.(*ast.StarExpr); isStar {
+					receiver = star.X
+				}
+				if ident, isIdent := receiver.(*ast.Ident); isIdent {
+					name = ident.Name + "." + name
+				}
+			}
+			record(name, d)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if typeSpec, isType := spec.(*ast.TypeSpec); isType {
+					record(typeSpec.Name.Name, d)
+				}
+			}
+		}
+	}
+	for _, name := range strings.FieldsFunc(symbol, func(r rune) bool { return r == '/' }) {
+		span, found := declarations[strings.TrimSpace(name)]
+		if !found {
+			return 0, 0, false
+		}
+		if start == 0 || span[0] < start {
+			start = span[0]
+		}
+		end = max(end, span[1])
+	}
+	return start, end, start != 0
+}
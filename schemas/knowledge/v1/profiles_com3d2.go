@@ -269,6 +269,43 @@ func com3d2Profiles() map[string]Guide {
 		pattern("/Entries/*/ObjectReferenceTrackIDList/*", "Referenced track ID", "A timeline track ID applied to the entry's resolved object.", "Dance playback uses the association to route track samples to this object.", "runtime_reference", "Only reference tracks that exist in the companion timeline.", objectSource),
 	}
 
+	texSource := implementationSource("COM3D2 2.48.0", "serialization/COM3D2/tex.go", "ReadTex/Tex.Dump", 89, 288, "The codec reads and writes the CM3D2_TEX signature, version, texture name, version-1011 atlas rectangles, version-1010 dimensions and texture format, and the length-prefixed image payload.")
+	texImageSource := implementationSource("COM3D2 2.48.0", "serialization/COM3D2/tex.go", "TexImageInfo/ConvertTexToImageInfo/ConvertImageInfoToTex", 964, 1099, "The editing form keeps the header fields in JSON and moves the pixels to a .dds attachment for DXT payloads or a .png attachment otherwise; write-back takes the dimensions from the image and encodes it as the requested TextureFormat.")
+	field = fieldFrom(texSource, texImageSource)
+	tex := guide(
+		"COM3D2 .tex editing guide",
+		"A COM3D2 texture. The editing JSON carries the header and atlas rectangles while the pixels travel as a .png or .dds attachment named after the JSON file.",
+		FormatVerificationSerializationVerified,
+		"The header layout and the image split were checked against this library's codec; the JSON alone is not a complete texture, so always convert it together with its image attachment.",
+		[]Source{texSource, texImageSource},
+		[]Field{
+			field("/Signature", "Texture signature", "The fixed CM3D2_TEX header string.", "The codec writes it as the first string of the native file.", "format_marker", "Keep CM3D2_TEX.", "critical"),
+			field("/Version", "Texture version", "The container version: 1010 adds dimensions and texture format, 1011 adds atlas rectangles.", "Write-back raises versions below 1010 to 1010 and raises any version carrying rectangles to 1011.", "format_version", "Keep the exported value unless rectangles are added or removed.", "high"),
+			field("/TextureName", "Source texture name", "The texture name recorded when the file was compiled.", "The codec round-trips it unchanged.", "descriptive_metadata", "Keep the original name; renaming the file does not require changing it.", "low"),
+			field("/Rects", "Atlas rectangles", "Version-1011 texture-atlas rectangles in normalized UV space.", "Each entry is written as X, Y, W, H single-precision floats after the texture name.", "layout_table", "Keep the order and count aligned with whatever consumer indexes the atlas; use an empty list for ordinary textures.", "high"),
+			field("/Width", "Exported width", "The pixel width of the texture when it was exported.", "Write-back replaces it with the attachment's width.", "derived_metadata", "Informational; resize the image attachment instead of editing this value.", "low"),
+			field("/Height", "Exported height", "The pixel height of the texture when it was exported.", "Write-back replaces it with the attachment's height.", "derived_metadata", "Informational; resize the image attachment instead of editing this value.", "low"),
+			field("/TextureFormat", "Payload texture format", "The Unity TextureFormat used for the native payload: 3 RGB24 (JPEG), 5 ARGB32 (PNG), 10 DXT1 or 12 DXT5.", "DXT targets reuse DDS blocks of the same format and recompress anything else; ARGB32 stores PNG and RGB24 stores JPEG.", "encoding_choice", "Use DXT5 or ARGB32 when the image needs alpha; DXT1 and RGB24 discard it.", "high"),
+		},
+	)
+	tex.FieldPatterns = []FieldPattern{
+		pattern("/Rects/*/{X,Y,W,H}", "Atlas rectangle component", "Lower-left origin, width and height of one atlas cell in normalized UV space.", "The codec stores each component as a single-precision float.", "layout_numeric", "Keep values within 0..1 and inside the image.", texSource),
+	}
+
+	neiSource := implementationSource("COM3D2 2.48.0", "serialization/common/nei/nei.go", "Read/Table.Dump", 83, 339, "The codec decrypts the AES container, checks the wsv signature, reads the row and column counts and the cell table, detects whether cells are Shift-JIS or UTF-8, and writes the same layout back with freshly encrypted padding.")
+	neiCSVSource := implementationSource("COM3D2 2.48.0", "serialization/COM3D2/nei.go", "NeiCSVInfo/ConvertNeiToCSVInfo/ConvertCSVInfoToNei", 58, 103, "The editing form keeps only the cell encoding in JSON and moves the table to a UTF-8 BOM CSV attachment; write-back pads short rows to the widest row and encodes every cell with the selected encoding.")
+	field = fieldFrom(neiSource, neiCSVSource)
+	nei := guide(
+		"COM3D2 .nei editing guide",
+		"An encrypted CSV table shared by COM3D2 and KCES. The editing JSON selects the cell encoding while the table itself travels as a UTF-8 BOM .csv attachment named after the JSON file.",
+		FormatVerificationSerializationVerified,
+		"The container and cell encodings were checked against this library's codec; the JSON alone is not a complete table, so always convert it together with its CSV attachment.",
+		[]Source{neiSource, neiCSVSource},
+		[]Field{
+			field("/TextEncoding", "Cell text encoding", "The encoding of cell text inside the native file: Shift-JIS for COM3D2 tables, UTF-8 for KCES tables.", "Export records the encoding detected from the cells, and write-back encodes every cell with it; an omitted value means Shift-JIS.", "encoding_choice", "Keep the exported value; choose UTF-8 only for tables read by KCES, because each game decodes cells with a fixed encoding and shows garbage otherwise.", "critical"),
+		},
+	)
+
 	return map[string]Guide{
 		"com3d2.menu":        menu,
 		"com3d2.mate":        material,
@@ -281,5 +318,7 @@ func com3d2Profiles() map[string]Guide {
 		"com3d2.preset":      preset,
 		"com3d2.timeline":    timeline,
 		"com3d2.object_data": objectData,
+		"com3d2.tex":         tex,
+		"com3d2.nei":         nei,
 	}
 }
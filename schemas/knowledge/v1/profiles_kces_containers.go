@@ -6,7 +6,7 @@ func kcesContainerProfiles() map[string]Guide {
 	vdSource := source("KCES 1.34.4", "KCES 1.34.4/WfSystem.Serialization/VirtualDirectory.cs", "VirtualDirectory.Serialize/Deserialize", 409, 575, "VirtualDirectory writes a fixed signature, a serialize-type byte, raw virtual-file data, compressed indexed metadata, and a trailing metadata length using its declared current layout.")
 	presetSource := source("KCES 1.34.4", "KCES 1.34.4/Assembly-CSharp/MaidPreset.cs", "MaidPreset.LoadPreset/Serialize", 31, 180, "MaidPreset stores thumbnail, maiddata, and optional meta files in a VirtualDirectory; maiddata contains compressed MaidPresetCore property, color, and body byte blocks.")
 	systemSource := source("KCES 1.34.4", "KCES 1.34.4/Assembly-CSharp/ApplicationSystemDataManager.cs", "ApplicationSystemDataManager.Load/Save", 12, 58, "ApplicationSystemDataManager opens system.dat as a VirtualDirectory and accesses typed EditData files below the EditData directory.")
-	editDataSource := implementationSource("KCES 1.34.4", "serialization/KCES/system_dat.go", "KCESEditDataKindForPath/DecodeKCESSystemData", 76, 207, "The serializer mirrors the game path dispatch for preset-panel names, palette colors, gradation points, movable panels, color-preset order lists, and color presets; only files outside every recognized path remain independent byte payloads.")
+	editDataSource := implementationSource("KCES 1.34.4", "serialization/KCES/system_dat.go", "KCESEditDataKindForPath/DecodeKCESSystemData", 267, 376, "The serializer mirrors the game path dispatch for preset-panel names, palette colors, gradation points, movable panels, color-preset order lists, and color presets; only files outside every recognized path remain independent byte payloads.")
 	ctSource := source("KCES 1.34.4", "KCES 1.34.4/WfSystem.FileSystem/Catalog/AssetBundleCatalog.cs", "AssetBundleCatalog", 15, 337, "The .ct catalog stores versioned catalog metadata, resource-file names, extension lists, hash-indexed items, and extension-name list side files used for resource lookup.")
 	ctUtilitySource := source("KCES 1.34.4", "KCES 1.34.4/WfSystem.FileSystem/Catalog/CatalogUtility.cs", "CatalogUtility.FromCatalog/ToCatalog", 128, 306, "CatalogUtility stores catalog and extension lists as MessagePack virtual files inside a VirtualDirectory and resolves resource locations from their hashes and indices.")
 	field := fieldFrom(presetSource, vdSource)
@@ -11,7 +11,7 @@ func kcesPayloadProfiles() map[string]Guide {
 	ikSource := source("KCES 1.34.4", "KCES 1.34.4/Assembly-CSharp/kt/ik/IKColliderSaveLoader.cs", "IKColliderSaveLoader.Save/Load", 66, 145, "IKColliderSaveLoader reads a length-prefixed Lz4BlockArray IKColliderDataPackage and creates collider objects for each effector group.")
 	limbSource := source("KCES 1.34.4", "KCES 1.34.4/Assembly-CSharp/LimbColliderMgr.cs", "LimbColliderMgr.Save/Load", 108, 140, "LimbColliderMgr reads a length-prefixed Lz4BlockArray LimbColliderPackage and applies each target limb collider status to the generated limb collider.")
 	colliderSource := implementationSource("KCES 1.34.4", "serialization/KCES/collider_payload.go", "ColliderPackage/ColliderRef and collider status unions", 17, 850, "The codec models the four game-defined plane, capsule, sphere, and maid-property collider tags and rejects every other union tag.")
-	clothSource := implementationSource("KCES 1.34.4", "serialization/KCES/cloth_params.go", "ClothParams", 107, 230, "ClothParams mirrors MagicaCloth's indexed keys 0..82, including sparse holes at keys 4, 5, and 56, Bezier parameters, constraints, and mode enums.")
+	clothSource := implementationSource("KCES 1.34.4", "serialization/KCES/cloth_params.go", "ClothParams", 180, 262, "ClothParams mirrors MagicaCloth's indexed keys 0..82, including sparse holes at keys 4, 5, and 56, Bezier parameters, constraints, and mode enums.")
 
 	commonFields := func(ext, expectedKind, expectedStorage string, src Source, rootField string, rootTitle, rootDescription, rootUsage, rootRole, rootGuidance, rootRisk string) []Field {
 		field := fieldFrom(src, payloadSource)
@@ -0,0 +1,65 @@
+// Package manifestv1 公开 packAba --manifest 读取的 KCES MOD 清单在仓库中签入的 Draft 2020-12 模式
+// Package manifestv1 exposes the checked-in Draft 2020-12 schema of the KCES MOD manifest read by packAba --manifest
+package manifestv1
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// Version 是当前清单模式版本 / Version is the current manifest schema version
+	Version = "1.0.0"
+	// Dialect 是清单模式使用的 JSON Schema 方言 / Dialect is the JSON Schema dialect used by the manifest schema
+	Dialect = "https://json-schema.org/draft/2020-12/schema"
+	// MediaType 是清单模式文档的媒体类型 / MediaType is the media type of the manifest schema document
+	MediaType = "application/schema+json"
+	// ID 是清单模式文档的规范标识符 / ID is the canonical identifier of the manifest schema document
+	ID = "urn:meido-serialization:kces-mod-manifest:v1"
+	// FileName 是签入的清单模式文件名 / FileName is the name of the checked-in manifest schema file
+	FileName = "kces.mod_manifest.schema.json"
+)
+
+//go:generate go run ../../../internal/schemagen/cmd -manifest -out .
+
+//go:embed kces.mod_manifest.schema.json
+var schemaFile []byte
+
+// Document 表示已嵌入且通过元数据校验的清单模式 / Document represents the embedded manifest schema with validated metadata
+type Document struct {
+	// Version 是模式声明的契约版本 / Version is the contract version declared by the schema
+	Version string
+	// ID 是模式文档的规范标识符 / ID is the canonical identifier of the schema document
+	ID string
+	// Dialect 是模式文档声明的 JSON Schema 方言 / Dialect is the JSON Schema dialect declared by the document
+	Dialect string
+	// MediaType 是模式文档的媒体类型 / MediaType is the media type of the schema document
+	MediaType string
+	// SHA256 是嵌入模式字节的十六进制 SHA-256 摘要 / SHA256 is the hexadecimal SHA-256 digest of the embedded schema bytes
+	SHA256 string
+	// JSON 是嵌入模式文档的独立字节副本 / JSON is an independent byte copy of the embedded schema document
+	JSON []byte
+}
+
+// Schema 读取并校验嵌入的 KCES MOD 清单模式
+// Schema reads and validates the embedded KCES MOD manifest schema
+func Schema() (Document, error) {
+	var header struct {
+		ID      string `json:"$id"`
+		Schema  string `json:"$schema"`
+		Version string `json:"x-meido-schema-version"`
+	}
+	if err := json.Unmarshal(schemaFile, &header); err != nil {
+		return Document{}, fmt.Errorf("decode embedded manifest schema: %w", err)
+	}
+	if header.ID != ID || header.Version != Version || header.Schema != Dialect {
+		return Document{}, fmt.Errorf("embedded manifest schema has inconsistent metadata")
+	}
+	digest := sha256.Sum256(schemaFile)
+	return Document{
+		Version: header.Version, ID: header.ID, Dialect: header.Schema, MediaType: MediaType,
+		SHA256: fmt.Sprintf("%x", digest[:]), JSON: append([]byte(nil), schemaFile...),
+	}, nil
+}
@@ -2,12 +2,12 @@ package arc
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio/stream"
@@ -404,16 +404,37 @@ func populateArc(arc *Arc, t *hashTable, nameLUT map[uint64]string, reader *stre
 // Dump 将 Arc 写入磁盘上的 ARC 文件
 // Dump writes the Arc to an ARC file on disk
 func (arc *Arc) Dump(path string) error {
-	tmpDir := filepath.Dir(path)
-	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+	return arc.DumpContext(context.Background(), path)
+}
+
+// DumpContext 将 Arc 流式写入磁盘上的 ARC 文件，内存占用与归档大小无关
+// 数据先写入同目录的临时文件，成功后才替换 path，因此取消或失败不会留下截断的 ARC
+// DumpContext streams the Arc to an ARC file on disk with memory use independent of the archive size
+// Data goes to a temporary file in the same directory that replaces path only on success, so cancellation or failure never leaves a truncated ARC
+func (arc *Arc) DumpContext(ctx context.Context, path string) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pats, err := arc.compileCompressGlobs()
+	if err != nil {
+		return err
+	}
+
+	outDir := filepath.Dir(path)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		// Windows 下忽略权限问题
 		// Ignore on Windows perms
 	}
-	f, err := os.Create(path)
+	f, err := os.CreateTemp(outDir, "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create ARC file: %w", err)
 	}
-	defer f.Close()
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
 
 	writer := stream.NewBinaryWriter(f)
 
@@ -430,60 +451,11 @@ func (arc *Arc) Dump(path string) error {
 		return fmt.Errorf("failed to get base offset: %w", err)
 	}
 
-	// 写入文件表
-	// File table write
+	// 逐个流式写入文件表，每次只缓冲一个复制块
+	// Stream the file table one file at a time, buffering a single copy chunk
 	fileOffsets := map[uint64]int64{}
-	files := AllFiles(arc)
-	// 将压缩通配模式编译为正则表达式
-	// Compile compress globs into regex
-	var pats []*regexp.Regexp
-	for _, g := range arc.CompressGlobs {
-		if g == "" {
-			continue
-		}
-		regex, err := globToRegex(g)
-		if err != nil {
-			return fmt.Errorf("invalid glob pattern: %w", err)
-		}
-		pats = append(pats, regex)
-	}
-
-	for i, fl := range files {
-		// 默认保留已有的压缩载荷
-		// 压缩通配模式还会选择未压缩文件或新文件进行压缩
-		// Preserve an existing compressed payload by default
-		// Compression globs additionally select uncompressed/new files for compression
-		wasCompressed := fl.Ptr.Compressed()
-		compress := wasCompressed
-		for _, p := range pats {
-			if p.MatchString(fl.Name) {
-				compress = true
-				break
-			}
-		}
-		stored, err := fl.Ptr.Data()
-		if err != nil {
-			return fmt.Errorf("failed to read file data: %w", err)
-		}
-		raw := stored
-		enc := stored
-		if wasCompressed {
-			raw, err = deflateDecompress(stored)
-			if err != nil {
-				return fmt.Errorf("failed to decompress existing file %q: %w", fl.RelativePath(), err)
-			}
-		} else if compress {
-			enc, err = deflateCompress(raw)
-			if err != nil {
-				return fmt.Errorf("failed to compress file data: %w", err)
-			}
-		}
-		rawSize, err := checkedArcUint32Length(fmt.Sprintf("file %q raw size", fl.RelativePath()), int64(len(raw)))
-		if err != nil {
-			return err
-		}
-		storedSize, err := checkedArcUint32Length(fmt.Sprintf("file %q stored size", fl.RelativePath()), int64(len(enc)))
-		if err != nil {
+	for _, fl := range AllFiles(arc) {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 		pos, err := writer.Tell()
@@ -491,30 +463,9 @@ func (arc *Arc) Dump(path string) error {
 			return fmt.Errorf("failed to get file position: %w", err)
 		}
 		fileOffsets[fl.UniqueID()] = pos - baseOff
-		// 写入文件头
-		// Header
-		flag := uint32(0)
-		if compress {
-			flag = 1
-		}
-		if err := writer.WriteUInt32(flag); err != nil {
-			return fmt.Errorf("failed to write file header: %w", err)
-		}
-		if err := writer.WriteUInt32(0); err != nil {
-			return fmt.Errorf("failed to write file header: %w", err)
-		}
-		if err := writer.WriteUInt32(rawSize); err != nil {
-			return fmt.Errorf("failed to write file header: %w", err)
-		}
-		if err := writer.WriteUInt32(storedSize); err != nil {
-			return fmt.Errorf("failed to write file header: %w", err)
-		}
-		if err := writer.WriteBytes(enc); err != nil {
-			return fmt.Errorf("failed to write file data: %w", err)
+		if err := writeFileEntry(ctx, writer, fl, shouldCompress(fl, pats)); err != nil {
+			return err
 		}
-		// 进度索引
-		// Progress
-		_ = i
 	}
 
 	// 写入元数据
@@ -534,7 +485,36 @@ func (arc *Arc) Dump(path string) error {
 	if _, err := writer.Seek(metadataPos, io.SeekStart); err != nil {
 		return fmt.Errorf("failed to seek to metadata position: %w", err)
 	}
+	if err := arc.writeMetadata(writer, fileOffsets); err != nil {
+		return err
+	}
 
+	// CreateTemp 以 0600 创建文件，改为与被替换文件一致的权限，新文件使用 os.Create 的 0644
+	// CreateTemp creates the file as 0600, so match the mode of the file being replaced, or os.Create's 0644 for a new file
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := f.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set ARC file mode: %w", err)
+	}
+	// 改名前先落盘，否则崩溃后可能留下空的或截断的 ARC 替换原文件
+	// Flush to disk before the rename, or a crash could leave an empty or truncated ARC in place of the original
+	if err := syncArcFile(f); err != nil {
+		return fmt.Errorf("failed to sync ARC file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close ARC file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to move ARC file into place: %w", err)
+	}
+	return nil
+}
+
+// writeMetadata 在写入器当前位置写出两个哈希表和名称表，fileOffsets 为相对 baseOff 的文件条目偏移
+// writeMetadata writes both hash tables and the name table at the writer's position, with fileOffsets relative to baseOff
+func (arc *Arc) writeMetadata(writer *stream.BinaryWriter, fileOffsets map[uint64]int64) error {
 	// 建立唯一 ID 到哈希的映射
 	// Build uuid->hash mappings
 	uuidToHash16 := map[uint64]uint64{}
@@ -718,9 +698,17 @@ func (arc *Arc) MergeFrom(src *Arc, keepDupes bool) {
 	walk(src.Root, arc.Root)
 }
 
-// Pack 将 dirPath 中的全部文件载入 Arc 结构并写到 arcPath
-// Pack loads all files from dirPath into an Arc structure and dumps it to arcPath
+// Pack 将 dirPath 中的全部文件写到 arcPath
+// Pack writes all files from dirPath to arcPath
 func Pack(dirPath string, arcPath string) error {
+	return PackContext(context.Background(), dirPath, arcPath)
+}
+
+// PackContext 将 dirPath 中的全部文件流式写到 arcPath
+// Arc 结构只记录文件路径，数据在写入时才从磁盘读取，因此内存占用与目录大小无关
+// PackContext streams all files from dirPath to arcPath
+// The Arc structure records only file paths and data is read from disk while writing, so memory use is independent of the directory size
+func PackContext(ctx context.Context, dirPath string, arcPath string) error {
 	absDir, err := filepath.Abs(dirPath)
 	if err != nil {
 		return fmt.Errorf("failed to getting absolute path for %q: %w", dirPath, err)
@@ -733,44 +721,13 @@ func Pack(dirPath string, arcPath string) error {
 	name := filepath.Base(absDir)
 	fs := NewArc(name)
 
-	err = filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("failed to walking %q: %w", path, err)
-		}
-		if info.IsDir() {
-			return nil
-		}
-
-		// 计算 ARC 内使用的相对路径
-		// Calculate relative path for use within the ARC
-		rel, err := filepath.Rel(absDir, path)
-		if err != nil {
-			return fmt.Errorf("failed to calculating relative path for %q: %w", path, err)
-		}
-
-		// 读取文件数据
-		// Read file data
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to reading file %q: %w", path, err)
-		}
-
-		// 加入 Arc
-		// Add to Arc
-		f := AddFileByPath(fs.Root, rel)
-		f.Arc = fs
-		f.Ptr = NewMemoryPointer(data)
-
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to walk directory %q: %w", absDir, err)
+	if _, err := fs.AddFromDir(ctx, absDir); err != nil {
+		return err
 	}
 
 	// 写到 arcPath
 	// Dump to arcPath
-	return fs.Dump(arcPath)
+	return fs.DumpContext(ctx, arcPath)
 }
 
 // Unpack 将整个 Arc 文件系统解压到指定目录
@@ -0,0 +1,187 @@
+package arc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// DiffKind 表示 ARC 条目在两个版本之间的变化类型
+// DiffKind represents how an ARC entry changed between two versions
+type DiffKind string
+
+const (
+	DiffAdded    DiffKind = "added"    // 只存在于新版本 / Present only in the new version
+	DiffRemoved  DiffKind = "removed"  // 只存在于旧版本 / Present only in the old version
+	DiffModified DiffKind = "modified" // 两个版本的内容不同 / Content differs between the versions
+)
+
+// DiffEntry 描述一个发生变化的条目，大小为零值的一侧表示该版本中不存在此条目
+// DiffEntry describes one changed entry, with zero sizes on the side where the entry does not exist
+type DiffEntry struct {
+	Path       string   `json:"path"`              // 使用正斜杠的完整路径 / Full path with forward slashes
+	Kind       DiffKind `json:"kind"`              // 变化类型 / Kind of change
+	OldRawSize uint32   `json:"oldRawSize"`        // 旧版本的原始大小 / Raw size in the old version
+	OldSize    uint32   `json:"oldSize"`           // 旧版本的存储大小 / Stored size in the old version
+	NewRawSize uint32   `json:"newRawSize"`        // 新版本的原始大小 / Raw size in the new version
+	NewSize    uint32   `json:"newSize"`           // 新版本的存储大小 / Stored size in the new version
+	OldHash    string   `json:"oldHash,omitempty"` // 旧版本原始内容的 SHA-256，原始大小不同时不计算 / SHA-256 of the old raw content, not computed when the raw sizes differ
+	NewHash    string   `json:"newHash,omitempty"` // 新版本原始内容的 SHA-256，原始大小不同时不计算 / SHA-256 of the new raw content, not computed when the raw sizes differ
+}
+
+// Diff 是两个 ARC 之间按路径排序的变化列表
+// Diff is the path-sorted list of changes between two ARCs
+type Diff struct {
+	Entries   []DiffEntry `json:"entries"`   // 发生变化的条目 / Changed entries
+	Unchanged int         `json:"unchanged"` // 内容相同的条目数量 / Number of entries with identical content
+}
+
+// Count 返回指定类型的变化数量
+// Count returns the number of changes of the given kind
+func (d *Diff) Count(kind DiffKind) int {
+	n := 0
+	for _, e := range d.Entries {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// DiffArcs 按完整路径比较两个 ARC 的条目
+// 原始大小不同即视为修改；原始大小相同时，存储数据完全一致的条目直接视为相同，否则比较原始内容的 SHA-256
+// 因此仅压缩方式不同的条目不算修改，文件数据一次只读入一个
+// DiffArcs compares the entries of two ARCs by full path
+// Entries with different raw sizes are modified; with equal raw sizes, identical stored data means unchanged, otherwise the SHA-256 of the raw content decides
+// Entries that differ only in compression are therefore not modified, and file data is read one file at a time
+func DiffArcs(ctx context.Context, oldArc *Arc, newArc *Arc) (*Diff, error) {
+	oldFiles := filesByPath(oldArc)
+	newFiles := filesByPath(newArc)
+	paths := make([]string, 0, len(oldFiles)+len(newFiles))
+	for p := range oldFiles {
+		paths = append(paths, p)
+	}
+	for p := range newFiles {
+		if _, ok := oldFiles[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	diff := &Diff{}
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		oldFile, newFile := oldFiles[p], newFiles[p]
+		entry := DiffEntry{Path: p}
+		if oldFile != nil {
+			entry.OldRawSize, entry.OldSize = oldFile.Ptr.RawSize(), oldFile.Ptr.Size()
+		}
+		if newFile != nil {
+			entry.NewRawSize, entry.NewSize = newFile.Ptr.RawSize(), newFile.Ptr.Size()
+		}
+		switch {
+		case oldFile == nil:
+			entry.Kind = DiffAdded
+		case newFile == nil:
+			entry.Kind = DiffRemoved
+		case entry.OldRawSize != entry.NewRawSize:
+			entry.Kind = DiffModified
+		default:
+			same, oldHash, newHash, err := sameFileContent(ctx, oldFile, newFile)
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				diff.Unchanged++
+				continue
+			}
+			entry.Kind = DiffModified
+			entry.OldHash, entry.NewHash = oldHash, newHash
+		}
+		diff.Entries = append(diff.Entries, entry)
+	}
+	return diff, nil
+}
+
+// PatchArc 返回只包含 newArc 中新增和修改条目的 Arc，条目共享 newArc 的数据指针
+// 删除的条目无法用补丁 ARC 表达，只会出现在差异报告中
+// PatchArc returns an Arc holding only the added and modified entries of newArc, sharing its data pointers
+// Removed entries cannot be expressed by a patch ARC and only appear in the diff report
+func (d *Diff) PatchArc(newArc *Arc) *Arc {
+	patch := NewArc(newArc.Name)
+	patch.CompressGlobs = append([]string(nil), newArc.CompressGlobs...)
+	for _, e := range d.Entries {
+		if e.Kind == DiffRemoved {
+			continue
+		}
+		src := newArc.GetFile(filepath.FromSlash(e.Path))
+		if src == nil {
+			continue
+		}
+		f := AddFileByPath(patch.Root, filepath.FromSlash(e.Path))
+		f.Arc = patch
+		f.Ptr = src.Ptr
+	}
+	return patch
+}
+
+// filesByPath 以正斜杠完整路径索引 Arc 中的全部文件
+// filesByPath indexes every file in the Arc by its full path with forward slashes
+func filesByPath(fs *Arc) map[string]*File {
+	out := map[string]*File{}
+	for _, f := range AllFiles(fs) {
+		out[filepath.ToSlash(f.RelativePath())] = f
+	}
+	return out
+}
+
+// sameFileContent 判断两个原始大小相同的文件内容是否一致，需要比较内容时返回两者的 SHA-256
+// sameFileContent reports whether two files of equal raw size hold the same content, returning both SHA-256 digests when the content had to be compared
+func sameFileContent(ctx context.Context, a *File, b *File) (bool, string, string, error) {
+	if a.Ptr.Compressed() && b.Ptr.Compressed() && a.Ptr.Size() == b.Ptr.Size() {
+		// 相同的压缩载荷无需解压
+		// Identical compressed payloads need no decompression
+		aData, err := a.Ptr.Data()
+		if err != nil {
+			return false, "", "", fmt.Errorf("failed to read %s: %w", a.RelativePath(), err)
+		}
+		bData, err := b.Ptr.Data()
+		if err != nil {
+			return false, "", "", fmt.Errorf("failed to read %s: %w", b.RelativePath(), err)
+		}
+		if bytes.Equal(aData, bData) {
+			return true, "", "", nil
+		}
+	}
+	aHash, err := rawContentHash(ctx, a)
+	if err != nil {
+		return false, "", "", err
+	}
+	bHash, err := rawContentHash(ctx, b)
+	if err != nil {
+		return false, "", "", err
+	}
+	return aHash == bHash, aHash, bHash, nil
+}
+
+// rawContentHash 流式计算文件原始内容的 SHA-256
+// rawContentHash streams the SHA-256 of a file's raw content
+func rawContentHash(ctx context.Context, fl *File) (string, error) {
+	in, err := openRawContent(ctx, fl)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, in); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", fl.RelativePath(), err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,42 @@
+package arc
+
+import (
+	"io"
+	"path"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/containerfs"
+)
+
+// FS 返回 ARC 的只读 io/fs 视图，实现 fs.FS、fs.ReadDirFS 和 fs.StatFS，空目录同样可见
+// 文件在首次读取时才解压，fs.FileInfo 的 Size 为原始大小，Sys 返回带存储大小的 containerfs.EntryInfo
+// 视图不会跟随之后对 ARC 的修改
+// FS returns a read-only io/fs view of the ARC implementing fs.FS, fs.ReadDirFS and fs.StatFS, with empty directories visible too
+// Files are decompressed on first read; fs.FileInfo.Size is the raw size and Sys returns a containerfs.EntryInfo carrying the stored size
+// The view does not follow later changes to the ARC
+func (arc *Arc) FS() (*containerfs.FS, error) {
+	var entries []containerfs.Entry
+	for _, d := range AllDirs(arc) {
+		if d.Parent != nil {
+			entries = append(entries, containerfs.Entry{Path: dirSlashPath(d), Dir: true})
+		}
+	}
+	for _, fl := range AllFiles(arc) {
+		entries = append(entries, containerfs.Entry{
+			Path:       path.Join(dirSlashPath(fl.Parent), fl.Name),
+			RawSize:    int64(fl.Ptr.RawSize()),
+			StoredSize: int64(fl.Ptr.Size()),
+			Compressed: fl.Ptr.Compressed(),
+			Open:       func() (io.ReadCloser, error) { return fl.Open() },
+		})
+	}
+	return containerfs.New(entries)
+}
+
+// dirSlashPath 返回目录相对于 ARC 根目录的正斜杠路径，根目录为空字符串
+// dirSlashPath returns the directory path relative to the Arc root with forward slashes, or an empty string for the root
+func dirSlashPath(d *Dir) string {
+	if d == nil || d.Parent == nil {
+		return ""
+	}
+	return path.Join(dirSlashPath(d.Parent), d.Name)
+}
@@ -0,0 +1,139 @@
+package arc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/containerfs"
+)
+
+func TestArcFSServesPackedFilesThroughStandardTooling(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	packed, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed.Root.GetOrCreateDir("empty")
+	fsys, err := packed.FS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"empty"}
+	for rel := range files {
+		expected = append(expected, rel)
+	}
+	if err := fstest.TestFS(fsys, expected...); err != nil {
+		t.Fatal(err)
+	}
+
+	// Size 报告原始大小，Sys 报告压缩后的存储大小
+	// Size reports the raw size and Sys the compressed stored size
+	info, err := fs.Stat(fsys, "dress.menu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := info.Sys().(containerfs.EntryInfo)
+	if info.Size() != int64(len(files["dress.menu"])) || !entry.Compressed || entry.StoredSize >= info.Size() {
+		t.Fatalf("dress.menu info: size=%d sys=%+v", info.Size(), entry)
+	}
+	info, err = fs.Stat(fsys, "model/dress.model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := info.Sys().(containerfs.EntryInfo); entry.Compressed || entry.StoredSize != info.Size() {
+		t.Fatalf("model/dress.model info: size=%d sys=%+v", info.Size(), entry)
+	}
+
+	// 压缩条目的范围请求需要在流中向后定位
+	// A range request on a compressed entry needs backward seeks within the stream
+	server := httptest.NewServer(http.FileServerFS(fsys))
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/dress.menu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Range", "bytes=100-199")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body, files["dress.menu"][100:200]) {
+		t.Fatalf("range request returned %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestArcFSReadsEntriesConcurrently(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	// 分别由磁盘文件和内存副本支持，所有条目共享同一个底层流
+	// Backed by the file on disk and by an in-memory copy, with every entry sharing one underlying stream
+	f, err := os.Open(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	onDisk, err := ReadArc(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inMemory, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, packed := range []*Arc{onDisk, inMemory} {
+		readArcFSConcurrently(t, packed, files)
+	}
+}
+
+// readArcFSConcurrently 通过 FS 并发读取每个条目多次并比较内容
+// readArcFSConcurrently reads every entry several times concurrently through the FS and compares the content
+func readArcFSConcurrently(t *testing.T, packed *Arc, files map[string][]byte) {
+	t.Helper()
+	fsys, err := packed.FS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(files)*8)
+	for i := 0; i < 8; i++ {
+		for rel, want := range files {
+			wg.Add(1)
+			go func(rel string, want []byte) {
+				defer wg.Done()
+				got, err := fs.ReadFile(fsys, rel)
+				if err != nil {
+					errs <- err
+				} else if !bytes.Equal(got, want) {
+					errs <- fmt.Errorf("%s content mismatch under concurrent reads", rel)
+				}
+			}(rel, want)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -3,8 +3,11 @@ package arc
 import (
 	"bytes"
 	"compress/flate"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"os"
+	"sync"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio/stream"
 )
@@ -85,15 +88,59 @@ func (m *MemoryPointerCompressed) RawSize() uint32 { return m.raw }
 // Size returns the number of bytes used to store the compressed data in the ARC
 func (m *MemoryPointerCompressed) Size() uint32 { return uint32(len(m.data)) }
 
+// DiskPointer 表示磁盘上尚未读入内存的未压缩文件，写入 ARC 时按块流式读取
+// DiskPointer represents an uncompressed file on disk that is not loaded into memory and is streamed in chunks when the ARC is written
+type DiskPointer struct {
+	path string // 磁盘文件路径 / Path of the file on disk
+	size uint32 // 创建指针时的文件大小 / File size when the pointer was created
+}
+
+// NewDiskPointer 为磁盘文件创建指针，并拒绝超出 ARC UInt32 大小字段的文件
+// NewDiskPointer creates a pointer to a file on disk and rejects files that exceed the ARC UInt32 size field
+func NewDiskPointer(path string) (*DiskPointer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+	size, err := checkedArcUint32Length(fmt.Sprintf("file %q size", path), info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return &DiskPointer{path: path, size: size}, nil
+}
+
+// Compressed 始终返回 false，因为磁盘文件以原始数据保存
+// Compressed always returns false because the file on disk holds raw data
+func (d *DiskPointer) Compressed() bool { return false }
+
+// Data 将整个磁盘文件读入内存
+// Data reads the whole file on disk into memory
+func (d *DiskPointer) Data() ([]byte, error) { return os.ReadFile(d.path) }
+
+// Open 打开磁盘文件以便流式读取
+// Open opens the file on disk for streaming
+func (d *DiskPointer) Open() (io.ReadCloser, error) { return os.Open(d.path) }
+
+// RawSize 返回创建指针时的文件大小
+// RawSize returns the file size when the pointer was created
+func (d *DiskPointer) RawSize() uint32 { return d.size }
+
+// Size 返回未压缩文件在 ARC 中的存储字节数
+// Size returns the number of bytes used to store the uncompressed file in the ARC
+func (d *DiskPointer) Size() uint32 { return d.size }
+
 // ArcPointer 从 ARC 文件中的给定偏移延迟读取数据
 // 此偏移指向每个文件所用的 16 字节头部起始位置
 // 头部依次为压缩标志、保留值、原始大小和存储大小，随后是文件数据
+// 底层流实现 io.ReaderAt 时按偏移读取而不移动共享的读取位置，因此同一 ARC 的多个指针可以并发读取
 // ArcPointer lazily reads from an .arc file at a given offset
 // The offset points to the start of the 16-byte per-file header
 // [u32 compressed][u32 padding][u32 rawSize][u32 size] followed by data
+// When the underlying stream implements io.ReaderAt it is read by offset without moving the shared read position, so pointers into the same ARC can read concurrently
 type ArcPointer struct {
 	reader      *stream.BinaryReader // 延迟读取所用的二进制读取器 / Binary reader used for lazy loading
 	offset      int64                // 文件头起始偏移 / Offset of the file header
+	mu          sync.Mutex           // 保护文件头缓存 / Guards the cached file header
 	initialized bool                 // 是否已读取并缓存文件头 / Whether the file header has been loaded and cached
 	compressed  bool                 // 文件数据是否压缩 / Whether the file data is compressed
 	raw         uint32               // 解压后的数据大小 / Uncompressed data size
@@ -101,46 +148,50 @@ type ArcPointer struct {
 	dataOff     int64                // 文件数据起始偏移 / Offset of the file data
 }
 
+// arcPointerSeekMu 串行化不支持 io.ReaderAt 的底层流上的定位和读取
+// arcPointerSeekMu serializes the seek and read on underlying streams that do not support io.ReaderAt
+var arcPointerSeekMu sync.Mutex
+
 // NewArcPointer 创建一个从给定文件头偏移读取数据的延迟指针
 // NewArcPointer creates a lazy pointer that reads data from the given file-header offset
 func NewArcPointer(reader *stream.BinaryReader, offset int64) *ArcPointer {
 	return &ArcPointer{reader: reader, offset: offset}
 }
 
+// readAt 从底层流的绝对偏移处读满 p，不依赖也不保留共享的读取位置
+// readAt fills p from an absolute offset of the underlying stream, neither relying on nor preserving the shared read position
+func (a *ArcPointer) readAt(p []byte, off int64) error {
+	if ra, ok := a.reader.R.(io.ReaderAt); ok {
+		_, err := ra.ReadAt(p, off)
+		return err
+	}
+	arcPointerSeekMu.Lock()
+	defer arcPointerSeekMu.Unlock()
+	if _, err := a.reader.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(a.reader.R, p)
+	return err
+}
+
 // ensure 在尚未初始化时从底层二进制流读取数据并初始化 ArcPointer
 // ensure initializes the ArcPointer by loading data from the underlying binary stream if it is not already initialized
 func (a *ArcPointer) ensure() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.initialized {
 		return nil
 	}
-	if _, err := a.reader.Seek(a.offset, io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek to offset %d: %w", a.offset, err)
-	}
-	flag, err := a.reader.ReadUInt32()
-	if err != nil {
-		return fmt.Errorf("failed to read compressed flag: %w", err)
-	}
-	// 跳过保留值
-	// Skip padding
-	if _, err := a.reader.ReadUInt32(); err != nil {
-		return fmt.Errorf("failed to read padding: %w", err)
-	}
-	raw, err := a.reader.ReadUInt32()
-	if err != nil {
-		return fmt.Errorf("failed to read raw size: %w", err)
-	}
-	sz, err := a.reader.ReadUInt32()
-	if err != nil {
-		return fmt.Errorf("failed to read compressed size: %w", err)
-	}
-	a.raw = raw
-	a.size = sz
-	a.compressed = flag == 1
-	pos, err := a.reader.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return fmt.Errorf("failed to determine data offset: %w", err)
+	var header [16]byte
+	if err := a.readAt(header[:], a.offset); err != nil {
+		return fmt.Errorf("failed to read file header at offset %d: %w", a.offset, err)
 	}
-	a.dataOff = pos
+	// 跳过第 4 至 8 字节的保留值
+	// Skip the padding in bytes 4 to 8
+	a.compressed = binary.LittleEndian.Uint32(header[0:4]) == 1
+	a.raw = binary.LittleEndian.Uint32(header[8:12])
+	a.size = binary.LittleEndian.Uint32(header[12:16])
+	a.dataOff = a.offset + int64(len(header))
 	a.initialized = true
 	return nil
 }
@@ -163,10 +214,45 @@ func (a *ArcPointer) Data() ([]byte, error) {
 	if err := a.ensure(); err != nil {
 		return nil, fmt.Errorf("failed to ensure pointer: %w", err)
 	}
-	if _, err := a.reader.Seek(a.dataOff, io.SeekStart); err != nil {
-		return nil, fmt.Errorf("failed to seek to data offset: %w", err)
+	data := make([]byte, a.size)
+	if err := a.readAt(data, a.dataOff); err != nil {
+		return nil, fmt.Errorf("failed to read data at offset %d: %w", a.dataOff, err)
+	}
+	return data, nil
+}
+
+// Open 打开存储数据的读取流；底层流实现 io.ReaderAt 时每个流拥有独立的 io.SectionReader，否则一次读入内存
+// Open opens a reader over the stored data; each reader gets its own io.SectionReader when the underlying stream implements io.ReaderAt, and the data is read into memory at once otherwise
+func (a *ArcPointer) Open() (io.ReadCloser, error) {
+	if err := a.ensure(); err != nil {
+		return nil, fmt.Errorf("failed to ensure pointer: %w", err)
+	}
+	if ra, ok := a.reader.R.(io.ReaderAt); ok {
+		return io.NopCloser(&exactSizeReader{r: io.NewSectionReader(ra, a.dataOff, int64(a.size)), remaining: int64(a.size)}), nil
+	}
+	data, err := a.Data()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// exactSizeReader 在底层流提前结束时返回 io.ErrUnexpectedEOF，使截断的 ARC 与 Data 一样报错
+// exactSizeReader returns io.ErrUnexpectedEOF when the underlying stream ends early, so a truncated ARC fails as it does with Data
+type exactSizeReader struct {
+	r         io.Reader
+	remaining int64 // 尚未读取的字节数 / Bytes not yet read
+}
+
+// Read 读取数据并在不足 remaining 字节时报告截断
+// Read reads data and reports truncation when fewer than remaining bytes are available
+func (e *exactSizeReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	e.remaining -= int64(n)
+	if err == io.EOF && e.remaining > 0 {
+		err = io.ErrUnexpectedEOF
 	}
-	return a.reader.ReadBytes(int64(a.size))
+	return n, err
 }
 
 // deflateCompress 生成 0x78 0x5E 头部和不带尾部的原始 DEFLATE 流
@@ -0,0 +1,270 @@
+package arc
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio/stream"
+)
+
+// streamOpener 表示可以流式读取未压缩数据的文件指针
+// streamOpener represents a file pointer whose uncompressed data can be streamed
+type streamOpener interface {
+	Open() (io.ReadCloser, error) // Open 打开未压缩数据流 / Open opens the uncompressed data stream
+}
+
+// countingWriter 统计写入底层写入器的字节数
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer // 底层写入器 / Underlying writer
+	n int64     // 已写入的字节数 / Bytes written so far
+}
+
+// Write 写入底层写入器并累加字节数
+// Write writes to the underlying writer and accumulates the byte count
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// contextReader 在每次读取前检查上下文，使大文件的复制也能及时取消
+// contextReader checks the context before every read so copying a large file can be cancelled promptly
+type contextReader struct {
+	ctx context.Context // 取消信号来源 / Source of the cancellation signal
+	r   io.Reader       // 底层读取器 / Underlying reader
+}
+
+// Read 在上下文未取消时从底层读取器读取
+// Read reads from the underlying reader while the context is not cancelled
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// compileCompressGlobs 将 CompressGlobs 编译为正则表达式，跳过空模式
+// compileCompressGlobs compiles CompressGlobs into regular expressions, skipping empty patterns
+func (arc *Arc) compileCompressGlobs() ([]*regexp.Regexp, error) {
+	var pats []*regexp.Regexp
+	for _, g := range arc.CompressGlobs {
+		if g == "" {
+			continue
+		}
+		regex, err := globToRegex(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern: %w", err)
+		}
+		pats = append(pats, regex)
+	}
+	return pats, nil
+}
+
+// shouldCompress 判断写入时是否压缩文件
+// 默认保留已有的压缩载荷，压缩通配模式还会选择未压缩文件或新文件进行压缩
+// shouldCompress reports whether a file is compressed when written
+// An existing compressed payload is preserved by default, and compression globs additionally select uncompressed or new files
+func shouldCompress(fl *File, pats []*regexp.Regexp) bool {
+	if fl.Ptr.Compressed() {
+		return true
+	}
+	for _, p := range pats {
+		if p.MatchString(fl.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFromDir 将 dirPath 下的全部文件按相对路径加入 Arc，已存在的同路径文件会被替换
+// 文件只记录磁盘路径，数据在写入 ARC 时才流式读取，返回加入的文件数量
+// AddFromDir adds every file under dirPath to the Arc at its relative path, replacing existing files at the same path
+// Only the disk path is recorded and the data is streamed when the ARC is written; the number of added files is returned
+func (arc *Arc) AddFromDir(ctx context.Context, dirPath string) (int, error) {
+	count := 0
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to walking %q: %w", path, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		// 计算 ARC 内使用的相对路径
+		// Calculate relative path for use within the ARC
+		rel, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return fmt.Errorf("failed to calculating relative path for %q: %w", path, err)
+		}
+
+		ptr, err := NewDiskPointer(path)
+		if err != nil {
+			return err
+		}
+		f := AddFileByPath(arc.Root, rel)
+		f.Arc = arc
+		f.Ptr = ptr
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to walk directory %q: %w", dirPath, err)
+	}
+	return count, nil
+}
+
+// openFileData 返回文件存储数据的读取器，可流式读取的指针不会被整体读入内存
+// openFileData returns a reader over a file's stored data without loading streamable pointers into memory
+func openFileData(fl *File) (io.ReadCloser, error) {
+	if opener, ok := fl.Ptr.(streamOpener); ok && !fl.Ptr.Compressed() {
+		return opener.Open()
+	}
+	data, err := fl.Ptr.Data()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// multiCloser 在关闭时依次关闭解压器和底层数据流
+// multiCloser closes the inflater and then the underlying data stream
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer // 按顺序关闭的对象 / Closers called in order
+}
+
+// Close 依次关闭全部对象并返回第一个错误
+// Close closes every closer in order and returns the first error
+func (m *multiCloser) Close() error {
+	var first error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openRawContent 返回文件原始内容的读取流，压缩条目跳过 0x78 0x5E 头部后边读边解压
+// openRawContent returns a reader over a file's raw content, inflating compressed entries on the fly after skipping the 0x78 0x5E header
+func openRawContent(ctx context.Context, fl *File) (io.ReadCloser, error) {
+	src, err := openFileData(fl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fl.RelativePath(), err)
+	}
+	in := &contextReader{ctx: ctx, r: src}
+	if !fl.Ptr.Compressed() {
+		return &multiCloser{Reader: in, closers: []io.Closer{src}}, nil
+	}
+	if _, err := io.CopyN(io.Discard, in, 2); err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("invalid deflate payload in %s: %w", fl.RelativePath(), err)
+	}
+	inflater := flate.NewReader(in)
+	return &multiCloser{Reader: inflater, closers: []io.Closer{inflater, src}}, nil
+}
+
+// Open 打开文件原始内容的读取流，压缩条目会边读边解压，调用者必须关闭返回的流
+// Open opens a reader over the file's raw content, inflating compressed entries on the fly; the caller must close it
+func (f *File) Open() (io.ReadCloser, error) {
+	return openRawContent(context.Background(), f)
+}
+
+// writeFileEntry 在写入器当前位置写出一个数据区条目
+// 未压缩数据按块复制并在需要时边读边压缩，随后回填头部中的原始大小和存储大小
+// 已压缩的载荷原样复制，原始大小取自指针
+// writeFileEntry writes one data-area entry at the writer's position
+// Uncompressed data is copied in chunks and deflated on the fly when requested, then the raw and stored sizes are patched into the header
+// An already compressed payload is copied as-is, taking the raw size from the pointer
+func writeFileEntry(ctx context.Context, writer *stream.BinaryWriter, fl *File, compress bool) error {
+	headerPos, err := writer.Tell()
+	if err != nil {
+		return fmt.Errorf("failed to get file position: %w", err)
+	}
+	flag := uint32(0)
+	if compress {
+		flag = 1
+	}
+	for _, v := range []uint32{flag, 0, 0, 0} {
+		if err := writer.WriteUInt32(v); err != nil {
+			return fmt.Errorf("failed to write file header: %w", err)
+		}
+	}
+
+	src, err := openFileData(fl)
+	if err != nil {
+		return fmt.Errorf("failed to read file data: %w", err)
+	}
+	defer src.Close()
+	in := &contextReader{ctx: ctx, r: src}
+
+	stored := &countingWriter{w: writer.W}
+	var rawLength int64
+	switch {
+	case fl.Ptr.Compressed():
+		if _, err := io.Copy(stored, in); err != nil {
+			return fmt.Errorf("failed to write file data %q: %w", fl.RelativePath(), err)
+		}
+		rawLength = int64(fl.Ptr.RawSize())
+	case compress:
+		// 与 deflateCompress 相同的 0x78 0x5E 头部加原始 DEFLATE 流
+		// The same 0x78 0x5E header plus raw DEFLATE stream as deflateCompress
+		if _, err := stored.Write([]byte{0x78, 0x5E}); err != nil {
+			return fmt.Errorf("failed to write file data %q: %w", fl.RelativePath(), err)
+		}
+		fw, err := flate.NewWriter(stored, flate.DefaultCompression)
+		if err != nil {
+			return fmt.Errorf("failed to create deflate writer: %w", err)
+		}
+		raw := &countingWriter{w: fw}
+		if _, err := io.Copy(raw, in); err != nil {
+			_ = fw.Close()
+			return fmt.Errorf("failed to compress file data %q: %w", fl.RelativePath(), err)
+		}
+		if err := fw.Close(); err != nil {
+			return fmt.Errorf("failed to close deflate writer: %w", err)
+		}
+		rawLength = raw.n
+	default:
+		if _, err := io.Copy(stored, in); err != nil {
+			return fmt.Errorf("failed to write file data %q: %w", fl.RelativePath(), err)
+		}
+		rawLength = stored.n
+	}
+
+	rawSize, err := checkedArcUint32Length(fmt.Sprintf("file %q raw size", fl.RelativePath()), rawLength)
+	if err != nil {
+		return err
+	}
+	storedSize, err := checkedArcUint32Length(fmt.Sprintf("file %q stored size", fl.RelativePath()), stored.n)
+	if err != nil {
+		return err
+	}
+
+	// 回填头部大小字段
+	// Patch the header size fields
+	endPos := headerPos + 16 + stored.n
+	if _, err := writer.Seek(headerPos+8, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to file header: %w", err)
+	}
+	if err := writer.WriteUInt32(rawSize); err != nil {
+		return fmt.Errorf("failed to write file header: %w", err)
+	}
+	if err := writer.WriteUInt32(storedSize); err != nil {
+		return fmt.Errorf("failed to write file header: %w", err)
+	}
+	if _, err := writer.Seek(endPos, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek past file data: %w", err)
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package arc
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -195,6 +199,433 @@ func TestArc(t *testing.T) {
 	}
 }
 
+func writePackTestTree(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "mod")
+	files := map[string][]byte{
+		"dress.menu":             bytes.Repeat([]byte("menu line\n"), 4096),
+		"model/dress.model":      bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7}, 1<<16),
+		"model/empty.tex":        {},
+		"script/ks/start.ks":     []byte("*start\n@jump storage=next.ks"),
+		"script/ks/notes.txt.ks": []byte("notes"),
+	}
+	for rel, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, files
+}
+
+func TestPackStreamsFilesAndCompressesByGlob(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "out", "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+
+	packed, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packed.GetFileList()) != len(files) {
+		t.Fatalf("packed %d files, want %d", len(packed.GetFileList()), len(files))
+	}
+	for rel, want := range files {
+		fl := packed.GetFile(rel)
+		if fl == nil {
+			t.Fatalf("%s missing from packed arc", rel)
+		}
+		wantCompressed := strings.HasSuffix(rel, ".menu") || strings.HasSuffix(rel, ".ks")
+		if fl.Ptr.Compressed() != wantCompressed {
+			t.Errorf("%s compressed = %t, want %t", rel, fl.Ptr.Compressed(), wantCompressed)
+		}
+		if fl.Ptr.RawSize() != uint32(len(want)) {
+			t.Errorf("%s raw size = %d, want %d", rel, fl.Ptr.RawSize(), len(want))
+		}
+		outPath := filepath.Join(t.TempDir(), "extracted")
+		if err := fl.Extract(outPath); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s content mismatch after packing", rel)
+		}
+	}
+	if menu := packed.GetFile("dress.menu"); menu.Ptr.Size() >= menu.Ptr.RawSize() {
+		t.Errorf("dress.menu stored %d bytes for %d raw bytes", menu.Ptr.Size(), menu.Ptr.RawSize())
+	}
+
+	// 重新写出时原样保留已压缩的载荷
+	// Rewriting keeps compressed payloads as-is
+	rewritten := filepath.Join(t.TempDir(), "rewritten.arc")
+	if err := packed.Dump(rewritten); err != nil {
+		t.Fatal(err)
+	}
+	reread, err := ReadArcFile(rewritten)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isArcEqual(packed, reread) {
+		t.Error("data mismatch after dumping a packed arc")
+	}
+}
+
+type cancellingPointer struct {
+	*MemoryPointer
+	cancel context.CancelFunc
+}
+
+func (c *cancellingPointer) Open() (io.ReadCloser, error) {
+	c.cancel()
+	data, _ := c.Data()
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestPackContextCancelledLeavesNoArc(t *testing.T) {
+	dir, _ := writePackTestTree(t)
+	outDir := t.TempDir()
+	arcPath := filepath.Join(outDir, "mod.arc")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := PackContext(ctx, dir, arcPath); !errors.Is(err, context.Canceled) {
+		t.Fatalf("PackContext error = %v, want context.Canceled", err)
+	}
+
+	// 在数据区写到一半时取消
+	// Cancel halfway through the data area
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	fs := NewArc("mod")
+	fs.CreateFile("a.tex", []byte("written before cancelling"))
+	fs.CreateFile("b.tex", nil).Ptr = &cancellingPointer{MemoryPointer: NewMemoryPointer([]byte("never written")), cancel: cancel}
+	if err := fs.DumpContext(ctx, arcPath); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DumpContext error = %v, want context.Canceled", err)
+	}
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("cancelled packing left %d files behind", len(entries))
+	}
+}
+
+func TestDumpSetsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no POSIX file modes")
+	}
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	fs := NewArc("mod")
+	fs.CreateFile("a.tex", []byte("data"))
+	if err := fs.Dump(arcPath); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(arcPath); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o644 {
+		t.Errorf("new arc mode = %v, want 0644", info.Mode().Perm())
+	}
+
+	// 替换已有文件时保留其权限
+	// Replacing an existing file keeps its mode
+	if err := os.Chmod(arcPath, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Dump(arcPath); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(arcPath); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o640 {
+		t.Errorf("replaced arc mode = %v, want 0640", info.Mode().Perm())
+	}
+}
+
+func TestDumpSyncsBeforeReplacing(t *testing.T) {
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	old := NewArc("mod")
+	old.CreateFile("a.tex", []byte("old"))
+	if err := old.Dump(arcPath); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(sync func(*os.File) error) { syncArcFile = sync }(syncArcFile)
+	syncErr := errors.New("sync failed")
+	syncArcFile = func(*os.File) error { return syncErr }
+	fs := NewArc("mod")
+	fs.CreateFile("a.tex", []byte("new"))
+	if err := fs.Dump(arcPath); !errors.Is(err, syncErr) {
+		t.Fatalf("dump error = %v, want the sync error", err)
+	}
+	after, err := os.ReadFile(arcPath)
+	if err != nil || !bytes.Equal(after, before) {
+		t.Fatalf("original arc changed after a failed sync: err=%v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(arcPath))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("directory entries = %v, err=%v", entries, err)
+	}
+}
+
+func readPackedFile(t *testing.T, fs *Arc, rel string) []byte {
+	t.Helper()
+	fl := fs.GetFile(rel)
+	if fl == nil {
+		t.Fatalf("%s missing from arc", rel)
+	}
+	data, err := fl.Ptr.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fl.Ptr.Compressed() {
+		if data, err = deflateDecompress(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return data
+}
+
+func TestUpdateAppendsChangesAndCompactReclaimsSpace(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patchDir := filepath.Join(t.TempDir(), "patch")
+	if err := os.MkdirAll(filepath.Join(patchDir, "model"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(patchDir, "model", "dress_alt.model"), []byte("alternate model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	newMenu := []byte("patched menu")
+	result, err := Update(context.Background(), arcPath, func(fs *Arc) error {
+		fs.CreateFile("dress.menu", newMenu)
+		if !fs.DeleteFile("model/dress.model") {
+			t.Error("model/dress.model not found for deletion")
+		}
+		_, err := fs.AddFromDir(context.Background(), patchDir)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AppendedFiles != 2 || result.ReusedFiles != 3 {
+		t.Fatalf("appended %d and reused %d files, want 2 and 3", result.AppendedFiles, result.ReusedFiles)
+	}
+	if result.DeadBytes < int64(len(files["model/dress.model"])) {
+		t.Fatalf("dead bytes = %d, want at least the deleted model", result.DeadBytes)
+	}
+
+	updated, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.GetFileList()) != len(files) {
+		t.Fatalf("updated arc has %d files, want %d", len(updated.GetFileList()), len(files))
+	}
+	if updated.GetFile("model/dress.model") != nil {
+		t.Fatal("deleted file is still listed")
+	}
+	if !updated.GetFile("dress.menu").Ptr.Compressed() {
+		t.Error("replaced .menu was not compressed")
+	}
+	if got := readPackedFile(t, updated, "dress.menu"); !bytes.Equal(got, newMenu) {
+		t.Errorf("dress.menu = %q, want %q", got, newMenu)
+	}
+	if got := readPackedFile(t, updated, "model/dress_alt.model"); string(got) != "alternate model" {
+		t.Errorf("model/dress_alt.model = %q", got)
+	}
+	if got := readPackedFile(t, updated, "script/ks/start.ks"); !bytes.Equal(got, files["script/ks/start.ks"]) {
+		t.Errorf("unchanged file = %q", got)
+	}
+
+	if err := Compact(context.Background(), arcPath); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Fatalf("compacted size %d is not below the original %d", after.Size(), before.Size())
+	}
+	compacted, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isArcEqual(updated, compacted) {
+		t.Error("data mismatch after compaction")
+	}
+}
+
+func TestUpdateFailureKeepsOriginalArc(t *testing.T) {
+	dir, _ := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	editErr := errors.New("edit failed")
+	if _, err := Update(context.Background(), arcPath, func(fs *Arc) error { return editErr }); !errors.Is(err, editErr) {
+		t.Fatalf("Update error = %v, want the edit error", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = Update(ctx, arcPath, func(fs *Arc) error {
+		fs.CreateFile("a.tex", []byte("appended before cancelling"))
+		fs.CreateFile("b.tex", nil).Ptr = &cancellingPointer{MemoryPointer: NewMemoryPointer([]byte("never written")), cancel: cancel}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Update error = %v, want context.Canceled", err)
+	}
+
+	current, err := os.ReadFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(current, original) {
+		t.Fatal("failed updates changed the ARC file")
+	}
+}
+
+func TestUpdateFailureAfterOffsetPatchKeepsMetadata(t *testing.T) {
+	dir, _ := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二次落盘发生在回填偏移之后
+	// The second sync happens after the offset is patched
+	syncErr := errors.New("sync failed")
+	syncs := 0
+	syncArcFile = func(f *os.File) error {
+		syncs++
+		if syncs == 2 {
+			return syncErr
+		}
+		return f.Sync()
+	}
+	defer func() { syncArcFile = func(f *os.File) error { return f.Sync() } }()
+	_, err := Update(context.Background(), arcPath, func(fs *Arc) error {
+		fs.CreateFile("added.tex", []byte("appended"))
+		return nil
+	})
+	if !errors.Is(err, syncErr) {
+		t.Fatalf("Update error = %v, want the sync error", err)
+	}
+
+	updated, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatalf("ARC unreadable after a failure past the offset patch: %v", err)
+	}
+	if got := readPackedFile(t, updated, "added.tex"); string(got) != "appended" {
+		t.Errorf("added.tex = %q", got)
+	}
+}
+
+func TestDiffArcsReportsChangesAndBuildsPatch(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	oldArc, err := ReadArcFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同样大小但内容不同、大小变化、新增和删除各一个
+	// One same-size edit, one resize, one addition, and one removal
+	model := append([]byte(nil), files["model/dress.model"]...)
+	model[len(model)-1] ^= 0xFF
+	edits := map[string][]byte{
+		"model/dress.model":  model,
+		"script/ks/start.ks": []byte("*start\n@jump storage=patched.ks"),
+		"model/new.tex":      []byte("new texture"),
+	}
+	for rel, data := range edits {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(rel)), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, "model", "empty.tex")); err != nil {
+		t.Fatal(err)
+	}
+	newArc := NewArc("mod")
+	if _, err := newArc.AddFromDir(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffArcs(context.Background(), oldArc, newArc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		path string
+		kind DiffKind
+	}{
+		{"model/dress.model", DiffModified},
+		{"model/empty.tex", DiffRemoved},
+		{"model/new.tex", DiffAdded},
+		{"script/ks/start.ks", DiffModified},
+	}
+	if len(diff.Entries) != len(want) {
+		t.Fatalf("diff entries = %+v, want %d entries", diff.Entries, len(want))
+	}
+	for i, w := range want {
+		if diff.Entries[i].Path != w.path || diff.Entries[i].Kind != w.kind {
+			t.Errorf("entry %d = %s %s, want %s %s", i, diff.Entries[i].Kind, diff.Entries[i].Path, w.kind, w.path)
+		}
+	}
+	// 已压缩的 .menu 与磁盘上的原始文件内容相同
+	// The compressed .menu matches the raw file on disk
+	if diff.Unchanged != 2 {
+		t.Errorf("unchanged = %d, want 2", diff.Unchanged)
+	}
+	if modified := diff.Entries[0]; modified.OldHash == "" || modified.OldHash == modified.NewHash {
+		t.Errorf("same-size modification hashes = %q %q", modified.OldHash, modified.NewHash)
+	}
+
+	patchPath := filepath.Join(t.TempDir(), "patch.arc")
+	if err := diff.PatchArc(newArc).Dump(patchPath); err != nil {
+		t.Fatal(err)
+	}
+	patch, err := ReadArcFile(patchPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := patch.GetFileList(); len(got) != 3 {
+		t.Fatalf("patch arc files = %v, want the 3 added and modified entries", got)
+	}
+	if got := readPackedFile(t, patch, "script/ks/start.ks"); !bytes.Equal(got, edits["script/ks/start.ks"]) {
+		t.Errorf("patched start.ks = %q", got)
+	}
+}
+
 func isArcEqual(a, b *Arc) bool {
 	if a == b {
 		return true
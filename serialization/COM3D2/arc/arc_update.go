@@ -0,0 +1,157 @@
+package arc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio/stream"
+)
+
+// UpdateResult 描述一次原地更新写入的内容和更新后 ARC 中的无用空间
+// UpdateResult describes what an in-place update wrote and the dead space left in the updated ARC
+type UpdateResult struct {
+	AppendedFiles int   `json:"appendedFiles"` // 追加到数据区的新增或修改文件数量 / Number of new or changed files appended to the data area
+	ReusedFiles   int   `json:"reusedFiles"`   // 沿用原有数据的文件数量 / Number of files whose existing data was reused
+	DeadBytes     int64 `json:"deadBytes"`     // 不再被引用的数据区和旧元数据字节数，可用 Compact 回收 / Bytes of unreferenced data and old metadata that Compact can reclaim
+}
+
+// Update 打开 arcPath，调用 edit 修改其文件系统，然后原地写回，无需完整重新打包
+// edit 可以用 CreateFile、AddFromDir、AddFileByPath 或 DeleteFile 增加、替换或删除文件
+// 未修改的文件沿用原有数据，新增或修改的文件数据以及新的哈希表和名称表追加到文件末尾，最后才回填元数据偏移
+// 因此在回填之前失败或取消时原 ARC 保持有效，追加的内容会被截断；回填开始后的失败保留追加的内容，使头部指向的元数据仍然存在
+// 被替换或删除的数据和旧元数据成为无用空间，可稍后调用 Compact 回收
+// Update opens arcPath, calls edit to modify its file system, and writes it back in place without a full repack
+// edit may use CreateFile, AddFromDir, AddFileByPath, or DeleteFile to add, replace, or delete files
+// Unchanged files keep their existing data; new or changed file data and fresh hash and name tables are appended to the end, and the metadata offset is patched last
+// A failure or cancellation before that patch therefore leaves the original ARC valid and truncates the appended bytes; a failure once the patch has started keeps them, so the metadata the header points at still exists
+// Replaced or deleted data and the old metadata become dead space that Compact can reclaim later
+func Update(ctx context.Context, arcPath string, edit func(*Arc) error) (result *UpdateResult, err error) {
+	src, err := os.Open(arcPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open .arc file: %w", err)
+	}
+	defer src.Close()
+	fs, err := ReadArc(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(fs); err != nil {
+		return nil, err
+	}
+	pats, err := fs.compileCompressGlobs()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dst, err := os.OpenFile(arcPath, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open .arc file for writing: %w", err)
+	}
+	defer dst.Close()
+	oldEnd, err := dst.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seek to end of ARC file: %w", err)
+	}
+	// 开始回填偏移后头部可能已指向追加的元数据，此时截断会破坏 ARC，因此只在此之前的失败时截断
+	// Once patching the offset starts the header may already point at the appended metadata and truncating would corrupt the ARC, so only earlier failures truncate
+	committed := false
+	defer func() {
+		if err != nil && !committed {
+			_ = dst.Truncate(oldEnd)
+		}
+	}()
+
+	writer := stream.NewBinaryWriter(dst)
+	baseOff := int64(len(arcHeader)) + 8
+	result = &UpdateResult{}
+	fileOffsets := map[uint64]int64{}
+	liveEntries := map[int64]struct{}{}
+	var liveBytes int64
+	for _, fl := range AllFiles(fs) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// 沿用仍来自本 ARC 的数据
+		// Reuse data that still comes from this ARC
+		if ptr, ok := fl.Ptr.(*ArcPointer); ok && ptr.reader.R == src {
+			if err := ptr.ensure(); err != nil {
+				return nil, fmt.Errorf("failed to read file header %q: %w", fl.RelativePath(), err)
+			}
+			fileOffsets[fl.UniqueID()] = ptr.offset - baseOff
+			if _, seen := liveEntries[ptr.offset]; !seen {
+				liveEntries[ptr.offset] = struct{}{}
+				liveBytes += 16 + int64(ptr.size)
+			}
+			result.ReusedFiles++
+			continue
+		}
+		pos, err := writer.Tell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file position: %w", err)
+		}
+		fileOffsets[fl.UniqueID()] = pos - baseOff
+		if err := writeFileEntry(ctx, writer, fl, shouldCompress(fl, pats)); err != nil {
+			return nil, err
+		}
+		end, err := writer.Tell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file position: %w", err)
+		}
+		liveBytes += end - pos
+		result.AppendedFiles++
+	}
+
+	metadataPos, err := writer.Tell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata position: %w", err)
+	}
+	if err := fs.writeMetadata(writer, fileOffsets); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// 先落盘新元数据，再回填偏移切换到新的目录树
+	// Persist the new metadata before patching the offset that switches to the new tree
+	if err := syncArcFile(dst); err != nil {
+		return nil, fmt.Errorf("failed to sync ARC file: %w", err)
+	}
+	committed = true
+	if _, err := writer.Seek(int64(len(arcHeader)), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to metadata offset: %w", err)
+	}
+	if err := writer.WriteInt64(metadataPos - baseOff); err != nil {
+		return nil, fmt.Errorf("failed to write metadata offset: %w", err)
+	}
+	if err := syncArcFile(dst); err != nil {
+		return nil, fmt.Errorf("failed to sync ARC file: %w", err)
+	}
+	result.DeadBytes = metadataPos - baseOff - liveBytes
+	return result, nil
+}
+
+// syncArcFile 将 ARC 文件落盘，测试可替换它以注入失败
+// syncArcFile flushes the ARC file to disk, and tests may replace it to inject failures
+var syncArcFile = func(f *os.File) error {
+	return f.Sync()
+}
+
+// Compact 重写 arcPath 以回收 Update 留下的无用空间，已压缩的载荷原样复制
+// Compact rewrites arcPath to reclaim the dead space left by Update, copying compressed payloads as-is
+func Compact(ctx context.Context, arcPath string) error {
+	src, err := os.Open(arcPath)
+	if err != nil {
+		return fmt.Errorf("cannot open .arc file: %w", err)
+	}
+	defer src.Close()
+	fs, err := ReadArc(src)
+	if err != nil {
+		return err
+	}
+	return fs.DumpContext(ctx, arcPath)
+}
@@ -0,0 +1,468 @@
+package arc
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio/stream"
+)
+
+// VerifyIssueKind 表示校验发现的问题类别
+// VerifyIssueKind represents the category of a problem found by verification
+type VerifyIssueKind string
+
+const (
+	VerifyHeader    VerifyIssueKind = "header"    // 文件头或元数据偏移无效 / Invalid header or metadata offset
+	VerifyMetadata  VerifyIssueKind = "metadata"  // 元数据块缺失、截断或无法解析 / Missing, truncated, or unparsable metadata block
+	VerifyNameTable VerifyIssueKind = "nameTable" // 名称表缺少名称或哈希与名称不符 / Name table lacks a name or a hash does not match its name
+	VerifyHashTable VerifyIssueKind = "hashTable" // 哈希表结构错误或两个哈希表不一致 / Malformed hash table or the two hash tables disagree
+	VerifyDuplicate VerifyIssueKind = "duplicate" // 同一完整路径出现多次 / The same full path appears more than once
+	VerifyCollision VerifyIssueKind = "collision" // 两个不同完整路径的唯一 ID 哈希相同 / Two different full paths share a unique ID hash
+	VerifyOffset    VerifyIssueKind = "offset"    // 条目偏移或存储大小超出数据区 / Entry offset or stored size lies outside the data area
+	VerifyOverlap   VerifyIssueKind = "overlap"   // 条目数据与其他条目部分重叠 / Entry data partially overlaps another entry
+	VerifySize      VerifyIssueKind = "size"      // 解压后大小与头部记录不符 / Decompressed size differs from the header
+	VerifyData      VerifyIssueKind = "data"      // 条目头部无效或数据无法读取或解压 / Invalid entry header, or data that cannot be read or decompressed
+)
+
+// VerifyIssue 描述一个校验问题，Path 为空表示问题属于整个 ARC
+// VerifyIssue describes one verification problem, with an empty Path for problems concerning the whole ARC
+type VerifyIssue struct {
+	Kind    VerifyIssueKind `json:"kind"`             // 问题类别 / Problem category
+	Path    string          `json:"path,omitempty"`   // 使用正斜杠的条目路径 / Entry path with forward slashes
+	Offset  int64           `json:"offset,omitempty"` // 相关数据在 ARC 文件中的绝对偏移 / Absolute offset of the related data in the ARC file
+	Message string          `json:"message"`          // 问题说明 / Problem description
+}
+
+// VerifyReport 是一次 ARC 完整性校验的结果
+// VerifyReport is the result of one ARC integrity verification
+type VerifyReport struct {
+	Files  int           `json:"files"`  // UTF-16 哈希表中的文件条目数 / Number of file entries in the UTF-16 hash table
+	Dirs   int           `json:"dirs"`   // UTF-16 哈希表中的目录数，含根目录 / Number of directories in the UTF-16 hash table, including the root
+	Issues []VerifyIssue `json:"issues"` // 发现的问题，按发现顺序排列 / Problems found, in discovery order
+}
+
+// OK 报告校验是否未发现问题
+// OK reports whether verification found no problems
+func (r *VerifyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// addf 追加一条格式化的问题
+// addf appends one formatted problem
+func (r *VerifyReport) addf(kind VerifyIssueKind, p string, offset int64, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, VerifyIssue{Kind: kind, Path: p, Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+// verifyEntry 是从 UTF-16 哈希表收集的一个文件条目
+// verifyEntry is one file entry collected from the UTF-16 hash table
+type verifyEntry struct {
+	path   string // 使用正斜杠的完整路径 / Full path with forward slashes
+	offset int64  // 条目头部的绝对偏移 / Absolute offset of the entry header
+}
+
+// VerifyFile 打开并校验 arcPath，参见 Verify
+// VerifyFile opens and verifies arcPath; see Verify
+func VerifyFile(ctx context.Context, arcPath string) (*VerifyReport, error) {
+	f, err := os.Open(arcPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open .arc file: %w", err)
+	}
+	defer f.Close()
+	return Verify(ctx, f)
+}
+
+// Verify 遍历整个 ARC 并报告每个条目的问题，而不是像 ReadArc 那样在第一个错误处停止
+// 它用 NameHashUTF16 校验名称表，用 NameHashUTF8 把 UTF-8 哈希表与 UTF-16 哈希表逐目录比对，用 UniqueIDHash 检测重复的完整路径，
+// 检查条目偏移是否越界或相互重叠，并解压每个条目以核对头部记录的原始大小
+// 文件头或元数据损坏到无法继续时报告中只包含已发现的问题；只有读取失败或 ctx 取消时才返回错误
+// Verify walks the whole ARC and reports problems per entry instead of stopping at the first error like ReadArc
+// It checks the name table with NameHashUTF16, compares the UTF-8 hash table against the UTF-16 one directory by directory with NameHashUTF8, detects duplicate full paths with UniqueIDHash,
+// checks entry offsets for out-of-range or overlapping data, and decompresses every entry to check the raw size recorded in its header
+// When the header or metadata is too damaged to continue, the report holds the problems found so far; an error is returned only for read failures or cancellation of ctx
+func Verify(ctx context.Context, rs io.ReadSeeker) (*VerifyReport, error) {
+	report := &VerifyReport{Issues: []VerifyIssue{}}
+	fileSize, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ARC file size: %w", err)
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to ARC header: %w", err)
+	}
+	reader := stream.NewBinaryReader(rs)
+	baseOff := int64(len(arcHeader)) + 8
+	if fileSize < baseOff {
+		report.addf(VerifyHeader, "", 0, "file is %d bytes, shorter than the %d-byte header", fileSize, baseOff)
+		return report, nil
+	}
+	header, err := reader.ReadBytes(int64(len(arcHeader)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ARC header: %w", err)
+	}
+	if !bytes.Equal(header, arcHeader) {
+		if bytes.HasPrefix(header, encArcHeader) {
+			report.addf(VerifyHeader, "", 0, "ARC is encrypted")
+		} else {
+			report.addf(VerifyHeader, "", 0, "invalid ARC header")
+		}
+		return report, nil
+	}
+	metadataOffset, err := reader.ReadInt64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata offset: %w", err)
+	}
+	if metadataOffset < 0 || metadataOffset > fileSize-baseOff {
+		report.addf(VerifyHeader, "", int64(len(arcHeader)), "metadata offset %d points outside the %d-byte file", metadataOffset, fileSize)
+		return report, nil
+	}
+	metadataPos := baseOff + metadataOffset
+
+	utf16Data, utf8Data, nameData, ok, err := readVerifyMetadata(reader, report, metadataPos, fileSize)
+	if err != nil || !ok {
+		return report, err
+	}
+	names, err := readNameTable(stream.NewBinaryReader(bytes.NewReader(nameData)))
+	if err != nil {
+		report.addf(VerifyNameTable, "", 0, "cannot parse name table: %v", err)
+		return report, nil
+	}
+	nameHashes := make([]uint64, 0, len(names))
+	for h := range names {
+		nameHashes = append(nameHashes, h)
+	}
+	sort.Slice(nameHashes, func(i, j int) bool { return nameHashes[i] < nameHashes[j] })
+	for _, h := range nameHashes {
+		if want := NameHashUTF16(names[h]); want != h {
+			report.addf(VerifyNameTable, "", 0, "name %q is stored under hash %016x but hashes to %016x", names[h], h, want)
+		}
+	}
+	utf16HT, err := readHashTable(stream.NewBinaryReader(bytes.NewReader(utf16Data)))
+	if err != nil {
+		report.addf(VerifyHashTable, "", 0, "cannot parse UTF-16 hash table: %v", err)
+		return report, nil
+	}
+
+	v := &arcVerifier{report: report, names: names, seen: map[uint64]string{}}
+	v.walk(utf16HT, "", nil)
+	if utf8HT, err := readHashTable(stream.NewBinaryReader(bytes.NewReader(utf8Data))); err != nil {
+		report.addf(VerifyHashTable, "", 0, "cannot parse UTF-8 hash table: %v", err)
+	} else {
+		v.compare(utf16HT, utf8HT, "")
+	}
+	if err := v.verifyData(ctx, rs, baseOff, metadataPos); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// readVerifyMetadata 读取元数据区的三个块，问题写入报告，缺少任一块时 ok 为 false
+// readVerifyMetadata reads the three metadata blocks, recording problems in the report, with ok false when any block is missing
+func readVerifyMetadata(reader *stream.BinaryReader, report *VerifyReport, metadataPos int64, fileSize int64) (utf16Data, utf8Data, nameData []byte, ok bool, err error) {
+	if _, err := reader.Seek(metadataPos, io.SeekStart); err != nil {
+		return nil, nil, nil, false, fmt.Errorf("failed to seek to metadata: %w", err)
+	}
+	pos := metadataPos
+	for utf16Data == nil || utf8Data == nil || nameData == nil {
+		if fileSize-pos < 12 {
+			report.addf(VerifyMetadata, "", pos, "metadata ends before all of the UTF-16 hash table, UTF-8 hash table, and name table blocks")
+			return nil, nil, nil, false, nil
+		}
+		blockType, err := reader.ReadInt32()
+		if err != nil {
+			return nil, nil, nil, false, fmt.Errorf("failed to read block type: %w", err)
+		}
+		blockSize, err := reader.ReadInt64()
+		if err != nil {
+			return nil, nil, nil, false, fmt.Errorf("failed to read block size: %w", err)
+		}
+		if blockSize < 0 || blockSize > fileSize-pos-12 {
+			report.addf(VerifyMetadata, "", pos, "metadata block %d size %d exceeds the remaining %d bytes", blockType, blockSize, fileSize-pos-12)
+			return nil, nil, nil, false, nil
+		}
+		data, err := reader.ReadBytes(blockSize)
+		if err != nil {
+			return nil, nil, nil, false, fmt.Errorf("failed to read metadata block %d: %w", blockType, err)
+		}
+		switch blockType {
+		case 0:
+			utf16Data = data
+		case 1:
+			utf8Data = data
+		case 3:
+			if nameData, ok = verifyNameBlock(report, pos, data); !ok {
+				return nil, nil, nil, false, nil
+			}
+		default:
+			report.addf(VerifyMetadata, "", pos, "unknown metadata block type %d", blockType)
+			return nil, nil, nil, false, nil
+		}
+		pos += 12 + blockSize
+	}
+	return utf16Data, utf8Data, nameData, true, nil
+}
+
+// verifyNameBlock 解码名称表块内嵌的数据区条目并核对其大小
+// verifyNameBlock decodes the data-area entry embedded in the name table block and checks its sizes
+func verifyNameBlock(report *VerifyReport, pos int64, block []byte) ([]byte, bool) {
+	if len(block) < 16 {
+		report.addf(VerifyMetadata, "", pos, "name table block is %d bytes, shorter than its 16-byte entry header", len(block))
+		return nil, false
+	}
+	flag := binary.LittleEndian.Uint32(block[0:])
+	rawSize := binary.LittleEndian.Uint32(block[8:])
+	storedSize := binary.LittleEndian.Uint32(block[12:])
+	if int64(storedSize) > int64(len(block)-16) {
+		report.addf(VerifyMetadata, "", pos, "name table stored size %d exceeds its %d-byte block", storedSize, len(block)-16)
+		return nil, false
+	}
+	data := block[16 : 16+int(storedSize)]
+	if flag == 1 {
+		dec, err := deflateDecompress(data)
+		if err != nil {
+			report.addf(VerifyMetadata, "", pos, "cannot decompress name table: %v", err)
+			return nil, false
+		}
+		data = dec
+	}
+	if int64(len(data)) != int64(rawSize) {
+		report.addf(VerifySize, "", pos, "name table is %d bytes after decompression but its header records %d", len(data), rawSize)
+	}
+	return data, true
+}
+
+// verifyUniqueIDHash 计算校验时用于查重的唯一 ID 哈希，测试可替换它以构造碰撞
+// verifyUniqueIDHash computes the unique ID hash used to find repeated paths during verification, and tests may replace it to force collisions
+var verifyUniqueIDHash = UniqueIDHash
+
+// arcVerifier 保存遍历哈希表时的校验状态
+// arcVerifier holds the verification state while walking the hash tables
+type arcVerifier struct {
+	report  *VerifyReport
+	names   map[uint64]string // 名称表 / Name table
+	seen    map[uint64]string // UniqueIDHash 到已见完整路径 / UniqueIDHash to the full path already seen
+	entries []verifyEntry     // 收集的文件条目 / Collected file entries
+}
+
+// name 按哈希查找名称，缺失时记录问题
+// name looks a hash up in the name table, recording a problem when it is missing
+func (v *arcVerifier) name(hash uint64, dir string, what string) (string, bool) {
+	name, ok := v.names[hash]
+	if !ok {
+		v.report.addf(VerifyNameTable, dir, 0, "%s hash %016x has no name in the name table", what, hash)
+	}
+	return name, ok
+}
+
+// walk 递归检查 UTF-16 哈希表的深度、父链、子表以及重复或哈希碰撞的路径，并收集文件条目
+// walk recursively checks the depth, parent chain, subtables, and duplicate or colliding paths of the UTF-16 hash table and collects the file entries
+func (v *arcVerifier) walk(t *hashTable, dir string, parents []uint64) {
+	v.report.Dirs++
+	if int(t.Depth) != len(parents) {
+		v.report.addf(VerifyHashTable, dir, 0, "directory depth is %d but it is nested %d levels deep", t.Depth, len(parents))
+	}
+	if !slices.Equal(t.ParentsID, parents) {
+		v.report.addf(VerifyHashTable, dir, 0, "parent hashes %x do not match the enclosing directories %x", t.ParentsID, parents)
+	}
+	for _, fe := range t.FileEntries {
+		name, ok := v.name(fe.Hash, dir, "file")
+		if !ok {
+			continue
+		}
+		p := path.Join(dir, name)
+		v.report.Files++
+		id := verifyUniqueIDHash(p)
+		if prev, ok := v.seen[id]; !ok {
+			v.seen[id] = p
+		} else if prev == p {
+			v.report.addf(VerifyDuplicate, p, 0, "path appears more than once")
+		} else {
+			v.report.addf(VerifyCollision, p, 0, "unique ID hash %016x collides with %s", id, prev)
+		}
+		v.entries = append(v.entries, verifyEntry{path: p, offset: fe.Offset})
+	}
+	used := make([]bool, len(t.SubDirEntries))
+	for _, de := range t.DirEntries {
+		name, ok := v.name(de.Hash, dir, "directory")
+		if !ok {
+			continue
+		}
+		p := path.Join(dir, name)
+		sub := -1
+		for i, st := range t.SubDirEntries {
+			if st.ID == de.Hash && !used[i] {
+				sub = i
+				break
+			}
+		}
+		if sub < 0 {
+			v.report.addf(VerifyHashTable, p, 0, "directory has no subtable")
+			continue
+		}
+		used[sub] = true
+		v.walk(t.SubDirEntries[sub], p, append(append([]uint64(nil), parents...), t.ID))
+	}
+}
+
+// compare 逐目录比对 UTF-8 哈希表与 UTF-16 哈希表，UTF-8 哈希由 UTF-16 名称表中的名称计算
+// compare checks the UTF-8 hash table against the UTF-16 one directory by directory, computing UTF-8 hashes from names in the UTF-16 name table
+func (v *arcVerifier) compare(t16 *hashTable, t8 *hashTable, dir string) {
+	files8 := map[uint64]int64{}
+	for _, fe := range t8.FileEntries {
+		files8[fe.Hash] = fe.Offset
+	}
+	if len(t8.FileEntries) != len(t16.FileEntries) {
+		v.report.addf(VerifyHashTable, dir, 0, "UTF-8 hash table lists %d files but the UTF-16 table lists %d", len(t8.FileEntries), len(t16.FileEntries))
+	}
+	for _, fe := range t16.FileEntries {
+		name, ok := v.names[fe.Hash]
+		if !ok {
+			continue
+		}
+		p := path.Join(dir, name)
+		offset, ok := files8[NameHashUTF8(name)]
+		switch {
+		case !ok:
+			v.report.addf(VerifyHashTable, p, 0, "file is missing from the UTF-8 hash table")
+		case offset != fe.Offset:
+			v.report.addf(VerifyHashTable, p, 0, "UTF-8 hash table offset %d differs from UTF-16 offset %d", offset, fe.Offset)
+		}
+	}
+	if len(t8.DirEntries) != len(t16.DirEntries) {
+		v.report.addf(VerifyHashTable, dir, 0, "UTF-8 hash table lists %d directories but the UTF-16 table lists %d", len(t8.DirEntries), len(t16.DirEntries))
+	}
+	for _, de := range t16.DirEntries {
+		name, ok := v.names[de.Hash]
+		if !ok {
+			continue
+		}
+		var sub16, sub8 *hashTable
+		for _, st := range t16.SubDirEntries {
+			if st.ID == de.Hash {
+				sub16 = st
+				break
+			}
+		}
+		h8 := NameHashUTF8(name)
+		for _, st := range t8.SubDirEntries {
+			if st.ID == h8 {
+				sub8 = st
+				break
+			}
+		}
+		p := path.Join(dir, name)
+		if sub8 == nil {
+			v.report.addf(VerifyHashTable, p, 0, "directory is missing from the UTF-8 hash table")
+			continue
+		}
+		if sub16 != nil {
+			v.compare(sub16, sub8, p)
+		}
+	}
+}
+
+// verifyData 按偏移顺序检查每个条目的范围和重叠，并解压核对原始大小；偏移相同的条目共享数据，只解压一次
+// verifyData checks the range and overlap of every entry in offset order and decompresses it to check the raw size; entries at the same offset share data and are decompressed once
+func (v *arcVerifier) verifyData(ctx context.Context, rs io.ReadSeeker, baseOff int64, metadataPos int64) error {
+	entries := v.entries
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+	var prevStart, prevEnd int64 = -1, baseOff
+	var prevPath string
+	var prevIssues []VerifyIssue
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := baseOff + e.offset
+		if e.offset < 0 || start > metadataPos-16 {
+			v.report.addf(VerifyOffset, e.path, start, "entry header at offset %d lies outside the data area [%d, %d)", start, baseOff, metadataPos)
+			continue
+		}
+		if start == prevStart {
+			// 与前一条目共享数据，沿用其数据问题
+			// Shares data with the previous entry and inherits its data problems
+			for _, issue := range prevIssues {
+				issue.Path = e.path
+				v.report.Issues = append(v.report.Issues, issue)
+			}
+			continue
+		}
+		if start < prevEnd && prevStart >= 0 {
+			v.report.addf(VerifyOverlap, e.path, start, "entry data overlaps %s, which ends at offset %d", prevPath, prevEnd)
+		}
+		before := len(v.report.Issues)
+		end, err := v.verifyEntryData(ctx, rs, e, start, metadataPos)
+		if err != nil {
+			return err
+		}
+		prevIssues = append([]VerifyIssue(nil), v.report.Issues[before:]...)
+		prevStart, prevPath = start, e.path
+		if end > prevEnd {
+			prevEnd = end
+		}
+	}
+	return nil
+}
+
+// verifyEntryData 检查一个条目的头部并解压其数据，返回数据末尾的绝对偏移
+// verifyEntryData checks one entry header and decompresses its data, returning the absolute offset where the data ends
+func (v *arcVerifier) verifyEntryData(ctx context.Context, rs io.ReadSeeker, e verifyEntry, start int64, metadataPos int64) (int64, error) {
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek to %s: %w", e.path, err)
+	}
+	var hdr [16]byte
+	if _, err := io.ReadFull(rs, hdr[:]); err != nil {
+		return 0, fmt.Errorf("failed to read entry header of %s: %w", e.path, err)
+	}
+	flag := binary.LittleEndian.Uint32(hdr[0:])
+	rawSize := binary.LittleEndian.Uint32(hdr[8:])
+	storedSize := binary.LittleEndian.Uint32(hdr[12:])
+	end := start + 16 + int64(storedSize)
+	if flag > 1 {
+		v.report.addf(VerifyData, e.path, start, "unknown compression flag %d", flag)
+		return start + 16, nil
+	}
+	if end > metadataPos {
+		v.report.addf(VerifyOffset, e.path, start, "stored size %d runs past the data area end at offset %d", storedSize, metadataPos)
+		return start + 16, nil
+	}
+	if flag == 0 {
+		if rawSize != storedSize {
+			v.report.addf(VerifySize, e.path, start, "uncompressed entry stores %d bytes but records raw size %d", storedSize, rawSize)
+		}
+		return end, nil
+	}
+	in := &contextReader{ctx: ctx, r: io.LimitReader(rs, int64(storedSize))}
+	if _, err := io.CopyN(io.Discard, in, 2); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		v.report.addf(VerifyData, e.path, start, "compressed entry is too short for a deflate header")
+		return end, nil
+	}
+	inflater := flate.NewReader(in)
+	defer inflater.Close()
+	n, err := io.Copy(io.Discard, inflater)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		var corrupt flate.CorruptInputError
+		if !errors.As(err, &corrupt) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, fmt.Errorf("failed to read %s: %w", e.path, err)
+		}
+		v.report.addf(VerifyData, e.path, start, "cannot decompress entry after %d bytes: %v", n, err)
+		return end, nil
+	}
+	if n != int64(rawSize) {
+		v.report.addf(VerifySize, e.path, start, "entry decompresses to %d bytes but its header records %d", n, rawSize)
+	}
+	return end, nil
+}
@@ -0,0 +1,130 @@
+package arc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyReportsPerEntryCorruption(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := Pack(dir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	report, err := VerifyFile(context.Background(), arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Files != len(files) || report.Dirs != 4 {
+		t.Fatalf("clean arc report: %+v", report)
+	}
+
+	data, err := os.ReadFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := ReadArcBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offsetOf := func(rel string) int64 {
+		return packed.GetFile(rel).Ptr.(*ArcPointer).offset
+	}
+	// 篡改压缩条目记录的原始大小、截断压缩流，并让未压缩条目的存储大小越过数据区
+	// Tamper with a compressed entry's recorded raw size, cut a compressed stream short, and push an uncompressed entry's stored size past the data area
+	binary.LittleEndian.PutUint32(data[offsetOf("dress.menu")+8:], 7)
+	start := offsetOf(filepath.Join("script", "ks", "start.ks"))
+	stored := binary.LittleEndian.Uint32(data[start+12:])
+	copy(data[start+16+2:start+16+int64(stored)], bytes.Repeat([]byte{0xff}, int(stored)))
+	binary.LittleEndian.PutUint32(data[offsetOf(filepath.Join("model", "dress.model"))+12:], 1<<30)
+
+	report, err = Verify(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]VerifyIssueKind{}
+	for _, issue := range report.Issues {
+		got[issue.Path] = issue.Kind
+	}
+	want := map[string]VerifyIssueKind{
+		"dress.menu":         VerifySize,
+		"script/ks/start.ks": VerifyData,
+		"model/dress.model":  VerifyOffset,
+	}
+	for p, kind := range want {
+		if got[p] != kind {
+			t.Errorf("%s: got issue kind %q, want %q (issues %+v)", p, got[p], kind, report.Issues)
+		}
+	}
+	if len(report.Issues) != len(want) {
+		t.Errorf("got %d issues, want %d: %+v", len(report.Issues), len(want), report.Issues)
+	}
+}
+
+func TestVerifyDetectsHashTableMismatch(t *testing.T) {
+	fs := NewArc("mod")
+	fs.CreateFile("menu/dress.menu", []byte("menu"))
+	fs.CreateFile("menu/dress.tex", []byte("tex"))
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := fs.Dump(arcPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// UTF-8 哈希表中 dress.tex 的文件条目哈希改为其他值
+	// Replace the file-entry hash of dress.tex in the UTF-8 hash table
+	var hash [8]byte
+	binary.LittleEndian.PutUint64(hash[:], NameHashUTF8("dress.tex"))
+	metadataPos := int64(len(arcHeader)) + 8 + int64(binary.LittleEndian.Uint64(data[len(arcHeader):]))
+	utf16Size := int64(binary.LittleEndian.Uint64(data[metadataPos+4:]))
+	utf8Start := metadataPos + 12 + utf16Size + 12
+	i := bytes.Index(data[utf8Start:], hash[:])
+	if i < 0 {
+		t.Fatal("UTF-8 hash of dress.tex not found")
+	}
+	binary.LittleEndian.PutUint64(data[utf8Start+int64(i):], NameHashUTF8("other.tex"))
+
+	report, err := Verify(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != VerifyHashTable || report.Issues[0].Path != "menu/dress.tex" {
+		t.Fatalf("issues: %+v", report.Issues)
+	}
+}
+
+func TestVerifyReportsUniqueIDHashCollision(t *testing.T) {
+	fs := NewArc("mod")
+	fs.CreateFile("menu/dress.menu", []byte("menu"))
+	fs.CreateFile("menu/dress.tex", []byte("tex"))
+	arcPath := filepath.Join(t.TempDir(), "mod.arc")
+	if err := fs.Dump(arcPath); err != nil {
+		t.Fatal(err)
+	}
+	// 让两个不同路径得到相同的唯一 ID 哈希
+	// Make two different paths share one unique ID hash
+	defer func(hash func(string) uint64) { verifyUniqueIDHash = hash }(verifyUniqueIDHash)
+	verifyUniqueIDHash = func(fullName string) uint64 {
+		if fullName == "menu/dress.tex" {
+			return UniqueIDHash("menu/dress.menu")
+		}
+		return UniqueIDHash(fullName)
+	}
+
+	report, err := VerifyFile(context.Background(), arcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != VerifyCollision {
+		t.Fatalf("issues: %+v", report.Issues)
+	}
+	if issue := report.Issues[0]; issue.Path != "menu/dress.tex" && issue.Path != "menu/dress.menu" {
+		t.Errorf("collision reported for %q", issue.Path)
+	}
+}
@@ -0,0 +1,200 @@
+package arc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VFS 是按优先级叠加多个 ARC 和散装目录的只读虚拟文件系统
+// 与游戏一样按不区分大小写的裸文件名查找，后挂载的来源覆盖先挂载的来源
+// VFS is a read-only virtual file system layering several ARCs and loose directories by priority
+// Like the game it looks files up by case-insensitive bare file name, and later mounts override earlier ones
+type VFS struct {
+	sources []*VFSSource           // 按挂载顺序排列的来源 / Sources in mount order
+	byName  map[string][]*VFSEntry // 小写裸文件名到候选条目的映射，优先者在前 / Lowercase bare file name to candidate entries, winner first
+	closers []io.Closer            // Close 时关闭的文件句柄 / File handles closed by Close
+}
+
+// VFSSource 表示一个挂载到 VFS 的 ARC 或目录
+// VFSSource represents an ARC or directory mounted into the VFS
+type VFSSource struct {
+	Name     string // 来源名称，通常是 ARC 或目录路径 / Source name, usually the ARC or directory path
+	Priority int    // 挂载顺序，数值越大优先级越高 / Mount order; higher values take precedence
+	Arc      *Arc   // 来源的文件系统，目录以磁盘指针载入 / File system of the source, with directories loaded as disk pointers
+}
+
+// VFSEntry 表示某个来源中的一个文件
+// VFSEntry represents one file in one source
+type VFSEntry struct {
+	Name   string     // 原始大小写的裸文件名 / Bare file name in its original case
+	Path   string     // 来源内使用正斜杠的相对路径 / Relative path inside the source with forward slashes
+	Source *VFSSource // 文件所在来源 / Source holding the file
+	File   *File      // 来源中的文件节点 / File node inside the source
+}
+
+// VFSShadow 描述一个被多个条目提供的文件名，以及胜出和被遮蔽的条目
+// VFSShadow describes a file name provided by several entries, with the winning and the shadowed entries
+type VFSShadow struct {
+	Name     string      // 小写裸文件名 / Lowercase bare file name
+	Winner   *VFSEntry   // 游戏实际使用的条目 / Entry the game actually uses
+	Shadowed []*VFSEntry // 被覆盖的条目，优先级从高到低 / Overridden entries from highest to lowest priority
+}
+
+// NewVFS 创建一个没有来源的 VFS
+// NewVFS creates a VFS without sources
+func NewVFS() *VFS {
+	return &VFS{byName: map[string][]*VFSEntry{}}
+}
+
+// vfsKey 把路径或文件名规范化为游戏使用的小写裸文件名
+// vfsKey normalizes a path or file name to the lowercase bare file name the game uses
+func vfsKey(name string) string {
+	return strings.ToLower(path.Base(filepath.ToSlash(name)))
+}
+
+// MountArc 以当前最高优先级挂载一个已读取的 Arc
+// 同一来源中的同名文件按路径顺序，先出现的胜出
+// MountArc mounts an Arc that has already been read at the current highest priority
+// Among same-named files within one source, the first in path order wins
+func (v *VFS) MountArc(name string, fs *Arc) *VFSSource {
+	src := &VFSSource{Name: name, Priority: len(v.sources), Arc: fs}
+	v.sources = append(v.sources, src)
+
+	added := map[string][]*VFSEntry{}
+	var keys []string
+	for _, fl := range AllFiles(fs) {
+		key := vfsKey(fl.Name)
+		if _, ok := added[key]; !ok {
+			keys = append(keys, key)
+		}
+		added[key] = append(added[key], &VFSEntry{
+			Name:   fl.Name,
+			Path:   filepath.ToSlash(fl.RelativePath()),
+			Source: src,
+			File:   fl,
+		})
+	}
+	for _, key := range keys {
+		entries := added[key]
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+		v.byName[key] = append(entries, v.byName[key]...)
+	}
+	return src
+}
+
+// MountArcFile 延迟读取 ARC 文件并以当前最高优先级挂载，文件句柄在 Close 时关闭
+// MountArcFile lazily reads an ARC file and mounts it at the current highest priority; the file handle is closed by Close
+func (v *VFS) MountArcFile(arcPath string) (*VFSSource, error) {
+	f, err := os.Open(arcPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open .arc file: %w", err)
+	}
+	fs, err := ReadArc(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", arcPath, err)
+	}
+	v.closers = append(v.closers, f)
+	return v.MountArc(arcPath, fs), nil
+}
+
+// MountDir 以当前最高优先级挂载散装目录，例如游戏的 Mod 文件夹，文件数据在读取时才从磁盘读入
+// MountDir mounts a loose directory such as the game's Mod folder at the current highest priority; file data is read from disk on access
+func (v *VFS) MountDir(ctx context.Context, dirPath string) (*VFSSource, error) {
+	fs := NewArc(filepath.Base(dirPath))
+	if _, err := fs.AddFromDir(ctx, dirPath); err != nil {
+		return nil, err
+	}
+	return v.MountArc(dirPath, fs), nil
+}
+
+// Sources 返回按挂载顺序排列的来源
+// Sources returns the sources in mount order
+func (v *VFS) Sources() []*VFSSource {
+	return append([]*VFSSource(nil), v.sources...)
+}
+
+// Lookup 按不区分大小写的裸文件名返回胜出的条目，name 含目录时只使用最后一段
+// Lookup returns the winning entry for a case-insensitive bare file name, using only the last element when name has directories
+func (v *VFS) Lookup(name string) (*VFSEntry, bool) {
+	entries := v.byName[vfsKey(name)]
+	if len(entries) == 0 {
+		return nil, false
+	}
+	return entries[0], true
+}
+
+// Candidates 返回提供该文件名的全部条目，胜出者在前
+// Candidates returns every entry providing the file name, winner first
+func (v *VFS) Candidates(name string) []*VFSEntry {
+	return append([]*VFSEntry(nil), v.byName[vfsKey(name)]...)
+}
+
+// Names 返回全部小写裸文件名并排序
+// Names returns every lowercase bare file name, sorted
+func (v *VFS) Names() []string {
+	names := make([]string, 0, len(v.byName))
+	for name := range v.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shadowed 返回所有由多个条目提供的文件名，按名称排序
+// Shadowed returns every file name provided by more than one entry, sorted by name
+func (v *VFS) Shadowed() []VFSShadow {
+	var out []VFSShadow
+	for _, name := range v.Names() {
+		entries := v.byName[name]
+		if len(entries) < 2 {
+			continue
+		}
+		out = append(out, VFSShadow{
+			Name:     name,
+			Winner:   entries[0],
+			Shadowed: append([]*VFSEntry(nil), entries[1:]...),
+		})
+	}
+	return out
+}
+
+// Open 打开胜出条目的原始内容，调用者必须关闭返回的流
+// Open opens the raw content of the winning entry; the caller must close it
+func (v *VFS) Open(name string) (io.ReadCloser, error) {
+	entry, ok := v.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s: %w", name, os.ErrNotExist)
+	}
+	return entry.File.Open()
+}
+
+// ReadFile 读取胜出条目的全部原始内容
+// ReadFile reads the whole raw content of the winning entry
+func (v *VFS) ReadFile(name string) ([]byte, error) {
+	r, err := v.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Close 关闭 MountArcFile 打开的全部文件句柄
+// Close closes every file handle opened by MountArcFile
+func (v *VFS) Close() error {
+	var first error
+	for _, c := range v.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	v.closers = nil
+	return first
+}
@@ -0,0 +1,89 @@
+package arc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVFSResolvesBareNamesByMountPriority(t *testing.T) {
+	dir, files := writePackTestTree(t)
+	baseArc := filepath.Join(t.TempDir(), "base.arc")
+	if err := Pack(dir, baseArc); err != nil {
+		t.Fatal(err)
+	}
+	update := NewArc("update")
+	update.CreateFile("menu/Dress.MENU", []byte("updated menu"))
+	modDir := filepath.Join(t.TempDir(), "Mod")
+	if err := os.MkdirAll(filepath.Join(modDir, "dress"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modDir, "dress", "dress.model"), []byte("mod model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vfs := NewVFS()
+	defer vfs.Close()
+	if _, err := vfs.MountArcFile(baseArc); err != nil {
+		t.Fatal(err)
+	}
+	vfs.MountArc("update", update)
+	if _, err := vfs.MountDir(context.Background(), modDir); err != nil {
+		t.Fatal(err)
+	}
+
+	menu, ok := vfs.Lookup("DRESS.menu")
+	if !ok || menu.Source.Name != "update" || menu.Path != "menu/Dress.MENU" {
+		t.Fatalf("dress.menu resolved to %+v", menu)
+	}
+	data, err := vfs.ReadFile("some/dir/dress.model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "mod model" {
+		t.Fatalf("dress.model = %q, want the Mod folder copy", data)
+	}
+	// 基础 ARC 中的压缩条目读取时会解压
+	// Compressed entries from the base ARC are inflated when read
+	start, err := vfs.ReadFile("start.ks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(start) != string(files["script/ks/start.ks"]) {
+		t.Fatalf("start.ks = %q", start)
+	}
+	if _, ok := vfs.Lookup("missing.tex"); ok {
+		t.Fatal("found a missing file")
+	}
+	if _, err := vfs.Open("missing.tex"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Open error = %v, want not exist", err)
+	}
+
+	shadowed := vfs.Shadowed()
+	if len(shadowed) != 2 || shadowed[0].Name != "dress.menu" || shadowed[1].Name != "dress.model" {
+		t.Fatalf("shadowed = %+v", shadowed)
+	}
+	if got := shadowed[1].Shadowed; len(got) != 1 || got[0].Source.Name != baseArc || got[0].Path != "model/dress.model" {
+		t.Fatalf("dress.model shadowed entries = %+v", got)
+	}
+	if candidates := vfs.Candidates("dress.menu"); len(candidates) != 2 || candidates[0] != menu {
+		t.Fatalf("dress.menu candidates = %+v", candidates)
+	}
+}
+
+func TestVFSPrefersFirstPathWithinOneSource(t *testing.T) {
+	fs := NewArc("mod")
+	fs.CreateFile("b/body.tex", []byte("b"))
+	fs.CreateFile("a/body.tex", []byte("a"))
+	vfs := NewVFS()
+	vfs.MountArc("mod", fs)
+	entry, ok := vfs.Lookup("body.tex")
+	if !ok || entry.Path != "a/body.tex" {
+		t.Fatalf("body.tex resolved to %+v", entry)
+	}
+	if shadowed := vfs.Shadowed(); len(shadowed) != 1 || shadowed[0].Shadowed[0].Path != "b/body.tex" {
+		t.Fatalf("shadowed = %+v", shadowed)
+	}
+}
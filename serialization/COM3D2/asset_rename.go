@@ -0,0 +1,371 @@
+package COM3D2
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// AssetRenamer 重命名资源文件和材质，并改写文件之间的引用
+// 可以给依赖闭包中的全部文件和材质加上前缀，使导出的 MOD 不与原文件冲突，也可以按显式的旧名到新名映射重构 MOD 文件夹
+// AssetRenamer renames asset files and materials and rewrites the references between files
+// It either prefixes every file and material in a dependency closure, so an exported mod does not collide with the originals, or applies explicit old-to-new mappings to refactor a mod folder
+type AssetRenamer struct {
+	prefix    string            // 材质名前缀，仅前缀模式使用 / Material name prefix, used only in prefix mode
+	names     map[string]string // 小写裸文件名到新文件名 / Lowercase bare file name to new file name
+	materials map[string]string // 小写材质名到新材质名，仅映射模式使用 / Lowercase material name to new material name, used only in mapping mode
+}
+
+// ReferenceChange 描述改写一个文件时修改的一处引用 / ReferenceChange describes one reference changed while rewriting a file
+type ReferenceChange struct {
+	Field string `json:"field"` // 被修改的命令参数或字段 / Command argument or field that changed
+	Old   string `json:"old"`   // 原值 / Old value
+	New   string `json:"new"`   // 新值 / New value
+}
+
+// NewDependencyRenamer 为 files 中的每个文件生成 prefix+原文件名 的新名称，并给所有材质名加上 prefix；prefix 为空时不改名也不改写内容
+// NewDependencyRenamer assigns each file in files the new name prefix+original name and prefixes every material name; an empty prefix renames and rewrites nothing
+func NewDependencyRenamer(prefix string, files []ResolvedDependency) (*AssetRenamer, error) {
+	if strings.ContainsAny(prefix, `/\`) {
+		return nil, fmt.Errorf("prefix %q must not contain path separators", prefix)
+	}
+	r := &AssetRenamer{prefix: prefix, names: map[string]string{}, materials: map[string]string{}}
+	if prefix == "" {
+		return r, nil
+	}
+	for _, file := range files {
+		name := path.Base(strings.ReplaceAll(file.Name, `\`, "/"))
+		r.names[dependencyKey(name)] = prefix + name
+	}
+	return r, nil
+}
+
+// NewAssetRenamer 按旧名到新名的映射创建重命名器，名称不区分大小写
+// 带扩展名的名称重命名文件，新旧扩展名必须相同；不带扩展名的名称重命名材质
+// 游戏按材质名查找 .pmat、按模型名查找 .phy 和 .psk，因此材质与同名 .pmat 一起改名，.model 与同名 .phy 和 .psk 一起改名，除非映射中另有指定
+// NewAssetRenamer creates a renamer from old-to-new name mappings, matching names case-insensitively
+// Names with an extension rename files and must keep the same extension; names without one rename materials
+// The game looks up a .pmat by material name and .phy and .psk files by model name, so a material is renamed together with its .pmat and a .model together with its .phy and .psk unless the mappings say otherwise
+func NewAssetRenamer(renames map[string]string) (*AssetRenamer, error) {
+	r := &AssetRenamer{names: map[string]string{}, materials: map[string]string{}}
+	implied := map[string]string{}
+	for oldName, newName := range renames {
+		if oldName == "" || newName == "" || strings.ContainsAny(oldName+newName, `/\`) {
+			return nil, fmt.Errorf("invalid rename %q -> %q: names must be non-empty bare file or material names", oldName, newName)
+		}
+		oldExt, newExt := strings.ToLower(path.Ext(oldName)), strings.ToLower(path.Ext(newName))
+		if oldExt != newExt {
+			return nil, fmt.Errorf("invalid rename %q -> %q: the extension must not change", oldName, newName)
+		}
+		key := dependencyKey(oldName)
+		if _, ok := r.names[key]; ok {
+			return nil, fmt.Errorf("duplicate rename for %q", oldName)
+		}
+		if _, ok := r.materials[key]; ok {
+			return nil, fmt.Errorf("duplicate rename for %q", oldName)
+		}
+		oldStem, newStem := strings.TrimSuffix(oldName, path.Ext(oldName)), strings.TrimSuffix(newName, path.Ext(newName))
+		switch oldExt {
+		case "":
+			r.materials[key] = newName
+			implied[dependencyKey(oldName+".pmat")] = newName + ".pmat"
+			continue
+		case ".pmat":
+			implied[dependencyKey(oldStem)] = newStem
+		case ".model":
+			implied[dependencyKey(oldStem+".phy")] = newStem + ".phy"
+			implied[dependencyKey(oldStem+".psk")] = newStem + ".psk"
+		}
+		r.names[key] = newName
+	}
+	for key, newName := range implied {
+		if path.Ext(newName) == "" {
+			if _, ok := r.materials[key]; !ok {
+				r.materials[key] = newName
+			}
+		} else if _, ok := r.names[key]; !ok {
+			r.names[key] = newName
+		}
+	}
+	return r, nil
+}
+
+// FileName 返回文件的新名称，未改名的文件保持原名
+// FileName returns the new name of a file, keeping names that are not renamed unchanged
+func (r *AssetRenamer) FileName(name string) string {
+	if renamed, ok := r.names[dependencyKey(name)]; ok {
+		return renamed
+	}
+	return name
+}
+
+// MaterialName 返回材质的新名称，未改名的材质保持原名 / MaterialName returns the new name of a material, keeping materials that are not renamed unchanged
+func (r *AssetRenamer) MaterialName(name string) string {
+	if name == "" {
+		return name
+	}
+	if r.prefix != "" {
+		return r.prefix + name
+	}
+	if renamed, ok := r.materials[strings.ToLower(name)]; ok {
+		return renamed
+	}
+	return name
+}
+
+// empty 报告重命名器是否不会修改任何内容 / empty reports whether the renamer changes nothing
+func (r *AssetRenamer) empty() bool {
+	return r.prefix == "" && len(r.names) == 0 && len(r.materials) == 0
+}
+
+// Rewrite 按文件类型改写 data 中的引用并返回新内容；不含引用的类型和不修改任何名称的重命名器原样返回
+// .menu 改写引用文件的命令参数；.mate 和 .model 改写材质名与贴图名；.pmat 改写材质名并重新计算哈希；.phy 改写碰撞器文件名；.preset 改写所选菜单文件名
+// Rewrite rewrites the references in data according to the file kind and returns the new content; kinds without references, and any file when the renamer changes nothing, are returned unchanged
+// .menu rewrites command arguments that name files; .mate and .model rewrite material and texture names; .pmat rewrites the material name and recalculates the hash; .phy rewrites the collider file name; .preset rewrites the selected menu file names
+func (r *AssetRenamer) Rewrite(kind DependencyKind, data []byte) ([]byte, error) {
+	rewritten, _, err := r.RewriteChanges(kind, data)
+	return rewritten, err
+}
+
+// RewriteChanges 与 Rewrite 相同，同时返回修改的每处引用；没有引用被修改时返回原内容
+// RewriteChanges is Rewrite that also returns every reference it changed; the original content is returned when no reference changed
+func (r *AssetRenamer) RewriteChanges(kind DependencyKind, data []byte) ([]byte, []ReferenceChange, error) {
+	if r.empty() {
+		return data, nil, nil
+	}
+	rw := &referenceRewrite{r: r}
+	var out bytes.Buffer
+	reader := bufio.NewReader(bytes.NewReader(data))
+	switch kind {
+	case DependencyKindMenu:
+		menu, err := ReadMenu(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, cmd := range menu.Commands {
+			if menu.Commands[i], err = rw.menuCommand(i, cmd); err != nil {
+				return nil, nil, fmt.Errorf("command %d: %w", i, err)
+			}
+		}
+		if len(rw.changes) == 0 {
+			return data, nil, nil
+		}
+		if err := menu.Dump(&out); err != nil {
+			return nil, nil, err
+		}
+	case DependencyKindMaterial:
+		mate, err := ReadMate(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rw.stem("Name", &mate.Name, ".mate")
+		rw.material("Material", mate.Material)
+		if len(rw.changes) == 0 {
+			return data, nil, nil
+		}
+		if err := mate.Dump(&out); err != nil {
+			return nil, nil, err
+		}
+	case DependencyKindModel:
+		model, err := ReadModel(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, material := range model.Materials {
+			rw.material(fmt.Sprintf("Materials[%d]", i), material)
+		}
+		if len(rw.changes) == 0 {
+			return data, nil, nil
+		}
+		if err := model.Dump(&out); err != nil {
+			return nil, nil, err
+		}
+	case DependencyKindPMat:
+		pmat, err := ReadPMat(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rw.materialName("MaterialName", &pmat.MaterialName)
+		if len(rw.changes) == 0 {
+			return data, nil, nil
+		}
+		if err := pmat.Dump(&out, true); err != nil {
+			return nil, nil, err
+		}
+	case DependencyKindPhysics:
+		phy, err := ReadPhy(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rw.stem("ColliderFileName", &phy.ColliderFileName, ".col")
+		if len(rw.changes) == 0 {
+			return data, nil, nil
+		}
+		if err := phy.Dump(&out); err != nil {
+			return nil, nil, err
+		}
+	case DependencyKindPreset:
+		preset, err := ReadPreset(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := rw.preset(preset); err != nil {
+			return nil, nil, err
+		}
+		if len(rw.changes) == 0 {
+			return data, nil, nil
+		}
+		if err := preset.Dump(&out); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return data, nil, nil
+	}
+	return out.Bytes(), rw.changes, nil
+}
+
+// referenceRewrite 记录一次 RewriteChanges 中修改的引用 / referenceRewrite records the references changed by one RewriteChanges call
+type referenceRewrite struct {
+	r       *AssetRenamer
+	changes []ReferenceChange
+}
+
+// set 把 *value 改为 renamed 并在值变化时记录 / set changes *value to renamed and records it when the value differs
+func (rw *referenceRewrite) set(field string, value *string, renamed string) {
+	if renamed == *value {
+		return
+	}
+	rw.changes = append(rw.changes, ReferenceChange{Field: field, Old: *value, New: renamed})
+	*value = renamed
+}
+
+// stem 改写一个可能省略扩展名 ext 的文件引用，保持原引用是否带扩展名的写法
+// stem rewrites a file reference that may omit the extension ext, keeping whether the original spelled the extension
+func (rw *referenceRewrite) stem(field string, value *string, ext string) {
+	name := *value
+	if name == "" {
+		return
+	}
+	if strings.HasSuffix(strings.ToLower(name), ext) {
+		rw.set(field, value, rw.r.FileName(name))
+		return
+	}
+	if renamed, ok := rw.r.names[dependencyKey(name+ext)]; ok {
+		rw.set(field, value, renamed[:len(renamed)-len(ext)])
+	}
+}
+
+// materialName 改写材质名 / materialName rewrites a material name
+func (rw *referenceRewrite) materialName(field string, value *string) {
+	rw.set(field, value, rw.r.MaterialName(*value))
+}
+
+// material 改写材质名、tex2d/cube 贴图名以及贴图路径的文件名部分
+// material rewrites the material name, the tex2d/cube texture names, and the file name part of texture paths
+func (rw *referenceRewrite) material(field string, material *Material) {
+	if material == nil {
+		return
+	}
+	rw.materialName(field+".Name", &material.Name)
+	for _, property := range material.Properties {
+		tex, ok := property.(*TexProperty)
+		if !ok || tex.Tex2D == nil {
+			continue
+		}
+		texField := field + ".Properties[" + tex.PropName + "].Tex2D"
+		rw.stem(texField+".Name", &tex.Tex2D.Name, ".tex")
+		rw.texturePath(texField+".Path", &tex.Tex2D.Path)
+	}
+}
+
+// texturePath 当路径的文件名部分与改名的 .tex 同名时改写它，保留目录和原扩展名
+// Tex2D.Path 不被游戏使用，但保持与 Name 一致可以避免编辑器显示过时的名称
+// texturePath rewrites the file name part of a path whose stem matches a renamed .tex, keeping the directory and the original extension
+// The game does not use Tex2D.Path, but keeping it in step with Name avoids editors showing a stale name
+func (rw *referenceRewrite) texturePath(field string, value *string) {
+	p := *value
+	cut := strings.LastIndexAny(p, `/\`) + 1
+	base := p[cut:]
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if stem == "" {
+		return
+	}
+	if renamed, ok := rw.r.names[dependencyKey(stem+".tex")]; ok {
+		rw.set(field, value, p[:cut]+strings.TrimSuffix(renamed, path.Ext(renamed))+ext)
+	}
+}
+
+// menuCommand 改写一条菜单命令中的引用
+// ResolveMenuDependencies 跟随的类型化命令按引用位置改写，允许省略扩展名；其他命令只改写带扩展名且与改名文件同名的参数，避免误改同名的显示文本
+// menuCommand rewrites the references in one menu command
+// Typed commands followed by ResolveMenuDependencies are rewritten at their reference positions, where the extension may be omitted; other commands only have arguments rewritten that spell a renamed file name with its extension, so display text that happens to match is left alone
+func (rw *referenceRewrite) menuCommand(index int, cmd Command) (Command, error) {
+	typed, err := DecodeMenuCommand(cmd)
+	if err != nil {
+		typed = &RawMenuCommand{Command: cmd}
+	}
+	field := "command " + strconv.Itoa(index) + " (" + cmd.Command + ")"
+	switch c := typed.(type) {
+	case *MenuIconCommand:
+		rw.stem(field, &c.Texture, ".tex")
+	case *MenuAddItemCommand:
+		rw.stem(field, &c.Model, ".model")
+	case *MenuMaterialCommand:
+		rw.stem(field, &c.Material, ".mate")
+	case *MenuTexCommand:
+		rw.stem(field, &c.Texture, ".tex")
+	case *MenuTextureCompositeCommand:
+		rw.stem(field, &c.Texture, ".tex")
+	case *MenuAnimeCommand:
+		rw.stem(field, &c.Animation, ".anm")
+	case *MenuItemCommand:
+		rw.stem(field, &c.Menu, ".menu")
+	default:
+		rewritten := Command{Command: cmd.Command, Args: append([]string(nil), cmd.Args...)}
+		for i := range rewritten.Args {
+			if path.Ext(rewritten.Args[i]) != "" {
+				rw.set(field+" arg "+strconv.Itoa(i), &rewritten.Args[i], rw.r.FileName(rewritten.Args[i]))
+			}
+		}
+		return rewritten, nil
+	}
+	return typed.Encode()
+}
+
+// preset 改写预设主属性、COM3D2.5 扩展属性及其子属性所选的菜单文件名
+// FileNameRID 和各槽位数据的 RID 是游戏计算的 C# 字符串哈希，无法在此重新计算：游戏会为非空文件名重新计算 FileNameRID，但槽位数据的 RID 不再匹配改名后的菜单，游戏会忽略这些数据
+// preset rewrites the menu file names selected by the main properties, the COM3D2.5 extension properties, and their sub-properties
+// FileNameRID and the per-slot RIDs are C# string hashes computed by the game and cannot be recalculated here: the game recalculates FileNameRID for a nonempty file name, but the per-slot RIDs no longer match a renamed menu and the game ignores that data
+func (rw *referenceRewrite) preset(preset *Preset) error {
+	ppl := preset.PresetPropertyList
+	if ppl == nil {
+		return nil
+	}
+	keys, err := orderedPresetPropertyKeys(ppl)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		prop := ppl.PresetProperties[key]
+		rw.presetProperty("PresetProperties["+key+"]", &prop)
+		ppl.PresetProperties[key] = prop
+	}
+	for i := range ppl.MaidPropOther {
+		rw.presetProperty(fmt.Sprintf("MaidPropOther[%d]", i), &ppl.MaidPropOther[i].Property)
+	}
+	return nil
+}
+
+// presetProperty 改写单个预设属性及其子属性的菜单文件名 / presetProperty rewrites the menu file names of one preset property and its sub-properties
+func (rw *referenceRewrite) presetProperty(field string, prop *PresetProperty) {
+	rw.stem(field+".FileName", &prop.FileName, ".menu")
+	for i, sub := range prop.SubProps {
+		if sub != nil {
+			rw.stem(fmt.Sprintf("%s.SubProps[%d].FileName", field, i), &sub.FileName, ".menu")
+		}
+	}
+}
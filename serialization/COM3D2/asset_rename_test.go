@@ -0,0 +1,214 @@
+package COM3D2
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestDependencyRenamerPrefixesClosure(t *testing.T) {
+	files := []ResolvedDependency{
+		{Name: "dress.menu", Kind: DependencyKindMenu},
+		{Name: "dress.model", Kind: DependencyKindModel},
+		{Name: "Dress_Alt.mate", Kind: DependencyKindMaterial},
+		{Name: "dress_body.tex", Kind: DependencyKindTexture},
+		{Name: "dress_mat.pmat", Kind: DependencyKindPMat},
+		{Name: "dress.phy", Kind: DependencyKindPhysics},
+		{Name: "dress.col", Kind: DependencyKindCollider},
+	}
+	renamer, err := NewDependencyRenamer("my_", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamer.FileName("DRESS.model") != "my_dress.model" || renamer.FileName("other.tex") != "other.tex" {
+		t.Fatalf("FileName = %q, %q", renamer.FileName("DRESS.model"), renamer.FileName("other.tex"))
+	}
+
+	menu := &Menu{Signature: MenuSignature, Version: 1000, Commands: []Command{
+		{Command: "additem", Args: []string{"dress.model", "wear"}},
+		{Command: "マテリアル変更", Args: []string{"wear", "0", "dress_alt"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "base.tex"}},
+		{Command: "name", Args: []string{"dress"}},
+	}}
+	data := dependencyTestBytes(t, menu.Dump)
+	rewritten, err := renamer.Rewrite(DependencyKindMenu, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadMenu(bufio.NewReader(bytes.NewReader(rewritten)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"my_dress.model", "wear"}, {"wear", "0", "my_Dress_Alt"}, {"wear", "0", "_MainTex", "base.tex"}, {"dress"}}
+	for i, cmd := range got.Commands {
+		if !reflect.DeepEqual(cmd.Args, want[i]) {
+			t.Errorf("command %d args = %q, want %q", i, cmd.Args, want[i])
+		}
+	}
+
+	mate := &Mate{Signature: MateSignature, Version: 1000, Name: "Dress_Alt", Material: dependencyTestMaterial("dress_mat", "dress_body", "toonramp")}
+	rewritten, err = renamer.Rewrite(DependencyKindMaterial, dependencyTestBytes(t, mate.Dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotMate, err := ReadMate(bufio.NewReader(bytes.NewReader(rewritten)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	textures := gotMate.Material.Properties
+	if gotMate.Name != "my_Dress_Alt" || gotMate.Material.Name != "my_dress_mat" ||
+		textures[0].(*TexProperty).Tex2D.Name != "my_dress_body" || textures[1].(*TexProperty).Tex2D.Name != "toonramp" {
+		t.Errorf("mate = %+v, textures %+v %+v", gotMate, textures[0].(*TexProperty).Tex2D, textures[1].(*TexProperty).Tex2D)
+	}
+
+	model := &Model{Signature: ModelSignature, Version: 2102, Name: "dress", Materials: []*Material{dependencyTestMaterial("dress_mat", "dress_body")}}
+	rewritten, err = renamer.Rewrite(DependencyKindModel, dependencyTestBytes(t, model.Dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotModel, err := ReadModel(bufio.NewReader(bytes.NewReader(rewritten)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotModel.Materials[0].Name != "my_dress_mat" || gotModel.Materials[0].Properties[0].(*TexProperty).Tex2D.Name != "my_dress_body" {
+		t.Errorf("model material = %+v", gotModel.Materials[0])
+	}
+
+	pmat := &PMat{Signature: "CM3D2_PMATERIAL", Version: 1000, Hash: 7, MaterialName: "dress_mat", RenderQueue: 3000, Shader: "CM3D2/Toony_Lighted"}
+	rewritten, err = renamer.Rewrite(DependencyKindPMat, dependencyTestBytes(t, func(w io.Writer) error { return pmat.Dump(w, false) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPMat, err := ReadPMat(bytes.NewReader(rewritten))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPMat.MaterialName != "my_dress_mat" || gotPMat.Hash == 7 {
+		t.Errorf("pmat = %+v", gotPMat)
+	}
+
+	phy := &Phy{Signature: "CM3D21_PHY", Version: 24102, ColliderFileName: "dress"}
+	rewritten, err = renamer.Rewrite(DependencyKindPhysics, dependencyTestBytes(t, phy.Dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPhy, err := ReadPhy(bytes.NewReader(rewritten))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPhy.ColliderFileName != "my_dress" {
+		t.Errorf("collider = %q", gotPhy.ColliderFileName)
+	}
+}
+
+func TestDependencyRenamerWithoutPrefixKeepsBytes(t *testing.T) {
+	renamer, err := NewDependencyRenamer("", []ResolvedDependency{{Name: "dress.menu", Kind: DependencyKindMenu}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("not even a menu")
+	rewritten, err := renamer.Rewrite(DependencyKindMenu, data)
+	if err != nil || !bytes.Equal(rewritten, data) || renamer.FileName("dress.menu") != "dress.menu" {
+		t.Fatalf("rewritten = %q err=%v", rewritten, err)
+	}
+	if _, err := NewDependencyRenamer("a/b", nil); err == nil {
+		t.Error("prefix with a path separator was accepted")
+	}
+}
+
+func TestAssetRenamerAppliesMappings(t *testing.T) {
+	renamer, err := NewAssetRenamer(map[string]string{
+		"dress_body.tex": "gown_body.tex",
+		"dress.model":    "gown.model",
+		"dress.menu":     "gown.menu",
+		"dress_mat":      "gown_mat",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamer.FileName("DRESS_MAT.pmat") != "gown_mat.pmat" || renamer.FileName("dress.phy") != "gown.phy" || renamer.MaterialName("other") != "other" {
+		t.Fatalf("implied renames = %q, %q", renamer.FileName("DRESS_MAT.pmat"), renamer.FileName("dress.phy"))
+	}
+
+	menu := &Menu{Signature: MenuSignature, Version: 1000, Commands: []Command{
+		{Command: "additem", Args: []string{"dress.model", "wear"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "dress_body"}},
+		{Command: "icon", Args: []string{"dress_i_.tex"}},
+	}}
+	data := dependencyTestBytes(t, menu.Dump)
+	rewritten, changes, err := renamer.RewriteChanges(DependencyKindMenu, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantChanges := []ReferenceChange{
+		{Field: "command 0 (additem)", Old: "dress.model", New: "gown.model"},
+		{Field: "command 1 (tex)", Old: "dress_body", New: "gown_body"},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	got, err := ReadMenu(bufio.NewReader(bytes.NewReader(rewritten)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Commands[0].Args[0] != "gown.model" || got.Commands[1].Args[3] != "gown_body" || got.BodySize == menu.BodySize {
+		t.Errorf("menu = %+v", got)
+	}
+
+	material := dependencyTestMaterial("dress_mat", "dress_body")
+	material.Properties[0].(*TexProperty).Tex2D.Path = "Assets/texture/dress_body.png"
+	mate := &Mate{Signature: MateSignature, Version: 1000, Name: "dress", Material: material}
+	rewritten, changes, err = renamer.RewriteChanges(DependencyKindMaterial, dependencyTestBytes(t, mate.Dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotMate, err := ReadMate(bufio.NewReader(bytes.NewReader(rewritten)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := gotMate.Material.Properties[0].(*TexProperty).Tex2D
+	if len(changes) != 3 || gotMate.Name != "dress" || gotMate.Material.Name != "gown_mat" || tex.Name != "gown_body" || tex.Path != "Assets/texture/gown_body.png" {
+		t.Errorf("mate = %+v, tex %+v, changes %+v", gotMate, tex, changes)
+	}
+
+	preset := &Preset{Signature: PresetSignature, Version: 1, PresetPropertyList: &PresetPropertyList{
+		Signature: PresetPropertyListSignature, Version: 4, PresetProperties: map[string]PresetProperty{
+			"wear": {Signature: PresetPropertySignature, Version: 200, Name: "wear", FileName: "Dress.menu", SubProps: []*SubProp{{FileName: "dress.menu"}, nil}},
+			"head": {Signature: PresetPropertySignature, Version: 200, Name: "head", FileName: "head.menu"},
+		},
+	}}
+	rewritten, changes, err = renamer.RewriteChanges(DependencyKindPreset, dependencyTestBytes(t, preset.Dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPreset, err := ReadPreset(bytes.NewReader(rewritten))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wear := gotPreset.PresetPropertyList.PresetProperties["wear"]
+	if len(changes) != 2 || wear.FileName != "gown.menu" || wear.SubProps[0].FileName != "gown.menu" ||
+		gotPreset.PresetPropertyList.PresetProperties["head"].FileName != "head.menu" {
+		t.Errorf("preset wear = %+v, changes %+v", wear, changes)
+	}
+
+	phy := &Phy{Signature: "CM3D21_PHY", Version: 24102, ColliderFileName: "other"}
+	data = dependencyTestBytes(t, phy.Dump)
+	if rewritten, changes, err = renamer.RewriteChanges(DependencyKindPhysics, data); err != nil || changes != nil || !bytes.Equal(rewritten, data) {
+		t.Errorf("unchanged phy rewritten: changes %+v err %v", changes, err)
+	}
+}
+
+func TestNewAssetRenamerRejectsInvalidMappings(t *testing.T) {
+	for _, renames := range []map[string]string{
+		{"dress.tex": "dress.png"},
+		{"dress.tex": "sub/dress.tex"},
+		{"dress.tex": ""},
+		{"dress.tex": "a.tex", "DRESS.tex": "b.tex"},
+	} {
+		if _, err := NewAssetRenamer(renames); err == nil {
+			t.Errorf("%v was accepted", renames)
+		}
+	}
+}
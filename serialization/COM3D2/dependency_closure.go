@@ -0,0 +1,377 @@
+package COM3D2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DependencyKind 是依赖闭包中文件的类型 / DependencyKind is the kind of a file in a dependency closure
+type DependencyKind string
+
+const (
+	DependencyKindMenu      DependencyKind = "menu"      // .menu 菜单 / .menu menu
+	DependencyKindModel     DependencyKind = "model"     // .model 模型 / .model model
+	DependencyKindMaterial  DependencyKind = "material"  // .mate 材质 / .mate material
+	DependencyKindTexture   DependencyKind = "texture"   // .tex 贴图 / .tex texture
+	DependencyKindPMat      DependencyKind = "pmat"      // .pmat 渲染顺序 / .pmat render order
+	DependencyKindPhysics   DependencyKind = "physics"   // .phy 头发物理 / .phy hair physics
+	DependencyKindSkirt     DependencyKind = "skirt"     // .psk 裙子物理 / .psk skirt physics
+	DependencyKindCollider  DependencyKind = "collider"  // .col 碰撞器 / .col colliders
+	DependencyKindAnimation DependencyKind = "animation" // .anm 动画 / .anm animation
+	DependencyKindPreset    DependencyKind = "preset"    // .preset 角色预设 / .preset character preset
+	DependencyKindResource  DependencyKind = "resource"  // 其他资源 / Other resource
+)
+
+// DependencyFile 是定位到的一个文件 / DependencyFile is one located file
+type DependencyFile struct {
+	Source string                        // 提供文件的来源，如 ARC 或目录路径 / Source providing the file, such as an ARC or directory path
+	Path   string                        // 来源内的路径 / Path inside the source
+	Open   func() (io.ReadCloser, error) // 打开原始内容 / Opens the raw content
+}
+
+// DependencyLocator 按游戏的方式（不区分大小写的裸文件名）定位文件，例如 arc.VFS
+// DependencyLocator locates files the way the game does, by case-insensitive bare file name, for example arc.VFS
+type DependencyLocator interface {
+	Locate(name string) (*DependencyFile, bool)
+}
+
+// DependencyClosure 是一个 .menu 会让游戏加载的全部文件的报告
+// DependencyClosure is a report of every file the game loads for one .menu
+type DependencyClosure struct {
+	Root       string                `json:"root"`             // 根菜单文件名 / Root menu file name
+	Files      []ResolvedDependency  `json:"files"`            // 已找到的文件，按发现顺序排列，根菜单在前 / Files found, in discovery order with the root menu first
+	References []DependencyReference `json:"references"`       // 全部引用 / Every reference
+	Missing    []DependencyReference `json:"missing"`          // 未找到的必需引用 / Required references that were not found
+	Errors     []DependencyError     `json:"errors,omitempty"` // 已找到但无法解析的文件 / Files that were found but could not be parsed
+}
+
+// ResolvedDependency 描述闭包中的一个文件及提供它的来源 / ResolvedDependency describes one file in the closure and the source providing it
+type ResolvedDependency struct {
+	Name   string         `json:"name"`   // 文件名 / File name
+	Kind   DependencyKind `json:"kind"`   // 文件类型 / File kind
+	Source string         `json:"source"` // 提供文件的来源 / Source providing the file
+	Path   string         `json:"path"`   // 来源内的路径 / Path inside the source
+}
+
+// DependencyReference 描述一条文件引用及其解析结果 / DependencyReference describes one file reference and its resolution
+type DependencyReference struct {
+	From     string         `json:"from"`               // 引用方文件名 / Referring file name
+	Via      string         `json:"via"`                // 引用所在的命令或字段 / Command or field holding the reference
+	Name     string         `json:"name"`               // 被引用的文件名 / Referenced file name
+	Kind     DependencyKind `json:"kind"`               // 被引用文件的类型 / Kind of the referenced file
+	Optional bool           `json:"optional,omitempty"` // 游戏在文件存在时才加载 / The game loads the file only when it exists
+	Found    bool           `json:"found"`              // 是否找到 / Whether the file was found
+	Source   string         `json:"source,omitempty"`   // 提供文件的来源 / Source providing the file
+	Path     string         `json:"path,omitempty"`     // 来源内的路径 / Path inside the source
+}
+
+// DependencyError 记录一个无法解析的文件 / DependencyError records one file that could not be parsed
+type DependencyError struct {
+	Name    string `json:"name"`    // 文件名 / File name
+	Source  string `json:"source"`  // 来源 / Source
+	Message string `json:"message"` // 错误信息 / Error message
+}
+
+// dependencyWalker 保存闭包遍历的状态 / dependencyWalker holds the state of a closure walk
+type dependencyWalker struct {
+	locator DependencyLocator
+	closure *DependencyClosure
+	visited map[string]bool
+	queue   []dependencyItem
+}
+
+// dependencyItem 是等待解析内容的文件 / dependencyItem is a file waiting for its content to be parsed
+type dependencyItem struct {
+	name string
+	kind DependencyKind
+	file *DependencyFile
+}
+
+// ResolveMenuDependencies 从根菜单开始遍历 menu → model/mate → tex/pmat/phy/col 引用，返回游戏会加载的全部文件
+// 菜单只解析能解码为类型化命令的引用（additem、マテリアル変更、tex、テクスチャ合成、icon、anime、アイテム、半脱ぎ、リソース参照）
+// 模型和材质中的 tex2d/cube 贴图是必需引用；与模型同名的 .phy/.psk、与材质同名的 .pmat 只在存在时加载，因此是可选引用，未找到时不出现在报告中
+// 同名文件只解析一次，循环引用不会导致重复遍历
+// ResolveMenuDependencies walks menu → model/mate → tex/pmat/phy/col references from the root menu and returns every file the game loads
+// Menus contribute only references that decode as typed commands (additem, マテリアル変更, tex, テクスチャ合成, icon, anime, アイテム, 半脱ぎ, リソース参照)
+// tex2d/cube textures in models and materials are required references; .phy/.psk files named after a model and .pmat files named after a material are loaded only when present, so they are optional references omitted from the report when not found
+// Each file name is parsed once, so reference cycles do not cause repeated walks
+func ResolveMenuDependencies(rootName string, root *DependencyFile, locator DependencyLocator) (*DependencyClosure, error) {
+	if root == nil || root.Open == nil {
+		return nil, fmt.Errorf("root menu %q has no content", rootName)
+	}
+	if locator == nil {
+		return nil, fmt.Errorf("dependency locator is nil")
+	}
+	w := &dependencyWalker{
+		locator: locator,
+		closure: &DependencyClosure{
+			Root:       rootName,
+			Files:      []ResolvedDependency{},
+			References: []DependencyReference{},
+			Missing:    []DependencyReference{},
+		},
+		visited: map[string]bool{},
+	}
+	w.add(rootName, DependencyKindMenu, root)
+
+	for len(w.queue) > 0 {
+		item := w.queue[0]
+		w.queue = w.queue[1:]
+		var err error
+		switch item.kind {
+		case DependencyKindMenu:
+			err = w.walkMenu(item)
+		case DependencyKindModel:
+			err = w.walkModel(item)
+		case DependencyKindMaterial:
+			err = w.walkMate(item)
+		case DependencyKindPhysics:
+			err = w.walkPhy(item)
+		}
+		if err != nil {
+			w.closure.Errors = append(w.closure.Errors, DependencyError{Name: item.name, Source: item.file.Source, Message: err.Error()})
+		}
+	}
+	return w.closure, nil
+}
+
+// add 登记一个已找到的文件，首次出现时加入结果和待解析队列 / add registers a found file, adding it to the result and parse queue on first sight
+func (w *dependencyWalker) add(name string, kind DependencyKind, file *DependencyFile) {
+	key := dependencyKey(name)
+	if w.visited[key] {
+		return
+	}
+	w.visited[key] = true
+	w.closure.Files = append(w.closure.Files, ResolvedDependency{Name: name, Kind: kind, Source: file.Source, Path: file.Path})
+	w.queue = append(w.queue, dependencyItem{name: name, kind: kind, file: file})
+}
+
+// reference 定位一条引用并记录结果；可选引用未找到时不记录
+// reference locates one reference and records the result; optional references that are not found are not recorded
+func (w *dependencyWalker) reference(from, via, name string, kind DependencyKind, optional bool) {
+	if name == "" {
+		return
+	}
+	ref := DependencyReference{From: from, Via: via, Name: name, Kind: kind, Optional: optional}
+	file, ok := w.locator.Locate(name)
+	if !ok {
+		if !optional {
+			w.closure.References = append(w.closure.References, ref)
+			w.closure.Missing = append(w.closure.Missing, ref)
+		}
+		return
+	}
+	ref.Found, ref.Source, ref.Path = true, file.Source, file.Path
+	w.closure.References = append(w.closure.References, ref)
+	w.add(name, kind, file)
+}
+
+// open 打开文件并包装为可 Peek 的读取器 / open opens a file and wraps it in a peekable reader
+func (item dependencyItem) open() (*bufio.Reader, io.Closer, error) {
+	rc, err := item.file.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("open failed: %w", err)
+	}
+	return bufio.NewReader(rc), rc, nil
+}
+
+// walkMenu 收集菜单命令中的文件引用 / walkMenu collects the file references of the menu commands
+func (w *dependencyWalker) walkMenu(item dependencyItem) error {
+	br, closer, err := item.open()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	menu, err := ReadMenu(br)
+	if err != nil {
+		return err
+	}
+	for i, cmd := range menu.Commands {
+		typed, err := DecodeMenuCommand(cmd)
+		if err != nil {
+			w.closure.Errors = append(w.closure.Errors, DependencyError{
+				Name: item.name, Source: item.file.Source, Message: fmt.Sprintf("command %d: %v", i, err),
+			})
+			w.rawMenuReference(item.name, cmd)
+			continue
+		}
+		via := typed.Opcode()
+		switch c := typed.(type) {
+		case *MenuIconCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Texture, ".tex"), DependencyKindTexture, false)
+		case *MenuAddItemCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Model, ".model"), DependencyKindModel, false)
+		case *MenuMaterialCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Material, ".mate"), DependencyKindMaterial, false)
+		case *MenuTexCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Texture, ".tex"), DependencyKindTexture, false)
+		case *MenuTextureCompositeCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Texture, ".tex"), DependencyKindTexture, false)
+		case *MenuAnimeCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Animation, ".anm"), DependencyKindAnimation, false)
+		case *MenuItemCommand:
+			w.reference(item.name, via, withDependencyExtension(c.Menu, ".menu"), DependencyKindMenu, false)
+		case *MenuHalfUndressCommand:
+			w.reference(item.name, via, c.Resource, DependencyKindOf(c.Resource), false)
+		case *MenuResourceRefCommand:
+			w.reference(item.name, via, c.Resource, DependencyKindOf(c.Resource), false)
+		}
+	}
+	return nil
+}
+
+// menuDependencyArg 描述菜单命令中文件名参数的位置 / menuDependencyArg describes where a menu command keeps its file name argument
+type menuDependencyArg struct {
+	index int            // 参数序号 / Argument index
+	ext   string         // 缺少时补上的扩展名，为空表示按名称判断类型 / Extension appended when missing, empty to take the kind from the name
+	kind  DependencyKind // 文件类型 / File kind
+}
+
+// menuDependencyArgs 按关键字记录引用文件的命令的参数位置，供类型化解码失败时回退使用
+// menuDependencyArgs records the argument position of each file-referencing command by keyword, as a fallback when typed decoding fails
+var menuDependencyArgs = map[string]menuDependencyArg{
+	"icon":       {0, ".tex", DependencyKindTexture},
+	"icons":      {0, ".tex", DependencyKindTexture},
+	"additem":    {0, ".model", DependencyKindModel},
+	"マテリアル変更":    {2, ".mate", DependencyKindMaterial},
+	"tex":        {3, ".tex", DependencyKindTexture},
+	"テクスチャ変更":    {3, ".tex", DependencyKindTexture},
+	"テクスチャ合成":    {4, ".tex", DependencyKindTexture},
+	"テクスチャセット合成": {4, ".tex", DependencyKindTexture},
+	"anime":      {1, ".anm", DependencyKindAnimation},
+	"アイテム":       {0, ".menu", DependencyKindMenu},
+	"半脱ぎ":        {0, "", ""},
+	"リソース参照":     {1, "", ""},
+}
+
+// rawMenuReference 在已知命令的参数未通过严格校验时，按原始参数位置继续跟踪它引用的文件
+// rawMenuReference still follows the file a known command references, by raw argument position, when its arguments fail strict validation
+func (w *dependencyWalker) rawMenuReference(from string, cmd Command) {
+	arg, ok := menuDependencyArgs[cmd.Command]
+	if !ok || arg.index >= len(cmd.Args) {
+		return
+	}
+	name := cmd.Args[arg.index]
+	if arg.ext == "" {
+		w.reference(from, cmd.Command, name, DependencyKindOf(name), false)
+		return
+	}
+	w.reference(from, cmd.Command, withDependencyExtension(name, arg.ext), arg.kind, false)
+}
+
+// walkModel 收集模型材质的贴图和 .pmat，以及与模型同名的 .phy 和 .psk
+// walkModel collects the textures and .pmat files of the model materials, plus the .phy and .psk files named after the model
+func (w *dependencyWalker) walkModel(item dependencyItem) error {
+	br, closer, err := item.open()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	metadata, err := ReadModelMetadata(br)
+	if err != nil {
+		return err
+	}
+	for _, material := range metadata.Materials {
+		w.materialReferences(item.name, material)
+	}
+	base := strings.TrimSuffix(item.name, path.Ext(item.name))
+	w.reference(item.name, "model physics", base+".phy", DependencyKindPhysics, true)
+	w.reference(item.name, "model skirt physics", base+".psk", DependencyKindSkirt, true)
+	return nil
+}
+
+// walkMate 收集 .mate 材质的贴图和 .pmat / walkMate collects the textures and .pmat of a .mate material
+func (w *dependencyWalker) walkMate(item dependencyItem) error {
+	br, closer, err := item.open()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	mate, err := ReadMate(br)
+	if err != nil {
+		return err
+	}
+	w.materialReferences(item.name, mate.Material)
+	return nil
+}
+
+// walkPhy 收集 .phy 引用的碰撞器文件 / walkPhy collects the collider file referenced by a .phy
+func (w *dependencyWalker) walkPhy(item dependencyItem) error {
+	br, closer, err := item.open()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	phy, err := ReadPhy(br)
+	if err != nil {
+		return err
+	}
+	if phy.ColliderFileName != "" {
+		w.reference(item.name, "ColliderFileName", withDependencyExtension(phy.ColliderFileName, ".col"), DependencyKindCollider, false)
+	}
+	return nil
+}
+
+// materialReferences 收集一个材质的 tex2d/cube 贴图和与材质同名的 .pmat
+// materialReferences collects the tex2d/cube textures of one material and the .pmat named after it
+func (w *dependencyWalker) materialReferences(from string, material *Material) {
+	if material == nil {
+		return
+	}
+	for _, property := range material.Properties {
+		tex, ok := property.(*TexProperty)
+		if !ok || tex.Tex2D == nil {
+			continue
+		}
+		w.reference(from, "tex "+tex.PropName, withDependencyExtension(tex.Tex2D.Name, ".tex"), DependencyKindTexture, false)
+	}
+	if material.Name != "" {
+		w.reference(from, "material "+material.Name, material.Name+".pmat", DependencyKindPMat, true)
+	}
+}
+
+// withDependencyExtension 在名称缺少扩展名时补上 ext，空名称保持为空
+// withDependencyExtension appends ext when the name lacks it, keeping empty names empty
+func withDependencyExtension(name, ext string) string {
+	if name == "" || strings.HasSuffix(strings.ToLower(name), ext) {
+		return name
+	}
+	return name + ext
+}
+
+// DependencyKindOf 根据扩展名推断文件类型 / DependencyKindOf infers the file kind from the extension
+func DependencyKindOf(name string) DependencyKind {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".menu":
+		return DependencyKindMenu
+	case ".model":
+		return DependencyKindModel
+	case ".mate":
+		return DependencyKindMaterial
+	case ".tex":
+		return DependencyKindTexture
+	case ".pmat":
+		return DependencyKindPMat
+	case ".phy":
+		return DependencyKindPhysics
+	case ".psk":
+		return DependencyKindSkirt
+	case ".col":
+		return DependencyKindCollider
+	case ".anm":
+		return DependencyKindAnimation
+	case ".preset":
+		return DependencyKindPreset
+	default:
+		return DependencyKindResource
+	}
+}
+
+// dependencyKey 把名称规范化为小写裸文件名 / dependencyKey normalizes a name to its lowercase bare file name
+func dependencyKey(name string) string {
+	return strings.ToLower(path.Base(filepath.ToSlash(name)))
+}
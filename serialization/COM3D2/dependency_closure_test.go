@@ -0,0 +1,141 @@
+package COM3D2
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// mapDependencyLocator 按小写文件名从内存中定位文件 / mapDependencyLocator locates files in memory by lowercase file name
+type mapDependencyLocator map[string][]byte
+
+func (m mapDependencyLocator) Locate(name string) (*DependencyFile, bool) {
+	data, ok := m[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return &DependencyFile{Source: "mem", Path: strings.ToLower(name), Open: func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}}, true
+}
+
+func dependencyTestBytes(t *testing.T, dump func(io.Writer) error) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func dependencyTestMaterial(name string, textures ...string) *Material {
+	material := &Material{Name: name, ShaderName: "CM3D2/Toony_Lighted", ShaderFilename: "toony_lighted"}
+	for i, texture := range textures {
+		material.Properties = append(material.Properties, &TexProperty{
+			TypeName: "tex", PropName: []string{"_MainTex", "_ToonRamp"}[i], SubTag: "tex2d",
+			Tex2D: &Tex2DSubProperty{Name: texture, Path: "Assets/" + texture + ".png", Scale: [2]float32{1, 1}},
+		})
+	}
+	return material
+}
+
+func TestResolveMenuDependencies(t *testing.T) {
+	menu := &Menu{Signature: MenuSignature, Version: 1000, Commands: []Command{
+		{Command: "icon", Args: []string{"dress_i_.tex"}},
+		{Command: "additem", Args: []string{"dress.model", "wear"}},
+		{Command: "マテリアル変更", Args: []string{"wear", "0", "dress_alt.mate"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "missing.tex"}},
+		{Command: "アイテム", Args: []string{"sub.menu"}},
+		{Command: "additem", Args: []string{"dress.model", "bogus_slot"}},
+	}}
+	sub := &Menu{Signature: MenuSignature, Version: 1000, Commands: []Command{
+		{Command: "anime", Args: []string{"wear", "dress.anm"}},
+		{Command: "アイテム", Args: []string{"root.menu"}},
+	}}
+	model := &Model{Signature: ModelSignature, Version: 2102, Name: "dress", Materials: []*Material{dependencyTestMaterial("dress_mat", "dress_body", "toonramp")}}
+	mate := &Mate{Signature: MateSignature, Version: 1000, Name: "dress_alt", Material: dependencyTestMaterial("dress_alt", "dress_alt_body")}
+	phy := &Phy{Signature: "CM3D21_PHY", Version: 24102, ColliderFileName: "dress"}
+
+	files := mapDependencyLocator{
+		"root.menu":          dependencyTestBytes(t, menu.Dump),
+		"sub.menu":           dependencyTestBytes(t, sub.Dump),
+		"dress.model":        dependencyTestBytes(t, model.Dump),
+		"dress_alt.mate":     dependencyTestBytes(t, mate.Dump),
+		"dress.phy":          dependencyTestBytes(t, phy.Dump),
+		"dress_i_.tex":       {},
+		"dress_body.tex":     {},
+		"toonramp.tex":       {},
+		"dress_alt_body.tex": {},
+		"dress_mat.pmat":     {},
+		"dress.anm":          {},
+	}
+	root, _ := files.Locate("root.menu")
+	closure, err := ResolveMenuDependencies("root.menu", root, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, file := range closure.Files {
+		names = append(names, file.Name)
+	}
+	want := "root.menu dress_i_.tex dress.model dress_alt.mate sub.menu dress_body.tex toonramp.tex dress_mat.pmat dress.phy dress_alt_body.tex dress.anm"
+	if strings.Join(names, " ") != want {
+		t.Fatalf("files = %v", names)
+	}
+	if len(closure.Missing) != 2 {
+		t.Fatalf("missing = %+v", closure.Missing)
+	}
+	for i, name := range []string{"missing.tex", "dress.col"} {
+		if closure.Missing[i].Name != name {
+			t.Errorf("missing[%d] = %+v, want %s", i, closure.Missing[i], name)
+		}
+	}
+	if closure.Missing[1].From != "dress.phy" || closure.Missing[1].Kind != DependencyKindCollider {
+		t.Errorf("collider reference = %+v", closure.Missing[1])
+	}
+	if len(closure.Errors) != 1 || closure.Errors[0].Name != "root.menu" || !strings.Contains(closure.Errors[0].Message, "command 5") {
+		t.Errorf("errors = %+v", closure.Errors)
+	}
+	for _, ref := range closure.References {
+		if ref.Name == "dress.psk" || (!ref.Found && ref.Optional) {
+			t.Errorf("absent optional reference was reported: %+v", ref)
+		}
+		if ref.Found && ref.Source != "mem" {
+			t.Errorf("reference without source: %+v", ref)
+		}
+	}
+}
+
+func TestResolveMenuDependenciesFollowsInvalidKnownCommands(t *testing.T) {
+	menu := &Menu{Signature: MenuSignature, Version: 1000, Commands: []Command{
+		{Command: "additem", Args: []string{"custom.model", "custom_slot"}},
+		{Command: "マテリアル変更", Args: []string{"custom_slot", "0", "custom.mate"}},
+		{Command: "tex", Args: []string{"custom_slot", "0", "_MainTex", "custom"}},
+	}}
+	files := mapDependencyLocator{
+		"root.menu":    dependencyTestBytes(t, menu.Dump),
+		"custom.model": dependencyTestBytes(t, (&Model{Signature: ModelSignature, Version: 2102, Name: "custom"}).Dump),
+		"custom.tex":   {},
+	}
+	root, _ := files.Locate("root.menu")
+	closure, err := ResolveMenuDependencies("root.menu", root, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, file := range closure.Files {
+		names = append(names, file.Name)
+	}
+	if strings.Join(names, " ") != "root.menu custom.model custom.tex" {
+		t.Errorf("files = %v", names)
+	}
+	if len(closure.Missing) != 1 || closure.Missing[0].Name != "custom.mate" || closure.Missing[0].Via != "マテリアル変更" || closure.Missing[0].Kind != DependencyKindMaterial {
+		t.Errorf("missing = %+v", closure.Missing)
+	}
+	if len(closure.Errors) != 3 {
+		t.Errorf("errors = %+v", closure.Errors)
+	}
+}
@@ -0,0 +1,1196 @@
+package COM3D2
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	knowledgev1 "github.com/MeidoPromotionAssociation/MeidoSerialization/schemas/knowledge/v1"
+)
+
+// 菜单命令类型化层
+// 在 Command 之上把已知命令解码为带参数校验的结构体，未知命令原样保留为 RawMenuCommand
+// 参数个数与字面量标记按 schemas/knowledge/v1 中审核过的命令形式编写，槽位、MPN 和枚举名称直接取自同一指南的值集合
+// 数值参数以原始文本保存，因此解码后再编码的结果与原命令逐字节一致
+// Typed menu-command layer
+// On top of Command, known commands are decoded into structs with argument validation, and unknown commands are kept verbatim as RawMenuCommand
+// Argument counts and literal markers follow the command forms reviewed in schemas/knowledge/v1, and slot, MPN, and enum names come directly from the value sets of the same guide
+// Numeric arguments keep their original text, so encoding a decoded command reproduces the original byte for byte
+
+const (
+	menuAttachBoneMarker = "ボーンにアタッチ"
+	menuAttachSlotMarker = "アタッチ"
+	menuSplitPrefix      = "split:"
+	menuSlotPrefix       = "slot="
+	menuAnimeLoopFlag    = "loop"
+)
+
+// MenuCommand 是一条类型化菜单命令 / MenuCommand is one typed menu command
+type MenuCommand interface {
+	// Opcode 返回命令关键字 / Opcode returns the command keyword
+	Opcode() string
+	// Encode 校验字段并编码为原始命令 / Encode validates the fields and encodes them as a raw command
+	Encode() (Command, error)
+}
+
+// MenuInt 是以原始文本保存的整数参数 / MenuInt is an integer argument kept as its original text
+type MenuInt string
+
+// NewMenuInt 返回整数的规范文本形式 / NewMenuInt returns the canonical text form of an integer
+func NewMenuInt(value int32) MenuInt {
+	return MenuInt(strconv.FormatInt(int64(value), 10))
+}
+
+// Int32 解析整数参数 / Int32 parses the integer argument
+func (v MenuInt) Int32() (int32, error) {
+	value, err := strconv.ParseInt(string(v), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an int32", string(v))
+	}
+	return int32(value), nil
+}
+
+// MenuFloat 是以原始文本保存的浮点参数 / MenuFloat is a float argument kept as its original text
+type MenuFloat string
+
+// NewMenuFloat 返回浮点数的最短文本形式 / NewMenuFloat returns the shortest text form of a float
+func NewMenuFloat(value float32) MenuFloat {
+	return MenuFloat(strconv.FormatFloat(float64(value), 'g', -1, 32))
+}
+
+// Float32 解析有限的十进制浮点参数 / Float32 parses a finite decimal float argument
+func (v MenuFloat) Float32() (float32, error) {
+	text := string(v)
+	if strings.ContainsAny(text, "xX_") {
+		return 0, fmt.Errorf("%q is not a decimal float", text)
+	}
+	value, err := strconv.ParseFloat(text, 32)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("%q is not a finite float", text)
+	}
+	return float32(value), nil
+}
+
+// RawMenuCommand 原样保存没有类型化结构的命令 / RawMenuCommand keeps a command without a typed struct verbatim
+type RawMenuCommand struct {
+	Command Command // 原始命令 / Raw command
+}
+
+// MenuEndCommand 对应 end，立即停止解释 / MenuEndCommand corresponds to end and stops interpretation immediately
+type MenuEndCommand struct{}
+
+// MenuNameCommand 对应 name / MenuNameCommand corresponds to name
+type MenuNameCommand struct {
+	Name string // 显示名称 / Display name
+}
+
+// MenuDescriptionCommand 对应 setumei / MenuDescriptionCommand corresponds to setumei
+type MenuDescriptionCommand struct {
+	Text string // 说明文本 / Description text
+}
+
+// MenuCategoryCommand 对应 category / MenuCategoryCommand corresponds to category
+type MenuCategoryCommand struct {
+	MPN string // 当前类别 MPN / Current category MPN
+}
+
+// MenuIconCommand 对应 icon 或 icons / MenuIconCommand corresponds to icon or icons
+type MenuIconCommand struct {
+	Keyword string // icon 或 icons，为空时写 icon / icon or icons, writing icon when empty
+	Texture string // 图标贴图资源名 / Icon texture resource name
+}
+
+// MenuPriorityCommand 对应 priority / MenuPriorityCommand corresponds to priority
+type MenuPriorityCommand struct {
+	Value MenuFloat // 编辑器排序优先级 / Editor sorting priority
+}
+
+// MenuFolderCommand 对应 メニューフォルダ / MenuFolderCommand corresponds to メニューフォルダ
+type MenuFolderCommand struct {
+	Folder string // 菜单文件夹，man 表示男性物品 / Menu folder, where man marks a male item
+}
+
+// MenuVersionCommand 对应 ver；Slot 为空时写 3.48 的单参数形式
+// MenuVersionCommand corresponds to ver; the one-argument 3.48 form is written when Slot is empty
+type MenuVersionCommand struct {
+	Slot    string  // 可选槽位 / Optional slot
+	Version MenuInt // 后续 additem 使用的部件版本 / Parts version used by later additem records
+}
+
+// MenuAddItemCommand 对应 additem；AttachBone 与 AttachSlot/AttachPoint 互斥，Split 为完整的 split: 选项
+// MenuAddItemCommand corresponds to additem; AttachBone and AttachSlot/AttachPoint are mutually exclusive, and Split is the complete split: option
+type MenuAddItemCommand struct {
+	Model       string // 模型资源名 / Model resource name
+	Slot        string // 目标槽位 / Destination slot
+	AttachBone  string // ボーンにアタッチ 的骨骼名 / Bone name for ボーンにアタッチ
+	AttachSlot  string // アタッチ 的目标槽位 / Destination slot for アタッチ
+	AttachPoint string // アタッチ 的挂点名 / Attachment point for アタッチ
+	Split       string // 3.48 的 split: 选项 / 3.48 split: option
+}
+
+// MenuDelItemCommand 对应 delitem；Slot 为空时使用当前类别
+// MenuDelItemCommand corresponds to delitem; the current category is used when Slot is empty
+type MenuDelItemCommand struct {
+	Slot string // 可选槽位 / Optional slot
+}
+
+// MenuMaskItemCommand 对应 maskitem；Slot 为空时不执行任何操作
+// MenuMaskItemCommand corresponds to maskitem; it does nothing when Slot is empty
+type MenuMaskItemCommand struct {
+	Slot string // 可选被遮罩槽位 / Optional masked slot
+}
+
+// MenuNoFloorYCommand 对应 nofloory / MenuNoFloorYCommand corresponds to nofloory
+type MenuNoFloorYCommand struct {
+	Slot string // 目标槽位 / Target slot
+}
+
+// MenuNodeCommand 对应 node消去 或 node表示；Slot 为空时使用当前类别
+// MenuNodeCommand corresponds to node消去 or node表示; the current category is used when Slot is empty
+type MenuNodeCommand struct {
+	Show bool   // true 为 node表示，false 为 node消去 / true for node表示, false for node消去
+	Node string // 节点名 / Node name
+	Slot string // 3.48 的 slot= 覆盖槽位 / 3.48 slot= override
+}
+
+// MenuTexCommand 对应 tex 或 テクスチャ変更 / MenuTexCommand corresponds to tex or テクスチャ変更
+type MenuTexCommand struct {
+	Keyword          string // tex 或 テクスチャ変更，为空时写 tex / tex or テクスチャ変更, writing tex when empty
+	Slot             string // 目标槽位 / Target slot
+	MaterialSelector string // 材质索引或 MPN=索引[&MPN=索引...] / Material index or MPN=index[&MPN=index...]
+	Property         string // 贴图属性名 / Texture property name
+	Texture          string // 贴图资源名 / Texture resource name
+	PartsColor       string // 可选无限色通道 / Optional infinite-color channel
+}
+
+// MenuColorCommand 对应 color，颜色分量以 0-255 为单位 / MenuColorCommand corresponds to color, with components in 0-255 units
+type MenuColorCommand struct {
+	Slot          string    // 目标槽位 / Target slot
+	MaterialIndex MenuInt   // 材质索引 / Material index
+	Property      string    // 颜色属性名 / Color property name
+	R, G, B, A    MenuFloat // 颜色分量 / Color components
+}
+
+// MenuManColorCommand 对应 mancolor / MenuManColorCommand corresponds to mancolor
+type MenuManColorCommand struct {
+	R, G, B MenuFloat // 颜色分量 / Color components
+}
+
+// MenuPropCommand 对应 prop / MenuPropCommand corresponds to prop
+type MenuPropCommand struct {
+	MPN   string  // 女仆属性 / Maid property
+	Value MenuInt // 属性数值 / Property value
+}
+
+// MenuMaterialCommand 对应 マテリアル変更 / MenuMaterialCommand corresponds to マテリアル変更
+type MenuMaterialCommand struct {
+	Slot          string  // 目标槽位 / Target slot
+	MaterialIndex MenuInt // 被替换的材质索引 / Index of the replaced material
+	Material      string  // 替换材质资源名 / Replacement material resource name
+}
+
+// MenuShaderCommand 对应 shader / MenuShaderCommand corresponds to shader
+type MenuShaderCommand struct {
+	Slot          string  // 目标槽位 / Target slot
+	MaterialIndex MenuInt // 材质索引 / Material index
+	Shader        string  // Unity 着色器名 / Unity shader name
+}
+
+// MenuTextureCompositeCommand 对应 テクスチャ合成 或 テクスチャセット合成
+// MenuTextureCompositeCommand corresponds to テクスチャ合成 or テクスチャセット合成
+type MenuTextureCompositeCommand struct {
+	Set           bool    // true 为 テクスチャセット合成 / true for テクスチャセット合成
+	Slot          string  // 目标槽位 / Target slot
+	MaterialIndex MenuInt // 材质索引 / Material index
+	Property      string  // 贴图属性名 / Texture property name
+	Layer         MenuInt // 合成层索引 / Composition layer index
+	Texture       string  // 图层贴图资源名 / Layer texture resource name
+	BlendMode     string  // GameUty.SystemMaterial 名称 / GameUty.SystemMaterial name
+}
+
+// MenuItemCommand 对应 アイテム，执行另一个菜单 / MenuItemCommand corresponds to アイテム and runs another menu
+type MenuItemCommand struct {
+	Menu string // 菜单资源名 / Menu resource name
+}
+
+// MenuItemParameterCommand 对应 アイテムパラメータ / MenuItemParameterCommand corresponds to アイテムパラメータ
+type MenuItemParameterCommand struct {
+	Slot  string // 目标槽位 / Target slot
+	Name  string // 参数名 / Parameter name
+	Value string // 参数值 / Parameter value
+}
+
+// MenuHalfUndressCommand 对应 半脱ぎ / MenuHalfUndressCommand corresponds to 半脱ぎ
+type MenuHalfUndressCommand struct {
+	Resource string // 半脱资源名 / Half-undressed resource name
+}
+
+// MenuResourceRefCommand 对应 リソース参照 / MenuResourceRefCommand corresponds to リソース参照
+type MenuResourceRefCommand struct {
+	Key      string // 引用键 / Reference key
+	Resource string // 资源名 / Resource name
+}
+
+// MenuAttachPointCommand 对应 アタッチポイントの設定；HasRotation 为 false 时写三参数位置形式
+// MenuAttachPointCommand corresponds to アタッチポイントの設定; the three-value position form is written when HasRotation is false
+type MenuAttachPointCommand struct {
+	Point       string    // 挂点名 / Attachment point name
+	X, Y, Z     MenuFloat // 本地位置 / Local position
+	HasRotation bool      // 是否包含欧拉角 / Whether Euler angles are present
+	RX, RY, RZ  MenuFloat // 本地欧拉角（度） / Local Euler angles in degrees
+}
+
+// MenuAnimeCommand 对应 anime / MenuAnimeCommand corresponds to anime
+type MenuAnimeCommand struct {
+	Slot      string // 目标槽位 / Target slot
+	Animation string // 动画资源名 / Animation resource name
+	Loop      bool   // 是否写出 loop 标记 / Whether the loop flag is written
+}
+
+// MenuAnimeMaterialCommand 对应 animematerial / MenuAnimeMaterialCommand corresponds to animematerial
+type MenuAnimeMaterialCommand struct {
+	Slot          string  // 目标槽位 / Target slot
+	MaterialIndex MenuInt // 材质索引 / Material index
+}
+
+// menuCommandDecoders 按命令关键字索引类型化解码器 / menuCommandDecoders indexes typed decoders by command keyword
+var menuCommandDecoders = map[string]func(Command) (MenuCommand, error){
+	"end":           decodeMenuEnd,
+	"name":          decodeMenuName,
+	"setumei":       decodeMenuDescription,
+	"category":      decodeMenuCategory,
+	"icon":          decodeMenuIcon,
+	"icons":         decodeMenuIcon,
+	"priority":      decodeMenuPriority,
+	"メニューフォルダ":      decodeMenuFolder,
+	"ver":           decodeMenuVersion,
+	"additem":       decodeMenuAddItem,
+	"delitem":       decodeMenuDelItem,
+	"maskitem":      decodeMenuMaskItem,
+	"nofloory":      decodeMenuNoFloorY,
+	"node消去":        decodeMenuNode,
+	"node表示":        decodeMenuNode,
+	"tex":           decodeMenuTex,
+	"テクスチャ変更":       decodeMenuTex,
+	"color":         decodeMenuColor,
+	"mancolor":      decodeMenuManColor,
+	"prop":          decodeMenuProp,
+	"マテリアル変更":       decodeMenuMaterial,
+	"shader":        decodeMenuShader,
+	"テクスチャ合成":       decodeMenuTextureComposite,
+	"テクスチャセット合成":    decodeMenuTextureComposite,
+	"アイテム":          decodeMenuItem,
+	"アイテムパラメータ":     decodeMenuItemParameter,
+	"半脱ぎ":           decodeMenuHalfUndress,
+	"リソース参照":        decodeMenuResourceRef,
+	"アタッチポイントの設定":   decodeMenuAttachPoint,
+	"anime":         decodeMenuAnime,
+	"animematerial": decodeMenuAnimeMaterial,
+}
+
+// DecodeMenuCommand 把一条原始命令解码为类型化命令
+// 关键字没有类型化结构时返回 *RawMenuCommand；已知命令的参数不合法时返回错误，调用方可自行回退为 RawMenuCommand
+// DecodeMenuCommand decodes one raw command into a typed command
+// A keyword without a typed struct yields *RawMenuCommand; invalid arguments of a known command yield an error, and callers may fall back to RawMenuCommand themselves
+func DecodeMenuCommand(cmd Command) (MenuCommand, error) {
+	decode, ok := menuCommandDecoders[cmd.Command]
+	if !ok {
+		if cmd.Command == "" {
+			return nil, fmt.Errorf("menu command name is empty")
+		}
+		return &RawMenuCommand{Command: cloneMenuCommand(cmd)}, nil
+	}
+	typed, err := decode(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.Command, err)
+	}
+	return typed, nil
+}
+
+// DecodeMenuCommands 按顺序解码全部命令，错误中包含命令序号
+// DecodeMenuCommands decodes every command in order, with the command index in any error
+func DecodeMenuCommands(commands []Command) ([]MenuCommand, error) {
+	typed := make([]MenuCommand, 0, len(commands))
+	for i, cmd := range commands {
+		decoded, err := DecodeMenuCommand(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("command %d: %w", i, err)
+		}
+		typed = append(typed, decoded)
+	}
+	return typed, nil
+}
+
+// EncodeMenuCommands 按顺序编码全部类型化命令 / EncodeMenuCommands encodes every typed command in order
+func EncodeMenuCommands(commands []MenuCommand) ([]Command, error) {
+	encoded := make([]Command, 0, len(commands))
+	for i, typed := range commands {
+		if typed == nil {
+			return nil, fmt.Errorf("command %d is nil", i)
+		}
+		cmd, err := typed.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("command %d: %w", i, err)
+		}
+		encoded = append(encoded, cmd)
+	}
+	return encoded, nil
+}
+
+// TypedCommands 返回菜单命令的类型化视图 / TypedCommands returns a typed view of the menu commands
+func (m *Menu) TypedCommands() ([]MenuCommand, error) {
+	if m == nil {
+		return nil, fmt.Errorf("nil menu")
+	}
+	return DecodeMenuCommands(m.Commands)
+}
+
+// SetTypedCommands 编码类型化命令并替换菜单命令，BodySize 在 Dump 时重新计算
+// SetTypedCommands encodes typed commands and replaces the menu commands; BodySize is recalculated by Dump
+func (m *Menu) SetTypedCommands(commands []MenuCommand) error {
+	if m == nil {
+		return fmt.Errorf("nil menu")
+	}
+	encoded, err := EncodeMenuCommands(commands)
+	if err != nil {
+		return err
+	}
+	m.Commands = encoded
+	return nil
+}
+
+// encodeValidatedMenuCommand 组装原始命令并用同一解码器校验，使编码与解码接受完全相同的参数
+// encodeValidatedMenuCommand assembles a raw command and validates it with the same decoder, so encoding and decoding accept exactly the same arguments
+func encodeValidatedMenuCommand(opcode string, args ...string) (Command, error) {
+	cmd := Command{Command: opcode, Args: args}
+	if len(args) == 0 {
+		cmd.Args = nil
+	}
+	if _, err := DecodeMenuCommand(cmd); err != nil {
+		return Command{}, err
+	}
+	return cmd, nil
+}
+
+// cloneMenuCommand 复制命令及其参数切片 / cloneMenuCommand copies a command and its argument slice
+func cloneMenuCommand(cmd Command) Command {
+	clone := Command{Command: cmd.Command}
+	if cmd.Args != nil {
+		clone.Args = append([]string(nil), cmd.Args...)
+	}
+	return clone
+}
+
+func (c *RawMenuCommand) Opcode() string { return c.Command.Command }
+func (c *RawMenuCommand) Encode() (Command, error) {
+	if c.Command.Command == "" {
+		return Command{}, fmt.Errorf("menu command name is empty")
+	}
+	return cloneMenuCommand(c.Command), nil
+}
+
+func (c *MenuEndCommand) Opcode() string           { return "end" }
+func (c *MenuEndCommand) Encode() (Command, error) { return encodeValidatedMenuCommand("end") }
+
+func (c *MenuNameCommand) Opcode() string { return "name" }
+func (c *MenuNameCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("name", c.Name)
+}
+
+func (c *MenuDescriptionCommand) Opcode() string { return "setumei" }
+func (c *MenuDescriptionCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("setumei", c.Text)
+}
+
+func (c *MenuCategoryCommand) Opcode() string { return "category" }
+func (c *MenuCategoryCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("category", c.MPN)
+}
+
+func (c *MenuIconCommand) Opcode() string {
+	if c.Keyword == "" {
+		return "icon"
+	}
+	return c.Keyword
+}
+func (c *MenuIconCommand) Encode() (Command, error) {
+	if c.Keyword != "" && c.Keyword != "icon" && c.Keyword != "icons" {
+		return Command{}, fmt.Errorf("icon keyword %q is neither icon nor icons", c.Keyword)
+	}
+	return encodeValidatedMenuCommand(c.Opcode(), c.Texture)
+}
+
+func (c *MenuPriorityCommand) Opcode() string { return "priority" }
+func (c *MenuPriorityCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("priority", string(c.Value))
+}
+
+func (c *MenuFolderCommand) Opcode() string { return "メニューフォルダ" }
+func (c *MenuFolderCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("メニューフォルダ", c.Folder)
+}
+
+func (c *MenuVersionCommand) Opcode() string { return "ver" }
+func (c *MenuVersionCommand) Encode() (Command, error) {
+	if c.Slot == "" {
+		return encodeValidatedMenuCommand("ver", string(c.Version))
+	}
+	return encodeValidatedMenuCommand("ver", c.Slot, string(c.Version))
+}
+
+func (c *MenuAddItemCommand) Opcode() string { return "additem" }
+func (c *MenuAddItemCommand) Encode() (Command, error) {
+	args := []string{c.Model, c.Slot}
+	switch {
+	case c.AttachBone != "" && (c.AttachSlot != "" || c.AttachPoint != ""):
+		return Command{}, fmt.Errorf("additem: AttachBone and AttachSlot/AttachPoint are mutually exclusive")
+	case c.AttachBone != "":
+		args = append(args, menuAttachBoneMarker, c.AttachBone)
+	case c.AttachSlot != "" || c.AttachPoint != "":
+		args = append(args, menuAttachSlotMarker, c.AttachSlot, c.AttachPoint)
+	}
+	if c.Split != "" {
+		args = append(args, c.Split)
+	}
+	return encodeValidatedMenuCommand("additem", args...)
+}
+
+func (c *MenuDelItemCommand) Opcode() string { return "delitem" }
+func (c *MenuDelItemCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("delitem", optionalMenuArgs(c.Slot)...)
+}
+
+func (c *MenuMaskItemCommand) Opcode() string { return "maskitem" }
+func (c *MenuMaskItemCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("maskitem", optionalMenuArgs(c.Slot)...)
+}
+
+func (c *MenuNoFloorYCommand) Opcode() string { return "nofloory" }
+func (c *MenuNoFloorYCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("nofloory", c.Slot)
+}
+
+func (c *MenuNodeCommand) Opcode() string {
+	if c.Show {
+		return "node表示"
+	}
+	return "node消去"
+}
+func (c *MenuNodeCommand) Encode() (Command, error) {
+	args := []string{c.Node}
+	if c.Slot != "" {
+		args = append(args, menuSlotPrefix+c.Slot)
+	}
+	return encodeValidatedMenuCommand(c.Opcode(), args...)
+}
+
+func (c *MenuTexCommand) Opcode() string {
+	if c.Keyword == "" {
+		return "tex"
+	}
+	return c.Keyword
+}
+func (c *MenuTexCommand) Encode() (Command, error) {
+	if c.Keyword != "" && c.Keyword != "tex" && c.Keyword != "テクスチャ変更" {
+		return Command{}, fmt.Errorf("tex keyword %q is neither tex nor テクスチャ変更", c.Keyword)
+	}
+	args := []string{c.Slot, c.MaterialSelector, c.Property, c.Texture}
+	return encodeValidatedMenuCommand(c.Opcode(), append(args, optionalMenuArgs(c.PartsColor)...)...)
+}
+
+func (c *MenuColorCommand) Opcode() string { return "color" }
+func (c *MenuColorCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("color", c.Slot, string(c.MaterialIndex), c.Property, string(c.R), string(c.G), string(c.B), string(c.A))
+}
+
+func (c *MenuManColorCommand) Opcode() string { return "mancolor" }
+func (c *MenuManColorCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("mancolor", string(c.R), string(c.G), string(c.B))
+}
+
+func (c *MenuPropCommand) Opcode() string { return "prop" }
+func (c *MenuPropCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("prop", c.MPN, string(c.Value))
+}
+
+func (c *MenuMaterialCommand) Opcode() string { return "マテリアル変更" }
+func (c *MenuMaterialCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("マテリアル変更", c.Slot, string(c.MaterialIndex), c.Material)
+}
+
+func (c *MenuShaderCommand) Opcode() string { return "shader" }
+func (c *MenuShaderCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("shader", c.Slot, string(c.MaterialIndex), c.Shader)
+}
+
+func (c *MenuTextureCompositeCommand) Opcode() string {
+	if c.Set {
+		return "テクスチャセット合成"
+	}
+	return "テクスチャ合成"
+}
+func (c *MenuTextureCompositeCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand(c.Opcode(), c.Slot, string(c.MaterialIndex), c.Property, string(c.Layer), c.Texture, c.BlendMode)
+}
+
+func (c *MenuItemCommand) Opcode() string { return "アイテム" }
+func (c *MenuItemCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("アイテム", c.Menu)
+}
+
+func (c *MenuItemParameterCommand) Opcode() string { return "アイテムパラメータ" }
+func (c *MenuItemParameterCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("アイテムパラメータ", c.Slot, c.Name, c.Value)
+}
+
+func (c *MenuHalfUndressCommand) Opcode() string { return "半脱ぎ" }
+func (c *MenuHalfUndressCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("半脱ぎ", c.Resource)
+}
+
+func (c *MenuResourceRefCommand) Opcode() string { return "リソース参照" }
+func (c *MenuResourceRefCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("リソース参照", c.Key, c.Resource)
+}
+
+func (c *MenuAttachPointCommand) Opcode() string { return "アタッチポイントの設定" }
+func (c *MenuAttachPointCommand) Encode() (Command, error) {
+	args := []string{c.Point, string(c.X), string(c.Y), string(c.Z)}
+	if c.HasRotation {
+		args = append(args, string(c.RX), string(c.RY), string(c.RZ))
+	}
+	return encodeValidatedMenuCommand("アタッチポイントの設定", args...)
+}
+
+func (c *MenuAnimeCommand) Opcode() string { return "anime" }
+func (c *MenuAnimeCommand) Encode() (Command, error) {
+	args := []string{c.Slot, c.Animation}
+	if c.Loop {
+		args = append(args, menuAnimeLoopFlag)
+	}
+	return encodeValidatedMenuCommand("anime", args...)
+}
+
+func (c *MenuAnimeMaterialCommand) Opcode() string { return "animematerial" }
+func (c *MenuAnimeMaterialCommand) Encode() (Command, error) {
+	return encodeValidatedMenuCommand("animematerial", c.Slot, string(c.MaterialIndex))
+}
+
+// optionalMenuArgs 把可选参数转换为零或一个参数 / optionalMenuArgs turns an optional argument into zero or one argument
+func optionalMenuArgs(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func decodeMenuEnd(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 0, 0); err != nil {
+		return nil, err
+	}
+	return &MenuEndCommand{}, nil
+}
+
+func decodeMenuName(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	return &MenuNameCommand{Name: cmd.Args[0]}, nil
+}
+
+func decodeMenuDescription(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	return &MenuDescriptionCommand{Text: cmd.Args[0]}, nil
+}
+
+func decodeMenuCategory(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := validateMenuMPN("mpn", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	return &MenuCategoryCommand{MPN: cmd.Args[0]}, nil
+}
+
+func decodeMenuIcon(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("texture-file", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	return &MenuIconCommand{Keyword: cmd.Command, Texture: cmd.Args[0]}, nil
+}
+
+func decodeMenuPriority(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := validateMenuFloat("value", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	return &MenuPriorityCommand{Value: MenuFloat(cmd.Args[0])}, nil
+}
+
+func decodeMenuFolder(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	return &MenuFolderCommand{Folder: cmd.Args[0]}, nil
+}
+
+func decodeMenuVersion(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 2); err != nil {
+		return nil, err
+	}
+	result := &MenuVersionCommand{}
+	versionArg := cmd.Args[len(cmd.Args)-1]
+	if len(cmd.Args) == 2 {
+		if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+			return nil, err
+		}
+		result.Slot = cmd.Args[0]
+	}
+	if err := validateMenuInt("parts-version", versionArg, math.MinInt32); err != nil {
+		return nil, err
+	}
+	result.Version = MenuInt(versionArg)
+	return result, nil
+}
+
+func decodeMenuAddItem(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 2, 6); err != nil {
+		return nil, err
+	}
+	args := cmd.Args
+	result := &MenuAddItemCommand{Model: args[0], Slot: args[1]}
+	if err := validateMenuResource("model-file", result.Model); err != nil {
+		return nil, err
+	}
+	if err := validateMenuSlot("slot", result.Slot); err != nil {
+		return nil, err
+	}
+	rest := args[2:]
+	if len(rest) > 0 && strings.HasPrefix(rest[len(rest)-1], menuSplitPrefix) {
+		result.Split = rest[len(rest)-1]
+		if err := validateMenuSplit(result.Split); err != nil {
+			return nil, err
+		}
+		rest = rest[:len(rest)-1]
+	}
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && rest[0] == menuAttachBoneMarker:
+		result.AttachBone = rest[1]
+		if err := validateMenuResource("bone-name", result.AttachBone); err != nil {
+			return nil, err
+		}
+	case len(rest) == 3 && rest[0] == menuAttachSlotMarker:
+		result.AttachSlot, result.AttachPoint = rest[1], rest[2]
+		if err := validateMenuSlot("attach-slot", result.AttachSlot); err != nil {
+			return nil, err
+		}
+		if err := validateMenuResource("attach-point", result.AttachPoint); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("arguments after the slot must be %s <bone-name> or %s <attach-slot> <attach-point>, optionally followed by %s<split-spec>", menuAttachBoneMarker, menuAttachSlotMarker, menuSplitPrefix)
+	}
+	return result, nil
+}
+
+func decodeMenuDelItem(cmd Command) (MenuCommand, error) {
+	slot, err := decodeOptionalMenuSlot(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &MenuDelItemCommand{Slot: slot}, nil
+}
+
+func decodeMenuMaskItem(cmd Command) (MenuCommand, error) {
+	slot, err := decodeOptionalMenuSlot(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &MenuMaskItemCommand{Slot: slot}, nil
+}
+
+func decodeMenuNoFloorY(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	return &MenuNoFloorYCommand{Slot: cmd.Args[0]}, nil
+}
+
+func decodeMenuNode(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 2); err != nil {
+		return nil, err
+	}
+	result := &MenuNodeCommand{Show: cmd.Command == "node表示", Node: cmd.Args[0]}
+	if err := validateMenuResource("node-name", result.Node); err != nil {
+		return nil, err
+	}
+	if len(cmd.Args) == 2 {
+		slot, ok := strings.CutPrefix(cmd.Args[1], menuSlotPrefix)
+		if !ok {
+			return nil, fmt.Errorf("slot-override %q must start with %s", cmd.Args[1], menuSlotPrefix)
+		}
+		if err := validateMenuSlot("slot-override", slot); err != nil {
+			return nil, err
+		}
+		result.Slot = slot
+	}
+	return result, nil
+}
+
+func decodeMenuTex(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 4, 5); err != nil {
+		return nil, err
+	}
+	result := &MenuTexCommand{Keyword: cmd.Command, Slot: cmd.Args[0], MaterialSelector: cmd.Args[1], Property: cmd.Args[2], Texture: cmd.Args[3]}
+	if err := validateMenuSlot("slot", result.Slot); err != nil {
+		return nil, err
+	}
+	if err := validateMenuMaterialSelector(result.MaterialSelector); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("property-name", result.Property); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("texture-file", result.Texture); err != nil {
+		return nil, err
+	}
+	if len(cmd.Args) == 5 {
+		result.PartsColor = cmd.Args[4]
+		if err := validateMenuValueSet("parts-color", result.PartsColor, menuPartsColorValueSetID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func decodeMenuColor(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 7, 7); err != nil {
+		return nil, err
+	}
+	a := cmd.Args
+	if err := validateMenuSlot("slot", a[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuInt("material-index", a[1], 0); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("property-name", a[2]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuFloats([]string{"r", "g", "b", "a"}, a[3:7]); err != nil {
+		return nil, err
+	}
+	return &MenuColorCommand{
+		Slot: a[0], MaterialIndex: MenuInt(a[1]), Property: a[2],
+		R: MenuFloat(a[3]), G: MenuFloat(a[4]), B: MenuFloat(a[5]), A: MenuFloat(a[6]),
+	}, nil
+}
+
+func decodeMenuManColor(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 3, 3); err != nil {
+		return nil, err
+	}
+	if err := validateMenuFloats([]string{"r", "g", "b"}, cmd.Args); err != nil {
+		return nil, err
+	}
+	return &MenuManColorCommand{R: MenuFloat(cmd.Args[0]), G: MenuFloat(cmd.Args[1]), B: MenuFloat(cmd.Args[2])}, nil
+}
+
+func decodeMenuProp(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 2, 2); err != nil {
+		return nil, err
+	}
+	if err := validateMenuMPN("mpn", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuInt("value", cmd.Args[1], math.MinInt32); err != nil {
+		return nil, err
+	}
+	return &MenuPropCommand{MPN: cmd.Args[0], Value: MenuInt(cmd.Args[1])}, nil
+}
+
+func decodeMenuMaterial(cmd Command) (MenuCommand, error) {
+	slot, index, value, err := decodeMenuSlotMaterial(cmd, "material-file")
+	if err != nil {
+		return nil, err
+	}
+	return &MenuMaterialCommand{Slot: slot, MaterialIndex: index, Material: value}, nil
+}
+
+func decodeMenuShader(cmd Command) (MenuCommand, error) {
+	slot, index, value, err := decodeMenuSlotMaterial(cmd, "shader-name")
+	if err != nil {
+		return nil, err
+	}
+	return &MenuShaderCommand{Slot: slot, MaterialIndex: index, Shader: value}, nil
+}
+
+func decodeMenuTextureComposite(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 6, 6); err != nil {
+		return nil, err
+	}
+	a := cmd.Args
+	if err := validateMenuSlot("slot", a[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuInt("material-index", a[1], 0); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("property-name", a[2]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuInt("layer-index", a[3], 0); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("texture-file", a[4]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuValueSet("blend-mode", a[5], menuBlendModeValueSetID); err != nil {
+		return nil, err
+	}
+	return &MenuTextureCompositeCommand{
+		Set: cmd.Command == "テクスチャセット合成", Slot: a[0], MaterialIndex: MenuInt(a[1]), Property: a[2],
+		Layer: MenuInt(a[3]), Texture: a[4], BlendMode: a[5],
+	}, nil
+}
+
+func decodeMenuItem(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("menu-file", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	return &MenuItemCommand{Menu: cmd.Args[0]}, nil
+}
+
+func decodeMenuItemParameter(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 3, 3); err != nil {
+		return nil, err
+	}
+	if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("parameter-name", cmd.Args[1]); err != nil {
+		return nil, err
+	}
+	return &MenuItemParameterCommand{Slot: cmd.Args[0], Name: cmd.Args[1], Value: cmd.Args[2]}, nil
+}
+
+func decodeMenuHalfUndress(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 1, 1); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("resource-name", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	return &MenuHalfUndressCommand{Resource: cmd.Args[0]}, nil
+}
+
+func decodeMenuResourceRef(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 2, 2); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("key", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("resource-name", cmd.Args[1]); err != nil {
+		return nil, err
+	}
+	return &MenuResourceRefCommand{Key: cmd.Args[0], Resource: cmd.Args[1]}, nil
+}
+
+func decodeMenuAttachPoint(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 4, 7); err != nil {
+		return nil, err
+	}
+	a := cmd.Args
+	if len(a) != 4 && len(a) != 7 {
+		return nil, fmt.Errorf("expected 4 or 7 arguments, got %d", len(a))
+	}
+	if err := validateMenuResource("point-name", a[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuFloats([]string{"x", "y", "z", "rx", "ry", "rz"}[:len(a)-1], a[1:]); err != nil {
+		return nil, err
+	}
+	result := &MenuAttachPointCommand{Point: a[0], X: MenuFloat(a[1]), Y: MenuFloat(a[2]), Z: MenuFloat(a[3])}
+	if len(a) == 7 {
+		result.HasRotation = true
+		result.RX, result.RY, result.RZ = MenuFloat(a[4]), MenuFloat(a[5]), MenuFloat(a[6])
+	}
+	return result, nil
+}
+
+func decodeMenuAnime(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 2, 3); err != nil {
+		return nil, err
+	}
+	if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuResource("animation-file", cmd.Args[1]); err != nil {
+		return nil, err
+	}
+	result := &MenuAnimeCommand{Slot: cmd.Args[0], Animation: cmd.Args[1]}
+	if len(cmd.Args) == 3 {
+		if cmd.Args[2] != menuAnimeLoopFlag {
+			return nil, fmt.Errorf("third argument %q must be %s", cmd.Args[2], menuAnimeLoopFlag)
+		}
+		result.Loop = true
+	}
+	return result, nil
+}
+
+func decodeMenuAnimeMaterial(cmd Command) (MenuCommand, error) {
+	if err := requireMenuArgCount(cmd, 2, 2); err != nil {
+		return nil, err
+	}
+	if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+		return nil, err
+	}
+	if err := validateMenuInt("material-index", cmd.Args[1], 0); err != nil {
+		return nil, err
+	}
+	return &MenuAnimeMaterialCommand{Slot: cmd.Args[0], MaterialIndex: MenuInt(cmd.Args[1])}, nil
+}
+
+// decodeOptionalMenuSlot 解码零或一个槽位参数 / decodeOptionalMenuSlot decodes zero or one slot argument
+func decodeOptionalMenuSlot(cmd Command) (string, error) {
+	if err := requireMenuArgCount(cmd, 0, 1); err != nil {
+		return "", err
+	}
+	if len(cmd.Args) == 0 {
+		return "", nil
+	}
+	if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+		return "", err
+	}
+	return cmd.Args[0], nil
+}
+
+// decodeMenuSlotMaterial 解码 <slot> <material-index> <value> 形式 / decodeMenuSlotMaterial decodes the <slot> <material-index> <value> form
+func decodeMenuSlotMaterial(cmd Command, valueName string) (string, MenuInt, string, error) {
+	if err := requireMenuArgCount(cmd, 3, 3); err != nil {
+		return "", "", "", err
+	}
+	if err := validateMenuSlot("slot", cmd.Args[0]); err != nil {
+		return "", "", "", err
+	}
+	if err := validateMenuInt("material-index", cmd.Args[1], 0); err != nil {
+		return "", "", "", err
+	}
+	if err := validateMenuResource(valueName, cmd.Args[2]); err != nil {
+		return "", "", "", err
+	}
+	return cmd.Args[0], MenuInt(cmd.Args[1]), cmd.Args[2], nil
+}
+
+// requireMenuArgCount 检查参数个数 / requireMenuArgCount checks the argument count
+func requireMenuArgCount(cmd Command, min, max int) error {
+	count := len(cmd.Args)
+	if count >= min && count <= max {
+		return nil
+	}
+	if min == max {
+		return fmt.Errorf("expected %d arguments, got %d", min, count)
+	}
+	return fmt.Errorf("expected %d to %d arguments, got %d", min, max, count)
+}
+
+// validateMenuResource 要求资源名或标识符非空 / validateMenuResource requires a nonempty resource name or identifier
+func validateMenuResource(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is empty", name)
+	}
+	return nil
+}
+
+// validateMenuInt 要求 int32 参数不小于 minimum / validateMenuInt requires an int32 argument no less than minimum
+func validateMenuInt(name, value string, minimum int32) error {
+	parsed, err := MenuInt(value).Int32()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if parsed < minimum {
+		return fmt.Errorf("%s %d is negative", name, parsed)
+	}
+	return nil
+}
+
+// validateMenuFloat 要求有限浮点参数 / validateMenuFloat requires a finite float argument
+func validateMenuFloat(name, value string) error {
+	if _, err := MenuFloat(value).Float32(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// validateMenuFloats 按名称依次校验浮点参数 / validateMenuFloats validates float arguments in order by name
+func validateMenuFloats(names []string, values []string) error {
+	for i, value := range values {
+		if err := validateMenuFloat(names[i], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMenuSlot 要求参数是任一已审核版本的 TBody.SlotID 名称（忽略大小写）
+// validateMenuSlot requires a TBody.SlotID name from either reviewed game version, ignoring case
+func validateMenuSlot(name, value string) error {
+	return validateMenuValueSet(name, value, menuSlotValueSetID)
+}
+
+// validateMenuMPN 要求参数是任一已审核版本的 MPN 名称（忽略大小写）
+// validateMenuMPN requires an MPN name from either reviewed game version, ignoring case
+func validateMenuMPN(name, value string) error {
+	return validateMenuValueSet(name, value, menuMPNValueSetID)
+}
+
+// validateMenuMaterialSelector 校验 tex 的材质索引或 MPN=索引[&MPN=索引...] 映射
+// validateMenuMaterialSelector validates the tex material index or MPN=index[&MPN=index...] mapping
+func validateMenuMaterialSelector(value string) error {
+	if !strings.Contains(value, "=") {
+		return validateMenuInt("material-selector", value, 0)
+	}
+	for _, pair := range strings.Split(value, "&") {
+		mpn, index, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("material-selector entry %q is not MPN=index", pair)
+		}
+		if err := validateMenuMPN("material-selector", mpn); err != nil {
+			return err
+		}
+		if err := validateMenuInt("material-selector", index, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMenuSplit 校验 additem 的 split:<slot>=<rule>[&<slot>=<rule>...] 选项
+// validateMenuSplit validates the additem split:<slot>=<rule>[&<slot>=<rule>...] option
+func validateMenuSplit(value string) error {
+	spec := strings.TrimPrefix(value, menuSplitPrefix)
+	if spec == "" {
+		return fmt.Errorf("split-option is empty")
+	}
+	for _, rule := range strings.Split(spec, "&") {
+		slot, body, ok := strings.Cut(rule, "=")
+		if !ok || body == "" {
+			return fmt.Errorf("split rule %q is not <slot>=<rule>", rule)
+		}
+		if err := validateMenuSlot("split slot", slot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	menuSlotValueSetID       = "slot"
+	menuMPNValueSetID        = "mpn"
+	menuPartsColorValueSetID = "com3d2.maid_parts_color"
+	menuBlendModeValueSetID  = "blend-mode"
+)
+
+var (
+	menuValueSetsOnce sync.Once
+	menuValueSets     map[string]map[string]struct{}
+	menuValueSetsErr  error
+)
+
+// loadMenuValueSets 从 com3d2.menu 指南读取一次值集合；槽位与 MPN 合并两个审核版本，混合模式取自命令形式的允许值，名称统一转为小写
+// loadMenuValueSets reads the value sets of the com3d2.menu guide once; slots and MPNs merge both reviewed versions, blend modes come from the allowed values of the command forms, and every name is lower-cased
+func loadMenuValueSets() (map[string]map[string]struct{}, error) {
+	menuValueSetsOnce.Do(func() {
+		guide, found, err := knowledgev1.Decode("com3d2.menu")
+		if err != nil || !found {
+			menuValueSetsErr = fmt.Errorf("load com3d2.menu guide: found=%v err=%v", found, err)
+			return
+		}
+		sets := make(map[string]map[string]struct{})
+		add := func(key string, values []knowledgev1.ValueSetValue) {
+			if sets[key] == nil {
+				sets[key] = make(map[string]struct{})
+			}
+			for _, value := range values {
+				sets[key][strings.ToLower(value.Name)] = struct{}{}
+			}
+		}
+		for _, valueSet := range guide.ValueSets {
+			switch {
+			case strings.HasPrefix(valueSet.ID, "com3d2.tbody_slot_id."):
+				add(menuSlotValueSetID, valueSet.Values)
+			case strings.HasPrefix(valueSet.ID, "com3d2.mpn."):
+				add(menuMPNValueSetID, valueSet.Values)
+			default:
+				add(valueSet.ID, valueSet.Values)
+			}
+		}
+		for _, command := range guide.Commands {
+			for _, form := range command.Forms {
+				for _, argument := range form.Arguments {
+					if argument.Name == menuBlendModeValueSetID && len(argument.AllowedValues) > 0 {
+						values := make([]knowledgev1.ValueSetValue, len(argument.AllowedValues))
+						for i, allowed := range argument.AllowedValues {
+							values[i] = knowledgev1.ValueSetValue{Name: allowed}
+						}
+						add(menuBlendModeValueSetID, values)
+					}
+				}
+			}
+		}
+		for _, key := range []string{menuSlotValueSetID, menuMPNValueSetID, menuPartsColorValueSetID, menuBlendModeValueSetID} {
+			if len(sets[key]) == 0 {
+				menuValueSetsErr = fmt.Errorf("com3d2.menu guide has no %s value set", key)
+				return
+			}
+		}
+		menuValueSets = sets
+	})
+	return menuValueSets, menuValueSetsErr
+}
+
+// validateMenuValueSet 要求参数属于指南中的某个值集合（忽略大小写）/ validateMenuValueSet requires an argument to belong to a guide value set, ignoring case
+func validateMenuValueSet(name, value, setID string) error {
+	sets, err := loadMenuValueSets()
+	if err != nil {
+		return err
+	}
+	if _, ok := sets[setID][strings.ToLower(value)]; !ok {
+		return fmt.Errorf("%s %q is not a known %s name", name, value, menuValueSetLabel(setID))
+	}
+	return nil
+}
+
+// menuValueSetLabel 返回错误信息中使用的值集合名称 / menuValueSetLabel returns the value-set name used in error messages
+func menuValueSetLabel(setID string) string {
+	switch setID {
+	case menuSlotValueSetID:
+		return "TBody.SlotID"
+	case menuMPNValueSetID:
+		return "MPN"
+	case menuPartsColorValueSetID:
+		return "MaidParts.PARTS_COLOR"
+	case menuBlendModeValueSetID:
+		return "GameUty.SystemMaterial"
+	default:
+		return setID
+	}
+}
@@ -0,0 +1,180 @@
+package COM3D2
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	knowledgev1 "github.com/MeidoPromotionAssociation/MeidoSerialization/schemas/knowledge/v1"
+)
+
+// menuProfileSampleArgs 按指南中的参数类型生成一组合法参数 / menuProfileSampleArgs builds valid arguments from the argument types in the guide
+func menuProfileSampleArgs(form knowledgev1.CommandForm, requiredOnly bool) []string {
+	tokens := strings.Fields(form.Syntax)[1:]
+	var args []string
+	for i, token := range tokens {
+		position := i + 1
+		var argument *knowledgev1.CommandArgument
+		for j := range form.Arguments {
+			if form.Arguments[j].Position == position {
+				argument = &form.Arguments[j]
+			}
+		}
+		if argument == nil {
+			args = append(args, token)
+			continue
+		}
+		if requiredOnly && !argument.Required {
+			break
+		}
+		switch {
+		case len(argument.AllowedValues) > 0:
+			args = append(args, argument.AllowedValues[0])
+		case strings.HasPrefix(argument.Type, "split:"):
+			args = append(args, "split:wear=x<0")
+		case strings.HasPrefix(argument.Type, "slot="):
+			args = append(args, "slot=wear")
+		case strings.Contains(argument.Type, "PARTS_COLOR"):
+			args = append(args, "HAIR")
+		case argument.Type == "int", strings.HasPrefix(argument.Type, "int "):
+			args = append(args, "0")
+		case strings.Contains(argument.Type, "SlotID"), strings.Contains(argument.Type, "MPN"):
+			args = append(args, "wear")
+		case strings.HasPrefix(argument.Type, "float"):
+			args = append(args, "1")
+		default:
+			args = append(args, "sample_"+argument.Name)
+		}
+	}
+	return args
+}
+
+func TestMenuCommandsFollowKnowledgeProfile(t *testing.T) {
+	guide, found, err := knowledgev1.Decode("com3d2.menu")
+	if err != nil || !found {
+		t.Fatalf("decode guide: found=%v err=%v", found, err)
+	}
+	profiled := make(map[string]bool)
+	for _, command := range guide.Commands {
+		for _, name := range append([]string{command.Name}, command.Aliases...) {
+			profiled[name] = true
+			if _, ok := menuCommandDecoders[name]; !ok {
+				continue
+			}
+			for _, form := range command.Forms {
+				for _, requiredOnly := range []bool{false, true} {
+					raw := Command{Command: name, Args: menuProfileSampleArgs(form, requiredOnly)}
+					typed, err := DecodeMenuCommand(raw)
+					if err != nil {
+						t.Errorf("%s %v (%s): %v", name, raw.Args, form.Syntax, err)
+						continue
+					}
+					if _, ok := typed.(*RawMenuCommand); ok {
+						t.Errorf("%s decoded as a raw command", name)
+					}
+					encoded, err := typed.Encode()
+					if err != nil {
+						t.Errorf("%s %v: encode: %v", name, raw.Args, err)
+						continue
+					}
+					if typed.Opcode() != name || !reflect.DeepEqual(encoded, cloneMenuCommand(raw)) {
+						t.Errorf("%s round trip: got %+v, want %+v", name, encoded, raw)
+					}
+				}
+			}
+		}
+	}
+	for opcode := range menuCommandDecoders {
+		if !profiled[opcode] {
+			t.Errorf("typed command %s is not in the com3d2.menu guide", opcode)
+		}
+	}
+}
+
+func TestMenuCommandsRejectInvalidArguments(t *testing.T) {
+	for _, cmd := range []Command{
+		{Command: "end", Args: []string{"x"}},
+		{Command: "name"},
+		{Command: "category", Args: []string{"not_a_mpn"}},
+		{Command: "additem", Args: []string{"a.model", "not_a_slot"}},
+		{Command: "additem", Args: []string{"a.model", "wear", "アタッチ", "head"}},
+		{Command: "additem", Args: []string{"a.model", "wear", "split:bogus=x"}},
+		{Command: "node消去", Args: []string{"Bone", "wear"}},
+		{Command: "tex", Args: []string{"wear", "-1", "_MainTex", "a.tex"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "a.tex", "PURPLE"}},
+		{Command: "color", Args: []string{"wear", "0", "_Color", "255", "255", "NaN", "255"}},
+		{Command: "priority", Args: []string{"0x10"}},
+		{Command: "prop", Args: []string{"wear", "1.5"}},
+		{Command: "テクスチャ合成", Args: []string{"wear", "0", "_MainTex", "0", "a.tex", "Max"}},
+		{Command: "anime", Args: []string{"wear", "a.anm", "once"}},
+		{Command: "アタッチポイントの設定", Args: []string{"p", "0", "0", "0", "0"}},
+		{Command: ""},
+	} {
+		if typed, err := DecodeMenuCommand(cmd); err == nil {
+			t.Errorf("%s %v was accepted as %+v", cmd.Command, cmd.Args, typed)
+		}
+	}
+
+	if _, err := (&MenuAddItemCommand{Model: "a.model", Slot: "wear", AttachBone: "Bip01", AttachSlot: "head"}).Encode(); err == nil {
+		t.Error("additem with both attach forms was encoded")
+	}
+	if _, err := (&MenuColorCommand{Slot: "wear", MaterialIndex: NewMenuInt(0), Property: "_Color", R: "1", G: "1", B: "1", A: "inf"}).Encode(); err == nil {
+		t.Error("color with an infinite component was encoded")
+	}
+}
+
+func TestMenuTypedCommandsRoundTrip(t *testing.T) {
+	menu := &Menu{
+		Signature: MenuSignature, Version: 1000, SrcFileName: "src.txt", ItemName: "item", Category: "wear", InfoText: "info",
+		Commands: []Command{
+			{Command: "category", Args: []string{"wear"}},
+			{Command: "priority", Args: []string{"100.50"}},
+			{Command: "additem", Args: []string{"dress.model", "Wear", "ボーンにアタッチ", "Bip01 Spine"}},
+			{Command: "tex", Args: []string{"wear", "wear=0&skirt=1", "_MainTex", "dress.tex", "HAIR"}},
+			{Command: "unknown_mod_command", Args: []string{"a", "", "b"}},
+			{Command: "end"},
+		},
+	}
+	typed, err := menu.TypedCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, ok := typed[4].(*RawMenuCommand); !ok || !reflect.DeepEqual(raw.Command, menu.Commands[4]) {
+		t.Fatalf("unknown command was not kept verbatim: %+v", typed[4])
+	}
+	addItem, ok := typed[2].(*MenuAddItemCommand)
+	if !ok || addItem.AttachBone != "Bip01 Spine" {
+		t.Fatalf("additem = %+v", typed[2])
+	}
+	priority := typed[1].(*MenuPriorityCommand)
+	if value, err := priority.Value.Float32(); err != nil || value != 100.5 || priority.Value != "100.50" {
+		t.Fatalf("priority = %q (%v, %v)", priority.Value, value, err)
+	}
+
+	typed = append(typed[:5], &MenuColorCommand{
+		Slot: "wear", MaterialIndex: NewMenuInt(2), Property: "_Color",
+		R: NewMenuFloat(255), G: NewMenuFloat(128), B: NewMenuFloat(0.5), A: NewMenuFloat(255),
+	}, typed[5])
+	original := append([]Command(nil), menu.Commands...)
+	if err := menu.SetTypedCommands(typed); err != nil {
+		t.Fatal(err)
+	}
+	want := append(append(original[:5:5], Command{Command: "color", Args: []string{"wear", "2", "_Color", "255", "128", "0.5", "255"}}), original[5])
+	if !reflect.DeepEqual(menu.Commands, want) {
+		t.Fatalf("commands = %+v\nwant %+v", menu.Commands, want)
+	}
+
+	var buf bytes.Buffer
+	if err := menu.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reread, err := ReadMenu(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reread.Commands, want) {
+		t.Fatalf("re-read commands = %+v", reread.Commands)
+	}
+}
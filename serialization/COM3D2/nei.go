@@ -1,7 +1,9 @@
 package COM3D2
 
 import (
+	"fmt"
 	"io"
+	"math"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/nei"
 )
@@ -48,3 +50,54 @@ func NewNei(rows uint32, cols uint32, data [][]string) *Nei {
 		TextEncoding: NeiTextEncodingShiftJIS,
 	}
 }
+
+// NeiCSVInfo 是 .nei 编辑 JSON 的根结构，表格本身作为 UTF-8 BOM 的 CSV 附件保存在 JSON 旁（例如 foo.nei.json 对应 foo.nei.json.csv）
+// TextEncoding 选择写回 .nei 时单元格使用的编码，省略时使用 COM3D2 的 Shift-JIS，KCES 表格应设置为 UTF-8
+// NeiCSVInfo is the root of the .nei editing JSON, whose table travels as a UTF-8 BOM CSV attachment beside it (for example foo.nei.json and foo.nei.json.csv)
+// TextEncoding selects the cell encoding used when writing the .nei back; it defaults to COM3D2's Shift-JIS when omitted and KCES tables should set UTF-8
+type NeiCSVInfo struct {
+	TextEncoding NeiTextEncoding `json:"TextEncoding,omitempty"`
+}
+
+// ConvertNeiToCSVInfo 将 Nei 拆分为编辑 JSON 根结构和 CSV 记录
+// ConvertNeiToCSVInfo splits a Nei into the editing JSON root and CSV records
+func ConvertNeiToCSVInfo(table *Nei) (NeiCSVInfo, [][]string, error) {
+	if table == nil {
+		return NeiCSVInfo{}, nil, fmt.Errorf("nei table is nil")
+	}
+	return NeiCSVInfo{TextEncoding: table.TextEncoding}, table.Data, nil
+}
+
+// ConvertCSVInfoToNei 使用编辑 JSON 选择的编码从 CSV 记录构建 Nei，列数不足的行用空单元格补齐
+// ConvertCSVInfoToNei builds a Nei from CSV records using the encoding selected by the editing JSON, padding short rows with empty cells
+func ConvertCSVInfoToNei(info NeiCSVInfo, records [][]string) (*Nei, error) {
+	encoding := info.TextEncoding
+	switch encoding {
+	case "":
+		encoding = NeiTextEncodingShiftJIS
+	case NeiTextEncodingShiftJIS, NeiTextEncodingUTF8:
+	default:
+		return nil, fmt.Errorf("unknown NEI text encoding %q, want %q or %q", info.TextEncoding, NeiTextEncodingShiftJIS, NeiTextEncodingUTF8)
+	}
+	if uint64(len(records)) > math.MaxUint32 {
+		return nil, fmt.Errorf("CSV row count %d exceeds Uint32", uint64(len(records)))
+	}
+	maxCols := 0
+	for _, record := range records {
+		if len(record) > maxCols {
+			maxCols = len(record)
+		}
+	}
+	if uint64(maxCols) > math.MaxUint32 {
+		return nil, fmt.Errorf("CSV column count %d exceeds Uint32", uint64(maxCols))
+	}
+	data := make([][]string, len(records))
+	for i, record := range records {
+		row := make([]string, maxCols)
+		copy(row, record)
+		data[i] = row
+	}
+	table := NewNei(uint32(len(records)), uint32(maxCols), data)
+	table.TextEncoding = encoding
+	return table, nil
+}
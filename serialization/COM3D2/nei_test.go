@@ -66,3 +66,24 @@ func TestNewNeiUsesCOM3D2CellEncoding(t *testing.T) {
 		t.Fatalf("round-trip = %#v", decoded)
 	}
 }
+
+func TestConvertCSVInfoToNeiPadsRowsAndSelectsEncoding(t *testing.T) {
+	table, err := ConvertCSVInfoToNei(NeiCSVInfo{}, [][]string{{"a", "b", "c"}, {"d"}})
+	if err != nil {
+		t.Fatalf("ConvertCSVInfoToNei: %v", err)
+	}
+	if table.Rows != 2 || table.Cols != 3 || table.TextEncoding != NeiTextEncodingShiftJIS {
+		t.Fatalf("table = %+v, want 2x3 Shift-JIS", table)
+	}
+	if want := [][]string{{"a", "b", "c"}, {"d", "", ""}}; !reflect.DeepEqual(table.Data, want) {
+		t.Fatalf("data = %q, want %q", table.Data, want)
+	}
+
+	table, err = ConvertCSVInfoToNei(NeiCSVInfo{TextEncoding: NeiTextEncodingUTF8}, nil)
+	if err != nil || table.Rows != 0 || table.TextEncoding != NeiTextEncodingUTF8 {
+		t.Fatalf("empty UTF-8 table = %+v, %v", table, err)
+	}
+	if _, err := ConvertCSVInfoToNei(NeiCSVInfo{TextEncoding: "EUC-JP"}, nil); err == nil {
+		t.Fatal("unknown text encoding accepted")
+	}
+}
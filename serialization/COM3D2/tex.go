@@ -5,6 +5,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"io"
 	"os"
 	"os/exec"
@@ -13,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio/stream"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
 
@@ -283,16 +287,29 @@ func (t *Tex) Dump(w io.Writer) error {
 	return nil
 }
 
-// ConvertImageToTex 将任意 ImageMagick 支持的文件格式转换为 tex 格式，但不写出
-// 依赖外部库 ImageMagick，并要求 PATH 环境变量可以直接调用 magick 命令
+// texJPEGQuality 是生成 JPEG 载荷或 JPEG 图像时使用的质量，与此前 ImageMagick 路径保持一致
+// texJPEGQuality is the quality used when producing JPEG payloads or images, matching the previous ImageMagick path
+const texJPEGQuality = 90
+
+// TexEncodeOptions 控制图像转换为 Tex 时的载荷选择
+// TexEncodeOptions controls payload selection when converting an image to Tex
+type TexEncodeOptions struct {
+	Compress       bool        // 为 true 且未强制 PNG 时进行 DXT 压缩 / Apply DXT compression when true and PNG is not forced
+	ForcePNG       bool        // 强制使用 PNG 载荷 / Force a PNG payload
+	Quality        bcn.Quality // DXT 编码质量 / DXT encoding quality
+	UseImageMagick bool        // 即使输入可原生解码也使用 ImageMagick / Use ImageMagick even when the input can be decoded natively
+}
+
+// ConvertImageToTex 将图像文件转换为 tex 格式，但不写出
+// PNG、JPEG、GIF 与 DXT1/DXT5 DDS 由内置编解码器处理，其他格式依赖 ImageMagick，并要求 PATH 环境变量可以直接调用 magick 命令
 // forcePNG 为 true 且 compress 为 false 时，tex 数据是原始 PNG 或转换后的 PNG
 // forcePNG 为 false 且 compress 为 false 时，PNG 或 JPG 输入会直接使用，否则有损且无透明通道的输入转换为 JPG，其余输入转换为 PNG
 // forcePNG 为 true 且 compress 为 true 时忽略 compress，结果与 forcePNG 为 true 且 compress 为 false 相同
 // forcePNG 为 false 且 compress 为 true 时进行 DXT 压缩，根据有无透明通道选择 DXT1 或 DXT5
 // 生成版本 1011 的纹理图集需要图片目录中存在同名 .uv.csv 文件，例如 foo.png 对应 foo.png.uv.csv，文件内容每行保存一组 x、y、w、h
 // 没有有效纹理图集矩形时生成版本 1010
-// ConvertImageToTex converts any ImageMagick-supported file format to tex without writing it
-// It depends on ImageMagick and requires the magick command to be directly available through PATH
+// ConvertImageToTex converts an image file to tex without writing it
+// PNG, JPEG, GIF, and DXT1/DXT5 DDS input uses the built-in codec, while other formats depend on ImageMagick and require the magick command to be directly available through PATH
 // When forcePNG is true and compress is false, the tex data is the original PNG or a converted PNG
 // When forcePNG and compress are both false, PNG or JPG input is used directly, otherwise lossy input without alpha becomes JPG and all other input becomes PNG
 // When forcePNG and compress are both true, compress is ignored and the result matches forcePNG true with compress false
@@ -300,47 +317,19 @@ func (t *Tex) Dump(w io.Writer) error {
 // Producing a version-1011 texture atlas requires a sibling .uv.csv file such as foo.png.uv.csv for foo.png, with each row storing x, y, w, and h
 // Version 1010 is produced when no valid texture-atlas rectangles are available
 func ConvertImageToTex(inputPath string, texName string, compress bool, forcePNG bool) (*Tex, error) {
-	// 检查 ImageMagick 是否安装
-	// Check whether ImageMagick is installed
-	err := tools.CheckMagick()
-	if err != nil {
-		return nil, err
-	}
+	return ConvertImageToTexWithOptions(inputPath, texName, TexEncodeOptions{
+		Compress: compress,
+		ForcePNG: forcePNG,
+		Quality:  bcn.QualityNormal,
+	})
+}
 
-	// 尝试读取同名 .uv.csv 文件中的纹理图集矩形
-	// Try to read texture-atlas rectangles from the sibling .uv.csv file
-	var rects []TexRect
-	rectsPath := inputPath + ".uv.csv"
-	if data, err := os.ReadFile(rectsPath); err == nil {
-		// 优先按逗号分隔读取，失败时回退到分号
-		// Read with comma delimiters first and fall back to semicolons on failure
-		reader := tools.NewCSVReaderSkipUTF8BOM(bytes.NewReader(data), 0)
-		records, rErr := reader.ReadAll()
-		if rErr != nil {
-			reader2 := tools.NewCSVReaderSkipUTF8BOM(bytes.NewReader(data), ';')
-			records, rErr = reader2.ReadAll()
-		}
-		if rErr == nil {
-			for _, rec := range records {
-				if len(rec) != 4 {
-					continue
-				}
-				x, xErr := strconv.ParseFloat(strings.TrimSpace(rec[0]), 64)
-				y, yErr := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
-				w, wErr := strconv.ParseFloat(strings.TrimSpace(rec[2]), 64)
-				h, hErr := strconv.ParseFloat(strings.TrimSpace(rec[3]), 64)
-				if xErr != nil || yErr != nil || wErr != nil || hErr != nil {
-					continue
-				}
-				rects = append(rects, TexRect{
-					X: float32(x),
-					Y: float32(y),
-					W: float32(w),
-					H: float32(h),
-				})
-			}
-		}
-	}
+// ConvertImageToTexWithOptions 按 TexEncodeOptions 将图像文件转换为 tex 格式，但不写出
+// 载荷选择规则与 ConvertImageToTex 相同，输入无法原生解码或 UseImageMagick 为 true 时才调用 ImageMagick
+// ConvertImageToTexWithOptions converts an image file to tex using TexEncodeOptions without writing it
+// Payload selection follows ConvertImageToTex, and ImageMagick is only invoked when the input cannot be decoded natively or UseImageMagick is true
+func ConvertImageToTexWithOptions(inputPath string, texName string, opts TexEncodeOptions) (*Tex, error) {
+	rects := readTexRectsCSV(inputPath + ".uv.csv")
 
 	// 存在矩形时使用版本 1011，否则使用版本 1010
 	// Use version 1011 when rectangles exist and version 1010 otherwise
@@ -352,19 +341,197 @@ func ConvertImageToTex(inputPath string, texName string, compress bool, forcePNG
 		rects = nil
 	}
 
+	var (
+		width, height, textureFormat int32
+		data                         []byte
+		err                          error
+	)
+	nativeFormat := tools.DetectNativeImageFile(inputPath)
+	if nativeFormat == "" || opts.UseImageMagick {
+		// 检查 ImageMagick 是否安装
+		// Check whether ImageMagick is installed
+		if err := tools.CheckMagick(); err != nil {
+			if nativeFormat == "" {
+				return nil, fmt.Errorf("%s is not a PNG, JPEG, GIF, or DDS image and ImageMagick is unavailable: %w", inputPath, err)
+			}
+			return nil, err
+		}
+		width, height, textureFormat, data, err = encodeTexPayloadWithMagick(inputPath, opts.Compress, opts.ForcePNG)
+	} else {
+		width, height, textureFormat, data, err = encodeTexPayloadNative(inputPath, nativeFormat, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 组装最终 Tex 结构
+	// Assemble the final Tex value
+	tex := &Tex{
+		Signature:     "CM3D2_TEX",
+		Version:       version,
+		TextureName:   texName,
+		Rects:         rects,
+		Width:         width,
+		Height:        height,
+		TextureFormat: textureFormat,
+		Data:          data,
+	}
+
+	return tex, nil
+}
+
+// readTexRectsCSV 尝试读取纹理图集矩形，文件不存在或格式无效的行会被忽略
+// readTexRectsCSV tries to read texture-atlas rectangles, ignoring a missing file and invalid rows
+func readTexRectsCSV(rectsPath string) []TexRect {
+	data, err := os.ReadFile(rectsPath)
+	if err != nil {
+		return nil
+	}
+
+	// 优先按逗号分隔读取，失败时回退到分号
+	// Read with comma delimiters first and fall back to semicolons on failure
+	reader := tools.NewCSVReaderSkipUTF8BOM(bytes.NewReader(data), 0)
+	records, rErr := reader.ReadAll()
+	if rErr != nil {
+		reader2 := tools.NewCSVReaderSkipUTF8BOM(bytes.NewReader(data), ';')
+		records, rErr = reader2.ReadAll()
+	}
+	if rErr != nil {
+		return nil
+	}
+
+	var rects []TexRect
+	for _, rec := range records {
+		if len(rec) != 4 {
+			continue
+		}
+		x, xErr := strconv.ParseFloat(strings.TrimSpace(rec[0]), 64)
+		y, yErr := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		w, wErr := strconv.ParseFloat(strings.TrimSpace(rec[2]), 64)
+		h, hErr := strconv.ParseFloat(strings.TrimSpace(rec[3]), 64)
+		if xErr != nil || yErr != nil || wErr != nil || hErr != nil {
+			continue
+		}
+		rects = append(rects, TexRect{
+			X: float32(x),
+			Y: float32(y),
+			W: float32(w),
+			H: float32(h),
+		})
+	}
+	return rects
+}
+
+// encodeTexPayloadNative 使用标准库和内置块压缩编码器生成 Tex 载荷
+// encodeTexPayloadNative produces a Tex payload with the standard library and the built-in block-compression encoder
+func encodeTexPayloadNative(inputPath string, nativeFormat tools.NativeImageFormat, opts TexEncodeOptions) (width, height, textureFormat int32, data []byte, err error) {
+	raw, err := os.ReadFile(inputPath)
+	if err != nil {
+		return 0, 0, 0, nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	var img image.Image
+	if nativeFormat == tools.NativeImageDDS {
+		dds, err := bcn.ReadDDS(raw)
+		if err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("failed to read DDS image: %w", err)
+		}
+		// 请求压缩且 DDS 本身为 DXT1 或 DXT5 时直接复用块数据，避免二次有损压缩
+		// Reuse DXT1 or DXT5 blocks directly when compression is requested, avoiding a second lossy pass
+		if texFormat, ok := texFormatFromBCN(dds.Format); ok && opts.Compress && !opts.ForcePNG {
+			w, h := int32(dds.Width), int32(dds.Height)
+			flipped, err := flipBlockCompressedTextureVertically(dds.FirstLevel(), w, h, texFormat)
+			if err != nil {
+				return 0, 0, 0, nil, err
+			}
+			return w, h, texFormat, flipped, nil
+		}
+		img, err = bcn.Decode(dds.Format, dds.FirstLevel(), dds.Width, dds.Height)
+		if err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("failed to decode DDS image: %w", err)
+		}
+	} else {
+		img, _, err = tools.DecodeNativeImage(raw)
+		if err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+	}
+
+	bounds := img.Bounds()
+	width, height = int32(bounds.Dx()), int32(bounds.Dy())
+	useAlpha := bcn.HasTransparency(img)
+
+	// COM3D2 的 TextureResource.CreateTexture2D 仅支持 DXT5、DXT1、ARGB32 和 RGB24
+	// DXT5 与 DXT1 载荷传给 LoadRawTextureData，ARGB32 与 RGB24 载荷传给 LoadImage
+	// COM3D2 TextureResource.CreateTexture2D supports only DXT5, DXT1, ARGB32, and RGB24
+	// DXT5 and DXT1 payloads go to LoadRawTextureData while ARGB32 and RGB24 payloads go to LoadImage
+	switch {
+	case opts.Compress && !opts.ForcePNG:
+		textureFormat, data, err = encodeTexDXT(img, useAlpha, opts.Quality)
+		return width, height, textureFormat, data, err
+	case nativeFormat == tools.NativeImagePNG:
+		// PNG 输入直接使用以避免重复编码
+		// PNG input is used directly to avoid re-encoding
+		return width, height, ARGB32, raw, nil
+	case nativeFormat == tools.NativeImageJPEG && !opts.ForcePNG:
+		return width, height, RGB24, raw, nil
+	default:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		return width, height, ARGB32, buf.Bytes(), nil
+	}
+}
+
+// encodeTexDXT 将正立图像压缩为 DXT1 或 DXT5 并转换为游戏使用的垂直块顺序
+// encodeTexDXT compresses an upright image to DXT1 or DXT5 and converts it to the vertical block order used by the game
+func encodeTexDXT(img image.Image, useAlpha bool, quality bcn.Quality) (int32, []byte, error) {
+	textureFormat, blockFormat := DXT1, bcn.FormatDXT1
+	if useAlpha {
+		textureFormat, blockFormat = DXT5, bcn.FormatDXT5
+	}
+	blocks, err := bcn.Encode(blockFormat, img, &bcn.EncodeOptions{Quality: quality})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to encode %s: %w", blockFormat, err)
+	}
+	bounds := img.Bounds()
+	flipped, err := flipBlockCompressedTextureVertically(blocks, int32(bounds.Dx()), int32(bounds.Dy()), textureFormat)
+	if err != nil {
+		return 0, nil, err
+	}
+	return textureFormat, flipped, nil
+}
+
+// texFormatFromBCN 将块压缩格式映射到 COM3D2 支持的 TextureFormat
+// texFormatFromBCN maps a block-compression format to a TextureFormat supported by COM3D2
+func texFormatFromBCN(format bcn.Format) (int32, bool) {
+	switch format {
+	case bcn.FormatDXT1:
+		return DXT1, true
+	case bcn.FormatDXT5:
+		return DXT5, true
+	default:
+		return 0, false
+	}
+}
+
+// encodeTexPayloadWithMagick 通过 ImageMagick 识别并转换输入图像，调用方需要先确认 ImageMagick 可用
+// encodeTexPayloadWithMagick identifies and converts the input image through ImageMagick, and callers must confirm ImageMagick is available first
+func encodeTexPayloadWithMagick(inputPath string, compress bool, forcePNG bool) (width, height, textureFormat int32, data []byte, err error) {
 	cmdIdentify := exec.Command("magick", "identify", "-format", "%wx%h %[channels] %[depth] %m", inputPath)
 	tools.SetHideWindow(cmdIdentify)
 
 	out, err := cmdIdentify.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to identify image: %w", err)
+		return 0, 0, 0, nil, fmt.Errorf("failed to identify image: %w", err)
 	}
 
 	// 解析类似 512x768 rgba 8 JPEG 的 identify 输出
 	// Parse identify output such as 512x768 rgba 8 JPEG
 	parts := strings.SplitN(strings.TrimSpace(string(out)), " ", 4)
 	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid identify output: %q", out)
+		return 0, 0, 0, nil, fmt.Errorf("invalid identify output: %q", out)
 	}
 
 	// 获取 ImageMagick 报告的图像格式
@@ -394,230 +561,121 @@ func ConvertImageToTex(inputPath string, texName string, compress bool, forcePNG
 	// Parse the image width and height
 	sizeParts := strings.Split(parts[0], "x")
 	if len(sizeParts) != 2 {
-		return nil, fmt.Errorf("invalid size format: %q", parts[0])
+		return 0, 0, 0, nil, fmt.Errorf("invalid size format: %q", parts[0])
 	}
 	widthRaw, err := strconv.ParseInt(sizeParts[0], 10, 32)
 	if err != nil {
-		return nil, fmt.Errorf("invalid width: %w", err)
+		return 0, 0, 0, nil, fmt.Errorf("invalid width: %w", err)
 	}
-	width := int32(widthRaw)
+	width = int32(widthRaw)
 	heightRaw, err := strconv.ParseInt(sizeParts[1], 10, 32)
 	if err != nil {
-		return nil, fmt.Errorf("invalid height: %w", err)
+		return 0, 0, 0, nil, fmt.Errorf("invalid height: %w", err)
 	}
-	height := int32(heightRaw)
+	height = int32(heightRaw)
 
 	channels := strings.ToLower(parts[1])
 	useAlpha := strings.Contains(channels, "a")
 
-	// COM3D2 的 TextureResource.CreateTexture2D 仅支持 DXT5、DXT1、ARGB32 和 RGB24
-	// DXT5 与 DXT1 载荷传给 LoadRawTextureData，ARGB32 与 RGB24 载荷传给 LoadImage
-	// COM3D2 TextureResource.CreateTexture2D supports only DXT5, DXT1, ARGB32, and RGB24
-	// DXT5 and DXT1 payloads go to LoadRawTextureData while ARGB32 and RGB24 payloads go to LoadImage
-	var data []byte
-	var textureFormat int32
-
 	// 请求压缩且未强制 PNG 时转换为 DXT5 或 DXT1
 	// Convert to DXT5 or DXT1 when compression is requested and PNG is not forced
 	if compress && !forcePNG {
-		// 使用内存管道接收 ImageMagick 输出
-		// Use an in-memory pipe to receive ImageMagick output
-		pr, pw := io.Pipe()
-
-		// 在 goroutine 中执行转换并写入管道
-		// Run the conversion in a goroutine and write it to the pipe
-		go func() {
-			dxtType := "dxt1"
-			textureFormat = DXT1
-			if useAlpha {
-				dxtType = "dxt5"
-				textureFormat = DXT5
-			}
-
-			// 让 ImageMagick 通过标准输出写出 DDS
-			// Have ImageMagick write DDS through standard output
-			cmd := exec.Command(
-				"magick", inputPath,
-				"-define", fmt.Sprintf("dds:compression=%s", dxtType),
-				"dds:-",
-			)
-			tools.SetHideWindow(cmd)
-			cmd.Stdout = pw
-
-			err := cmd.Run()
-			if err != nil {
-				err = pw.CloseWithError(fmt.Errorf("failed to convert image to DDS: %w", err))
-				if err != nil {
-					return
-				}
-				return
-			}
-
-			// 正常关闭管道写端
-			// Close the pipe writer normally
-			pw.Close()
-		}()
+		dxtType := "dxt1"
+		textureFormat = DXT1
+		if useAlpha {
+			dxtType = "dxt5"
+			textureFormat = DXT5
+		}
 
-		// 从管道读取转换结果
-		// Read the conversion result from the pipe
-		data, err = io.ReadAll(pr)
+		// 让 ImageMagick 通过标准输出写出 DDS
+		// Have ImageMagick write DDS through standard output
+		data, err = runMagickToBytes(inputPath, "-define", fmt.Sprintf("dds:compression=%s", dxtType), "dds:-")
 		if err != nil {
-			return nil, err
+			return 0, 0, 0, nil, fmt.Errorf("failed to convert image to DDS: %w", err)
 		}
 
 		// DXT 结果剥离 128 字节 DDS 头部后转换为游戏使用的垂直块顺序
 		// Strip the 128-byte DDS header from DXT output and convert it to the vertical block order used by the game
-		if (textureFormat == DXT1 || textureFormat == DXT5) && len(data) > 128 {
+		if len(data) > 128 {
 			if string(data[:4]) == "DDS " {
 				data = data[128:]
 			}
 			data, err = flipBlockCompressedTextureVertically(data, width, height, textureFormat)
 			if err != nil {
-				return nil, err
+				return 0, 0, 0, nil, err
 			}
 		}
-	} else {
-		// forcePNG 为 true 时强制转换为 PNG
-		// Force conversion to PNG when forcePNG is true
-		if forcePNG {
-			// 检查原始文件能否直接作为带透明通道的 PNG 使用
-			// Check whether the source can be used directly as a PNG with alpha
-			isDirectlyUsable := isPNG && useAlpha
-
-			if isDirectlyUsable {
-				// 直接读取原始文件以避免重复编码
-				// Read the source file directly to avoid re-encoding
-				data, err = os.ReadFile(inputPath)
-				if err != nil {
-					return nil, fmt.Errorf("failed to read image file: %w", err)
-				}
-
-				textureFormat = ARGB32
-			} else {
-				// 使用内存管道执行 PNG 转换
-				// Use an in-memory pipe for PNG conversion
-				pr, pw := io.Pipe()
-
-				go func() {
-					// 转换为保留透明通道的 PNG
-					// Convert to PNG while retaining the alpha channel
-					cmd := exec.Command("magick", inputPath, "png:-")
-					tools.SetHideWindow(cmd)
-					cmd.Stdout = pw
-					err := cmd.Run()
-					if err != nil {
-						err := pw.CloseWithError(fmt.Errorf("failed to convert image to PNG: %w", err))
-						if err != nil {
-							return
-						}
-						return
-					}
-					// 正常关闭管道写端
-					// Close the pipe writer normally
-					pw.Close()
-				}()
-
-				// 从管道读取 PNG 数据
-				// Read PNG data from the pipe
-				data, err = io.ReadAll(pr)
-				if err != nil {
-					return nil, err
-				}
+		return width, height, textureFormat, data, nil
+	}
 
-				// PNG 数据使用 ARGB32 纹理格式
-				// PNG data uses the ARGB32 texture format
-				textureFormat = ARGB32
-			}
-		} else {
-			// 检查原始 PNG 或 JPEG 能否直接使用
-			// Check whether the source PNG or JPEG can be used directly
-			isDirectlyUsable := (isPNG && useAlpha) || (isJPEG && !useAlpha)
-
-			if isDirectlyUsable {
-				// 直接读取原始文件以避免重复编码
-				// Read the source file directly to avoid re-encoding
-				data, err = os.ReadFile(inputPath)
-				if err != nil {
-					return nil, fmt.Errorf("failed to read image file: %w", err)
-				}
+	// 检查原始文件能否直接使用，forcePNG 为 true 时只接受带透明通道的 PNG
+	// Check whether the source can be used directly, accepting only PNG with alpha when forcePNG is true
+	isDirectlyUsable := isPNG && useAlpha
+	if !forcePNG {
+		isDirectlyUsable = isDirectlyUsable || (isJPEG && !useAlpha)
+	}
+	if isDirectlyUsable {
+		// 直接读取原始文件以避免重复编码
+		// Read the source file directly to avoid re-encoding
+		data, err = os.ReadFile(inputPath)
+		if err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("failed to read image file: %w", err)
+		}
+		if isPNG {
+			return width, height, ARGB32, data, nil
+		}
+		return width, height, RGB24, data, nil
+	}
 
-				// 根据直接使用的图像格式设置纹理格式
-				// Set the texture format from the directly used image format
-				if isPNG {
-					textureFormat = ARGB32
-				} else {
-					textureFormat = RGB24
-				}
-			} else {
-				// 使用内存管道转换不兼容的输入格式
-				// Use an in-memory pipe to convert an incompatible input format
-				pr, pw := io.Pipe()
-
-				go func() {
-					var cmd *exec.Cmd
-
-					if useAlpha || !isLossyFormat {
-						// 有透明通道或输入无损时转换为 PNG
-						// Convert to PNG when alpha is present or the input is lossless
-						cmd = exec.Command("magick", inputPath, "png:-")
-						tools.SetHideWindow(cmd)
-						textureFormat = ARGB32
-					} else {
-						// 无透明通道的有损输入转换为 JPEG
-						// Convert lossy input without alpha to JPEG
-						quality := "90"
-						cmd = exec.Command("magick", inputPath, "-quality", quality, "jpg:-")
-						tools.SetHideWindow(cmd)
-						textureFormat = RGB24
-					}
-
-					cmd.Stdout = pw
-					err := cmd.Run()
-					if err != nil {
-						err = pw.CloseWithError(fmt.Errorf("failed to convert image: %w", err))
-						if err != nil {
-							return
-						}
-						return
-					}
-
-					// 正常关闭管道写端
-					// Close the pipe writer normally
-					pw.Close()
-				}()
-
-				// 从管道读取转换结果
-				// Read the conversion result from the pipe
-				data, err = io.ReadAll(pr)
-				if err != nil {
-					return nil, err
-				}
-			}
+	if forcePNG || useAlpha || !isLossyFormat {
+		// 强制 PNG、有透明通道或输入无损时转换为 PNG
+		// Convert to PNG when PNG is forced, alpha is present, or the input is lossless
+		data, err = runMagickToBytes(inputPath, "png:-")
+		if err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("failed to convert image to PNG: %w", err)
 		}
+		return width, height, ARGB32, data, nil
 	}
 
-	// 组装最终 Tex 结构
-	// Assemble the final Tex value
-	tex := &Tex{
-		Signature:     "CM3D2_TEX",
-		Version:       version,
-		TextureName:   texName,
-		Rects:         rects,
-		Width:         width,
-		Height:        height,
-		TextureFormat: textureFormat,
-		Data:          data,
+	// 无透明通道的有损输入转换为 JPEG
+	// Convert lossy input without alpha to JPEG
+	data, err = runMagickToBytes(inputPath, "-quality", strconv.Itoa(texJPEGQuality), "jpg:-")
+	if err != nil {
+		return 0, 0, 0, nil, fmt.Errorf("failed to convert image: %w", err)
 	}
+	return width, height, RGB24, data, nil
+}
 
-	return tex, nil
+// runMagickToBytes 运行 magick 并返回标准输出，失败时附带标准错误内容
+// runMagickToBytes runs magick and returns standard output, including standard error on failure
+func runMagickToBytes(args ...string) ([]byte, error) {
+	cmd := exec.Command("magick", args...)
+	tools.SetHideWindow(cmd)
+	var stderrBuf bytes.Buffer
+	cmd.Stderr = &stderrBuf
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w, stderr: %s", err, stderrBuf.String())
+	}
+	return out, nil
 }
 
-// ConvertImageToTexAndWrite 将任意 ImageMagick 支持的文件格式转换为 tex 格式并写出
+// ConvertImageToTexAndWrite 将图像文件转换为 tex 格式并写出
 // 转换规则与 ConvertImageToTex 相同，包括 forcePNG、compress 和同名 .uv.csv 的处理
-// ConvertImageToTexAndWrite converts any ImageMagick-supported file format to tex and writes it
+// ConvertImageToTexAndWrite converts an image file to tex and writes it
 // It uses the same forcePNG, compress, and sibling .uv.csv behavior as ConvertImageToTex
 func ConvertImageToTexAndWrite(inputPath string, texName string, compress bool, forcePNG bool, outputPath string) error {
-	tex, err := ConvertImageToTex(inputPath, texName, compress, forcePNG)
+	return ConvertImageToTexWithOptionsAndWrite(inputPath, texName, TexEncodeOptions{
+		Compress: compress,
+		ForcePNG: forcePNG,
+		Quality:  bcn.QualityNormal,
+	}, outputPath)
+}
+
+// ConvertImageToTexWithOptionsAndWrite 按 TexEncodeOptions 将图像文件转换为 tex 格式并写出
+// ConvertImageToTexWithOptionsAndWrite converts an image file to tex using TexEncodeOptions and writes it
+func ConvertImageToTexWithOptionsAndWrite(inputPath string, texName string, opts TexEncodeOptions, outputPath string) error {
+	tex, err := ConvertImageToTexWithOptions(inputPath, texName, opts)
 	if err != nil {
 		return fmt.Errorf("failed to convert image to tex: %w", err)
 	}
@@ -638,170 +696,127 @@ func ConvertImageToTexAndWrite(inputPath string, texName string, compress bool,
 	return nil
 }
 
-// ConvertTexToImage 将 Tex 数据转换为图像数据但不写出
-// 依赖外部库 ImageMagick，并要求 PATH 环境变量可以直接调用 magick 命令
-// forcePNG 为 false 时，PNG 或 JPG 图像载荷可直接返回，否则根据透明通道输出 JPG 或 PNG
-// forcePNG 为 true 时不考虑原始格式和透明通道并强制输出 PNG
-// 版本 1011 的纹理图集还会返回 rects
-// ConvertTexToImage converts Tex data to image data without writing it
-// It depends on ImageMagick and requires the magick command to be directly available through PATH
-// When forcePNG is false, PNG or JPG image payloads may be returned directly, otherwise the output is JPG or PNG according to alpha presence
-// When forcePNG is true, PNG output is forced regardless of the original format and alpha presence
-// Version-1011 texture atlases also return rects
-func ConvertTexToImage(tex *Tex, forcePNG bool) (imgData []byte, format string, rects []TexRect, err error) {
-	if tex.Version == 1011 {
-		rects = tex.Rects
-	}
-
-	// 检查 ImageMagick 是否安装
-	// Check whether ImageMagick is installed
-	if err := tools.CheckMagick(); err != nil {
-		return nil, "", nil, err
-	}
-
-	// 根据 TextureFormat 判断输入格式和透明通道
-	// Determine the input format and alpha presence from TextureFormat
-	var inputFormat string
-	var hasAlpha bool
-
+// texPayloadKind 根据 TextureFormat 和数据魔数判断载荷的 ImageMagick 输入格式以及是否带透明通道
+// texPayloadKind determines the ImageMagick input format of the payload and whether it carries alpha from TextureFormat and the data signature
+func texPayloadKind(tex *Tex) (inputFormat string, hasAlpha bool, err error) {
 	switch tex.TextureFormat {
 	case DXT1:
-		inputFormat = "dds"
-		hasAlpha = false
+		return "dds", false, nil
 	case DXT5:
-		inputFormat = "dds"
-		hasAlpha = true
+		return "dds", true, nil
 	case ARGB32, RGB24, 0:
 		// 优先从数据魔数检测实际格式
 		// Prefer detecting the actual format from the data signature
-		if len(tex.Data) >= 8 && bytes.Equal(tex.Data[:8], []byte("\x89PNG\r\n\x1a\n")) {
-			inputFormat = "png"
-			hasAlpha = true
-		} else if len(tex.Data) >= 3 && bytes.Equal(tex.Data[:3], []byte("\xff\xd8\xff")) {
-			inputFormat = "jpg"
-			hasAlpha = false
-		} else {
-			// 检测失败时回退到 TextureFormat 对应的默认格式
-			// Fall back to the default format associated with TextureFormat when detection fails
-			if tex.TextureFormat == RGB24 {
-				inputFormat = "jpg"
-				hasAlpha = false
-			} else {
-				inputFormat = "png"
-				hasAlpha = true
-			}
+		switch tools.SniffNativeImageFormat(tex.Data) {
+		case tools.NativeImagePNG:
+			return "png", true, nil
+		case tools.NativeImageJPEG:
+			return "jpg", false, nil
+		}
+		// 检测失败时回退到 TextureFormat 对应的默认格式
+		// Fall back to the default format associated with TextureFormat when detection fails
+		if tex.TextureFormat == RGB24 {
+			return "jpg", false, nil
 		}
+		return "png", true, nil
 	default:
-		return nil, inputFormat, nil, fmt.Errorf("unsupported texture format: %d", tex.TextureFormat)
-	}
-
-	// ARGB32 以及未强制 PNG 的 RGB24 可直接返回原始编码数据
-	// ARGB32 and RGB24 without forced PNG can return the original encoded data directly
-	skipConversion := false
-	if tex.TextureFormat == ARGB32 || (tex.TextureFormat == RGB24 && !forcePNG) {
-		skipConversion = true
+		return "", false, fmt.Errorf("unsupported texture format: %d", tex.TextureFormat)
 	}
+}
 
-	if skipConversion {
-		return tex.Data, inputFormat, rects, nil
+// DecodeTexImage 将 Tex 载荷解码为正立图像，DXT 块会先转换为标准 DDS 块顺序
+// DecodeTexImage decodes a Tex payload to an upright image, converting DXT blocks to standard DDS block order first
+func DecodeTexImage(tex *Tex) (image.Image, error) {
+	if tex == nil {
+		return nil, fmt.Errorf("nil tex")
 	}
-	// forcePNG 为 true 时使用 ImageMagick 强制输出 PNG
-	// Use ImageMagick to force PNG output when forcePNG is true
-
-	if forcePNG {
-		cmd := exec.Command("magick", inputFormat+":-", "png:-")
-		tools.SetHideWindow(cmd)
-
-		var stderrBuf bytes.Buffer
-		cmd.Stderr = &stderrBuf
-
-		d := tex.Data
-		if tex.TextureFormat == DXT1 || tex.TextureFormat == DXT5 {
-			d, err = flipBlockCompressedTextureVertically(d, tex.Width, tex.Height, tex.TextureFormat)
-			if err != nil {
-				return nil, "", nil, err
-			}
-			d = ensureDDSHeader(d, tex.Width, tex.Height, tex.TextureFormat)
+	switch tex.TextureFormat {
+	case DXT1, DXT5:
+		blockFormat := bcn.FormatDXT1
+		if tex.TextureFormat == DXT5 {
+			blockFormat = bcn.FormatDXT5
 		}
-		cmd.Stdin = bytes.NewReader(d)
-
-		// 从标准输出读取转换后的 PNG 数据
-		// Read converted PNG data from standard output
-		outPipe, err := cmd.StdoutPipe()
+		blocks, err := flipBlockCompressedTextureVertically(tex.Data, tex.Width, tex.Height, tex.TextureFormat)
 		if err != nil {
-			return nil, "", nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+			return nil, err
 		}
-		if err = cmd.Start(); err != nil {
-			return nil, "", nil, fmt.Errorf("failed to start magick command: %w", err)
+		return bcn.Decode(blockFormat, blocks, int(tex.Width), int(tex.Height))
+	case ARGB32, RGB24, 0:
+		img, _, err := tools.DecodeNativeImage(tex.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tex image payload: %w", err)
 		}
+		return img, nil
+	default:
+		return nil, fmt.Errorf("unsupported texture format: %d", tex.TextureFormat)
+	}
+}
 
-		convertedBytes, err := io.ReadAll(outPipe)
-		if err != nil {
-			_ = cmd.Wait()
-			return nil, "", nil, fmt.Errorf("failed to read converted data: %w, stderr: %s", err, stderrBuf.String())
+// encodeTexImage 将图像编码为 PNG 或 JPEG 字节 / encodeTexImage encodes an image as PNG or JPEG bytes
+func encodeTexImage(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "jpg", "jpeg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: texJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode JPEG: %w", err)
 		}
-		if err = cmd.Wait(); err != nil {
-			return nil, "", nil, fmt.Errorf("magick command error: %w, stderr: %s", err, stderrBuf.String())
+	default:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
 		}
-
-		return convertedBytes, "png", rects, nil
 	}
+	return buf.Bytes(), nil
+}
 
-	var args []string
-	if hasAlpha {
-		args = []string{inputFormat + ":-", "png:-"}
-		format = "png"
-	} else {
-		args = []string{inputFormat + ":-", "jpg:-", "-quality", "90"}
-		format = "jpg"
+// ConvertTexToImage 将 Tex 数据转换为图像数据但不写出，全部由内置编解码器处理，不需要 ImageMagick
+// forcePNG 为 false 时，PNG 或 JPG 图像载荷可直接返回，否则根据透明通道输出 JPG 或 PNG
+// forcePNG 为 true 时不考虑原始格式和透明通道并强制输出 PNG
+// 版本 1011 的纹理图集还会返回 rects
+// ConvertTexToImage converts Tex data to image data without writing it, handled entirely by the built-in codec without ImageMagick
+// When forcePNG is false, PNG or JPG image payloads may be returned directly, otherwise the output is JPG or PNG according to alpha presence
+// When forcePNG is true, PNG output is forced regardless of the original format and alpha presence
+// Version-1011 texture atlases also return rects
+func ConvertTexToImage(tex *Tex, forcePNG bool) (imgData []byte, format string, rects []TexRect, err error) {
+	if tex.Version == 1011 {
+		rects = tex.Rects
 	}
 
-	// 无透明通道时输出 JPEG，否则输出 PNG
-	// Output JPEG without alpha and PNG otherwise
-	cmd := exec.Command("magick", args...)
-	tools.SetHideWindow(cmd)
-
-	var stderrBuf bytes.Buffer
-	cmd.Stderr = &stderrBuf
+	inputFormat, hasAlpha, err := texPayloadKind(tex)
+	if err != nil {
+		return nil, inputFormat, nil, err
+	}
 
-	d := tex.Data
-	if tex.TextureFormat == DXT1 || tex.TextureFormat == DXT5 {
-		d, err = flipBlockCompressedTextureVertically(d, tex.Width, tex.Height, tex.TextureFormat)
-		if err != nil {
-			return nil, "", nil, err
+	// 载荷已是目标格式时直接返回原始编码数据
+	// Return the original encoded data directly when the payload already has the target format
+	if inputFormat == "png" || (inputFormat == "jpg" && !forcePNG) {
+		if tools.SniffNativeImageFormat(tex.Data) != "" {
+			return tex.Data, inputFormat, rects, nil
 		}
-		d = ensureDDSHeader(d, tex.Width, tex.Height, tex.TextureFormat)
 	}
-	cmd.Stdin = bytes.NewReader(d)
 
-	outPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-	if err = cmd.Start(); err != nil {
-		return nil, "", nil, fmt.Errorf("failed to start magick command: %w", err)
+	format = "png"
+	if !forcePNG && !hasAlpha {
+		format = "jpg"
 	}
-
-	convertedBytes, err := io.ReadAll(outPipe)
+	img, err := DecodeTexImage(tex)
 	if err != nil {
-		_ = cmd.Wait()
-		return nil, "", nil, fmt.Errorf("failed to read converted data: %w, stderr: %s", err, stderrBuf.String())
+		return nil, "", nil, err
 	}
-	if err = cmd.Wait(); err != nil {
-		return nil, "", nil, fmt.Errorf("magick command error: %w, stderr: %s", err, stderrBuf.String())
+	imgData, err = encodeTexImage(img, format)
+	if err != nil {
+		return nil, "", nil, err
 	}
-
-	return convertedBytes, format, rects, nil
+	return imgData, format, rects, nil
 }
 
 // ConvertTexToImageAndWrite 将 .tex 文件转换为图像文件并写出
-// 依赖外部库 ImageMagick，并要求 PATH 环境变量可以直接调用 magick 命令
+// PNG、JPG 与 DDS 输出由内置编解码器处理，其他输出格式依赖 ImageMagick，并要求 PATH 环境变量可以直接调用 magick 命令
 // forcePNG 为 false 时根据输出路径后缀决定格式，没有后缀时有损且无透明通道的数据保存为 JPG，其余保存为 PNG
 // forcePNG 为 true 时不考虑原始格式和透明通道并强制保存为 PNG
 // 版本 1011 的纹理图集还会生成同名 .uv.csv 文件，每行保存一组 x、y、w、h
 // 输出路径使用 .tex 后缀时原样写出 Tex
 // ConvertTexToImageAndWrite converts a .tex file to an image file and writes it
-// It depends on ImageMagick and requires the magick command to be directly available through PATH
+// PNG, JPG, and DDS output uses the built-in codec, while other output formats depend on ImageMagick and require the magick command to be directly available through PATH
 // When forcePNG is false, the output suffix selects the format, while a missing suffix uses JPG for lossy data without alpha and PNG otherwise
 // When forcePNG is true, PNG is forced regardless of the original format and alpha presence
 // Version-1011 texture atlases also produce a sibling .uv.csv file with one x, y, w, h group per row
@@ -822,48 +837,14 @@ func ConvertTexToImageAndWrite(tex *Tex, outputPath string, forcePNG bool) error
 		if err := bw.Flush(); err != nil {
 			return fmt.Errorf("an error occurred while flush bufio: %w", err)
 		}
-	}
-
-	// 检查 ImageMagick 是否安装
-	// Check whether ImageMagick is installed
-	if err := tools.CheckMagick(); err != nil {
-		return err
+		return nil
 	}
 
 	// 根据 TextureFormat 判断输入格式和透明通道
 	// Determine the input format and alpha presence from TextureFormat
-	var inputFormat string
-	var hasAlpha bool
-
-	switch tex.TextureFormat {
-	case DXT1:
-		inputFormat = "dds"
-		hasAlpha = false
-	case DXT5:
-		inputFormat = "dds"
-		hasAlpha = true
-	case ARGB32, RGB24, 0:
-		// 优先从数据魔数检测实际格式
-		// Prefer detecting the actual format from the data signature
-		if len(tex.Data) >= 8 && bytes.Equal(tex.Data[:8], []byte("\x89PNG\r\n\x1a\n")) {
-			inputFormat = "png"
-			hasAlpha = true
-		} else if len(tex.Data) >= 3 && bytes.Equal(tex.Data[:3], []byte("\xff\xd8\xff")) {
-			inputFormat = "jpg"
-			hasAlpha = false
-		} else {
-			// 检测失败时回退到 TextureFormat 对应的默认格式
-			// Fall back to the default format associated with TextureFormat when detection fails
-			if tex.TextureFormat == RGB24 {
-				inputFormat = "jpg"
-				hasAlpha = false
-			} else {
-				inputFormat = "png"
-				hasAlpha = true
-			}
-		}
-	default:
-		return fmt.Errorf("unsupported texture format: %d", tex.TextureFormat)
+	inputFormat, hasAlpha, err := texPayloadKind(tex)
+	if err != nil {
+		return err
 	}
 
 	// forcePNG 为 true 时将输出后缀改为 .png
@@ -874,99 +855,47 @@ func ConvertTexToImageAndWrite(tex *Tex, outputPath string, forcePNG bool) error
 
 	// 未指定后缀时根据透明通道选择 PNG 或 JPG
 	// Choose PNG or JPG from alpha presence when no suffix is specified
-	ext := filepath.Ext(outputPath)
-	if ext == "" {
-		if forcePNG {
+	if filepath.Ext(outputPath) == "" {
+		if hasAlpha {
 			outputPath += ".png"
 		} else {
-			if hasAlpha {
-				outputPath += ".png"
-			} else {
-				outputPath += ".jpg"
-			}
+			outputPath += ".jpg"
 		}
 	}
 
-	// 扩展名为 .tex 时直接写出 Tex
-	// Write Tex directly when the extension is .tex
-	if ext == ".tex" {
-		f, err := os.Create(outputPath)
-		if err != nil {
-			return fmt.Errorf("unable to create.tex file: %w", err)
-		}
-		defer f.Close()
-		bw := bufio.NewWriter(f)
-		if err := tex.Dump(bw); err != nil {
-			return fmt.Errorf("failed to write to.tex file: %w", err)
-		}
-		if err := bw.Flush(); err != nil {
-			return fmt.Errorf("an error occurred while flush bufio: %w", err)
-		}
-		return nil
-	}
-
-	// ARGB32 以及未强制 PNG 的 RGB24 可跳过重新编码
-	// ARGB32 and RGB24 without forced PNG can skip re-encoding
-	skipConversion := false
-	if tex.TextureFormat == ARGB32 || (tex.TextureFormat == RGB24 && !forcePNG) {
-		skipConversion = true
-	}
-
-	// 原始数据为 PNG 或 JPG 时优先直接写出以避免质量损失
-	// Prefer writing original PNG or JPG data directly to avoid quality loss
-	if skipConversion {
-		if ext == ".png" || ext == ".jpg" {
-			if err := os.WriteFile(outputPath, tex.Data, 0755); err != nil {
-				return fmt.Errorf("failed to write file directly: %w", err)
-			}
-		}
-		// 目标后缀不匹配时用 ImageMagick 转换为用户指定格式
-		// Use ImageMagick when the target suffix requests another format
-		cmd := exec.Command("magick", inputFormat+":-", outputPath)
-		tools.SetHideWindow(cmd)
-
-		d := tex.Data
-		if tex.TextureFormat == DXT1 || tex.TextureFormat == DXT5 {
-			flipped, err := flipBlockCompressedTextureVertically(d, tex.Width, tex.Height, tex.TextureFormat)
+	switch ext := strings.ToLower(filepath.Ext(outputPath)); ext {
+	case ".png", ".jpg", ".jpeg":
+		target := strings.TrimPrefix(ext, ".")
+		sniffed := tools.SniffNativeImageFormat(tex.Data)
+		var out []byte
+		// 原始数据已是目标格式时直接写出以避免质量损失
+		// Write original data that already has the target format directly to avoid quality loss
+		if (sniffed == tools.NativeImagePNG && target == "png") || (sniffed == tools.NativeImageJPEG && target != "png") {
+			out = tex.Data
+		} else {
+			img, err := DecodeTexImage(tex)
 			if err != nil {
 				return err
 			}
-			d = flipped
-			d = ensureDDSHeader(d, tex.Width, tex.Height, tex.TextureFormat)
-		}
-		cmd.Stdin = bytes.NewReader(d)
-
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to convert image: %w, output: %s", err, string(output))
-		}
-	} else {
-		// 需要转换时由 ImageMagick 直接写入目标文件
-		// Have ImageMagick write the target file when conversion is required
-		var args []string
-		if strings.HasSuffix(strings.ToLower(outputPath), ".jpg") {
-			args = []string{inputFormat + ":-", "-quality", "90", outputPath}
-		} else {
-			args = []string{inputFormat + ":-", outputPath}
-		}
-
-		cmd := exec.Command("magick", args...)
-		tools.SetHideWindow(cmd)
-
-		d := tex.Data
-		if tex.TextureFormat == DXT1 || tex.TextureFormat == DXT5 {
-			flipped, err := flipBlockCompressedTextureVertically(d, tex.Width, tex.Height, tex.TextureFormat)
+			out, err = encodeTexImage(img, target)
 			if err != nil {
 				return err
 			}
-			d = flipped
-			d = ensureDDSHeader(d, tex.Width, tex.Height, tex.TextureFormat)
 		}
-		cmd.Stdin = bytes.NewReader(d)
-
-		output, err := cmd.CombinedOutput()
+		if err := os.WriteFile(outputPath, out, 0644); err != nil {
+			return fmt.Errorf("failed to write image file: %w", err)
+		}
+	case ".dds":
+		out, err := texDDSBytes(tex)
 		if err != nil {
-			return fmt.Errorf("failed to convert image: %w, output: %s", err, string(output))
+			return err
+		}
+		if err := os.WriteFile(outputPath, out, 0644); err != nil {
+			return fmt.Errorf("failed to write DDS file: %w", err)
+		}
+	default:
+		if err := writeTexImageWithMagick(tex, inputFormat, outputPath); err != nil {
+			return err
 		}
 	}
 
@@ -999,6 +928,211 @@ func ConvertTexToImageAndWrite(tex *Tex, outputPath string, forcePNG bool) error
 	return nil
 }
 
+// texDDSBytes 返回标准 DirectX 块顺序的 DDS 文件，非 DXT 载荷按透明通道压缩为 DXT1 或 DXT5
+// texDDSBytes returns a DDS file in standard DirectX block order, compressing non-DXT payloads to DXT1 or DXT5 according to alpha
+func texDDSBytes(tex *Tex) ([]byte, error) {
+	format := tex.TextureFormat
+	var blocks []byte
+	var err error
+	if format == DXT1 || format == DXT5 {
+		blocks, err = flipBlockCompressedTextureVertically(tex.Data, tex.Width, tex.Height, format)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		img, err := DecodeTexImage(tex)
+		if err != nil {
+			return nil, err
+		}
+		blockFormat := bcn.FormatDXT1
+		format = DXT1
+		if bcn.HasTransparency(img) {
+			blockFormat, format = bcn.FormatDXT5, DXT5
+		}
+		blocks, err = bcn.Encode(blockFormat, img, &bcn.EncodeOptions{Quality: bcn.QualityNormal})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", blockFormat, err)
+		}
+		bounds := img.Bounds()
+		return ensureDDSHeader(blocks, int32(bounds.Dx()), int32(bounds.Dy()), format), nil
+	}
+	return ensureDDSHeader(blocks, tex.Width, tex.Height, format), nil
+}
+
+// TexImageInfo 保存 Tex 拆分为独立图像后仍需保留的头部字段，图像本身作为 .png 或 .dds 伴随文件传输
+// TexImageInfo stores the Tex header fields that must survive splitting the texture into a standalone image, with the image itself travelling as a .png or .dds companion file
+type TexImageInfo struct {
+	Signature     string    `json:"Signature"`     // 文件签名，必须为 CM3D2_TEX / File signature, must be CM3D2_TEX
+	Version       int32     `json:"Version"`       // 写回时使用的格式版本 / Format version used when writing back
+	TextureName   string    `json:"TextureName"`   // 游戏读取后未使用的编译源纹理名称 / Compiled source texture name read but unused by the game
+	Rects         []TexRect `json:"Rects"`         // 版本 1011 及以上的图集矩形 / Atlas rectangles for version 1011 and later
+	Width         int32     `json:"Width"`         // 导出时的纹理宽度，写回时以图像为准 / Texture width at export, replaced by the image on write-back
+	Height        int32     `json:"Height"`        // 导出时的纹理高度，写回时以图像为准 / Texture height at export, replaced by the image on write-back
+	TextureFormat int32     `json:"TextureFormat"` // 写回时使用的 Unity TextureFormat / Unity TextureFormat used on write-back
+}
+
+// ConvertTexToImageInfo 将 Tex 拆分为头部信息和无损图像，DXT 载荷以原始块导出为 DDS，其余载荷导出为 PNG
+// 返回的 suffix 为 .dds 或 .png，PNG 载荷原样返回，JPG 与无法识别的载荷会解码后重新编码为 PNG
+// ConvertTexToImageInfo splits a Tex into header information and a lossless image, exporting DXT payloads as DDS with their original blocks and every other payload as PNG
+// The returned suffix is .dds or .png, PNG payloads are returned unchanged, and JPG or unrecognized payloads are decoded and re-encoded as PNG
+func ConvertTexToImageInfo(tex *Tex) (info TexImageInfo, imageData []byte, suffix string, err error) {
+	if tex == nil {
+		return info, nil, "", fmt.Errorf("nil tex")
+	}
+	info = TexImageInfo{
+		Signature:     tex.Signature,
+		Version:       tex.Version,
+		TextureName:   tex.TextureName,
+		Rects:         tex.Rects,
+		Width:         tex.Width,
+		Height:        tex.Height,
+		TextureFormat: tex.TextureFormat,
+	}
+	// 版本 1000 没有纹理格式字段，按载荷魔数补全写回时使用的格式
+	// Version 1000 has no texture-format field, so the write-back format is filled in from the payload signature
+	if info.TextureFormat == 0 {
+		info.TextureFormat = ARGB32
+		if tools.SniffNativeImageFormat(tex.Data) == tools.NativeImageJPEG {
+			info.TextureFormat = RGB24
+		}
+	}
+	switch {
+	case tex.TextureFormat == DXT1 || tex.TextureFormat == DXT5:
+		imageData, err = texDDSBytes(tex)
+		return info, imageData, ".dds", err
+	case tools.SniffNativeImageFormat(tex.Data) == tools.NativeImagePNG:
+		return info, tex.Data, ".png", nil
+	default:
+		img, err := DecodeTexImage(tex)
+		if err != nil {
+			return info, nil, "", err
+		}
+		imageData, err = encodeTexImage(img, "png")
+		return info, imageData, ".png", err
+	}
+}
+
+// ConvertImageInfoToTex 按头部信息将 PNG 或 DDS 图像重新组装为 Tex，宽高取自图像
+// TextureFormat 决定载荷：DXT1 与 DXT5 在 DDS 块格式相同时直接复用块数据，否则重新压缩；ARGB32 保存 PNG；RGB24 保存 JPG
+// 版本低于 1010 时提升为 1010，存在图集矩形而版本低于 1011 时提升为 1011，避免 Dump 拒绝写出或静默丢弃矩形
+// ConvertImageInfoToTex reassembles a Tex from header information and a PNG or DDS image, taking the dimensions from the image
+// TextureFormat selects the payload: DXT1 and DXT5 reuse DDS blocks of the same block format directly and recompress otherwise, ARGB32 stores PNG, and RGB24 stores JPG
+// Versions below 1010 are raised to 1010 and versions below 1011 carrying atlas rectangles are raised to 1011, so Dump neither rejects the texture nor silently drops the rectangles
+func ConvertImageInfoToTex(info TexImageInfo, imageData []byte, quality bcn.Quality) (*Tex, error) {
+	if info.Signature != TexSignature {
+		return nil, fmt.Errorf("invalid .tex signature %q, want %q", info.Signature, TexSignature)
+	}
+	version := max(info.Version, 1010)
+	rects := info.Rects
+	if len(rects) > 0 {
+		version = max(version, 1011)
+	}
+
+	var (
+		img  image.Image
+		dds  *bcn.DDS
+		err  error
+		kind = tools.SniffNativeImageFormat(imageData)
+	)
+	switch kind {
+	case tools.NativeImageDDS:
+		dds, err = bcn.ReadDDS(imageData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DDS image: %w", err)
+		}
+	case tools.NativeImagePNG:
+		img, _, err = tools.DecodeNativeImage(imageData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode PNG image: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("tex image must be PNG or DDS")
+	}
+
+	tex := &Tex{
+		Signature:     TexSignature,
+		Version:       version,
+		TextureName:   info.TextureName,
+		Rects:         rects,
+		TextureFormat: info.TextureFormat,
+	}
+	// 与 DDS 块格式相同的 DXT 目标直接复用块数据，避免二次有损压缩
+	// DXT targets matching the DDS block format reuse the blocks directly, avoiding a second lossy pass
+	if dds != nil {
+		if texFormat, ok := texFormatFromBCN(dds.Format); ok && texFormat == info.TextureFormat {
+			tex.Width, tex.Height = int32(dds.Width), int32(dds.Height)
+			tex.Data, err = flipBlockCompressedTextureVertically(dds.FirstLevel(), tex.Width, tex.Height, texFormat)
+			if err != nil {
+				return nil, err
+			}
+			return tex, nil
+		}
+		img, err = bcn.Decode(dds.Format, dds.FirstLevel(), dds.Width, dds.Height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode DDS image: %w", err)
+		}
+	}
+
+	bounds := img.Bounds()
+	tex.Width, tex.Height = int32(bounds.Dx()), int32(bounds.Dy())
+	switch info.TextureFormat {
+	case DXT1, DXT5:
+		textureFormat, data, err := encodeTexDXT(img, info.TextureFormat == DXT5, quality)
+		if err != nil {
+			return nil, err
+		}
+		tex.TextureFormat, tex.Data = textureFormat, data
+	case ARGB32:
+		if kind == tools.NativeImagePNG {
+			tex.Data = imageData
+		} else if tex.Data, err = encodeTexImage(img, "png"); err != nil {
+			return nil, err
+		}
+	case RGB24:
+		if tex.Data, err = encodeTexImage(img, "jpg"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported texture format: %d", info.TextureFormat)
+	}
+	return tex, nil
+}
+
+// writeTexImageWithMagick 通过 ImageMagick 把 Tex 载荷写成内置编解码器不支持的输出格式
+// writeTexImageWithMagick writes the Tex payload through ImageMagick to an output format the built-in codec does not support
+func writeTexImageWithMagick(tex *Tex, inputFormat string, outputPath string) error {
+	// 检查 ImageMagick 是否安装
+	// Check whether ImageMagick is installed
+	if err := tools.CheckMagick(); err != nil {
+		return err
+	}
+
+	var args []string
+	if strings.HasSuffix(strings.ToLower(outputPath), ".jpg") {
+		args = []string{inputFormat + ":-", "-quality", strconv.Itoa(texJPEGQuality), outputPath}
+	} else {
+		args = []string{inputFormat + ":-", outputPath}
+	}
+	cmd := exec.Command("magick", args...)
+	tools.SetHideWindow(cmd)
+
+	d := tex.Data
+	if tex.TextureFormat == DXT1 || tex.TextureFormat == DXT5 {
+		flipped, err := flipBlockCompressedTextureVertically(d, tex.Width, tex.Height, tex.TextureFormat)
+		if err != nil {
+			return err
+		}
+		d = ensureDDSHeader(flipped, tex.Width, tex.Height, tex.TextureFormat)
+	}
+	cmd.Stdin = bytes.NewReader(d)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to convert image: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
 // createDDSHeader 为 DXT1 或 DXT5 创建一个基本的 128 字节 DDS 头部
 // createDDSHeader creates a basic 128-byte DDS header for DXT1 or DXT5
 func createDDSHeader(width, height int32, format int32) []byte {
@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
 
@@ -219,9 +220,9 @@ func TestConvertImageToTexCompressedStoresFlippedDXTPayload(t *testing.T) {
 				t.Fatalf("failed to write %s fixture: %v", tc.format, err)
 			}
 
-			tex, err := ConvertImageToTex(inputPath, tc.name, true, false)
+			tex, err := ConvertImageToTexWithOptions(inputPath, tc.name, TexEncodeOptions{Compress: true, UseImageMagick: true})
 			if err != nil {
-				t.Fatalf("ConvertImageToTex failed: %v", err)
+				t.Fatalf("ConvertImageToTexWithOptions failed: %v", err)
 			}
 
 			if tex.Width != 80 || tex.Height != 80 {
@@ -255,6 +256,123 @@ func TestConvertImageToTexCompressedStoresFlippedDXTPayload(t *testing.T) {
 	}
 }
 
+func TestConvertImageToTexNativeDXTRoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		ext          string
+		textureFmt   int32
+		writeImageFn func(string) error
+	}{
+		{name: "dxt1", ext: ".jpg", textureFmt: DXT1, writeImageFn: func(path string) error { return writeDirectionalJPEG(path, 80, 80) }},
+		{name: "dxt5", ext: ".png", textureFmt: DXT5, writeImageFn: func(path string) error { return writeDirectionalPNG(path, 80, 80) }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inputPath := filepath.Join(t.TempDir(), tc.name+tc.ext)
+			if err := tc.writeImageFn(inputPath); err != nil {
+				t.Fatalf("failed to write fixture: %v", err)
+			}
+			source, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sourceImage, _, err := image.Decode(bytes.NewReader(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tex, err := ConvertImageToTexWithOptions(inputPath, tc.name, TexEncodeOptions{Compress: true, Quality: bcn.QualityBest})
+			if err != nil {
+				t.Fatalf("ConvertImageToTexWithOptions failed: %v", err)
+			}
+			if tex.TextureFormat != tc.textureFmt || tex.Width != 80 || tex.Height != 80 {
+				t.Fatalf("unexpected tex header: format %d size %dx%d", tex.TextureFormat, tex.Width, tex.Height)
+			}
+
+			// 游戏载荷的第一块行对应图像底部 / The first block row of the game payload maps to the image bottom
+			topDown, err := bcn.Encode(bcnFormatForTest(tc.textureFmt), sourceImage, &bcn.EncodeOptions{Quality: bcn.QualityBest})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := flipBlockCompressedTextureVertically(topDown, 80, 80, tc.textureFmt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(tex.Data, expected) {
+				t.Fatalf("native tex payload is not stored in Unity block order")
+			}
+
+			decoded, err := DecodeTexImage(tex)
+			if err != nil {
+				t.Fatalf("DecodeTexImage failed: %v", err)
+			}
+			top := color.NRGBAModel.Convert(decoded.At(10, 5)).(color.NRGBA)
+			bottom := color.NRGBAModel.Convert(decoded.At(10, 75)).(color.NRGBA)
+			if top.R < bottom.R || top.B > bottom.B {
+				t.Fatalf("decoded image is not upright: top %v bottom %v", top, bottom)
+			}
+		})
+	}
+}
+
+func TestConvertTexToImageNativeFormats(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	blocks, err := bcn.Encode(bcn.FormatDXT1, img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := &Tex{Signature: "CM3D2_TEX", Version: 1011, Rects: []TexRect{{W: 1, H: 1}}, Width: 8, Height: 8, TextureFormat: DXT1, Data: blocks}
+
+	data, format, rects, err := ConvertTexToImage(tex, false)
+	if err != nil {
+		t.Fatalf("ConvertTexToImage failed: %v", err)
+	}
+	if format != "jpg" || len(rects) != 1 {
+		t.Fatalf("unexpected result format %q rects %d", format, len(rects))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("DXT1 output is not JPEG: %v", err)
+	}
+
+	data, format, _, err = ConvertTexToImage(tex, true)
+	if err != nil || format != "png" {
+		t.Fatalf("forced PNG result = %q, %v", format, err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("forced output is not PNG: %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "out.dds")
+	if err := ConvertTexToImageAndWrite(tex, outputPath, false); err != nil {
+		t.Fatalf("ConvertTexToImageAndWrite dds failed: %v", err)
+	}
+	written, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dds, err := bcn.ReadDDS(written)
+	if err != nil {
+		t.Fatalf("written DDS is invalid: %v", err)
+	}
+	if dds.Format != bcn.FormatDXT1 || dds.Width != 8 || dds.Height != 8 {
+		t.Fatalf("unexpected DDS header %+v", dds)
+	}
+	if _, err := os.Stat(outputPath + ".uv.csv"); err != nil {
+		t.Fatalf("expected atlas sidecar: %v", err)
+	}
+}
+
+func bcnFormatForTest(textureFormat int32) bcn.Format {
+	if textureFormat == DXT5 {
+		return bcn.FormatDXT5
+	}
+	return bcn.FormatDXT1
+}
+
 func writeDirectionalJPEG(path string, width, height int) error {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 	for y := 0; y < height; y++ {
@@ -332,3 +450,71 @@ func convertImageToDDSBytes(inputPath string, compression string) ([]byte, error
 	}
 	return output, nil
 }
+
+func TestTexImageInfoRoundTrip(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	blocks, err := bcn.Encode(bcn.FormatDXT5, img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		tex    *Tex
+		suffix string
+	}{
+		{name: "dxt5 atlas", suffix: ".dds", tex: &Tex{Signature: TexSignature, Version: 1011, TextureName: "atlas", Rects: []TexRect{{X: 0.5, W: 0.5, H: 1}}, Width: 8, Height: 4, TextureFormat: DXT5, Data: blocks}},
+		{name: "argb32", suffix: ".png", tex: &Tex{Signature: TexSignature, Version: 1010, TextureName: "plain", Width: 8, Height: 4, TextureFormat: ARGB32, Data: pngData.Bytes()}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info, imageData, suffix, err := ConvertTexToImageInfo(tc.tex)
+			if err != nil {
+				t.Fatalf("ConvertTexToImageInfo failed: %v", err)
+			}
+			if suffix != tc.suffix {
+				t.Fatalf("suffix = %q, want %q", suffix, tc.suffix)
+			}
+			back, err := ConvertImageInfoToTex(info, imageData, bcn.QualityNormal)
+			if err != nil {
+				t.Fatalf("ConvertImageInfoToTex failed: %v", err)
+			}
+			if !reflect.DeepEqual(back, tc.tex) {
+				t.Fatalf("round trip changed tex: got %+v", back)
+			}
+		})
+	}
+}
+
+func TestConvertImageInfoToTexUsesImageAndTargetFormat(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewNRGBA(image.Rect(0, 0, 12, 8))); err != nil {
+		t.Fatal(err)
+	}
+	info := TexImageInfo{Signature: TexSignature, Version: 1000, Rects: []TexRect{{W: 1, H: 1}}, Width: 4, Height: 4, TextureFormat: DXT1}
+	tex, err := ConvertImageInfoToTex(info, pngData.Bytes(), bcn.QualityNormal)
+	if err != nil {
+		t.Fatalf("ConvertImageInfoToTex failed: %v", err)
+	}
+	if tex.Version != 1011 || tex.Width != 12 || tex.Height != 8 || tex.TextureFormat != DXT1 || len(tex.Data) != 3*2*8 {
+		t.Fatalf("unexpected tex header: version %d size %dx%d format %d data %d", tex.Version, tex.Width, tex.Height, tex.TextureFormat, len(tex.Data))
+	}
+
+	info.TextureFormat = RGB24
+	if tex, err = ConvertImageInfoToTex(info, pngData.Bytes(), bcn.QualityNormal); err != nil || tools.SniffNativeImageFormat(tex.Data) != tools.NativeImageJPEG {
+		t.Fatalf("RGB24 payload is not JPEG: %v", err)
+	}
+	if _, err := ConvertImageInfoToTex(TexImageInfo{Signature: "CM3D2_MENU", TextureFormat: ARGB32}, pngData.Bytes(), bcn.QualityNormal); err == nil {
+		t.Fatal("wrong signature accepted")
+	}
+	if _, err := ConvertImageInfoToTex(TexImageInfo{Signature: TexSignature, TextureFormat: ARGB32}, []byte("not an image"), bcn.QualityNormal); err == nil {
+		t.Fatal("non-image attachment accepted")
+	}
+}
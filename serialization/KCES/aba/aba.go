@@ -641,17 +641,9 @@ func (b *Aba) readDataRange(offset int64, size int64) ([]byte, error) {
 		overlaps := offset < blockEnd && end > blockStart
 
 		if overlaps {
-			compressed := make([]byte, int64(block.CompressedSize))
-			if _, err := readerAt.ReadAt(compressed, compressedOffset); err != nil {
-				return nil, fmt.Errorf("read block[%d] data: %w", blockIndex, err)
-			}
-
-			blockData, err := decompressDataBlock(block, compressed)
+			blockData, err := readDataBlock(readerAt, blockIndex, block, compressedOffset)
 			if err != nil {
-				return nil, fmt.Errorf("decompress block[%d]: %w", blockIndex, err)
-			}
-			if int64(len(blockData)) != int64(block.DecompressedSize) {
-				return nil, fmt.Errorf("block[%d] decompressed size mismatch: got %d, want %d", blockIndex, len(blockData), block.DecompressedSize)
+				return nil, err
 			}
 
 			copyStart := maxInt64(offset, blockStart) - blockStart
@@ -672,6 +664,24 @@ func (b *Aba) readDataRange(offset int64, size int64) ([]byte, error) {
 	return result, nil
 }
 
+// readDataBlock 从压缩数据区读取并解压一个数据块，compressedOffset 为该块在数据区中的偏移
+// readDataBlock reads and decompresses one data block from the compressed data area, with compressedOffset as the block offset in that area
+func readDataBlock(readerAt io.ReaderAt, blockIndex int, block BlockInfo, compressedOffset int64) ([]byte, error) {
+	compressed := make([]byte, int64(block.CompressedSize))
+	if _, err := readerAt.ReadAt(compressed, compressedOffset); err != nil {
+		return nil, fmt.Errorf("read block[%d] data: %w", blockIndex, err)
+	}
+
+	blockData, err := decompressDataBlock(block, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompress block[%d]: %w", blockIndex, err)
+	}
+	if int64(len(blockData)) != int64(block.DecompressedSize) {
+		return nil, fmt.Errorf("block[%d] decompressed size mismatch: got %d, want %d", blockIndex, len(blockData), block.DecompressedSize)
+	}
+	return blockData, nil
+}
+
 // decompressDataBlock 按数据块 Flags 解压一个压缩块并校验输出长度
 // decompressDataBlock decompresses one data block according to its Flags and validates the output length
 func decompressDataBlock(block BlockInfo, compressed []byte) ([]byte, error) {
@@ -0,0 +1,161 @@
+package aba
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/containerfs"
+)
+
+// FS 返回 .aba 目录表的只读 io/fs 视图，实现 fs.FS、fs.ReadDirFS 和 fs.StatFS
+// 文件按块延迟解压并支持随机访问，每个打开的文件只缓存最近解压的一个块，因此可读取超过 GetFileData 内存上限的条目
+// fs.FileInfo 的 Size 为解压后大小，Sys 返回的 containerfs.EntryInfo 中存储大小为与条目重叠的压缩块大小之和，多个条目共享的块会分别计入
+// FS returns a read-only io/fs view of the .aba directory table implementing fs.FS, fs.ReadDirFS and fs.StatFS
+// Files are decompressed lazily block by block with random access, and each open file caches only the most recently decompressed block, so entries beyond the GetFileData memory limit can be read
+// fs.FileInfo.Size is the decompressed size; the stored size in the containerfs.EntryInfo returned by Sys is the sum of the compressed blocks overlapping the entry, counting blocks shared by several entries for each of them
+func (b *Aba) FS() (*containerfs.FS, error) {
+	if b == nil {
+		return nil, fmt.Errorf(".aba is nil")
+	}
+	layout, err := newBlockLayout(b.BlockInfo.BlockInfos)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]containerfs.Entry, 0, len(b.BlockInfo.DirectoryInfos))
+	for _, dir := range b.BlockInfo.DirectoryInfos {
+		end, ok := addNonNegativeInt64(dir.Offset, dir.DecompressedSize)
+		if dir.Offset < 0 || dir.DecompressedSize < 0 || !ok || end > layout.totalSize() {
+			return nil, fmt.Errorf("file %q range offset=%d size=%d out of decompressed data bounds %d", dir.Name, dir.Offset, dir.DecompressedSize, layout.totalSize())
+		}
+		entry := containerfs.Entry{Path: dir.Name, RawSize: dir.DecompressedSize, StoredSize: dir.DecompressedSize}
+		if dir.DecompressedSize > 0 {
+			first, last := layout.blockRange(dir.Offset, end)
+			entry.StoredSize = 0
+			for _, block := range b.BlockInfo.BlockInfos[first : last+1] {
+				entry.StoredSize += int64(block.CompressedSize)
+				if block.GetCompressionType() != CompressionNone {
+					entry.Compressed = true
+				}
+			}
+		}
+		offset, size := dir.Offset, dir.DecompressedSize
+		entry.Open = func() (io.ReadCloser, error) {
+			readerAt, ok := b.DataReader.(io.ReaderAt)
+			if !ok {
+				return nil, fmt.Errorf(".aba data reader does not support random access")
+			}
+			return &entryReader{aba: b, readerAt: readerAt, layout: layout, offset: offset, size: size, cached: -1}, nil
+		}
+		entries = append(entries, entry)
+	}
+	return containerfs.New(entries)
+}
+
+// blockLayout 记录每个数据块在解压数据流和压缩数据区中的起始偏移
+// blockLayout records where each data block starts in the decompressed stream and in the compressed data area
+type blockLayout struct {
+	starts           []int64 // 解压数据流中的起始偏移，末尾附加总大小 / Start offsets in the decompressed stream, followed by the total size
+	compressedStarts []int64 // 压缩数据区中的起始偏移 / Start offsets in the compressed data area
+}
+
+// newBlockLayout 累加块表大小并检查溢出
+// newBlockLayout accumulates the block-table sizes and checks for overflow
+func newBlockLayout(blocks []BlockInfo) (*blockLayout, error) {
+	layout := &blockLayout{
+		starts:           make([]int64, len(blocks)+1),
+		compressedStarts: make([]int64, len(blocks)),
+	}
+	var compressedOffset int64
+	for i, block := range blocks {
+		layout.compressedStarts[i] = compressedOffset
+		var ok bool
+		if layout.starts[i+1], ok = addNonNegativeInt64(layout.starts[i], int64(block.DecompressedSize)); !ok {
+			return nil, fmt.Errorf("decompressed offset overflows at block[%d]", i)
+		}
+		if compressedOffset, ok = addNonNegativeInt64(compressedOffset, int64(block.CompressedSize)); !ok {
+			return nil, fmt.Errorf("compressed offset overflows at block[%d]", i)
+		}
+	}
+	return layout, nil
+}
+
+// totalSize 返回解压数据流的总大小
+// totalSize returns the total size of the decompressed stream
+func (l *blockLayout) totalSize() int64 {
+	return l.starts[len(l.starts)-1]
+}
+
+// blockAt 返回包含解压偏移 offset 的块索引，offset 必须小于总大小
+// blockAt returns the index of the block containing decompressed offset offset, which must be below the total size
+func (l *blockLayout) blockAt(offset int64) int {
+	return sort.Search(len(l.starts)-1, func(i int) bool { return l.starts[i+1] > offset })
+}
+
+// blockRange 返回与非空解压范围 [start, end) 重叠的首尾块索引
+// blockRange returns the first and last block indexes overlapping the non-empty decompressed range [start, end)
+func (l *blockLayout) blockRange(start int64, end int64) (int, int) {
+	return l.blockAt(start), l.blockAt(end - 1)
+}
+
+// entryReader 按块读取一个目录条目，实现 io.ReadCloser 和 io.ReaderAt
+// entryReader reads one directory entry block by block, implementing io.ReadCloser and io.ReaderAt
+type entryReader struct {
+	aba      *Aba
+	readerAt io.ReaderAt
+	layout   *blockLayout
+	offset   int64  // 条目在解压数据流中的偏移 / Entry offset in the decompressed stream
+	size     int64  // 条目的解压大小 / Decompressed entry size
+	pos      int64  // Read 使用的位置 / Position used by Read
+	cached   int    // 已缓存块的索引，-1 表示无缓存 / Index of the cached block, -1 when none
+	block    []byte // 已缓存块的解压数据 / Decompressed data of the cached block
+}
+
+// Read 从当前位置读取解压数据
+// Read reads decompressed data from the current position
+func (r *entryReader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt 在条目内的偏移 off 处读取，只解压需要的块
+// ReadAt reads at offset off within the entry, decompressing only the blocks it needs
+func (r *entryReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("invalid read offset %d", off)
+	}
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		absolute := r.offset + off
+		index := r.layout.blockAt(absolute)
+		if index != r.cached {
+			data, err := readDataBlock(r.readerAt, index, r.aba.BlockInfo.BlockInfos[index], r.layout.compressedStarts[index])
+			if err != nil {
+				return n, err
+			}
+			r.cached, r.block = index, data
+		}
+		available := r.block[absolute-r.layout.starts[index]:]
+		if remaining := r.size - off; int64(len(available)) > remaining {
+			available = available[:remaining]
+		}
+		m := copy(p[n:], available)
+		n += m
+		off += int64(m)
+	}
+	return n, nil
+}
+
+// Close 释放缓存的块
+// Close releases the cached block
+func (r *entryReader) Close() error {
+	r.cached, r.block = -1, nil
+	return nil
+}
@@ -0,0 +1,65 @@
+package aba
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/containerfs"
+)
+
+func TestAbaFSReadsEntriesAcrossBlocks(t *testing.T) {
+	// 大条目跨越多个 0x20000 字节的块，小条目与其共享末尾块
+	// The large entry spans several 0x20000-byte blocks and the small one shares its last block
+	large := bytes.Repeat([]byte("aba fs block data "), 30000)
+	small := []byte("resource bytes")
+	var out bytes.Buffer
+	if err := WriteAba(&out, []AbaFileEntry{
+		{Name: "CAB-large", Data: large, IsSerialized: true},
+		{Name: "CAB-large.resS", Data: small},
+	}, &AbaWriteOptions{Compress: true}); err != nil {
+		t.Fatalf("WriteAba: %v", err)
+	}
+	bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba: %v", err)
+	}
+	fsys, err := bundle.FS()
+	if err != nil {
+		t.Fatalf("FS: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "CAB-large", "CAB-large.resS"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fs.Stat(fsys, "CAB-large")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	entry, ok := info.Sys().(containerfs.EntryInfo)
+	if !ok || info.Size() != int64(len(large)) || !entry.Compressed || entry.StoredSize >= info.Size() {
+		t.Fatalf("CAB-large info: size=%d sys=%+v", info.Size(), info.Sys())
+	}
+
+	// 随机读取跨越块边界的范围
+	// Random reads spanning a block boundary
+	f, err := fsys.Open("CAB-large")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	const boundary = 0x20000
+	got := make([]byte, 64)
+	if _, err := f.(io.ReaderAt).ReadAt(got, boundary-32); err != nil {
+		t.Fatalf("ReadAt: %v", err)
+	}
+	if !bytes.Equal(got, large[boundary-32:boundary+32]) {
+		t.Fatalf("ReadAt across block boundary returned %q", got)
+	}
+	data, err := fs.ReadFile(fsys, "CAB-large.resS")
+	if err != nil || !bytes.Equal(data, small) {
+		t.Fatalf("ReadFile CAB-large.resS = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,62 @@
+package aba
+
+import (
+	"fmt"
+	"io"
+)
+
+// RecompressAba 以 opts 指定的压缩方式重写已解析的 ABA，按原顺序逐字节复制每个目录条目而不解析其中的 SerializedFile
+// opts 中为空的 EngineVersion、GenerationVersion 和 Version 沿用源文件头部；条目按块流式读取，内存占用与条目大小无关
+// RecompressAba rewrites a parsed ABA with the compression selected by opts, copying every directory entry byte for byte in its original order without parsing any SerializedFile
+// Empty EngineVersion, GenerationVersion, and Version fields in opts are taken from the source header; entries are streamed block by block, so memory use does not depend on entry size
+func RecompressAba(w io.Writer, source *Aba, opts *AbaWriteOptions) error {
+	if source == nil {
+		return fmt.Errorf(".aba is nil")
+	}
+	readerAt, ok := source.DataReader.(io.ReaderAt)
+	if !ok {
+		return fmt.Errorf(".aba data reader does not support random access")
+	}
+	layout, err := newBlockLayout(source.BlockInfo.BlockInfos)
+	if err != nil {
+		return err
+	}
+	var options AbaWriteOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.EngineVersion == "" {
+		options.EngineVersion = source.Header.EngineVersion
+	}
+	if options.GenerationVersion == "" {
+		options.GenerationVersion = source.Header.GenerationVersion
+	}
+	if options.Version == 0 {
+		options.Version = source.Header.Version
+	}
+
+	entries, err := sourceAbaEntries(source, readerAt, layout)
+	if err != nil {
+		return err
+	}
+	return WriteAba(w, entries, &options)
+}
+
+// sourceAbaEntries 为源 ABA 的每个目录条目建立按块流式读取的写入条目，保持原顺序和序列化标记
+// sourceAbaEntries builds a block-streaming write entry for every directory entry of the source ABA, keeping the original order and serialized flags
+func sourceAbaEntries(source *Aba, readerAt io.ReaderAt, layout *blockLayout) ([]AbaFileEntry, error) {
+	entries := make([]AbaFileEntry, len(source.BlockInfo.DirectoryInfos))
+	for i, dir := range source.BlockInfo.DirectoryInfos {
+		end, ok := addNonNegativeInt64(dir.Offset, dir.DecompressedSize)
+		if dir.Offset < 0 || dir.DecompressedSize < 0 || !ok || end > layout.totalSize() {
+			return nil, fmt.Errorf("file %q range offset=%d size=%d out of decompressed data bounds %d", dir.Name, dir.Offset, dir.DecompressedSize, layout.totalSize())
+		}
+		entries[i] = AbaFileEntry{
+			Name:         dir.Name,
+			ReaderAt:     &entryReader{aba: source, readerAt: readerAt, layout: layout, offset: dir.Offset, size: dir.DecompressedSize, cached: -1},
+			Size:         dir.DecompressedSize,
+			IsSerialized: dir.IsSerialized(),
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,434 @@
+package aba
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// AbaObjectReplacement 描述替换 ABA 内单个对象所需的目标和新数据
+// PathID 与 Name 必须二选一；Data 必须按目标对象在包内的 TypeTree 编码
+// AbaObjectReplacement describes the target and new data needed to replace one object inside an ABA
+// Exactly one of PathID and Name must be set; Data must be encoded with the TypeTree the target object uses inside the bundle
+type AbaObjectReplacement struct {
+	PathID int64  // 目标对象 PathID，非零时使用 / Target object PathID, used when non-zero
+	Name   string // AssetBundle m_Container 加载名，不区分大小写 / AssetBundle m_Container load name, matched case-insensitively
+	Data   []byte // 新的 Unity 序列化对象正文 / New Unity serialized object payload
+
+	// ClassID 非零时要求目标对象具有相同的 Unity ClassID / ClassID, when non-zero, requires the target object to have the same Unity ClassID
+	ClassID int32
+
+	// StreamData 非 nil 时追加到 SerializedFile 对应的 .resS 条目，并将新对象的 m_StreamData 或 m_Resource 指向该范围
+	// When StreamData is non-nil it is appended to the SerializedFile's .resS entry and the new object's m_StreamData or m_Resource is pointed at that range
+	StreamData []byte
+}
+
+// AbaReplaceResult 描述一次对象替换的结果 / AbaReplaceResult describes the outcome of one object replacement
+type AbaReplaceResult struct {
+	Entry        string // 包含目标对象的 SerializedFile 条目名 / Name of the SerializedFile entry containing the target
+	PathID       int64  // 被替换对象的 PathID / PathID of the replaced object
+	ClassID      int32  // 被替换对象的 Unity ClassID / Unity ClassID of the replaced object
+	OldSize      uint32 // 原对象正文字节数 / Original object payload byte size
+	NewSize      uint32 // 新对象正文字节数 / New object payload byte size
+	StreamEntry  string // 写入 StreamData 的 .resS 条目名，未写入时为空 / Name of the .resS entry receiving StreamData, empty when none was written
+	StreamOffset int64  // StreamData 在 .resS 条目中的偏移 / Offset of StreamData inside the .resS entry
+}
+
+// ReplaceAbaObject 将源 ABA 中一个对象的正文替换为新数据并把结果写入 w，无需解包或重新生成 PathID 与 .ct
+// 目标 SerializedFile 只改写被替换对象的对象表项、其后对象的 ByteOffset 和文件大小，其他对象、metadata 和目录条目逐字节保留；后续对象整体平移 8 字节对齐的距离以保持原有对齐
+// 旧的流式载荷保留在 .resS 中不做回收，以免移动其他对象引用的范围；opts 为 nil 或未指定压缩类型时沿用源数据块的压缩类型，为空的头部字段沿用源文件
+// ReplaceAbaObject replaces the payload of one object in the source ABA with new data and writes the result to w without unpacking or re-deriving PathIDs and the .ct
+// The target SerializedFile only has the replaced object's table entry, the ByteOffset of later objects, and the file size rewritten; every other object, the metadata, and all directory entries are kept byte for byte, and later objects move by a multiple of 8 bytes to preserve their alignment
+// Old stream payloads stay in the .resS entry unreclaimed so ranges referenced by other objects never move; when opts is nil or selects no compression the source data-block compression is kept, and empty header fields are taken from the source
+func ReplaceAbaObject(w io.Writer, source *Aba, replacement *AbaObjectReplacement, opts *AbaWriteOptions) (*AbaReplaceResult, error) {
+	if source == nil {
+		return nil, fmt.Errorf(".aba is nil")
+	}
+	if replacement == nil {
+		return nil, fmt.Errorf("object replacement is nil")
+	}
+	if (replacement.PathID == 0) == (strings.TrimSpace(replacement.Name) == "") {
+		return nil, fmt.Errorf("object replacement needs exactly one of PathID and Name")
+	}
+	if int64(len(replacement.Data)) > math.MaxUint32 {
+		return nil, fmt.Errorf("replacement data size %d exceeds the UInt32 object size limit", len(replacement.Data))
+	}
+	readerAt, ok := source.DataReader.(io.ReaderAt)
+	if !ok {
+		return nil, fmt.Errorf(".aba data reader does not support random access")
+	}
+	layout, err := newBlockLayout(source.BlockInfo.BlockInfos)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := sourceAbaEntries(source, readerAt, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := findAbaReplaceTarget(source, replacement)
+	if err != nil {
+		return nil, err
+	}
+	info := &target.file.Metadata.AssetInfos[target.index]
+	if replacement.ClassID != 0 && replacement.ClassID != info.TypeId {
+		return nil, fmt.Errorf("PathID %d has ClassID %d, but the replacement is ClassID %d", info.PathId, info.TypeId, replacement.ClassID)
+	}
+	data := replacement.Data
+	result := &AbaReplaceResult{
+		Entry:   source.BlockInfo.DirectoryInfos[target.dir].Name,
+		PathID:  info.PathId,
+		ClassID: info.TypeId,
+		OldSize: info.ByteSize,
+	}
+
+	// 有 TypeTree 时先按目标布局完整解码新数据，防止写入与包内类型不符的对象
+	// With a TypeTree, fully decode the new data against the target layout first so an object that does not match the bundle type is never written
+	var object *NativeUnityObject
+	if target.file.AssetHasTypeTree(info) {
+		tree, err := target.file.AssetTypeTree(info)
+		if err != nil {
+			return nil, err
+		}
+		object = &NativeUnityObject{ClassID: info.TypeId, BigEndian: target.file.Header.Endianness, TypeTree: tree, Data: data}
+		if _, err := object.DecodeValue(); err != nil {
+			return nil, fmt.Errorf("replacement data does not match the type tree of PathID %d: %w", info.PathId, err)
+		}
+	}
+
+	if replacement.StreamData != nil {
+		if object == nil {
+			return nil, fmt.Errorf("PathID %d has no type tree, so its stream data reference cannot be rewritten", info.PathId)
+		}
+		streamName := result.Entry + ".resS"
+		streamIndex := -1
+		for i := range entries {
+			if entries[i].Name == streamName {
+				streamIndex = i
+				break
+			}
+		}
+		var streamBase int64
+		var previous *AbaFileEntry
+		if streamIndex >= 0 {
+			if entries[streamIndex].IsSerialized {
+				return nil, fmt.Errorf("stream entry %q is a serialized file", streamName)
+			}
+			original := entries[streamIndex]
+			previous = &original
+			streamBase = original.Size
+		}
+		offset := (streamBase + 15) &^ 15
+		data, err = setObjectStreamingInfo(object, StreamingInfo{
+			Offset: offset,
+			Size:   uint64(len(replacement.StreamData)),
+			Path:   "archive:/" + result.Entry + "/" + streamName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > math.MaxUint32 {
+			return nil, fmt.Errorf("replacement data size %d exceeds the UInt32 object size limit", len(data))
+		}
+		streamEntry := AbaFileEntry{
+			Name:    streamName,
+			WriteTo: appendedStreamWriter(previous, offset, replacement.StreamData),
+			Size:    offset + int64(len(replacement.StreamData)),
+		}
+		if streamIndex >= 0 {
+			entries[streamIndex] = streamEntry
+		} else {
+			entries = append(entries, streamEntry)
+		}
+		result.StreamEntry = streamName
+		result.StreamOffset = offset
+	}
+	result.NewSize = uint32(len(data))
+
+	patched, err := patchSerializedFileObject(target.file, target.index, data, entries[target.dir].ReaderAt)
+	if err != nil {
+		return nil, fmt.Errorf("replace PathID %d in %q: %w", info.PathId, result.Entry, err)
+	}
+	entries[target.dir] = AbaFileEntry{Name: result.Entry, WriteTo: patched.writeTo, Size: patched.size, IsSerialized: true}
+
+	var options AbaWriteOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.Compression == "" && !options.Compress {
+		options.Compression = source.DataCompression()
+	}
+	if options.EngineVersion == "" {
+		options.EngineVersion = source.Header.EngineVersion
+	}
+	if options.GenerationVersion == "" {
+		options.GenerationVersion = source.Header.GenerationVersion
+	}
+	if options.Version == 0 {
+		options.Version = source.Header.Version
+	}
+	if err := WriteAba(w, entries, &options); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DataCompression 返回数据块使用的压缩类型，取第一个压缩块的类型，全部未压缩时返回 AbaCompressionNone
+// DataCompression returns the compression kind used by the data blocks, taken from the first compressed block, or AbaCompressionNone when no block is compressed
+func (b *Aba) DataCompression() AbaCompression {
+	for i := range b.BlockInfo.BlockInfos {
+		switch b.BlockInfo.BlockInfos[i].GetCompressionType() {
+		case CompressionLZMA:
+			return AbaCompressionLZMA
+		case CompressionLZ4:
+			return AbaCompressionLZ4
+		case CompressionLZ4HC:
+			return AbaCompressionLZ4HC
+		}
+	}
+	return AbaCompressionNone
+}
+
+// abaReplaceTarget 定位包内的一个对象 / abaReplaceTarget locates one object inside a bundle
+type abaReplaceTarget struct {
+	dir   int         // SerializedFile 目录条目索引 / SerializedFile directory entry index
+	file  *AssetsFile // 已解析的 SerializedFile / Parsed SerializedFile
+	index int         // AssetInfos 中的对象索引 / Object index in AssetInfos
+}
+
+// findAbaReplaceTarget 在所有 SerializedFile 中按 PathID 或容器加载名查找唯一对象
+// findAbaReplaceTarget searches every SerializedFile for the single object matching a PathID or container load name
+func findAbaReplaceTarget(source *Aba, replacement *AbaObjectReplacement) (abaReplaceTarget, error) {
+	var matches []abaReplaceTarget
+	var names []string
+	for i := range source.BlockInfo.DirectoryInfos {
+		dir := source.BlockInfo.DirectoryInfos[i]
+		if !dir.IsSerialized() {
+			continue
+		}
+		index := int64(i)
+		af, err := ReadAssetsFileRange(dir.DecompressedSize, func(offset int64, size int64) ([]byte, error) {
+			return source.GetFileDataRange(index, offset, size)
+		})
+		if err != nil {
+			return abaReplaceTarget{}, fmt.Errorf("parse serialized file %q: %w", dir.Name, err)
+		}
+		wanted := map[int64]bool{}
+		if replacement.PathID != 0 {
+			wanted[replacement.PathID] = true
+		} else {
+			containers, err := af.GetAssetBundleContainerMap()
+			if err != nil {
+				return abaReplaceTarget{}, fmt.Errorf("read AssetBundle container of %q: %w", dir.Name, err)
+			}
+			for pathID, name := range containers {
+				if strings.EqualFold(name, strings.TrimSpace(replacement.Name)) {
+					wanted[pathID] = true
+				}
+			}
+		}
+		for j := range af.Metadata.AssetInfos {
+			if wanted[af.Metadata.AssetInfos[j].PathId] {
+				matches = append(matches, abaReplaceTarget{dir: i, file: af, index: j})
+				names = append(names, fmt.Sprintf("%s:%d", dir.Name, af.Metadata.AssetInfos[j].PathId))
+			}
+		}
+	}
+	target := replacement.Name
+	if replacement.PathID != 0 {
+		target = fmt.Sprintf("PathID %d", replacement.PathID)
+	}
+	switch len(matches) {
+	case 0:
+		return abaReplaceTarget{}, fmt.Errorf("object %s not found in .aba", target)
+	case 1:
+		return matches[0], nil
+	}
+	return abaReplaceTarget{}, fmt.Errorf("object %s is ambiguous in .aba: %s", target, strings.Join(names, ", "))
+}
+
+// setObjectStreamingInfo 改写对象的 m_StreamData（Texture2D、Cubemap、Mesh）或 m_Resource（AudioClip）并返回重编码的正文
+// setObjectStreamingInfo rewrites an object's m_StreamData (Texture2D, Cubemap, Mesh) or m_Resource (AudioClip) and returns the re-encoded payload
+func setObjectStreamingInfo(object *NativeUnityObject, info StreamingInfo) ([]byte, error) {
+	if info.Offset < 0 || info.Size > math.MaxUint32 {
+		return nil, fmt.Errorf("stream range offset %d size %d is outside the wire range", info.Offset, info.Size)
+	}
+	root, err := object.DecodeValue()
+	if err != nil {
+		return nil, err
+	}
+	stream := firstTypeTreeField(root, "m_StreamData", "m_Resource")
+	if stream == nil {
+		return nil, fmt.Errorf("class %d has no m_StreamData or m_Resource field", object.ClassID)
+	}
+	if offset := firstTypeTreeField(stream, "offset", "m_Offset"); offset != nil {
+		offset.Value = uint64(info.Offset)
+	}
+	if size := firstTypeTreeField(stream, "size", "m_Size"); size != nil {
+		size.Value = info.Size
+	}
+	if streamPath := firstTypeTreeField(stream, "path", "m_Source"); streamPath != nil {
+		streamPath.Value = info.Path
+	}
+	data, err := object.EncodeValue(root)
+	if err != nil {
+		return nil, fmt.Errorf("encode stream data reference: %w", err)
+	}
+	return data, nil
+}
+
+// appendedStreamWriter 返回先复制原 .resS 条目、再写对齐填充和新载荷的可重复生成器
+// appendedStreamWriter returns a repeatable generator that copies the original .resS entry, then writes alignment padding and the new payload
+func appendedStreamWriter(previous *AbaFileEntry, offset int64, payload []byte) AbaEntryWriteFunc {
+	return func(out io.Writer) error {
+		var written int64
+		if previous != nil {
+			if err := writeAbaReaderAtEntry(out, previous.ReaderAt, previous.Size); err != nil {
+				return err
+			}
+			written = previous.Size
+		}
+		if padding := offset - written; padding > 0 {
+			if err := writeAbaBytes(out, make([]byte, padding)); err != nil {
+				return err
+			}
+		}
+		return writeAbaBytes(out, payload)
+	}
+}
+
+// patchedSerializedFile 保存改写后的 SerializedFile 布局，写出时逐段复制未修改的字节
+// patchedSerializedFile stores the rewritten SerializedFile layout and copies unchanged bytes segment by segment when written
+type patchedSerializedFile struct {
+	source    io.ReaderAt // 原 SerializedFile 字节 / Original SerializedFile bytes
+	prefix    []byte      // 改写后的头部、metadata 和数据区前填充 / Rewritten header, metadata, and padding before the data section
+	dataStart int64       // 原数据区起点 / Original data-section start
+	objStart  int64       // 被替换对象的绝对起点 / Absolute start of the replaced object
+	object    []byte      // 新对象正文 / New object payload
+	padding   int64       // 新对象后的零填充字节数 / Zero padding after the new object
+	tailStart int64       // 原文件尾部复制起点 / Start of the copied original tail
+	tailEnd   int64       // 原文件终点 / Original file end
+	size      int64       // 改写后的文件大小 / Rewritten file size
+}
+
+// writeTo 按顺序写出改写后的 SerializedFile，可重复调用
+// writeTo writes the rewritten SerializedFile in order and can be called repeatedly
+func (p *patchedSerializedFile) writeTo(out io.Writer) error {
+	if err := writeAbaBytes(out, p.prefix); err != nil {
+		return err
+	}
+	if err := writeAbaReaderAtEntry(out, io.NewSectionReader(p.source, p.dataStart, p.objStart-p.dataStart), p.objStart-p.dataStart); err != nil {
+		return err
+	}
+	if err := writeAbaBytes(out, p.object); err != nil {
+		return err
+	}
+	if p.padding > 0 {
+		if err := writeAbaBytes(out, make([]byte, p.padding)); err != nil {
+			return err
+		}
+	}
+	return writeAbaReaderAtEntry(out, io.NewSectionReader(p.source, p.tailStart, p.tailEnd-p.tailStart), p.tailEnd-p.tailStart)
+}
+
+// patchSerializedFileObject 计算以 data 替换第 index 个对象后的 SerializedFile 布局，并原地改写对象表和文件大小
+// patchSerializedFileObject computes the SerializedFile layout after replacing object index with data and patches the object table and file size in place
+func patchSerializedFileObject(af *AssetsFile, index int, data []byte, source io.ReaderAt) (*patchedSerializedFile, error) {
+	if len(af.assetInfoPositions) != len(af.Metadata.AssetInfos) {
+		return nil, fmt.Errorf("object table positions are unavailable")
+	}
+	header := af.Header
+	info := af.Metadata.AssetInfos[index]
+	objStart := header.DataOffset + info.ByteOffset
+	objEnd := objStart + int64(info.ByteSize)
+
+	// 尾部从原对象 8 字节对齐的末尾或下一个对象处开始，取较早者，使原有填充与对象一并替换
+	// The tail starts at the 8-byte-aligned end of the old object or at the next object, whichever comes first, so the old padding is replaced together with the object
+	tailStart, ok := alignInt64(objEnd, 8)
+	if !ok || tailStart > header.FileSize {
+		tailStart = header.FileSize
+	}
+	for i := range af.Metadata.AssetInfos {
+		other := af.Metadata.AssetInfos[i]
+		otherStart := header.DataOffset + other.ByteOffset
+		if i == index || otherStart < objEnd && otherStart+int64(other.ByteSize) <= objStart {
+			continue
+		}
+		if otherStart < objEnd {
+			return nil, fmt.Errorf("object PathID %d lies inside the replaced range [%d,%d)", other.PathId, objStart, objEnd)
+		}
+		tailStart = minInt64(tailStart, otherStart)
+	}
+	newEnd := objStart + int64(len(data))
+	padding := ((tailStart-newEnd)%8 + 8) % 8
+	delta := newEnd + padding - tailStart
+	newFileSize := header.FileSize + delta
+
+	prefix, err := readAssetsFileRangeExact(af.readRange, 0, header.DataOffset)
+	if err != nil {
+		return nil, fmt.Errorf("read header and metadata: %w", err)
+	}
+	prefix = append([]byte(nil), prefix...)
+
+	// 文件头固定为 Big-Endian；v22 起使用扩展头中的 Int64 文件大小，更早版本使用固定头偏移 4 处的 UInt32
+	// The header is always Big-Endian; v22+ uses the Int64 file size in the extended header, and earlier versions the UInt32 at offset 4 of the fixed header
+	headerSize := int64(20)
+	if header.Version >= 22 {
+		headerSize = 48
+		binary.BigEndian.PutUint64(prefix[24:32], uint64(newFileSize))
+	} else {
+		if newFileSize > math.MaxUint32 {
+			return nil, fmt.Errorf("serialized file size %d exceeds the UInt32 limit of format %d", newFileSize, header.Version)
+		}
+		binary.BigEndian.PutUint32(prefix[4:8], uint32(newFileSize))
+	}
+
+	order := af.byteOrder()
+	pathIDSize, offsetSize := int64(4), int64(4)
+	if header.Version >= 14 || af.Metadata.BigIDEnabled != 0 {
+		pathIDSize = 8
+	}
+	if header.Version >= 22 {
+		offsetSize = 8
+	}
+	for i := range af.Metadata.AssetInfos {
+		other := af.Metadata.AssetInfos[i]
+		byteOffset := other.ByteOffset
+		if i != index && header.DataOffset+byteOffset < objEnd {
+			continue
+		}
+		if i != index {
+			byteOffset += delta
+		}
+		position := af.assetInfoPositions[i]
+		if header.Version >= 14 {
+			position = (position + 3) &^ 3
+		}
+		position += headerSize + pathIDSize
+		if offsetSize == 8 {
+			order.PutUint64(prefix[position:position+8], uint64(byteOffset))
+		} else {
+			if byteOffset > math.MaxUint32 {
+				return nil, fmt.Errorf("object PathID %d offset %d exceeds the UInt32 limit of format %d", other.PathId, byteOffset, header.Version)
+			}
+			order.PutUint32(prefix[position:position+4], uint32(byteOffset))
+		}
+		if i == index {
+			order.PutUint32(prefix[position+offsetSize:position+offsetSize+4], uint32(len(data)))
+		}
+	}
+
+	return &patchedSerializedFile{
+		source:    source,
+		prefix:    prefix,
+		dataStart: header.DataOffset,
+		objStart:  objStart,
+		object:    data,
+		padding:   padding,
+		tailStart: tailStart,
+		tailEnd:   header.FileSize,
+		size:      newFileSize,
+	}, nil
+}
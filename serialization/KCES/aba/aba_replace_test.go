@@ -0,0 +1,207 @@
+package aba
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// buildReplaceTestAba 生成包含三个 TextAsset 和一张 Texture2D 的 LZ4 测试包
+// buildReplaceTestAba builds an LZ4 test bundle containing three TextAssets and one Texture2D
+func buildReplaceTestAba(t *testing.T) *Aba {
+	t.Helper()
+	writer := NewSerializedFileWriter("2022.3.35f1")
+	writer.AddTextAsset("first.menuassets", []byte("first payload"))
+	writer.AddTextAsset("second.menuassets", []byte("second"))
+	writer.AddTextAsset("third.menuassets", bytes.Repeat([]byte("third "), 40))
+	writer.AddTexture2D("replace.tex", 2, 2, bytes.Repeat([]byte{1, 2, 3, 4}, 4))
+	var serialized bytes.Buffer
+	if err := writer.Write(&serialized); err != nil {
+		t.Fatalf("SerializedFileWriter.Write: %v", err)
+	}
+	var out bytes.Buffer
+	entries := []AbaFileEntry{{Name: "CAB-replace", Data: serialized.Bytes(), IsSerialized: true}}
+	if err := WriteAba(&out, entries, &AbaWriteOptions{Compress: true}); err != nil {
+		t.Fatalf("WriteAba: %v", err)
+	}
+	source, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba: %v", err)
+	}
+	return source
+}
+
+// readReplaceTestAssets 读取测试包中的 SerializedFile / readReplaceTestAssets reads the SerializedFile of a test bundle
+func readReplaceTestAssets(t *testing.T, bundle *Aba) *AssetsFile {
+	t.Helper()
+	data, err := bundle.GetFileDataByName("CAB-replace")
+	if err != nil {
+		t.Fatalf("GetFileDataByName: %v", err)
+	}
+	af, err := ReadAssetsFile(data)
+	if err != nil {
+		t.Fatalf("ReadAssetsFile: %v", err)
+	}
+	return af
+}
+
+// findTextAssetInfo 按 m_Name 查找 TextAsset / findTextAssetInfo finds a TextAsset by m_Name
+func findTextAssetInfo(t *testing.T, af *AssetsFile, name string) *AssetInfo {
+	t.Helper()
+	for i := range af.Metadata.AssetInfos {
+		info := &af.Metadata.AssetInfos[i]
+		if info.TypeId != ClassIDTextAsset {
+			continue
+		}
+		root, err := af.ReadAssetValue(info)
+		if err != nil {
+			t.Fatalf("ReadAssetValue: %v", err)
+		}
+		if got, _ := root.Field("m_Name").String(); got == name {
+			return info
+		}
+	}
+	t.Fatalf("TextAsset %q not found", name)
+	return nil
+}
+
+func TestReplaceAbaObjectKeepsOtherObjectsByteIdentical(t *testing.T) {
+	source := buildReplaceTestAba(t)
+	before := readReplaceTestAssets(t, source)
+	target := findTextAssetInfo(t, before, "second.menuassets")
+	root, err := before.ReadAssetValue(target)
+	if err != nil {
+		t.Fatalf("ReadAssetValue: %v", err)
+	}
+	replacementScript := strings.Repeat("a much longer replacement script ", 9)
+	root.Field("m_Script").Value = replacementScript
+	data, err := before.EncodeAssetValue(target, root)
+	if err != nil {
+		t.Fatalf("EncodeAssetValue: %v", err)
+	}
+
+	var out bytes.Buffer
+	result, err := ReplaceAbaObject(&out, source, &AbaObjectReplacement{Name: "SECOND.menuassets", Data: data}, nil)
+	if err != nil {
+		t.Fatalf("ReplaceAbaObject: %v", err)
+	}
+	if result.PathID != target.PathId || result.NewSize != uint32(len(data)) || result.OldSize != target.ByteSize {
+		t.Errorf("result = %+v, want PathID %d sizes %d -> %d", result, target.PathId, target.ByteSize, len(data))
+	}
+	bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba replaced: %v", err)
+	}
+	if got := bundle.DataCompression(); got != AbaCompressionLZ4 {
+		t.Errorf("compression = %q, want the source lz4", got)
+	}
+	after := readReplaceTestAssets(t, bundle)
+	if len(after.Metadata.AssetInfos) != len(before.Metadata.AssetInfos) {
+		t.Fatalf("object count %d, want %d", len(after.Metadata.AssetInfos), len(before.Metadata.AssetInfos))
+	}
+	for i := range before.Metadata.AssetInfos {
+		oldInfo, newInfo := &before.Metadata.AssetInfos[i], &after.Metadata.AssetInfos[i]
+		if oldInfo.PathId != newInfo.PathId || oldInfo.TypeIdOrIndex != newInfo.TypeIdOrIndex {
+			t.Fatalf("object[%d] identity changed: %+v -> %+v", i, oldInfo, newInfo)
+		}
+		if newInfo.ByteOffset%8 != oldInfo.ByteOffset%8 {
+			t.Errorf("object[%d] alignment changed: offset %d -> %d", i, oldInfo.ByteOffset, newInfo.ByteOffset)
+		}
+		got, err := after.GetAssetData(newInfo)
+		if err != nil {
+			t.Fatalf("GetAssetData after: %v", err)
+		}
+		if newInfo.PathId == target.PathId {
+			if !bytes.Equal(got, data) {
+				t.Fatal("replaced object data mismatch")
+			}
+			continue
+		}
+		want, err := before.GetAssetData(oldInfo)
+		if err != nil {
+			t.Fatalf("GetAssetData before: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("object PathID %d is no longer byte-identical", newInfo.PathId)
+		}
+	}
+	replaced, err := after.ReadAssetValue(after.GetAssetInfoByPathID(target.PathId))
+	if err != nil {
+		t.Fatalf("ReadAssetValue replaced: %v", err)
+	}
+	if got, _ := replaced.Field("m_Script").String(); got != replacementScript {
+		t.Errorf("m_Script = %q, want %q", got, replacementScript)
+	}
+}
+
+func TestReplaceAbaObjectAppendsStreamData(t *testing.T) {
+	source := buildReplaceTestAba(t)
+	before := readReplaceTestAssets(t, source)
+	textures := before.GetAssetsByType(ClassIDTexture2D)
+	if len(textures) != 1 {
+		t.Fatalf("got %d textures, want 1", len(textures))
+	}
+	info := textures[0]
+	data, err := before.GetAssetData(&info)
+	if err != nil {
+		t.Fatalf("GetAssetData: %v", err)
+	}
+	stream := bytes.Repeat([]byte{0xaa, 0xbb}, 300)
+
+	var out bytes.Buffer
+	result, err := ReplaceAbaObject(&out, source, &AbaObjectReplacement{PathID: info.PathId, Data: data, StreamData: stream}, &AbaWriteOptions{Compression: AbaCompressionNone})
+	if err != nil {
+		t.Fatalf("ReplaceAbaObject: %v", err)
+	}
+	if result.StreamEntry != "CAB-replace.resS" || result.StreamOffset != 0 {
+		t.Errorf("stream entry = %q offset %d, want CAB-replace.resS at 0", result.StreamEntry, result.StreamOffset)
+	}
+	bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba replaced: %v", err)
+	}
+	if got := bundle.DataCompression(); got != AbaCompressionNone {
+		t.Errorf("compression = %q, want none", got)
+	}
+	resS, err := bundle.GetFileDataByName("CAB-replace.resS")
+	if err != nil {
+		t.Fatalf("GetFileDataByName .resS: %v", err)
+	}
+	if !bytes.Equal(resS, stream) {
+		t.Fatal(".resS entry does not hold the stream data")
+	}
+	after := readReplaceTestAssets(t, bundle)
+	root, err := after.ReadAssetValue(after.GetAssetInfoByPathID(info.PathId))
+	if err != nil {
+		t.Fatalf("ReadAssetValue: %v", err)
+	}
+	streamInfo, err := readStreamingInfo(root.Field("m_StreamData"))
+	if err != nil {
+		t.Fatalf("readStreamingInfo: %v", err)
+	}
+	if streamInfo.Offset != 0 || streamInfo.Size != uint64(len(stream)) || streamInfo.Path != "archive:/CAB-replace/CAB-replace.resS" {
+		t.Errorf("m_StreamData = %+v", streamInfo)
+	}
+}
+
+func TestReplaceAbaObjectRejectsInvalidTargets(t *testing.T) {
+	source := buildReplaceTestAba(t)
+	tests := []struct {
+		name        string
+		replacement AbaObjectReplacement
+		want        string
+	}{
+		{"no target", AbaObjectReplacement{Data: []byte{1}}, "exactly one"},
+		{"missing name", AbaObjectReplacement{Name: "missing.menuassets", Data: []byte{1}}, "not found"},
+		{"type mismatch", AbaObjectReplacement{Name: "first.menuassets", Data: []byte{1, 2, 3}}, "type tree"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := ReplaceAbaObject(&out, source, &tt.replacement, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
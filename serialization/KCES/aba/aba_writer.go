@@ -9,6 +9,7 @@ import (
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio"
 	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz/lzma"
 )
 
 // AbaWriteOptions 控制 ABA 文件的写入行为 / AbaWriteOptions controls ABA file writing
@@ -16,7 +17,54 @@ type AbaWriteOptions struct {
 	EngineVersion     string // Unity 引擎版本，默认固定为 2022.3.35f1 / Unity engine version, fixed to 2022.3.35f1 by default
 	GenerationVersion string // 生成版本（如 "5.x.x"），默认 "5.x.x" / Generation version such as "5.x.x", default "5.x.x"
 	Version           uint32 // 文件格式版本，默认 8 / File format version, default 8
-	Compress          bool   // 是否使用 LZ4 压缩数据块 / Whether to compress data blocks with LZ4
+	Compress          bool   // Compression 为空时是否使用 LZ4 压缩数据块 / Whether to compress data blocks with LZ4 when Compression is empty
+
+	// Compression 选择数据块压缩类型，为空时由 Compress 决定
+	// Compression selects the data-block compression kind, with Compress deciding when it is empty
+	Compression AbaCompression
+	// CompressionLevel 是 LZ4HC 或 LZMA 的 1-9 级别，0 使用默认级别，其他压缩类型必须为 0
+	// CompressionLevel is the LZ4HC or LZMA level from 1 to 9, with 0 selecting the default level; other kinds require 0
+	CompressionLevel int
+	// InfoCompression 选择 BlockAndDirInfo 的压缩类型，为空时与 Unity 相同：LZMA 包使用 LZMA，LZ4HC 包使用 LZ4HC，其他使用 LZ4
+	// InfoCompression selects the BlockAndDirInfo compression kind; when empty it follows Unity: LZMA bundles use LZMA, LZ4HC bundles use LZ4HC, and others use LZ4
+	InfoCompression AbaCompression
+}
+
+// AbaCompression 表示写入 ABA 时使用的压缩类型 / AbaCompression names the compression kind used when writing an ABA
+type AbaCompression string
+
+const (
+	AbaCompressionNone  AbaCompression = "none"  // 不压缩 / No compression
+	AbaCompressionLZ4   AbaCompression = "lz4"   // LZ4 快速压缩 / Fast LZ4 compression
+	AbaCompressionLZ4HC AbaCompression = "lz4hc" // 高压缩率 LZ4HC / Higher-ratio LZ4HC compression
+	AbaCompressionLZMA  AbaCompression = "lzma"  // LZMA 压缩，官方 KCES 包常用 / LZMA compression, common in official KCES bundles
+)
+
+// ParseAbaCompression 不区分大小写地解析压缩类型名称，空字符串返回空类型
+// ParseAbaCompression parses a compression kind name case-insensitively, returning the empty kind for an empty string
+func ParseAbaCompression(name string) (AbaCompression, error) {
+	kind := AbaCompression(strings.ToLower(strings.TrimSpace(name)))
+	switch kind {
+	case "", AbaCompressionNone, AbaCompressionLZ4, AbaCompressionLZ4HC, AbaCompressionLZMA:
+		return kind, nil
+	}
+	return "", fmt.Errorf("unknown .aba compression %q (supported: none, lz4, lz4hc, lzma)", name)
+}
+
+// wireType 返回压缩类型在 UnityFS Flags 中的取值
+// wireType returns the value of the compression kind in UnityFS Flags
+func (c AbaCompression) wireType() (byte, error) {
+	switch c {
+	case AbaCompressionNone:
+		return CompressionNone, nil
+	case AbaCompressionLZ4:
+		return CompressionLZ4, nil
+	case AbaCompressionLZ4HC:
+		return CompressionLZ4HC, nil
+	case AbaCompressionLZMA:
+		return CompressionLZMA, nil
+	}
+	return 0, fmt.Errorf("unknown .aba compression %q", string(c))
 }
 
 // AbaEntryWriteFunc 将一个 ABA 目录条目的完整逻辑字节流写入目标
@@ -34,9 +82,9 @@ type AbaFileEntry struct {
 }
 
 // WriteAba 将文件条目列表写入采用 Unity AssetBundle UnityFS 格式的 .aba 文件
-// 输出依次包含 Header、BlockAndDirInfo、16 字节对齐填充和数据块，数据块按 0x20000 字节分块并可选使用 LZ4 压缩
+// 输出依次包含 Header、BlockAndDirInfo、16 字节对齐填充和数据块，数据块按 0x20000 字节分块（LZMA 与 Unity 相同，整段数据为单个块）并可选使用 LZ4、LZ4HC 或 LZMA 压缩
 // WriteAba writes file entries as an .aba file using the Unity AssetBundle UnityFS format
-// Output contains Header, BlockAndDirInfo, 16-byte alignment padding, and data blocks in order; data is split into 0x20000-byte blocks with optional LZ4 compression
+// Output contains Header, BlockAndDirInfo, 16-byte alignment padding, and data blocks in order; data is split into 0x20000-byte blocks (a single block for LZMA, as Unity writes it) with optional LZ4, LZ4HC, or LZMA compression
 func WriteAba(w io.Writer, entries []AbaFileEntry, opts *AbaWriteOptions) error {
 	if w == nil {
 		return fmt.Errorf(".aba writer is nil")
@@ -67,6 +115,30 @@ func WriteAba(w io.Writer, entries []AbaFileEntry, opts *AbaWriteOptions) error
 	if err := validateAbaHeaderString("engine version", options.EngineVersion); err != nil {
 		return err
 	}
+	dataCompression := options.Compression
+	if dataCompression == "" {
+		dataCompression = AbaCompressionNone
+		if options.Compress {
+			dataCompression = AbaCompressionLZ4
+		}
+	}
+	codec, err := newAbaCodec(dataCompression, options.CompressionLevel)
+	if err != nil {
+		return err
+	}
+	infoCompression := options.InfoCompression
+	if infoCompression == "" {
+		switch dataCompression {
+		case AbaCompressionLZMA, AbaCompressionLZ4HC:
+			infoCompression = dataCompression
+		default:
+			infoCompression = AbaCompressionLZ4
+		}
+	}
+	infoCodec, err := newAbaCodec(infoCompression, 0)
+	if err != nil {
+		return fmt.Errorf("block/directory info: %w", err)
+	}
 
 	// 首先构建 DirectoryInfos，只计算逻辑偏移而不复制全部输入数据
 	// First build DirectoryInfos, computing logical offsets without copying all input data
@@ -95,17 +167,20 @@ func WriteAba(w io.Writer, entries []AbaFileEntry, opts *AbaWriteOptions) error
 		}
 	}
 
+	dataBlockSize, err := codec.blockSize(totalDataSize)
+	if err != nil {
+		return err
+	}
 	var blockInfos []BlockInfo
 	var compressedDataSize int64
-	var err error
-	if options.Compress {
+	if codec.kind != CompressionNone {
 		// 第一遍编码压缩数据块时只记录块表和压缩后总大小，最终写出时重新编码，以免为整个 ABA 保留第二份大切片
 		// The first compressed data-block pass records only the block table and total compressed size, and final output re-encodes the blocks to avoid retaining a second full ABA-sized slice
-		err = forEachAbaDataBlock(entries, func(index int64, block []byte) error {
+		err = forEachAbaDataBlock(entries, dataBlockSize, func(index int64, block []byte) error {
 			if _, err := int32WireLength("data block count", uint64(index)+1); err != nil {
 				return fmt.Errorf("data block count exceeds Int32 wire range")
 			}
-			info, encoded, err := encodeAbaDataBlock(block, true)
+			info, encoded, err := encodeAbaDataBlock(block, codec)
 			if err != nil {
 				return fmt.Errorf("encode data block %d: %w", index, err)
 			}
@@ -138,23 +213,18 @@ func WriteAba(w io.Writer, entries []AbaFileEntry, opts *AbaWriteOptions) error
 		return fmt.Errorf("block/directory info size %d exceeds limit %d", len(blockAndDirBytes), maxBlockAndDirInfoSize)
 	}
 
-	// 尝试使用 LZ4 压缩 BlockAndDirInfo
-	// Attempt to compress BlockAndDirInfo with LZ4
-	blockAndDirCompressed := make([]byte, lz4.CompressBlockBound(len(blockAndDirBytes)))
-	n, err := lz4.CompressBlock(blockAndDirBytes, blockAndDirCompressed, nil)
+	// 按 InfoCompression 尝试压缩 BlockAndDirInfo，压缩没有减小数据时保留原始元数据
+	// Attempt to compress BlockAndDirInfo with InfoCompression, keeping raw metadata when compression does not reduce its size
+	blockAndDirCompressed, shrunk, err := infoCodec.compress(blockAndDirBytes)
 	if err != nil {
 		return fmt.Errorf("compress block/directory info: %w", err)
 	}
-	infoCompression := byte(CompressionNone)
-	if n == 0 || n >= len(blockAndDirBytes) {
-		// 压缩没有减小数据时保留原始元数据
-		// Keep raw metadata when compression does not reduce its size
+	infoWireType := infoCodec.kind
+	if !shrunk {
 		blockAndDirCompressed = blockAndDirBytes
-		n = len(blockAndDirBytes)
-	} else {
-		blockAndDirCompressed = blockAndDirCompressed[:n]
-		infoCompression = CompressionLZ4
+		infoWireType = CompressionNone
 	}
+	n := len(blockAndDirCompressed)
 
 	// 头部大小包括三个 NUL 结尾字符串、UInt32 Version 和固定 20 字节 FSHeader
 	// Header size includes three NUL-terminated strings, UInt32 Version, and the fixed 20-byte FSHeader
@@ -189,7 +259,7 @@ func WriteAba(w io.Writer, entries []AbaFileEntry, opts *AbaWriteOptions) error
 
 	// 当前写入布局将 BlockAndDirInfo 放在数据块之前，并设置组合目录与数据块起始对齐标志
 	// The emitted layout places BlockAndDirInfo before data blocks and sets the combined-directory and data-block alignment flags
-	flags := uint32(FlagHasDirectoryInfo) | uint32(FlagBlockInfoNeedPaddingAtStart) | uint32(infoCompression)
+	flags := uint32(FlagHasDirectoryInfo) | uint32(FlagBlockInfoNeedPaddingAtStart) | uint32(infoWireType)
 
 	// 在内存缓冲区中构造 Big-Endian Header
 	// Build the Big-Endian Header in a memory buffer
@@ -266,8 +336,8 @@ func WriteAba(w io.Writer, entries []AbaFileEntry, opts *AbaWriteOptions) error
 		}
 	}
 	var blockIndex int64
-	err = forEachAbaDataBlock(entries, func(index int64, block []byte) error {
-		info, encoded, err := encodeAbaDataBlock(block, options.Compress)
+	err = forEachAbaDataBlock(entries, dataBlockSize, func(index int64, block []byte) error {
+		info, encoded, err := encodeAbaDataBlock(block, codec)
 		if err != nil {
 			return err
 		}
@@ -295,7 +365,7 @@ func uncompressedAbaBlockInfos(totalDataSize int64) ([]BlockInfo, error) {
 	if totalDataSize < 0 {
 		return nil, fmt.Errorf("negative ABA data size %d", totalDataSize)
 	}
-	const blockSize int64 = 0x20000
+	const blockSize int64 = abaBlockSize
 	blockCount := totalDataSize / blockSize
 	if totalDataSize%blockSize != 0 {
 		blockCount++
@@ -355,15 +425,17 @@ func abaFileEntrySize(entry AbaFileEntry) (int64, error) {
 	return dataSize, nil
 }
 
-// forEachAbaDataBlock 将拼接后的条目流按有界 UnityFS 块传给回调
+// forEachAbaDataBlock 将拼接后的条目流按 blockSize 字节的 UnityFS 块传给回调
 // 暂存区会复用，每个 block 切片只在对应 fn 调用返回前有效
-// forEachAbaDataBlock passes the concatenated entry stream to a callback in bounded UnityFS blocks
+// forEachAbaDataBlock passes the concatenated entry stream to a callback in UnityFS blocks of blockSize bytes
 // Scratch storage is reused, and each block slice remains valid only until its fn call returns
-func forEachAbaDataBlock(entries []AbaFileEntry, fn func(index int64, block []byte) error) error {
+func forEachAbaDataBlock(entries []AbaFileEntry, blockSize int, fn func(index int64, block []byte) error) error {
 	if fn == nil {
 		return fmt.Errorf("data block callback is nil")
 	}
-	const blockSize = 0x20000
+	if blockSize <= 0 {
+		return fmt.Errorf("invalid data block size %d", blockSize)
+	}
 	blocks := &abaDataBlockWriter{callback: fn, scratch: make([]byte, blockSize)}
 	for entryIndex := range entries {
 		entry := entries[entryIndex]
@@ -511,11 +583,11 @@ func (w *abaExactWriter) Write(data []byte) (int, error) {
 	return n, err
 }
 
-// encodeAbaDataBlock 按选项尝试 LZ4 压缩一个数据块，并在无收益时保留原始字节
+// encodeAbaDataBlock 按 codec 尝试压缩一个数据块，并在无收益时保留原始字节
 // 块 Flags 只保存压缩类型，官方 KCES 文件的数据块不设置额外标志位
-// encodeAbaDataBlock attempts LZ4 compression for one data block and retains raw bytes when compression has no benefit
+// encodeAbaDataBlock attempts to compress one data block with codec and retains raw bytes when compression has no benefit
 // Block Flags store only the compression type because official KCES data blocks set no extra flag bits
-func encodeAbaDataBlock(block []byte, compress bool) (BlockInfo, []byte, error) {
+func encodeAbaDataBlock(block []byte, codec abaCodec) (BlockInfo, []byte, error) {
 	if len(block) > maxAbaBlockSize || uint64(len(block)) > uint64(^uint32(0)) {
 		return BlockInfo{}, nil, fmt.Errorf("data block is too large: %d bytes", len(block))
 	}
@@ -524,23 +596,136 @@ func encodeAbaDataBlock(block []byte, compress bool) (BlockInfo, []byte, error)
 		CompressedSize:   uint32(len(block)),
 		Flags:            uint16(CompressionNone),
 	}
-	if !compress || len(block) == 0 {
-		return rawInfo, block, nil
-	}
-
-	dst := make([]byte, lz4.CompressBlockBound(len(block)))
-	n, err := lz4.CompressBlock(block, dst, nil)
+	encoded, shrunk, err := codec.compress(block)
 	if err != nil {
 		return BlockInfo{}, nil, err
 	}
-	if n == 0 || n >= len(block) {
+	if !shrunk {
 		return rawInfo, block, nil
 	}
 	return BlockInfo{
 		DecompressedSize: uint32(len(block)),
-		CompressedSize:   uint32(n),
-		Flags:            uint16(CompressionLZ4),
-	}, dst[:n], nil
+		CompressedSize:   uint32(len(encoded)),
+		Flags:            uint16(codec.kind),
+	}, encoded, nil
+}
+
+const (
+	abaBlockSize = 0x20000 // LZ4 与未压缩数据块大小，与 Unity 相同 / LZ4 and uncompressed data-block size, matching Unity
+
+	defaultLZ4HCLevel = 9 // Unity 使用的 LZ4HC 级别 / LZ4HC level used by Unity
+	defaultLZMALevel  = 5 // LZMA 默认级别，对应 4 MiB 字典 / Default LZMA level, corresponding to a 4 MiB dictionary
+)
+
+// abaCodec 是已校验的 UnityFS 压缩类型和级别 / abaCodec is a validated UnityFS compression type and level
+type abaCodec struct {
+	kind  byte // UnityFS 压缩类型 / UnityFS compression type
+	level int  // LZ4HC 或 LZMA 的 1-9 级别 / LZ4HC or LZMA level from 1 to 9
+}
+
+// newAbaCodec 校验压缩类型和级别，级别为 0 时使用该类型的默认级别
+// newAbaCodec validates a compression kind and level, using the kind's default level when level is 0
+func newAbaCodec(compression AbaCompression, level int) (abaCodec, error) {
+	kind, err := compression.wireType()
+	if err != nil {
+		return abaCodec{}, err
+	}
+	switch kind {
+	case CompressionLZ4HC, CompressionLZMA:
+		if level < 0 || level > 9 {
+			return abaCodec{}, fmt.Errorf("%s compression level %d out of range 1-9", compression, level)
+		}
+		if level == 0 {
+			level = defaultLZ4HCLevel
+			if kind == CompressionLZMA {
+				level = defaultLZMALevel
+			}
+		}
+	default:
+		if level != 0 {
+			return abaCodec{}, fmt.Errorf("compression level applies only to lz4hc and lzma, not %s", compression)
+		}
+	}
+	return abaCodec{kind: kind, level: level}, nil
+}
+
+// blockSize 返回该压缩类型对 totalDataSize 字节数据使用的数据块大小
+// Unity 把 LZMA 数据写成单个块，这里同样让整段数据成为一个块，因此数据不能超过单块上限
+// blockSize returns the data-block size the compression type uses for totalDataSize bytes of data
+// Unity writes LZMA data as a single block, so the whole data becomes one block here too and must fit the per-block limit
+func (c abaCodec) blockSize(totalDataSize int64) (int, error) {
+	if c.kind != CompressionLZMA {
+		return abaBlockSize, nil
+	}
+	if totalDataSize > maxAbaBlockSize {
+		return 0, fmt.Errorf("LZMA data is written as a single block, and %d bytes exceed the per-block limit %d", totalDataSize, maxAbaBlockSize)
+	}
+	if totalDataSize == 0 {
+		return abaBlockSize, nil
+	}
+	return int(totalDataSize), nil
+}
+
+// compress 压缩 data，shrunk 为 false 表示不压缩或压缩没有减小数据，此时调用者应保留原始字节
+// compress compresses data, with shrunk false when the codec does not compress or compression does not reduce the size, in which case the caller keeps the raw bytes
+func (c abaCodec) compress(data []byte) (encoded []byte, shrunk bool, err error) {
+	if c.kind == CompressionNone || len(data) == 0 {
+		return nil, false, nil
+	}
+	switch c.kind {
+	case CompressionLZ4, CompressionLZ4HC:
+		dst := make([]byte, lz4.CompressBlockBound(len(data)))
+		var n int
+		if c.kind == CompressionLZ4HC {
+			n, err = lz4.CompressBlockHC(data, dst, lz4.CompressionLevel(1<<(8+c.level)), nil, nil)
+		} else {
+			n, err = lz4.CompressBlock(data, dst, nil)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		encoded = dst[:n]
+	case CompressionLZMA:
+		if encoded, err = compressUnityLZMA(data, c.level); err != nil {
+			return nil, false, err
+		}
+	default:
+		return nil, false, fmt.Errorf("unknown compression type %d", c.kind)
+	}
+	if len(encoded) == 0 || len(encoded) >= len(data) {
+		return nil, false, nil
+	}
+	return encoded, true, nil
+}
+
+// compressUnityLZMA 以 UnityFS 布局写出 LZMA 流：五字节属性头后直接跟随压缩数据，省略由块表给出的八字节解压大小
+// 字典大小为 2^(17+level) 字节，但不超过容纳输入所需的大小，以免解码端分配过大的字典
+// compressUnityLZMA emits an LZMA stream in the UnityFS layout: the five-byte properties header directly followed by compressed data, omitting the eight-byte uncompressed size given by the block table
+// The dictionary is 2^(17+level) bytes but no larger than needed to hold the input, so decoders do not allocate an oversized dictionary
+func compressUnityLZMA(data []byte, level int) ([]byte, error) {
+	dictCap := 1 << (17 + level)
+	for dictCap > lzma.MinDictCap && dictCap/2 >= len(data) {
+		dictCap /= 2
+	}
+	var buf bytes.Buffer
+	writer, err := (lzma.WriterConfig{DictCap: dictCap, Size: int64(len(data))}).NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	encoded := buf.Bytes()
+	if len(encoded) < 13 {
+		return nil, fmt.Errorf("LZMA stream is shorter than its 13-byte header")
+	}
+	// 把五字节属性头移到八字节大小字段之后，直接复用缓冲区
+	// Move the five-byte properties header past the eight-byte size field, reusing the buffer in place
+	copy(encoded[8:13], encoded[:5])
+	return encoded[8:], nil
 }
 
 // writeAbaBytes 持续写入直到全部字节完成，并拒绝无进展或无效写入计数
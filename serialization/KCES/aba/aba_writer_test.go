@@ -194,3 +194,143 @@ func TestWriteAbaRejectsIncorrectGeneratedEntrySize(t *testing.T) {
 		t.Fatal("WriteAba accepted a short generated entry")
 	}
 }
+
+func TestWriteAbaCompressionKindsRoundTrip(t *testing.T) {
+	serialized := bytes.Repeat([]byte("serialized ABA entry "), 30000)
+	stream := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8}, 40000)
+	entries := []AbaFileEntry{
+		{Name: "CAB-kinds", Data: serialized, IsSerialized: true},
+		{Name: "CAB-kinds.resS", Data: stream},
+	}
+	tests := []struct {
+		compression AbaCompression
+		level       int
+		blockType   byte
+		infoType    byte
+	}{
+		{AbaCompressionNone, 0, CompressionNone, CompressionLZ4},
+		{AbaCompressionLZ4, 0, CompressionLZ4, CompressionLZ4},
+		{AbaCompressionLZ4HC, 4, CompressionLZ4HC, CompressionLZ4HC},
+		{AbaCompressionLZMA, 0, CompressionLZMA, CompressionLZMA},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.compression), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := WriteAba(&out, entries, &AbaWriteOptions{Compression: tc.compression, CompressionLevel: tc.level}); err != nil {
+				t.Fatalf("WriteAba: %v", err)
+			}
+			bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+			if err != nil {
+				t.Fatalf("ReadAba: %v", err)
+			}
+			if got := bundle.Header.FSHeader.GetCompressionType(); got != tc.infoType {
+				t.Errorf("block/directory info compression = %d, want %d", got, tc.infoType)
+			}
+			if got := bundle.BlockInfo.BlockInfos[0].GetCompressionType(); got != tc.blockType {
+				t.Errorf("data block compression = %d, want %d", got, tc.blockType)
+			}
+			for i, entry := range entries {
+				got, err := bundle.GetFileData(int64(i))
+				if err != nil {
+					t.Fatalf("GetFileData(%d): %v", i, err)
+				}
+				if !bytes.Equal(got, entry.Data) {
+					t.Fatalf("%s differs: got %d bytes, want %d", entry.Name, len(got), len(entry.Data))
+				}
+			}
+		})
+	}
+}
+
+func TestWriteAbaRejectsInvalidCompressionLevel(t *testing.T) {
+	for _, opts := range []*AbaWriteOptions{
+		{Compression: AbaCompressionLZ4, CompressionLevel: 3},
+		{Compression: AbaCompressionLZMA, CompressionLevel: 10},
+		{Compression: "zstd"},
+	} {
+		if err := WriteAba(io.Discard, []AbaFileEntry{{Name: "x", Data: []byte("x")}}, opts); err == nil {
+			t.Errorf("WriteAba accepted %+v", *opts)
+		}
+	}
+}
+
+func TestWriteAbaLZMAUsesSingleBlock(t *testing.T) {
+	// 不可简单重复的载荷使 LZMA 需要真正跨越原先 4 MiB 块边界进行匹配
+	// A payload that is not a plain repetition makes LZMA match across the former 4 MiB block boundary
+	payload := make([]byte, 5<<20+123)
+	state := uint32(1)
+	for i := range payload {
+		state = state*1664525 + 1013904223
+		payload[i] = byte(i/4096) ^ byte(state>>28)
+	}
+	entries := []AbaFileEntry{
+		{Name: "CAB-lzma", Data: payload[:3<<20], IsSerialized: true},
+		{Name: "CAB-lzma.resS", Data: payload[3<<20:]},
+	}
+	var out bytes.Buffer
+	if err := WriteAba(&out, entries, &AbaWriteOptions{Compression: AbaCompressionLZMA, CompressionLevel: 1}); err != nil {
+		t.Fatalf("WriteAba: %v", err)
+	}
+	bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba: %v", err)
+	}
+	blocks := bundle.BlockInfo.BlockInfos
+	if len(blocks) != 1 {
+		t.Fatalf("LZMA data has %d blocks, want 1", len(blocks))
+	}
+	if blocks[0].DecompressedSize != uint32(len(payload)) || blocks[0].GetCompressionType() != CompressionLZMA {
+		t.Errorf("block = %+v, want one LZMA block of %d bytes", blocks[0], len(payload))
+	}
+	for i, entry := range entries {
+		got, err := bundle.GetFileData(int64(i))
+		if err != nil {
+			t.Fatalf("GetFileData(%d): %v", i, err)
+		}
+		if !bytes.Equal(got, entry.Data) {
+			t.Fatalf("%s differs after the LZMA round trip", entry.Name)
+		}
+	}
+}
+
+func TestRecompressAbaKeepsEntries(t *testing.T) {
+	entries := []AbaFileEntry{
+		{Name: "CAB-recompress", Data: bytes.Repeat([]byte("recompressed entry "), 20000), IsSerialized: true},
+		{Name: "CAB-recompress.resS", Data: bytes.Repeat([]byte{9, 8, 7}, 70000)},
+	}
+	var original bytes.Buffer
+	if err := WriteAba(&original, entries, &AbaWriteOptions{EngineVersion: "2019.4.40f1", Version: 7, Compress: true}); err != nil {
+		t.Fatalf("WriteAba: %v", err)
+	}
+	source, err := ReadAba(bytes.NewReader(original.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba: %v", err)
+	}
+	var out bytes.Buffer
+	if err := RecompressAba(&out, source, &AbaWriteOptions{Compression: AbaCompressionLZMA}); err != nil {
+		t.Fatalf("RecompressAba: %v", err)
+	}
+	bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba recompressed: %v", err)
+	}
+	if bundle.Header.EngineVersion != "2019.4.40f1" || bundle.Header.Version != 7 {
+		t.Errorf("header = %q version %d, want the source header", bundle.Header.EngineVersion, bundle.Header.Version)
+	}
+	if out.Len() >= original.Len() {
+		t.Errorf("LZMA output is %d bytes, not smaller than the %d-byte LZ4 source", out.Len(), original.Len())
+	}
+	for i, entry := range entries {
+		dir := bundle.BlockInfo.DirectoryInfos[i]
+		if dir.Name != entry.Name || dir.IsSerialized() != entry.IsSerialized {
+			t.Errorf("directory[%d] = %q serialized=%v, want %q serialized=%v", i, dir.Name, dir.IsSerialized(), entry.Name, entry.IsSerialized)
+		}
+		got, err := bundle.GetFileData(int64(i))
+		if err != nil {
+			t.Fatalf("GetFileData(%d): %v", i, err)
+		}
+		if !bytes.Equal(got, entry.Data) {
+			t.Fatalf("%s differs after recompression", entry.Name)
+		}
+	}
+}
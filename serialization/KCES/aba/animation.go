@@ -2,6 +2,7 @@ package aba
 
 import (
 	"fmt"
+	"math"
 	"strings"
 )
 
@@ -21,8 +22,10 @@ const (
 
 // AnimationTRSKeyframe 表示一个固定宽度的 Unity TRS 关键帧 / AnimationTRSKeyframe represents one fixed-width Unity TRS keyframe
 type AnimationTRSKeyframe struct {
-	Time  float32    // 关键帧时间，单位为秒 / Keyframe time in seconds
-	Value [4]float32 // XYZ 或 XYZW 值 / XYZ or XYZW value
+	Time     float32    // 关键帧时间，单位为秒 / Keyframe time in seconds
+	Value    [4]float32 // XYZ 或 XYZW 值 / XYZ or XYZW value
+	InSlope  [4]float32 // 每秒的入切线，无穷大表示阶跃 / Incoming tangent per second, where infinity means a step
+	OutSlope [4]float32 // 每秒的出切线，无穷大表示阶跃 / Outgoing tangent per second, where infinity means a step
 }
 
 // AnimationTRSCurve 表示绑定到一个 Transform 路径的显式 TRS 曲线 / AnimationTRSCurve represents an explicit TRS curve bound to one Transform path
@@ -35,8 +38,10 @@ type AnimationTRSCurve struct {
 
 // AnimationClipCurves 表示从 Unity AnimationClip 中提取的显式节点曲线 / AnimationClipCurves represents explicit node curves extracted from a Unity AnimationClip
 type AnimationClipCurves struct {
-	Name   string              // Unity m_Name / Unity m_Name
-	Curves []AnimationTRSCurve // 可导出的 TRS 曲线 / Exportable TRS curves
+	Name       string              // Unity m_Name / Unity m_Name
+	SampleRate float32             // Unity m_SampleRate，单位为每秒帧数 / Unity m_SampleRate in frames per second
+	WrapMode   int32               // Unity m_WrapMode，取 AnimationWrapMode 常量 / Unity m_WrapMode as an AnimationWrapMode constant
+	Curves     []AnimationTRSCurve // 可导出的 TRS 曲线 / Exportable TRS curves
 }
 
 // DecodeAnimationClipCurves 使用内嵌 TypeTree 提取带明文 Transform 路径的显式 TRS 曲线
@@ -51,6 +56,10 @@ func (object *NativeUnityObject) DecodeAnimationClipCurves() (*AnimationClipCurv
 	}
 	result := &AnimationClipCurves{}
 	result.Name, _ = root.Field("m_Name").String()
+	result.SampleRate, _ = animationFloat32(root.Field("m_SampleRate"))
+	if wrapMode, ok := root.Field("m_WrapMode").Int64(); ok && wrapMode >= math.MinInt32 && wrapMode <= math.MaxInt32 {
+		result.WrapMode = int32(wrapMode)
+	}
 	rotationPaths := make(map[string]struct{})
 	rotationCurves, err := decodeAnimationCurveVector(root.Field("m_RotationCurves"), AnimationCurveRotation, 4)
 	if err != nil {
@@ -126,6 +135,8 @@ func decodeAnimationCurveVector(vector *TypeTreeValue, property AnimationCurvePr
 					return nil, fmt.Errorf("curve %d keyframe %d has no %s component", curveIndex, keyIndex, componentNames[componentIndex])
 				}
 				keyframe.Value[componentIndex] = component
+				keyframe.InSlope[componentIndex], _ = animationFloat32(key.Field("inSlope").Field(componentNames[componentIndex]))
+				keyframe.OutSlope[componentIndex], _ = animationFloat32(key.Field("outSlope").Field(componentNames[componentIndex]))
 			}
 			curve.Keyframes = append(curve.Keyframes, keyframe)
 			previousTime = time
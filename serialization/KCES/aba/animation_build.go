@@ -0,0 +1,458 @@
+package aba
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// AnimationWrapModeDefault 表示使用 Animation 组件设置的默认循环方式 / AnimationWrapModeDefault uses the default wrap mode configured on the Animation component
+	AnimationWrapModeDefault int32 = 0
+	// AnimationWrapModeOnce 表示播放到末尾后停止 / AnimationWrapModeOnce stops when playback reaches the end
+	AnimationWrapModeOnce int32 = 1
+	// AnimationWrapModeLoop 表示播放到末尾后从头循环 / AnimationWrapModeLoop restarts from the beginning when playback reaches the end
+	AnimationWrapModeLoop int32 = 2
+	// AnimationWrapModePingPong 表示在首尾之间往返播放 / AnimationWrapModePingPong plays back and forth between the start and the end
+	AnimationWrapModePingPong int32 = 4
+	// AnimationWrapModeClampForever 表示播放到末尾后保持最后一帧 / AnimationWrapModeClampForever holds the last frame after playback reaches the end
+	AnimationWrapModeClampForever int32 = 8
+)
+
+const (
+	// animationDefaultSampleRate 是 Unity 新建剪辑的默认采样率 / animationDefaultSampleRate is Unity's default sample rate for new clips
+	animationDefaultSampleRate float32 = 60
+	// animationCurveClampForever 是 Unity 曲线前后无限区间的默认 ClampForever 模式 / animationCurveClampForever is Unity's default ClampForever mode for curve pre- and post-infinity
+	animationCurveClampForever int32 = 2
+	// animationRotationOrderZXY 是 Unity 默认的 ZXY 欧拉角应用顺序 / animationRotationOrderZXY is Unity's default ZXY Euler-angle application order
+	animationRotationOrderZXY int32 = 4
+	// animationDefaultTangentWeight 是 Unity 未加权关键帧的默认切线权重 / animationDefaultTangentWeight is Unity's default tangent weight for unweighted keyframes
+	animationDefaultTangentWeight float32 = 1.0 / 3.0
+)
+
+// animationTypeTreeField 描述手工构建的 TypeTree 中的一个字段及其子字段 / animationTypeTreeField describes one field and its children in a hand-built TypeTree
+type animationTypeTreeField struct {
+	typeName  string
+	name      string
+	byteSize  int32
+	typeFlags byte
+	metaFlags uint32
+	children  []animationTypeTreeField
+}
+
+// animationScalarField 返回一个定长标量字段
+// animationScalarField returns a fixed-size scalar field
+func animationScalarField(typeName string, name string, byteSize int32) animationTypeTreeField {
+	return animationTypeTreeField{typeName: typeName, name: name, byteSize: byteSize}
+}
+
+// animationAlignedField 为字段加上写入后四字节对齐标志
+// animationAlignedField adds the four-byte alignment-after-write flag to a field
+func animationAlignedField(field animationTypeTreeField) animationTypeTreeField {
+	field.metaFlags |= 0x4000
+	return field
+}
+
+// animationStructField 返回一个由子字段组成的结构字段，子字段全部定长时同时计算其字节数
+// animationStructField returns a structure field made of child fields and computes its byte size when every child is fixed-size
+func animationStructField(typeName string, name string, children ...animationTypeTreeField) animationTypeTreeField {
+	field := animationTypeTreeField{typeName: typeName, name: name, children: children}
+	for _, child := range children {
+		if child.byteSize < 0 || child.metaFlags&0x4000 != 0 {
+			field.byteSize = -1
+			break
+		}
+		field.byteSize += child.byteSize
+	}
+	for _, child := range children {
+		if child.metaFlags&0xC000 != 0 {
+			field.metaFlags |= 0x8000
+		}
+	}
+	return field
+}
+
+// animationVectorField 返回 Unity vector 字段及其 Array 子结构
+// animationVectorField returns a Unity vector field with its Array substructure
+func animationVectorField(name string, element animationTypeTreeField) animationTypeTreeField {
+	element.name = "data"
+	array := animationTypeTreeField{
+		typeName:  "Array",
+		name:      "Array",
+		byteSize:  -1,
+		typeFlags: 0x1,
+		metaFlags: 0x4000,
+		children:  []animationTypeTreeField{animationScalarField("int", "size", 4), element},
+	}
+	return animationTypeTreeField{typeName: "vector", name: name, byteSize: -1, metaFlags: 0x8000, children: []animationTypeTreeField{array}}
+}
+
+// animationStringField 返回 Unity string 字段及其字符 Array 子结构
+// animationStringField returns a Unity string field with its character Array substructure
+func animationStringField(name string) animationTypeTreeField {
+	array := animationTypeTreeField{
+		typeName:  "Array",
+		name:      "Array",
+		byteSize:  -1,
+		typeFlags: 0x1,
+		metaFlags: 0x4001,
+		children:  []animationTypeTreeField{animationScalarField("int", "size", 4), animationScalarField("char", "data", 1)},
+	}
+	return animationTypeTreeField{typeName: "string", name: name, byteSize: -1, metaFlags: 0x8000, children: []animationTypeTreeField{array}}
+}
+
+// animationPPtrField 返回一个指向 targetType 的 PPtr 字段
+// animationPPtrField returns a PPtr field pointing to targetType
+func animationPPtrField(targetType string, name string) animationTypeTreeField {
+	return animationStructField("PPtr<"+targetType+">", name,
+		animationScalarField("int", "m_FileID", 4),
+		animationScalarField("SInt64", "m_PathID", 8),
+	)
+}
+
+// animationVectorStruct 返回 x、y、z 以及可选 w 分量组成的向量或四元数结构
+// animationVectorStruct returns a vector or quaternion structure made of x, y, z, and an optional w component
+func animationVectorStruct(typeName string, name string) animationTypeTreeField {
+	components := []animationTypeTreeField{
+		animationScalarField("float", "x", 4),
+		animationScalarField("float", "y", 4),
+		animationScalarField("float", "z", 4),
+	}
+	if typeName == "Quaternionf" {
+		components = append(components, animationScalarField("float", "w", 4))
+	}
+	return animationStructField(typeName, name, components...)
+}
+
+// animationCurveField 返回值类型为 valueType 的 AnimationCurve 字段
+// animationCurveField returns an AnimationCurve field whose keyframe values use valueType
+func animationCurveField(valueType string) animationTypeTreeField {
+	value := func(name string) animationTypeTreeField {
+		if valueType == "float" {
+			return animationScalarField("float", name, 4)
+		}
+		return animationVectorStruct(valueType, name)
+	}
+	keyframe := animationStructField("Keyframe", "data",
+		animationScalarField("float", "time", 4),
+		value("value"),
+		value("inSlope"),
+		value("outSlope"),
+		animationScalarField("int", "weightedMode", 4),
+		value("inWeight"),
+		value("outWeight"),
+	)
+	return animationStructField("AnimationCurve", "curve",
+		animationVectorField("m_Curve", keyframe),
+		animationScalarField("int", "m_PreInfinity", 4),
+		animationScalarField("int", "m_PostInfinity", 4),
+		animationScalarField("int", "m_RotationOrder", 4),
+	)
+}
+
+// unity2022AnimationClipTypeTree 构建 Unity 2022.3 旧版 AnimationClip 的 TypeTree
+// 仓库没有官方 AnimationClip schema 样本，因此 TypeHash 为零并省略仅 Mecanim 使用的 m_MuscleClip 与压缩旋转曲线；带 TypeTree 的 AssetBundle 按字段名读取，缺失字段保持 Unity 默认值
+// FloatCurve、PPtrCurve 与 GenericBinding 已经是 Unity 2022.3 形状，normalizeAnimationClipForUnity2022 不会再改写
+// unity2022AnimationClipTypeTree builds the TypeTree of a Unity 2022.3 legacy AnimationClip
+// The repository has no official AnimationClip schema sample, so the TypeHash is zero and the Mecanim-only m_MuscleClip and compressed rotation curves are omitted; AssetBundles with TypeTrees are read by field name and missing fields keep Unity's defaults
+// FloatCurve, PPtrCurve, and GenericBinding already use the Unity 2022.3 shape, so normalizeAnimationClipForUnity2022 leaves them unchanged
+func unity2022AnimationClipTypeTree() (TypeTreeType, error) {
+	vectorCurve := func(typeName string, valueType string) animationTypeTreeField {
+		return animationStructField(typeName, "data", animationCurveField(valueType), animationStringField("path"))
+	}
+	curveBinding := func(curve animationTypeTreeField) []animationTypeTreeField {
+		return []animationTypeTreeField{
+			curve,
+			animationStringField("attribute"),
+			animationStringField("path"),
+			animationScalarField("int", "classID", 4),
+			animationPPtrField("MonoScript", "script"),
+			animationScalarField("int", "flags", 4),
+		}
+	}
+	pptrKeyframe := animationStructField("PPtrKeyframe", "data",
+		animationScalarField("float", "time", 4),
+		animationPPtrField("Object", "value"),
+	)
+	genericBinding := animationStructField("GenericBinding", "data",
+		animationScalarField("unsigned int", "path", 4),
+		animationScalarField("unsigned int", "attribute", 4),
+		animationPPtrField("Object", "script"),
+		animationScalarField("int", "typeID", 4),
+		animationScalarField("UInt8", "customType", 1),
+		animationScalarField("UInt8", "isPPtrCurve", 1),
+		animationAlignedField(animationScalarField("UInt8", "isIntCurve", 1)),
+	)
+	animationEvent := animationStructField("AnimationEvent", "data",
+		animationScalarField("float", "time", 4),
+		animationStringField("functionName"),
+		animationStringField("data"),
+		animationPPtrField("Object", "objectReferenceParameter"),
+		animationScalarField("float", "floatParameter", 4),
+		animationScalarField("int", "intParameter", 4),
+		animationScalarField("int", "messageOptions", 4),
+	)
+	root := animationStructField("AnimationClip", "Base",
+		animationStringField("m_Name"),
+		animationScalarField("bool", "m_Legacy", 1),
+		animationScalarField("bool", "m_Compressed", 1),
+		animationAlignedField(animationScalarField("bool", "m_UseHighQualityCurve", 1)),
+		animationVectorField("m_RotationCurves", vectorCurve("QuaternionCurve", "Quaternionf")),
+		animationVectorField("m_EulerCurves", vectorCurve("Vector3Curve", "Vector3f")),
+		animationVectorField("m_PositionCurves", vectorCurve("Vector3Curve", "Vector3f")),
+		animationVectorField("m_ScaleCurves", vectorCurve("Vector3Curve", "Vector3f")),
+		animationVectorField("m_FloatCurves", animationStructField("FloatCurve", "data", curveBinding(animationCurveField("float"))...)),
+		animationVectorField("m_PPtrCurves", animationStructField("PPtrCurve", "data", curveBinding(animationVectorField("curve", pptrKeyframe))...)),
+		animationScalarField("float", "m_SampleRate", 4),
+		animationScalarField("int", "m_WrapMode", 4),
+		animationStructField("AABB", "m_Bounds", animationVectorStruct("Vector3f", "m_Center"), animationVectorStruct("Vector3f", "m_Extent")),
+		animationStructField("AnimationClipBindingConstant", "m_ClipBindingConstant",
+			animationVectorField("genericBindings", genericBinding),
+			animationVectorField("pptrCurveMapping", animationPPtrField("Object", "data")),
+		),
+		animationScalarField("bool", "m_HasGenericRootTransform", 1),
+		animationAlignedField(animationScalarField("bool", "m_HasMotionFloatCurves", 1)),
+		animationVectorField("m_Events", animationEvent),
+	)
+
+	tree := TypeTreeType{TypeId: ClassIDAnimationClip, ScriptTypeIndex: -1}
+	offsets := make(map[string]uint32)
+	stringOffset := func(value string) (uint32, error) {
+		if offset, ok := offsets[value]; ok {
+			return offset, nil
+		}
+		offset, err := appendTypeTreeString(&tree, value)
+		if err != nil {
+			return 0, err
+		}
+		offsets[value] = offset
+		return offset, nil
+	}
+	var appendField func(field animationTypeTreeField, level byte) error
+	appendField = func(field animationTypeTreeField, level byte) error {
+		typeOffset, err := stringOffset(field.typeName)
+		if err != nil {
+			return err
+		}
+		nameOffset, err := stringOffset(field.name)
+		if err != nil {
+			return err
+		}
+		tree.Nodes = append(tree.Nodes, TypeTreeNode{
+			Version:    1,
+			Level:      level,
+			TypeFlags:  field.typeFlags,
+			TypeStrOff: typeOffset,
+			NameStrOff: nameOffset,
+			ByteSize:   field.byteSize,
+			Index:      int32(len(tree.Nodes)),
+			MetaFlags:  field.metaFlags,
+		})
+		for _, child := range field.children {
+			if err := appendField(child, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := appendField(root, 0); err != nil {
+		return TypeTreeType{}, fmt.Errorf("build AnimationClip TypeTree: %w", err)
+	}
+	return tree, nil
+}
+
+// NewNativeAnimationClipObject 从显式 TRS 曲线构建携带 Unity 2022.3 TypeTree 的独立旧版 AnimationClip 对象
+// 剪辑长度由最后一个关键帧时间决定；SampleRate 为零时使用 Unity 默认的 60，WrapMode 使用 AnimationWrapMode 常量
+// NewNativeAnimationClipObject builds a standalone legacy AnimationClip object carrying a Unity 2022.3 TypeTree from explicit TRS curves
+// The clip length is the last keyframe time; a zero SampleRate uses Unity's default of 60, and WrapMode takes an AnimationWrapMode constant
+func NewNativeAnimationClipObject(clip *AnimationClipCurves) (*NativeUnityObject, error) {
+	if err := validateAnimationClipForBuild(clip); err != nil {
+		return nil, err
+	}
+	tree, err := unity2022AnimationClipTypeTree()
+	if err != nil {
+		return nil, err
+	}
+	root, next, err := newTypeTreeValueSkeleton(&tree, 0)
+	if err != nil {
+		return nil, fmt.Errorf("build AnimationClip value skeleton: %w", err)
+	}
+	if next != int64(len(tree.Nodes)) {
+		return nil, fmt.Errorf("AnimationClip value skeleton consumed %d of %d TypeTree nodes", next, len(tree.Nodes))
+	}
+
+	sampleRate := clip.SampleRate
+	if sampleRate == 0 {
+		sampleRate = animationDefaultSampleRate
+	}
+	for _, field := range []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "m_Name", value: clip.Name},
+		{name: "m_Legacy", value: true},
+		{name: "m_UseHighQualityCurve", value: true},
+		{name: "m_SampleRate", value: sampleRate},
+		{name: "m_WrapMode", value: int64(clip.WrapMode)},
+	} {
+		if err := setMeshValue(root, field.value, field.name); err != nil {
+			return nil, fmt.Errorf("AnimationClip: %w", err)
+		}
+	}
+	for curveIndex, curve := range clip.Curves {
+		fieldName := animationCurveVectorName(curve.Property)
+		vector, err := meshValueField(root, fieldName)
+		if err != nil {
+			return nil, fmt.Errorf("AnimationClip: %w", err)
+		}
+		element, err := newMeshArrayElement(&tree, vector, len(vector.Children))
+		if err != nil {
+			return nil, err
+		}
+		if err := fillAnimationCurveElement(&tree, element, curve); err != nil {
+			return nil, fmt.Errorf("curve %d path %q: %w", curveIndex, curve.Path, err)
+		}
+		vector.Children = append(vector.Children, element)
+	}
+
+	object := &NativeUnityObject{ClassID: ClassIDAnimationClip, TypeTree: tree}
+	data, err := object.EncodeValue(root)
+	if err != nil {
+		return nil, fmt.Errorf("encode AnimationClip: %w", err)
+	}
+	object.Data = data
+	return object, nil
+}
+
+// validateAnimationClipForBuild 验证剪辑名、采样率、循环方式以及每条曲线的路径、维度和关键帧
+// validateAnimationClipForBuild validates the clip name, sample rate, wrap mode, and every curve's path, dimension, and keyframes
+func validateAnimationClipForBuild(clip *AnimationClipCurves) error {
+	if clip == nil {
+		return fmt.Errorf("nil AnimationClip curves")
+	}
+	if strings.IndexByte(clip.Name, 0) >= 0 {
+		return fmt.Errorf("AnimationClip name contains a NUL byte")
+	}
+	if clip.SampleRate < 0 || math.IsNaN(float64(clip.SampleRate)) || math.IsInf(float64(clip.SampleRate), 0) {
+		return fmt.Errorf("AnimationClip sample rate %g is invalid", clip.SampleRate)
+	}
+	switch clip.WrapMode {
+	case AnimationWrapModeDefault, AnimationWrapModeOnce, AnimationWrapModeLoop, AnimationWrapModePingPong, AnimationWrapModeClampForever:
+	default:
+		return fmt.Errorf("AnimationClip wrap mode %d is unsupported", clip.WrapMode)
+	}
+	if len(clip.Curves) == 0 {
+		return fmt.Errorf("AnimationClip has no curves")
+	}
+	seen := make(map[string]struct{}, len(clip.Curves))
+	for curveIndex, curve := range clip.Curves {
+		if strings.IndexByte(curve.Path, 0) >= 0 {
+			return fmt.Errorf("curve %d path contains a NUL byte", curveIndex)
+		}
+		wantDimension := uint8(3)
+		switch curve.Property {
+		case AnimationCurveRotation:
+			wantDimension = 4
+		case AnimationCurveTranslation, AnimationCurveScale, AnimationCurveEuler:
+		default:
+			return fmt.Errorf("curve %d has unsupported property %d", curveIndex, curve.Property)
+		}
+		if curve.Dimension != wantDimension {
+			return fmt.Errorf("curve %d dimension is %d, want %d", curveIndex, curve.Dimension, wantDimension)
+		}
+		key := fmt.Sprintf("%d/%s", curve.Property, curve.Path)
+		if _, duplicated := seen[key]; duplicated {
+			return fmt.Errorf("curve %d duplicates another curve for path %q", curveIndex, curve.Path)
+		}
+		seen[key] = struct{}{}
+		if len(curve.Keyframes) == 0 {
+			return fmt.Errorf("curve %d has no keyframes", curveIndex)
+		}
+		for keyIndex, keyframe := range curve.Keyframes {
+			if math.IsNaN(float64(keyframe.Time)) || math.IsInf(float64(keyframe.Time), 0) || keyframe.Time < 0 {
+				return fmt.Errorf("curve %d keyframe %d time %g is invalid", curveIndex, keyIndex, keyframe.Time)
+			}
+			if keyIndex != 0 && keyframe.Time <= curve.Keyframes[keyIndex-1].Time {
+				return fmt.Errorf("curve %d keyframe %d has non-increasing time", curveIndex, keyIndex)
+			}
+			for componentIndex := uint8(0); componentIndex < curve.Dimension; componentIndex++ {
+				value := float64(keyframe.Value[componentIndex])
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					return fmt.Errorf("curve %d keyframe %d value is not finite", curveIndex, keyIndex)
+				}
+				if math.IsNaN(float64(keyframe.InSlope[componentIndex])) || math.IsNaN(float64(keyframe.OutSlope[componentIndex])) {
+					return fmt.Errorf("curve %d keyframe %d slope is NaN", curveIndex, keyIndex)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// animationCurveVectorName 返回 TRS 属性对应的 AnimationClip 曲线向量字段名
+// animationCurveVectorName returns the AnimationClip curve-vector field name of a TRS property
+func animationCurveVectorName(property AnimationCurveProperty) string {
+	switch property {
+	case AnimationCurveRotation:
+		return "m_RotationCurves"
+	case AnimationCurveScale:
+		return "m_ScaleCurves"
+	case AnimationCurveEuler:
+		return "m_EulerCurves"
+	default:
+		return "m_PositionCurves"
+	}
+}
+
+// fillAnimationCurveElement 写入一条 QuaternionCurve 或 Vector3Curve 的路径、关键帧和无限区间模式
+// fillAnimationCurveElement fills one QuaternionCurve or Vector3Curve with its path, keyframes, and infinity modes
+func fillAnimationCurveElement(tree *TypeTreeType, element *TypeTreeValue, curve AnimationTRSCurve) error {
+	if err := setMeshValue(element, curve.Path, "path"); err != nil {
+		return err
+	}
+	for _, field := range []struct {
+		name  string
+		value int32
+	}{
+		{name: "m_PreInfinity", value: animationCurveClampForever},
+		{name: "m_PostInfinity", value: animationCurveClampForever},
+		{name: "m_RotationOrder", value: animationRotationOrderZXY},
+	} {
+		if err := setMeshValue(element, int64(field.value), "curve", field.name); err != nil {
+			return err
+		}
+	}
+	keys, err := meshValueField(element, "curve", "m_Curve")
+	if err != nil {
+		return err
+	}
+	componentNames := [...]string{"x", "y", "z", "w"}
+	for keyIndex, keyframe := range curve.Keyframes {
+		key, err := newMeshArrayElement(tree, keys, keyIndex)
+		if err != nil {
+			return err
+		}
+		if err := setMeshValue(key, keyframe.Time, "time"); err != nil {
+			return err
+		}
+		for componentIndex := uint8(0); componentIndex < curve.Dimension; componentIndex++ {
+			component := componentNames[componentIndex]
+			for _, field := range []struct {
+				name  string
+				value float32
+			}{
+				{name: "value", value: keyframe.Value[componentIndex]},
+				{name: "inSlope", value: keyframe.InSlope[componentIndex]},
+				{name: "outSlope", value: keyframe.OutSlope[componentIndex]},
+				{name: "inWeight", value: animationDefaultTangentWeight},
+				{name: "outWeight", value: animationDefaultTangentWeight},
+			} {
+				if err := setMeshValue(key, field.value, field.name, component); err != nil {
+					return err
+				}
+			}
+		}
+		keys.Children = append(keys.Children, key)
+	}
+	return nil
+}
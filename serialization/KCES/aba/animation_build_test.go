@@ -0,0 +1,128 @@
+package aba
+
+import (
+	"math"
+	"testing"
+)
+
+func newBuildTestAnimationClip() *AnimationClipCurves {
+	step := float32(math.Inf(1))
+	return &AnimationClipCurves{
+		Name:       "custom_motion",
+		SampleRate: 30,
+		WrapMode:   AnimationWrapModeLoop,
+		Curves: []AnimationTRSCurve{
+			{Path: "Bip01", Property: AnimationCurveTranslation, Dimension: 3, Keyframes: []AnimationTRSKeyframe{
+				{Time: 0, Value: [4]float32{0, 1, 0}, OutSlope: [4]float32{1, 0, 0}},
+				{Time: 0.5, Value: [4]float32{0.5, 1, 0}, InSlope: [4]float32{1, 0, 0}, OutSlope: [4]float32{step, step, step}},
+				{Time: 1, Value: [4]float32{0.5, 1.2, 0}, InSlope: [4]float32{step, step, step}},
+			}},
+			{Path: "Bip01/Bip01 Spine", Property: AnimationCurveRotation, Dimension: 4, Keyframes: []AnimationTRSKeyframe{
+				{Time: 0, Value: [4]float32{0, 0, 0, 1}},
+				{Time: 1, Value: [4]float32{0, 0.7071068, 0, 0.7071068}},
+			}},
+			{Path: "Bip01/Bip01 Spine", Property: AnimationCurveScale, Dimension: 3, Keyframes: []AnimationTRSKeyframe{
+				{Time: 0.25, Value: [4]float32{1, 1, 1}},
+			}},
+			{Path: "", Property: AnimationCurveEuler, Dimension: 3, Keyframes: []AnimationTRSKeyframe{
+				{Time: 0, Value: [4]float32{0, 90, 0}},
+			}},
+		},
+	}
+}
+
+func TestNewNativeAnimationClipObjectRoundTripsCurves(t *testing.T) {
+	source := newBuildTestAnimationClip()
+	object, err := NewNativeAnimationClipObject(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := WriteAnimationClip(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reread, err := ReadAnimationClip(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := reread.DecodeAnimationClipCurves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name != source.Name || decoded.SampleRate != source.SampleRate || decoded.WrapMode != source.WrapMode {
+		t.Fatalf("clip settings = %q %g %d, want %q %g %d", decoded.Name, decoded.SampleRate, decoded.WrapMode, source.Name, source.SampleRate, source.WrapMode)
+	}
+	if len(decoded.Curves) != len(source.Curves) {
+		t.Fatalf("decoded %d curves, want %d", len(decoded.Curves), len(source.Curves))
+	}
+	byKey := make(map[AnimationCurveProperty]AnimationTRSCurve)
+	for _, curve := range decoded.Curves {
+		byKey[curve.Property] = curve
+	}
+	for _, want := range source.Curves {
+		got, ok := byKey[want.Property]
+		if !ok || got.Path != want.Path || got.Dimension != want.Dimension || len(got.Keyframes) != len(want.Keyframes) {
+			t.Fatalf("decoded curve for property %d = %+v, want %+v", want.Property, got, want)
+		}
+		for keyIndex := range want.Keyframes {
+			if got.Keyframes[keyIndex] != want.Keyframes[keyIndex] {
+				t.Fatalf("property %d keyframe %d = %+v, want %+v", want.Property, keyIndex, got.Keyframes[keyIndex], want.Keyframes[keyIndex])
+			}
+		}
+	}
+
+	root, err := reread.DecodeValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy, _ := root.Field("m_Legacy").Value.(bool); !legacy {
+		t.Fatal("built AnimationClip is not legacy")
+	}
+	tree := cloneTypeTreeType(&reread.TypeTree)
+	changed, err := normalizeAnimationClipForUnity2022(&tree, root)
+	if err != nil || changed {
+		t.Fatalf("normalize built AnimationClip = %t, %v; want an unchanged Unity 2022.3 schema", changed, err)
+	}
+}
+
+func TestNewNativeAnimationClipObjectDefaultsSampleRate(t *testing.T) {
+	clip := newBuildTestAnimationClip()
+	clip.SampleRate = 0
+	object, err := NewNativeAnimationClipObject(clip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := object.DecodeAnimationClipCurves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SampleRate != 60 {
+		t.Fatalf("sample rate = %g, want 60", decoded.SampleRate)
+	}
+}
+
+func TestNewNativeAnimationClipObjectRejectsInvalidCurves(t *testing.T) {
+	for name, mutate := range map[string]func(*AnimationClipCurves){
+		"no curves":     func(clip *AnimationClipCurves) { clip.Curves = nil },
+		"wrap mode":     func(clip *AnimationClipCurves) { clip.WrapMode = 3 },
+		"negative rate": func(clip *AnimationClipCurves) { clip.SampleRate = -1 },
+		"dimension":     func(clip *AnimationClipCurves) { clip.Curves[1].Dimension = 3 },
+		"empty curve":   func(clip *AnimationClipCurves) { clip.Curves[2].Keyframes = nil },
+		"duplicated curve": func(clip *AnimationClipCurves) {
+			clip.Curves[2].Property = AnimationCurveTranslation
+			clip.Curves[2].Path = "Bip01"
+		},
+		"time order":        func(clip *AnimationClipCurves) { clip.Curves[0].Keyframes[2].Time = 0.5 },
+		"non-finite value":  func(clip *AnimationClipCurves) { clip.Curves[1].Keyframes[0].Value[3] = float32(math.NaN()) },
+		"NUL in path":       func(clip *AnimationClipCurves) { clip.Curves[0].Path = "Bip01\x00" },
+		"negative key time": func(clip *AnimationClipCurves) { clip.Curves[3].Keyframes[0].Time = -1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			clip := newBuildTestAnimationClip()
+			mutate(clip)
+			if _, err := NewNativeAnimationClipObject(clip); err == nil {
+				t.Fatal("NewNativeAnimationClipObject accepted invalid curves")
+			}
+		})
+	}
+}
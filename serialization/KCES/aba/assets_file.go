@@ -53,6 +53,10 @@ type AssetsFile struct {
 	Metadata  AssetsMetadata          // 包含类型树和资源列表的元数据 / Metadata including type trees and asset list
 	Data      []byte                  // 用于按偏移读取资源的原始文件数据 / Raw file bytes used to read assets by offset
 	readRange AssetsFileRangeResolver // 未整体载入文件时使用的范围读取器 / Range reader used when the complete file is not loaded
+
+	// assetInfoPositions 保存每个对象表条目在 metadata 中的起始位置（对齐前），供原地改写对象偏移使用
+	// assetInfoPositions stores where each object-table entry starts in metadata (before alignment) for in-place offset patching
+	assetInfoPositions []int64
 }
 
 // AssetsFileRangeResolver 从 SerializedFile 的绝对偏移读取精确字节范围
@@ -453,12 +457,15 @@ func (af *AssetsFile) readMetadata(data []byte, pos int64, order binary.ByteOrde
 		return err
 	}
 	af.Metadata.AssetInfos = makeABACountedSliceForAppend[AssetInfo](int64(assetCount))
+	af.assetInfoPositions = makeABACountedSliceForAppend[int64](int64(assetCount))
 	for i := int64(0); i < int64(assetCount); i++ {
 		var info AssetInfo
+		position := r.Pos()
 		if err := af.readAssetInfo(r, &info); err != nil {
 			return fmt.Errorf("read asset info[%d] failed: %w", i, err)
 		}
 		af.Metadata.AssetInfos = append(af.Metadata.AssetInfos, info)
+		af.assetInfoPositions = append(af.assetInfoPositions, position)
 	}
 
 	// 支持的 Unity 格式在外部引用前包含 LocalSerializedObjectIdentifier 脚本类型数组
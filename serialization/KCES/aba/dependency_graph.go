@@ -0,0 +1,377 @@
+package aba
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// DependencyEdgeStatus 表示一条 PPtr 引用的解析结果
+// DependencyEdgeStatus represents the resolution result of one PPtr reference
+type DependencyEdgeStatus string
+
+const (
+	DependencyResolved      DependencyEdgeStatus = "resolved"      // 目标对象位于输入的某个 SerializedFile 中 / The target object lies in one of the input SerializedFiles
+	DependencyBuiltin       DependencyEdgeStatus = "builtin"       // 目标位于 Unity 内置资源文件，由游戏本体提供 / The target lies in a Unity built-in resource file provided by the game itself
+	DependencyMissingFile   DependencyEdgeStatus = "missingFile"   // 引用的外部 CAB 不在任何输入包中 / The referenced external CAB is not in any input bundle
+	DependencyMissingObject DependencyEdgeStatus = "missingObject" // 目标文件已加载但不含该 PathID / The target file is loaded but does not contain the PathID
+	DependencyInvalidFileID DependencyEdgeStatus = "invalidFileID" // m_FileID 超出外部文件表 / m_FileID lies outside the external-file table
+)
+
+// Dangling 报告引用是否悬空，即既未解析也不指向内置资源
+// Dangling reports whether the reference dangles, being neither resolved nor pointing at a built-in resource
+func (s DependencyEdgeStatus) Dangling() bool {
+	return s != DependencyResolved && s != DependencyBuiltin
+}
+
+// DependencyGraphSource 是依赖图的一个输入包 / DependencyGraphSource is one input bundle of a dependency graph
+type DependencyGraphSource struct {
+	Name   string // 报告中显示的包名，通常是文件路径 / Bundle name shown in the report, normally its file path
+	Bundle *Aba   // 已解析的 UnityFS 包（.aba、.asset_bg 或 .asset_scene） / Parsed UnityFS bundle (.aba, .asset_bg, or .asset_scene)
+}
+
+// DependencyGraph 是一组 UnityFS 包中全部对象及其 PPtr 引用的报告
+// DependencyGraph is a report of every object and its PPtr references across a set of UnityFS bundles
+type DependencyGraph struct {
+	Bundles      []DependencyBundle `json:"bundles"`      // 输入包及其 CAB 依赖 / Input bundles and their CAB dependencies
+	Objects      []DependencyObject `json:"objects"`      // 全部对象，按输入包和对象表顺序排列 / Every object, in input-bundle and object-table order
+	Edges        []DependencyEdge   `json:"edges"`        // 全部非空 PPtr 引用 / Every non-null PPtr reference
+	Dangling     []DependencyEdge   `json:"dangling"`     // 无法解析且不指向内置资源的引用 / References that cannot be resolved and do not point at built-in resources
+	Unreferenced []DependencyObject `json:"unreferenced"` // 除 AssetBundle 外没有任何引用指向的对象 / Objects other than AssetBundle that nothing refers to
+}
+
+// DependencyBundle 描述一个输入包 / DependencyBundle describes one input bundle
+type DependencyBundle struct {
+	Name            string                   `json:"name"`                   // 包名 / Bundle name
+	SerializedFiles []string                 `json:"serializedFiles"`        // 包内 SerializedFile 条目名 / SerializedFile entry names in the bundle
+	Dependencies    []DependencyExternal     `json:"dependencies,omitempty"` // 外部文件表引用的其他文件，已去重 / Other files referenced by the external-file tables, deduplicated
+	Errors          []string                 `json:"errors,omitempty"`       // 无法解析的条目或对象 / Entries or objects that could not be parsed
+	objectsByFile   map[string]map[int64]int // 未导出：文件名到 PathID 到对象索引 / Unexported: file name to PathID to object index
+}
+
+// DependencyExternal 描述包依赖的一个外部文件 / DependencyExternal describes one external file a bundle depends on
+type DependencyExternal struct {
+	File     string `json:"file"`              // 外部文件名，如 CAB-xxx / External file name such as CAB-xxx
+	PathName string `json:"pathName"`          // 外部文件表中的原始路径 / Original path from the external-file table
+	Bundle   string `json:"bundle,omitempty"`  // 提供该文件的输入包，未找到时为空 / Input bundle providing the file, empty when not found
+	Builtin  bool   `json:"builtin,omitempty"` // 是否为 Unity 内置资源文件 / Whether this is a Unity built-in resource file
+	Missing  bool   `json:"missing,omitempty"` // 既不在输入包中也不是内置资源 / Neither in the input bundles nor a built-in resource
+}
+
+// DependencyObject 描述图中的一个对象 / DependencyObject describes one object in the graph
+type DependencyObject struct {
+	Bundle   string `json:"bundle"`             // 所在包名 / Containing bundle name
+	File     string `json:"file"`               // 所在 SerializedFile 条目名 / Containing SerializedFile entry name
+	PathID   int64  `json:"pathId"`             // Unity PathID / Unity PathID
+	ClassID  int32  `json:"classId"`            // Unity ClassID / Unity ClassID
+	Type     string `json:"type"`               // 类型名 / Type name
+	Name     string `json:"name,omitempty"`     // 对象 m_Name / Object m_Name
+	LoadName string `json:"loadName,omitempty"` // AssetBundle m_Container 加载名 / AssetBundle m_Container load name
+	RefCount int    `json:"refCount"`           // 指向该对象的已解析引用数 / Number of resolved references to this object
+}
+
+// DependencyEdge 描述一条 PPtr 引用 / DependencyEdge describes one PPtr reference
+type DependencyEdge struct {
+	FromBundle string               `json:"fromBundle"`         // 引用方所在包 / Bundle of the referring object
+	FromFile   string               `json:"fromFile"`           // 引用方所在 SerializedFile / SerializedFile of the referring object
+	FromPathID int64                `json:"fromPathId"`         // 引用方 PathID / PathID of the referring object
+	Field      string               `json:"field"`              // PPtr 字段路径，如 m_Materials[0] / PPtr field path such as m_Materials[0]
+	FileID     int32                `json:"fileId"`             // 原始 m_FileID / Original m_FileID
+	ToFile     string               `json:"toFile,omitempty"`   // 目标 SerializedFile 名 / Target SerializedFile name
+	ToBundle   string               `json:"toBundle,omitempty"` // 目标所在包，未解析时为空 / Bundle of the target, empty when unresolved
+	ToPathID   int64                `json:"toPathId"`           // 目标 PathID / Target PathID
+	Status     DependencyEdgeStatus `json:"status"`             // 解析结果 / Resolution result
+}
+
+// unityBuiltinResourceFiles 是由 Unity 播放器提供、不会出现在 AssetBundle 中的内置资源文件
+// unityBuiltinResourceFiles lists built-in resource files provided by the Unity player that never appear in an AssetBundle
+var unityBuiltinResourceFiles = map[string]bool{
+	"unity default resources": true,
+	"unity_builtin_extra":     true,
+	"unity editor resources":  true,
+}
+
+// dependencyFileRef 记录一个已加载 SerializedFile 的来源 / dependencyFileRef records where a loaded SerializedFile came from
+type dependencyFileRef struct {
+	bundle int         // 输入包索引 / Input bundle index
+	name   string      // 条目名 / Entry name
+	file   *AssetsFile // 已解析的文件 / Parsed file
+}
+
+// BuildDependencyGraph 读取全部输入包中的对象，按 TypeTree 收集 PPtr 引用并跨包解析
+// 外部文件按条目名（不区分大小写）匹配其他输入包中的 SerializedFile；没有 TypeTree 的对象只作为节点出现
+// BuildDependencyGraph reads the objects of every input bundle, collects PPtr references through their TypeTrees, and resolves them across bundles
+// External files are matched case-insensitively by entry name against SerializedFiles in the other input bundles; objects without a TypeTree appear only as nodes
+func BuildDependencyGraph(sources []DependencyGraphSource) (*DependencyGraph, error) {
+	graph := &DependencyGraph{
+		Bundles:      make([]DependencyBundle, len(sources)),
+		Objects:      []DependencyObject{},
+		Edges:        []DependencyEdge{},
+		Dangling:     []DependencyEdge{},
+		Unreferenced: []DependencyObject{},
+	}
+	filesByName := map[string]dependencyFileRef{}
+	var files []dependencyFileRef
+
+	// 第一遍登记所有 SerializedFile 和对象，使第二遍可以解析跨包引用
+	// The first pass registers every SerializedFile and object so the second pass can resolve cross-bundle references
+	for bundleIndex, source := range sources {
+		if source.Bundle == nil {
+			return nil, fmt.Errorf("bundle %q is nil", source.Name)
+		}
+		bundle := &graph.Bundles[bundleIndex]
+		bundle.Name = source.Name
+		bundle.SerializedFiles = []string{}
+		bundle.objectsByFile = map[string]map[int64]int{}
+		for dirIndex, dir := range source.Bundle.BlockInfo.DirectoryInfos {
+			if !dir.IsSerialized() {
+				continue
+			}
+			index := int64(dirIndex)
+			af, err := ReadAssetsFileRange(dir.DecompressedSize, func(offset int64, size int64) ([]byte, error) {
+				return source.Bundle.GetFileDataRange(index, offset, size)
+			})
+			if err != nil {
+				bundle.Errors = append(bundle.Errors, fmt.Sprintf("%s: %v", dir.Name, err))
+				continue
+			}
+			key := strings.ToLower(dir.Name)
+			if previous, ok := filesByName[key]; ok {
+				return nil, fmt.Errorf("serialized file %q appears in both %q and %q", dir.Name, graph.Bundles[previous.bundle].Name, source.Name)
+			}
+			ref := dependencyFileRef{bundle: bundleIndex, name: dir.Name, file: af}
+			filesByName[key] = ref
+			files = append(files, ref)
+			bundle.SerializedFiles = append(bundle.SerializedFiles, dir.Name)
+
+			loadNames, err := af.GetAssetBundleContainerMap()
+			if err != nil {
+				bundle.Errors = append(bundle.Errors, fmt.Sprintf("%s: read AssetBundle container: %v", dir.Name, err))
+			}
+			objects := map[int64]int{}
+			for i := range af.Metadata.AssetInfos {
+				info := &af.Metadata.AssetInfos[i]
+				objects[info.PathId] = len(graph.Objects)
+				graph.Objects = append(graph.Objects, DependencyObject{
+					Bundle:   source.Name,
+					File:     dir.Name,
+					PathID:   info.PathId,
+					ClassID:  info.TypeId,
+					Type:     classIdToName(info.TypeId),
+					Name:     af.tryReadAssetName(info),
+					LoadName: loadNames[info.PathId],
+				})
+			}
+			bundle.objectsByFile[key] = objects
+		}
+	}
+
+	// 第二遍解码对象并解析每个非空 PPtr
+	// The second pass decodes objects and resolves every non-null PPtr
+	for _, ref := range files {
+		bundle := &graph.Bundles[ref.bundle]
+		af := ref.file
+		externals := make([]DependencyExternal, len(af.Metadata.ExternalFiles))
+		for i, external := range af.Metadata.ExternalFiles {
+			externals[i] = resolveDependencyExternal(external, filesByName, graph.Bundles)
+			bundle.addDependency(externals[i])
+		}
+		objects := bundle.objectsByFile[strings.ToLower(ref.name)]
+		for i := range af.Metadata.AssetInfos {
+			info := &af.Metadata.AssetInfos[i]
+			if !af.AssetHasTypeTree(info) {
+				continue
+			}
+			root, _, _, err := af.readAssetValuePrefix(info)
+			if err != nil {
+				bundle.Errors = append(bundle.Errors, fmt.Sprintf("%s PathID %d: %v", ref.name, info.PathId, err))
+				continue
+			}
+			object := &graph.Objects[objects[info.PathId]]
+			if root.TypeName != "" {
+				object.Type = root.TypeName
+			}
+			if name, ok := root.Field("m_Name").String(); ok && object.Name == "" {
+				object.Name = name
+			}
+			walkPPtrFields(root, "", func(field string, fileID int32, pathID int64) {
+				edge := DependencyEdge{
+					FromBundle: bundle.Name,
+					FromFile:   ref.name,
+					FromPathID: info.PathId,
+					Field:      field,
+					FileID:     fileID,
+					ToPathID:   pathID,
+				}
+				var target dependencyFileRef
+				var found bool
+				switch {
+				case fileID == 0:
+					target, found = ref, true
+				case fileID < 0 || int(fileID) > len(externals):
+					edge.Status = DependencyInvalidFileID
+				default:
+					external := externals[fileID-1]
+					edge.ToFile = external.File
+					switch {
+					case external.Builtin:
+						edge.Status = DependencyBuiltin
+					case external.Missing:
+						edge.Status = DependencyMissingFile
+					default:
+						target, found = filesByName[strings.ToLower(external.File)]
+					}
+				}
+				if found {
+					edge.ToFile = target.name
+					targetBundle := &graph.Bundles[target.bundle]
+					if index, ok := targetBundle.objectsByFile[strings.ToLower(target.name)][pathID]; ok {
+						edge.Status = DependencyResolved
+						edge.ToBundle = targetBundle.Name
+						graph.Objects[index].RefCount++
+					} else {
+						edge.Status = DependencyMissingObject
+					}
+				}
+				graph.Edges = append(graph.Edges, edge)
+				if edge.Status.Dangling() {
+					graph.Dangling = append(graph.Dangling, edge)
+				}
+			})
+		}
+	}
+
+	for _, object := range graph.Objects {
+		if object.RefCount == 0 && object.ClassID != ClassIDAssetBundle {
+			graph.Unreferenced = append(graph.Unreferenced, object)
+		}
+	}
+	return graph, nil
+}
+
+// resolveDependencyExternal 将外部文件表项映射到输入包或内置资源
+// resolveDependencyExternal maps an external-file table entry to an input bundle or a built-in resource
+func resolveDependencyExternal(external ExternalFile, filesByName map[string]dependencyFileRef, bundles []DependencyBundle) DependencyExternal {
+	name := normalizeStreamDataPath(external.PathName)
+	result := DependencyExternal{File: name, PathName: external.PathName}
+	if ref, ok := filesByName[strings.ToLower(name)]; ok {
+		result.File = ref.name
+		result.Bundle = bundles[ref.bundle].Name
+		return result
+	}
+	if unityBuiltinResourceFiles[strings.ToLower(path.Base(name))] {
+		result.Builtin = true
+		return result
+	}
+	result.Missing = true
+	return result
+}
+
+// addDependency 记录包对其他包或外部文件的依赖，忽略包内文件并按文件名去重
+// addDependency records a bundle's dependency on another bundle or external file, skipping its own files and deduplicating by file name
+func (b *DependencyBundle) addDependency(external DependencyExternal) {
+	if external.Bundle == b.Name {
+		return
+	}
+	for _, existing := range b.Dependencies {
+		if strings.EqualFold(existing.File, external.File) {
+			return
+		}
+	}
+	b.Dependencies = append(b.Dependencies, external)
+}
+
+// walkPPtrFields 按先序遍历值树并对每个非空 PPtr 调用 visit，field 是以数组下标表示元素的字段路径
+// walkPPtrFields walks the value tree in preorder and calls visit for every non-null PPtr, with field being the field path using array indices for elements
+func walkPPtrFields(value *TypeTreeValue, field string, visit func(field string, fileID int32, pathID int64)) {
+	if value == nil {
+		return
+	}
+	if isPPtrTypeTreeValue(value) {
+		fileID, _ := value.Field("m_FileID").Int64()
+		pathID, _ := value.Field("m_PathID").Int64()
+		if pathID != 0 {
+			visit(field, int32(fileID), pathID)
+		}
+		return
+	}
+	for _, child := range value.Children {
+		childField := child.Name
+		if childField == "Array" {
+			childField = field
+		} else if strings.HasPrefix(childField, "data[") {
+			childField = field + strings.TrimPrefix(childField, "data")
+		} else if field != "" {
+			childField = field + "." + childField
+		}
+		walkPPtrFields(child, childField, visit)
+	}
+}
+
+// WriteDOT 将依赖图写为 Graphviz DOT：每个包是一个子图，悬空引用指向红色虚线占位节点，未被引用的对象以灰色填充
+// WriteDOT writes the dependency graph as Graphviz DOT: each bundle is a subgraph, dangling references point at red dashed placeholder nodes, and unreferenced objects are filled gray
+func (g *DependencyGraph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph dependencies {\n")
+	sb.WriteString("  rankdir=LR;\n  node [shape=box, fontsize=10];\n  edge [fontsize=9];\n")
+
+	unreferenced := map[string]bool{}
+	for _, object := range g.Unreferenced {
+		unreferenced[dependencyNodeID(object.File, object.PathID)] = true
+	}
+	for bundleIndex, bundle := range g.Bundles {
+		fmt.Fprintf(&sb, "  subgraph cluster_%d {\n    label=%s;\n", bundleIndex, strconv.Quote(bundle.Name))
+		for _, object := range g.Objects {
+			if object.Bundle != bundle.Name {
+				continue
+			}
+			label := object.Type + "\n" + strconv.FormatInt(object.PathID, 10)
+			if object.LoadName != "" {
+				label = object.Type + "\n" + object.LoadName + "\n" + strconv.FormatInt(object.PathID, 10)
+			} else if object.Name != "" {
+				label = object.Type + "\n" + object.Name + "\n" + strconv.FormatInt(object.PathID, 10)
+			}
+			style := ""
+			if unreferenced[dependencyNodeID(object.File, object.PathID)] {
+				style = ", style=filled, fillcolor=lightgray"
+			}
+			fmt.Fprintf(&sb, "    %s [label=%s%s];\n", strconv.Quote(dependencyNodeID(object.File, object.PathID)), strconv.Quote(label), style)
+		}
+		sb.WriteString("  }\n")
+	}
+
+	placeholders := map[string]bool{}
+	for _, edge := range g.Edges {
+		from := dependencyNodeID(edge.FromFile, edge.FromPathID)
+		toFile := edge.ToFile
+		if toFile == "" {
+			toFile = fmt.Sprintf("FileID %d", edge.FileID)
+		}
+		to := dependencyNodeID(toFile, edge.ToPathID)
+		attributes := "label=" + strconv.Quote(edge.Field)
+		if edge.Status != DependencyResolved {
+			if !placeholders[to] {
+				placeholders[to] = true
+				nodeStyle := "style=dashed, color=red, fontcolor=red"
+				if edge.Status == DependencyBuiltin {
+					nodeStyle = "style=dotted"
+				}
+				fmt.Fprintf(&sb, "  %s [label=%s, %s];\n", strconv.Quote(to), strconv.Quote(string(edge.Status)+"\n"+toFile+"\n"+strconv.FormatInt(edge.ToPathID, 10)), nodeStyle)
+			}
+			if edge.Status.Dangling() {
+				attributes += ", color=red, fontcolor=red"
+			}
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [%s];\n", strconv.Quote(from), strconv.Quote(to), attributes)
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// dependencyNodeID 返回对象在 DOT 中的节点 ID / dependencyNodeID returns the DOT node ID of an object
+func dependencyNodeID(file string, pathID int64) string {
+	return file + ":" + strconv.FormatInt(pathID, 10)
+}
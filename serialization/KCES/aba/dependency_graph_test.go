@@ -0,0 +1,161 @@
+package aba
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// buildDependencyTestAba 生成包含 TextAsset 的单文件测试包，remap 非 nil 时改写 AssetBundle 对象中的 PPtr
+// buildDependencyTestAba builds a single-file test bundle of TextAssets, rewriting the PPtrs of the AssetBundle object when remap is non-nil
+func buildDependencyTestAba(t *testing.T, cab string, names []string, externals []ExternalFile, remap func(pathIDs []int64) PPtrRemapFunc) (*Aba, []int64) {
+	t.Helper()
+	writer := NewSerializedFileWriter("2022.3.35f1")
+	writer.SetExternalFiles(externals)
+	pathIDs := make([]int64, len(names))
+	for i, name := range names {
+		pathIDs[i] = writer.AddTextAsset(name, []byte(name))
+	}
+	var serialized bytes.Buffer
+	if err := writer.Write(&serialized); err != nil {
+		t.Fatalf("SerializedFileWriter.Write: %v", err)
+	}
+	var out bytes.Buffer
+	if err := WriteAba(&out, []AbaFileEntry{{Name: cab, Data: serialized.Bytes(), IsSerialized: true}}, nil); err != nil {
+		t.Fatalf("WriteAba: %v", err)
+	}
+	bundle, err := ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba: %v", err)
+	}
+	if remap == nil {
+		return bundle, pathIDs
+	}
+
+	af, err := ReadAssetsFile(serialized.Bytes())
+	if err != nil {
+		t.Fatalf("ReadAssetsFile: %v", err)
+	}
+	bundles := af.GetAssetsByType(ClassIDAssetBundle)
+	if len(bundles) != 1 {
+		t.Fatalf("got %d AssetBundle objects, want 1", len(bundles))
+	}
+	root, err := af.ReadAssetValue(&bundles[0])
+	if err != nil {
+		t.Fatalf("ReadAssetValue: %v", err)
+	}
+	if _, err := RewritePPtrReferences(root, remap(pathIDs)); err != nil {
+		t.Fatalf("RewritePPtrReferences: %v", err)
+	}
+	data, err := af.EncodeAssetValue(&bundles[0], root)
+	if err != nil {
+		t.Fatalf("EncodeAssetValue: %v", err)
+	}
+	out.Reset()
+	if _, err := ReplaceAbaObject(&out, bundle, &AbaObjectReplacement{PathID: bundles[0].PathId, Data: data}, nil); err != nil {
+		t.Fatalf("ReplaceAbaObject: %v", err)
+	}
+	bundle, err = ReadAba(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAba patched: %v", err)
+	}
+	return bundle, pathIDs
+}
+
+func TestBuildDependencyGraphResolvesAcrossBundles(t *testing.T) {
+	shared, sharedIDs := buildDependencyTestAba(t, "CAB-shared", []string{"shared.menuassets"}, nil, nil)
+	externals := []ExternalFile{
+		{PathName: "archive:/CAB-shared/CAB-shared"},
+		{PathName: "Resources/unity_builtin_extra"},
+	}
+	mod, modIDs := buildDependencyTestAba(t, "CAB-mod", []string{"cross.menuassets", "builtin.menuassets", "missing.menuassets"}, externals,
+		func(pathIDs []int64) PPtrRemapFunc {
+			return func(fileID int32, pathID int64) (int32, int64, error) {
+				switch pathID {
+				case pathIDs[0]:
+					return 1, sharedIDs[0], nil
+				case pathIDs[1]:
+					return 2, 10753, nil
+				case pathIDs[2]:
+					return 1, 424242, nil
+				}
+				return fileID, pathID, nil
+			}
+		})
+
+	graph, err := BuildDependencyGraph([]DependencyGraphSource{{Name: "mod.aba", Bundle: mod}, {Name: "shared.aba", Bundle: shared}})
+	if err != nil {
+		t.Fatalf("BuildDependencyGraph: %v", err)
+	}
+	statuses := map[DependencyEdgeStatus]int{}
+	for _, edge := range graph.Edges {
+		if edge.FromFile == "CAB-mod" && edge.FileID != 0 {
+			statuses[edge.Status]++
+			if edge.Status == DependencyResolved && (edge.ToBundle != "shared.aba" || edge.ToPathID != sharedIDs[0]) {
+				t.Errorf("cross-bundle edge = %+v", edge)
+			}
+			if !strings.HasPrefix(edge.Field, "m_PreloadTable[") && !strings.HasPrefix(edge.Field, "m_Container[") {
+				t.Errorf("edge field = %q, want an indexed AssetBundle field", edge.Field)
+			}
+		}
+	}
+	if statuses[DependencyResolved] == 0 || statuses[DependencyBuiltin] == 0 || statuses[DependencyMissingObject] == 0 {
+		t.Fatalf("edge statuses = %v, want resolved, builtin, and missingObject edges", statuses)
+	}
+	for _, edge := range graph.Dangling {
+		if edge.Status != DependencyMissingObject || edge.ToPathID != 424242 {
+			t.Errorf("unexpected dangling edge %+v", edge)
+		}
+	}
+	unreferenced := map[string]bool{}
+	for _, object := range graph.Unreferenced {
+		unreferenced[dependencyNodeID(object.File, object.PathID)] = true
+	}
+	for _, pathID := range modIDs {
+		if !unreferenced[dependencyNodeID("CAB-mod", pathID)] {
+			t.Errorf("PathID %d should be unreferenced after its references were redirected", pathID)
+		}
+	}
+	if unreferenced[dependencyNodeID("CAB-shared", sharedIDs[0])] {
+		t.Error("shared object is referenced but reported as unreferenced")
+	}
+	deps := graph.Bundles[0].Dependencies
+	if len(deps) != 2 || deps[0].File != "CAB-shared" || deps[0].Bundle != "shared.aba" || !deps[1].Builtin {
+		t.Errorf("mod dependencies = %+v", deps)
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+	for _, want := range []string{"digraph dependencies {", "cluster_1", `"CAB-shared:`, "missingObject", "color=red"} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output lacks %q", want)
+		}
+	}
+}
+
+func TestBuildDependencyGraphReportsMissingBundles(t *testing.T) {
+	externals := []ExternalFile{{PathName: "archive:/CAB-absent/CAB-absent"}}
+	mod, _ := buildDependencyTestAba(t, "CAB-mod", []string{"cross.menuassets"}, externals,
+		func(pathIDs []int64) PPtrRemapFunc {
+			return func(fileID int32, pathID int64) (int32, int64, error) {
+				return 1, pathID, nil
+			}
+		})
+	graph, err := BuildDependencyGraph([]DependencyGraphSource{{Name: "mod.aba", Bundle: mod}})
+	if err != nil {
+		t.Fatalf("BuildDependencyGraph: %v", err)
+	}
+	if len(graph.Dangling) == 0 {
+		t.Fatal("expected dangling references to the absent bundle")
+	}
+	for _, edge := range graph.Dangling {
+		if edge.Status != DependencyMissingFile || edge.ToFile != "CAB-absent" {
+			t.Errorf("dangling edge = %+v, want missingFile CAB-absent", edge)
+		}
+	}
+	if deps := graph.Bundles[0].Dependencies; len(deps) != 1 || !deps[0].Missing {
+		t.Errorf("dependencies = %+v, want one missing CAB", deps)
+	}
+}
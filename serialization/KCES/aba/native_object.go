@@ -150,6 +150,12 @@ func EncodeNativeUnityObject(object *NativeUnityObject) ([]byte, error) {
 	return out.Bytes(), nil
 }
 
+// IsNativeUnityObjectData 判断数据是否以独立 Unity 对象文件的魔数开头
+// IsNativeUnityObjectData reports whether data starts with the standalone Unity object file magic
+func IsNativeUnityObjectData(data []byte) bool {
+	return len(data) >= len(nativeUnityObjectMagic) && bytes.Equal(data[:len(nativeUnityObjectMagic)], nativeUnityObjectMagic[:])
+}
+
 // ReadNativeUnityObjectHeader 读取并校验独立 Unity 对象头与 TypeTree，读取结束后 reader 位于正文开头
 // ReadNativeUnityObjectHeader reads and validates a standalone Unity object header and TypeTree and leaves the reader at the payload start
 func ReadNativeUnityObjectHeader(in io.Reader, fileSize int64) (*NativeUnityObjectHeader, error) {
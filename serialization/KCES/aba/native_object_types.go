@@ -1,6 +1,10 @@
 package aba
 
-import "fmt"
+import (
+	"fmt"
+	"image"
+	"math"
+)
 
 // ReadMMesh 读取 ClassID 43 Mesh 独立对象文件
 // ReadMMesh reads a standalone ClassID 43 Mesh object file
@@ -109,6 +113,79 @@ func NewNativeTexture2DObject(name string, width int64, height int64, rgba []byt
 	return &NativeUnityObject{ClassID: ClassIDTexture2D, BigEndian: false, TypeTree: tree, Data: data}, nil
 }
 
+// NewNativeTexture2DObjectWithOptions 从自上而下的图像按选项构建独立 Texture2D 对象，opts.StreamPath 非空时图像数据不内联，而是作为第二个返回值供调用方写入 .resS 伴随文件
+// NewNativeTexture2DObjectWithOptions builds a standalone Texture2D object from a top-down image according to the options; when opts.StreamPath is non-empty the image data is not inlined and is instead returned as the second result for the caller to write into a .resS companion
+func NewNativeTexture2DObjectWithOptions(name string, img image.Image, opts Texture2DEncodeOptions) (*NativeUnityObject, []byte, error) {
+	encoded, err := EncodeTexture2DImage(img, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload := texture2DPayload{
+		format:     encoded.TextureFormat,
+		mipCount:   encoded.MipCount,
+		colorSpace: encoded.ColorSpace,
+		imageData:  encoded.ImageData,
+	}
+	var streamData []byte
+	if opts.StreamPath != "" {
+		payload.imageData = nil
+		payload.stream = StreamingInfo{Size: uint64(len(encoded.ImageData)), Path: opts.StreamPath}
+		streamData = encoded.ImageData
+	}
+	data, err := encodeTexture2DPayload("2022.3.35f1", name, encoded.Width, encoded.Height, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	tree := unity2022Texture2DTypeTree()
+	return &NativeUnityObject{ClassID: ClassIDTexture2D, BigEndian: false, TypeTree: tree, Data: data}, streamData, nil
+}
+
+// Texture2DStreamData 返回独立 Texture2D 的 m_StreamData / Texture2DStreamData returns the m_StreamData of a standalone Texture2D
+func (object *NativeUnityObject) Texture2DStreamData() (StreamingInfo, error) {
+	if object == nil || object.ClassID != ClassIDTexture2D {
+		return StreamingInfo{}, fmt.Errorf("native Unity object is not a Texture2D")
+	}
+	root, err := object.DecodeValue()
+	if err != nil {
+		return StreamingInfo{}, err
+	}
+	return readStreamingInfo(root.Field("m_StreamData"))
+}
+
+// SetTexture2DStreamData 改写独立 Texture2D 的 m_StreamData，打包时用于把伴随 .resS 的范围重定位到 AssetBundle 内的流条目
+// SetTexture2DStreamData rewrites the m_StreamData of a standalone Texture2D, which packing uses to relocate a companion .resS range into the AssetBundle stream entry
+func (object *NativeUnityObject) SetTexture2DStreamData(info StreamingInfo) error {
+	if object == nil || object.ClassID != ClassIDTexture2D {
+		return fmt.Errorf("native Unity object is not a Texture2D")
+	}
+	if info.Offset < 0 || info.Size > math.MaxUint32 {
+		return fmt.Errorf("Texture2D stream range offset %d size %d is outside the wire range", info.Offset, info.Size)
+	}
+	root, err := object.DecodeValue()
+	if err != nil {
+		return err
+	}
+	stream := root.Field("m_StreamData")
+	if stream == nil {
+		return fmt.Errorf("Texture2D has no m_StreamData field")
+	}
+	if offset := firstTypeTreeField(stream, "offset", "m_Offset"); offset != nil {
+		offset.Value = uint64(info.Offset)
+	}
+	if size := firstTypeTreeField(stream, "size", "m_Size"); size != nil {
+		size.Value = info.Size
+	}
+	if streamPath := firstTypeTreeField(stream, "path", "m_Source"); streamPath != nil {
+		streamPath.Value = info.Path
+	}
+	data, err := object.EncodeValue(root)
+	if err != nil {
+		return fmt.Errorf("encode Texture2D stream data: %w", err)
+	}
+	object.Data = data
+	return nil
+}
+
 // readNativeUnityObjectClass 读取独立对象并验证精确 ClassID
 // readNativeUnityObjectClass reads a standalone object and validates its exact ClassID
 func readNativeUnityObjectClass(data []byte, classID int32, typeName string) (*NativeUnityObject, error) {
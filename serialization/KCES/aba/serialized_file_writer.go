@@ -1077,24 +1077,60 @@ func encodeTextAssetData(name string, script []byte) ([]byte, error) {
 // encodeTexture2DData 按 Unity 2022.3 内置 TypeTree 编码内联 RGBA32 Texture2D
 // encodeTexture2DData encodes an inline RGBA32 Texture2D using the Unity 2022.3 built-in TypeTree
 func encodeTexture2DData(unityVersion string, name string, width, height int64, imageData []byte) ([]byte, error) {
+	return encodeTexture2DPayload(unityVersion, name, width, height, texture2DPayload{
+		format:     TextureFormatRGBA32,
+		mipCount:   1,
+		colorSpace: TextureColorSpaceSRGB,
+		imageData:  imageData,
+	})
+}
+
+// texture2DPayload 描述 Texture2D 正文中随编码选项变化的格式、mip、色彩空间和图像数据位置
+// texture2DPayload describes the format, mip, color-space, and image-data placement fields of a Texture2D payload that vary with encoding options
+type texture2DPayload struct {
+	format     int32         // Unity TextureFormat 枚举值 / Unity TextureFormat enum value
+	mipCount   int32         // mip 层数 / Mip level count
+	colorSpace int32         // m_ColorSpace 枚举值 / m_ColorSpace enum value
+	imageData  []byte        // 自下而上的内联图像数据，流式输出时为空 / Bottom-up inline image data, empty when streamed
+	stream     StreamingInfo // 外部流式数据引用，Size 为 0 表示内联 / External stream reference, where a zero Size means inline data
+}
+
+// encodeTexture2DPayload 按 Unity 2022.3 内置 TypeTree 编码任意受支持格式、mip 层数和数据位置的 Texture2D
+// encodeTexture2DPayload encodes a Texture2D with any supported format, mip count, and data placement using the Unity 2022.3 built-in TypeTree
+func encodeTexture2DPayload(unityVersion string, name string, width, height int64, payload texture2DPayload) ([]byte, error) {
 	if width <= 0 || height <= 0 {
 		return nil, fmt.Errorf("invalid Texture2D dimensions %dx%d", width, height)
 	}
 	if uint64(width) > uint64(math.MaxInt32) || uint64(height) > uint64(math.MaxInt32) {
 		return nil, fmt.Errorf("Texture2D dimensions %dx%d exceed Int32 wire range", width, height)
 	}
-	const bytesPerPixel = uint64(4)
-	if uint64(width) > uint64(math.MaxInt32)/bytesPerPixel/uint64(height) {
-		return nil, fmt.Errorf("Texture2D dimensions %dx%d exceed inline RGBA32 size limit", width, height)
-	}
-	expectedSize := uint64(width) * uint64(height) * bytesPerPixel
-	if uint64(len(imageData)) != expectedSize {
-		return nil, fmt.Errorf("Texture2D RGBA32 data size %d does not match %dx%d (%d bytes)", len(imageData), width, height, expectedSize)
+	if payload.mipCount < 1 || payload.mipCount > Texture2DMaxMipCount(width, height) {
+		return nil, fmt.Errorf("Texture2D mip count %d is invalid for %dx%d", payload.mipCount, width, height)
 	}
-	imageDataLength, err := int32WireLength("Texture2D image data length", uint64(len(imageData)))
+	expectedSize, err := Texture2DImageSize(payload.format, width, height, payload.mipCount)
 	if err != nil {
 		return nil, err
 	}
+	if expectedSize > int64(math.MaxInt32) {
+		return nil, fmt.Errorf("Texture2D dimensions %dx%d exceed %s size limit", width, height, textureFormatName(payload.format))
+	}
+	streamed := payload.stream.Size > 0
+	if streamed {
+		if len(payload.imageData) != 0 {
+			return nil, fmt.Errorf("Texture2D has both inline image data and external stream data")
+		}
+		if payload.stream.Size != uint64(expectedSize) {
+			return nil, fmt.Errorf("Texture2D %s stream size %d does not match %dx%d with %d mips (%d bytes)", textureFormatName(payload.format), payload.stream.Size, width, height, payload.mipCount, expectedSize)
+		}
+		if payload.stream.Offset < 0 || payload.stream.Path == "" {
+			return nil, fmt.Errorf("Texture2D stream data needs a non-negative offset and a path")
+		}
+	} else if int64(len(payload.imageData)) != expectedSize {
+		return nil, fmt.Errorf("Texture2D %s data size %d does not match %dx%d with %d mips (%d bytes)", textureFormatName(payload.format), len(payload.imageData), width, height, payload.mipCount, expectedSize)
+	}
+	completeImageSize := int32(expectedSize)
+	imageDataLength := int32(len(payload.imageData))
+	imageData := payload.imageData
 	if err := validateSerializedFileUnityVersion(unityVersion); err != nil {
 		return nil, err
 	}
@@ -1108,8 +1144,8 @@ func encodeTexture2DData(unityVersion string, name string, width, height int64,
 		return nil, fmt.Errorf("write Texture2D m_Name: %w", err)
 	}
 
-	// m_ForcedFallbackFormat 写为 RGBA32 枚举值
-	// m_ForcedFallbackFormat is the RGBA32 enum value
+	// m_ForcedFallbackFormat 与 Unity 导入器一致，总是写为 RGBA32 枚举值
+	// m_ForcedFallbackFormat is always the RGBA32 enum value, matching the Unity importer
 	if err := bw.WriteUInt32(uint32(TextureFormatRGBA32)); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_ForcedFallbackFormat: %w", err)
 	}
@@ -1136,9 +1172,9 @@ func encodeTexture2DData(unityVersion string, name string, width, height int64,
 		return nil, fmt.Errorf("write Texture2D m_Height: %w", err)
 	}
 
-	// m_CompleteImageSize 保存内联 RGBA32 数据长度
-	// m_CompleteImageSize stores inline RGBA32 data length
-	if err := bw.WriteInt32(imageDataLength); err != nil {
+	// m_CompleteImageSize 保存全部 mip 的图像数据长度，流式输出时与 m_StreamData.size 相同
+	// m_CompleteImageSize stores the image data length of all mips, equal to m_StreamData.size when streamed
+	if err := bw.WriteInt32(completeImageSize); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_CompleteImageSize: %w", err)
 	}
 
@@ -1148,15 +1184,15 @@ func encodeTexture2DData(unityVersion string, name string, width, height int64,
 		return nil, fmt.Errorf("write Texture2D m_MipsStripped: %w", err)
 	}
 
-	// m_TextureFormat 写为 4，即 RGBA32
-	// m_TextureFormat is 4 for RGBA32
-	if err := bw.WriteInt32(4); err != nil {
+	// m_TextureFormat 保存 Unity TextureFormat 枚举值
+	// m_TextureFormat stores the Unity TextureFormat enum value
+	if err := bw.WriteInt32(payload.format); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_TextureFormat: %w", err)
 	}
 
-	// m_MipCount 写为单层
-	// m_MipCount is one
-	if err := bw.WriteInt32(1); err != nil {
+	// m_MipCount 保存包含基础层在内的 mip 层数
+	// m_MipCount stores the mip level count including the base level
+	if err := bw.WriteInt32(payload.mipCount); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_MipCount: %w", err)
 	}
 
@@ -1233,9 +1269,9 @@ func encodeTexture2DData(unityVersion string, name string, width, height int64,
 	if err := bw.WriteInt32(0); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_LightmapFormat: %w", err)
 	}
-	// m_ColorSpace 写为 Linear 枚举值 1
-	// m_ColorSpace is the Linear enum value 1
-	if err := bw.WriteInt32(1); err != nil {
+	// m_ColorSpace 为 1 表示按 sRGB 采样，为 0 表示线性数据
+	// m_ColorSpace is 1 for sRGB sampling and 0 for linear data
+	if err := bw.WriteInt32(payload.colorSpace); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_ColorSpace: %w", err)
 	}
 
@@ -1248,8 +1284,8 @@ func encodeTexture2DData(unityVersion string, name string, width, height int64,
 		return nil, fmt.Errorf("align Texture2D m_PlatformBlob: %w", err)
 	}
 
-	// image data 保存长度、内联 RGBA32 字节和四字节对齐填充
-	// image data stores length, inline RGBA32 bytes, and four-byte alignment padding
+	// image data 保存长度、内联图像字节和四字节对齐填充，流式输出时为空
+	// image data stores length, inline image bytes, and four-byte alignment padding, and is empty when streamed
 	if err := bw.WriteInt32(imageDataLength); err != nil {
 		return nil, fmt.Errorf("write Texture2D image data length: %w", err)
 	}
@@ -1260,15 +1296,15 @@ func encodeTexture2DData(unityVersion string, name string, width, height int64,
 		return nil, fmt.Errorf("align Texture2D image data: %w", err)
 	}
 
-	// m_StreamData 的 offset、size 和 path 均写零值，因为图像数据已内联
-	// m_StreamData offset, size, and path are zero values because image data is inline
-	if err := bw.WriteUInt64(0); err != nil {
+	// m_StreamData 的 offset、size 和 path 在内联时均为零值，流式输出时指向 .resS 中的范围
+	// m_StreamData offset, size, and path are zero values for inline data and point at a .resS range when streamed
+	if err := bw.WriteUInt64(uint64(payload.stream.Offset)); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_StreamData offset: %w", err)
 	}
-	if err := bw.WriteUInt32(0); err != nil {
+	if err := bw.WriteUInt32(uint32(payload.stream.Size)); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_StreamData size: %w", err)
 	}
-	if err := bw.WriteAlignedString(""); err != nil {
+	if err := bw.WriteAlignedString(payload.stream.Path); err != nil {
 		return nil, fmt.Errorf("write Texture2D m_StreamData path: %w", err)
 	}
 
@@ -1,16 +1,15 @@
 package aba
 
 import (
-	"bytes"
 	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
 	"io"
 	"math"
 	"os"
-	"os/exec"
 	"path"
 	"strings"
-
-	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
 
 // AssetResolver 解析 Unity PPtr 引用
@@ -335,9 +334,7 @@ func resolveTexture2D(relativeTo *AssetsFile, pptr unityPPtr, resolver AssetReso
 }
 
 // WriteSpritePNG 将 Sprite 裁剪结果导出为 PNG
-// 贴图解压和最终裁剪、翻转、旋转交给 ImageMagick 处理
 // WriteSpritePNG exports a Sprite crop as PNG
-// Texture decompression and final crop, flip, and rotation are delegated to ImageMagick
 func WriteSpritePNG(sprite *SpriteExport, outPath string) error {
 	if sprite == nil {
 		return fmt.Errorf("nil sprite")
@@ -345,9 +342,6 @@ func WriteSpritePNG(sprite *SpriteExport, outPath string) error {
 	if sprite.Texture == nil {
 		return fmt.Errorf("sprite has no texture")
 	}
-	if err := tools.CheckMagick(); err != nil {
-		return err
-	}
 	f, err := os.Create(outPath)
 	if err != nil {
 		return fmt.Errorf("create sprite PNG %q: %w", outPath, err)
@@ -363,8 +357,8 @@ func WriteSpritePNG(sprite *SpriteExport, outPath string) error {
 	return nil
 }
 
-// WriteSpritePNGTo 将裁剪后的 Sprite PNG 写入已打开的目标，ImageMagick 输出到 stdout 以便调用方保留 os.Root 文件系统约束
-// WriteSpritePNGTo writes a cropped Sprite PNG to an open destination; ImageMagick emits to stdout so callers can retain filesystem confinement with an os.Root-backed handle
+// WriteSpritePNGTo 将裁剪后的 Sprite PNG 写入已打开的目标，调用方可直接传入受 os.Root 管理的文件
+// WriteSpritePNGTo writes a cropped Sprite PNG to an open destination, so callers can pass os.Root-backed files directly
 func WriteSpritePNGTo(sprite *SpriteExport, out io.Writer) error {
 	if sprite == nil {
 		return fmt.Errorf("nil sprite")
@@ -375,44 +369,27 @@ func WriteSpritePNGTo(sprite *SpriteExport, out io.Writer) error {
 	if out == nil {
 		return fmt.Errorf("nil sprite PNG writer")
 	}
-	if err := tools.CheckMagick(); err != nil {
-		return err
-	}
-	inputFormat, inputData, err := textureInputForMagick(sprite.Texture)
+	// DecodeTexture2DImage 已把自下而上的贴图翻正，spriteCropRect 才能把左下原点矩形换算成自上而下的行号
+	// DecodeTexture2DImage already flips the bottom-up texture upright, which lets spriteCropRect convert the lower-left-origin rectangle into top-down rows
+	texture, err := DecodeTexture2DImage(sprite.Texture)
 	if err != nil {
 		return err
 	}
-
-	crop := spriteCropGeometry(sprite.Texture, sprite.Rect)
-	args := []string{}
-	if isRawMagickInputFormat(inputFormat) {
-		args = append(args, "-size", fmt.Sprintf("%dx%d", sprite.Texture.Width, sprite.Texture.Height), "-depth", "8")
-	}
-	args = append(args, inputFormat+":-")
-	// Unity 贴图载荷自下而上存储，先翻正整张图，spriteCropGeometry 才能把左下原点矩形换算成自上而下的行号
-	// Unity texture payloads are stored bottom-up, so flipping the whole texture upright first lets spriteCropGeometry convert the lower-left-origin rectangle into top-down rows
-	args = append(args, "-flip")
-	if crop != "" {
-		args = append(args, "-crop", crop, "+repage")
-	}
-	args = append(args, spriteMagickOrientationArgs(sprite.SettingsRaw)...)
-	args = append(args, "png32:-")
-
-	cmd := exec.Command("magick", args...)
-	tools.SetHideWindow(cmd)
-	cmd.Stdin = bytes.NewReader(inputData)
-	cmd.Stdout = out
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("magick export sprite %q failed: %w, stderr: %s", sprite.Name, err, stderr.String())
+	crop := spriteCropRect(sprite.Texture, sprite.Rect)
+	cropped := image.NewNRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(cropped, cropped.Rect, texture, crop.Min, draw.Src)
+	if err := png.Encode(out, orientSprite(cropped, sprite.SettingsRaw)); err != nil {
+		return fmt.Errorf("encode sprite %q as PNG: %w", sprite.Name, err)
 	}
 	return nil
 }
 
-// spriteCropGeometry 将 Unity 左下原点矩形转换为 ImageMagick 左上原点裁剪参数，调用方需先把自下而上的贴图翻正
-// spriteCropGeometry converts a Unity lower-left-origin rectangle to ImageMagick upper-left-origin crop geometry and expects callers to flip the bottom-up texture upright first
-func spriteCropGeometry(tex *Texture2DData, rect SpriteRect) string {
+// spriteCropRect 将 Unity 左下原点矩形转换为左上原点的裁剪区域并限制在贴图范围内，调用方需先把自下而上的贴图翻正
+// spriteCropRect converts a Unity lower-left-origin rectangle to an upper-left-origin crop clamped to the texture and expects callers to flip the bottom-up texture upright first
+func spriteCropRect(tex *Texture2DData, rect SpriteRect) image.Rectangle {
+	if tex.Width <= 0 || tex.Height <= 0 {
+		return image.Rectangle{}
+	}
 	x := int64(math.Round(float64(rect.X)))
 	y := int64(tex.Height) - int64(math.Round(float64(rect.Y+rect.Height)))
 	w := int64(rect.Width)
@@ -423,37 +400,50 @@ func spriteCropGeometry(tex *Texture2DData, rect SpriteRect) string {
 	if h < 1 {
 		h = 1
 	}
-	if tex.Width <= 0 || tex.Height <= 0 {
-		return ""
-	}
 	x = clampInt64(x, 0, int64(tex.Width)-1)
 	y = clampInt64(y, 0, int64(tex.Height)-1)
 	w = clampInt64(w, 1, int64(tex.Width)-x)
 	h = clampInt64(h, 1, int64(tex.Height)-y)
-	if x == 0 && y == 0 && w == int64(tex.Width) && h == int64(tex.Height) {
-		return ""
-	}
-	return fmt.Sprintf("%dx%d+%d+%d", w, h, x, y)
+	return image.Rect(int(x), int(y), int(x+w), int(y+h))
 }
 
-// spriteMagickOrientationArgs 根据 SpriteSettings 的 packed 位和 packing rotation 位生成翻转或旋转参数
-// spriteMagickOrientationArgs builds flip or rotation arguments from the packed and packing-rotation bits of SpriteSettings
-func spriteMagickOrientationArgs(settingsRaw uint32) []string {
+// orientSprite 根据 SpriteSettings 的 packed 位和 packing rotation 位翻转或旋转裁剪结果
+// orientSprite flips or rotates the crop according to the packed and packing-rotation bits of SpriteSettings
+func orientSprite(img *image.NRGBA, settingsRaw uint32) *image.NRGBA {
 	if settingsRaw&1 == 0 {
-		return nil
+		return img
 	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	var out *image.NRGBA
+	var source func(x, y int) (int, int)
 	switch (settingsRaw >> 2) & 15 {
 	case 1:
-		return []string{"-flop"}
+		// 水平翻转 / Horizontal flip
+		out = image.NewNRGBA(img.Rect)
+		source = func(x, y int) (int, int) { return width - 1 - x, y }
 	case 2:
-		return []string{"-rotate", "180", "-flop"}
+		// 旋转 180 度后水平翻转，等价于垂直翻转 / A 180-degree rotation followed by a horizontal flip, equivalent to a vertical flip
+		out = image.NewNRGBA(img.Rect)
+		source = func(x, y int) (int, int) { return x, height - 1 - y }
 	case 3:
-		return []string{"-rotate", "180"}
+		// 旋转 180 度 / 180-degree rotation
+		out = image.NewNRGBA(img.Rect)
+		source = func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }
 	case 4:
-		return []string{"-rotate", "270"}
+		// 顺时针旋转 270 度 / 270-degree clockwise rotation
+		out = image.NewNRGBA(image.Rect(0, 0, height, width))
+		source = func(x, y int) (int, int) { return width - 1 - y, x }
 	default:
-		return nil
+		return img
+	}
+	bounds := out.Rect
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sx, sy := source(x, y)
+			out.SetNRGBA(x, y, img.NRGBAAt(sx, sy))
+		}
 	}
+	return out
 }
 
 // readPPtr 从 TypeTreeValue 的 m_FileID 和 m_PathID 字段读取 Unity PPtr
@@ -2,13 +2,13 @@ package aba
 
 import (
 	"bytes"
+	"image"
+	"image/color"
 	"image/png"
 	"math"
 	"os"
 	"path/filepath"
 	"testing"
-
-	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
 
 func TestReadPPtrRejectsFileIDOutsideInt32(t *testing.T) {
@@ -24,10 +24,6 @@ func TestReadPPtrRejectsFileIDOutsideInt32(t *testing.T) {
 }
 
 func TestGetSpriteExport_Sample(t *testing.T) {
-	if err := tools.CheckMagick(); err != nil {
-		t.Skipf("ImageMagick not available: %v", err)
-	}
-
 	abaFile, f := openAbaSample(t, "parts_personal002.aba")
 	defer f.Close()
 
@@ -87,9 +83,6 @@ func TestGetSpriteExport_Sample(t *testing.T) {
 }
 
 func TestWriteSpritePNGToCropsLowerLeftOriginRectFromBottomUpTexture(t *testing.T) {
-	if err := tools.CheckMagick(); err != nil {
-		t.Skipf("ImageMagick not available: %v", err)
-	}
 	green := []byte{0, 255, 0, 255}
 	red := []byte{255, 0, 0, 255}
 	// Unity stores rows bottom-up, so the first two stored rows carry the green 2x2 patch at the lower-left corner.
@@ -136,3 +129,28 @@ func TestWriteSpritePNGToCropsLowerLeftOriginRectFromBottomUpTexture(t *testing.
 		}
 	}
 }
+
+func TestOrientSpriteAppliesPackingRotation(t *testing.T) {
+	// A 2x1 image with red on the left and blue on the right.
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	img.SetNRGBA(0, 0, red)
+	img.SetNRGBA(1, 0, blue)
+
+	if got := orientSprite(img, 0<<2|0); got != img {
+		t.Fatal("unpacked sprite should not be reoriented")
+	}
+	flopped := orientSprite(img, 1<<2|1)
+	if flopped.NRGBAAt(0, 0) != blue || flopped.NRGBAAt(1, 0) != red {
+		t.Fatalf("flop got %v %v", flopped.NRGBAAt(0, 0), flopped.NRGBAAt(1, 0))
+	}
+	rotated := orientSprite(img, 4<<2|1)
+	if rotated.Rect.Dx() != 1 || rotated.Rect.Dy() != 2 {
+		t.Fatalf("rotate 270 size = %v", rotated.Rect)
+	}
+	// Rotating 270 degrees clockwise moves the right edge to the top.
+	if rotated.NRGBAAt(0, 0) != blue || rotated.NRGBAAt(0, 1) != red {
+		t.Fatalf("rotate 270 got %v %v", rotated.NRGBAAt(0, 0), rotated.NRGBAAt(0, 1))
+	}
+}
@@ -4,14 +4,15 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"math"
 	"os"
-	"os/exec"
 	"path"
 	"strings"
 
-	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 )
 
 const (
@@ -343,15 +344,12 @@ func normalizeStreamDataPath(p string) string {
 	return path.Base(strings.ReplaceAll(p, "\\", "/"))
 }
 
-// WriteTexturePNG 通过 ImageMagick 将 Unity Texture2D 载荷转换为 PNG 文件
-// WriteTexturePNG converts a Unity Texture2D payload to a PNG file through ImageMagick
+// WriteTexturePNG 将 Unity Texture2D 载荷解码为正立 PNG 文件
+// WriteTexturePNG decodes a Unity Texture2D payload into an upright PNG file
 func WriteTexturePNG(tex *Texture2DData, outPath string) error {
 	if tex == nil {
 		return fmt.Errorf("nil texture")
 	}
-	if err := tools.CheckMagick(); err != nil {
-		return err
-	}
 	f, err := os.Create(outPath)
 	if err != nil {
 		return fmt.Errorf("create texture PNG %q: %w", outPath, err)
@@ -367,8 +365,8 @@ func WriteTexturePNG(tex *Texture2DData, outPath string) error {
 	return nil
 }
 
-// WriteTexturePNGTo 转换 Unity Texture2D 载荷并写入已打开的目标，ImageMagick 输出到 stdout 以便调用方使用受 os.Root 管理的文件
-// WriteTexturePNGTo converts a Unity Texture2D payload and writes the PNG to an open destination; keeping ImageMagick on stdout lets callers use os.Root-backed files without reopening a path in the external process
+// WriteTexturePNGTo 解码 Unity Texture2D 载荷并将 PNG 写入已打开的目标，调用方可直接传入受 os.Root 管理的文件
+// WriteTexturePNGTo decodes a Unity Texture2D payload and writes the PNG to an open destination, so callers can pass os.Root-backed files directly
 func WriteTexturePNGTo(tex *Texture2DData, out io.Writer) error {
 	if tex == nil {
 		return fmt.Errorf("nil texture")
@@ -376,36 +374,18 @@ func WriteTexturePNGTo(tex *Texture2DData, out io.Writer) error {
 	if out == nil {
 		return fmt.Errorf("nil texture PNG writer")
 	}
-	if err := tools.CheckMagick(); err != nil {
-		return err
-	}
-
-	inputFormat, inputData, err := textureInputForMagick(tex)
+	img, err := DecodeTexture2DImage(tex)
 	if err != nil {
 		return err
 	}
-	args := []string{}
-	if isRawMagickInputFormat(inputFormat) {
-		args = append(args, "-size", fmt.Sprintf("%dx%d", tex.Width, tex.Height), "-depth", "8")
-	}
-	args = append(args, inputFormat+":-")
-	// Unity 贴图载荷以左下角为原点自下而上存储，而 DDS 与原始像素输入都按自上而下解释，因此导出前必须垂直翻转才能得到正立图像
-	// Unity texture payloads are stored bottom-up from the lower-left origin while DDS and raw pixel inputs are interpreted top-down, so exporting requires a vertical flip to produce an upright image
-	args = append(args, "-flip", "png32:-")
-	cmd := exec.Command("magick", args...)
-	tools.SetHideWindow(cmd)
-	cmd.Stdin = bytes.NewReader(inputData)
-	cmd.Stdout = out
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("magick convert %s texture %q failed: %w, stderr: %s", textureFormatName(tex.TextureFormat), tex.Name, err, stderr.String())
+	if err := png.Encode(out, img); err != nil {
+		return fmt.Errorf("encode %s texture %q as PNG: %w", textureFormatName(tex.TextureFormat), tex.Name, err)
 	}
 	return nil
 }
 
-// TexturePNGBytes 通过 ImageMagick 将 Unity Texture2D 载荷转换为 PNG 字节
-// TexturePNGBytes converts a Unity Texture2D payload to PNG bytes through ImageMagick
+// TexturePNGBytes 将 Unity Texture2D 载荷解码为 PNG 字节
+// TexturePNGBytes decodes a Unity Texture2D payload into PNG bytes
 func TexturePNGBytes(tex *Texture2DData) ([]byte, error) {
 	var out bytes.Buffer
 	if err := WriteTexturePNGTo(tex, &out); err != nil {
@@ -414,6 +394,159 @@ func TexturePNGBytes(tex *Texture2DData) ([]byte, error) {
 	return out.Bytes(), nil
 }
 
+// DecodeTexture2DImage 使用纯 Go 解码器将 Texture2D 第一个 mip 层级解码为正立的非预乘 RGBA 图像
+// 块压缩格式由 bcn 包解码，其中 BC6H 经色调映射输出 sRGB；Alpha8 与 R8 按灰度输出，与 BC4 一致
+// DecodeTexture2DImage decodes the first mip level of a Texture2D into an upright non-premultiplied RGBA image with pure-Go decoders
+// Block-compressed formats are decoded by the bcn package with BC6H tone-mapped to sRGB, while Alpha8 and R8 decode to grayscale like BC4
+func DecodeTexture2DImage(tex *Texture2DData) (*image.NRGBA, error) {
+	if tex == nil {
+		return nil, fmt.Errorf("nil texture")
+	}
+	if tex.Width <= 0 || tex.Height <= 0 {
+		return nil, fmt.Errorf("invalid texture dimensions %dx%d", tex.Width, tex.Height)
+	}
+	width, height := int(tex.Width), int(tex.Height)
+
+	var img *image.NRGBA
+	var err error
+	if format, ok := textureBlockFormat(tex.TextureFormat); ok {
+		img, err = bcn.Decode(format, tex.ImageData, width, height)
+	} else if bytesPerPixel := rawTextureBytesPerPixel(tex.TextureFormat); bytesPerPixel > 0 {
+		img, err = decodeRawTexturePixels(tex.TextureFormat, bytesPerPixel, tex.ImageData, width, height)
+	} else if len(tex.ImageData) >= 4 && string(tex.ImageData[:4]) == "DDS " {
+		var dds *bcn.DDS
+		if dds, err = bcn.ReadDDS(tex.ImageData); err == nil {
+			img, err = bcn.Decode(dds.Format, dds.FirstLevel(), dds.Width, dds.Height)
+		}
+	} else {
+		return nil, fmt.Errorf("unsupported Unity TextureFormat %d (%s)", tex.TextureFormat, textureFormatName(tex.TextureFormat))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode %s texture %q: %w", textureFormatName(tex.TextureFormat), tex.Name, err)
+	}
+	// Unity 贴图载荷以左下角为原点自下而上存储，解码器按自上而下解释，因此必须垂直翻转才能得到正立图像
+	// Unity texture payloads are stored bottom-up from the lower-left origin while the decoders read them top-down, so a vertical flip is required for an upright image
+	flipNRGBARows(img)
+	return img, nil
+}
+
+// TextureHalfFloatDDSBytes 将 BC6H Texture2D 解码为 R16G16B16A16_FLOAT 的正立 DDS，保留色调映射会丢失的 HDR 数值
+// TextureHalfFloatDDSBytes decodes a BC6H Texture2D into an upright R16G16B16A16_FLOAT DDS, keeping the HDR values that tone mapping would discard
+func TextureHalfFloatDDSBytes(tex *Texture2DData) ([]byte, error) {
+	if tex == nil {
+		return nil, fmt.Errorf("nil texture")
+	}
+	if tex.TextureFormat != TextureFormatBC6H {
+		return nil, fmt.Errorf("half-float export requires a BC6H texture, got %s", textureFormatName(tex.TextureFormat))
+	}
+	if tex.Width <= 0 || tex.Height <= 0 {
+		return nil, fmt.Errorf("invalid texture dimensions %dx%d", tex.Width, tex.Height)
+	}
+	width, height := int(tex.Width), int(tex.Height)
+	halves, err := bcn.DecodeHalfFloat(bcn.FormatBC6H, tex.ImageData, width, height)
+	if err != nil {
+		return nil, fmt.Errorf("decode BC6H texture %q: %w", tex.Name, err)
+	}
+
+	stride := width * 4
+	payload := make([]byte, len(halves)*2)
+	for row := 0; row < height; row++ {
+		source := halves[row*stride : (row+1)*stride]
+		destination := payload[(height-1-row)*stride*2:]
+		for i, h := range source {
+			binary.LittleEndian.PutUint16(destination[i*2:], h)
+		}
+	}
+	header := createDX10DDSHeaderForDXGI(tex.Width, tex.Height, dxgiFormatR16G16B16A16Float, 1, int64(len(payload)))
+	// 未压缩格式以行跨度代替线性大小 / Uncompressed formats declare a row pitch instead of a linear size
+	flags := binary.LittleEndian.Uint32(header[8:12])&^0x80000 | 0x8
+	binary.LittleEndian.PutUint32(header[8:12], flags)
+	binary.LittleEndian.PutUint32(header[20:24], uint32(width*8))
+	return append(header, payload...), nil
+}
+
+// textureBlockFormat 将 Unity 块压缩 TextureFormat 映射到 bcn 格式
+// textureBlockFormat maps a block-compressed Unity TextureFormat to a bcn format
+func textureBlockFormat(format int32) (bcn.Format, bool) {
+	switch format {
+	case TextureFormatDXT1:
+		return bcn.FormatDXT1, true
+	case TextureFormatDXT5:
+		return bcn.FormatDXT5, true
+	case TextureFormatBC4:
+		return bcn.FormatBC4, true
+	case TextureFormatBC5:
+		return bcn.FormatBC5, true
+	case TextureFormatBC6H:
+		return bcn.FormatBC6H, true
+	case TextureFormatBC7:
+		return bcn.FormatBC7, true
+	default:
+		return 0, false
+	}
+}
+
+// rawTextureBytesPerPixel 返回未压缩 TextureFormat 的每像素字节数，不支持的格式返回 0
+// rawTextureBytesPerPixel returns the bytes per pixel of an uncompressed TextureFormat, or 0 for unsupported formats
+func rawTextureBytesPerPixel(format int32) int {
+	switch format {
+	case TextureFormatAlpha8, TextureFormatR8:
+		return 1
+	case TextureFormatRGB24:
+		return 3
+	case TextureFormatRGBA32, TextureFormatARGB32, TextureFormatBGRA32:
+		return 4
+	case TextureFormatRGBA64:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// decodeRawTexturePixels 将未压缩像素按自上而下的行序转换为 RGBA，行序翻转由调用方处理
+// decodeRawTexturePixels converts uncompressed pixels to RGBA in top-down row order, leaving the row flip to the caller
+func decodeRawTexturePixels(format int32, bytesPerPixel int, data []byte, width, height int) (*image.NRGBA, error) {
+	expected := int64(width) * int64(height) * int64(bytesPerPixel)
+	if int64(len(data)) < expected {
+		return nil, fmt.Errorf("image data too short: got %d bytes, want %d for %dx%d", len(data), expected, width, height)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		src := data[i*bytesPerPixel : (i+1)*bytesPerPixel]
+		dst := img.Pix[i*4 : i*4+4]
+		switch format {
+		case TextureFormatAlpha8, TextureFormatR8:
+			dst[0], dst[1], dst[2], dst[3] = src[0], src[0], src[0], 255
+		case TextureFormatRGB24:
+			dst[0], dst[1], dst[2], dst[3] = src[0], src[1], src[2], 255
+		case TextureFormatRGBA32:
+			copy(dst, src)
+		case TextureFormatARGB32:
+			dst[0], dst[1], dst[2], dst[3] = src[1], src[2], src[3], src[0]
+		case TextureFormatBGRA32:
+			dst[0], dst[1], dst[2], dst[3] = src[2], src[1], src[0], src[3]
+		case TextureFormatRGBA64:
+			// 每通道 16 位小端序，保留高字节 / 16-bit little-endian channels keep their high byte
+			dst[0], dst[1], dst[2], dst[3] = src[1], src[3], src[5], src[7]
+		}
+	}
+	return img, nil
+}
+
+// flipNRGBARows 原地垂直翻转图像行 / flipNRGBARows vertically flips image rows in place
+func flipNRGBARows(img *image.NRGBA) {
+	height := img.Rect.Dy()
+	rowBytes := img.Rect.Dx() * 4
+	tmp := make([]byte, rowBytes)
+	for top, bottom := 0, height-1; top < bottom; top, bottom = top+1, bottom-1 {
+		a := img.Pix[top*img.Stride : top*img.Stride+rowBytes]
+		b := img.Pix[bottom*img.Stride : bottom*img.Stride+rowBytes]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+}
+
 // textureInputForMagick 根据 Unity TextureFormat 选择 ImageMagick 输入格式和字节
 // textureInputForMagick selects the ImageMagick input format and bytes for a Unity TextureFormat
 func textureInputForMagick(tex *Texture2DData) (string, []byte, error) {
@@ -438,17 +571,6 @@ func textureInputForMagick(tex *Texture2DData) (string, []byte, error) {
 	}
 }
 
-// isRawMagickInputFormat 判断 ImageMagick 输入是否为需要尺寸参数的原始像素格式
-// isRawMagickInputFormat reports whether an ImageMagick input is a raw pixel format requiring dimensions
-func isRawMagickInputFormat(format string) bool {
-	switch format {
-	case "rgb", "rgba", "bgra", "gray":
-		return true
-	default:
-		return false
-	}
-}
-
 // argbToRGBA 将每个像素的 ARGB 通道顺序转换为 RGBA
 // argbToRGBA converts each pixel from ARGB channel order to RGBA
 func argbToRGBA(data []byte) []byte {
@@ -542,13 +664,20 @@ func createLegacyDDSHeader(width, height int32, format int32, mipCount int32, da
 // createDX10DDSHeader 创建包含 DX10 扩展头的 DDS 头
 // createDX10DDSHeader creates a DDS header with a DX10 extension header
 func createDX10DDSHeader(width, height int32, format int32, mipCount int32, dataLen int64) []byte {
+	return createDX10DDSHeaderForDXGI(width, height, dxgiFormat(format), mipCount, dataLen)
+}
+
+// createDX10DDSHeaderForDXGI 创建声明指定 DXGI 格式的 DX10 DDS 头
+// createDX10DDSHeaderForDXGI creates a DX10 DDS header declaring the given DXGI format
+func createDX10DDSHeaderForDXGI(width, height int32, dxgi uint32, mipCount int32, dataLen int64) []byte {
 	if mipCount <= 0 {
 		mipCount = 1
 	}
-	buf := createLegacyDDSHeader(width, height, format, mipCount, dataLen)
+	// 传统头部分的 FourCC 对非 DXT 格式统一写为 DX10 / The legacy part writes the DX10 FourCC for every non-DXT format
+	buf := createLegacyDDSHeader(width, height, 0, mipCount, dataLen)
 	le := binary.LittleEndian
 	dx10 := make([]byte, 20)
-	le.PutUint32(dx10[0:4], dxgiFormat(format))
+	le.PutUint32(dx10[0:4], dxgi)
 	// DX10 扩展头声明 D3D10 资源维度为 Texture2D
 	// The DX10 extension header declares the D3D10 resource dimension as Texture2D
 	le.PutUint32(dx10[4:8], 3)
@@ -569,6 +698,10 @@ func requiresDX10DDS(format int32) bool {
 	}
 }
 
+// dxgiFormatR16G16B16A16Float 是半精度浮点 RGBA 的 DXGI 格式编号
+// dxgiFormatR16G16B16A16Float is the DXGI format number of half-float RGBA
+const dxgiFormatR16G16B16A16Float = 10
+
 // dxgiFormat 将 Unity 压缩纹理格式转换为 DXGI 格式编号
 // dxgiFormat converts a Unity compressed texture format to its DXGI format number
 func dxgiFormat(format int32) uint32 {
@@ -0,0 +1,399 @@
+package aba
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
+)
+
+const (
+	// TextureColorSpaceLinear 表示贴图保存线性数据，如法线、遮罩和粗糙度
+	// TextureColorSpaceLinear marks a texture holding linear data such as normals, masks, and roughness
+	TextureColorSpaceLinear int32 = 0
+	// TextureColorSpaceSRGB 表示贴图保存 sRGB 颜色，Unity 采样时先转换到线性空间
+	// TextureColorSpaceSRGB marks a texture holding sRGB color that Unity converts to linear space when sampling
+	TextureColorSpaceSRGB int32 = 1
+)
+
+// MipFilter 选择生成 mip 链时使用的降采样滤波器 / MipFilter selects the downsampling filter used to generate mip chains
+type MipFilter int
+
+const (
+	// MipFilterBox 对 2x2 像素取平均，速度最快，与 Unity 导入器的 Box 选项一致
+	// MipFilterBox averages 2x2 pixels, which is fastest and matches the Unity importer's Box option
+	MipFilterBox MipFilter = iota
+	// MipFilterTriangle 使用双线性帐篷核，比 Box 更平滑
+	// MipFilterTriangle uses a bilinear tent kernel that is smoother than Box
+	MipFilterTriangle
+	// MipFilterKaiser 使用 Kaiser 窗 sinc 核，保留更多细节，与 Unity 导入器的 Kaiser 选项一致
+	// MipFilterKaiser uses a Kaiser-windowed sinc kernel that keeps more detail and matches the Unity importer's Kaiser option
+	MipFilterKaiser
+)
+
+// String 返回滤波器名称 / String returns the filter name
+func (f MipFilter) String() string {
+	switch f {
+	case MipFilterBox:
+		return "box"
+	case MipFilterTriangle:
+		return "triangle"
+	case MipFilterKaiser:
+		return "kaiser"
+	default:
+		return fmt.Sprintf("MipFilter(%d)", int(f))
+	}
+}
+
+// ParseMipFilter 不区分大小写地解析 box、triangle 或 kaiser，空字符串视为 box
+// ParseMipFilter parses box, triangle, or kaiser case-insensitively and treats an empty string as box
+func ParseMipFilter(s string) (MipFilter, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "box":
+		return MipFilterBox, nil
+	case "triangle", "tent", "bilinear":
+		return MipFilterTriangle, nil
+	case "kaiser":
+		return MipFilterKaiser, nil
+	default:
+		return MipFilterBox, fmt.Errorf("unknown mip filter %q, expected box, triangle, or kaiser", s)
+	}
+}
+
+// ParseTexture2DEncodeFormat 不区分大小写地解析可编码的 TextureFormat 名称，空字符串视为 RGBA32，BC1 和 BC3 分别是 DXT1 和 DXT5 的别名
+// ParseTexture2DEncodeFormat parses an encodable TextureFormat name case-insensitively, treating an empty string as RGBA32 and BC1 and BC3 as aliases of DXT1 and DXT5
+func ParseTexture2DEncodeFormat(s string) (int32, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "rgba32":
+		return TextureFormatRGBA32, nil
+	case "rgb24":
+		return TextureFormatRGB24, nil
+	case "alpha8":
+		return TextureFormatAlpha8, nil
+	case "dxt1", "bc1":
+		return TextureFormatDXT1, nil
+	case "dxt5", "bc3":
+		return TextureFormatDXT5, nil
+	case "bc4":
+		return TextureFormatBC4, nil
+	case "bc5":
+		return TextureFormatBC5, nil
+	case "bc7":
+		return TextureFormatBC7, nil
+	default:
+		return 0, fmt.Errorf("unsupported Texture2D encode format %q, expected RGBA32, RGB24, Alpha8, DXT1, DXT5, BC4, BC5, or BC7", s)
+	}
+}
+
+// Texture2DEncodeOptions 控制从图像重建 Texture2D 时的格式、mip 链、色彩空间和流式输出
+// Texture2DEncodeOptions controls the format, mip chain, color space, and streamed output when rebuilding a Texture2D from an image
+type Texture2DEncodeOptions struct {
+	Format     int32       // 目标 TextureFormat，0 表示 RGBA32 / Target TextureFormat, where 0 means RGBA32
+	MipMaps    bool        // 是否生成完整 mip 链 / Whether to generate a full mip chain
+	MipFilter  MipFilter   // mip 降采样滤波器 / Mip downsampling filter
+	Linear     bool        // 是否按线性数据处理并写入线性色彩空间 / Whether to treat the image as linear data and write the linear color space
+	Quality    bcn.Quality // 块压缩编码质量 / Block-compression encoding quality
+	StreamPath string      // 非空时图像数据写入该 .resS 路径而非内联 / When non-empty, image data goes to this .resS path instead of inline
+}
+
+// EncodedTexture2D 保存按 Unity 自下而上行序编码的全部 mip 数据 / EncodedTexture2D holds all mip data encoded in Unity's bottom-up row order
+type EncodedTexture2D struct {
+	Width         int64  // 基础层宽度 / Base level width
+	Height        int64  // 基础层高度 / Base level height
+	TextureFormat int32  // Unity TextureFormat 枚举值 / Unity TextureFormat enum value
+	MipCount      int32  // mip 层数 / Mip level count
+	ColorSpace    int32  // m_ColorSpace 枚举值 / m_ColorSpace enum value
+	ImageData     []byte // 从基础层开始依次拼接的各层数据 / Level data concatenated starting from the base level
+}
+
+// Texture2DMaxMipCount 返回指定尺寸的完整 mip 链层数 / Texture2DMaxMipCount returns the level count of a full mip chain for the given dimensions
+func Texture2DMaxMipCount(width, height int64) int32 {
+	largest := max(width, height)
+	if largest <= 0 {
+		return 0
+	}
+	return int32(bits.Len64(uint64(largest)))
+}
+
+// Texture2DImageSize 返回指定格式、尺寸和 mip 层数的图像数据总字节数
+// Texture2DImageSize returns the total image data byte length for the given format, dimensions, and mip count
+func Texture2DImageSize(format int32, width, height int64, mipCount int32) (int64, error) {
+	if width <= 0 || height <= 0 || width > math.MaxInt32 || height > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid Texture2D dimensions %dx%d", width, height)
+	}
+	var total int64
+	for level := int32(0); level < mipCount; level++ {
+		w, h := max(width>>level, 1), max(height>>level, 1)
+		var size int64
+		if blockFormat, ok := textureBlockFormat(format); ok {
+			blockSize, err := bcn.EncodedSize(blockFormat, int(w), int(h))
+			if err != nil {
+				return 0, err
+			}
+			size = blockSize
+		} else if bytesPerPixel := rawTextureBytesPerPixel(format); bytesPerPixel > 0 {
+			size = w * h * int64(bytesPerPixel)
+		} else {
+			return 0, fmt.Errorf("unsupported Texture2D format %s", textureFormatName(format))
+		}
+		if size > math.MaxInt64-total {
+			return 0, fmt.Errorf("Texture2D image size overflows Int64")
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// EncodeTexture2DImage 将自上而下的图像按选项生成 mip 链并编码为 Unity 自下而上行序的 Texture2D 图像数据
+// sRGB 贴图在线性空间中降采样以免 mip 变暗，所有滤波均使用预乘 Alpha 以免透明像素的颜色渗入
+// EncodeTexture2DImage generates the optional mip chain for a top-down image and encodes it as Texture2D image data in Unity's bottom-up row order
+// sRGB textures are downsampled in linear space so mips do not darken, and all filtering uses premultiplied alpha so colors of transparent pixels do not bleed
+func EncodeTexture2DImage(img image.Image, opts Texture2DEncodeOptions) (*EncodedTexture2D, error) {
+	if img == nil {
+		return nil, fmt.Errorf("nil image")
+	}
+	format := opts.Format
+	if format == 0 {
+		format = TextureFormatRGBA32
+	}
+	if _, err := ParseTexture2DEncodeFormat(textureFormatName(format)); err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	width, height := int64(bounds.Dx()), int64(bounds.Dy())
+	mipCount := int32(1)
+	if opts.MipMaps {
+		mipCount = Texture2DMaxMipCount(width, height)
+	}
+	totalSize, err := Texture2DImageSize(format, width, height, mipCount)
+	if err != nil {
+		return nil, err
+	}
+	if totalSize > math.MaxInt32 {
+		return nil, fmt.Errorf("Texture2D %s data for %dx%d exceeds the Int32 size limit", textureFormatName(format), width, height)
+	}
+
+	base := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	draw.Draw(base, base.Rect, img, bounds.Min, draw.Src)
+	levels := []*image.NRGBA{base}
+	for level := int32(1); level < mipCount; level++ {
+		previous := levels[len(levels)-1]
+		w, h := max(int(width>>level), 1), max(int(height>>level), 1)
+		levels = append(levels, downsampleNRGBA(previous, w, h, opts.MipFilter, !opts.Linear))
+	}
+
+	colorSpace := TextureColorSpaceSRGB
+	if opts.Linear {
+		colorSpace = TextureColorSpaceLinear
+	}
+	encoded := &EncodedTexture2D{
+		Width:         width,
+		Height:        height,
+		TextureFormat: format,
+		MipCount:      mipCount,
+		ColorSpace:    colorSpace,
+		ImageData:     make([]byte, 0, totalSize),
+	}
+	for _, level := range levels {
+		flipNRGBARows(level)
+		data, err := encodeTextureLevel(format, level, opts.Quality)
+		if err != nil {
+			return nil, err
+		}
+		encoded.ImageData = append(encoded.ImageData, data...)
+	}
+	return encoded, nil
+}
+
+// encodeTextureLevel 将单个已翻转的 mip 层编码为目标格式 / encodeTextureLevel encodes one already-flipped mip level into the target format
+func encodeTextureLevel(format int32, level *image.NRGBA, quality bcn.Quality) ([]byte, error) {
+	if blockFormat, ok := textureBlockFormat(format); ok {
+		return bcn.Encode(blockFormat, level, &bcn.EncodeOptions{Quality: quality})
+	}
+	pixelCount := len(level.Pix) / 4
+	switch format {
+	case TextureFormatRGBA32:
+		return append([]byte(nil), level.Pix...), nil
+	case TextureFormatRGB24:
+		out := make([]byte, pixelCount*3)
+		for i := 0; i < pixelCount; i++ {
+			copy(out[i*3:i*3+3], level.Pix[i*4:i*4+3])
+		}
+		return out, nil
+	case TextureFormatAlpha8:
+		// 带透明度的图像写入 Alpha，不透明图像按 Unity 的 From Gray Scale 规则写入亮度
+		// Images with transparency store alpha, while opaque images store luminance following Unity's From Gray Scale rule
+		useAlpha := bcn.HasTransparency(level)
+		out := make([]byte, pixelCount)
+		for i := 0; i < pixelCount; i++ {
+			p := level.Pix[i*4 : i*4+4]
+			if useAlpha {
+				out[i] = p[3]
+			} else {
+				out[i] = uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2]) + 500) / 1000)
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported Texture2D encode format %s", textureFormatName(format))
+	}
+}
+
+// mipFilterSupport 返回滤波核在目标像素单位下的半径 / mipFilterSupport returns the kernel radius in destination pixel units
+func mipFilterSupport(filter MipFilter) float64 {
+	switch filter {
+	case MipFilterTriangle:
+		return 1
+	case MipFilterKaiser:
+		return 3
+	default:
+		return 0.5
+	}
+}
+
+// mipFilterWeight 返回滤波核在距离 x 处的权重 / mipFilterWeight returns the kernel weight at distance x
+func mipFilterWeight(filter MipFilter, x float64) float64 {
+	x = math.Abs(x)
+	switch filter {
+	case MipFilterTriangle:
+		return math.Max(0, 1-x)
+	case MipFilterKaiser:
+		// alpha 为 4 的 Kaiser 窗截断 sinc，窗口宽度与 NVTT 和 Unity 导入器一致
+		// A sinc truncated by a Kaiser window with alpha 4, using the same window width as NVTT and the Unity importer
+		const alpha, width = 4.0, 3.0
+		if x >= width {
+			return 0
+		}
+		sinc := 1.0
+		if x > 1e-6 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		ratio := x / width
+		return sinc * besselI0(alpha*math.Sqrt(1-ratio*ratio)) / besselI0(alpha)
+	default:
+		if x <= 0.5 {
+			return 1
+		}
+		return 0
+	}
+}
+
+// besselI0 计算第一类零阶修正贝塞尔函数 / besselI0 computes the zeroth-order modified Bessel function of the first kind
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 32; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// downsampleNRGBA 以可分离滤波器将图像缩小到目标尺寸，srgb 为真时在线性光中滤波
+// downsampleNRGBA shrinks an image to the target size with a separable filter, filtering in linear light when srgb is true
+func downsampleNRGBA(src *image.NRGBA, width, height int, filter MipFilter, srgb bool) *image.NRGBA {
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+	var toLinear [256]float32
+	for i := range toLinear {
+		v := float64(i) / 255
+		if srgb {
+			v = srgbToLinear(v)
+		}
+		toLinear[i] = float32(v)
+	}
+
+	// 转换为预乘 Alpha 的单精度像素，以免 4K 贴图的中间缓冲超过数百 MiB
+	// Convert to single-precision pixels with premultiplied alpha so intermediate buffers for 4K textures stay within a few hundred MiB
+	pixels := make([][4]float32, srcW*srcH)
+	for y := 0; y < srcH; y++ {
+		for x := 0; x < srcW; x++ {
+			p := src.Pix[src.PixOffset(x, y):]
+			a := float32(p[3]) / 255
+			pixels[y*srcW+x] = [4]float32{toLinear[p[0]] * a, toLinear[p[1]] * a, toLinear[p[2]] * a, a}
+		}
+	}
+
+	horizontal := make([][4]float32, width*srcH)
+	resampleAxis(pixels, horizontal, srcW, width, srcH, 1, srcW, 1, width, filter)
+	vertical := make([][4]float32, width*height)
+	resampleAxis(horizontal, vertical, srcH, height, width, width, 1, width, 1, filter)
+
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, p := range vertical {
+		a := math.Max(0, math.Min(1, float64(p[3])))
+		dst := out.Pix[i*4 : i*4+4]
+		dst[3] = uint8(math.Round(a * 255))
+		if a <= 0 {
+			dst[0], dst[1], dst[2] = 0, 0, 0
+			continue
+		}
+		for ch := 0; ch < 3; ch++ {
+			v := math.Max(0, math.Min(1, float64(p[ch])/a))
+			if srgb {
+				v = bcn.LinearToSRGB(v)
+			}
+			dst[ch] = uint8(math.Round(v * 255))
+		}
+	}
+	return out
+}
+
+// resampleAxis 沿一个轴重采样 lines 条像素线，stride 参数描述源和目标中沿轴及跨线的步长，边缘像素按钳位扩展
+// resampleAxis resamples lines pixel lines along one axis, with stride parameters describing the along-axis and across-line steps in source and destination, extending edges by clamping
+func resampleAxis(src, dst [][4]float32, srcLen, dstLen, lines, srcStep, srcLineStep, dstStep, dstLineStep int, filter MipFilter) {
+	scale := float64(srcLen) / float64(dstLen)
+	filterScale := math.Max(scale, 1)
+	support := mipFilterSupport(filter) * filterScale
+	for i := 0; i < dstLen; i++ {
+		center := (float64(i)+0.5)*scale - 0.5
+		first := int(math.Floor(center - support + 0.5))
+		last := int(math.Ceil(center + support - 0.5))
+		weights := make([]float64, 0, last-first+1)
+		total := 0.0
+		for j := first; j <= last; j++ {
+			w := mipFilterWeight(filter, (float64(j)-center)/filterScale)
+			weights = append(weights, w)
+			total += w
+		}
+		if total == 0 {
+			// 放大或退化核时回退到最近像素 / Fall back to the nearest pixel for magnification or degenerate kernels
+			for k := range weights {
+				weights[k] = 0
+			}
+			weights[int(math.Round(center))-first] = 1
+			total = 1
+		}
+		for line := 0; line < lines; line++ {
+			var sum [4]float64
+			for k, w := range weights {
+				if w == 0 {
+					continue
+				}
+				j := max(0, min(srcLen-1, first+k))
+				p := src[line*srcLineStep+j*srcStep]
+				for ch := 0; ch < 4; ch++ {
+					sum[ch] += float64(p[ch]) * w
+				}
+			}
+			var out [4]float32
+			for ch := 0; ch < 4; ch++ {
+				out[ch] = float32(sum[ch] / total)
+			}
+			dst[line*dstLineStep+i*dstStep] = out
+		}
+	}
+}
+
+// srgbToLinear 应用 sRGB 电光转换函数 / srgbToLinear applies the sRGB electro-optical transfer function
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
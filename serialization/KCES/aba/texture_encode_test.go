@@ -0,0 +1,180 @@
+package aba
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
+)
+
+func checkerImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(0)
+			if (x+y)%2 == 0 {
+				v = 255
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: uint8(y * 255 / max(height-1, 1)), B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestEncodeTexture2DImageBuildsFullMipChain(t *testing.T) {
+	for _, format := range []int32{TextureFormatRGBA32, TextureFormatRGB24, TextureFormatAlpha8, TextureFormatDXT1, TextureFormatDXT5, TextureFormatBC4, TextureFormatBC5, TextureFormatBC7} {
+		t.Run(textureFormatName(format), func(t *testing.T) {
+			encoded, err := EncodeTexture2DImage(checkerImage(16, 8), Texture2DEncodeOptions{Format: format, MipMaps: true, MipFilter: MipFilterKaiser})
+			if err != nil {
+				t.Fatalf("EncodeTexture2DImage: %v", err)
+			}
+			if encoded.MipCount != 5 {
+				t.Fatalf("MipCount = %d, want 5 levels down to 1x1", encoded.MipCount)
+			}
+			want, err := Texture2DImageSize(format, 16, 8, 5)
+			if err != nil {
+				t.Fatalf("Texture2DImageSize: %v", err)
+			}
+			if int64(len(encoded.ImageData)) != want {
+				t.Fatalf("image data = %d bytes, want %d", len(encoded.ImageData), want)
+			}
+		})
+	}
+}
+
+func TestEncodeTexture2DImageStoresRowsBottomUp(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		src.SetNRGBA(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+	encoded, err := EncodeTexture2DImage(src, Texture2DEncodeOptions{Format: TextureFormatBC7, Quality: bcn.QualityBest})
+	if err != nil {
+		t.Fatalf("EncodeTexture2DImage: %v", err)
+	}
+	img, err := DecodeTexture2DImage(&Texture2DData{Width: 4, Height: 4, TextureFormat: encoded.TextureFormat, MipCount: encoded.MipCount, ImageData: encoded.ImageData})
+	if err != nil {
+		t.Fatalf("DecodeTexture2DImage: %v", err)
+	}
+	if top := img.NRGBAAt(1, 0); top.R < 240 {
+		t.Fatalf("top row = %v, want the red source row back on top", top)
+	}
+	if bottom := img.NRGBAAt(1, 3); bottom.R > 15 {
+		t.Fatalf("bottom row = %v, want the black source row at the bottom", bottom)
+	}
+}
+
+func TestDownsampleFiltersSRGBInLinearLight(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{A: 255})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+
+	if got := downsampleNRGBA(src, 1, 1, MipFilterBox, true).NRGBAAt(0, 0); got.R != 188 {
+		t.Fatalf("sRGB box average = %v, want 188 (linear 0.5 re-encoded)", got)
+	}
+	if got := downsampleNRGBA(src, 1, 1, MipFilterBox, false).NRGBAAt(0, 0); got.R != 128 {
+		t.Fatalf("linear box average = %v, want 128", got)
+	}
+
+	// 完全透明像素的颜色不应渗入 / Colors of fully transparent pixels must not bleed in
+	src.SetNRGBA(1, 0, color.NRGBA{R: 255})
+	if got := downsampleNRGBA(src, 1, 1, MipFilterTriangle, true).NRGBAAt(0, 0); got.R != 0 || got.A != 128 {
+		t.Fatalf("premultiplied average = %v, want black at half alpha", got)
+	}
+}
+
+func TestNewNativeTexture2DObjectWithOptionsStreamsImageData(t *testing.T) {
+	object, streamData, err := NewNativeTexture2DObjectWithOptions("streamed.tex", checkerImage(8, 8), Texture2DEncodeOptions{
+		Format:     TextureFormatDXT5,
+		MipMaps:    true,
+		Linear:     true,
+		StreamPath: "streamed.tex.resS",
+	})
+	if err != nil {
+		t.Fatalf("NewNativeTexture2DObjectWithOptions: %v", err)
+	}
+	wantSize, _ := Texture2DImageSize(TextureFormatDXT5, 8, 8, 4)
+	if int64(len(streamData)) != wantSize {
+		t.Fatalf("stream data = %d bytes, want %d", len(streamData), wantSize)
+	}
+	root, err := object.DecodeValue()
+	if err != nil {
+		t.Fatalf("DecodeValue: %v", err)
+	}
+	assertTypeTreeInt(t, root, "m_TextureFormat", int64(TextureFormatDXT5))
+	assertTypeTreeInt(t, root, "m_MipCount", 4)
+	assertTypeTreeInt(t, root, "m_CompleteImageSize", wantSize)
+	assertTypeTreeInt(t, root, "m_ColorSpace", int64(TextureColorSpaceLinear))
+	if imageData, ok := root.Field("image data").Bytes(); !ok || len(imageData) != 0 {
+		t.Fatalf("inline image data = %d bytes, want empty", len(imageData))
+	}
+
+	stream, err := object.Texture2DStreamData()
+	if err != nil {
+		t.Fatalf("Texture2DStreamData: %v", err)
+	}
+	if stream != (StreamingInfo{Size: uint64(wantSize), Path: "streamed.tex.resS"}) {
+		t.Fatalf("stream = %+v", stream)
+	}
+	relocated := StreamingInfo{Offset: 48, Size: stream.Size, Path: "archive:/CAB-mod/CAB-mod.resS"}
+	if err := object.SetTexture2DStreamData(relocated); err != nil {
+		t.Fatalf("SetTexture2DStreamData: %v", err)
+	}
+	if stream, err = object.Texture2DStreamData(); err != nil || stream != relocated {
+		t.Fatalf("relocated stream = %+v, %v; want %+v", stream, err, relocated)
+	}
+
+	af, info, err := object.AssetsFileView()
+	if err != nil {
+		t.Fatalf("AssetsFileView: %v", err)
+	}
+	bundleStream := append(make([]byte, 48), streamData...)
+	texture, err := af.GetTexture2DDataRange(info, func(name string, offset int64, size int64) ([]byte, error) {
+		if name != "CAB-mod.resS" {
+			t.Fatalf("resolver name = %q", name)
+		}
+		return bundleStream[offset : offset+size], nil
+	})
+	if err != nil {
+		t.Fatalf("GetTexture2DDataRange: %v", err)
+	}
+	if !bytes.Equal(texture.ImageData, streamData) {
+		t.Fatal("resolved stream data differs from the encoded image data")
+	}
+}
+
+func TestNewNativeTexture2DObjectWithDefaultOptionsMatchesLegacyEncoder(t *testing.T) {
+	src := checkerImage(3, 2)
+	object, streamData, err := NewNativeTexture2DObjectWithOptions("legacy.tex", src, Texture2DEncodeOptions{})
+	if err != nil {
+		t.Fatalf("NewNativeTexture2DObjectWithOptions: %v", err)
+	}
+	if streamData != nil {
+		t.Fatalf("inline texture returned %d stream bytes", len(streamData))
+	}
+	legacy, err := NewNativeTexture2DObject("legacy.tex", 3, 2, src.Pix)
+	if err != nil {
+		t.Fatalf("NewNativeTexture2DObject: %v", err)
+	}
+	if !bytes.Equal(object.Data, legacy.Data) {
+		t.Fatal("default options no longer produce the legacy inline RGBA32 payload")
+	}
+}
+
+func TestParseTexture2DEncodeFormatAndMipFilter(t *testing.T) {
+	for input, want := range map[string]int32{"": TextureFormatRGBA32, "bc1": TextureFormatDXT1, "DXT5": TextureFormatDXT5, " bc7 ": TextureFormatBC7, "Alpha8": TextureFormatAlpha8} {
+		if got, err := ParseTexture2DEncodeFormat(input); err != nil || got != want {
+			t.Fatalf("ParseTexture2DEncodeFormat(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	if _, err := ParseTexture2DEncodeFormat("BC6H"); err == nil {
+		t.Fatal("BC6H accepted as an encode format")
+	}
+	if filter, err := ParseMipFilter("Kaiser"); err != nil || filter != MipFilterKaiser {
+		t.Fatalf("ParseMipFilter(Kaiser) = %v, %v", filter, err)
+	}
+	if _, err := ParseMipFilter("lanczos"); err == nil {
+		t.Fatal("unknown mip filter accepted")
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"testing"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/binaryio"
-	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
 
 func TestGetTexture2DData_Sample(t *testing.T) {
@@ -218,9 +217,6 @@ func TestFlipRGBA32RowsIsItsOwnInverse(t *testing.T) {
 }
 
 func TestWriteTexturePNGToFlipsUnityBottomUpRowsUpright(t *testing.T) {
-	if err := tools.CheckMagick(); err != nil {
-		t.Skipf("ImageMagick not available: %v", err)
-	}
 	red := []byte{255, 0, 0, 255}
 	blue := []byte{0, 0, 255, 255}
 	// Unity stores the lower-left origin first, so the bottom row is red and the top row is blue.
@@ -249,3 +245,84 @@ func TestWriteTexturePNGToFlipsUnityBottomUpRowsUpright(t *testing.T) {
 		t.Errorf("PNG bottom row is not red: r=%d b=%d", bottomR>>8, bottomB>>8)
 	}
 }
+
+func TestDecodeTexture2DImageFlipsBlockCompressedRowsUpright(t *testing.T) {
+	solid := func(color565 uint16) []byte {
+		block := make([]byte, 8)
+		binary.LittleEndian.PutUint16(block[0:2], color565)
+		binary.LittleEndian.PutUint16(block[2:4], color565)
+		return block
+	}
+	// Unity stores the lower block row first, so red is at the bottom and blue at the top.
+	tex := &Texture2DData{
+		Name:          "blocks.tex",
+		Width:         4,
+		Height:        8,
+		TextureFormat: TextureFormatDXT1,
+		MipCount:      1,
+		ImageData:     append(solid(0xF800), solid(0x001F)...),
+	}
+	img, err := DecodeTexture2DImage(tex)
+	if err != nil {
+		t.Fatalf("DecodeTexture2DImage: %v", err)
+	}
+	if top := img.NRGBAAt(0, 0); top.B != 255 || top.R != 0 {
+		t.Fatalf("top pixel = %v, want blue", top)
+	}
+	if bottom := img.NRGBAAt(3, 7); bottom.R != 255 || bottom.B != 0 {
+		t.Fatalf("bottom pixel = %v, want red", bottom)
+	}
+}
+
+func TestTexturePNGBytesDecodesEveryListedFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format int32
+		size   int
+	}{
+		{TextureFormatAlpha8, 16}, {TextureFormatR8, 16}, {TextureFormatRGB24, 48},
+		{TextureFormatRGBA32, 64}, {TextureFormatARGB32, 64}, {TextureFormatBGRA32, 64},
+		{TextureFormatRGBA64, 128}, {TextureFormatDXT1, 8}, {TextureFormatDXT5, 16},
+		{TextureFormatBC4, 8}, {TextureFormatBC5, 16}, {TextureFormatBC6H, 16}, {TextureFormatBC7, 16},
+	} {
+		tex := &Texture2DData{Name: "formats.tex", Width: 4, Height: 4, TextureFormat: tc.format, MipCount: 1, ImageData: make([]byte, tc.size)}
+		data, err := TexturePNGBytes(tex)
+		if err != nil {
+			t.Fatalf("%s: %v", textureFormatName(tc.format), err)
+		}
+		if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err != nil || cfg.Width != 4 || cfg.Height != 4 {
+			t.Fatalf("%s: PNG config %+v, %v", textureFormatName(tc.format), cfg, err)
+		}
+		tex.ImageData = tex.ImageData[:tc.size-1]
+		if _, err := TexturePNGBytes(tex); err == nil {
+			t.Fatalf("%s: expected truncated image data to fail", textureFormatName(tc.format))
+		}
+	}
+}
+
+func TestTextureHalfFloatDDSBytes(t *testing.T) {
+	// BC6H mode 3 with zero endpoints decodes to black with alpha 1.0.
+	block := make([]byte, 16)
+	block[0] = 3
+	tex := &Texture2DData{Name: "sky.tex", Width: 4, Height: 4, TextureFormat: TextureFormatBC6H, MipCount: 1, ImageData: block}
+	data, err := TextureHalfFloatDDSBytes(tex)
+	if err != nil {
+		t.Fatalf("TextureHalfFloatDDSBytes: %v", err)
+	}
+	if len(data) != 148+4*4*8 {
+		t.Fatalf("DDS length = %d", len(data))
+	}
+	if dxgi := binary.LittleEndian.Uint32(data[128:132]); dxgi != dxgiFormatR16G16B16A16Float {
+		t.Fatalf("DXGI format = %d", dxgi)
+	}
+	if pitch := binary.LittleEndian.Uint32(data[20:24]); pitch != 32 {
+		t.Fatalf("row pitch = %d, want 32", pitch)
+	}
+	if alpha := binary.LittleEndian.Uint16(data[148+6:]); alpha != 0x3C00 {
+		t.Fatalf("alpha = %#x, want half 1.0", alpha)
+	}
+
+	tex.TextureFormat = TextureFormatBC7
+	if _, err := TextureHalfFloatDDSBytes(tex); err == nil {
+		t.Fatal("expected a non-BC6H texture to be rejected")
+	}
+}
@@ -0,0 +1,243 @@
+package ct
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CatalogConflictSource 是冲突分析的一个输入 catalog / CatalogConflictSource is one input catalog of a conflict analysis
+type CatalogConflictSource struct {
+	Name    string              // 报告中显示的 catalog 名，通常是 .ct 文件路径 / Catalog name shown in the report, normally the .ct file path
+	Catalog *AssetBundleCatalog // 已解码的 catalog / Decoded catalog
+}
+
+// CatalogConflictReport 是多个 MOD catalog 之间资源冲突和哈希碰撞的报告
+// CatalogConflictReport is a report of resource conflicts and hash collisions across several mod catalogs
+type CatalogConflictReport struct {
+	Catalogs       []CatalogSummary       `json:"catalogs"`         // 输入 catalog 摘要，按输入顺序排列 / Input catalog summaries, in input order
+	Conflicts      []CatalogConflict      `json:"conflicts"`        // 由多个 catalog 提供的资源 / Resources provided by more than one catalog
+	HashCollisions []CatalogHashCollision `json:"hashCollisions"`   // 哈希相同但名称不同的资源 / Resources whose names differ but share a hash
+	Errors         []CatalogLoadError     `json:"errors,omitempty"` // 无法读取的 .ct 文件 / .ct files that could not be read
+}
+
+// CatalogSummary 描述一个输入 catalog / CatalogSummary describes one input catalog
+type CatalogSummary struct {
+	Source      string `json:"source"`      // 输入名 / Input name
+	Name        string `json:"name"`        // catalog 名称 / Catalog name
+	SubName     string `json:"subName"`     // catalog 子名称 / Catalog sub-name
+	PackageType string `json:"packageType"` // 包类型名称 / Package-type name
+	Priority    int32  `json:"priority"`    // catalog 优先级 / Catalog priority
+	Items       int    `json:"items"`       // 条目数 / Item count
+}
+
+// CatalogLoadError 记录一个无法读取的 .ct 文件 / CatalogLoadError records one .ct file that could not be read
+type CatalogLoadError struct {
+	Source  string `json:"source"`  // 输入名 / Input name
+	Message string `json:"message"` // 错误信息 / Error message
+}
+
+// CatalogProvider 是提供某个资源的一个 catalog 条目 / CatalogProvider is one catalog item that provides a resource
+type CatalogProvider struct {
+	Source      string `json:"source"`             // 输入名 / Input name
+	Catalog     string `json:"catalog"`            // catalog 名称 / Catalog name
+	PackageType string `json:"packageType"`        // 包类型名称 / Package-type name
+	Priority    int32  `json:"priority"`           // catalog 优先级 / Catalog priority
+	Name        string `json:"name"`               // 条目中的资源名称 / Resource name stored in the item
+	Resource    string `json:"resource,omitempty"` // 所在 AssetBundle 文件或 Unity 工程路径 / Containing AssetBundle file or Unity project path
+}
+
+// CatalogConflict 是同一资源哈希由多个 catalog 提供的冲突；Providers[0] 是预计生效的一方
+// CatalogConflict is a conflict in which several catalogs provide the same resource hash; Providers[0] is the expected winner
+type CatalogConflict struct {
+	Name      string            `json:"name"`      // 资源名称 / Resource name
+	Hash      uint64            `json:"hash"`      // 资源哈希 / Resource hash
+	Winner    string            `json:"winner"`    // 预计生效的输入名 / Input name expected to win
+	Ambiguous bool              `json:"ambiguous"` // 胜者与次者的优先级和包类型相同，实际结果取决于加载顺序 / The winner ties the runner-up on priority and package type, so the outcome depends on load order
+	Providers []CatalogProvider `json:"providers"` // 按预计生效顺序排列的提供者 / Providers in expected precedence order
+}
+
+// CatalogHashCollision 是不同资源名称得到相同哈希的碰撞，游戏只能按哈希找到其中之一
+// CatalogHashCollision is a collision in which different resource names share a hash, so the game can find only one of them by hash
+type CatalogHashCollision struct {
+	Hash      uint64            `json:"hash"`      // 共享的哈希 / Shared hash
+	Names     []string          `json:"names"`     // 不同的资源名称 / Distinct resource names
+	Providers []CatalogProvider `json:"providers"` // 全部提供者 / Every provider
+}
+
+// catalogProviderEntry 保存提供者及其输入顺序，用于稳定排序 / catalogProviderEntry keeps a provider with its input order for stable sorting
+type catalogProviderEntry struct {
+	provider    CatalogProvider
+	packageType CatalogPackageType
+	order       int
+}
+
+// AnalyzeCatalogConflicts 按资源哈希分组全部 catalog 条目，报告由多个 catalog 提供的资源和名称不同的哈希碰撞
+// 胜者按 catalog priority 从高到低选择，priority 相同时补丁包类型（PluginPatch、BasePatch、ExtraPatch）优先于非补丁包类型
+// AnalyzeCatalogConflicts groups every catalog item by resource hash and reports resources provided by several catalogs and hash collisions between different names
+// The winner is chosen by descending catalog priority, and at equal priority patch package types (PluginPatch, BasePatch, ExtraPatch) take precedence over non-patch types
+func AnalyzeCatalogConflicts(sources []CatalogConflictSource) (*CatalogConflictReport, error) {
+	report := &CatalogConflictReport{
+		Catalogs:       []CatalogSummary{},
+		Conflicts:      []CatalogConflict{},
+		HashCollisions: []CatalogHashCollision{},
+	}
+	byHash := make(map[uint64][]catalogProviderEntry)
+	var hashes []uint64
+	for sourceIndex, source := range sources {
+		if source.Catalog == nil {
+			return nil, fmt.Errorf("catalog %q is nil", source.Name)
+		}
+		cat := source.Catalog
+		summary := CatalogSummary{
+			Source: source.Name, Name: derefCatalogString(cat.Name), SubName: derefCatalogString(cat.SubName),
+			PackageType: PackageTypeName(cat.PackageType), Priority: cat.Priority,
+		}
+		add := func(name *string, hash uint64, resource string) {
+			if _, ok := byHash[hash]; !ok {
+				hashes = append(hashes, hash)
+			}
+			byHash[hash] = append(byHash[hash], catalogProviderEntry{
+				provider: CatalogProvider{
+					Source: source.Name, Catalog: summary.Name, PackageType: summary.PackageType, Priority: cat.Priority,
+					Name: derefCatalogString(name), Resource: resource,
+				},
+				packageType: cat.PackageType,
+				order:       sourceIndex,
+			})
+			summary.Items++
+		}
+		for _, item := range cat.Items {
+			if item == nil {
+				continue
+			}
+			resource := ""
+			if item.ResourceIndex >= 0 && int(item.ResourceIndex) < len(cat.ResourceFileNames) {
+				resource = derefCatalogString(cat.ResourceFileNames[item.ResourceIndex])
+			}
+			add(item.Name, item.Hash, resource)
+		}
+		for _, item := range cat.VirtualItems {
+			if item == nil {
+				continue
+			}
+			add(item.Name, item.Hash, derefCatalogString(item.AssetPath))
+		}
+		report.Catalogs = append(report.Catalogs, summary)
+	}
+
+	for _, hash := range hashes {
+		entries := byHash[hash]
+		sort.SliceStable(entries, func(i, j int) bool {
+			return catalogProviderPrecedes(entries[i], entries[j])
+		})
+
+		names := distinctCatalogNames(entries)
+		if len(names) > 1 {
+			report.HashCollisions = append(report.HashCollisions, CatalogHashCollision{
+				Hash: hash, Names: names, Providers: catalogProviders(entries),
+			})
+		}
+
+		sourcesSeen := make(map[int]bool)
+		for _, entry := range entries {
+			sourcesSeen[entry.order] = true
+		}
+		if len(sourcesSeen) < 2 {
+			continue
+		}
+		winner, runnerUp := entries[0], catalogProviderEntry{order: -1}
+		for _, entry := range entries[1:] {
+			if entry.order != winner.order {
+				runnerUp = entry
+				break
+			}
+		}
+		report.Conflicts = append(report.Conflicts, CatalogConflict{
+			Name:      winner.provider.Name,
+			Hash:      hash,
+			Winner:    winner.provider.Source,
+			Ambiguous: !catalogProviderPrecedes(winner, runnerUp),
+			Providers: catalogProviders(entries),
+		})
+	}
+
+	sort.SliceStable(report.Conflicts, func(i, j int) bool {
+		return strings.ToLower(report.Conflicts[i].Name) < strings.ToLower(report.Conflicts[j].Name)
+	})
+	sort.SliceStable(report.HashCollisions, func(i, j int) bool {
+		return report.HashCollisions[i].Hash < report.HashCollisions[j].Hash
+	})
+	return report, nil
+}
+
+// PackageTypeName 返回包类型的枚举名称，未知值返回十进制数字
+// PackageTypeName returns the enumeration name of a package type, or its decimal value when unknown
+func PackageTypeName(packageType CatalogPackageType) string {
+	switch packageType {
+	case PackageTypeBase:
+		return "Base"
+	case PackageTypePlugin:
+		return "Plugin"
+	case PackageTypePluginPatch:
+		return "PluginPatch"
+	case PackageTypeBasePatch:
+		return "BasePatch"
+	case PackageTypeExtraBase:
+		return "ExtraBase"
+	case PackageTypeExtraPatch:
+		return "ExtraPatch"
+	default:
+		return fmt.Sprintf("%d", int32(packageType))
+	}
+}
+
+// isPatchPackageType 报告包类型是否为覆盖其他包的补丁类型 / isPatchPackageType reports whether a package type is a patch type that overrides other packages
+func isPatchPackageType(packageType CatalogPackageType) bool {
+	return packageType == PackageTypePluginPatch || packageType == PackageTypeBasePatch || packageType == PackageTypeExtraPatch
+}
+
+// catalogProviderPrecedes 报告 a 是否严格优先于 b：先比较 priority，再比较是否为补丁包类型
+// catalogProviderPrecedes reports whether a strictly takes precedence over b, comparing priority first and then whether the package type is a patch type
+func catalogProviderPrecedes(a, b catalogProviderEntry) bool {
+	if b.order < 0 {
+		return true
+	}
+	if a.provider.Priority != b.provider.Priority {
+		return a.provider.Priority > b.provider.Priority
+	}
+	return isPatchPackageType(a.packageType) && !isPatchPackageType(b.packageType)
+}
+
+// distinctCatalogNames 返回忽略大小写后不同的资源名称，保留首次出现的写法
+// distinctCatalogNames returns the resource names that differ ignoring case, keeping the first spelling seen
+func distinctCatalogNames(entries []catalogProviderEntry) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, entry := range entries {
+		key := strings.ToLower(entry.provider.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, entry.provider.Name)
+	}
+	return names
+}
+
+// catalogProviders 提取排序后的提供者列表 / catalogProviders extracts the sorted provider list
+func catalogProviders(entries []catalogProviderEntry) []CatalogProvider {
+	providers := make([]CatalogProvider, len(entries))
+	for i, entry := range entries {
+		providers[i] = entry.provider
+	}
+	return providers
+}
+
+// derefCatalogString 返回可空 catalog 字符串的值，nil 返回空字符串 / derefCatalogString returns the value of a nullable catalog string, or an empty string for nil
+func derefCatalogString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
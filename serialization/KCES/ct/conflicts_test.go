@@ -0,0 +1,90 @@
+package ct
+
+import "testing"
+
+func conflictTestCatalog(name string, packageType CatalogPackageType, priority int32, items ...string) *AssetBundleCatalog {
+	resource := name + ".aba"
+	cat := &AssetBundleCatalog{
+		Kind: CatalogKindAssetBundle, Version: 1, CatalogType: CatalogTypeParts, PackageType: packageType, Priority: priority,
+		Name: &name, ResourceFileNames: []*string{&resource},
+	}
+	for _, item := range items {
+		itemName := item
+		cat.Items = append(cat.Items, &CatalogItem{Name: &itemName, Hash: HashStringIgnoreCase(itemName)})
+	}
+	return cat
+}
+
+func TestAnalyzeCatalogConflictsPicksWinner(t *testing.T) {
+	report, err := AnalyzeCatalogConflicts([]CatalogConflictSource{
+		{Name: "a.ct", Catalog: conflictTestCatalog("a", PackageTypePlugin, 0, "dress.menu", "only_a.tex")},
+		{Name: "b.ct", Catalog: conflictTestCatalog("b", PackageTypePlugin, 5, "Dress.menu", "hair.menu")},
+		{Name: "c.ct", Catalog: conflictTestCatalog("c", PackageTypePlugin, 0, "hair.menu")},
+		{Name: "d.ct", Catalog: conflictTestCatalog("d", PackageTypePluginPatch, 5, "hair.menu", "shoes.menu")},
+		{Name: "e.ct", Catalog: conflictTestCatalog("e", PackageTypePlugin, 1, "shoes.menu")},
+		{Name: "f.ct", Catalog: conflictTestCatalog("f", PackageTypePlugin, 1, "shoes.menu")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Catalogs) != 6 || report.Catalogs[3].PackageType != "PluginPatch" || report.Catalogs[0].Items != 2 {
+		t.Fatalf("unexpected catalog summaries: %+v", report.Catalogs)
+	}
+	if len(report.HashCollisions) != 0 {
+		t.Fatalf("unexpected hash collisions: %+v", report.HashCollisions)
+	}
+
+	want := map[string]struct {
+		winner    string
+		ambiguous bool
+		providers int
+	}{
+		"Dress.menu": {"b.ct", false, 2},
+		"hair.menu":  {"d.ct", false, 3},
+		"shoes.menu": {"d.ct", false, 3},
+	}
+	if len(report.Conflicts) != len(want) {
+		t.Fatalf("conflicts = %+v", report.Conflicts)
+	}
+	for _, conflict := range report.Conflicts {
+		expected, ok := want[conflict.Name]
+		if !ok {
+			t.Fatalf("unexpected conflict %q", conflict.Name)
+		}
+		if conflict.Winner != expected.winner || conflict.Ambiguous != expected.ambiguous || len(conflict.Providers) != expected.providers {
+			t.Errorf("%s: got winner=%s ambiguous=%v providers=%d", conflict.Name, conflict.Winner, conflict.Ambiguous, len(conflict.Providers))
+		}
+		if conflict.Providers[0].Source != conflict.Winner || conflict.Hash != HashStringIgnoreCase(conflict.Name) {
+			t.Errorf("%s: providers are not in precedence order: %+v", conflict.Name, conflict.Providers)
+		}
+	}
+	if report.Conflicts[0].Providers[0].Resource != "b.aba" {
+		t.Errorf("resource = %q", report.Conflicts[0].Providers[0].Resource)
+	}
+}
+
+func TestAnalyzeCatalogConflictsReportsTiesAndCollisions(t *testing.T) {
+	first := conflictTestCatalog("first", PackageTypePlugin, 2, "body.tex")
+	second := conflictTestCatalog("second", PackageTypePlugin, 2, "body.tex")
+	other := "other.tex"
+	second.Items = append(second.Items, &CatalogItem{Name: &other, Hash: HashStringIgnoreCase("body.tex")})
+
+	report, err := AnalyzeCatalogConflicts([]CatalogConflictSource{{Name: "first.ct", Catalog: first}, {Name: "second.ct", Catalog: second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Conflicts) != 1 || !report.Conflicts[0].Ambiguous || report.Conflicts[0].Winner != "first.ct" {
+		t.Fatalf("conflicts = %+v", report.Conflicts)
+	}
+	if len(report.HashCollisions) != 1 {
+		t.Fatalf("hash collisions = %+v", report.HashCollisions)
+	}
+	collision := report.HashCollisions[0]
+	if len(collision.Names) != 2 || collision.Names[0] != "body.tex" || collision.Names[1] != "other.tex" || len(collision.Providers) != 3 {
+		t.Errorf("collision = %+v", collision)
+	}
+
+	if _, err := AnalyzeCatalogConflicts([]CatalogConflictSource{{Name: "nil.ct"}}); err == nil {
+		t.Error("nil catalog was accepted")
+	}
+}
@@ -0,0 +1,47 @@
+package ct
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/containerfs"
+)
+
+// FS 返回 ContentTable 的只读 io/fs 视图，实现 fs.FS、fs.ReadDirFS 和 fs.StatFS，Directories 中的空目录同样可见
+// CT 数据不压缩，fs.FileInfo 的 Size 与 Sys 返回的 containerfs.EntryInfo 存储大小相同，文件直接引用 Raw 并支持随机访问
+// FS returns a read-only io/fs view of the ContentTable implementing fs.FS, fs.ReadDirFS and fs.StatFS, with empty directories from Directories visible too
+// CT data is stored uncompressed, so fs.FileInfo.Size equals the stored size in the containerfs.EntryInfo returned by Sys, and files reference Raw directly with random access
+func (ct *ContentTable) FS() (*containerfs.FS, error) {
+	var entries []containerfs.Entry
+	if ct != nil {
+		for dir := range ct.Directories {
+			entries = append(entries, containerfs.Entry{Path: dir, Dir: true})
+		}
+	}
+	for _, name := range ct.GetFileNames() {
+		size := int64(ct.Files[name].Size)
+		entries = append(entries, containerfs.Entry{
+			Path:       name,
+			RawSize:    size,
+			StoredSize: size,
+			Open: func() (io.ReadCloser, error) {
+				data, err := ct.GetFileData(name)
+				if err != nil {
+					return nil, err
+				}
+				return bytesFile{bytes.NewReader(data)}, nil
+			},
+		})
+	}
+	return containerfs.New(entries)
+}
+
+// bytesFile 为 bytes.Reader 补充 Close，同时保留其 ReadAt
+// bytesFile adds Close to bytes.Reader while keeping its ReadAt
+type bytesFile struct {
+	*bytes.Reader
+}
+
+// Close 不做任何事
+// Close does nothing
+func (bytesFile) Close() error { return nil }
@@ -0,0 +1,56 @@
+package ct
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/containerfs"
+)
+
+func TestContentTableFSWalksFilesAndEmptyDirectories(t *testing.T) {
+	ct := &ContentTable{
+		Files:       map[string]VirtualFile{},
+		Directories: map[string]VirtualDirectoryMetadata{"empty": {}, "menu": {}},
+	}
+	for name, data := range map[string]string{
+		"menu/dress.menu":     "menu data",
+		"menu/icon/dress.png": "png data",
+		"root.txt":            "root",
+	} {
+		if err := ct.AddFile(name, []byte(data)); err != nil {
+			t.Fatalf("AddFile(%q): %v", name, err)
+		}
+	}
+	fsys, err := ct.FS()
+	if err != nil {
+		t.Fatalf("FS: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "menu/dress.menu", "menu/icon/dress.png", "root.txt", "empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	var walked []string
+	if err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		walked = append(walked, p)
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkDir: %v", err)
+	}
+	want := []string{".", "empty", "menu", "menu/dress.menu", "menu/icon", "menu/icon/dress.png", "root.txt"}
+	if !reflect.DeepEqual(walked, want) {
+		t.Fatalf("WalkDir visited %v, want %v", walked, want)
+	}
+
+	info, err := fs.Stat(fsys, "menu/dress.menu")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if entry := info.Sys().(containerfs.EntryInfo); info.Size() != 9 || entry.StoredSize != 9 || entry.Compressed {
+		t.Fatalf("menu/dress.menu info: size=%d sys=%+v", info.Size(), entry)
+	}
+}
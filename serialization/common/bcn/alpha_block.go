@@ -0,0 +1,130 @@
+package bcn
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// 插值单通道块布局：两个 8 位端点后接 48 位索引，第 i 个像素占用第 3i 至 3i+2 位
+// 端点 0 大于端点 1 时为 8 级插值模式，否则为 6 级插值模式并额外提供 0 与 255，BC3 的 Alpha 以及 BC4、BC5 的通道均使用此布局
+// Interpolated single-channel block layout: two 8-bit endpoints followed by 48 index bits, with pixel i using bits 3i through 3i+2
+// Endpoint 0 greater than endpoint 1 selects 8-step interpolation, otherwise 6-step interpolation with extra 0 and 255 entries; BC3 alpha and the BC4 and BC5 channels all use this layout
+
+// alphaPalette 根据端点生成 8 项调色板 / alphaPalette builds the 8-entry palette from the endpoints
+func alphaPalette(a0, a1 uint8) [8]uint8 {
+	var palette [8]uint8
+	palette[0], palette[1] = a0, a1
+	e0, e1 := int(a0), int(a1)
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = uint8(((7-i)*e0*2 + i*e1*2 + 7) / 14)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = uint8(((5-i)*e0*2 + i*e1*2 + 5) / 10)
+		}
+		palette[6], palette[7] = 0, 255
+	}
+	return palette
+}
+
+// decodeAlphaBlock 解码一个 8 字节插值单通道块 / decodeAlphaBlock decodes one 8-byte interpolated single-channel block
+func decodeAlphaBlock(block []byte, out *[16]uint8) {
+	palette := alphaPalette(block[0], block[1])
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(block[2+i]) << (8 * i)
+	}
+	for i := 0; i < 16; i++ {
+		out[i] = palette[(bits>>(3*i))&7]
+	}
+}
+
+// encodeAlphaBlock 编码一个插值单通道块，Normal 及以上档位比较两种插值模式，Best 还会搜索端点邻域
+// encodeAlphaBlock encodes one interpolated single-channel block; Normal and above compare both interpolation modes and Best also searches the endpoint neighborhood
+func encodeAlphaBlock(dst []byte, values *[16]uint8, quality Quality) {
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	if lo == hi {
+		writeAlphaBlock(dst, hi, lo, &[16]uint8{})
+		return
+	}
+
+	bestA0, bestA1 := hi, lo
+	bestIndices, bestErr := assignAlphaIndices(values, hi, lo)
+
+	if quality >= QualityNormal {
+		// 6 级模式的端点只需覆盖 0 与 255 以外的值，两个极值由固定索引表示
+		// 6-step endpoints only need to cover values other than 0 and 255 because fixed indices represent both extremes
+		innerLo, innerHi := uint8(255), uint8(0)
+		for _, v := range values {
+			if v != 0 && v != 255 {
+				innerLo = min(innerLo, v)
+				innerHi = max(innerHi, v)
+			}
+		}
+		if innerLo > innerHi {
+			innerLo, innerHi = 0, 255
+		}
+		if indices, e := assignAlphaIndices(values, innerLo, innerHi); e < bestErr {
+			bestA0, bestA1, bestIndices, bestErr = innerLo, innerHi, indices, e
+		}
+	}
+
+	if quality >= QualityBest && bestErr > 0 {
+		baseA0, baseA1 := int(bestA0), int(bestA1)
+		for d0 := -2; d0 <= 2; d0++ {
+			for d1 := -2; d1 <= 2; d1++ {
+				a0, a1 := baseA0+d0, baseA1+d1
+				if a0 < 0 || a0 > 255 || a1 < 0 || a1 > 255 || (d0 == 0 && d1 == 0) {
+					continue
+				}
+				// 只在原模式内搜索，避免端点交叉时意外切换模式
+				// Search only within the original mode so crossing endpoints do not switch modes unexpectedly
+				if (a0 > a1) != (baseA0 > baseA1) {
+					continue
+				}
+				if indices, e := assignAlphaIndices(values, uint8(a0), uint8(a1)); e < bestErr {
+					bestA0, bestA1, bestIndices, bestErr = uint8(a0), uint8(a1), indices, e
+				}
+			}
+		}
+	}
+
+	writeAlphaBlock(dst, bestA0, bestA1, &bestIndices)
+}
+
+// assignAlphaIndices 为每个值选择最近的调色板项并返回平方误差
+// assignAlphaIndices selects the nearest palette entry for each value and returns the squared error
+func assignAlphaIndices(values *[16]uint8, a0, a1 uint8) ([16]uint8, int) {
+	palette := alphaPalette(a0, a1)
+	var indices [16]uint8
+	total := 0
+	for i, v := range values {
+		bestIndex, bestErr := 0, math.MaxInt
+		for j, p := range palette {
+			d := int(v) - int(p)
+			if e := d * d; e < bestErr {
+				bestIndex, bestErr = j, e
+			}
+		}
+		indices[i] = uint8(bestIndex)
+		total += bestErr
+	}
+	return indices, total
+}
+
+// writeAlphaBlock 写出端点和 48 位索引 / writeAlphaBlock writes the endpoints and 48 index bits
+func writeAlphaBlock(dst []byte, a0, a1 uint8, indices *[16]uint8) {
+	dst[0], dst[1] = a0, a1
+	var bits uint64
+	for i := 15; i >= 0; i-- {
+		bits = bits<<3 | uint64(indices[i]&7)
+	}
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], bits)
+	copy(dst[2:8], tmp[:6])
+}
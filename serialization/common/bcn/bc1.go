@@ -0,0 +1,316 @@
+package bcn
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// BC1 颜色块布局：两个小端 RGB565 端点后接 32 位索引，第 i 个像素（行优先）占用第 2i 至 2i+1 位
+// color0 大于 color1 时为 4 色模式，否则为 3 色模式且索引 3 表示透明黑色；BC2 与 BC3 的颜色块始终按 4 色模式解释
+// BC1 color block layout: two little-endian RGB565 endpoints followed by 32 index bits, with pixel i in row-major order using bits 2i through 2i+1
+// color0 greater than color1 selects 4-color mode, otherwise 3-color mode where index 3 is transparent black; BC2 and BC3 color blocks always use 4-color mode
+
+// unpack565 将 RGB565 扩展为 8 位通道，低位复制高位以覆盖完整范围
+// unpack565 expands RGB565 to 8-bit channels, replicating the high bits into the low bits to cover the full range
+func unpack565(c uint16) [3]int32 {
+	r := int32(c>>11) & 0x1f
+	g := int32(c>>5) & 0x3f
+	b := int32(c) & 0x1f
+	return [3]int32{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
+
+// pack565 将 8 位通道四舍五入量化为 RGB565
+// pack565 rounds 8-bit channels to RGB565
+func pack565(c [3]float64) uint16 {
+	r := quantizeChannel(c[0], 31)
+	g := quantizeChannel(c[1], 63)
+	b := quantizeChannel(c[2], 31)
+	return uint16(r<<11 | g<<5 | b)
+}
+
+// quantizeChannel 将 0 至 255 的通道值四舍五入到 0 至 levels
+// quantizeChannel rounds a channel value in 0 through 255 to 0 through levels
+func quantizeChannel(v float64, levels int) int {
+	q := int(math.Round(v * float64(levels) / 255))
+	return max(0, min(levels, q))
+}
+
+// colorPalette 根据端点和模式生成 4 项调色板，3 色模式下第 4 项为透明黑色
+// colorPalette builds the 4-entry palette from endpoints and mode, with the fourth entry transparent black in 3-color mode
+func colorPalette(c0, c1 uint16, fourColor bool) [4][4]uint8 {
+	e0 := unpack565(c0)
+	e1 := unpack565(c1)
+	var palette [4][4]uint8
+	for ch := 0; ch < 3; ch++ {
+		palette[0][ch] = uint8(e0[ch])
+		palette[1][ch] = uint8(e1[ch])
+		if fourColor {
+			palette[2][ch] = uint8((2*e0[ch] + e1[ch] + 1) / 3)
+			palette[3][ch] = uint8((e0[ch] + 2*e1[ch] + 1) / 3)
+		} else {
+			palette[2][ch] = uint8((e0[ch] + e1[ch] + 1) / 2)
+			palette[3][ch] = 0
+		}
+	}
+	palette[0][3], palette[1][3], palette[2][3] = 255, 255, 255
+	if fourColor {
+		palette[3][3] = 255
+	}
+	return palette
+}
+
+// decodeColorBlock 解码一个 8 字节 BC1 颜色块，forceFourColor 用于 BC2 与 BC3
+// decodeColorBlock decodes one 8-byte BC1 color block, with forceFourColor used for BC2 and BC3
+func decodeColorBlock(block []byte, out *[16][4]uint8, forceFourColor bool) {
+	c0 := binary.LittleEndian.Uint16(block[0:2])
+	c1 := binary.LittleEndian.Uint16(block[2:4])
+	indices := binary.LittleEndian.Uint32(block[4:8])
+	palette := colorPalette(c0, c1, forceFourColor || c0 > c1)
+	for i := 0; i < 16; i++ {
+		out[i] = palette[(indices>>(2*i))&3]
+	}
+}
+
+// colorEndpoints 是量化前的一对浮点端点 / colorEndpoints is a pair of floating-point endpoints before quantization
+type colorEndpoints [2][3]float64
+
+// encodeColorBlock 编码一个 BC1 颜色块，allowTransparent 为 true 时 Alpha 小于 128 的像素使用 3 色模式透明索引
+// encodeColorBlock encodes one BC1 color block, mapping pixels with alpha below 128 to the 3-color transparent index when allowTransparent is true
+func encodeColorBlock(dst []byte, pixels *[16][4]uint8, allowTransparent bool, quality Quality) {
+	var transparent [16]bool
+	hasTransparent := false
+	var points [][3]float64
+	for i := range pixels {
+		if allowTransparent && pixels[i][3] < 128 {
+			transparent[i] = true
+			hasTransparent = true
+			continue
+		}
+		points = append(points, [3]float64{float64(pixels[i][0]), float64(pixels[i][1]), float64(pixels[i][2])})
+	}
+
+	if len(points) == 0 {
+		// 整块透明时使用 3 色模式的透明索引
+		// A fully transparent block uses the transparent index of 3-color mode
+		binary.LittleEndian.PutUint16(dst[0:2], 0)
+		binary.LittleEndian.PutUint16(dst[2:4], 0)
+		binary.LittleEndian.PutUint32(dst[4:8], 0xffffffff)
+		return
+	}
+
+	endpoints := principalEndpoints(points)
+	// 含透明像素的块只能使用 3 色模式，Best 档位还会比较不透明块的 3 色模式
+	// Blocks with transparent pixels must use 3-color mode, and Best also evaluates 3-color mode for opaque blocks
+	tryFour := !hasTransparent
+	tryThree := hasTransparent || (allowTransparent && quality >= QualityBest)
+
+	bestErr := math.Inf(1)
+	var best [8]byte
+	var candidate [8]byte
+	if tryFour {
+		if e := fitColorBlock(candidate[:], pixels, &transparent, endpoints, true, quality); e < bestErr {
+			bestErr = e
+			best = candidate
+		}
+	}
+	if tryThree {
+		if e := fitColorBlock(candidate[:], pixels, &transparent, endpoints, false, quality); e < bestErr {
+			best = candidate
+		}
+	}
+	copy(dst, best[:])
+}
+
+// fitColorBlock 在给定模式下量化并优化端点后写出块，返回不透明像素的平方误差
+// fitColorBlock quantizes and refines endpoints in the given mode, writes the block, and returns the squared error of opaque pixels
+func fitColorBlock(dst []byte, pixels *[16][4]uint8, transparent *[16]bool, endpoints colorEndpoints, fourColor bool, quality Quality) float64 {
+	iterations := 0
+	switch quality {
+	case QualityNormal:
+		iterations = 1
+	case QualityBest:
+		iterations = 8
+	}
+
+	c0, c1 := pack565(endpoints[0]), pack565(endpoints[1])
+	indices, bestErr := assignColorIndices(pixels, transparent, c0, c1, fourColor)
+	for iter := 0; iter < iterations; iter++ {
+		refined, ok := refineColorEndpoints(pixels, transparent, &indices, fourColor)
+		if !ok {
+			break
+		}
+		r0, r1 := pack565(refined[0]), pack565(refined[1])
+		if r0 == c0 && r1 == c1 {
+			break
+		}
+		refinedIndices, refinedErr := assignColorIndices(pixels, transparent, r0, r1, fourColor)
+		if refinedErr >= bestErr {
+			break
+		}
+		c0, c1, indices, bestErr = r0, r1, refinedIndices, refinedErr
+	}
+
+	writeColorBlock(dst, c0, c1, &indices, fourColor)
+	return bestErr
+}
+
+// assignColorIndices 为每个像素选择最近的调色板项，透明像素固定使用索引 3
+// assignColorIndices selects the nearest palette entry for each pixel, with transparent pixels fixed to index 3
+func assignColorIndices(pixels *[16][4]uint8, transparent *[16]bool, c0, c1 uint16, fourColor bool) ([16]uint8, float64) {
+	palette := colorPalette(c0, c1, fourColor)
+	entries := 4
+	if !fourColor {
+		entries = 3
+	}
+	var indices [16]uint8
+	total := 0.0
+	for i := range pixels {
+		if transparent[i] {
+			indices[i] = 3
+			continue
+		}
+		bestIndex, bestErr := 0, math.MaxInt
+		for j := 0; j < entries; j++ {
+			dr := int(pixels[i][0]) - int(palette[j][0])
+			dg := int(pixels[i][1]) - int(palette[j][1])
+			db := int(pixels[i][2]) - int(palette[j][2])
+			if e := dr*dr + dg*dg + db*db; e < bestErr {
+				bestIndex, bestErr = j, e
+			}
+		}
+		indices[i] = uint8(bestIndex)
+		total += float64(bestErr)
+	}
+	return indices, total
+}
+
+// refineColorEndpoints 根据当前索引对两个端点做最小二乘求解
+// refineColorEndpoints solves both endpoints by least squares from the current indices
+func refineColorEndpoints(pixels *[16][4]uint8, transparent *[16]bool, indices *[16]uint8, fourColor bool) (colorEndpoints, bool) {
+	weights := [4]float64{1, 0, 2.0 / 3, 1.0 / 3}
+	if !fourColor {
+		weights = [4]float64{1, 0, 0.5, 0}
+	}
+	var aa, ab, bb float64
+	var ax, bx [3]float64
+	for i := range pixels {
+		if transparent[i] {
+			continue
+		}
+		w := weights[indices[i]]
+		a, b := w, 1-w
+		aa += a * a
+		ab += a * b
+		bb += b * b
+		for ch := 0; ch < 3; ch++ {
+			v := float64(pixels[i][ch])
+			ax[ch] += a * v
+			bx[ch] += b * v
+		}
+	}
+	det := aa*bb - ab*ab
+	if math.Abs(det) < 1e-9 {
+		return colorEndpoints{}, false
+	}
+	var out colorEndpoints
+	for ch := 0; ch < 3; ch++ {
+		out[0][ch] = clamp255((ax[ch]*bb - bx[ch]*ab) / det)
+		out[1][ch] = clamp255((bx[ch]*aa - ax[ch]*ab) / det)
+	}
+	return out, true
+}
+
+// writeColorBlock 按模式要求排列端点顺序并在交换端点时重映射索引
+// writeColorBlock orders endpoints as the mode requires and remaps indices when the endpoints are swapped
+func writeColorBlock(dst []byte, c0, c1 uint16, indices *[16]uint8, fourColor bool) {
+	remap := [4]uint8{0, 1, 2, 3}
+	if fourColor {
+		switch {
+		case c0 < c1:
+			c0, c1 = c1, c0
+			remap = [4]uint8{1, 0, 3, 2}
+		case c0 == c1:
+			// 端点相同时 4 色模式无法表达，所有像素使用端点本身
+			// Equal endpoints cannot express 4-color mode, so every pixel uses the endpoint itself
+			remap = [4]uint8{0, 0, 0, 0}
+		}
+	} else if c0 > c1 {
+		c0, c1 = c1, c0
+		remap = [4]uint8{1, 0, 2, 3}
+	}
+
+	var bits uint32
+	for i := 15; i >= 0; i-- {
+		bits = bits<<2 | uint32(remap[indices[i]])
+	}
+	binary.LittleEndian.PutUint16(dst[0:2], c0)
+	binary.LittleEndian.PutUint16(dst[2:4], c1)
+	binary.LittleEndian.PutUint32(dst[4:8], bits)
+}
+
+// principalEndpoints 沿颜色协方差主轴投影像素并返回投影范围两端的颜色
+// principalEndpoints projects pixels onto the principal axis of their color covariance and returns the colors at both ends of the projected range
+func principalEndpoints(points [][3]float64) colorEndpoints {
+	var mean [3]float64
+	for _, p := range points {
+		for ch := 0; ch < 3; ch++ {
+			mean[ch] += p[ch]
+		}
+	}
+	n := float64(len(points))
+	for ch := 0; ch < 3; ch++ {
+		mean[ch] /= n
+	}
+
+	var cov [3][3]float64
+	for _, p := range points {
+		d := [3]float64{p[0] - mean[0], p[1] - mean[1], p[2] - mean[2]}
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				cov[r][c] += d[r] * d[c]
+			}
+		}
+	}
+
+	// 以最大方差通道为初值做幂迭代求主轴
+	// Power iteration seeded with the highest-variance channel finds the principal axis
+	axis := [3]float64{0, 0, 0}
+	seed := 0
+	for ch := 1; ch < 3; ch++ {
+		if cov[ch][ch] > cov[seed][seed] {
+			seed = ch
+		}
+	}
+	axis[seed] = 1
+	for iter := 0; iter < 8; iter++ {
+		var next [3]float64
+		for r := 0; r < 3; r++ {
+			next[r] = cov[r][0]*axis[0] + cov[r][1]*axis[1] + cov[r][2]*axis[2]
+		}
+		length := math.Sqrt(next[0]*next[0] + next[1]*next[1] + next[2]*next[2])
+		if length < 1e-9 {
+			break
+		}
+		for ch := 0; ch < 3; ch++ {
+			axis[ch] = next[ch] / length
+		}
+	}
+
+	minT, maxT := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		t := (p[0]-mean[0])*axis[0] + (p[1]-mean[1])*axis[1] + (p[2]-mean[2])*axis[2]
+		minT = math.Min(minT, t)
+		maxT = math.Max(maxT, t)
+	}
+	var out colorEndpoints
+	for ch := 0; ch < 3; ch++ {
+		out[0][ch] = clamp255(mean[ch] + axis[ch]*maxT)
+		out[1][ch] = clamp255(mean[ch] + axis[ch]*minT)
+	}
+	return out
+}
+
+// clamp255 将浮点通道值限制在 0 至 255 / clamp255 clamps a floating-point channel value to 0 through 255
+func clamp255(v float64) float64 {
+	return math.Max(0, math.Min(255, v))
+}
@@ -0,0 +1,57 @@
+package bcn
+
+import "math"
+
+// BC4 每块只含一个插值单通道块，BC5 依次存放红、绿两个插值单通道块，两者复用 BC3 Alpha 块布局
+// BC4 stores a single interpolated single-channel block per block, and BC5 stores a red block followed by a green block; both reuse the BC3 alpha block layout
+
+// decodeBC4Block 将 BC4 单通道解码为灰度像素，与 R8 贴图的灰度导出保持一致
+// decodeBC4Block decodes the BC4 channel into grayscale pixels, matching the grayscale export of R8 textures
+func decodeBC4Block(block []byte, out *[16][4]uint8) {
+	var values [16]uint8
+	decodeAlphaBlock(block[:8], &values)
+	for i, v := range values {
+		out[i] = [4]uint8{v, v, v, 255}
+	}
+}
+
+// decodeBC5Block 解码 BC5 的红绿通道，并按单位法线重建蓝色通道，使 BC5 法线贴图导出后可直接查看
+// decodeBC5Block decodes the BC5 red and green channels and reconstructs blue as the unit-normal Z so exported BC5 normal maps are directly viewable
+func decodeBC5Block(block []byte, out *[16][4]uint8) {
+	var red, green [16]uint8
+	decodeAlphaBlock(block[:8], &red)
+	decodeAlphaBlock(block[8:16], &green)
+	for i := range out {
+		out[i] = [4]uint8{red[i], green[i], reconstructNormalZ(red[i], green[i]), 255}
+	}
+}
+
+// reconstructNormalZ 根据切线空间法线的 X、Y 分量计算 Z 分量并映射回 0 到 255
+// reconstructNormalZ computes the Z component of a tangent-space normal from its X and Y components and maps it back to 0 through 255
+func reconstructNormalZ(r, g uint8) uint8 {
+	x := float64(r)/127.5 - 1
+	y := float64(g)/127.5 - 1
+	z := math.Sqrt(math.Max(0, 1-x*x-y*y))
+	return uint8(clamp255(math.Round((z + 1) * 127.5)))
+}
+
+// encodeBC4Block 将红色通道编码为一个插值单通道块 / encodeBC4Block encodes the red channel into one interpolated single-channel block
+func encodeBC4Block(dst []byte, pixels *[16][4]uint8, quality Quality) {
+	var red [16]uint8
+	for i := range pixels {
+		red[i] = pixels[i][0]
+	}
+	encodeAlphaBlock(dst[:8], &red, quality)
+}
+
+// encodeBC5Block 将红、绿通道分别编码为两个插值单通道块，蓝色与 Alpha 被丢弃并在解码时按法线重建
+// encodeBC5Block encodes the red and green channels into two interpolated single-channel blocks, discarding blue and alpha, which decoding rebuilds as the normal Z
+func encodeBC5Block(dst []byte, pixels *[16][4]uint8, quality Quality) {
+	var red, green [16]uint8
+	for i := range pixels {
+		red[i] = pixels[i][0]
+		green[i] = pixels[i][1]
+	}
+	encodeAlphaBlock(dst[:8], &red, quality)
+	encodeAlphaBlock(dst[8:16], &green, quality)
+}
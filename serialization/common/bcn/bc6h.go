@@ -0,0 +1,312 @@
+package bcn
+
+import (
+	"fmt"
+	"math"
+)
+
+// BC6H 每块 16 字节，存放无符号半精度浮点 RGB，前 2 位或 5 位模式号决定 14 种端点布局之一
+// 两区域模式使用 3 位索引和 BC7 的两子集分区表，单区域模式使用 4 位索引；多数模式以首个端点为基准，其余端点存为有符号差值
+// BC6H uses 16 bytes per block to store unsigned half-float RGB, and a leading 2-bit or 5-bit mode number selects one of 14 endpoint layouts
+// Two-region modes use 3-bit indices with the BC7 two-subset partition table and one-region modes use 4-bit indices; most modes store the first endpoint as a base and the others as signed deltas
+
+// bc6hField 标识 BC6H 端点分量，w、x 为区域 0 的两个端点，y、z 为区域 1 的两个端点
+// bc6hField identifies a BC6H endpoint component, where w and x are the two endpoints of region 0 and y and z are the two endpoints of region 1
+type bc6hField uint8
+
+const (
+	bc6hRW bc6hField = iota
+	bc6hGW
+	bc6hBW
+	bc6hRX
+	bc6hGX
+	bc6hBX
+	bc6hRY
+	bc6hGY
+	bc6hBY
+	bc6hRZ
+	bc6hGZ
+	bc6hBZ
+)
+
+// bc6hSegment 描述模式头中连续存放的一段端点位，从 first 位逐位读到 last 位，first 大于 last 时按逆序存放
+// bc6hSegment describes a run of endpoint bits in the mode header, read one bit at a time from bit first to bit last and stored in reverse when first is greater than last
+type bc6hSegment struct {
+	field       bc6hField
+	first, last int
+}
+
+// bc6hMode 描述一种 BC6H 模式 / bc6hMode describes one BC6H mode
+type bc6hMode struct {
+	id           int           // 模式号 / Mode number
+	regions      int           // 区域数 / Region count
+	transformed  bool          // 非基准端点是否存为差值 / Whether non-base endpoints are stored as deltas
+	endpointBits int           // 基准端点精度 / Base endpoint precision
+	deltaBits    [3]int        // R、G、B 差值位数 / Delta bits for R, G, and B
+	layout       []bc6hSegment // 模式号之后的端点位布局 / Endpoint bit layout following the mode number
+}
+
+// bc6hSeg 创建一个端点位段 / bc6hSeg creates an endpoint bit segment
+func bc6hSeg(field bc6hField, first, last int) bc6hSegment {
+	return bc6hSegment{field: field, first: first, last: last}
+}
+
+// bc6hBit 创建只含单个位的端点位段 / bc6hBit creates an endpoint bit segment holding a single bit
+func bc6hBit(field bc6hField, index int) bc6hSegment {
+	return bc6hSegment{field: field, first: index, last: index}
+}
+
+// bc6hModes 按 DirectX 规范记录全部 BC6H 模式，布局中的位按块内顺序排列
+// bc6hModes lists every BC6H mode from the DirectX specification, with layout bits in their in-block order
+var bc6hModes = []bc6hMode{
+	{id: 0, regions: 2, transformed: true, endpointBits: 10, deltaBits: [3]int{5, 5, 5}, layout: []bc6hSegment{
+		bc6hBit(bc6hGY, 4), bc6hBit(bc6hBY, 4), bc6hBit(bc6hBZ, 4), bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9),
+		bc6hSeg(bc6hRX, 0, 4), bc6hBit(bc6hGZ, 4), bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 4), bc6hBit(bc6hBZ, 0), bc6hSeg(bc6hGZ, 0, 3),
+		bc6hSeg(bc6hBX, 0, 4), bc6hBit(bc6hBZ, 1), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 4), bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 1, regions: 2, transformed: true, endpointBits: 7, deltaBits: [3]int{6, 6, 6}, layout: []bc6hSegment{
+		bc6hBit(bc6hGY, 5), bc6hBit(bc6hGZ, 4), bc6hBit(bc6hGZ, 5), bc6hSeg(bc6hRW, 0, 6), bc6hBit(bc6hBZ, 0), bc6hBit(bc6hBZ, 1), bc6hBit(bc6hBY, 4),
+		bc6hSeg(bc6hGW, 0, 6), bc6hBit(bc6hBY, 5), bc6hBit(bc6hBZ, 2), bc6hBit(bc6hGY, 4), bc6hSeg(bc6hBW, 0, 6), bc6hBit(bc6hBZ, 3), bc6hBit(bc6hBZ, 5),
+		bc6hBit(bc6hBZ, 4), bc6hSeg(bc6hRX, 0, 5), bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 5), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 5),
+		bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 5), bc6hSeg(bc6hRZ, 0, 5),
+	}},
+	{id: 2, regions: 2, transformed: true, endpointBits: 11, deltaBits: [3]int{5, 4, 4}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 4), bc6hBit(bc6hRW, 10), bc6hSeg(bc6hGY, 0, 3),
+		bc6hSeg(bc6hGX, 0, 3), bc6hBit(bc6hGW, 10), bc6hBit(bc6hBZ, 0), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 3), bc6hBit(bc6hBW, 10),
+		bc6hBit(bc6hBZ, 1), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 4), bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 6, regions: 2, transformed: true, endpointBits: 11, deltaBits: [3]int{4, 5, 4}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 3), bc6hBit(bc6hRW, 10), bc6hBit(bc6hGZ, 4),
+		bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 4), bc6hBit(bc6hGW, 10), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 3), bc6hBit(bc6hBW, 10),
+		bc6hBit(bc6hBZ, 1), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 3), bc6hBit(bc6hBZ, 0), bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 3),
+		bc6hBit(bc6hGY, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 10, regions: 2, transformed: true, endpointBits: 11, deltaBits: [3]int{4, 4, 5}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 3), bc6hBit(bc6hRW, 10), bc6hBit(bc6hBY, 4),
+		bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 3), bc6hBit(bc6hGW, 10), bc6hBit(bc6hBZ, 0), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 4),
+		bc6hBit(bc6hBW, 10), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 3), bc6hBit(bc6hBZ, 1), bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 3),
+		bc6hBit(bc6hBZ, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 14, regions: 2, transformed: true, endpointBits: 9, deltaBits: [3]int{5, 5, 5}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 8), bc6hBit(bc6hBY, 4), bc6hSeg(bc6hGW, 0, 8), bc6hBit(bc6hGY, 4), bc6hSeg(bc6hBW, 0, 8), bc6hBit(bc6hBZ, 4),
+		bc6hSeg(bc6hRX, 0, 4), bc6hBit(bc6hGZ, 4), bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 4), bc6hBit(bc6hBZ, 0), bc6hSeg(bc6hGZ, 0, 3),
+		bc6hSeg(bc6hBX, 0, 4), bc6hBit(bc6hBZ, 1), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 4), bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 18, regions: 2, transformed: true, endpointBits: 8, deltaBits: [3]int{6, 5, 5}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 7), bc6hBit(bc6hGZ, 4), bc6hBit(bc6hBY, 4), bc6hSeg(bc6hGW, 0, 7), bc6hBit(bc6hBZ, 2), bc6hBit(bc6hGY, 4),
+		bc6hSeg(bc6hBW, 0, 7), bc6hBit(bc6hBZ, 3), bc6hBit(bc6hBZ, 4), bc6hSeg(bc6hRX, 0, 5), bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 4),
+		bc6hBit(bc6hBZ, 0), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 4), bc6hBit(bc6hBZ, 1), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 5), bc6hSeg(bc6hRZ, 0, 5),
+	}},
+	{id: 22, regions: 2, transformed: true, endpointBits: 8, deltaBits: [3]int{5, 6, 5}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 7), bc6hBit(bc6hBZ, 0), bc6hBit(bc6hBY, 4), bc6hSeg(bc6hGW, 0, 7), bc6hBit(bc6hGY, 5), bc6hBit(bc6hGY, 4),
+		bc6hSeg(bc6hBW, 0, 7), bc6hBit(bc6hGZ, 5), bc6hBit(bc6hBZ, 4), bc6hSeg(bc6hRX, 0, 4), bc6hBit(bc6hGZ, 4), bc6hSeg(bc6hGY, 0, 3),
+		bc6hSeg(bc6hGX, 0, 5), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 4), bc6hBit(bc6hBZ, 1), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 4),
+		bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 26, regions: 2, transformed: true, endpointBits: 8, deltaBits: [3]int{5, 5, 6}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 7), bc6hBit(bc6hBZ, 1), bc6hBit(bc6hBY, 4), bc6hSeg(bc6hGW, 0, 7), bc6hBit(bc6hBY, 5), bc6hBit(bc6hGY, 4),
+		bc6hSeg(bc6hBW, 0, 7), bc6hBit(bc6hBZ, 5), bc6hBit(bc6hBZ, 4), bc6hSeg(bc6hRX, 0, 4), bc6hBit(bc6hGZ, 4), bc6hSeg(bc6hGY, 0, 3),
+		bc6hSeg(bc6hGX, 0, 4), bc6hBit(bc6hBZ, 0), bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 5), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 4),
+		bc6hBit(bc6hBZ, 2), bc6hSeg(bc6hRZ, 0, 4), bc6hBit(bc6hBZ, 3),
+	}},
+	{id: 30, regions: 2, endpointBits: 6, deltaBits: [3]int{6, 6, 6}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 5), bc6hBit(bc6hGZ, 4), bc6hBit(bc6hBZ, 0), bc6hBit(bc6hBZ, 1), bc6hBit(bc6hBY, 4), bc6hSeg(bc6hGW, 0, 5),
+		bc6hBit(bc6hGY, 5), bc6hBit(bc6hBY, 5), bc6hBit(bc6hBZ, 2), bc6hBit(bc6hGY, 4), bc6hSeg(bc6hBW, 0, 5), bc6hBit(bc6hGZ, 5),
+		bc6hBit(bc6hBZ, 3), bc6hBit(bc6hBZ, 5), bc6hBit(bc6hBZ, 4), bc6hSeg(bc6hRX, 0, 5), bc6hSeg(bc6hGY, 0, 3), bc6hSeg(bc6hGX, 0, 5),
+		bc6hSeg(bc6hGZ, 0, 3), bc6hSeg(bc6hBX, 0, 5), bc6hSeg(bc6hBY, 0, 3), bc6hSeg(bc6hRY, 0, 5), bc6hSeg(bc6hRZ, 0, 5),
+	}},
+	{id: 3, regions: 1, endpointBits: 10, deltaBits: [3]int{10, 10, 10}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 9), bc6hSeg(bc6hGX, 0, 9), bc6hSeg(bc6hBX, 0, 9),
+	}},
+	{id: 7, regions: 1, transformed: true, endpointBits: 11, deltaBits: [3]int{9, 9, 9}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 8), bc6hBit(bc6hRW, 10),
+		bc6hSeg(bc6hGX, 0, 8), bc6hBit(bc6hGW, 10), bc6hSeg(bc6hBX, 0, 8), bc6hBit(bc6hBW, 10),
+	}},
+	{id: 11, regions: 1, transformed: true, endpointBits: 12, deltaBits: [3]int{8, 8, 8}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 7), bc6hSeg(bc6hRW, 11, 10),
+		bc6hSeg(bc6hGX, 0, 7), bc6hSeg(bc6hGW, 11, 10), bc6hSeg(bc6hBX, 0, 7), bc6hSeg(bc6hBW, 11, 10),
+	}},
+	{id: 15, regions: 1, transformed: true, endpointBits: 16, deltaBits: [3]int{4, 4, 4}, layout: []bc6hSegment{
+		bc6hSeg(bc6hRW, 0, 9), bc6hSeg(bc6hGW, 0, 9), bc6hSeg(bc6hBW, 0, 9), bc6hSeg(bc6hRX, 0, 3), bc6hSeg(bc6hRW, 15, 10),
+		bc6hSeg(bc6hGX, 0, 3), bc6hSeg(bc6hGW, 15, 10), bc6hSeg(bc6hBX, 0, 3), bc6hSeg(bc6hBW, 15, 10),
+	}},
+}
+
+// bc6hModeByID 按模式号索引 bc6hModes，保留模式号为 nil
+// bc6hModeByID indexes bc6hModes by mode number, with reserved mode numbers left nil
+var bc6hModeByID = func() [32]*bc6hMode {
+	var byID [32]*bc6hMode
+	for i := range bc6hModes {
+		byID[bc6hModes[i].id] = &bc6hModes[i]
+	}
+	return byID
+}()
+
+// decodeBC6HBlock 将一个 BC6H 块解码为半精度浮点位模式，Alpha 固定为 1.0，保留模式按规范输出黑色
+// decodeBC6HBlock decodes one BC6H block into half-float bit patterns with alpha fixed at 1.0, and reserved modes output black as the specification requires
+func decodeBC6HBlock(block []byte, out *[16][4]uint16) {
+	const halfOne = 0x3C00
+	bits := newBlockBits(block)
+	id := bits.read(2)
+	if id >= 2 {
+		id |= bits.read(3) << 2
+	}
+	mode := bc6hModeByID[id]
+	if mode == nil {
+		for i := range out {
+			out[i] = [4]uint16{0, 0, 0, halfOne}
+		}
+		return
+	}
+
+	var fields [12]int
+	for _, s := range mode.layout {
+		step := 1
+		if s.first > s.last {
+			step = -1
+		}
+		for b := s.first; ; b += step {
+			fields[s.field] |= bits.bit() << b
+			if b == s.last {
+				break
+			}
+		}
+	}
+
+	endpointCount := mode.regions * 2
+	mask := 1<<mode.endpointBits - 1
+	var endpoints [4][3]int
+	for e := 0; e < endpointCount; e++ {
+		for ch := 0; ch < 3; ch++ {
+			v := fields[e*3+ch]
+			if e > 0 && mode.transformed {
+				v = (fields[ch] + signExtend(v, mode.deltaBits[ch])) & mask
+			}
+			endpoints[e][ch] = bc6hUnquantize(v, mode.endpointBits)
+		}
+	}
+
+	partition := 0
+	indexBits := 4
+	if mode.regions == 2 {
+		partition = bits.read(5)
+		indexBits = 3
+	}
+	weights := bptcWeights[indexBits]
+	for i := 0; i < 16; i++ {
+		n := indexBits
+		if bptcIsAnchor(mode.regions, partition, i) {
+			n--
+		}
+		weight := weights[bits.read(n)]
+		region := bptcSubset(mode.regions, partition, i)
+		e0, e1 := endpoints[region*2], endpoints[region*2+1]
+		for ch := 0; ch < 3; ch++ {
+			// 无符号格式将 16 位插值结果缩放到半精度浮点的最大有限值 0x7BFF 以内
+			// The unsigned format scales the 16-bit interpolated result into the half-float range ending at the largest finite value 0x7BFF
+			out[i][ch] = uint16(bptcInterpolate(e0[ch], e1[ch], weight) * 31 >> 6)
+		}
+		out[i][3] = halfOne
+	}
+}
+
+// signExtend 将 n 位有符号整数扩展为 int / signExtend widens an n-bit signed integer to int
+func signExtend(v, n int) int {
+	if v&(1<<(n-1)) != 0 {
+		return v - 1<<n
+	}
+	return v
+}
+
+// bc6hUnquantize 将 n 位无符号端点扩展到 16 位 / bc6hUnquantize widens an n-bit unsigned endpoint to 16 bits
+func bc6hUnquantize(v, n int) int {
+	switch {
+	case n >= 15:
+		return v
+	case v == 0:
+		return 0
+	case v == 1<<n-1:
+		return 0xFFFF
+	default:
+		return (v<<16 + 0x8000) >> n
+	}
+}
+
+// DecodeHalfFloat 将自上而下排列的 BC6H 块数据解码为 RGBA 半精度浮点位模式，每像素 4 个 uint16，按行自上而下排列
+// DecodeHalfFloat decodes top-down BC6H block data into RGBA half-float bit patterns, 4 uint16 values per pixel in top-down rows
+func DecodeHalfFloat(f Format, data []byte, width, height int) ([]uint16, error) {
+	if f != FormatBC6H {
+		return nil, fmt.Errorf("%s is not a half-float block-compression format", f)
+	}
+	expected, err := EncodedSize(f, width, height)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) < expected {
+		return nil, fmt.Errorf("%s data too short: got %d bytes, want %d for %dx%d", f, len(data), expected, width, height)
+	}
+
+	out := make([]uint16, width*height*4)
+	blocksWide := (width + 3) / 4
+	blocksHigh := (height + 3) / 4
+	var pixels [16][4]uint16
+	offset := 0
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			decodeBC6HBlock(data[offset:offset+16], &pixels)
+			offset += 16
+			for y := 0; y < 4 && by*4+y < height; y++ {
+				for x := 0; x < 4 && bx*4+x < width; x++ {
+					p := ((by*4+y)*width + bx*4 + x) * 4
+					copy(out[p:p+4], pixels[y*4+x][:])
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// decodeBC6HToneMapped 解码 BC6H 块并用 Reinhard 算子把线性 HDR 颜色压缩到 [0,1)，再按 sRGB 编码为 8 位
+// decodeBC6HToneMapped decodes a BC6H block and compresses linear HDR color into [0,1) with the Reinhard operator before encoding it as 8-bit sRGB
+func decodeBC6HToneMapped(block []byte, out *[16][4]uint8) {
+	var halves [16][4]uint16
+	decodeBC6HBlock(block, &halves)
+	for i, pixel := range halves {
+		for ch := 0; ch < 3; ch++ {
+			c := float64(HalfToFloat32(pixel[ch]))
+			out[i][ch] = uint8(clamp255(math.Round(LinearToSRGB(c/(1+c)) * 255)))
+		}
+		out[i][3] = 255
+	}
+}
+
+// LinearToSRGB 应用 sRGB 光电转换函数 / LinearToSRGB applies the sRGB opto-electronic transfer function
+func LinearToSRGB(c float64) float64 {
+	if c <= 0.0031308 {
+		return 12.92 * c
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+// HalfToFloat32 将 IEEE 754 半精度浮点位模式转换为 float32
+// HalfToFloat32 converts an IEEE 754 half-precision bit pattern to float32
+func HalfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exponent := int(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+	switch {
+	case exponent == 0 && mantissa == 0:
+		return math.Float32frombits(sign)
+	case exponent == 0:
+		// 次正规数 / Subnormal number
+		v := float32(mantissa) / (1 << 24)
+		if sign != 0 {
+			return -v
+		}
+		return v
+	case exponent == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mantissa<<13)
+	default:
+		return math.Float32frombits(sign | uint32(exponent-15+127)<<23 | mantissa<<13)
+	}
+}
@@ -0,0 +1,518 @@
+package bcn
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// BC7 每块 16 字节，最低位起连续的 0 位数量决定 8 种模式之一，各模式在子集数、端点精度、P 位、通道旋转和索引位宽上不同
+// BC7 uses 16 bytes per block; the number of consecutive zero bits from the lowest bit selects one of 8 modes, which differ in subset count, endpoint precision, P-bits, channel rotation, and index width
+
+// bc7Mode 描述一种 BC7 模式的位布局 / bc7Mode describes the bit layout of one BC7 mode
+type bc7Mode struct {
+	subsets        int // 子集数 / Subset count
+	partitionBits  int // 分区编号位数 / Partition number bits
+	rotationBits   int // 通道旋转位数 / Channel rotation bits
+	indexSelection int // 索引选择位数 / Index selection bits
+	colorBits      int // 每个颜色端点分量的位数 / Bits per color endpoint component
+	alphaBits      int // 每个 Alpha 端点分量的位数 / Bits per alpha endpoint component
+	endpointPBits  int // 是否每个端点各有一个 P 位 / Whether every endpoint has its own P-bit
+	sharedPBits    int // 是否每个子集共享一个 P 位 / Whether every subset shares one P-bit
+	indexBits      int // 主索引位宽 / Primary index width
+	indexBits2     int // 次索引位宽，0 表示无次索引 / Secondary index width, or 0 without a secondary index
+}
+
+// bc7Modes 按模式号记录 DirectX 规范中的 BC7 位布局 / bc7Modes lists the BC7 bit layouts from the DirectX specification by mode number
+var bc7Modes = [8]bc7Mode{
+	{subsets: 3, partitionBits: 4, colorBits: 4, endpointPBits: 1, indexBits: 3},
+	{subsets: 2, partitionBits: 6, colorBits: 6, sharedPBits: 1, indexBits: 3},
+	{subsets: 3, partitionBits: 6, colorBits: 5, indexBits: 2},
+	{subsets: 2, partitionBits: 6, colorBits: 7, endpointPBits: 1, indexBits: 2},
+	{subsets: 1, rotationBits: 2, indexSelection: 1, colorBits: 5, alphaBits: 6, indexBits: 2, indexBits2: 3},
+	{subsets: 1, rotationBits: 2, colorBits: 7, alphaBits: 8, indexBits: 2, indexBits2: 2},
+	{subsets: 1, colorBits: 7, alphaBits: 7, endpointPBits: 1, indexBits: 4},
+	{subsets: 2, partitionBits: 6, colorBits: 5, alphaBits: 5, endpointPBits: 1, indexBits: 2},
+}
+
+// bptcWeights 是 BC6H 与 BC7 共用的 2、3、4 位插值权重表，总权重为 64
+// bptcWeights holds the 2-, 3-, and 4-bit interpolation weights shared by BC6H and BC7, out of a total of 64
+var bptcWeights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// bptcPartitions2 以位掩码记录两子集分区，第 i 位为 1 表示第 i 个像素属于子集 1，BC6H 与 BC7 共用
+// bptcPartitions2 records the two-subset partitions as bit masks where bit i set means pixel i belongs to subset 1, shared by BC6H and BC7
+var bptcPartitions2 = [64]uint16{
+	0xCCCC, 0x8888, 0xEEEE, 0xECC8, 0xC880, 0xFEEC, 0xFEC8, 0xEC80,
+	0xC800, 0xFFEC, 0xFE80, 0xE800, 0xFFE8, 0xFF00, 0xFFF0, 0xF000,
+	0xF710, 0x008E, 0x7100, 0x08CE, 0x008C, 0x7310, 0x3100, 0x8CCE,
+	0x088C, 0x3110, 0x6666, 0x366C, 0x17E8, 0x0FF0, 0x718E, 0x399C,
+	0xAAAA, 0xF0F0, 0x5A5A, 0x33CC, 0x3C3C, 0x55AA, 0x9696, 0xA55A,
+	0x73CE, 0x13C8, 0x324C, 0x3BDC, 0x6996, 0xC33C, 0x9966, 0x0660,
+	0x0272, 0x04E4, 0x4E40, 0x2720, 0xC936, 0x936C, 0x39C6, 0x639C,
+	0x9336, 0x9CC6, 0x817E, 0xE718, 0xCCF0, 0x0FCC, 0x7744, 0xEE22,
+}
+
+// bptcPartitions3 记录三子集分区中每个像素所属的子集 / bptcPartitions3 records the subset of every pixel in the three-subset partitions
+var bptcPartitions3 = [64]string{
+	"0011001102212222", "0001001122112221", "0000200122112211", "0222002200110111",
+	"0000000011221122", "0011001100220022", "0022002211111111", "0011001122112211",
+	"0000000011112222", "0000111111112222", "0000111122222222", "0012001200120012",
+	"0112011201120112", "0122012201220122", "0011011211221222", "0011200122002220",
+	"0001001101121122", "0111001120012200", "0000112211221122", "0022002200221111",
+	"0111011102220222", "0001000122212221", "0000001101220122", "0000110022102210",
+	"0122012200110000", "0012001211222222", "0110122112210110", "0000011012211221",
+	"0022110211020022", "0110011020022222", "0011012201220011", "0000200022112221",
+	"0000000211221222", "0222002200120011", "0011001200220222", "0120012001200120",
+	"0000111122220000", "0120120120120120", "0120201212010120", "0011220011220011",
+	"0011112222000011", "0101010122222222", "0000000021212121", "0022112200221122",
+	"0022001100220011", "0220122102201221", "0101222222220101", "0000212121212121",
+	"0101010101012222", "0222011102220111", "0002111200021112", "0000211221122112",
+	"0222011101110222", "0002111211120002", "0110011001102222", "0000000021122112",
+	"0110011022222222", "0022001100110022", "0022112211220022", "0000000000002112",
+	"0002000100020001", "0222122202221222", "0101222222222222", "0111201122012220",
+}
+
+// bptcAnchors2 是两子集分区中子集 1 的锚点像素，锚点索引省略最高位
+// bptcAnchors2 lists the anchor pixel of subset 1 in two-subset partitions, whose index omits the most significant bit
+var bptcAnchors2 = [64]uint8{
+	15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// bptcAnchors3 是三子集分区中子集 1 与子集 2 的锚点像素
+// bptcAnchors3 lists the anchor pixels of subsets 1 and 2 in three-subset partitions
+var bptcAnchors3 = [2][64]uint8{
+	{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	},
+	{
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	},
+}
+
+// bptcSubset 返回分区中第 i 个像素所属的子集 / bptcSubset returns the subset of pixel i in a partition
+func bptcSubset(subsets, partition, i int) int {
+	switch subsets {
+	case 2:
+		return int(bptcPartitions2[partition]>>i) & 1
+	case 3:
+		return int(bptcPartitions3[partition][i] - '0')
+	default:
+		return 0
+	}
+}
+
+// bptcIsAnchor 判断第 i 个像素是否为所在子集的锚点 / bptcIsAnchor reports whether pixel i is the anchor of its subset
+func bptcIsAnchor(subsets, partition, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch subsets {
+	case 2:
+		return i == int(bptcAnchors2[partition])
+	case 3:
+		return i == int(bptcAnchors3[0][partition]) || i == int(bptcAnchors3[1][partition])
+	default:
+		return false
+	}
+}
+
+// bptcInterpolate 按 BPTC 权重在两个端点之间插值 / bptcInterpolate interpolates between two endpoints with a BPTC weight
+func bptcInterpolate(e0, e1 int, weight int) int {
+	return ((64-weight)*e0 + weight*e1 + 32) >> 6
+}
+
+// blockBits 按最低有效位优先的顺序读取 128 位块 / blockBits reads a 128-bit block least-significant bit first
+type blockBits struct {
+	lo, hi uint64
+	pos    uint
+}
+
+// newBlockBits 从 16 字节块创建位读取器 / newBlockBits creates a bit reader over a 16-byte block
+func newBlockBits(block []byte) blockBits {
+	return blockBits{lo: binary.LittleEndian.Uint64(block[0:8]), hi: binary.LittleEndian.Uint64(block[8:16])}
+}
+
+// read 读取 n 位并返回无符号值，越过块尾的位按 0 处理
+// read reads n bits and returns them as an unsigned value, treating bits past the end of the block as 0
+func (b *blockBits) read(n int) int {
+	value := 0
+	for i := 0; i < n; i++ {
+		value |= b.bit() << i
+	}
+	return value
+}
+
+// write 按最低有效位优先的顺序写入 value 的低 n 位 / write writes the low n bits of value least-significant bit first
+func (b *blockBits) write(value, n int) {
+	for i := 0; i < n; i++ {
+		bit := uint64(value>>i) & 1
+		if b.pos < 64 {
+			b.lo |= bit << b.pos
+		} else if b.pos < 128 {
+			b.hi |= bit << (b.pos - 64)
+		}
+		b.pos++
+	}
+}
+
+// store 将 128 位块写回 16 字节缓冲区 / store writes the 128-bit block back into a 16-byte buffer
+func (b *blockBits) store(dst []byte) {
+	binary.LittleEndian.PutUint64(dst[0:8], b.lo)
+	binary.LittleEndian.PutUint64(dst[8:16], b.hi)
+}
+
+// bit 读取单个位 / bit reads a single bit
+func (b *blockBits) bit() int {
+	var v uint64
+	switch {
+	case b.pos < 64:
+		v = b.lo >> b.pos
+	case b.pos < 128:
+		v = b.hi >> (b.pos - 64)
+	}
+	b.pos++
+	return int(v & 1)
+}
+
+// decodeBC7Block 解码一个 BC7 块，首字节全为 0 的保留模式按规范输出透明黑色
+// decodeBC7Block decodes one BC7 block, and the reserved mode with an all-zero first byte outputs transparent black as the specification requires
+func decodeBC7Block(block []byte, out *[16][4]uint8) {
+	bits := newBlockBits(block)
+	modeIndex := 0
+	for modeIndex < 8 && bits.bit() == 0 {
+		modeIndex++
+	}
+	if modeIndex == 8 {
+		*out = [16][4]uint8{}
+		return
+	}
+	mode := bc7Modes[modeIndex]
+
+	partition := bits.read(mode.partitionBits)
+	rotation := bits.read(mode.rotationBits)
+	indexSelection := bits.read(mode.indexSelection)
+
+	// 端点按通道优先、子集次之的顺序存放 / Endpoints are stored channel-major, then by subset
+	var endpoints [3][2][4]int
+	for ch := 0; ch < 3; ch++ {
+		for s := 0; s < mode.subsets; s++ {
+			endpoints[s][0][ch] = bits.read(mode.colorBits)
+			endpoints[s][1][ch] = bits.read(mode.colorBits)
+		}
+	}
+	if mode.alphaBits > 0 {
+		for s := 0; s < mode.subsets; s++ {
+			endpoints[s][0][3] = bits.read(mode.alphaBits)
+			endpoints[s][1][3] = bits.read(mode.alphaBits)
+		}
+	}
+
+	colorBits, alphaBits := mode.colorBits, mode.alphaBits
+	if mode.endpointPBits > 0 || mode.sharedPBits > 0 {
+		var pBits [3][2]int
+		for s := 0; s < mode.subsets; s++ {
+			if mode.endpointPBits > 0 {
+				pBits[s][0] = bits.bit()
+				pBits[s][1] = bits.bit()
+			} else {
+				pBits[s][0] = bits.bit()
+				pBits[s][1] = pBits[s][0]
+			}
+		}
+		for s := 0; s < mode.subsets; s++ {
+			for e := 0; e < 2; e++ {
+				for ch := 0; ch < 4; ch++ {
+					endpoints[s][e][ch] = endpoints[s][e][ch]<<1 | pBits[s][e]
+				}
+			}
+		}
+		colorBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+	}
+	for s := 0; s < mode.subsets; s++ {
+		for e := 0; e < 2; e++ {
+			for ch := 0; ch < 3; ch++ {
+				endpoints[s][e][ch] = expandBits(endpoints[s][e][ch], colorBits)
+			}
+			if alphaBits > 0 {
+				endpoints[s][e][3] = expandBits(endpoints[s][e][3], alphaBits)
+			} else {
+				endpoints[s][e][3] = 255
+			}
+		}
+	}
+
+	var primary, secondary [16]int
+	for i := 0; i < 16; i++ {
+		n := mode.indexBits
+		if bptcIsAnchor(mode.subsets, partition, i) {
+			n--
+		}
+		primary[i] = bits.read(n)
+	}
+	if mode.indexBits2 > 0 {
+		for i := 0; i < 16; i++ {
+			n := mode.indexBits2
+			if i == 0 {
+				n--
+			}
+			secondary[i] = bits.read(n)
+		}
+	}
+
+	for i := 0; i < 16; i++ {
+		s := bptcSubset(mode.subsets, partition, i)
+		e0, e1 := endpoints[s][0], endpoints[s][1]
+		colorWeights, colorIndex := bptcWeights[mode.indexBits], primary[i]
+		alphaWeights, alphaIndex := colorWeights, colorIndex
+		if mode.indexBits2 > 0 {
+			alphaWeights, alphaIndex = bptcWeights[mode.indexBits2], secondary[i]
+			if indexSelection == 1 {
+				colorWeights, alphaWeights = alphaWeights, colorWeights
+				colorIndex, alphaIndex = alphaIndex, colorIndex
+			}
+		}
+		var pixel [4]uint8
+		for ch := 0; ch < 3; ch++ {
+			pixel[ch] = uint8(bptcInterpolate(e0[ch], e1[ch], colorWeights[colorIndex]))
+		}
+		pixel[3] = uint8(bptcInterpolate(e0[3], e1[3], alphaWeights[alphaIndex]))
+		// 旋转模式把 Alpha 与某个颜色通道互换，以便把精度更高的标量通道留给变化最大的分量
+		// Rotation swaps alpha with one color channel so the higher-precision scalar channel can carry the component that varies most
+		if rotation > 0 {
+			pixel[3], pixel[rotation-1] = pixel[rotation-1], pixel[3]
+		}
+		out[i] = pixel
+	}
+}
+
+// expandBits 将 n 位端点复制高位扩展到 8 位 / expandBits widens an n-bit endpoint to 8 bits by replicating its high bits
+func expandBits(v, n int) int {
+	if n >= 8 {
+		return v
+	}
+	v <<= 8 - n
+	return v | v>>n
+}
+
+// BC7 编码器只输出模式 6：单子集 RGBA 端点各 7 位加独立 P 位、4 位索引，覆盖 Unity 导入器的大多数用途且无需分区搜索
+// The BC7 encoder emits mode 6 only: one subset with 7-bit RGBA endpoints plus per-endpoint P-bits and 4-bit indices, which covers most of what the Unity importer produces without a partition search
+
+// bc7Endpoints 保存模式 6 的两个 8 位 RGBA 端点 / bc7Endpoints holds the two 8-bit RGBA endpoints of mode 6
+type bc7Endpoints [2][4]float64
+
+// encodeBC7Block 以模式 6 编码一个块，Normal 及以上档位用最小二乘迭代优化端点
+// encodeBC7Block encodes one block in mode 6, and Normal and above refine the endpoints with least-squares iterations
+func encodeBC7Block(dst []byte, pixels *[16][4]uint8, quality Quality) {
+	endpoints := principalEndpoints4(pixels)
+	bestQuantized, bestIndices, bestErr := quantizeBC7Mode6(pixels, endpoints)
+
+	iterations := 0
+	switch quality {
+	case QualityNormal:
+		iterations = 1
+	case QualityBest:
+		iterations = 4
+	}
+	for iter := 0; iter < iterations && bestErr > 0; iter++ {
+		refined, ok := refineBC7Endpoints(pixels, &bestIndices)
+		if !ok {
+			break
+		}
+		quantized, indices, e := quantizeBC7Mode6(pixels, refined)
+		if e >= bestErr {
+			break
+		}
+		bestQuantized, bestIndices, bestErr = quantized, indices, e
+	}
+	writeBC7Mode6Block(dst, bestQuantized, &bestIndices)
+}
+
+// quantizeBC7Mode6 尝试四种 P 位组合量化端点，返回误差最小的 8 位端点、索引和平方误差
+// quantizeBC7Mode6 quantizes the endpoints under all four P-bit combinations and returns the 8-bit endpoints, indices, and squared error with the smallest error
+func quantizeBC7Mode6(pixels *[16][4]uint8, endpoints bc7Endpoints) ([2][4]int, [16]int, int) {
+	var bestQuantized [2][4]int
+	var bestIndices [16]int
+	bestErr := math.MaxInt
+	for pBits := 0; pBits < 4; pBits++ {
+		var quantized [2][4]int
+		for e := 0; e < 2; e++ {
+			p := (pBits >> e) & 1
+			for ch := 0; ch < 4; ch++ {
+				q := int(math.Round((endpoints[e][ch] - float64(p)) / 2))
+				quantized[e][ch] = max(0, min(127, q))<<1 | p
+			}
+		}
+		indices, e := assignBC7Indices(pixels, quantized)
+		if e < bestErr {
+			bestQuantized, bestIndices, bestErr = quantized, indices, e
+		}
+	}
+	return bestQuantized, bestIndices, bestErr
+}
+
+// assignBC7Indices 为每个像素选择最近的 4 位插值项并返回平方误差
+// assignBC7Indices selects the nearest 4-bit interpolation entry for each pixel and returns the squared error
+func assignBC7Indices(pixels *[16][4]uint8, quantized [2][4]int) ([16]int, int) {
+	var palette [16][4]int
+	for i, weight := range bptcWeights[4] {
+		for ch := 0; ch < 4; ch++ {
+			palette[i][ch] = bptcInterpolate(quantized[0][ch], quantized[1][ch], weight)
+		}
+	}
+	var indices [16]int
+	total := 0
+	for i, pixel := range pixels {
+		bestIndex, bestErr := 0, math.MaxInt
+		for j, entry := range palette {
+			e := 0
+			for ch := 0; ch < 4; ch++ {
+				d := int(pixel[ch]) - entry[ch]
+				e += d * d
+			}
+			if e < bestErr {
+				bestIndex, bestErr = j, e
+			}
+		}
+		indices[i] = bestIndex
+		total += bestErr
+	}
+	return indices, total
+}
+
+// refineBC7Endpoints 固定索引后按最小二乘求解使插值误差最小的端点
+// refineBC7Endpoints solves the least-squares endpoints that minimize interpolation error for fixed indices
+func refineBC7Endpoints(pixels *[16][4]uint8, indices *[16]int) (bc7Endpoints, bool) {
+	var a, b, c float64
+	var x0, x1 [4]float64
+	for i, pixel := range pixels {
+		t := float64(bptcWeights[4][indices[i]]) / 64
+		s := 1 - t
+		a += s * s
+		b += s * t
+		c += t * t
+		for ch := 0; ch < 4; ch++ {
+			x0[ch] += s * float64(pixel[ch])
+			x1[ch] += t * float64(pixel[ch])
+		}
+	}
+	det := a*c - b*b
+	if math.Abs(det) < 1e-9 {
+		return bc7Endpoints{}, false
+	}
+	var out bc7Endpoints
+	for ch := 0; ch < 4; ch++ {
+		out[0][ch] = clamp255((c*x0[ch] - b*x1[ch]) / det)
+		out[1][ch] = clamp255((a*x1[ch] - b*x0[ch]) / det)
+	}
+	return out, true
+}
+
+// writeBC7Mode6Block 写出模式 6 块，锚点像素索引最高位为 1 时交换端点并反转索引，使锚点可省略该位
+// writeBC7Mode6Block writes a mode 6 block, swapping endpoints and inverting indices when the anchor pixel's index has its high bit set so the anchor can omit that bit
+func writeBC7Mode6Block(dst []byte, quantized [2][4]int, indices *[16]int) {
+	if indices[0]&8 != 0 {
+		quantized[0], quantized[1] = quantized[1], quantized[0]
+		for i := range indices {
+			indices[i] = 15 - indices[i]
+		}
+	}
+	var bits blockBits
+	bits.write(1<<6, 7)
+	for ch := 0; ch < 4; ch++ {
+		bits.write(quantized[0][ch]>>1, 7)
+		bits.write(quantized[1][ch]>>1, 7)
+	}
+	bits.write(quantized[0][0]&1, 1)
+	bits.write(quantized[1][0]&1, 1)
+	bits.write(indices[0], 3)
+	for i := 1; i < 16; i++ {
+		bits.write(indices[i], 4)
+	}
+	bits.store(dst)
+}
+
+// principalEndpoints4 沿 RGBA 协方差主轴投影像素并返回投影范围两端的颜色
+// principalEndpoints4 projects pixels onto the principal axis of their RGBA covariance and returns the colors at both ends of the projected range
+func principalEndpoints4(pixels *[16][4]uint8) bc7Endpoints {
+	var mean [4]float64
+	for _, p := range pixels {
+		for ch := 0; ch < 4; ch++ {
+			mean[ch] += float64(p[ch])
+		}
+	}
+	for ch := 0; ch < 4; ch++ {
+		mean[ch] /= 16
+	}
+
+	var cov [4][4]float64
+	for _, p := range pixels {
+		var d [4]float64
+		for ch := 0; ch < 4; ch++ {
+			d[ch] = float64(p[ch]) - mean[ch]
+		}
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				cov[r][c] += d[r] * d[c]
+			}
+		}
+	}
+
+	// 以最大方差通道为初值做幂迭代求主轴
+	// Power iteration seeded with the highest-variance channel finds the principal axis
+	var axis [4]float64
+	seed := 0
+	for ch := 1; ch < 4; ch++ {
+		if cov[ch][ch] > cov[seed][seed] {
+			seed = ch
+		}
+	}
+	axis[seed] = 1
+	for iter := 0; iter < 8; iter++ {
+		var next [4]float64
+		length := 0.0
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				next[r] += cov[r][c] * axis[c]
+			}
+			length += next[r] * next[r]
+		}
+		length = math.Sqrt(length)
+		if length < 1e-9 {
+			break
+		}
+		for ch := 0; ch < 4; ch++ {
+			axis[ch] = next[ch] / length
+		}
+	}
+
+	minT, maxT := math.Inf(1), math.Inf(-1)
+	for _, p := range pixels {
+		t := 0.0
+		for ch := 0; ch < 4; ch++ {
+			t += (float64(p[ch]) - mean[ch]) * axis[ch]
+		}
+		minT = math.Min(minT, t)
+		maxT = math.Max(maxT, t)
+	}
+	var out bc7Endpoints
+	for ch := 0; ch < 4; ch++ {
+		out[0][ch] = clamp255(mean[ch] + axis[ch]*minT)
+		out[1][ch] = clamp255(mean[ch] + axis[ch]*maxT)
+	}
+	return out
+}
@@ -0,0 +1,307 @@
+// Package bcn 实现 DirectX 与 Unity 使用的 4x4 块压缩纹理格式的纯 Go 编解码
+// 本包只处理按 DirectX 约定自上而下排列的块数据，Unity 与 COM3D2 的自下而上块顺序由调用方负责翻转，因此同一编解码器可以同时服务 DDS 导出和游戏载荷
+// Package bcn implements pure-Go encoding and decoding of the 4x4 block-compressed texture formats used by DirectX and Unity
+// This package only handles block data laid out top-down under the DirectX convention; callers flip the bottom-up block order used by Unity and COM3D2, so the same codec serves both DDS export and game payloads
+package bcn
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// Format 表示一种块压缩格式 / Format identifies a block-compression format
+type Format int
+
+const (
+	// FormatDXT1 即 BC1，每块 8 字节，RGB565 端点加 2 位索引，支持 1 位透明
+	// FormatDXT1 is BC1, using 8 bytes per block with RGB565 endpoints, 2-bit indices, and optional 1-bit alpha
+	FormatDXT1 Format = iota + 1
+	// FormatDXT5 即 BC3，每块 16 字节，插值 Alpha 块加 BC1 颜色块
+	// FormatDXT5 is BC3, using 16 bytes per block with an interpolated alpha block followed by a BC1 color block
+	FormatDXT5
+	// FormatBC4 每块 8 字节，单个插值通道，Unity 用于单通道遮罩
+	// FormatBC4 uses 8 bytes per block with one interpolated channel, used by Unity for single-channel masks
+	FormatBC4
+	// FormatBC5 每块 16 字节，两个插值通道，Unity 用于法线贴图
+	// FormatBC5 uses 16 bytes per block with two interpolated channels, used by Unity for normal maps
+	FormatBC5
+	// FormatBC6H 每块 16 字节，存放无符号半精度浮点 HDR 颜色
+	// FormatBC6H uses 16 bytes per block to store unsigned half-float HDR color
+	FormatBC6H
+	// FormatBC7 每块 16 字节，8 种模式的高质量 RGBA
+	// FormatBC7 uses 16 bytes per block for high-quality RGBA across 8 modes
+	FormatBC7
+)
+
+// String 返回格式的常用名称 / String returns the common name of the format
+func (f Format) String() string {
+	switch f {
+	case FormatDXT1:
+		return "DXT1"
+	case FormatDXT5:
+		return "DXT5"
+	case FormatBC4:
+		return "BC4"
+	case FormatBC5:
+		return "BC5"
+	case FormatBC6H:
+		return "BC6H"
+	case FormatBC7:
+		return "BC7"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// BlockSize 返回每个 4x4 块的字节数，未知格式返回 0
+// BlockSize returns the number of bytes in each 4x4 block, or 0 for unknown formats
+func (f Format) BlockSize() int {
+	switch f {
+	case FormatDXT1, FormatBC4:
+		return 8
+	case FormatDXT5, FormatBC5, FormatBC6H, FormatBC7:
+		return 16
+	default:
+		return 0
+	}
+}
+
+// EncodedSize 返回指定尺寸单个 mip 层级的块数据字节数
+// EncodedSize returns the byte length of block data for one mip level of the given dimensions
+func EncodedSize(f Format, width, height int) (int64, error) {
+	blockSize := f.BlockSize()
+	if blockSize == 0 {
+		return 0, fmt.Errorf("unsupported block-compression format %s", f)
+	}
+	if width <= 0 || height <= 0 || width > math.MaxInt32 || height > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid %s dimensions: %dx%d", f, width, height)
+	}
+	blocksWide := (int64(width) + 3) / 4
+	blocksHigh := (int64(height) + 3) / 4
+	return blocksWide * blocksHigh * int64(blockSize), nil
+}
+
+// Quality 控制编码器在速度与误差之间的取舍 / Quality controls the encoder's trade-off between speed and error
+type Quality int
+
+const (
+	// QualityFast 只使用主轴端点，不做迭代优化
+	// QualityFast uses principal-axis endpoints without iterative refinement
+	QualityFast Quality = iota
+	// QualityNormal 在主轴端点基础上做一次最小二乘优化，并尝试 Alpha 的 6 级插值模式
+	// QualityNormal adds one least-squares refinement over principal-axis endpoints and tries the 6-step alpha mode
+	QualityNormal
+	// QualityBest 反复优化端点，同时比较 DXT1 的 3 色与 4 色模式并搜索 Alpha 端点邻域
+	// QualityBest iterates endpoint refinement, compares DXT1 3-color and 4-color modes, and searches the alpha endpoint neighborhood
+	QualityBest
+)
+
+// String 返回质量档位名称 / String returns the quality level name
+func (q Quality) String() string {
+	switch q {
+	case QualityFast:
+		return "fast"
+	case QualityNormal:
+		return "normal"
+	case QualityBest:
+		return "best"
+	default:
+		return fmt.Sprintf("Quality(%d)", int(q))
+	}
+}
+
+// ParseQuality 不区分大小写地解析 fast、normal 或 best，空字符串视为 normal
+// ParseQuality parses fast, normal, or best case-insensitively and treats an empty string as normal
+func ParseQuality(s string) (Quality, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "fast":
+		return QualityFast, nil
+	case "", "normal":
+		return QualityNormal, nil
+	case "best", "high":
+		return QualityBest, nil
+	default:
+		return QualityNormal, fmt.Errorf("unknown block-compression quality %q, expected fast, normal, or best", s)
+	}
+}
+
+// EncodeOptions 控制块压缩编码行为 / EncodeOptions controls block-compression encoding
+type EncodeOptions struct {
+	Quality Quality // 编码质量档位 / Encoding quality level
+}
+
+// Decode 将自上而下排列的块数据解码为非预乘 RGBA 图像
+// 数据长度必须与 EncodedSize 一致，宽高不是 4 的倍数时丢弃边缘块中超出范围的像素
+// BC4 输出灰度，BC5 按法线重建蓝色通道，BC6H 经色调映射后输出 sRGB，需要原始 HDR 数据时使用 DecodeHalfFloat
+// Decode decodes top-down block data into a non-premultiplied RGBA image
+// The data length must match EncodedSize, and pixels of edge blocks beyond a width or height that is not a multiple of 4 are discarded
+// BC4 decodes to grayscale, BC5 reconstructs blue as the normal Z, and BC6H is tone-mapped to sRGB; use DecodeHalfFloat for the raw HDR values
+func Decode(f Format, data []byte, width, height int) (*image.NRGBA, error) {
+	expected, err := EncodedSize(f, width, height)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) < expected {
+		return nil, fmt.Errorf("%s data too short: got %d bytes, want %d for %dx%d", f, len(data), expected, width, height)
+	}
+
+	var decodeBlock func(block []byte, out *[16][4]uint8)
+	switch f {
+	case FormatDXT1:
+		decodeBlock = func(block []byte, out *[16][4]uint8) {
+			decodeColorBlock(block, out, false)
+		}
+	case FormatDXT5:
+		decodeBlock = func(block []byte, out *[16][4]uint8) {
+			decodeColorBlock(block[8:], out, true)
+			var alpha [16]uint8
+			decodeAlphaBlock(block[:8], &alpha)
+			for i := range out {
+				out[i][3] = alpha[i]
+			}
+		}
+	case FormatBC4:
+		decodeBlock = decodeBC4Block
+	case FormatBC5:
+		decodeBlock = decodeBC5Block
+	case FormatBC6H:
+		decodeBlock = decodeBC6HToneMapped
+	case FormatBC7:
+		decodeBlock = decodeBC7Block
+	default:
+		return nil, fmt.Errorf("unsupported block-compression format %s", f)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	blockSize := f.BlockSize()
+	blocksWide := (width + 3) / 4
+	blocksHigh := (height + 3) / 4
+	var pixels [16][4]uint8
+	offset := 0
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			decodeBlock(data[offset:offset+blockSize], &pixels)
+			offset += blockSize
+			storeBlock(img, bx*4, by*4, &pixels)
+		}
+	}
+	return img, nil
+}
+
+// Encode 将图像编码为自上而下排列的块数据，opts 为 nil 时使用 QualityNormal
+// DXT1 遇到 Alpha 小于 128 的像素时使用 3 色模式的透明索引，DXT5 保留完整 Alpha，BC4 只保留红色，BC5 只保留红绿两通道，BC7 使用模式 6，BC6H 不支持编码
+// Encode encodes an image into top-down block data, using QualityNormal when opts is nil
+// DXT1 maps pixels with alpha below 128 to the transparent index of 3-color mode, DXT5 keeps full alpha, BC4 keeps only red, BC5 keeps only red and green, BC7 uses mode 6, and BC6H is not supported for encoding
+func Encode(f Format, img image.Image, opts *EncodeOptions) ([]byte, error) {
+	if img == nil {
+		return nil, fmt.Errorf("nil image")
+	}
+	quality := QualityNormal
+	if opts != nil {
+		quality = opts.Quality
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	size, err := EncodedSize(f, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	src := toNRGBA(img)
+	out := make([]byte, size)
+	blockSize := f.BlockSize()
+	blocksWide := (width + 3) / 4
+	blocksHigh := (height + 3) / 4
+	var pixels [16][4]uint8
+	offset := 0
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			loadBlock(src, bx*4, by*4, &pixels)
+			block := out[offset : offset+blockSize]
+			switch f {
+			case FormatDXT1:
+				encodeColorBlock(block, &pixels, true, quality)
+			case FormatDXT5:
+				var alpha [16]uint8
+				for i := range pixels {
+					alpha[i] = pixels[i][3]
+				}
+				encodeAlphaBlock(block[:8], &alpha, quality)
+				encodeColorBlock(block[8:], &pixels, false, quality)
+			case FormatBC4:
+				encodeBC4Block(block, &pixels, quality)
+			case FormatBC5:
+				encodeBC5Block(block, &pixels, quality)
+			case FormatBC7:
+				encodeBC7Block(block, &pixels, quality)
+			default:
+				return nil, fmt.Errorf("unsupported block-compression format %s", f)
+			}
+			offset += blockSize
+		}
+	}
+	return out, nil
+}
+
+// HasTransparency 判断图像是否包含任何非完全不透明的像素
+// HasTransparency reports whether an image contains any pixel that is not fully opaque
+func HasTransparency(img image.Image) bool {
+	if img == nil {
+		return false
+	}
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return !opaque.Opaque()
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toNRGBA 返回以原点为起点的非预乘 RGBA 图像，已是该类型时直接复用
+// toNRGBA returns a non-premultiplied RGBA image anchored at the origin, reusing the input when it already has that form
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Rect, img, bounds.Min, draw.Src)
+	return out
+}
+
+// loadBlock 读取一个 4x4 块，超出图像的像素重复最近的边缘像素以免污染端点
+// loadBlock reads one 4x4 block, repeating the nearest edge pixel beyond the image so padding does not skew endpoints
+func loadBlock(img *image.NRGBA, x0, y0 int, out *[16][4]uint8) {
+	maxX := img.Rect.Dx() - 1
+	maxY := img.Rect.Dy() - 1
+	for y := 0; y < 4; y++ {
+		sy := min(y0+y, maxY)
+		for x := 0; x < 4; x++ {
+			sx := min(x0+x, maxX)
+			p := img.PixOffset(sx, sy)
+			copy(out[y*4+x][:], img.Pix[p:p+4])
+		}
+	}
+}
+
+// storeBlock 写回一个 4x4 块中位于图像范围内的像素
+// storeBlock writes back the pixels of one 4x4 block that fall inside the image
+func storeBlock(img *image.NRGBA, x0, y0 int, pixels *[16][4]uint8) {
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+	for y := 0; y < 4 && y0+y < height; y++ {
+		for x := 0; x < 4 && x0+x < width; x++ {
+			p := img.PixOffset(x0+x, y0+y)
+			copy(img.Pix[p:p+4], pixels[y*4+x][:])
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package bcn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func gradientImage(width, height int, withAlpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			a := uint8(255)
+			if withAlpha {
+				a = uint8((x * 255) / max(width-1, 1))
+			}
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8((x * 255) / max(width-1, 1)),
+				G: uint8((y * 255) / max(height-1, 1)),
+				B: uint8(((x + y) * 127) / max(width+height-2, 1)),
+				A: a,
+			})
+		}
+	}
+	return img
+}
+
+func meanSquaredError(a, b *image.NRGBA, channels int) float64 {
+	total := 0.0
+	count := 0
+	for i := 0; i+3 < len(a.Pix); i += 4 {
+		for ch := 0; ch < channels; ch++ {
+			d := float64(a.Pix[i+ch]) - float64(b.Pix[i+ch])
+			total += d * d
+			count++
+		}
+	}
+	return total / float64(count)
+}
+
+func TestDecodeDXT1KnownBlock(t *testing.T) {
+	block := make([]byte, 8)
+	// 纯红与纯蓝端点，4 色模式 / Pure red and pure blue endpoints in 4-color mode
+	binary.LittleEndian.PutUint16(block[0:2], 0xF800)
+	binary.LittleEndian.PutUint16(block[2:4], 0x001F)
+	// 第一行依次使用索引 0、1、2、3 / The first row uses indices 0, 1, 2, 3 in order
+	binary.LittleEndian.PutUint32(block[4:8], 0b11100100)
+
+	img, err := Decode(FormatDXT1, block, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []color.NRGBA{
+		{255, 0, 0, 255},
+		{0, 0, 255, 255},
+		{170, 0, 85, 255},
+		{85, 0, 170, 255},
+	}
+	for x, w := range want {
+		if got := img.NRGBAAt(x, 0); got != w {
+			t.Fatalf("pixel %d = %v, want %v", x, got, w)
+		}
+	}
+	if got := img.NRGBAAt(0, 3); got != want[0] {
+		t.Fatalf("pixel (0,3) = %v, want %v", got, want[0])
+	}
+}
+
+func TestDecodeDXT1TransparentIndex(t *testing.T) {
+	block := make([]byte, 8)
+	binary.LittleEndian.PutUint16(block[0:2], 0x001F)
+	binary.LittleEndian.PutUint16(block[2:4], 0xF800)
+	binary.LittleEndian.PutUint32(block[4:8], 0xffffffff)
+
+	img, err := Decode(FormatDXT1, block, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got := img.NRGBAAt(1, 1); got != (color.NRGBA{}) {
+		t.Fatalf("3-color index 3 = %v, want transparent black", got)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		alpha    bool
+		channels int
+		maxMSE   float64
+	}{
+		{name: "dxt1", format: FormatDXT1, channels: 3, maxMSE: 30},
+		{name: "dxt5", format: FormatDXT5, alpha: true, channels: 4, maxMSE: 30},
+		{name: "bc4", format: FormatBC4, channels: 1, maxMSE: 4},
+		{name: "bc5", format: FormatBC5, channels: 2, maxMSE: 4},
+		{name: "bc7", format: FormatBC7, alpha: true, channels: 4, maxMSE: 30},
+	}
+	for _, tc := range tests {
+		for _, quality := range []Quality{QualityFast, QualityNormal, QualityBest} {
+			t.Run(tc.name+"_"+quality.String(), func(t *testing.T) {
+				src := gradientImage(37, 21, tc.alpha)
+				data, err := Encode(tc.format, src, &EncodeOptions{Quality: quality})
+				if err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+				size, _ := EncodedSize(tc.format, 37, 21)
+				if int64(len(data)) != size {
+					t.Fatalf("encoded size = %d, want %d", len(data), size)
+				}
+				decoded, err := Decode(tc.format, data, 37, 21)
+				if err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				if mse := meanSquaredError(src, decoded, tc.channels); mse > tc.maxMSE {
+					t.Fatalf("mean squared error %.2f exceeds %.2f", mse, tc.maxMSE)
+				}
+			})
+		}
+	}
+}
+
+func TestEncodeQualityDoesNotIncreaseError(t *testing.T) {
+	src := gradientImage(64, 64, true)
+	errors := make([]float64, 0, 3)
+	for _, quality := range []Quality{QualityFast, QualityNormal, QualityBest} {
+		data, err := Encode(FormatDXT5, src, &EncodeOptions{Quality: quality})
+		if err != nil {
+			t.Fatalf("Encode %s: %v", quality, err)
+		}
+		decoded, err := Decode(FormatDXT5, data, 64, 64)
+		if err != nil {
+			t.Fatalf("Decode %s: %v", quality, err)
+		}
+		errors = append(errors, meanSquaredError(src, decoded, 4))
+	}
+	if errors[1] > errors[0] || errors[2] > errors[1] {
+		t.Fatalf("error should not grow with quality: %v", errors)
+	}
+}
+
+func TestEncodeDXT1PunchThroughAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < 16; i++ {
+		a := uint8(255)
+		if i%2 == 0 {
+			a = 0
+		}
+		src.SetNRGBA(i%4, i/4, color.NRGBA{R: 200, G: 40, B: 10, A: a})
+	}
+	data, err := Encode(FormatDXT1, src, nil)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if binary.LittleEndian.Uint16(data[0:2]) > binary.LittleEndian.Uint16(data[2:4]) {
+		t.Fatalf("punch-through block must use 3-color mode")
+	}
+	decoded, err := Decode(FormatDXT1, data, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		got := decoded.NRGBAAt(i%4, i/4).A
+		if (i%2 == 0) != (got == 0) {
+			t.Fatalf("pixel %d alpha = %d", i, got)
+		}
+	}
+}
+
+func TestEncodeSolidBlockIsExact(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 128})
+		}
+	}
+	data, err := Encode(FormatDXT5, src, &EncodeOptions{Quality: QualityFast})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	decoded, err := Decode(FormatDXT5, data, 8, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Equal(decoded.Pix, src.Pix) {
+		t.Fatalf("solid block did not round-trip exactly")
+	}
+}
+
+func TestDecodeRejectsShortData(t *testing.T) {
+	if _, err := Decode(FormatDXT5, make([]byte, 15), 4, 4); err == nil {
+		t.Fatalf("expected short DXT5 data to fail")
+	}
+	if _, err := Decode(FormatDXT1, make([]byte, 8), 0, 4); err == nil {
+		t.Fatalf("expected zero width to fail")
+	}
+}
+
+func TestReadDDS(t *testing.T) {
+	payload := make([]byte, 16*4)
+	header := make([]byte, ddsHeaderSize)
+	copy(header, "DDS ")
+	binary.LittleEndian.PutUint32(header[4:8], 124)
+	binary.LittleEndian.PutUint32(header[12:16], 8)
+	binary.LittleEndian.PutUint32(header[16:20], 8)
+	binary.LittleEndian.PutUint32(header[ddsPixelFormatOff+4:ddsPixelFormatOff+8], ddsPixelFourCCFlag)
+	copy(header[ddsPixelFormatOff+8:ddsPixelFormatOff+12], "DXT5")
+
+	dds, err := ReadDDS(append(header, payload...))
+	if err != nil {
+		t.Fatalf("ReadDDS: %v", err)
+	}
+	if dds.Format != FormatDXT5 || dds.Width != 8 || dds.Height != 8 || dds.MipCount != 1 {
+		t.Fatalf("unexpected DDS: %+v", dds)
+	}
+	if len(dds.FirstLevel()) != len(payload) {
+		t.Fatalf("first level length = %d, want %d", len(dds.FirstLevel()), len(payload))
+	}
+
+	if _, err := ReadDDS(append(header, payload[:10]...)); err == nil {
+		t.Fatalf("expected truncated DDS payload to fail")
+	}
+}
+
+func TestParseQuality(t *testing.T) {
+	for input, want := range map[string]Quality{"": QualityNormal, "FAST": QualityFast, "best": QualityBest} {
+		got, err := ParseQuality(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseQuality(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseQuality("ultra"); err == nil {
+		t.Fatalf("expected unknown quality to fail")
+	}
+}
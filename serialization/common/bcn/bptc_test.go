@@ -0,0 +1,334 @@
+package bcn
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// testBlockWriter 按最低有效位优先的顺序拼装 128 位测试块 / testBlockWriter assembles a 128-bit test block least-significant bit first
+type testBlockWriter struct {
+	lo, hi uint64
+	pos    uint
+}
+
+func (w *testBlockWriter) write(value, n int) {
+	for i := 0; i < n; i++ {
+		if value>>i&1 != 0 {
+			if w.pos < 64 {
+				w.lo |= 1 << w.pos
+			} else {
+				w.hi |= 1 << (w.pos - 64)
+			}
+		}
+		w.pos++
+	}
+}
+
+func (w *testBlockWriter) bytes() []byte {
+	block := make([]byte, 16)
+	binary.LittleEndian.PutUint64(block[0:8], w.lo)
+	binary.LittleEndian.PutUint64(block[8:16], w.hi)
+	return block
+}
+
+func TestDecodeBC4AndBC5(t *testing.T) {
+	bc4 := make([]byte, 8)
+	bc4[0], bc4[1] = 200, 40
+	img, err := Decode(FormatBC4, bc4, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode BC4: %v", err)
+	}
+	if got := img.NRGBAAt(2, 2); got != (color.NRGBA{200, 200, 200, 255}) {
+		t.Fatalf("BC4 pixel = %v, want gray 200", got)
+	}
+
+	// 红绿两个通道均为中值时法线指向 +Z / A normal with mid-range red and green points along +Z
+	bc5 := make([]byte, 16)
+	bc5[0], bc5[1] = 128, 128
+	bc5[8], bc5[9] = 128, 128
+	img, err = Decode(FormatBC5, bc5, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode BC5: %v", err)
+	}
+	if got := img.NRGBAAt(1, 3); got.R != 128 || got.G != 128 || got.B != 255 || got.A != 255 {
+		t.Fatalf("BC5 pixel = %v, want reconstructed +Z normal", got)
+	}
+}
+
+func TestDecodeBC7Mode6(t *testing.T) {
+	var w testBlockWriter
+	w.write(1<<6, 7)
+	// 端点 0 为白色、端点 1 为黑色，两个端点的 Alpha 都是满值
+	// Endpoint 0 is white and endpoint 1 is black, with full alpha on both endpoints
+	for ch := 0; ch < 3; ch++ {
+		w.write(127, 7)
+		w.write(0, 7)
+	}
+	w.write(127, 7)
+	w.write(127, 7)
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(0, 3)
+	w.write(8, 4)
+	w.write(15, 4)
+	img, err := Decode(FormatBC7, w.bytes(), 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []color.NRGBA{{255, 255, 255, 255}, {120, 120, 120, 254}, {0, 0, 0, 254}, {255, 255, 255, 255}}
+	for x, wantPixel := range want {
+		if got := img.NRGBAAt(x, 0); got != wantPixel {
+			t.Fatalf("pixel %d = %v, want %v", x, got, wantPixel)
+		}
+	}
+}
+
+func TestDecodeBC7Mode1UsesPartitionAndSharedPBits(t *testing.T) {
+	var w testBlockWriter
+	w.write(1<<1, 2)
+	w.write(0, 6)
+	// 子集 0 为红色，子集 1 为蓝色，共享 P 位同时抬高零值通道的最低位
+	// Subset 0 is red and subset 1 is blue, and the shared P-bit also raises the lowest bit of the zero channels
+	endpoints := [3][4]int{{63, 63, 0, 0}, {0, 0, 0, 0}, {0, 0, 63, 63}}
+	for ch := 0; ch < 3; ch++ {
+		for _, v := range endpoints[ch] {
+			w.write(v, 6)
+		}
+	}
+	w.write(1, 1)
+	w.write(1, 1)
+	img, err := Decode(FormatBC7, w.bytes(), 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got := img.NRGBAAt(0, 0); got != (color.NRGBA{255, 2, 2, 255}) {
+		t.Fatalf("subset 0 pixel = %v, want red", got)
+	}
+	if got := img.NRGBAAt(3, 2); got != (color.NRGBA{2, 2, 255, 255}) {
+		t.Fatalf("subset 1 pixel = %v, want blue", got)
+	}
+}
+
+func TestDecodeBC7ReservedModeIsTransparentBlack(t *testing.T) {
+	img, err := Decode(FormatBC7, make([]byte, 16), 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got := img.NRGBAAt(0, 0); got != (color.NRGBA{}) {
+		t.Fatalf("reserved mode pixel = %v, want transparent black", got)
+	}
+}
+
+func TestBPTCAnchorsBelongToTheirSubsets(t *testing.T) {
+	for p := 0; p < 64; p++ {
+		if bptcSubset(2, p, int(bptcAnchors2[p])) != 1 {
+			t.Fatalf("two-subset partition %d anchor is not in subset 1", p)
+		}
+		for s := 0; s < 2; s++ {
+			if bptcSubset(3, p, int(bptcAnchors3[s][p])) != s+1 {
+				t.Fatalf("three-subset partition %d anchor %d is not in subset %d", p, s, s+1)
+			}
+		}
+	}
+}
+
+func TestBC6HModeLayoutsCoverEveryEndpointBit(t *testing.T) {
+	for _, mode := range bc6hModes {
+		var seen [12]int
+		total := 0
+		for _, s := range mode.layout {
+			step := 1
+			if s.first > s.last {
+				step = -1
+			}
+			for b := s.first; ; b += step {
+				if seen[s.field]&(1<<b) != 0 {
+					t.Fatalf("mode %d repeats bit %d of field %d", mode.id, b, s.field)
+				}
+				seen[s.field] |= 1 << b
+				total++
+				if b == s.last {
+					break
+				}
+			}
+		}
+		modeBits := 5
+		if mode.id < 2 {
+			modeBits = 2
+		}
+		wantTotal := 65
+		if mode.regions == 2 {
+			wantTotal = 77
+		}
+		if total+modeBits != wantTotal {
+			t.Fatalf("mode %d header has %d bits, want %d", mode.id, total+modeBits, wantTotal)
+		}
+		for field := 0; field < mode.regions*6; field++ {
+			width := mode.endpointBits
+			if field >= 3 {
+				width = mode.deltaBits[field%3]
+			}
+			if seen[field] != 1<<width-1 {
+				t.Fatalf("mode %d field %d covers bits %b, want %d bits", mode.id, field, seen[field], width)
+			}
+		}
+	}
+}
+
+func TestDecodeBC6HDirectMode(t *testing.T) {
+	var w testBlockWriter
+	w.write(3, 5)
+	// 端点 0 为 0，端点 1 为最大值 / Endpoint 0 is zero and endpoint 1 is the maximum
+	for ch := 0; ch < 3; ch++ {
+		w.write(0, 10)
+	}
+	for ch := 0; ch < 3; ch++ {
+		w.write(1023, 10)
+	}
+	w.write(0, 3)
+	w.write(15, 4)
+	data := w.bytes()
+
+	halves, err := DecodeHalfFloat(FormatBC6H, data, 4, 4)
+	if err != nil {
+		t.Fatalf("DecodeHalfFloat: %v", err)
+	}
+	if halves[0] != 0 || halves[3] != 0x3C00 {
+		t.Fatalf("pixel 0 = %#x, want black with alpha 1.0", halves[0:4])
+	}
+	if halves[4] != 0x7BFF {
+		t.Fatalf("pixel 1 red = %#x, want the largest finite half 0x7BFF", halves[4])
+	}
+
+	img, err := Decode(FormatBC6H, data, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got := img.NRGBAAt(0, 0); got != (color.NRGBA{0, 0, 0, 255}) {
+		t.Fatalf("tone-mapped black = %v", got)
+	}
+	if got := img.NRGBAAt(1, 0); got != (color.NRGBA{255, 255, 255, 255}) {
+		t.Fatalf("tone-mapped maximum = %v, want white", got)
+	}
+}
+
+func TestDecodeBC6HTransformedModeWithReversedBits(t *testing.T) {
+	// 模式 15 的 16 位基准端点高 6 位逆序存放，0x7BE0 经缩放后恰为半精度 1.0
+	// Mode 15 stores the top 6 bits of its 16-bit base endpoint in reverse, and 0x7BE0 scales to exactly half-float 1.0
+	const base = 0x7BE0
+	reversedHigh := 0
+	for i := 0; i < 6; i++ {
+		reversedHigh |= (base >> (15 - i) & 1) << i
+	}
+	var w testBlockWriter
+	w.write(15, 5)
+	for ch := 0; ch < 3; ch++ {
+		w.write(base&0x3FF, 10)
+	}
+	for ch := 0; ch < 3; ch++ {
+		// 差值 -1 的 4 位补码 / The 4-bit two's complement of delta -1
+		w.write(0xF, 4)
+		w.write(reversedHigh, 6)
+	}
+	halves, err := DecodeHalfFloat(FormatBC6H, w.bytes(), 4, 4)
+	if err != nil {
+		t.Fatalf("DecodeHalfFloat: %v", err)
+	}
+	for ch := 0; ch < 3; ch++ {
+		if got := HalfToFloat32(halves[ch]); got != 1 {
+			t.Fatalf("channel %d = %v (%#x), want 1.0", ch, got, halves[ch])
+		}
+	}
+}
+
+func TestHalfToFloat32(t *testing.T) {
+	for h, want := range map[uint16]float32{
+		0x0000: 0,
+		0x3C00: 1,
+		0xC000: -2,
+		0x3555: 0.333251953125,
+		0x0001: 5.960464477539063e-08,
+		0x7BFF: 65504,
+	} {
+		if got := HalfToFloat32(h); got != want {
+			t.Fatalf("HalfToFloat32(%#x) = %v, want %v", h, got, want)
+		}
+	}
+	if !math.IsInf(float64(HalfToFloat32(0x7C00)), 1) {
+		t.Fatalf("0x7C00 should decode to +Inf")
+	}
+	if _, err := DecodeHalfFloat(FormatBC7, make([]byte, 16), 4, 4); err == nil {
+		t.Fatalf("expected non-HDR format to fail")
+	}
+}
+
+func TestReadDDSDX10BC7(t *testing.T) {
+	header := make([]byte, ddsHeaderSize+ddsDX10HeaderSize)
+	copy(header, "DDS ")
+	binary.LittleEndian.PutUint32(header[4:8], 124)
+	binary.LittleEndian.PutUint32(header[12:16], 4)
+	binary.LittleEndian.PutUint32(header[16:20], 4)
+	binary.LittleEndian.PutUint32(header[ddsPixelFormatOff+4:ddsPixelFormatOff+8], ddsPixelFourCCFlag)
+	copy(header[ddsPixelFormatOff+8:ddsPixelFormatOff+12], "DX10")
+	binary.LittleEndian.PutUint32(header[ddsHeaderSize:ddsHeaderSize+4], 98)
+
+	dds, err := ReadDDS(append(header, make([]byte, 16)...))
+	if err != nil {
+		t.Fatalf("ReadDDS: %v", err)
+	}
+	if dds.Format != FormatBC7 || len(dds.FirstLevel()) != 16 {
+		t.Fatalf("unexpected DDS: %+v", dds)
+	}
+}
+
+func TestEncodeBC7WritesMode6WithClearAnchorBit(t *testing.T) {
+	src := gradientImage(8, 8, true)
+	data, err := Encode(FormatBC7, src, nil)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	for offset := 0; offset < len(data); offset += 16 {
+		bits := newBlockBits(data[offset : offset+16])
+		if mode := bits.read(7); mode != 1<<6 {
+			t.Fatalf("block at %d mode bits = %07b, want mode 6", offset, mode)
+		}
+		// 7 位模式、56 位端点和 2 位 P 位之后的首个索引只有 3 位，最高位隐含为 0
+		// After 7 mode bits, 56 endpoint bits, and 2 P-bits, the first index has only 3 bits with an implied zero high bit
+		bits.pos = 65
+		_ = bits.read(3)
+		if bits.pos != 68 {
+			t.Fatalf("anchor index ended at bit %d, want 68", bits.pos)
+		}
+	}
+
+	solid := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < len(solid.Pix); i += 4 {
+		copy(solid.Pix[i:i+4], []byte{10, 130, 250, 77})
+	}
+	data, err = Encode(FormatBC7, solid, &EncodeOptions{Quality: QualityBest})
+	if err != nil {
+		t.Fatalf("Encode solid: %v", err)
+	}
+	decoded, err := Decode(FormatBC7, data, 4, 4)
+	if err != nil {
+		t.Fatalf("Decode solid: %v", err)
+	}
+	// 模式 6 每个端点的四个通道共享一个 P 位，奇偶性不同的通道最多偏差 1
+	// Mode 6 shares one P-bit across all four channels of an endpoint, so channels of mixed parity may be off by at most 1
+	got := decoded.NRGBAAt(3, 3)
+	want := color.NRGBA{10, 130, 250, 77}
+	for ch, pair := range [][2]uint8{{got.R, want.R}, {got.G, want.G}, {got.B, want.B}, {got.A, want.A}} {
+		if d := int(pair[0]) - int(pair[1]); d < -1 || d > 1 {
+			t.Fatalf("solid BC7 channel %d = %d, want %d within 1", ch, pair[0], pair[1])
+		}
+	}
+}
+
+func TestEncodeRejectsBC6H(t *testing.T) {
+	if _, err := Encode(FormatBC6H, gradientImage(4, 4, false), nil); err == nil {
+		t.Fatal("Encode BC6H succeeded; HDR encoding is not supported")
+	}
+}
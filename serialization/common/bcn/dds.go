@@ -0,0 +1,116 @@
+package bcn
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DDS 是从 DDS 文件中解析出的块压缩纹理，Data 从第一个 mip 层级开始并包含文件中的全部后续层级
+// DDS is a block-compressed texture parsed from a DDS file, with Data starting at the first mip level and containing every following level in the file
+type DDS struct {
+	Format   Format // 块压缩格式 / Block-compression format
+	Width    int    // 第一个 mip 层级的宽度 / Width of the first mip level
+	Height   int    // 第一个 mip 层级的高度 / Height of the first mip level
+	MipCount int    // 文件声明的 mip 层级数，至少为 1 / Mip level count declared by the file, at least 1
+	Data     []byte // 自上而下排列的块数据 / Top-down block data
+}
+
+const (
+	ddsHeaderSize      = 128
+	ddsDX10HeaderSize  = 20
+	ddsPixelFormatOff  = 76
+	ddsPixelFourCCFlag = 0x4
+)
+
+// ReadDDS 解析 DDS 文件头并返回块压缩载荷，支持传统 FourCC 与 DX10 扩展头
+// ReadDDS parses a DDS file header and returns the block-compressed payload, supporting both legacy FourCC and DX10 extension headers
+func ReadDDS(data []byte) (*DDS, error) {
+	if len(data) < ddsHeaderSize || string(data[:4]) != "DDS " {
+		return nil, fmt.Errorf("not a DDS file")
+	}
+	le := binary.LittleEndian
+	if size := le.Uint32(data[4:8]); size != 124 {
+		return nil, fmt.Errorf("invalid DDS header size %d", size)
+	}
+	height := int(le.Uint32(data[12:16]))
+	width := int(le.Uint32(data[16:20]))
+	mipCount := max(int(le.Uint32(data[28:32])), 1)
+
+	pfFlags := le.Uint32(data[ddsPixelFormatOff+4 : ddsPixelFormatOff+8])
+	if pfFlags&ddsPixelFourCCFlag == 0 {
+		return nil, fmt.Errorf("DDS pixel format is not block-compressed")
+	}
+	fourCC := string(data[ddsPixelFormatOff+8 : ddsPixelFormatOff+12])
+	payloadOffset := ddsHeaderSize
+
+	var format Format
+	switch fourCC {
+	case "DXT1":
+		format = FormatDXT1
+	case "DXT5":
+		format = FormatDXT5
+	case "ATI1", "BC4U":
+		format = FormatBC4
+	case "ATI2", "BC5U":
+		format = FormatBC5
+	case "DX10":
+		if len(data) < ddsHeaderSize+ddsDX10HeaderSize {
+			return nil, fmt.Errorf("DDS DX10 header truncated")
+		}
+		dxgi := le.Uint32(data[ddsHeaderSize : ddsHeaderSize+4])
+		f, ok := formatFromDXGI(dxgi)
+		if !ok {
+			return nil, fmt.Errorf("unsupported DDS DXGI format %d", dxgi)
+		}
+		format = f
+		payloadOffset += ddsDX10HeaderSize
+	default:
+		return nil, fmt.Errorf("unsupported DDS FourCC %q", fourCC)
+	}
+
+	size, err := EncodedSize(format, width, height)
+	if err != nil {
+		return nil, err
+	}
+	payload := data[payloadOffset:]
+	if int64(len(payload)) < size {
+		return nil, fmt.Errorf("DDS payload too short: got %d bytes, want at least %d", len(payload), size)
+	}
+	return &DDS{
+		Format:   format,
+		Width:    width,
+		Height:   height,
+		MipCount: mipCount,
+		Data:     payload,
+	}, nil
+}
+
+// FirstLevel 返回第一个 mip 层级的块数据 / FirstLevel returns the block data of the first mip level
+func (d *DDS) FirstLevel() []byte {
+	size, err := EncodedSize(d.Format, d.Width, d.Height)
+	if err != nil || int64(len(d.Data)) < size {
+		return d.Data
+	}
+	return d.Data[:size]
+}
+
+// formatFromDXGI 将 DXGI 格式编号映射到块压缩格式，TYPELESS、UNORM 与 SRGB 变体共用同一编解码，有符号的 BC4、BC5 与 BC6H 变体不受支持
+// formatFromDXGI maps a DXGI format number to a block-compression format, with TYPELESS, UNORM, and SRGB variants sharing one codec; the signed BC4, BC5, and BC6H variants are not supported
+func formatFromDXGI(dxgi uint32) (Format, bool) {
+	switch dxgi {
+	case 70, 71, 72:
+		return FormatDXT1, true
+	case 76, 77, 78:
+		return FormatDXT5, true
+	case 79, 80:
+		return FormatBC4, true
+	case 82, 83:
+		return FormatBC5, true
+	case 94, 95:
+		return FormatBC6H, true
+	case 97, 98, 99:
+		return FormatBC7, true
+	default:
+		return 0, false
+	}
+}
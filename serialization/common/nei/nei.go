@@ -569,6 +569,41 @@ func encryptData(data []byte, key []byte, ivSeed []byte) ([]byte, error) {
 	return result, nil
 }
 
+// Probe 只检查 .nei 的尺寸不变量并解密第一个分组核对签名，不读取整个文件
+// key 传入 nil 则使用默认密钥
+// Probe checks only the size invariants of a .nei file and decrypts its first block to compare the signature, without reading the whole file
+// Passing nil as key selects the default key
+func Probe(r io.ReaderAt, size int64, key []byte) error {
+	if key == nil {
+		key = Key
+	}
+	dataLen := size - 5
+	if dataLen <= 0 || dataLen%aes.BlockSize != 0 {
+		return fmt.Errorf("invalid NEI size %d", size)
+	}
+	trailer := make([]byte, 5)
+	if _, err := r.ReadAt(trailer, dataLen); err != nil {
+		return fmt.Errorf("failed to read NEI trailer: %w", err)
+	}
+	ivSeed := trailer[1:]
+	if extraLen := int64(trailer[0] ^ ivSeed[0]); extraLen >= aes.BlockSize || extraLen > dataLen-int64(len(Signature)) {
+		return fmt.Errorf("invalid padding length (extraLen): %d", extraLen)
+	}
+	first := make([]byte, aes.BlockSize)
+	if _, err := r.ReadAt(first, 0); err != nil {
+		return fmt.Errorf("failed to read NEI header block: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+	cipher.NewCBCDecrypter(block, generateIV(ivSeed)).CryptBlocks(first, first)
+	if !bytes.HasPrefix(first, Signature) {
+		return fmt.Errorf("invalid NEI signature, want %v, got %v", Signature, first[:len(Signature)])
+	}
+	return nil
+}
+
 // decryptData 解密数据
 // decryptData decrypts data
 func decryptData(encryptedData []byte, key []byte) ([]byte, error) {
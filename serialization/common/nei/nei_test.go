@@ -212,3 +212,27 @@ func TestDumpRejectsInvalidCellsForTextEncoding(t *testing.T) {
 		t.Error("Dump accepted an unknown text encoding")
 	}
 }
+
+func TestProbeChecksSizeAndSignatureOnly(t *testing.T) {
+	wire := buildWire(t, 1, 1, [][]byte{[]byte("cell")})
+	if err := Probe(bytes.NewReader(wire), int64(len(wire)), nil); err != nil {
+		t.Fatalf("probe valid table: %v", err)
+	}
+
+	// 损坏第一个分组之后的密文只影响完整读取，探测仍然通过
+	// Corrupting ciphertext past the first block only breaks a full read, and the probe still passes
+	tail := append([]byte(nil), wire...)
+	tail[len(tail)-6] ^= 0xff
+	if err := Probe(bytes.NewReader(tail), int64(len(tail)), nil); err != nil {
+		t.Errorf("probe with a corrupt later block: %v", err)
+	}
+
+	header := append([]byte(nil), wire...)
+	header[0] ^= 0xff
+	if err := Probe(bytes.NewReader(header), int64(len(header)), nil); err == nil {
+		t.Error("probe accepted a corrupt signature block")
+	}
+	if err := Probe(bytes.NewReader(wire[:len(wire)-1]), int64(len(wire)-1), nil); err == nil {
+		t.Error("probe accepted a truncated table")
+	}
+}
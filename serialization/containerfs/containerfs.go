@@ -0,0 +1,442 @@
+// Package containerfs 提供把 ARC、CT、ABA 等容器暴露为只读 io/fs 文件系统的通用实现
+// Package containerfs provides the shared implementation exposing ARC, CT, ABA and similar containers as read-only io/fs file systems
+package containerfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry 描述容器中的一个文件或目录条目
+// Entry describes one file or directory entry of a container
+type Entry struct {
+	Path       string                        // 容器内使用正斜杠的相对路径 / Relative path inside the container with forward slashes
+	Dir        bool                          // 条目是否为目录，用于保留空目录 / Whether the entry is a directory, used to keep empty directories
+	RawSize    int64                         // 解压后的原始大小 / Raw size after decompression
+	StoredSize int64                         // 在容器中占用的存储大小 / Stored size occupied inside the container
+	Compressed bool                          // 存储数据是否经过压缩 / Whether the stored data is compressed
+	Open       func() (io.ReadCloser, error) // 打开原始内容流，返回值实现 io.ReaderAt 时用于随机访问 / Opens the raw content stream; a return value implementing io.ReaderAt is used for random access
+}
+
+// EntryInfo 是文件 fs.FileInfo.Sys 返回的容器存储信息
+// EntryInfo is the container storage information returned by a file's fs.FileInfo.Sys
+type EntryInfo struct {
+	RawSize    int64 // 解压后的原始大小，与 Size 相同 / Raw size after decompression, equal to Size
+	StoredSize int64 // 在容器中占用的存储大小 / Stored size occupied inside the container
+	Compressed bool  // 存储数据是否经过压缩 / Whether the stored data is compressed
+}
+
+// FS 是由条目列表构建的只读文件系统，实现 fs.FS、fs.ReadDirFS、fs.StatFS 和 fs.ReadFileFS
+// 文件内容在首次读取时才打开和解压，文件实现 io.Seeker 和 io.ReaderAt，可直接用于 http.FileServer
+// FS is a read-only file system built from an entry list, implementing fs.FS, fs.ReadDirFS, fs.StatFS and fs.ReadFileFS
+// File content is opened and decompressed on first read, and files implement io.Seeker and io.ReaderAt so they work with http.FileServer
+type FS struct {
+	nodes map[string]*node // 规范路径到节点的映射，根目录为 "." / Canonical path to node, with "." as the root
+}
+
+// node 是文件系统树中的一个文件或目录
+// node is one file or directory in the file system tree
+type node struct {
+	name     string   // 路径的最后一段 / Last path element
+	entry    *Entry   // 文件条目，目录为 nil / File entry, nil for directories
+	children []string // 目录的子节点完整路径，按名称排序 / Full paths of a directory's children, sorted by name
+}
+
+// New 由条目列表构建文件系统，缺失的父目录自动补全
+// 路径重复或文件与目录同名时返回错误
+// New builds a file system from an entry list, creating missing parent directories
+// It returns an error for duplicate paths or for a file and a directory sharing a name
+func New(entries []Entry) (*FS, error) {
+	fsys := &FS{nodes: map[string]*node{".": {name: "."}}}
+	for i := range entries {
+		e := &entries[i]
+		name := path.Clean(strings.TrimPrefix(e.Path, "/"))
+		if name == "." || !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid container path %q", e.Path)
+		}
+		if err := fsys.ensureDir(path.Dir(name)); err != nil {
+			return nil, err
+		}
+		if existing, ok := fsys.nodes[name]; ok {
+			if e.Dir && existing.entry == nil {
+				continue
+			}
+			return nil, fmt.Errorf("duplicate container path %q", e.Path)
+		}
+		n := &node{name: path.Base(name)}
+		if !e.Dir {
+			if e.Open == nil {
+				return nil, fmt.Errorf("container file %q has no content", e.Path)
+			}
+			n.entry = e
+		}
+		fsys.addChild(name, n)
+	}
+	for _, n := range fsys.nodes {
+		sort.Strings(n.children)
+	}
+	return fsys, nil
+}
+
+// ensureDir 确保目录及其全部父目录存在
+// ensureDir makes sure a directory and all of its parents exist
+func (fsys *FS) ensureDir(name string) error {
+	if n, ok := fsys.nodes[name]; ok {
+		if n.entry != nil {
+			return fmt.Errorf("container path %q is both a file and a directory", name)
+		}
+		return nil
+	}
+	if err := fsys.ensureDir(path.Dir(name)); err != nil {
+		return err
+	}
+	fsys.addChild(name, &node{name: path.Base(name)})
+	return nil
+}
+
+// addChild 登记节点并挂到父目录下
+// addChild registers a node and attaches it to its parent directory
+func (fsys *FS) addChild(name string, n *node) {
+	fsys.nodes[name] = n
+	parent := fsys.nodes[path.Dir(name)]
+	parent.children = append(parent.children, name)
+}
+
+// lookup 按 io/fs 路径规则查找节点
+// lookup finds a node following io/fs path rules
+func (fsys *FS) lookup(op string, name string) (*node, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	n, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return n, nil
+}
+
+// Open 打开文件或目录，文件内容在首次读取时才打开
+// Open opens a file or directory; file content is not opened until the first read
+func (fsys *FS) Open(name string) (fs.File, error) {
+	n, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.entry == nil {
+		return &dirFile{fsys: fsys, path: name, node: n}, nil
+	}
+	return &file{path: name, node: n}, nil
+}
+
+// Stat 返回文件或目录的信息，不会打开文件内容
+// Stat returns information about a file or directory without opening its content
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	n, err := fsys.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{n}, nil
+}
+
+// ReadDir 返回目录下按名称排序的条目
+// ReadDir returns the entries of a directory sorted by name
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.entry != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return fsys.dirEntries(n.children), nil
+}
+
+// ReadFile 读取文件的全部原始内容
+// ReadFile reads the whole raw content of a file
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	n, err := fsys.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.entry == nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	rc, err := n.entry.Open()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	buf.Grow(int(n.entry.RawSize))
+	if _, err := buf.ReadFrom(rc); err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return buf.Bytes(), nil
+}
+
+// dirEntries 把子节点路径转换为目录条目
+// dirEntries converts child node paths into directory entries
+func (fsys *FS) dirEntries(children []string) []fs.DirEntry {
+	out := make([]fs.DirEntry, len(children))
+	for i, child := range children {
+		out[i] = fs.FileInfoToDirEntry(fileInfo{fsys.nodes[child]})
+	}
+	return out
+}
+
+// fileInfo 实现 fs.FileInfo，Size 为原始大小，Sys 返回 EntryInfo
+// fileInfo implements fs.FileInfo, with Size as the raw size and Sys returning EntryInfo
+type fileInfo struct {
+	node *node
+}
+
+// Name 返回路径的最后一段
+// Name returns the last path element
+func (fi fileInfo) Name() string { return fi.node.name }
+
+// ModTime 返回零值，容器不记录修改时间
+// ModTime returns the zero time because containers record no modification time
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+
+// IsDir 报告条目是否为目录
+// IsDir reports whether the entry is a directory
+func (fi fileInfo) IsDir() bool { return fi.node.entry == nil }
+
+// Size 返回文件的原始大小，目录为 0
+// Size returns the raw size of a file, or 0 for a directory
+func (fi fileInfo) Size() int64 {
+	if fi.node.entry == nil {
+		return 0
+	}
+	return fi.node.entry.RawSize
+}
+
+// Mode 返回只读的文件或目录模式
+// Mode returns a read-only file or directory mode
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.node.entry == nil {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// Sys 对文件返回 EntryInfo，对目录返回 nil
+// Sys returns EntryInfo for a file and nil for a directory
+func (fi fileInfo) Sys() any {
+	if fi.node.entry == nil {
+		return nil
+	}
+	return EntryInfo{
+		RawSize:    fi.node.entry.RawSize,
+		StoredSize: fi.node.entry.StoredSize,
+		Compressed: fi.node.entry.Compressed,
+	}
+}
+
+// dirFile 是打开的目录，实现 fs.ReadDirFile
+// dirFile is an open directory implementing fs.ReadDirFile
+type dirFile struct {
+	fsys   *FS
+	path   string
+	node   *node
+	offset int  // 已由 ReadDir 返回的条目数 / Number of entries already returned by ReadDir
+	closed bool // 是否已关闭 / Whether the directory is closed
+}
+
+// Stat 返回目录信息
+// Stat returns the directory information
+func (d *dirFile) Stat() (fs.FileInfo, error) { return fileInfo{d.node}, nil }
+
+// Read 总是失败，目录没有内容
+// Read always fails because a directory has no content
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// Close 关闭目录
+// Close closes the directory
+func (d *dirFile) Close() error {
+	if d.closed {
+		return &fs.PathError{Op: "close", Path: d.path, Err: fs.ErrClosed}
+	}
+	d.closed = true
+	return nil
+}
+
+// ReadDir 按 fs.ReadDirFile 约定分批返回目录条目
+// ReadDir returns directory entries in batches following the fs.ReadDirFile contract
+func (d *dirFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.path, Err: fs.ErrClosed}
+	}
+	rest := d.node.children[d.offset:]
+	if count > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(rest) {
+			rest = rest[:count]
+		}
+	}
+	d.offset += len(rest)
+	return d.fsys.dirEntries(rest), nil
+}
+
+// file 是打开的文件，底层内容在首次读取时打开
+// 不支持随机访问的内容流在向后定位时重新打开，向前定位时跳过数据
+// file is an open file whose underlying content is opened on the first read
+// Content streams without random access are reopened when seeking backwards and skipped forward when seeking ahead
+type file struct {
+	path   string
+	node   *node
+	rc     io.ReadCloser // 已打开的内容流 / Opened content stream
+	ra     io.ReaderAt   // rc 支持随机访问时的同一对象 / The same object when rc supports random access
+	rpos   int64         // rc 的流位置 / Stream position of rc
+	pos    int64         // 文件的逻辑位置 / Logical file position
+	closed bool          // 是否已关闭 / Whether the file is closed
+}
+
+// Stat 返回文件信息，不会打开内容
+// Stat returns the file information without opening the content
+func (f *file) Stat() (fs.FileInfo, error) { return fileInfo{f.node}, nil }
+
+// open 在需要时打开或重新打开内容流
+// open opens or reopens the content stream when needed
+func (f *file) open() error {
+	if f.rc != nil {
+		_ = f.rc.Close()
+		f.rc, f.ra = nil, nil
+	}
+	rc, err := f.node.entry.Open()
+	if err != nil {
+		return err
+	}
+	f.rc, f.rpos = rc, 0
+	f.ra, _ = rc.(io.ReaderAt)
+	return nil
+}
+
+// Read 从当前位置读取原始内容
+// Read reads raw content from the current position
+func (f *file) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrClosed}
+	}
+	n, err := f.readAt(p, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+// ReadAt 在指定偏移读取，不改变 Read 使用的位置
+// ReadAt reads at the given offset without changing the position used by Read
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrClosed}
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrInvalid}
+	}
+	n := 0
+	for n < len(p) {
+		m, err := f.readAt(p[n:], off+int64(n))
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// readAt 从偏移 off 处最多读取 len(p) 字节
+// readAt reads up to len(p) bytes at offset off
+func (f *file) readAt(p []byte, off int64) (int, error) {
+	size := f.node.entry.RawSize
+	if off >= size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > size-off {
+		p = p[:size-off]
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if f.rc == nil || (f.ra == nil && off < f.rpos) {
+		if err := f.open(); err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.path, Err: err}
+		}
+	}
+	if f.ra != nil {
+		n, err := f.ra.ReadAt(p, off)
+		if err == io.EOF && n == len(p) {
+			err = nil
+		}
+		return n, f.wrapErr(err)
+	}
+	if off > f.rpos {
+		skipped, err := io.CopyN(io.Discard, f.rc, off-f.rpos)
+		f.rpos += skipped
+		if err != nil {
+			return 0, f.wrapErr(err)
+		}
+	}
+	n, err := f.rc.Read(p)
+	f.rpos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, f.wrapErr(err)
+}
+
+// wrapErr 把内容流错误包装为 fs.PathError，内容提前结束视为数据损坏
+// wrapErr wraps content stream errors as fs.PathError, treating a premature end of content as corrupt data
+func (f *file) wrapErr(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case io.EOF:
+		err = io.ErrUnexpectedEOF
+	}
+	return &fs.PathError{Op: "read", Path: f.path, Err: err}
+}
+
+// Seek 只移动逻辑位置，实际定位推迟到下一次读取
+// Seek only moves the logical position; the actual positioning is deferred to the next read
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.node.entry.RawSize
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrInvalid}
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+// Close 关闭文件和已打开的内容流
+// Close closes the file and any opened content stream
+func (f *file) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.path, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	if f.rc != nil {
+		return f.rc.Close()
+	}
+	return nil
+}
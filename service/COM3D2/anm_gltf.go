@@ -0,0 +1,527 @@
+package COM3D2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// COM3D2AnmExtrasKey 是 glTF 动画 extras 中承载 COM3D2 专有动画字段的键名
+// COM3D2AnmExtrasKey is the key in glTF animation extras that carries COM3D2-specific animation fields
+const COM3D2AnmExtrasKey = "com3d2Anm"
+
+// com3d2AnmExtras 保存 glTF 动画无法表达且反向转换需要还原的 Anm 字段
+// com3d2AnmExtras stores Anm fields that a glTF animation cannot express and that the reverse conversion restores
+type com3d2AnmExtras struct {
+	Signature    string                `json:"signature"`              // 文件签名 / File signature
+	Version      int32                 `json:"version"`                // Anm 版本号 / Anm version value
+	BustKeyLeft  bool                  `json:"bustKeyLeft,omitempty"`  // 左胸部动画开关 / Left bust-animation switch
+	BustKeyRight bool                  `json:"bustKeyRight,omitempty"` // 右胸部动画开关 / Right bust-animation switch
+	Bones        []com3d2AnmBoneExtras `json:"bones"`                  // 按原顺序排列的骨骼曲线 / Bone curves in their original order
+}
+
+// com3d2AnmBoneExtras 保存一个骨骼的原始路径和属性曲线顺序
+// com3d2AnmBoneExtras stores one bone's raw path and property-curve order
+type com3d2AnmBoneExtras struct {
+	Path       string                    `json:"path"`                 // 原始骨骼路径 / Raw bone path
+	Properties []com3d2AnmPropertyExtras `json:"properties,omitempty"` // 按原顺序排列的属性曲线 / Property curves in their original order
+}
+
+// com3d2AnmPropertyExtras 保存采样器无法表达的属性曲线细节
+// 浮点位模式以 uint32 保存，因为 JSON 无法表示阶跃切线使用的无穷值
+// com3d2AnmPropertyExtras stores property-curve details that a sampler cannot express
+// Float bit patterns are stored as uint32 because JSON cannot represent the infinities used by stepped tangents
+type com3d2AnmPropertyExtras struct {
+	Index     int32     `json:"index"`               // 属性索引 / Property index
+	Times     []float32 `json:"times,omitempty"`     // 与采样器时间不一致时该分量自身的关键帧时间 / The component's own keyframe times when they differ from the sampler times
+	Tangents  []uint32  `json:"tangents,omitempty"`  // 存在非有限切线时各关键帧入、出切线的位模式 / Bit patterns of each keyframe's in and out tangents when a tangent is not finite
+	Raw       bool      `json:"raw,omitempty"`       // 曲线未映射到采样器而原样保存 / The curve is stored verbatim instead of being mapped to a sampler
+	Keyframes []uint32  `json:"keyframes,omitempty"` // 原样保存时每个关键帧的时间、值、入切线和出切线位模式 / Bit patterns of each keyframe's time, value, in tangent, and out tangent when stored verbatim
+}
+
+// com3d2AnmChannel 描述一个 glTF 通道对应的一组 Anm 属性索引及其镜像符号
+// com3d2AnmChannel describes the group of Anm property indices behind one glTF channel and their mirroring signs
+type com3d2AnmChannel struct {
+	path    gltf.TRSProperty
+	indices []int32
+	signs   []float32
+}
+
+// com3d2AnmChannels 列出旋转与位置两个通道，X 镜像翻转旋转的 Y、Z 分量和位置的 X 分量
+// com3d2AnmChannels lists the rotation and position channels; the X mirror flips the rotation's Y and Z components and the position's X component
+var com3d2AnmChannels = []com3d2AnmChannel{
+	{path: gltf.TRSRotation, indices: []int32{COM3D2.LocalRotationX, COM3D2.LocalRotationY, COM3D2.LocalRotationZ, COM3D2.LocalRotationW}, signs: []float32{1, -1, -1, 1}},
+	{path: gltf.TRSTranslation, indices: []int32{4, 5, 6}, signs: []float32{-1, 1, 1}},
+}
+
+// com3d2AnmSampledCurve 是一个通道在共同时间轴上的埃尔米特关键帧，分量已处于 Unity 坐标
+// com3d2AnmSampledCurve holds one channel's Hermite keyframes on a shared time axis with components in Unity coordinates
+type com3d2AnmSampledCurve struct {
+	times       []float32
+	values      [][]float32
+	inTangents  [][]float32
+	outTangents [][]float32
+}
+
+// ConvertAnmToGLTF 将 .anm 或 .anm.json 导出为 CUBICSPLINE 动画的 glTF 或 GLB
+// modelPath 非空时同时导出该 .model 的骨架、蒙皮和网格作为静止姿势，动画通道按骨骼路径匹配到其中的节点
+// COM3D2 专有字段保存在动画 extras 的 com3d2Anm 键中，供反向转换还原逐字节一致的文件
+// ConvertAnmToGLTF exports an .anm or .anm.json to glTF or GLB with CUBICSPLINE animation
+// When modelPath is not empty, the skeleton, skin, and mesh of that .model are exported as the rest pose, and channels are matched to its nodes by bone path
+// COM3D2-specific fields are stored under the com3d2Anm key in animation extras so the reverse conversion restores a byte-identical file
+func (m *AnmService) ConvertAnmToGLTF(ctx context.Context, inputPath string, outputPath string, format string, modelPath string, maxOutputBytes int64) error {
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	}
+	if format != "gltf" && format != "glb" {
+		return fmt.Errorf("anm glTF output format %q is unsupported; use gltf or glb", format)
+	}
+
+	anmData, err := m.ReadAnmFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read anm file: %w", err)
+	}
+	var modelData *COM3D2.Model
+	if modelPath != "" {
+		modelData, err = (&ModelService{}).ReadModelFile(modelPath)
+		if err != nil {
+			return fmt.Errorf("failed to read rest-pose model file: %w", err)
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	document, err := encodeCOM3D2AnmGLTFDocument(anmData, modelData, strings.TrimSuffix(name, ".anm"))
+	if err != nil {
+		return fmt.Errorf("convert anm %q to glTF: %w", inputPath, err)
+	}
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	err = writeConversionBinary(ctx, outputPath, maxOutputBytes, func(w io.Writer) error {
+		encoder := gltf.NewEncoder(w)
+		encoder.AsBinary = format == "glb"
+		return encoder.Encode(document)
+	})
+	if err != nil {
+		return conversionOutputError("anm glTF", err)
+	}
+	return nil
+}
+
+// encodeCOM3D2AnmGLTFDocument 构建带一个动画的 glTF 文档，model 非空时在其模型文档上追加动画
+// encodeCOM3D2AnmGLTFDocument builds a glTF document with one animation, appending it to the model document when model is not nil
+func encodeCOM3D2AnmGLTFDocument(anm *COM3D2.Anm, model *COM3D2.Model, name string) (*gltf.Document, error) {
+	if anm == nil {
+		return nil, fmt.Errorf("anm is null")
+	}
+	var document *gltf.Document
+	if model != nil {
+		modelDocument, err := encodeCOM3D2ModelGLTFDocument(model)
+		if err != nil {
+			return nil, fmt.Errorf("rest-pose model: %w", err)
+		}
+		document = modelDocument
+	} else {
+		document = gltf.NewDocument()
+		document.Asset.Generator = "MeidoSerialization"
+		document.Scenes[0].Nodes = nil
+	}
+	nodePaths, err := com3d2GLTFNodePaths(document)
+	if err != nil {
+		return nil, err
+	}
+	createdNodes := make(map[int]bool)
+
+	animation := &gltf.Animation{Name: name}
+	extras := &com3d2AnmExtras{
+		Signature:    anm.Signature,
+		Version:      anm.Version,
+		BustKeyLeft:  anm.BustKeyLeft,
+		BustKeyRight: anm.BustKeyRight,
+		Bones:        make([]com3d2AnmBoneExtras, 0, len(anm.BoneCurves)),
+	}
+	for boneIndex, bone := range anm.BoneCurves {
+		boneExtras := com3d2AnmBoneExtras{Path: bone.BonePath}
+		// 每个通道取各属性索引首次出现的曲线，重复或未知索引的曲线原样保存在 extras 中
+		// Each channel takes the first curve of each property index, while curves with repeated or unknown indices are stored verbatim in extras
+		curveByIndex := make(map[int32]*COM3D2.PropertyCurve)
+		mapped := make([]bool, len(bone.PropertyCurves))
+		for propertyIndex := range bone.PropertyCurves {
+			curve := &bone.PropertyCurves[propertyIndex]
+			if curve.PropertyIndex < COM3D2.LocalRotationX || curve.PropertyIndex > 6 || len(curve.Keyframes) == 0 {
+				continue
+			}
+			if _, exists := curveByIndex[curve.PropertyIndex]; exists {
+				continue
+			}
+			curveByIndex[curve.PropertyIndex] = curve
+			mapped[propertyIndex] = true
+		}
+		boneExtras.Properties = make([]com3d2AnmPropertyExtras, len(bone.PropertyCurves))
+		propertyExtras := make(map[int32]int)
+		for propertyIndex := range bone.PropertyCurves {
+			curve := &bone.PropertyCurves[propertyIndex]
+			boneExtras.Properties[propertyIndex].Index = curve.PropertyIndex
+			if mapped[propertyIndex] {
+				propertyExtras[curve.PropertyIndex] = propertyIndex
+				continue
+			}
+			boneExtras.Properties[propertyIndex].Raw = true
+			boneExtras.Properties[propertyIndex].Keyframes = com3d2AnmKeyframeBits(curve.Keyframes)
+		}
+
+		nodeIndex := -1
+		for _, channel := range com3d2AnmChannels {
+			components := make([]*COM3D2.PropertyCurve, len(channel.indices))
+			present := false
+			for componentIndex, propertyIndex := range channel.indices {
+				components[componentIndex] = curveByIndex[propertyIndex]
+				present = present || components[componentIndex] != nil
+			}
+			if !present {
+				continue
+			}
+			if nodeIndex < 0 {
+				nodeIndex, err = resolveCOM3D2AnmNode(document, nodePaths, createdNodes, bone.BonePath)
+				if err != nil {
+					return nil, fmt.Errorf("BoneCurves[%d] %q: %w", boneIndex, bone.BonePath, err)
+				}
+			}
+			rest := com3d2AnmRestValues(document.Nodes[nodeIndex], channel)
+			sampled, err := sampleCOM3D2AnmChannel(components, rest)
+			if err != nil {
+				return nil, fmt.Errorf("BoneCurves[%d] %q: %w", boneIndex, bone.BonePath, err)
+			}
+			for componentIndex, component := range components {
+				if component == nil {
+					continue
+				}
+				entry := &boneExtras.Properties[propertyExtras[channel.indices[componentIndex]]]
+				if len(component.Keyframes) != len(sampled.times) {
+					entry.Times = make([]float32, len(component.Keyframes))
+					for keyIndex, keyframe := range component.Keyframes {
+						entry.Times[keyIndex] = keyframe.Time
+					}
+				}
+				entry.Tangents = com3d2AnmNonFiniteTangentBits(component.Keyframes)
+			}
+			if createdNodes[nodeIndex] {
+				setCOM3D2AnmRestPose(document.Nodes[nodeIndex], channel, sampled)
+			}
+			appendCOM3D2AnmSampler(document, animation, nodeIndex, channel, sampled)
+		}
+		extras.Bones = append(extras.Bones, boneExtras)
+	}
+	animation.Extras = map[string]interface{}{COM3D2AnmExtrasKey: extras}
+	document.Animations = append(document.Animations, animation)
+	return document, nil
+}
+
+// com3d2GLTFNodePaths 返回默认场景中每个可达节点从根开始按名称拼接的路径
+// com3d2GLTFNodePaths returns the path of every node reachable in the default scene, joined by name from the root
+func com3d2GLTFNodePaths(document *gltf.Document) (map[int]string, error) {
+	paths := make(map[int]string)
+	if len(document.Scenes) == 0 || len(document.Scenes[0].Nodes) == 0 {
+		return paths, nil
+	}
+	reachable, parents, err := collectCOM3D2GLTFSceneNodes(document)
+	if err != nil {
+		return nil, err
+	}
+	for nodeIndex := range reachable {
+		path := document.Nodes[nodeIndex].Name
+		for ancestor, ok := parents[nodeIndex]; ok; ancestor, ok = parents[ancestor] {
+			path = document.Nodes[ancestor].Name + "/" + path
+		}
+		paths[nodeIndex] = path
+	}
+	return paths, nil
+}
+
+// resolveCOM3D2AnmNode 按完整路径或路径后缀查找动画目标节点，找不到时沿路径创建缺失节点
+// resolveCOM3D2AnmNode finds the animation target node by full path or path suffix and creates the missing nodes along the path when none matches
+func resolveCOM3D2AnmNode(document *gltf.Document, nodePaths map[int]string, createdNodes map[int]bool, bonePath string) (int, error) {
+	if bonePath == "" {
+		return 0, fmt.Errorf("empty bone path")
+	}
+	match := -1
+	for nodeIndex := range document.Nodes {
+		path, ok := nodePaths[nodeIndex]
+		if !ok {
+			continue
+		}
+		if path == bonePath {
+			return nodeIndex, nil
+		}
+		if match < 0 && strings.HasSuffix(path, "/"+bonePath) {
+			match = nodeIndex
+		}
+	}
+	if match >= 0 {
+		return match, nil
+	}
+
+	parentIndex := -1
+	currentPath := ""
+	for _, part := range strings.Split(bonePath, "/") {
+		if part == "" {
+			return 0, fmt.Errorf("bone path has an empty component")
+		}
+		if currentPath == "" {
+			currentPath = part
+		} else {
+			currentPath += "/" + part
+		}
+		existing := -1
+		for nodeIndex, path := range nodePaths {
+			if path == currentPath && (existing < 0 || nodeIndex < existing) {
+				existing = nodeIndex
+			}
+		}
+		if existing >= 0 {
+			parentIndex = existing
+			continue
+		}
+		nodeIndex := len(document.Nodes)
+		document.Nodes = append(document.Nodes, &gltf.Node{Name: part})
+		nodePaths[nodeIndex] = currentPath
+		createdNodes[nodeIndex] = true
+		if parentIndex >= 0 {
+			document.Nodes[parentIndex].Children = append(document.Nodes[parentIndex].Children, nodeIndex)
+		} else {
+			document.Scenes[0].Nodes = append(document.Scenes[0].Nodes, nodeIndex)
+		}
+		parentIndex = nodeIndex
+	}
+	return parentIndex, nil
+}
+
+// com3d2AnmRestValues 返回节点静止姿势在 Unity 坐标中的通道分量，用于填补缺失的分量曲线
+// com3d2AnmRestValues returns the channel components of the node's rest pose in Unity coordinates, used to fill missing component curves
+func com3d2AnmRestValues(node *gltf.Node, channel com3d2AnmChannel) []float32 {
+	var values []float64
+	if channel.path == gltf.TRSRotation {
+		rotation := node.RotationOrDefault()
+		values = rotation[:]
+	} else {
+		translation := node.TranslationOrDefault()
+		values = translation[:]
+	}
+	rest := make([]float32, len(values))
+	for componentIndex, value := range values {
+		rest[componentIndex] = float32(value) * channel.signs[componentIndex]
+	}
+	return rest
+}
+
+// sampleCOM3D2AnmChannel 把一个通道的分量曲线合并到它们关键帧时间的并集上
+// 三次埃尔米特段在内部点处拆分后仍是同一曲线，因此在并集时间上取值和导数不会改变动画
+// sampleCOM3D2AnmChannel merges one channel's component curves onto the union of their keyframe times
+// A cubic Hermite segment split at an interior point is still the same curve, so taking values and derivatives at the union times leaves the animation unchanged
+func sampleCOM3D2AnmChannel(components []*COM3D2.PropertyCurve, rest []float32) (*com3d2AnmSampledCurve, error) {
+	timeSet := make(map[float32]bool)
+	for _, component := range components {
+		if component == nil {
+			continue
+		}
+		for keyIndex, keyframe := range component.Keyframes {
+			if !com3d2AnmFinite(keyframe.Time) || !com3d2AnmFinite(keyframe.Value) {
+				return nil, fmt.Errorf("property %d keyframe %d has a non-finite time or value", component.PropertyIndex, keyIndex)
+			}
+			if keyIndex != 0 && keyframe.Time <= component.Keyframes[keyIndex-1].Time {
+				return nil, fmt.Errorf("property %d keyframe %d time %g does not increase", component.PropertyIndex, keyIndex, keyframe.Time)
+			}
+			timeSet[keyframe.Time] = true
+		}
+	}
+	times := make([]float32, 0, len(timeSet))
+	for time := range timeSet {
+		times = append(times, time)
+	}
+	sort.Slice(times, func(left, right int) bool { return times[left] < times[right] })
+
+	sampled := &com3d2AnmSampledCurve{
+		times:       times,
+		values:      make([][]float32, len(times)),
+		inTangents:  make([][]float32, len(times)),
+		outTangents: make([][]float32, len(times)),
+	}
+	cursors := make([]int, len(components))
+	for timeIndex, time := range times {
+		values := make([]float32, len(components))
+		inTangents := make([]float32, len(components))
+		outTangents := make([]float32, len(components))
+		for componentIndex, component := range components {
+			if component == nil {
+				values[componentIndex] = rest[componentIndex]
+				continue
+			}
+			keyframes := component.Keyframes
+			cursor := cursors[componentIndex]
+			if cursor < len(keyframes) && keyframes[cursor].Time == time {
+				// glTF 访问器不能保存非有限值，阶跃切线以零写入采样器并在 extras 中保留原值
+				// glTF accessors cannot hold non-finite values, so stepped tangents are written as zero to the sampler and kept verbatim in extras
+				values[componentIndex] = keyframes[cursor].Value
+				inTangents[componentIndex] = com3d2AnmFiniteOrZero(keyframes[cursor].InTangent)
+				outTangents[componentIndex] = com3d2AnmFiniteOrZero(keyframes[cursor].OutTangent)
+				cursors[componentIndex] = cursor + 1
+				continue
+			}
+			value, slope := evaluateCOM3D2AnmKeyframes(keyframes, cursor, time)
+			values[componentIndex] = value
+			inTangents[componentIndex] = slope
+			outTangents[componentIndex] = slope
+		}
+		sampled.values[timeIndex] = values
+		sampled.inTangents[timeIndex] = inTangents
+		sampled.outTangents[timeIndex] = outTangents
+	}
+	return sampled, nil
+}
+
+// evaluateCOM3D2AnmKeyframes 按 Unity 规则在 time 处求曲线的值和导数，next 是第一个时间大于 time 的关键帧下标
+// 曲线范围外保持端点值，任一端切线为无穷时整段保持起点值
+// evaluateCOM3D2AnmKeyframes evaluates the curve's value and derivative at time by Unity's rules, where next is the index of the first keyframe later than time
+// Outside the curve's range the end value holds, and a segment with an infinite tangent at either end holds its start value
+func evaluateCOM3D2AnmKeyframes(keyframes []COM3D2.Keyframe, next int, time float32) (float32, float32) {
+	if next <= 0 {
+		return keyframes[0].Value, 0
+	}
+	if next >= len(keyframes) {
+		return keyframes[len(keyframes)-1].Value, 0
+	}
+	start := keyframes[next-1]
+	end := keyframes[next]
+	if !com3d2AnmFinite(start.OutTangent) || !com3d2AnmFinite(end.InTangent) {
+		return start.Value, 0
+	}
+	duration := float64(end.Time) - float64(start.Time)
+	s := (float64(time) - float64(start.Time)) / duration
+	s2 := s * s
+	s3 := s2 * s
+	v0, v1 := float64(start.Value), float64(end.Value)
+	m0, m1 := float64(start.OutTangent)*duration, float64(end.InTangent)*duration
+	value := (2*s3-3*s2+1)*v0 + (s3-2*s2+s)*m0 + (-2*s3+3*s2)*v1 + (s3-s2)*m1
+	derivative := ((6*s2-6*s)*v0 + (3*s2-4*s+1)*m0 + (-6*s2+6*s)*v1 + (3*s2-2*s)*m1) / duration
+	return float32(value), float32(derivative)
+}
+
+// setCOM3D2AnmRestPose 把新建节点的静止姿势设为通道在第一个关键帧处的取值
+// setCOM3D2AnmRestPose sets a created node's rest pose to the channel value at the first keyframe
+func setCOM3D2AnmRestPose(node *gltf.Node, channel com3d2AnmChannel, sampled *com3d2AnmSampledCurve) {
+	first := sampled.values[0]
+	if channel.path == gltf.TRSTranslation {
+		node.Translation = [3]float64{float64(first[0] * channel.signs[0]), float64(first[1]), float64(first[2])}
+		return
+	}
+	rotation := [4]float64{float64(first[0]), float64(first[1] * channel.signs[1]), float64(first[2] * channel.signs[2]), float64(first[3])}
+	length := math.Sqrt(rotation[0]*rotation[0] + rotation[1]*rotation[1] + rotation[2]*rotation[2] + rotation[3]*rotation[3])
+	if length == 0 || math.IsNaN(length) || math.IsInf(length, 0) {
+		return
+	}
+	for componentIndex := range rotation {
+		rotation[componentIndex] /= length
+	}
+	node.Rotation = rotation
+}
+
+// appendCOM3D2AnmSampler 以 CUBICSPLINE 插值写入镜像后的采样器和通道
+// appendCOM3D2AnmSampler writes the mirrored sampler and channel with CUBICSPLINE interpolation
+func appendCOM3D2AnmSampler(document *gltf.Document, animation *gltf.Animation, nodeIndex int, channel com3d2AnmChannel, sampled *com3d2AnmSampledCurve) {
+	inputAccessor := modeler.WriteAccessor(document, gltf.TargetNone, sampled.times)
+	document.Accessors[inputAccessor].Min = []float64{float64(sampled.times[0])}
+	document.Accessors[inputAccessor].Max = []float64{float64(sampled.times[len(sampled.times)-1])}
+
+	// CUBICSPLINE 输出按入切线、值、出切线三元组排列，切线与 Unity 一样以每秒导数表示
+	// CUBICSPLINE output is laid out as in-tangent, value, out-tangent triples, with tangents as per-second derivatives just like Unity
+	mirror := func(values []float32) []float32 {
+		mirrored := make([]float32, len(values))
+		for componentIndex, value := range values {
+			mirrored[componentIndex] = value * channel.signs[componentIndex]
+		}
+		return mirrored
+	}
+	var outputAccessor int
+	if channel.path == gltf.TRSRotation {
+		output := make([][4]float32, 0, 3*len(sampled.times))
+		for keyIndex := range sampled.times {
+			for _, values := range [][]float32{sampled.inTangents[keyIndex], sampled.values[keyIndex], sampled.outTangents[keyIndex]} {
+				output = append(output, [4]float32(mirror(values)))
+			}
+		}
+		outputAccessor = modeler.WriteAccessor(document, gltf.TargetNone, output)
+	} else {
+		output := make([][3]float32, 0, 3*len(sampled.times))
+		for keyIndex := range sampled.times {
+			for _, values := range [][]float32{sampled.inTangents[keyIndex], sampled.values[keyIndex], sampled.outTangents[keyIndex]} {
+				output = append(output, [3]float32(mirror(values)))
+			}
+		}
+		outputAccessor = modeler.WriteAccessor(document, gltf.TargetNone, output)
+	}
+	samplerIndex := len(animation.Samplers)
+	animation.Samplers = append(animation.Samplers, &gltf.AnimationSampler{
+		Input:         inputAccessor,
+		Interpolation: gltf.InterpolationCubicSpline,
+		Output:        outputAccessor,
+	})
+	animation.Channels = append(animation.Channels, &gltf.AnimationChannel{
+		Sampler: samplerIndex,
+		Target: gltf.AnimationChannelTarget{
+			Node: gltf.Index(nodeIndex),
+			Path: channel.path,
+		},
+	})
+}
+
+// com3d2AnmKeyframeBits 把关键帧展开为时间、值、入切线和出切线的位模式
+// com3d2AnmKeyframeBits flattens keyframes into the bit patterns of their time, value, in tangent, and out tangent
+func com3d2AnmKeyframeBits(keyframes []COM3D2.Keyframe) []uint32 {
+	bits := make([]uint32, 0, 4*len(keyframes))
+	for _, keyframe := range keyframes {
+		bits = append(bits, math.Float32bits(keyframe.Time), math.Float32bits(keyframe.Value), math.Float32bits(keyframe.InTangent), math.Float32bits(keyframe.OutTangent))
+	}
+	return bits
+}
+
+// com3d2AnmNonFiniteTangentBits 在任一切线非有限时返回全部入、出切线的位模式，否则返回 nil
+// com3d2AnmNonFiniteTangentBits returns the bit patterns of every in and out tangent when any tangent is not finite, and nil otherwise
+func com3d2AnmNonFiniteTangentBits(keyframes []COM3D2.Keyframe) []uint32 {
+	for _, keyframe := range keyframes {
+		if com3d2AnmFinite(keyframe.InTangent) && com3d2AnmFinite(keyframe.OutTangent) {
+			continue
+		}
+		bits := make([]uint32, 0, 2*len(keyframes))
+		for _, tangentKeyframe := range keyframes {
+			bits = append(bits, math.Float32bits(tangentKeyframe.InTangent), math.Float32bits(tangentKeyframe.OutTangent))
+		}
+		return bits
+	}
+	return nil
+}
+
+// com3d2AnmFinite 判断 float32 是否为有限值
+// com3d2AnmFinite reports whether a float32 is finite
+func com3d2AnmFinite(value float32) bool {
+	return !math.IsNaN(float64(value)) && !math.IsInf(float64(value), 0)
+}
+
+// com3d2AnmFiniteOrZero 把非有限值替换为零
+// com3d2AnmFiniteOrZero replaces a non-finite value with zero
+func com3d2AnmFiniteOrZero(value float32) float32 {
+	if com3d2AnmFinite(value) {
+		return value
+	}
+	return 0
+}
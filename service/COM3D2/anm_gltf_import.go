@@ -0,0 +1,426 @@
+package COM3D2
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// ConvertGLTFToAnm 将 glTF 或 GLB 中的动画转换为 .anm 文件
+// CUBICSPLINE 采样器的切线直接成为关键帧切线，LINEAR 采样器取相邻段斜率，STEP 采样器写成无穷切线
+// convert2gltf 写入的 com3d2Anm extras 会被还原；缺少 extras 时按 1001 版本构建且胸部动画开关关闭
+// ConvertGLTFToAnm converts the animation in a glTF or GLB into an .anm file
+// CUBICSPLINE sampler tangents become keyframe tangents directly, LINEAR samplers take the adjacent segment slopes, and STEP samplers become infinite tangents
+// The com3d2Anm extras written by convert2gltf are restored; without them the file is built as version 1001 with both bust-animation switches off
+func (m *AnmService) ConvertGLTFToAnm(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	document, err := gltf.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("open glTF %q: %w", inputPath, err)
+	}
+	anmData, err := decodeCOM3D2GLTFAnmDocument(document)
+	if err != nil {
+		return fmt.Errorf("convert glTF %q to anm: %w", inputPath, err)
+	}
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	if err := writeConversionBinary(ctx, outputPath, maxOutputBytes, anmData.Dump); err != nil {
+		return conversionOutputError("anm", err)
+	}
+	return nil
+}
+
+// IsCOM3D2AnmGLTFDocument 判断 glTF 或 GLB 文件是否有动画携带 convert2gltf 写入的 com3d2Anm extras
+// IsCOM3D2AnmGLTFDocument reports whether a glTF or GLB file has an animation carrying the com3d2Anm extras written by convert2gltf
+func IsCOM3D2AnmGLTFDocument(path string) bool {
+	document, err := gltf.Open(path)
+	if err != nil {
+		return false
+	}
+	for _, animation := range document.Animations {
+		if animation == nil {
+			continue
+		}
+		if found, err := decodeCOM3D2GLTFExtras(animation.Extras, COM3D2AnmExtrasKey, &com3d2AnmExtras{}); err == nil && found {
+			return true
+		}
+	}
+	return false
+}
+
+// com3d2AnmNodeCurves 保存一个节点的旋转和位置通道
+// com3d2AnmNodeCurves holds one node's rotation and position channels
+type com3d2AnmNodeCurves struct {
+	rotation    *com3d2AnmSampledCurve
+	translation *com3d2AnmSampledCurve
+}
+
+// decodeCOM3D2GLTFAnmDocument 从带 com3d2Anm extras 的动画或第一个动画还原 Anm
+// 骨骼路径由节点名称按层级拼接，存在 Bip01 祖先时从 Bip01 开始；缩放和变形权重通道被忽略
+// decodeCOM3D2GLTFAnmDocument restores an Anm from the animation carrying com3d2Anm extras, or from the first animation
+// Bone paths join node names along the hierarchy, starting at the Bip01 ancestor when one exists; scale and morph-weight channels are ignored
+func decodeCOM3D2GLTFAnmDocument(document *gltf.Document) (*COM3D2.Anm, error) {
+	if len(document.Animations) == 0 {
+		return nil, fmt.Errorf("document has no animation")
+	}
+	var animation *gltf.Animation
+	var extras *com3d2AnmExtras
+	for _, candidate := range document.Animations {
+		if candidate == nil {
+			continue
+		}
+		candidateExtras := &com3d2AnmExtras{}
+		found, err := decodeCOM3D2GLTFExtras(candidate.Extras, COM3D2AnmExtrasKey, candidateExtras)
+		if err != nil {
+			return nil, fmt.Errorf("animation %q: %w", candidate.Name, err)
+		}
+		if found {
+			animation, extras = candidate, candidateExtras
+			break
+		}
+		if animation == nil {
+			animation = candidate
+		}
+	}
+	if animation == nil {
+		return nil, fmt.Errorf("document has no animation")
+	}
+
+	nodePaths, err := com3d2GLTFNodePaths(document)
+	if err != nil {
+		return nil, err
+	}
+	curvesByNode := make(map[int]*com3d2AnmNodeCurves)
+	var nodeOrder []int
+	for channelIndex, channel := range animation.Channels {
+		if channel == nil || channel.Target.Node == nil {
+			continue
+		}
+		if channel.Target.Path != gltf.TRSRotation && channel.Target.Path != gltf.TRSTranslation {
+			continue
+		}
+		nodeIndex := *channel.Target.Node
+		if _, ok := nodePaths[nodeIndex]; !ok {
+			return nil, fmt.Errorf("channel %d targets node %d outside the default scene", channelIndex, nodeIndex)
+		}
+		if channel.Sampler < 0 || channel.Sampler >= len(animation.Samplers) || animation.Samplers[channel.Sampler] == nil {
+			return nil, fmt.Errorf("channel %d references sampler %d out of range", channelIndex, channel.Sampler)
+		}
+		definition := com3d2AnmChannels[0]
+		if channel.Target.Path == gltf.TRSTranslation {
+			definition = com3d2AnmChannels[1]
+		}
+		sampled, err := readCOM3D2GLTFAnmSampler(document, animation.Samplers[channel.Sampler], definition)
+		if err != nil {
+			return nil, fmt.Errorf("channel %d on node %q: %w", channelIndex, document.Nodes[nodeIndex].Name, err)
+		}
+		curves, ok := curvesByNode[nodeIndex]
+		if !ok {
+			curves = &com3d2AnmNodeCurves{}
+			curvesByNode[nodeIndex] = curves
+			nodeOrder = append(nodeOrder, nodeIndex)
+		}
+		if channel.Target.Path == gltf.TRSRotation {
+			curves.rotation = sampled
+		} else {
+			curves.translation = sampled
+		}
+	}
+
+	anm := &COM3D2.Anm{Signature: COM3D2.AnmSignature, Version: 1001}
+	consumed := make(map[int]bool)
+	if extras != nil {
+		anm.Signature = extras.Signature
+		anm.Version = extras.Version
+		anm.BustKeyLeft = extras.BustKeyLeft
+		anm.BustKeyRight = extras.BustKeyRight
+		for boneIndex, boneExtras := range extras.Bones {
+			nodeIndex := findCOM3D2AnmNode(nodeOrder, nodePaths, consumed, boneExtras.Path)
+			var curves *com3d2AnmNodeCurves
+			if nodeIndex >= 0 {
+				consumed[nodeIndex] = true
+				curves = curvesByNode[nodeIndex]
+			}
+			bone, err := decodeCOM3D2AnmBoneWithExtras(boneExtras, curves)
+			if err != nil {
+				return nil, fmt.Errorf("bone %d %q: %w", boneIndex, boneExtras.Path, err)
+			}
+			anm.BoneCurves = append(anm.BoneCurves, bone)
+		}
+	}
+	for _, nodeIndex := range nodeOrder {
+		if consumed[nodeIndex] {
+			continue
+		}
+		bone := COM3D2.BoneCurveData{BonePath: com3d2AnmBonePath(nodePaths[nodeIndex]), PropertyCurves: []COM3D2.PropertyCurve{}}
+		curves := curvesByNode[nodeIndex]
+		for channelIndex, sampled := range []*com3d2AnmSampledCurve{curves.rotation, curves.translation} {
+			if sampled == nil {
+				continue
+			}
+			for componentIndex, propertyIndex := range com3d2AnmChannels[channelIndex].indices {
+				bone.PropertyCurves = append(bone.PropertyCurves, COM3D2.PropertyCurve{
+					PropertyIndex: propertyIndex,
+					Keyframes:     com3d2AnmComponentKeyframes(sampled, componentIndex, nil),
+				})
+			}
+		}
+		anm.BoneCurves = append(anm.BoneCurves, bone)
+	}
+	return anm, nil
+}
+
+// findCOM3D2AnmNode 在带动画通道的节点中按完整路径或路径后缀查找尚未使用的节点，找不到时返回 -1
+// findCOM3D2AnmNode finds an unused animated node by full path or path suffix and returns -1 when none matches
+func findCOM3D2AnmNode(nodeOrder []int, nodePaths map[int]string, consumed map[int]bool, bonePath string) int {
+	match := -1
+	for _, nodeIndex := range nodeOrder {
+		if consumed[nodeIndex] {
+			continue
+		}
+		path := nodePaths[nodeIndex]
+		if path == bonePath {
+			return nodeIndex
+		}
+		if match < 0 && strings.HasSuffix(path, "/"+bonePath) {
+			match = nodeIndex
+		}
+	}
+	return match
+}
+
+// com3d2AnmBonePath 从节点完整路径取得 Anm 骨骼路径，路径中有 Bip01 时从它开始
+// com3d2AnmBonePath derives the Anm bone path from a node's full path, starting at Bip01 when the path contains it
+func com3d2AnmBonePath(nodePath string) string {
+	parts := strings.Split(nodePath, "/")
+	for partIndex, part := range parts {
+		if part == "Bip01" {
+			return strings.Join(parts[partIndex:], "/")
+		}
+	}
+	return nodePath
+}
+
+// decodeCOM3D2AnmBoneWithExtras 按 extras 记录的属性顺序、关键帧时间和切线还原一个骨骼的曲线
+// 记录的时间在采样器中缺失时说明曲线被编辑过，此时改用采样器的全部关键帧
+// decodeCOM3D2AnmBoneWithExtras restores one bone's curves in the property order, keyframe times, and tangents recorded in extras
+// A recorded time missing from the sampler means the curve was edited, in which case every sampler keyframe is used instead
+func decodeCOM3D2AnmBoneWithExtras(boneExtras com3d2AnmBoneExtras, curves *com3d2AnmNodeCurves) (COM3D2.BoneCurveData, error) {
+	bone := COM3D2.BoneCurveData{BonePath: boneExtras.Path, PropertyCurves: []COM3D2.PropertyCurve{}}
+	for _, property := range boneExtras.Properties {
+		if property.Raw {
+			if len(property.Keyframes)%4 != 0 {
+				return bone, fmt.Errorf("raw property %d keyframe data length %d is not a multiple of four", property.Index, len(property.Keyframes))
+			}
+			keyframes := make([]COM3D2.Keyframe, len(property.Keyframes)/4)
+			for keyIndex := range keyframes {
+				bits := property.Keyframes[4*keyIndex : 4*keyIndex+4]
+				keyframes[keyIndex] = COM3D2.Keyframe{
+					Time:       math.Float32frombits(bits[0]),
+					Value:      math.Float32frombits(bits[1]),
+					InTangent:  math.Float32frombits(bits[2]),
+					OutTangent: math.Float32frombits(bits[3]),
+				}
+			}
+			bone.PropertyCurves = append(bone.PropertyCurves, COM3D2.PropertyCurve{PropertyIndex: property.Index, Keyframes: keyframes})
+			continue
+		}
+		if curves == nil {
+			continue
+		}
+		var sampled *com3d2AnmSampledCurve
+		componentIndex := -1
+		for channelIndex, channel := range com3d2AnmChannels {
+			for candidateIndex, propertyIndex := range channel.indices {
+				if propertyIndex != property.Index {
+					continue
+				}
+				componentIndex = candidateIndex
+				if channelIndex == 0 {
+					sampled = curves.rotation
+				} else {
+					sampled = curves.translation
+				}
+			}
+		}
+		if componentIndex < 0 {
+			return bone, fmt.Errorf("property %d is not a rotation or position component", property.Index)
+		}
+		if sampled == nil {
+			continue
+		}
+		var selected []int
+		if len(property.Times) != 0 {
+			keyByTime := make(map[float32]int, len(sampled.times))
+			for keyIndex, time := range sampled.times {
+				keyByTime[time] = keyIndex
+			}
+			for _, time := range property.Times {
+				keyIndex, ok := keyByTime[time]
+				if !ok {
+					selected = nil
+					break
+				}
+				selected = append(selected, keyIndex)
+			}
+		}
+		keyframes := com3d2AnmComponentKeyframes(sampled, componentIndex, selected)
+		if len(property.Tangents) == 2*len(keyframes) {
+			for keyIndex := range keyframes {
+				keyframes[keyIndex].InTangent = math.Float32frombits(property.Tangents[2*keyIndex])
+				keyframes[keyIndex].OutTangent = math.Float32frombits(property.Tangents[2*keyIndex+1])
+			}
+		}
+		bone.PropertyCurves = append(bone.PropertyCurves, COM3D2.PropertyCurve{PropertyIndex: property.Index, Keyframes: keyframes})
+	}
+	return bone, nil
+}
+
+// com3d2AnmComponentKeyframes 取出采样曲线中一个分量的关键帧，selected 为 nil 时取全部关键帧
+// com3d2AnmComponentKeyframes extracts one component's keyframes from a sampled curve, taking every keyframe when selected is nil
+func com3d2AnmComponentKeyframes(sampled *com3d2AnmSampledCurve, componentIndex int, selected []int) []COM3D2.Keyframe {
+	if selected == nil {
+		selected = make([]int, len(sampled.times))
+		for keyIndex := range selected {
+			selected[keyIndex] = keyIndex
+		}
+	}
+	keyframes := make([]COM3D2.Keyframe, len(selected))
+	for outputIndex, keyIndex := range selected {
+		keyframes[outputIndex] = COM3D2.Keyframe{
+			Time:       sampled.times[keyIndex],
+			Value:      sampled.values[keyIndex][componentIndex],
+			InTangent:  sampled.inTangents[keyIndex][componentIndex],
+			OutTangent: sampled.outTangents[keyIndex][componentIndex],
+		}
+	}
+	return keyframes
+}
+
+// readCOM3D2GLTFAnmSampler 读取一个采样器并拟合为镜像回 Unity 坐标的埃尔米特关键帧
+// readCOM3D2GLTFAnmSampler reads one sampler and fits it to Hermite keyframes mirrored back to Unity coordinates
+func readCOM3D2GLTFAnmSampler(document *gltf.Document, sampler *gltf.AnimationSampler, channel com3d2AnmChannel) (*com3d2AnmSampledCurve, error) {
+	if sampler.Input < 0 || sampler.Input >= len(document.Accessors) || sampler.Output < 0 || sampler.Output >= len(document.Accessors) {
+		return nil, fmt.Errorf("sampler accessor out of range")
+	}
+	rawTimes, err := modeler.ReadAccessor(document, document.Accessors[sampler.Input], nil)
+	if err != nil {
+		return nil, fmt.Errorf("read sampler input: %w", err)
+	}
+	times, ok := rawTimes.([]float32)
+	if !ok {
+		return nil, fmt.Errorf("sampler input type %T is unsupported", rawTimes)
+	}
+	for keyIndex := 1; keyIndex < len(times); keyIndex++ {
+		if times[keyIndex] <= times[keyIndex-1] {
+			return nil, fmt.Errorf("sampler input time %g at key %d does not increase", times[keyIndex], keyIndex)
+		}
+	}
+	rawOutput, err := modeler.ReadAccessor(document, document.Accessors[sampler.Output], nil)
+	if err != nil {
+		return nil, fmt.Errorf("read sampler output: %w", err)
+	}
+	var output [][]float32
+	switch values := rawOutput.(type) {
+	case [][4]float32:
+		for _, value := range values {
+			output = append(output, []float32{value[0], value[1], value[2], value[3]})
+		}
+	case [][3]float32:
+		for _, value := range values {
+			output = append(output, []float32{value[0], value[1], value[2]})
+		}
+	default:
+		return nil, fmt.Errorf("sampler output type %T is unsupported", rawOutput)
+	}
+	for outputIndex, value := range output {
+		if len(value) != len(channel.indices) {
+			return nil, fmt.Errorf("sampler output has %d components but %s needs %d", len(value), channel.path, len(channel.indices))
+		}
+		for componentIndex := range value {
+			value[componentIndex] *= channel.signs[componentIndex]
+		}
+		output[outputIndex] = value
+	}
+
+	keyCount := len(times)
+	sampled := &com3d2AnmSampledCurve{
+		times:       times,
+		values:      make([][]float32, keyCount),
+		inTangents:  make([][]float32, keyCount),
+		outTangents: make([][]float32, keyCount),
+	}
+	switch sampler.Interpolation {
+	case gltf.InterpolationCubicSpline:
+		if len(output) != 3*keyCount {
+			return nil, fmt.Errorf("CUBICSPLINE sampler has %d outputs for %d keyframes", len(output), keyCount)
+		}
+		for keyIndex := range times {
+			sampled.inTangents[keyIndex] = output[3*keyIndex]
+			sampled.values[keyIndex] = output[3*keyIndex+1]
+			sampled.outTangents[keyIndex] = output[3*keyIndex+2]
+		}
+		return sampled, nil
+	case gltf.InterpolationLinear, gltf.InterpolationStep:
+		if len(output) != keyCount {
+			return nil, fmt.Errorf("sampler has %d outputs for %d keyframes", len(output), keyCount)
+		}
+	default:
+		return nil, fmt.Errorf("sampler interpolation %v is unsupported", sampler.Interpolation)
+	}
+
+	// 旋转逐分量插值，因此相邻四元数保持在同一半球，避免跨越符号翻转
+	// Rotations interpolate per component, so adjacent quaternions stay in the same hemisphere to avoid crossing a sign flip
+	if channel.path == gltf.TRSRotation {
+		for keyIndex := 1; keyIndex < keyCount; keyIndex++ {
+			var dot float32
+			for componentIndex := range output[keyIndex] {
+				dot += output[keyIndex][componentIndex] * output[keyIndex-1][componentIndex]
+			}
+			if dot < 0 {
+				for componentIndex := range output[keyIndex] {
+					output[keyIndex][componentIndex] = -output[keyIndex][componentIndex]
+				}
+			}
+		}
+	}
+	componentCount := len(channel.indices)
+	for keyIndex := range times {
+		sampled.values[keyIndex] = output[keyIndex]
+		inTangents := make([]float32, componentCount)
+		outTangents := make([]float32, componentCount)
+		for componentIndex := 0; componentIndex < componentCount; componentIndex++ {
+			if sampler.Interpolation == gltf.InterpolationStep {
+				inTangents[componentIndex] = float32(math.Inf(1))
+				outTangents[componentIndex] = float32(math.Inf(1))
+				continue
+			}
+			slope := func(start int) float32 {
+				return (output[start+1][componentIndex] - output[start][componentIndex]) / (times[start+1] - times[start])
+			}
+			switch {
+			case keyCount == 1:
+			case keyIndex == 0:
+				inTangents[componentIndex] = slope(0)
+				outTangents[componentIndex] = slope(0)
+			case keyIndex == keyCount-1:
+				inTangents[componentIndex] = slope(keyIndex - 1)
+				outTangents[componentIndex] = slope(keyIndex - 1)
+			default:
+				inTangents[componentIndex] = slope(keyIndex - 1)
+				outTangents[componentIndex] = slope(keyIndex)
+			}
+		}
+		sampled.inTangents[keyIndex] = inTangents
+		sampled.outTangents[keyIndex] = outTangents
+	}
+	return sampled, nil
+}
@@ -0,0 +1,202 @@
+package COM3D2
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// newGLTFTestAnm 构建覆盖分量时间不一致、阶跃切线、缺失分量、重复和未知属性以及胸部开关的合成 Anm
+// newGLTFTestAnm builds a synthetic Anm covering mismatched component times, stepped tangents, missing components, repeated and unknown properties, and the bust switches
+func newGLTFTestAnm() *COM3D2.Anm {
+	rotation := func(index int32, values ...float32) COM3D2.PropertyCurve {
+		keyframes := make([]COM3D2.Keyframe, len(values))
+		for keyIndex, value := range values {
+			keyframes[keyIndex] = COM3D2.Keyframe{Time: float32(keyIndex) / 30, Value: value, InTangent: value - 0.5, OutTangent: 0.25 - value}
+		}
+		return COM3D2.PropertyCurve{PropertyIndex: index, Keyframes: keyframes}
+	}
+	return &COM3D2.Anm{
+		Signature:   COM3D2.AnmSignature,
+		Version:     1001,
+		BustKeyLeft: true,
+		BoneCurves: []COM3D2.BoneCurveData{
+			{BonePath: "Bip01", PropertyCurves: []COM3D2.PropertyCurve{
+				rotation(COM3D2.LocalRotationX, 0, 0.1, -0.2),
+				rotation(COM3D2.LocalRotationY, 0.3, 0, 0.2),
+				rotation(COM3D2.LocalRotationZ, -0.1, 0.05, 0),
+				rotation(COM3D2.LocalRotationW, 0.95, 0.99, 0.96),
+				{PropertyIndex: 4, Keyframes: []COM3D2.Keyframe{{Time: 0, Value: 0.5, InTangent: 0, OutTangent: 1}, {Time: 0.1, Value: 0.7, InTangent: 2, OutTangent: 3}}},
+				{PropertyIndex: 5, Keyframes: []COM3D2.Keyframe{{Time: 0, Value: 1.2, OutTangent: float32(math.Inf(1))}, {Time: 0.05, Value: 1.3, InTangent: float32(math.Inf(1))}, {Time: 0.2, Value: 1.1, InTangent: -1, OutTangent: -1}}},
+			}},
+			{BonePath: "Bip01/Bip01 Pelvis", PropertyCurves: []COM3D2.PropertyCurve{
+				rotation(COM3D2.LocalRotationW, 1, 0.9),
+				{PropertyIndex: 9, Keyframes: []COM3D2.Keyframe{{Time: 0.5, Value: float32(math.Inf(-1))}}},
+				rotation(COM3D2.LocalRotationW, 0.5),
+				{PropertyIndex: COM3D2.LocalRotationX, Keyframes: []COM3D2.Keyframe{}},
+			}},
+			{BonePath: "Bip01/Bip01 Pelvis/Bip01 Spine", PropertyCurves: []COM3D2.PropertyCurve{}},
+		},
+	}
+}
+
+// TestAnmGLTFRoundTripIsByteIdentical 校验 .anm 单独导出或叠加静止姿势模型导出的 glTF 都能还原逐字节一致的文件
+// TestAnmGLTFRoundTripIsByteIdentical verifies that glTF exported from an .anm alone or over a rest-pose model restores a byte-identical file
+func TestAnmGLTFRoundTripIsByteIdentical(t *testing.T) {
+	source := newGLTFTestAnm()
+	var want bytes.Buffer
+	if err := source.Dump(&want); err != nil {
+		t.Fatalf("dump source anm: %v", err)
+	}
+
+	service := &AnmService{}
+	for _, testCase := range []struct {
+		name      string
+		format    string
+		withModel bool
+	}{
+		{name: "glb", format: "glb"},
+		{name: "gltf", format: "gltf"},
+		{name: "model", format: "glb", withModel: true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			dir := t.TempDir()
+			inputPath := filepath.Join(dir, "dance.anm")
+			if err := service.WriteAnmFile(inputPath, source); err != nil {
+				t.Fatalf("write source anm: %v", err)
+			}
+			modelPath := ""
+			if testCase.withModel {
+				modelPath = filepath.Join(dir, "crc_skirt.model")
+				if err := (&ModelService{}).WriteModelFile(modelPath, newGLTFTestModel()); err != nil {
+					t.Fatalf("write rest-pose model: %v", err)
+				}
+			}
+			gltfPath := filepath.Join(dir, "dance."+testCase.format)
+			if err := service.ConvertAnmToGLTF(TestConversionContext, inputPath, gltfPath, testCase.format, modelPath, TestConversionMaxOutput); err != nil {
+				t.Fatalf("ConvertAnmToGLTF: %v", err)
+			}
+
+			document, err := gltf.Open(gltfPath)
+			if err != nil {
+				t.Fatalf("open exported glTF: %v", err)
+			}
+			if len(document.Animations) != 1 || len(document.Animations[0].Channels) != 3 {
+				t.Fatalf("animations = %+v, want one animation with three channels", document.Animations)
+			}
+			for _, sampler := range document.Animations[0].Samplers {
+				if sampler.Interpolation != gltf.InterpolationCubicSpline {
+					t.Fatalf("interpolation = %v, want CUBICSPLINE", sampler.Interpolation)
+				}
+			}
+			pelvisNode := *document.Animations[0].Channels[2].Target.Node
+			if got := document.Nodes[pelvisNode].Name; got != "Bip01 Pelvis" {
+				t.Fatalf("third channel targets %q, want Bip01 Pelvis", got)
+			}
+			if testCase.withModel && (pelvisNode != 2 || len(document.Nodes) != 5) {
+				t.Fatalf("pelvis node = %d with %d nodes, want the model's bone node without created nodes", pelvisNode, len(document.Nodes))
+			}
+
+			outputPath := filepath.Join(dir, "back.anm")
+			if err := service.ConvertGLTFToAnm(TestConversionContext, gltfPath, outputPath, TestConversionMaxOutput); err != nil {
+				t.Fatalf("ConvertGLTFToAnm: %v", err)
+			}
+			back, err := service.ReadAnmFile(outputPath)
+			if err != nil {
+				t.Fatalf("read converted anm: %v", err)
+			}
+			var got bytes.Buffer
+			if err := back.Dump(&got); err != nil {
+				t.Fatalf("dump converted anm: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Fatalf("round-tripped anm differs: %d bytes, want %d", got.Len(), want.Len())
+			}
+		})
+	}
+}
+
+// TestAnmGLTFMergedChannelKeepsCurveShape 校验合并到时间并集上的分量曲线在原关键帧之间取值不变
+// TestAnmGLTFMergedChannelKeepsCurveShape verifies that a component curve merged onto the union of times evaluates identically between its original keyframes
+func TestAnmGLTFMergedChannelKeepsCurveShape(t *testing.T) {
+	original := &COM3D2.PropertyCurve{PropertyIndex: 4, Keyframes: []COM3D2.Keyframe{{Time: 0, Value: 0.5, OutTangent: 1}, {Time: 0.3, Value: 0.7, InTangent: 2, OutTangent: 3}}}
+	other := &COM3D2.PropertyCurve{PropertyIndex: 5, Keyframes: []COM3D2.Keyframe{{Time: 0}, {Time: 0.1}, {Time: 0.3}}}
+	sampled, err := sampleCOM3D2AnmChannel([]*COM3D2.PropertyCurve{original, other, nil}, []float32{0, 0, 0})
+	if err != nil {
+		t.Fatalf("sample channel: %v", err)
+	}
+	merged := com3d2AnmComponentKeyframes(sampled, 0, nil)
+	if len(merged) != 3 {
+		t.Fatalf("merged keyframes = %+v, want three", merged)
+	}
+	for _, time := range []float32{0.05, 0.15, 0.25} {
+		want, _ := evaluateCOM3D2AnmKeyframes(original.Keyframes, 1, time)
+		next := 1
+		for next < len(merged) && merged[next].Time <= time {
+			next++
+		}
+		got, _ := evaluateCOM3D2AnmKeyframes(merged, next, time)
+		if math.Abs(float64(got-want)) > 1e-6 {
+			t.Fatalf("value at %g = %g, want %g", time, got, want)
+		}
+	}
+}
+
+// TestAnmGLTFImportLinearSamplerWithoutExtras 校验不带 extras 的 LINEAR 采样器按 Bip01 相对路径和线段斜率导入
+// TestAnmGLTFImportLinearSamplerWithoutExtras verifies that a LINEAR sampler without extras imports with a Bip01-relative path and segment-slope tangents
+func TestAnmGLTFImportLinearSamplerWithoutExtras(t *testing.T) {
+	document := gltf.NewDocument()
+	document.Nodes = []*gltf.Node{
+		{Name: "Armature", Children: []int{1}},
+		{Name: "Bip01", Children: []int{2}},
+		{Name: "Bip01 Spine"},
+	}
+	document.Scenes[0].Nodes = []int{0}
+	input := modeler.WriteAccessor(document, gltf.TargetNone, []float32{0, 0.5, 1})
+	output := modeler.WriteAccessor(document, gltf.TargetNone, [][3]float32{{1, 0, 0}, {2, 0, 0}, {2, 1, 0}})
+	document.Animations = []*gltf.Animation{{
+		Name:     "Action",
+		Samplers: []*gltf.AnimationSampler{{Input: input, Output: output, Interpolation: gltf.InterpolationLinear}},
+		Channels: []*gltf.AnimationChannel{{Sampler: 0, Target: gltf.AnimationChannelTarget{Node: gltf.Index(2), Path: gltf.TRSTranslation}}},
+	}}
+
+	dir := t.TempDir()
+	gltfPath := filepath.Join(dir, "action.glb")
+	if err := gltf.SaveBinary(document, gltfPath); err != nil {
+		t.Fatalf("save glTF: %v", err)
+	}
+	outputPath := filepath.Join(dir, "action.anm")
+	service := &AnmService{}
+	if err := service.ConvertGLTFToAnm(TestConversionContext, gltfPath, outputPath, TestConversionMaxOutput); err != nil {
+		t.Fatalf("ConvertGLTFToAnm: %v", err)
+	}
+	anm, err := service.ReadAnmFile(outputPath)
+	if err != nil {
+		t.Fatalf("read converted anm: %v", err)
+	}
+	if anm.Version != 1001 || anm.BustKeyLeft || anm.BustKeyRight {
+		t.Fatalf("header = %d %v %v, want version 1001 without bust keys", anm.Version, anm.BustKeyLeft, anm.BustKeyRight)
+	}
+	if len(anm.BoneCurves) != 1 || anm.BoneCurves[0].BonePath != "Bip01/Bip01 Spine" {
+		t.Fatalf("bones = %+v, want one Bip01/Bip01 Spine bone", anm.BoneCurves)
+	}
+	curves := anm.BoneCurves[0].PropertyCurves
+	if len(curves) != 3 || curves[0].PropertyIndex != 4 || curves[1].PropertyIndex != 5 {
+		t.Fatalf("property curves = %+v, want position X, Y, and Z", curves)
+	}
+	wantX := []COM3D2.Keyframe{
+		{Time: 0, Value: -1, InTangent: -2, OutTangent: -2},
+		{Time: 0.5, Value: -2, InTangent: -2, OutTangent: 0},
+		{Time: 1, Value: -2, InTangent: 0, OutTangent: 0},
+	}
+	for keyIndex, keyframe := range curves[0].Keyframes {
+		if keyframe != wantX[keyIndex] {
+			t.Fatalf("position X keyframe %d = %+v, want %+v", keyIndex, keyframe, wantX[keyIndex])
+		}
+	}
+}
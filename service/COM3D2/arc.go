@@ -1,6 +1,7 @@
 package COM3D2
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -59,10 +60,104 @@ func (a *ArcService) UnpackArc(path string, outDir string) error {
 }
 
 // PackArc 将文件夹打包为 .arc 文件
+// 文件数据在写入时从磁盘流式读取，内存占用与文件夹大小无关
 func (a *ArcService) PackArc(dirPath string, arcPath string) error {
 	return arc.Pack(dirPath, arcPath)
 }
 
+// PackArcContext 将文件夹打包为 .arc 文件，ctx 取消时停止写入且不留下不完整的 .arc 文件
+func (a *ArcService) PackArcContext(ctx context.Context, dirPath string, arcPath string) error {
+	return arc.PackContext(ctx, dirPath, arcPath)
+}
+
+// UpdateArc 原地更新 .arc 文件：edit 修改读取到的文件系统，新增或修改的数据和新的目录表追加到文件末尾，无需完整重新打包
+func (a *ArcService) UpdateArc(ctx context.Context, arcPath string, edit func(*arc.Arc) error) (*arc.UpdateResult, error) {
+	return arc.Update(ctx, arcPath, edit)
+}
+
+// CompactArc 重写 .arc 文件以回收 UpdateArc 留下的无用空间
+func (a *ArcService) CompactArc(ctx context.Context, arcPath string) error {
+	return arc.Compact(ctx, arcPath)
+}
+
+// VerifyArc 逐条目校验 .arc 文件：解压每个文件并核对大小，交叉核对两个哈希表与名称表，检查越界和重叠的偏移
+// 损坏之处记录在返回的报告中，只有读取失败或 ctx 取消时才返回错误
+func (a *ArcService) VerifyArc(ctx context.Context, arcPath string) (*arc.VerifyReport, error) {
+	return arc.VerifyFile(ctx, arcPath)
+}
+
+// DiffArc 按完整路径比较两个 .arc 文件或目录中的条目，报告新增、删除和修改的文件
+// patchPath 不为空时，把新增和修改的条目写为只含这些条目的补丁 .arc 文件
+func (a *ArcService) DiffArc(ctx context.Context, oldPath string, newPath string, patchPath string) (*arc.Diff, error) {
+	oldArc, oldCloser, err := a.openArcOrDir(ctx, oldPath)
+	if err != nil {
+		return nil, err
+	}
+	defer oldCloser.Close()
+	newArc, newCloser, err := a.openArcOrDir(ctx, newPath)
+	if err != nil {
+		return nil, err
+	}
+	defer newCloser.Close()
+
+	diff, err := arc.DiffArcs(ctx, oldArc, newArc)
+	if err != nil {
+		return nil, err
+	}
+	if patchPath != "" {
+		if err := diff.PatchArc(newArc).DumpContext(ctx, patchPath); err != nil {
+			return nil, fmt.Errorf("writing the patch .arc file failed: %w", err)
+		}
+	}
+	return diff, nil
+}
+
+// openArcOrDir 延迟读取 .arc 文件，或把目录中的文件按相对路径载入为 Arc
+// 调用者使用完毕后必须关闭返回的 io.Closer
+func (a *ArcService) openArcOrDir(ctx context.Context, path string) (*arc.Arc, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return a.ReadArcLazy(path)
+	}
+	fs := arc.NewArc(filepath.Base(path))
+	if _, err := fs.AddFromDir(ctx, path); err != nil {
+		return nil, nil, err
+	}
+	return fs, dirArcCloser{}, nil
+}
+
+// dirArcCloser 是从目录载入的 Arc 的关闭器，目录中的文件已读入内存，没有需要释放的资源
+type dirArcCloser struct{}
+
+// Close 不做任何操作
+func (dirArcCloser) Close() error { return nil }
+
+// OpenVFS 按顺序挂载 .arc 文件和散装目录（例如 Mod 文件夹）并返回分层 VFS，后面的路径覆盖前面的路径
+// 调用者使用完毕后必须调用 VFS.Close
+func (a *ArcService) OpenVFS(ctx context.Context, paths []string) (*arc.VFS, error) {
+	vfs := arc.NewVFS()
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			_ = vfs.Close()
+			return nil, err
+		}
+		if info.IsDir() {
+			_, err = vfs.MountDir(ctx, p)
+		} else {
+			_, err = vfs.MountArcFile(p)
+		}
+		if err != nil {
+			_ = vfs.Close()
+			return nil, err
+		}
+	}
+	return vfs, nil
+}
+
 // MergeArc 将 fromArc 合并到 toArc 中。如果 keepDupes 为真，则使用文件的完整路径作为键；否则使用最后一个段。
 func (a *ArcService) MergeArc(fromArc *arc.Arc, toArc *arc.Arc, keepDupes bool) *arc.Arc {
 	toArc.MergeFrom(fromArc, keepDupes)
@@ -2,6 +2,7 @@ package COM3D2
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -213,3 +214,41 @@ func TestArcServiceReadArcLazyRequiresOpenCloser(t *testing.T) {
 		t.Fatal("expected lazy read to fail after closer is closed")
 	}
 }
+
+func TestArcServiceDiffArcAgainstDirectoryWritesPatch(t *testing.T) {
+	s := &ArcService{}
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	arcPath := filepath.Join(tempDir, "base.arc")
+	patchPath := filepath.Join(tempDir, "patch.arc")
+	for rel, data := range map[string]string{"menu/a.menu": "menu a", "menu/b.menu": "menu b"} {
+		path := filepath.Join(sourceDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.PackArc(sourceDir, arcPath); err != nil {
+		t.Fatalf("PackArc failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "menu", "b.menu"), []byte("menu b v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := s.DiffArc(context.Background(), arcPath, sourceDir, patchPath)
+	if err != nil {
+		t.Fatalf("DiffArc failed: %v", err)
+	}
+	if len(diff.Entries) != 1 || diff.Entries[0].Path != "menu/b.menu" || diff.Unchanged != 1 {
+		t.Fatalf("diff = %+v", diff)
+	}
+	patch, err := s.ReadArc(patchPath)
+	if err != nil {
+		t.Fatalf("ReadArc of patch failed: %v", err)
+	}
+	if list := s.GetFileList(patch); len(list) != 1 || filepath.ToSlash(list[0]) != "menu/b.menu" {
+		t.Fatalf("patch files = %v", list)
+	}
+}
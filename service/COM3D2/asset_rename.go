@@ -0,0 +1,158 @@
+package COM3D2
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+)
+
+// RenameService 在 MOD 文件夹中重命名资源并改写引用 / RenameService renames assets in a mod folder and rewrites the references to them
+type RenameService struct{}
+
+// AssetRenamePlan 是一次资源重命名的计划，写入前可用于预览
+// AssetRenamePlan is the plan of one asset rename, which can be previewed before anything is written
+type AssetRenamePlan struct {
+	Root      string            `json:"root"`      // 被扫描的文件夹 / Folder that was scanned
+	Files     []AssetRenameFile `json:"files"`     // 改名或改写的文件，按路径排序 / Files renamed or rewritten, sorted by path
+	Unmatched []string          `json:"unmatched"` // 文件夹中不存在对应文件的文件映射，其引用仍会被改写 / File mappings with no matching file in the folder, whose references are still rewritten
+	Applied   bool              `json:"applied"`   // 是否已写入磁盘 / Whether the plan was written to disk
+}
+
+// AssetRenameFile 描述计划中的一个文件 / AssetRenameFile describes one file in the plan
+type AssetRenameFile struct {
+	Path    string                   `json:"path"`              // 相对于根文件夹的路径 / Path relative to the root folder
+	NewPath string                   `json:"newPath,omitempty"` // 改名后的相对路径，未改名时为空 / Relative path after the rename, empty when not renamed
+	Kind    COM3D2.DependencyKind    `json:"kind"`              // 文件类型 / File kind
+	Changes []COM3D2.ReferenceChange `json:"changes,omitempty"` // 改写的引用 / References rewritten
+	data    []byte                   // 改写后的内容，未改写时为 nil / Rewritten content, nil when not rewritten
+}
+
+// RenameAssets 扫描 dir 中的 .menu、.mate、.model、.pmat、.phy 和 .preset，按 renames 的旧名到新名映射改写全部引用并重命名对应文件
+// apply 为 false 时只返回计划而不写入；任何受影响的文件无法解析，或新文件名与已有文件冲突时，不写入任何内容并返回错误
+// 映射规则见 COM3D2.NewAssetRenamer；.pmat 的哈希和 .menu 的 BodySize 在写出时重新计算
+// RenameAssets scans the .menu, .mate, .model, .pmat, .phy, and .preset files in dir, rewrites every reference according to the old-to-new name mappings in renames, and renames the matching files
+// With apply false only the plan is returned and nothing is written; when any scanned file cannot be parsed or a new file name collides with an existing file, nothing is written and an error is returned
+// See COM3D2.NewAssetRenamer for the mapping rules; the .pmat hash and the .menu BodySize are recalculated when written
+func (s *RenameService) RenameAssets(ctx context.Context, dir string, renames map[string]string, apply bool) (*AssetRenamePlan, error) {
+	renamer, err := COM3D2.NewAssetRenamer(renames)
+	if err != nil {
+		return nil, err
+	}
+	plan := &AssetRenamePlan{Root: dir, Files: []AssetRenameFile{}, Unmatched: []string{}}
+	present := map[string]bool{}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		base := path.Base(rel)
+		present[strings.ToLower(base)] = true
+		file := AssetRenameFile{Path: rel, Kind: COM3D2.DependencyKindOf(base)}
+		if newBase := renamer.FileName(base); newBase != base {
+			file.NewPath = path.Join(path.Dir(rel), newBase)
+		}
+		if hasReferences(file.Kind) {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rewritten, changes, err := renamer.RewriteChanges(file.Kind, data)
+			if err != nil {
+				return fmt.Errorf("failed to rewrite %s: %w", rel, err)
+			}
+			if len(changes) > 0 {
+				file.Changes, file.data = changes, rewritten
+			}
+		}
+		if file.NewPath != "" || file.data != nil {
+			plan.Files = append(plan.Files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(plan.Files, func(i, j int) bool { return plan.Files[i].Path < plan.Files[j].Path })
+	for oldName := range renames {
+		if path.Ext(oldName) != "" && !present[strings.ToLower(oldName)] {
+			plan.Unmatched = append(plan.Unmatched, oldName)
+		}
+	}
+	sort.Strings(plan.Unmatched)
+	if err := checkRenameTargets(dir, plan.Files); err != nil {
+		return nil, err
+	}
+	if !apply {
+		return plan, nil
+	}
+
+	for _, file := range plan.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		oldPath := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if file.data != nil {
+			if err := os.WriteFile(oldPath, file.data, 0o644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", file.Path, err)
+			}
+		}
+		if file.NewPath != "" {
+			if err := os.Rename(oldPath, filepath.Join(dir, filepath.FromSlash(file.NewPath))); err != nil {
+				return nil, fmt.Errorf("failed to rename %s: %w", file.Path, err)
+			}
+		}
+	}
+	plan.Applied = true
+	return plan, nil
+}
+
+// checkRenameTargets 拒绝与已有文件或彼此冲突的新文件名，仅大小写不同的改名除外
+// checkRenameTargets rejects new file names that collide with an existing file or with each other, except renames that only change case
+func checkRenameTargets(dir string, files []AssetRenameFile) error {
+	targets := map[string]string{}
+	for _, file := range files {
+		if file.NewPath == "" {
+			continue
+		}
+		key := strings.ToLower(file.NewPath)
+		if other, ok := targets[key]; ok {
+			return fmt.Errorf("%s and %s would both be renamed to %s", other, file.Path, file.NewPath)
+		}
+		targets[key] = file.Path
+		if strings.EqualFold(file.Path, file.NewPath) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file.NewPath))); err == nil {
+			return fmt.Errorf("cannot rename %s: %s already exists", file.Path, file.NewPath)
+		}
+	}
+	return nil
+}
+
+// hasReferences 报告该类型的文件是否可能含有引用 / hasReferences reports whether files of the kind can hold references
+func hasReferences(kind COM3D2.DependencyKind) bool {
+	switch kind {
+	case COM3D2.DependencyKindMenu, COM3D2.DependencyKindMaterial, COM3D2.DependencyKindModel,
+		COM3D2.DependencyKindPMat, COM3D2.DependencyKindPhysics, COM3D2.DependencyKindPreset:
+		return true
+	default:
+		return false
+	}
+}
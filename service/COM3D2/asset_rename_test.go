@@ -0,0 +1,75 @@
+package COM3D2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+)
+
+func TestRenameServiceRenameAssetsDryRunAndApply(t *testing.T) {
+	dir := t.TempDir()
+	menuService := &MenuService{}
+	menu := &COM3D2.Menu{Signature: COM3D2.MenuSignature, Version: 1000, Commands: []COM3D2.Command{
+		{Command: "name", Args: []string{"dress"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "dress.tex"}},
+	}}
+	menuPath := filepath.Join(dir, "dress.menu")
+	if err := menuService.WriteMenuFile(menuPath, menu); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "texture"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	texPath := filepath.Join(dir, "texture", "dress.tex")
+	if err := os.WriteFile(texPath, []byte("tex"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(menuPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &RenameService{}
+	renames := map[string]string{"dress.tex": "gown.tex", "missing.anm": "other.anm"}
+	plan, err := service.RenameAssets(context.Background(), dir, renames, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Applied || len(plan.Files) != 2 || plan.Files[0].Path != "dress.menu" || plan.Files[0].NewPath != "" ||
+		len(plan.Files[0].Changes) != 1 || plan.Files[1].NewPath != "texture/gown.tex" || plan.Files[1].Changes != nil {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if len(plan.Unmatched) != 1 || plan.Unmatched[0] != "missing.anm" {
+		t.Errorf("unmatched = %v", plan.Unmatched)
+	}
+	if data, err := os.ReadFile(menuPath); err != nil || string(data) != string(original) {
+		t.Fatal("dry run modified the menu")
+	}
+
+	if _, err := service.RenameAssets(context.Background(), dir, renames, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(texPath); !os.IsNotExist(err) {
+		t.Errorf("old texture still exists: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "texture", "gown.tex")); err != nil || string(data) != "tex" {
+		t.Errorf("renamed texture = %q, %v", data, err)
+	}
+	renamed, err := menuService.ReadMenuFile(menuPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Commands[1].Args[3] != "gown.tex" || renamed.Commands[0].Args[0] != "dress" {
+		t.Errorf("commands = %+v", renamed.Commands)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "texture", "other.tex"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.RenameAssets(context.Background(), dir, map[string]string{"gown.tex": "other.tex"}, true); err == nil {
+		t.Error("rename onto an existing file was accepted")
+	}
+}
@@ -0,0 +1,96 @@
+package COM3D2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+)
+
+// vfsDependencyLocator 让分层 VFS 满足 COM3D2.DependencyLocator / vfsDependencyLocator makes a layered VFS satisfy COM3D2.DependencyLocator
+type vfsDependencyLocator struct {
+	vfs *arc.VFS
+}
+
+// Locate 返回 VFS 中胜出的条目 / Locate returns the winning entry in the VFS
+func (l vfsDependencyLocator) Locate(name string) (*COM3D2.DependencyFile, bool) {
+	entry, ok := l.vfs.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+	return &COM3D2.DependencyFile{Source: entry.Source.Name, Path: entry.Path, Open: entry.File.Open}, true
+}
+
+// menuDependencies 是一次依赖解析的结果及读取闭包文件所需的来源，使用完毕后必须调用 close
+// menuDependencies is the result of one dependency resolution together with the sources needed to read the closure files; close must be called when done
+type menuDependencies struct {
+	closure *COM3D2.DependencyClosure
+	root    *COM3D2.DependencyFile
+	locator vfsDependencyLocator
+}
+
+// open 打开闭包中第 index 个文件，第 0 个是根菜单 / open opens file index of the closure, where file 0 is the root menu
+func (d *menuDependencies) open(index int) (io.ReadCloser, error) {
+	if index == 0 {
+		return d.root.Open()
+	}
+	name := d.closure.Files[index].Name
+	file, ok := d.locator.Locate(name)
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s: %w", name, os.ErrNotExist)
+	}
+	return file.Open()
+}
+
+// close 关闭挂载的来源 / close closes the mounted sources
+func (d *menuDependencies) close() error {
+	return d.locator.vfs.Close()
+}
+
+// ResolveDependencies 按顺序挂载 .arc 文件和散装目录（后面的覆盖前面的），并解析一个 .menu 会让游戏加载的全部文件
+// menu 是磁盘上存在的 .menu 文件时直接读取，否则按文件名在来源中查找
+// ResolveDependencies mounts .arc files and loose directories in order, with later ones overriding earlier ones, and resolves every file the game loads for one .menu
+// menu is read directly when it is a .menu file on disk, and otherwise looked up by file name in the sources
+func (s *MenuService) ResolveDependencies(ctx context.Context, menu string, sources []string) (*COM3D2.DependencyClosure, error) {
+	deps, err := s.resolveDependencies(ctx, menu, sources)
+	if err != nil {
+		return nil, err
+	}
+	defer deps.close()
+	return deps.closure, nil
+}
+
+// resolveDependencies 挂载来源并解析依赖闭包，保持来源打开以便读取闭包文件
+// resolveDependencies mounts the sources and resolves the dependency closure, keeping the sources open so the closure files can be read
+func (s *MenuService) resolveDependencies(ctx context.Context, menu string, sources []string) (*menuDependencies, error) {
+	arcService := &ArcService{}
+	vfs, err := arcService.OpenVFS(ctx, sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount sources: %w", err)
+	}
+	deps := &menuDependencies{locator: vfsDependencyLocator{vfs: vfs}}
+
+	rootName := filepath.Base(menu)
+	if info, err := os.Stat(menu); err == nil && !info.IsDir() {
+		deps.root = &COM3D2.DependencyFile{Source: filepath.Dir(menu), Path: rootName, Open: func() (io.ReadCloser, error) {
+			return os.Open(menu)
+		}}
+	} else {
+		found, ok := deps.locator.Locate(menu)
+		if !ok {
+			_ = vfs.Close()
+			return nil, fmt.Errorf("menu %s was not found on disk or in any source", menu)
+		}
+		deps.root = found
+	}
+	deps.closure, err = COM3D2.ResolveMenuDependencies(rootName, deps.root, deps.locator)
+	if err != nil {
+		_ = vfs.Close()
+		return nil, err
+	}
+	return deps, nil
+}
@@ -0,0 +1,66 @@
+package COM3D2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+)
+
+func TestMenuServiceResolveDependenciesAcrossArcAndMod(t *testing.T) {
+	tempDir := t.TempDir()
+	gameDir := filepath.Join(tempDir, "game")
+	modDir := filepath.Join(tempDir, "Mod")
+	arcPath := filepath.Join(tempDir, "parts.arc")
+	writeFile := func(path string, data []byte) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	menu := &COM3D2.Menu{Signature: COM3D2.MenuSignature, Version: 1000, Commands: []COM3D2.Command{
+		{Command: "additem", Args: []string{"dress.model", "wear"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "Dress.tex"}},
+		{Command: "icon", Args: []string{"dress_i_.tex"}},
+	}}
+	menuService := &MenuService{}
+	menuPath := filepath.Join(modDir, "outfit", "dress.menu")
+	if err := os.MkdirAll(filepath.Dir(menuPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := menuService.WriteMenuFile(menuPath, menu); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(filepath.Join(gameDir, "model", "dress.model"), []byte("not a model"))
+	writeFile(filepath.Join(gameDir, "texture", "dress.tex"), []byte("arc"))
+	writeFile(filepath.Join(modDir, "outfit", "dress.tex"), []byte("mod"))
+	if err := (&ArcService{}).PackArc(gameDir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, menuArg := range []string{menuPath, "DRESS.menu"} {
+		closure, err := menuService.ResolveDependencies(context.Background(), menuArg, []string{arcPath, modDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(closure.Files) != 3 || closure.Files[1].Source != arcPath || closure.Files[2].Source != modDir || closure.Files[2].Path != "outfit/dress.tex" {
+			t.Fatalf("%s: files = %+v", menuArg, closure.Files)
+		}
+		if len(closure.Missing) != 1 || closure.Missing[0].Name != "dress_i_.tex" {
+			t.Errorf("%s: missing = %+v", menuArg, closure.Missing)
+		}
+		if len(closure.Errors) != 1 || closure.Errors[0].Name != "dress.model" {
+			t.Errorf("%s: errors = %+v", menuArg, closure.Errors)
+		}
+	}
+
+	if _, err := menuService.ResolveDependencies(context.Background(), "absent.menu", []string{modDir}); err == nil {
+		t.Error("absent root menu was accepted")
+	}
+}
@@ -0,0 +1,109 @@
+package COM3D2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+)
+
+// ModExportResult 是一次 MOD 导出的结果 / ModExportResult is the result of one mod export
+type ModExportResult struct {
+	Output  string                    `json:"output"`  // 输出目录或 .arc 路径 / Output directory or .arc path
+	Files   []ExportedDependency      `json:"files"`   // 已写出的文件，顺序与闭包相同 / Files written, in closure order
+	Closure *COM3D2.DependencyClosure `json:"closure"` // 导出所依据的依赖闭包 / Dependency closure the export is based on
+}
+
+// ExportedDependency 描述一个写出的文件 / ExportedDependency describes one file written
+type ExportedDependency struct {
+	Name      string `json:"name"`      // 原文件名 / Original file name
+	Path      string `json:"path"`      // 输出中的相对路径 / Relative path in the output
+	Source    string `json:"source"`    // 读取文件的来源 / Source the file was read from
+	Rewritten bool   `json:"rewritten"` // 内容是否因改名而改写 / Whether the content was rewritten for the rename
+}
+
+// ExportMod 解析 menu 的依赖闭包并把全部文件复制到干净的输出目录，output 以 .arc 结尾时改为打包成新的 .arc
+// prefix 非空时全部文件和材质名加上 prefix，并改写 .menu、.mate、.model、.pmat 和 .phy 中的引用；prefix 为空时文件逐字节复制
+// 文件保持其在来源中的相对目录；缺失的引用不会中止导出，而是留在 Closure.Missing 中
+// ExportMod resolves the dependency closure of menu and copies every file into a clean output directory, or packs them into a new .arc when output ends with .arc
+// A non-empty prefix is added to every file and material name and the references in .menu, .mate, .model, .pmat, and .phy files are rewritten; with an empty prefix files are copied byte for byte
+// Files keep their relative directory from the source; missing references do not stop the export and remain in Closure.Missing
+func (s *MenuService) ExportMod(ctx context.Context, menu string, sources []string, output string, prefix string) (*ModExportResult, error) {
+	deps, err := s.resolveDependencies(ctx, menu, sources)
+	if err != nil {
+		return nil, err
+	}
+	defer deps.close()
+	renamer, err := COM3D2.NewDependencyRenamer(prefix, deps.closure.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	toArc := strings.EqualFold(filepath.Ext(output), ".arc")
+	outDir := output
+	if toArc {
+		stageDir, err := os.MkdirTemp("", "meido-export-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create staging directory: %w", err)
+		}
+		defer os.RemoveAll(stageDir)
+		outDir = filepath.Join(stageDir, strings.TrimSuffix(filepath.Base(output), filepath.Ext(output)))
+	} else if entries, err := os.ReadDir(output); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("output directory %s is not empty", output)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	result := &ModExportResult{Output: output, Files: []ExportedDependency{}, Closure: deps.closure}
+	for i, file := range deps.closure.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		relPath := path.Join(path.Dir(file.Path), prefix+path.Base(file.Path))
+		if !filepath.IsLocal(filepath.FromSlash(relPath)) {
+			return nil, fmt.Errorf("%s has unsafe path %q in %s", file.Name, file.Path, file.Source)
+		}
+		data, err := readDependency(deps, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", file.Name, file.Source, err)
+		}
+		rewritten, err := renamer.Rewrite(file.Kind, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", file.Name, err)
+		}
+		target := filepath.Join(outDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, rewritten, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		result.Files = append(result.Files, ExportedDependency{
+			Name: file.Name, Path: relPath, Source: file.Source, Rewritten: prefix != "" && string(rewritten) != string(data),
+		})
+	}
+
+	if toArc {
+		if err := arc.PackContext(ctx, outDir, output); err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %w", output, err)
+		}
+	}
+	return result, nil
+}
+
+// readDependency 读取闭包中第 index 个文件的全部内容 / readDependency reads the whole content of file index of the closure
+func readDependency(deps *menuDependencies, index int) ([]byte, error) {
+	rc, err := deps.open(index)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,90 @@
+package COM3D2
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2/arc"
+)
+
+func TestMenuServiceExportModWithPrefix(t *testing.T) {
+	tempDir := t.TempDir()
+	gameDir := filepath.Join(tempDir, "game")
+	modDir := filepath.Join(tempDir, "Mod")
+	arcPath := filepath.Join(tempDir, "parts.arc")
+	if err := os.MkdirAll(filepath.Join(gameDir, "texture"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gameDir, "texture", "dress.tex"), []byte("arc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ArcService{}).PackArc(gameDir, arcPath); err != nil {
+		t.Fatal(err)
+	}
+	menu := &COM3D2.Menu{Signature: COM3D2.MenuSignature, Version: 1000, Commands: []COM3D2.Command{
+		{Command: "name", Args: []string{"dress"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "Dress.tex"}},
+		{Command: "icon", Args: []string{"dress_i_.tex"}},
+	}}
+	menuService := &MenuService{}
+	menuPath := filepath.Join(modDir, "dress.menu")
+	if err := os.MkdirAll(modDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := menuService.WriteMenuFile(menuPath, menu); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(tempDir, "out")
+	result, err := menuService.ExportMod(context.Background(), menuPath, []string{arcPath, modDir}, outDir, "my_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 || result.Files[0].Path != "my_dress.menu" || !result.Files[0].Rewritten ||
+		result.Files[1].Path != "texture/my_dress.tex" || result.Files[1].Rewritten {
+		t.Fatalf("files = %+v", result.Files)
+	}
+	if len(result.Closure.Missing) != 1 {
+		t.Errorf("missing = %+v", result.Closure.Missing)
+	}
+	if data, err := os.ReadFile(filepath.Join(outDir, "texture", "my_dress.tex")); err != nil || string(data) != "arc" {
+		t.Fatalf("texture = %q, %v", data, err)
+	}
+	exported, err := menuService.ReadMenuFile(filepath.Join(outDir, "my_dress.menu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exported.Commands[0].Args, []string{"dress"}) || exported.Commands[1].Args[3] != "my_Dress.tex" {
+		t.Errorf("commands = %+v", exported.Commands)
+	}
+
+	if _, err := menuService.ExportMod(context.Background(), menuPath, []string{arcPath, modDir}, outDir, "my_"); err == nil {
+		t.Error("export into a non-empty directory was accepted")
+	}
+
+	outArc := filepath.Join(tempDir, "dress.arc")
+	if _, err := menuService.ExportMod(context.Background(), menuPath, []string{arcPath, modDir}, outArc, ""); err != nil {
+		t.Fatal(err)
+	}
+	packed, err := arc.ReadArcFile(outArc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := packed.GetFile("dress.menu")
+	if file == nil || packed.GetFile("texture/dress.tex") == nil {
+		t.Fatalf("packed files = %v", packed.GetFileList())
+	}
+	rc, err := file.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if _, err := COM3D2.ReadMenu(bufio.NewReader(rc)); err != nil {
+		t.Errorf("packed menu: %v", err)
+	}
+}
@@ -0,0 +1,590 @@
+package COM3D2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// COM3D2ModelExtrasKey 是 glTF 场景 extras 中承载 COM3D2 专有模型字段的键名
+// COM3D2ModelExtrasKey is the key in glTF scene extras that carries COM3D2-specific model fields
+const COM3D2ModelExtrasKey = "com3d2Model"
+
+const (
+	com3d2BoneExtrasKey     = "com3d2Bone"     // 骨骼节点 extras 键 / Bone node extras key
+	com3d2JointExtrasKey    = "com3d2Joint"    // 仅供蒙皮引用的合成关节节点 extras 键 / Extras key of synthesized joint nodes referenced only by the skin
+	com3d2MaterialExtrasKey = "com3d2Material" // 完整材质数据的材质 extras 键 / Material extras key holding the complete material data
+	com3d2MorphsExtrasKey   = "com3d2Morphs"   // 变形目标补充数据的网格 extras 键 / Mesh extras key holding supplementary morph-target data
+)
+
+// com3d2ExtendedUVChannels 是版本 2101 起的扩展 UV 通道，按出现顺序依次映射到 TEXCOORD_1 起的集合
+// com3d2ExtendedUVChannels lists the extended UV channels from version 2101, mapped in order of presence to the sets from TEXCOORD_1 onward
+var com3d2ExtendedUVChannels = []string{"UV2", "UV3", "UV4"}
+
+// com3d2UnknownChannels 是版本 2101 起游戏读取但未使用的 Vector2 通道，原样写入自定义属性
+// com3d2UnknownChannels lists the Vector2 channels from version 2101 that the game reads but does not use, written verbatim to custom attributes
+var com3d2UnknownChannels = []string{"Unknown1", "Unknown2", "Unknown3", "Unknown4"}
+
+// com3d2ModelExtras 保存 glTF 无法自然表达且反向转换需要还原的 Model 字段 / com3d2ModelExtras stores Model fields that glTF cannot express naturally and that the reverse conversion restores
+type com3d2ModelExtras struct {
+	Signature         string                `json:"signature"`                   // 文件签名 / File signature
+	Version           int32                 `json:"version"`                     // Model 版本号 / Model version value
+	Name              string                `json:"name"`                        // 模型名称 / Model name
+	RootBoneName      string                `json:"rootBoneName"`                // 根骨骼名称 / Root bone name
+	ShadowCastingMode *string               `json:"shadowCastingMode,omitempty"` // 阴影投射方式 / Shadow-casting mode
+	UVChannels        []string              `json:"uvChannels,omitempty"`        // TEXCOORD_1 起各集合对应的扩展 UV 通道 / Extended UV channel carried by each set from TEXCOORD_1 onward
+	Tangents          []COM3D2.Quaternion   `json:"tangents,omitempty"`          // 数量与顶点数不一致时原样保存的切线 / Tangents kept verbatim when their count differs from the vertex count
+	SkinThickness     *COM3D2.SkinThickness `json:"skinThickness,omitempty"`     // 皮肤厚度数据 / Skin-thickness data
+}
+
+// com3d2BoneExtras 保存骨骼节点的缩放辅助节点标志、局部缩放是否存在，以及节点省略默认值时丢失正负零的原始变换
+// com3d2BoneExtras stores a bone node's scaling-helper flag, whether its local scale is present, and the raw transform whose signed zeros are lost when the node omits a default value
+type com3d2BoneExtras struct {
+	HasScale   bool               `json:"hasScale"`           // 是否创建 _SCL_ 缩放辅助节点 / Whether to create the _SCL_ scaling helper node
+	LocalScale bool               `json:"localScale"`         // 是否写出版本 2001 的局部缩放 / Whether the version 2001 local scale is written
+	Position   *COM3D2.Vector3    `json:"position,omitempty"` // 平移为零时的原始位置 / Raw position when the translation is zero
+	Rotation   *COM3D2.Quaternion `json:"rotation,omitempty"` // 旋转为单位四元数时的原始旋转 / Raw rotation when the rotation is the identity
+}
+
+// com3d2MorphExtras 保存稠密变形目标无法表达的稀疏索引顺序和切线 W 差分 / com3d2MorphExtras stores the sparse index order and tangent W deltas that dense morph targets cannot express
+type com3d2MorphExtras struct {
+	Indices  []int32   `json:"indices,omitempty"`  // 原始顶点索引列表 / Original vertex-index list
+	TangentW []float32 `json:"tangentW,omitempty"` // 与 Indices 对应的切线 W 差分 / Tangent W deltas matching Indices
+}
+
+// ConvertModelToGLTF 将 .model 或 .model.json 导出为带骨架、蒙皮、子网格图元、变形目标和材质的 glTF 或 GLB
+// COM3D2 专有字段保存在场景 extras 的 com3d2Model 键及节点、材质和网格 extras 中，供反向转换还原逐字节一致的文件
+// ConvertModelToGLTF exports a .model or .model.json to glTF or GLB with the skeleton, skin, submesh primitives, morph targets, and materials
+// COM3D2-specific fields are stored under the com3d2Model key in scene extras and in node, material, and mesh extras so the reverse conversion restores a byte-identical file
+func (m *ModelService) ConvertModelToGLTF(ctx context.Context, inputPath string, outputPath string, format string, maxOutputBytes int64) error {
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	}
+	if format != "gltf" && format != "glb" {
+		return fmt.Errorf("model glTF output format %q is unsupported; use gltf or glb", format)
+	}
+
+	modelData, err := m.ReadModelFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read model file: %w", err)
+	}
+	document, err := encodeCOM3D2ModelGLTFDocument(modelData)
+	if err != nil {
+		return fmt.Errorf("convert model %q to glTF: %w", inputPath, err)
+	}
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	err = writeConversionBinary(ctx, outputPath, maxOutputBytes, func(w io.Writer) error {
+		encoder := gltf.NewEncoder(w)
+		encoder.AsBinary = format == "glb"
+		return encoder.Encode(document)
+	})
+	if err != nil {
+		return conversionOutputError("model glTF", err)
+	}
+	return nil
+}
+
+// encodeCOM3D2ModelGLTFDocument 从 Model 构建 -X 镜像后的右手坐标 glTF 文档
+// 骨骼按原顺序成为节点 0 到 n-1，网格挂在名为 Model.Name 的独立根节点上
+// encodeCOM3D2ModelGLTFDocument builds a right-handed glTF document mirrored along -X from a Model
+// Bones become nodes 0 through n-1 in their original order, and the mesh hangs from a separate root node named after Model.Name
+func encodeCOM3D2ModelGLTFDocument(model *COM3D2.Model) (*gltf.Document, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model is null")
+	}
+	if len(model.Vertices) == 0 {
+		return nil, fmt.Errorf("model has no vertices")
+	}
+	if len(model.BoneWeights) != len(model.Vertices) {
+		return nil, fmt.Errorf("model has %d vertices but %d bone weights", len(model.Vertices), len(model.BoneWeights))
+	}
+	if len(model.BoneNames) == 0 {
+		return nil, fmt.Errorf("model has no BoneNames; a skinned glTF needs at least one joint")
+	}
+	if len(model.BoneNames) != len(model.BindPoses) {
+		return nil, fmt.Errorf("model has %d BoneNames but %d BindPoses", len(model.BoneNames), len(model.BindPoses))
+	}
+	document := gltf.NewDocument()
+	document.Asset.Generator = "MeidoSerialization"
+	document.Scenes[0].Nodes = nil
+
+	nodeByName := make(map[string]int, len(model.Bones))
+	for boneIndex, bone := range model.Bones {
+		if bone == nil {
+			return nil, fmt.Errorf("Bones[%d] is null", boneIndex)
+		}
+		node := &gltf.Node{
+			Name:        bone.Name,
+			Translation: [3]float64{float64(-bone.Position.X), float64(bone.Position.Y), float64(bone.Position.Z)},
+			Rotation:    [4]float64{float64(bone.Rotation.X), float64(-bone.Rotation.Y), float64(-bone.Rotation.Z), float64(bone.Rotation.W)},
+			Scale:       gltf.DefaultScale,
+		}
+		boneExtras := com3d2BoneExtras{HasScale: bone.HasScale, LocalScale: bone.Scale != nil}
+		if node.Rotation == ([4]float64{}) {
+			node.Rotation = gltf.DefaultRotation
+		}
+		// glTF 编码器省略默认平移和旋转，导入时读到的正零会改变镜像分量的符号位，因此保存原值
+		// The glTF encoder omits default translations and rotations, and the positive zeros read back would flip the sign bit of mirrored components, so the raw values are saved
+		if node.Translation == ([3]float64{}) {
+			position := bone.Position
+			boneExtras.Position = &position
+		}
+		if node.Rotation == gltf.DefaultRotation {
+			rotation := bone.Rotation
+			boneExtras.Rotation = &rotation
+		}
+		node.Extras = map[string]interface{}{com3d2BoneExtrasKey: boneExtras}
+		if bone.Scale != nil {
+			node.Scale = [3]float64{float64(bone.Scale.X), float64(bone.Scale.Y), float64(bone.Scale.Z)}
+		}
+		if _, exists := nodeByName[bone.Name]; !exists {
+			nodeByName[bone.Name] = boneIndex
+		}
+		document.Nodes = append(document.Nodes, node)
+	}
+	for boneIndex, bone := range model.Bones {
+		parentIndex := int64(bone.ParentIndex)
+		if parentIndex < 0 {
+			document.Scenes[0].Nodes = append(document.Scenes[0].Nodes, boneIndex)
+			continue
+		}
+		if parentIndex >= int64(len(model.Bones)) || parentIndex == int64(boneIndex) {
+			return nil, fmt.Errorf("Bones[%d] %q has invalid parent index %d", boneIndex, bone.Name, parentIndex)
+		}
+		parent := document.Nodes[parentIndex]
+		parent.Children = append(parent.Children, boneIndex)
+	}
+	if err := validateCOM3D2GLTFNodeTree(document, len(model.Bones)); err != nil {
+		return nil, err
+	}
+
+	// 网格引用但骨架中不存在的骨骼名合成为根级关节节点，让蒙皮仍能完整引用
+	// Mesh bone names missing from the skeleton become synthesized root-level joint nodes so the skin can still reference them all
+	joints := make([]int, len(model.BoneNames))
+	for boneIndex, boneName := range model.BoneNames {
+		nodeIndex, ok := nodeByName[boneName]
+		if !ok {
+			nodeIndex = len(document.Nodes)
+			document.Nodes = append(document.Nodes, &gltf.Node{
+				Name:   boneName,
+				Extras: map[string]interface{}{com3d2JointExtrasKey: true},
+			})
+			document.Scenes[0].Nodes = append(document.Scenes[0].Nodes, nodeIndex)
+			nodeByName[boneName] = nodeIndex
+		}
+		joints[boneIndex] = nodeIndex
+	}
+
+	extras := &com3d2ModelExtras{
+		Signature:         model.Signature,
+		Version:           model.Version,
+		Name:              model.Name,
+		RootBoneName:      model.RootBoneName,
+		ShadowCastingMode: model.ShadowCastingMode,
+		SkinThickness:     model.SkinThickness,
+	}
+	attributes, err := buildCOM3D2GLTFAttributes(document, model, extras)
+	if err != nil {
+		return nil, err
+	}
+	targets, targetNames, morphExtras, err := buildCOM3D2GLTFMorphTargets(document, model)
+	if err != nil {
+		return nil, err
+	}
+
+	for materialIndex, material := range model.Materials {
+		if material == nil {
+			return nil, fmt.Errorf("Materials[%d] is null", materialIndex)
+		}
+		document.Materials = append(document.Materials, &gltf.Material{
+			Name:                 material.Name,
+			PBRMetallicRoughness: &gltf.PBRMetallicRoughness{},
+			Extras:               map[string]interface{}{com3d2MaterialExtrasKey: material},
+		})
+	}
+
+	primitives := make([]*gltf.Primitive, 0, len(model.SubMeshes))
+	for subMeshIndex, subMesh := range model.SubMeshes {
+		if len(subMesh) == 0 {
+			return nil, fmt.Errorf("SubMeshes[%d] is empty; a glTF primitive needs at least one triangle", subMeshIndex)
+		}
+		if len(subMesh)%3 != 0 {
+			return nil, fmt.Errorf("SubMeshes[%d] index count %d is not divisible by three", subMeshIndex, len(subMesh))
+		}
+		indices := make([]uint32, len(subMesh))
+		for indexIndex, index := range subMesh {
+			if index < 0 || int64(index) >= int64(len(model.Vertices)) {
+				return nil, fmt.Errorf("SubMeshes[%d][%d] targets vertex %d outside %d vertices", subMeshIndex, indexIndex, index, len(model.Vertices))
+			}
+			indices[indexIndex] = uint32(index)
+		}
+		for index := int64(0); index < int64(len(indices)); index += 3 {
+			indices[index+1], indices[index+2] = indices[index+2], indices[index+1]
+		}
+		primitive := &gltf.Primitive{
+			Attributes: attributes,
+			Indices:    gltf.Index(modeler.WriteIndices(document, indices)),
+			Mode:       gltf.PrimitiveTriangles,
+			Targets:    targets,
+		}
+		if subMeshIndex < len(model.Materials) {
+			primitive.Material = gltf.Index(subMeshIndex)
+		}
+		primitives = append(primitives, primitive)
+	}
+	if len(primitives) == 0 {
+		return nil, fmt.Errorf("model has no SubMeshes")
+	}
+
+	mesh := &gltf.Mesh{Name: model.Name, Primitives: primitives}
+	if len(targetNames) != 0 {
+		mesh.Weights = make([]float64, len(targetNames))
+		meshExtras := map[string]interface{}{"targetNames": targetNames}
+		if morphExtras != nil {
+			meshExtras[com3d2MorphsExtrasKey] = morphExtras
+		}
+		mesh.Extras = meshExtras
+	}
+	document.Meshes = append(document.Meshes, mesh)
+
+	inverseBindMatrices := make([][4][4]float32, len(model.BindPoses))
+	for matrixIndex, matrix := range model.BindPoses {
+		inverseBindMatrices[matrixIndex] = gltfMatrixFromCOM3D2(mirrorCOM3D2MatrixX(matrix))
+	}
+	document.Skins = append(document.Skins, &gltf.Skin{
+		Name:                model.Name,
+		Joints:              joints,
+		InverseBindMatrices: gltf.Index(modeler.WriteAccessor(document, gltf.TargetNone, inverseBindMatrices)),
+	})
+	document.Nodes = append(document.Nodes, &gltf.Node{Name: model.Name, Mesh: gltf.Index(0), Skin: gltf.Index(0)})
+	document.Scenes[0].Nodes = append(document.Scenes[0].Nodes, len(document.Nodes)-1)
+
+	document.Scenes[0].Extras = map[string]interface{}{COM3D2ModelExtrasKey: extras}
+	return document, nil
+}
+
+// validateCOM3D2GLTFNodeTree 验证骨骼节点从场景根出发恰好每个可达一次，防止环和多父节点
+// validateCOM3D2GLTFNodeTree verifies every bone node is reachable exactly once from the scene roots, preventing cycles and multiple parents
+func validateCOM3D2GLTFNodeTree(document *gltf.Document, nodeCount int) error {
+	visited := make([]bool, nodeCount)
+	var walk func(nodeIndex int) error
+	walk = func(nodeIndex int) error {
+		if visited[nodeIndex] {
+			return fmt.Errorf("bone %q is reachable through more than one path", document.Nodes[nodeIndex].Name)
+		}
+		visited[nodeIndex] = true
+		for _, childIndex := range document.Nodes[nodeIndex].Children {
+			if err := walk(childIndex); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, rootIndex := range document.Scenes[0].Nodes {
+		if err := walk(rootIndex); err != nil {
+			return err
+		}
+	}
+	for nodeIndex := 0; nodeIndex < nodeCount; nodeIndex++ {
+		if !visited[nodeIndex] {
+			return fmt.Errorf("bone %q is not reachable from any root; its parent chain forms a cycle", document.Nodes[nodeIndex].Name)
+		}
+	}
+	return nil
+}
+
+// buildCOM3D2GLTFAttributes 将镜像后的全部顶点属性写入文档并返回共享属性表
+// UV 翻转在 float32 下不可精确逆转，因此原始 UV 同时写入 _COM3D2_ 前缀的自定义属性
+// buildCOM3D2GLTFAttributes writes every mirrored vertex attribute into the document and returns the shared attribute map
+// The UV flip is not exactly invertible in float32, so raw UVs are also written to custom attributes prefixed with _COM3D2_
+func buildCOM3D2GLTFAttributes(document *gltf.Document, model *COM3D2.Model, extras *com3d2ModelExtras) (gltf.PrimitiveAttributes, error) {
+	vertexCount := len(model.Vertices)
+	positions := make([][3]float32, vertexCount)
+	normals := make([][3]float32, vertexCount)
+	for vertexIndex, vertex := range model.Vertices {
+		positions[vertexIndex] = [3]float32{-vertex.Position.X, vertex.Position.Y, vertex.Position.Z}
+		normals[vertexIndex] = [3]float32{-vertex.Normal.X, vertex.Normal.Y, vertex.Normal.Z}
+	}
+	attributes := gltf.PrimitiveAttributes{
+		gltf.POSITION: modeler.WritePosition(document, positions),
+		gltf.NORMAL:   modeler.WriteNormal(document, normals),
+	}
+
+	if len(model.Tangents) == vertexCount {
+		tangents := make([][4]float32, vertexCount)
+		for vertexIndex, tangent := range model.Tangents {
+			tangents[vertexIndex] = [4]float32{-tangent.X, tangent.Y, tangent.Z, -tangent.W}
+		}
+		attributes[gltf.TANGENT] = modeler.WriteTangent(document, tangents)
+	} else if len(model.Tangents) != 0 {
+		extras.Tangents = model.Tangents
+	}
+
+	writeUVSet := func(channel string, setIndex int) error {
+		coords := make([][2]float32, vertexCount)
+		raw := make([][2]float32, vertexCount)
+		for vertexIndex := range model.Vertices {
+			value := com3d2VertexChannel(&model.Vertices[vertexIndex], channel)
+			if value == nil {
+				return fmt.Errorf("vertex %d has no %s while vertex 0 does; channel presence must be uniform", vertexIndex, channel)
+			}
+			raw[vertexIndex] = [2]float32{value.X, value.Y}
+			coords[vertexIndex] = [2]float32{value.X, 1 - value.Y}
+		}
+		attributes[fmt.Sprintf("TEXCOORD_%d", setIndex)] = modeler.WriteTextureCoord(document, coords)
+		attributes["_COM3D2_"+strings.ToUpper(channel)] = modeler.WriteAccessor(document, gltf.TargetArrayBuffer, raw)
+		return nil
+	}
+	if err := writeUVSet("UV", 0); err != nil {
+		return nil, err
+	}
+	for _, channel := range com3d2ExtendedUVChannels {
+		if com3d2VertexChannel(&model.Vertices[0], channel) == nil {
+			continue
+		}
+		extras.UVChannels = append(extras.UVChannels, channel)
+		if err := writeUVSet(channel, len(extras.UVChannels)); err != nil {
+			return nil, err
+		}
+	}
+	for _, channel := range com3d2UnknownChannels {
+		if com3d2VertexChannel(&model.Vertices[0], channel) == nil {
+			continue
+		}
+		values := make([][2]float32, vertexCount)
+		for vertexIndex := range model.Vertices {
+			value := com3d2VertexChannel(&model.Vertices[vertexIndex], channel)
+			if value == nil {
+				return nil, fmt.Errorf("vertex %d has no %s while vertex 0 does; channel presence must be uniform", vertexIndex, channel)
+			}
+			values[vertexIndex] = [2]float32{value.X, value.Y}
+		}
+		attributes["_COM3D2_"+strings.ToUpper(channel)] = modeler.WriteAccessor(document, gltf.TargetArrayBuffer, values)
+	}
+
+	joints := make([][4]uint16, vertexCount)
+	weights := make([][4]float32, vertexCount)
+	for vertexIndex, weight := range model.BoneWeights {
+		joints[vertexIndex] = [4]uint16{weight.BoneIndex0, weight.BoneIndex1, weight.BoneIndex2, weight.BoneIndex3}
+		weights[vertexIndex] = [4]float32{weight.Weight0, weight.Weight1, weight.Weight2, weight.Weight3}
+		for _, boneIndex := range joints[vertexIndex] {
+			if int64(boneIndex) >= int64(len(model.BoneNames)) {
+				return nil, fmt.Errorf("vertex %d references bone %d outside %d BoneNames", vertexIndex, boneIndex, len(model.BoneNames))
+			}
+		}
+	}
+	attributes[gltf.JOINTS_0] = modeler.WriteJoints(document, joints)
+	attributes[gltf.WEIGHTS_0] = modeler.WriteWeights(document, weights)
+	return attributes, nil
+}
+
+// com3d2VertexChannel 按通道名返回顶点的 UV 或扩展 Vector2 字段指针
+// com3d2VertexChannel returns a vertex's UV or extended Vector2 field pointer by channel name
+func com3d2VertexChannel(vertex *COM3D2.Vertex, channel string) *COM3D2.Vector2 {
+	switch channel {
+	case "UV":
+		return &vertex.UV
+	case "UV2":
+		return vertex.UV2
+	case "UV3":
+		return vertex.UV3
+	case "UV4":
+		return vertex.UV4
+	case "Unknown1":
+		return vertex.Unknown1
+	case "Unknown2":
+		return vertex.Unknown2
+	case "Unknown3":
+		return vertex.Unknown3
+	case "Unknown4":
+		return vertex.Unknown4
+	default:
+		return nil
+	}
+}
+
+// setCOM3D2VertexChannel 按通道名写入顶点的 UV 或扩展 Vector2 字段
+// setCOM3D2VertexChannel writes a vertex's UV or extended Vector2 field by channel name
+func setCOM3D2VertexChannel(vertex *COM3D2.Vertex, channel string, value COM3D2.Vector2) {
+	switch channel {
+	case "UV":
+		vertex.UV = value
+	case "UV2":
+		vertex.UV2 = &value
+	case "UV3":
+		vertex.UV3 = &value
+	case "UV4":
+		vertex.UV4 = &value
+	case "Unknown1":
+		vertex.Unknown1 = &value
+	case "Unknown2":
+		vertex.Unknown2 = &value
+	case "Unknown3":
+		vertex.Unknown3 = &value
+	case "Unknown4":
+		vertex.Unknown4 = &value
+	}
+}
+
+// buildCOM3D2GLTFMorphTargets 将 MorphData 的稀疏差分展开为稠密 glTF 变形目标
+// 非升序、含零差分的索引列表和切线 W 差分记录在返回的 extras 中，全部可推导时返回 nil
+// buildCOM3D2GLTFMorphTargets expands sparse MorphData deltas into dense glTF morph targets
+// Index lists that are unsorted or contain zero deltas and tangent W deltas are recorded in the returned extras, which is nil when everything is derivable
+func buildCOM3D2GLTFMorphTargets(document *gltf.Document, model *COM3D2.Model) ([]gltf.PrimitiveAttributes, []string, []com3d2MorphExtras, error) {
+	if len(model.MorphData) == 0 {
+		return nil, nil, nil, nil
+	}
+	vertexCount := len(model.Vertices)
+	targets := make([]gltf.PrimitiveAttributes, 0, len(model.MorphData))
+	names := make([]string, 0, len(model.MorphData))
+	morphExtras := make([]com3d2MorphExtras, len(model.MorphData))
+	needExtras := false
+	for morphIndex, morph := range model.MorphData {
+		if morph == nil {
+			return nil, nil, nil, fmt.Errorf("MorphData[%d] is null", morphIndex)
+		}
+		if len(morph.Vertex) != len(morph.Indices) || len(morph.Normals) != len(morph.Indices) {
+			return nil, nil, nil, fmt.Errorf("morph %q has %d vertex and %d normal deltas for %d indices", morph.Name, len(morph.Vertex), len(morph.Normals), len(morph.Indices))
+		}
+		hasTangents := morph.Tangents != nil
+		if hasTangents && len(morph.Tangents) != len(morph.Indices) {
+			return nil, nil, nil, fmt.Errorf("morph %q has %d tangent deltas for %d indices", morph.Name, len(morph.Tangents), len(morph.Indices))
+		}
+		deltaPositions := make([][3]float32, vertexCount)
+		deltaNormals := make([][3]float32, vertexCount)
+		var deltaTangents [][3]float32
+		if hasTangents {
+			deltaTangents = make([][3]float32, vertexCount)
+		}
+		seen := make([]bool, vertexCount)
+		tangentW := make([]float32, len(morph.Indices))
+		hasTangentW := false
+		for entryIndex, vertexIndex := range morph.Indices {
+			if vertexIndex < 0 || int64(vertexIndex) >= int64(vertexCount) {
+				return nil, nil, nil, fmt.Errorf("morph %q targets vertex %d outside %d vertices", morph.Name, vertexIndex, vertexCount)
+			}
+			if seen[vertexIndex] {
+				return nil, nil, nil, fmt.Errorf("morph %q lists vertex %d more than once; dense glTF morph targets cannot hold duplicate indices", morph.Name, vertexIndex)
+			}
+			seen[vertexIndex] = true
+			deltaPositions[vertexIndex] = [3]float32{-morph.Vertex[entryIndex].X, morph.Vertex[entryIndex].Y, morph.Vertex[entryIndex].Z}
+			deltaNormals[vertexIndex] = [3]float32{-morph.Normals[entryIndex].X, morph.Normals[entryIndex].Y, morph.Normals[entryIndex].Z}
+			if hasTangents {
+				tangent := morph.Tangents[entryIndex]
+				deltaTangents[vertexIndex] = [3]float32{-tangent.X, tangent.Y, tangent.Z}
+				tangentW[entryIndex] = tangent.W
+				hasTangentW = hasTangentW || tangent.W != 0
+			}
+		}
+
+		// 只有原索引恰为非零差分的升序集合时才能从稠密目标推导，否则保存原索引
+		// The original indices can be derived from the dense target only when they are exactly the ascending set of nonzero deltas; otherwise they are saved
+		derivable := true
+		next := 0
+		for vertexIndex := 0; vertexIndex < vertexCount && derivable; vertexIndex++ {
+			var tangent [3]float32
+			if hasTangents {
+				tangent = deltaTangents[vertexIndex]
+			}
+			if !com3d2MorphDeltaIsZero(deltaPositions[vertexIndex], deltaNormals[vertexIndex], tangent) {
+				derivable = next < len(morph.Indices) && morph.Indices[next] == int32(vertexIndex)
+				next++
+			}
+		}
+		derivable = derivable && next == len(morph.Indices)
+		if !derivable || hasTangentW {
+			morphExtras[morphIndex].Indices = append([]int32(nil), morph.Indices...)
+			needExtras = true
+		}
+		if hasTangentW {
+			morphExtras[morphIndex].TangentW = tangentW
+		}
+
+		target := gltf.PrimitiveAttributes{
+			gltf.POSITION: writeCOM3D2MorphPositionAccessor(document, deltaPositions),
+			gltf.NORMAL:   modeler.WriteAccessor(document, gltf.TargetArrayBuffer, deltaNormals),
+		}
+		if hasTangents {
+			target[gltf.TANGENT] = modeler.WriteAccessor(document, gltf.TargetArrayBuffer, deltaTangents)
+		}
+		targets = append(targets, target)
+		names = append(names, morph.Name)
+	}
+	if !needExtras {
+		morphExtras = nil
+	}
+	return targets, names, morphExtras, nil
+}
+
+// com3d2MorphDeltaIsZero 判断一个顶点的位置、法线和切线差分是否全为零
+// com3d2MorphDeltaIsZero reports whether a vertex's position, normal, and tangent deltas are all zero
+func com3d2MorphDeltaIsZero(position [3]float32, normal [3]float32, tangent [3]float32) bool {
+	return position == [3]float32{} && normal == [3]float32{} && tangent == [3]float32{}
+}
+
+// writeCOM3D2MorphPositionAccessor 写入变形目标位置差分访问器并按规范补充 min 和 max 边界
+// writeCOM3D2MorphPositionAccessor writes a morph-target position delta accessor with the min and max bounds the specification requires
+func writeCOM3D2MorphPositionAccessor(document *gltf.Document, deltas [][3]float32) int {
+	accessorIndex := modeler.WriteAccessor(document, gltf.TargetArrayBuffer, deltas)
+	minBounds := []float64{0, 0, 0}
+	maxBounds := []float64{0, 0, 0}
+	for deltaIndex, delta := range deltas {
+		for axis := 0; axis < 3; axis++ {
+			value := float64(delta[axis])
+			if deltaIndex == 0 || value < minBounds[axis] {
+				minBounds[axis] = value
+			}
+			if deltaIndex == 0 || value > maxBounds[axis] {
+				maxBounds[axis] = value
+			}
+		}
+	}
+	document.Accessors[accessorIndex].Min = minBounds
+	document.Accessors[accessorIndex].Max = maxBounds
+	return accessorIndex
+}
+
+// mirrorCOM3D2MatrixX 用 diag(-1,1,1,1) 相似变换将矩阵在 X 轴镜像，行列主序均适用
+// mirrorCOM3D2MatrixX mirrors a matrix along the X axis with a diag(-1,1,1,1) similarity transform, which holds for either storage order
+func mirrorCOM3D2MatrixX(matrix COM3D2.Matrix4x4) COM3D2.Matrix4x4 {
+	signs := [4]float32{-1, 1, 1, 1}
+	var result COM3D2.Matrix4x4
+	for row := 0; row < 4; row++ {
+		for column := 0; column < 4; column++ {
+			result[row*4+column] = signs[row] * matrix[row*4+column] * signs[column]
+		}
+	}
+	return result
+}
+
+// gltfMatrixFromCOM3D2 将按 Unity 列主序下标保存的 Matrix4x4 转为 qmuntal 的矩阵，两者内存顺序相同
+// gltfMatrixFromCOM3D2 converts a Matrix4x4 stored in Unity's column-major index order to a qmuntal matrix with the same memory order
+func gltfMatrixFromCOM3D2(matrix COM3D2.Matrix4x4) [4][4]float32 {
+	var result [4][4]float32
+	for index := 0; index < 16; index++ {
+		result[index/4][index%4] = matrix[index]
+	}
+	return result
+}
+
+// com3d2MatrixFromGLTF 将 qmuntal 读取的矩阵按相同内存顺序转回 Matrix4x4
+// com3d2MatrixFromGLTF converts a matrix read by qmuntal back to a Matrix4x4 with the same memory order
+func com3d2MatrixFromGLTF(matrix [4][4]float32) COM3D2.Matrix4x4 {
+	var result COM3D2.Matrix4x4
+	for index := 0; index < 16; index++ {
+		result[index] = matrix[index/4][index%4]
+	}
+	return result
+}
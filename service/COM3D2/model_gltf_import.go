@@ -0,0 +1,963 @@
+package COM3D2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// ConvertGLTFToModel 将 glTF 或 GLB 转换为 .model 文件
+// convert2gltf 写入的 extras 会被还原；缺少 extras 的文件按 2001 版本构建，存在扩展 UV 通道时提升到 2101
+// ConvertGLTFToModel converts glTF or GLB into a .model file
+// Extras written by convert2gltf are restored; files without them are built as version 2001, raised to 2101 when extended UV channels are present
+func (m *ModelService) ConvertGLTFToModel(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	document, err := gltf.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("open glTF %q: %w", inputPath, err)
+	}
+	modelData, err := decodeCOM3D2GLTFModelDocument(document)
+	if err != nil {
+		return fmt.Errorf("convert glTF %q to model: %w", inputPath, err)
+	}
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	if err := writeConversionBinary(ctx, outputPath, maxOutputBytes, modelData.Dump); err != nil {
+		return conversionOutputError("model", err)
+	}
+	return nil
+}
+
+// IsCOM3D2ModelGLTFDocument 判断 glTF 文件的场景 extras 是否带有 convert2gltf 写入的 com3d2Model 字段
+// IsCOM3D2ModelGLTFDocument reports whether a glTF file's scene extras carry the com3d2Model field written by convert2gltf
+func IsCOM3D2ModelGLTFDocument(path string) bool {
+	document, err := gltf.Open(path)
+	if err != nil {
+		return false
+	}
+	extras, err := parseCOM3D2ModelExtras(document)
+	return err == nil && extras != nil
+}
+
+// decodeCOM3D2GLTFModelDocument 从 glTF 文档还原左手坐标的 Model
+// decodeCOM3D2GLTFModelDocument restores a left-handed Model from a glTF document
+func decodeCOM3D2GLTFModelDocument(document *gltf.Document) (*COM3D2.Model, error) {
+	extras, err := parseCOM3D2ModelExtras(document)
+	if err != nil {
+		return nil, err
+	}
+	meshNodeIndex := -1
+	for nodeIndex, node := range document.Nodes {
+		if node != nil && node.Mesh != nil {
+			if meshNodeIndex >= 0 {
+				return nil, fmt.Errorf("document has more than one mesh node; exactly one is required")
+			}
+			meshNodeIndex = nodeIndex
+		}
+	}
+	if meshNodeIndex < 0 {
+		return nil, fmt.Errorf("document has no mesh node")
+	}
+	meshNode := document.Nodes[meshNodeIndex]
+	if *meshNode.Mesh < 0 || *meshNode.Mesh >= len(document.Meshes) {
+		return nil, fmt.Errorf("mesh node references mesh %d out of range", *meshNode.Mesh)
+	}
+	mesh := document.Meshes[*meshNode.Mesh]
+	var skin *gltf.Skin
+	if meshNode.Skin != nil {
+		if *meshNode.Skin < 0 || *meshNode.Skin >= len(document.Skins) {
+			return nil, fmt.Errorf("mesh node references skin %d out of range", *meshNode.Skin)
+		}
+		skin = document.Skins[*meshNode.Skin]
+		if len(skin.Joints) == 0 {
+			return nil, fmt.Errorf("skin has no joints")
+		}
+	}
+
+	model := &COM3D2.Model{Signature: COM3D2.ModelSignature, Version: 2001, Name: meshNode.Name}
+	if extras != nil {
+		model.Signature = extras.Signature
+		model.Version = extras.Version
+		model.Name = extras.Name
+		model.RootBoneName = extras.RootBoneName
+		model.ShadowCastingMode = extras.ShadowCastingMode
+		model.SkinThickness = extras.SkinThickness
+	}
+
+	bones, err := decodeCOM3D2GLTFBones(document, meshNodeIndex, skin, model.Version)
+	if err != nil {
+		return nil, err
+	}
+	model.Bones = bones
+	if extras == nil {
+		for _, bone := range bones {
+			if bone.ParentIndex < 0 {
+				model.RootBoneName = bone.Name
+				break
+			}
+		}
+	}
+
+	var uvChannels []string
+	if extras != nil {
+		uvChannels = extras.UVChannels
+	}
+	vertices, tangents, weights, subMeshes, materialRefs, pools, err := decodeCOM3D2GLTFMeshGeometry(document, mesh, uvChannels, extras == nil)
+	if err != nil {
+		return nil, err
+	}
+	model.Vertices = vertices
+	model.Tangents = tangents
+	if tangents == nil && extras != nil {
+		model.Tangents = extras.Tangents
+	}
+	model.SubMeshes = subMeshes
+	if extras == nil && model.Version < 2101 && len(vertices) != 0 {
+		for _, channel := range append(append([]string(nil), com3d2ExtendedUVChannels...), com3d2UnknownChannels...) {
+			if com3d2VertexChannel(&vertices[0], channel) != nil {
+				model.Version = 2101
+				break
+			}
+		}
+	}
+
+	if skin != nil {
+		if weights == nil {
+			return nil, fmt.Errorf("mesh node has a skin but the primitives carry no JOINTS_0 and WEIGHTS_0 attributes")
+		}
+		model.BoneNames = make([]string, len(skin.Joints))
+		for jointIndex, jointNode := range skin.Joints {
+			if jointNode < 0 || jointNode >= len(document.Nodes) || document.Nodes[jointNode] == nil {
+				return nil, fmt.Errorf("skin joint %d references node %d out of range", jointIndex, jointNode)
+			}
+			model.BoneNames[jointIndex] = document.Nodes[jointNode].Name
+		}
+		for vertexIndex, weight := range weights {
+			for _, boneIndex := range [4]uint16{weight.BoneIndex0, weight.BoneIndex1, weight.BoneIndex2, weight.BoneIndex3} {
+				if int64(boneIndex) >= int64(len(skin.Joints)) {
+					return nil, fmt.Errorf("vertex %d references joint %d outside %d skin joints", vertexIndex, boneIndex, len(skin.Joints))
+				}
+			}
+		}
+		model.BoneWeights = weights
+		model.BindPoses, err = readCOM3D2GLTFInverseBindMatrices(document, skin)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if weights != nil {
+			return nil, fmt.Errorf("primitives carry skin attributes but the mesh node has no skin")
+		}
+		// 无蒙皮场景合成绑定到根骨骼的单骨蒙皮，让网格刚性跟随根骨骼
+		// An unskinned scene synthesizes a single-bone skin bound to the root bone so the mesh follows it rigidly
+		boneName := model.RootBoneName
+		if boneName == "" {
+			boneName = model.Name
+		}
+		model.BoneNames = []string{boneName}
+		model.BindPoses = []COM3D2.Matrix4x4{{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}}
+		model.BoneWeights = make([]COM3D2.BoneWeight, len(vertices))
+		for vertexIndex := range model.BoneWeights {
+			model.BoneWeights[vertexIndex].Weight0 = 1
+		}
+	}
+
+	model.Materials, err = decodeCOM3D2GLTFMaterials(document, materialRefs)
+	if err != nil {
+		return nil, err
+	}
+	model.MorphData, err = decodeCOM3D2GLTFMorphTargets(document, mesh, len(vertices), pools, model.Version >= 2102)
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// parseCOM3D2ModelExtras 解析默认场景 extras 中的 com3d2Model 字段
+// parseCOM3D2ModelExtras parses the com3d2Model field in the default scene's extras
+func parseCOM3D2ModelExtras(document *gltf.Document) (*com3d2ModelExtras, error) {
+	if len(document.Scenes) == 0 {
+		return nil, nil
+	}
+	sceneIndex := 0
+	if document.Scene != nil {
+		sceneIndex = *document.Scene
+	}
+	if sceneIndex < 0 || sceneIndex >= len(document.Scenes) || document.Scenes[sceneIndex] == nil {
+		return nil, nil
+	}
+	extras := &com3d2ModelExtras{}
+	found, err := decodeCOM3D2GLTFExtras(document.Scenes[sceneIndex].Extras, COM3D2ModelExtrasKey, extras)
+	if err != nil || !found {
+		return nil, err
+	}
+	return extras, nil
+}
+
+// decodeCOM3D2GLTFExtras 把 extras 对象中指定键的值重新解码到 target，键不存在时返回 false
+// decodeCOM3D2GLTFExtras re-decodes the value under a key of an extras object into target and returns false when the key is absent
+func decodeCOM3D2GLTFExtras(extras interface{}, key string, target interface{}) (bool, error) {
+	extrasMap, ok := extras.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	raw, ok := extrasMap[key]
+	if !ok {
+		return false, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return false, fmt.Errorf("encode %s extras: %w", key, err)
+	}
+	if err := json.Unmarshal(encoded, target); err != nil {
+		return false, fmt.Errorf("parse %s extras: %w", key, err)
+	}
+	return true, nil
+}
+
+// decodeCOM3D2GLTFBones 按文档节点顺序还原骨骼列表
+// 带 com3d2Bone extras 的节点和蒙皮关节是骨骼；都不存在时场景中除网格节点外的全部节点都视为骨骼
+// 其余节点（如 Blender 的骨架对象节点）被跳过，其子骨骼挂到最近的骨骼祖先上
+// decodeCOM3D2GLTFBones restores the bone list in document node order
+// Nodes with com3d2Bone extras and skin joints are bones; when neither exists, every scene node except the mesh node counts as a bone
+// Other nodes, such as Blender's armature object node, are skipped and their child bones attach to the nearest bone ancestor
+func decodeCOM3D2GLTFBones(document *gltf.Document, meshNodeIndex int, skin *gltf.Skin, version int32) ([]*COM3D2.Bone, error) {
+	reachable, parents, err := collectCOM3D2GLTFSceneNodes(document)
+	if err != nil {
+		return nil, err
+	}
+	jointNodes := make(map[int]bool)
+	if skin != nil {
+		for _, jointNode := range skin.Joints {
+			jointNodes[jointNode] = true
+		}
+	}
+	boneExtras := make(map[int]*com3d2BoneExtras)
+	synthesized := make(map[int]bool)
+	for nodeIndex, node := range document.Nodes {
+		if node == nil || !reachable[nodeIndex] {
+			continue
+		}
+		extras := &com3d2BoneExtras{}
+		found, err := decodeCOM3D2GLTFExtras(node.Extras, com3d2BoneExtrasKey, extras)
+		if err != nil {
+			return nil, fmt.Errorf("node %q: %w", node.Name, err)
+		}
+		if found {
+			boneExtras[nodeIndex] = extras
+		}
+		var joint bool
+		if found, err := decodeCOM3D2GLTFExtras(node.Extras, com3d2JointExtrasKey, &joint); err != nil {
+			return nil, fmt.Errorf("node %q: %w", node.Name, err)
+		} else if found && joint {
+			synthesized[nodeIndex] = true
+		}
+	}
+	identified := len(boneExtras) != 0 || len(jointNodes) != 0
+	isBone := func(nodeIndex int) bool {
+		if nodeIndex == meshNodeIndex || synthesized[nodeIndex] || !reachable[nodeIndex] {
+			return false
+		}
+		if !identified {
+			return true
+		}
+		_, hasExtras := boneExtras[nodeIndex]
+		return hasExtras || jointNodes[nodeIndex]
+	}
+
+	boneIndexByNode := make(map[int]int32)
+	var boneNodes []int
+	for nodeIndex := range document.Nodes {
+		if isBone(nodeIndex) {
+			boneIndexByNode[nodeIndex] = int32(len(boneNodes))
+			boneNodes = append(boneNodes, nodeIndex)
+		}
+	}
+	bones := make([]*COM3D2.Bone, len(boneNodes))
+	for boneIndex, nodeIndex := range boneNodes {
+		node := document.Nodes[nodeIndex]
+		if node.Matrix != ([16]float64{}) && node.Matrix != gltf.DefaultMatrix {
+			return nil, fmt.Errorf("node %q uses a matrix transform; decompose it to translation, rotation, and scale before converting", node.Name)
+		}
+		translation := node.TranslationOrDefault()
+		rotation := node.RotationOrDefault()
+		scale := node.ScaleOrDefault()
+		parentIndex := int32(-1)
+		for ancestor, ok := parents[nodeIndex]; ok; ancestor, ok = parents[ancestor] {
+			if parentBone, isParentBone := boneIndexByNode[ancestor]; isParentBone {
+				parentIndex = parentBone
+				break
+			}
+		}
+		bone := &COM3D2.Bone{
+			Name:        node.Name,
+			ParentIndex: parentIndex,
+			Position:    COM3D2.Vector3{X: float32(-translation[0]), Y: float32(translation[1]), Z: float32(translation[2])},
+			Rotation:    COM3D2.Quaternion{X: float32(rotation[0]), Y: float32(-rotation[1]), Z: float32(-rotation[2]), W: float32(rotation[3])},
+		}
+		localScale := version >= 2001 && scale != gltf.DefaultScale
+		if extras, ok := boneExtras[nodeIndex]; ok {
+			bone.HasScale = extras.HasScale
+			localScale = extras.LocalScale
+			if extras.Position != nil && translation == ([3]float64{}) {
+				bone.Position = *extras.Position
+			}
+			if extras.Rotation != nil && rotation == gltf.DefaultRotation {
+				bone.Rotation = *extras.Rotation
+			}
+		}
+		if localScale {
+			bone.Scale = &COM3D2.Vector3{X: float32(scale[0]), Y: float32(scale[1]), Z: float32(scale[2])}
+		}
+		bones[boneIndex] = bone
+	}
+	return bones, nil
+}
+
+// collectCOM3D2GLTFSceneNodes 收集默认场景可达的节点并返回节点的父子关系
+// collectCOM3D2GLTFSceneNodes collects the nodes reachable from the default scene and returns the parent relationships
+func collectCOM3D2GLTFSceneNodes(document *gltf.Document) (map[int]bool, map[int]int, error) {
+	if len(document.Scenes) == 0 {
+		return nil, nil, fmt.Errorf("document has no scene")
+	}
+	sceneIndex := 0
+	if document.Scene != nil {
+		sceneIndex = *document.Scene
+	}
+	if sceneIndex < 0 || sceneIndex >= len(document.Scenes) {
+		return nil, nil, fmt.Errorf("default scene %d out of range", sceneIndex)
+	}
+	reachable := make(map[int]bool)
+	parents := make(map[int]int)
+	var walk func(nodeIndex int) error
+	walk = func(nodeIndex int) error {
+		if nodeIndex < 0 || nodeIndex >= len(document.Nodes) || document.Nodes[nodeIndex] == nil {
+			return fmt.Errorf("node index %d out of range", nodeIndex)
+		}
+		if reachable[nodeIndex] {
+			return fmt.Errorf("node %q appears in the hierarchy more than once", document.Nodes[nodeIndex].Name)
+		}
+		reachable[nodeIndex] = true
+		for _, childIndex := range document.Nodes[nodeIndex].Children {
+			parents[childIndex] = nodeIndex
+			if err := walk(childIndex); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, rootIndex := range document.Scenes[sceneIndex].Nodes {
+		if err := walk(rootIndex); err != nil {
+			return nil, nil, err
+		}
+	}
+	return reachable, parents, nil
+}
+
+// readCOM3D2GLTFInverseBindMatrices 读取蒙皮的逆绑定矩阵并镜像回 Unity 左手坐标
+// readCOM3D2GLTFInverseBindMatrices reads the skin's inverse bind matrices and mirrors them back to Unity left-handed coordinates
+func readCOM3D2GLTFInverseBindMatrices(document *gltf.Document, skin *gltf.Skin) ([]COM3D2.Matrix4x4, error) {
+	bindPoses := make([]COM3D2.Matrix4x4, len(skin.Joints))
+	if skin.InverseBindMatrices == nil {
+		for matrixIndex := range bindPoses {
+			bindPoses[matrixIndex] = COM3D2.Matrix4x4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+		}
+		return bindPoses, nil
+	}
+	if *skin.InverseBindMatrices < 0 || *skin.InverseBindMatrices >= len(document.Accessors) {
+		return nil, fmt.Errorf("skin inverseBindMatrices accessor %d out of range", *skin.InverseBindMatrices)
+	}
+	matrices, err := modeler.ReadInverseBindMatrices(document, document.Accessors[*skin.InverseBindMatrices], nil)
+	if err != nil {
+		return nil, fmt.Errorf("read inverse bind matrices: %w", err)
+	}
+	if len(matrices) < len(skin.Joints) {
+		return nil, fmt.Errorf("inverse bind matrix count %d is smaller than joint count %d", len(matrices), len(skin.Joints))
+	}
+	for matrixIndex := range bindPoses {
+		bindPoses[matrixIndex] = mirrorCOM3D2MatrixX(com3d2MatrixFromGLTF(matrices[matrixIndex]))
+	}
+	return bindPoses, nil
+}
+
+// com3d2GLTFPrimitivePool 记录一组共享访问器的图元在合并顶点池中的基址 / com3d2GLTFPrimitivePool records the base offset of primitives sharing accessors within the merged vertex pool
+type com3d2GLTFPrimitivePool struct {
+	Base        int  // 顶点池基址 / Vertex pool base offset
+	VertexCount int  // 顶点数量 / Vertex count
+	First       bool // 是否为该组的第一个图元 / Whether this is the group's first primitive
+}
+
+// decodeCOM3D2GLTFMeshGeometry 读取全部图元并合并为共享顶点池，每个图元成为一个子网格
+// 返回的权重在图元没有 JOINTS_0 时为 nil，材质引用为每个图元的 glTF 材质索引，没有材质时为 -1
+// decodeCOM3D2GLTFMeshGeometry reads every primitive into a shared vertex pool, with each primitive becoming one submesh
+// The returned weights are nil when primitives carry no JOINTS_0, and material references hold each primitive's glTF material index or -1 without a material
+func decodeCOM3D2GLTFMeshGeometry(document *gltf.Document, mesh *gltf.Mesh, uvChannels []string, inferChannels bool) ([]COM3D2.Vertex, []COM3D2.Quaternion, []COM3D2.BoneWeight, [][]int32, []int, []*com3d2GLTFPrimitivePool, error) {
+	if mesh == nil || len(mesh.Primitives) == 0 {
+		return nil, nil, nil, nil, nil, nil, fmt.Errorf("mesh has no primitives")
+	}
+	if inferChannels {
+		uvChannels = nil
+		for setIndex := range com3d2ExtendedUVChannels {
+			if _, ok := mesh.Primitives[0].Attributes[fmt.Sprintf("TEXCOORD_%d", setIndex+1)]; !ok {
+				break
+			}
+			uvChannels = append(uvChannels, com3d2ExtendedUVChannels[setIndex])
+		}
+	}
+	geometry := &com3d2GLTFGeometry{}
+	pools := make(map[string]*com3d2GLTFPrimitivePool)
+	primitivePools := make([]*com3d2GLTFPrimitivePool, len(mesh.Primitives))
+	subMeshes := make([][]int32, 0, len(mesh.Primitives))
+	materials := make([]int, 0, len(mesh.Primitives))
+
+	reference := mesh.Primitives[0].Attributes
+	for primitiveIndex, primitive := range mesh.Primitives {
+		if primitive == nil {
+			return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive %d is null", primitiveIndex)
+		}
+		if primitive.Mode != gltf.PrimitiveTriangles {
+			return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive %d mode is not triangles", primitiveIndex)
+		}
+		for attributeName := range reference {
+			if _, ok := primitive.Attributes[attributeName]; !ok {
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive %d is missing attribute %s carried by primitive 0; all primitives must share the same attribute set", primitiveIndex, attributeName)
+			}
+		}
+		for attributeName := range primitive.Attributes {
+			if _, ok := reference[attributeName]; !ok {
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive 0 is missing attribute %s carried by primitive %d; all primitives must share the same attribute set", attributeName, primitiveIndex)
+			}
+		}
+
+		key := com3d2GLTFAttributeSignature(primitive.Attributes)
+		pool, exists := pools[key]
+		if !exists {
+			base := len(geometry.vertices)
+			vertexCount, err := geometry.appendPrimitive(document, primitive, uvChannels)
+			if err != nil {
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive %d: %w", primitiveIndex, err)
+			}
+			pool = &com3d2GLTFPrimitivePool{Base: base, VertexCount: vertexCount, First: true}
+			pools[key] = pool
+		} else {
+			pool = &com3d2GLTFPrimitivePool{Base: pool.Base, VertexCount: pool.VertexCount}
+		}
+		primitivePools[primitiveIndex] = pool
+
+		indices, err := readCOM3D2GLTFPrimitiveIndices(document, primitive, pool.VertexCount)
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive %d: %w", primitiveIndex, err)
+		}
+		subMesh := make([]int32, len(indices))
+		for index := 0; index < len(indices); index += 3 {
+			subMesh[index] = int32(indices[index]) + int32(pool.Base)
+			subMesh[index+1] = int32(indices[index+2]) + int32(pool.Base)
+			subMesh[index+2] = int32(indices[index+1]) + int32(pool.Base)
+		}
+		subMeshes = append(subMeshes, subMesh)
+
+		materialIndex := -1
+		if primitive.Material != nil {
+			if *primitive.Material < 0 || *primitive.Material >= len(document.Materials) {
+				return nil, nil, nil, nil, nil, nil, fmt.Errorf("primitive %d references material %d out of range", primitiveIndex, *primitive.Material)
+			}
+			materialIndex = *primitive.Material
+		}
+		materials = append(materials, materialIndex)
+	}
+	if len(geometry.vertices) > math.MaxUint16+1 {
+		return nil, nil, nil, nil, nil, nil, fmt.Errorf("mesh has %d vertices; COM3D2 submesh indices are UInt16 and address at most 65536", len(geometry.vertices))
+	}
+	return geometry.vertices, geometry.tangents, geometry.weights, subMeshes, materials, primitivePools, nil
+}
+
+// com3d2GLTFGeometry 累积各顶点池读取出的 Model 顶点数据 / com3d2GLTFGeometry accumulates the Model vertex data read from each vertex pool
+type com3d2GLTFGeometry struct {
+	vertices []COM3D2.Vertex     // 顶点 / Vertices
+	tangents []COM3D2.Quaternion // 顶点切线，没有 TANGENT 时为 nil / Vertex tangents, nil without TANGENT
+	weights  []COM3D2.BoneWeight // 骨骼权重，没有 JOINTS_0 时为 nil / Bone weights, nil without JOINTS_0
+}
+
+// com3d2GLTFAttributeSignature 用排序后的属性访问器索引构造顶点池共享键
+// com3d2GLTFAttributeSignature builds a vertex-pool sharing key from sorted attribute accessor indices
+func com3d2GLTFAttributeSignature(attributes gltf.PrimitiveAttributes) string {
+	keys := make([]string, 0, len(attributes))
+	for attributeName, accessorIndex := range attributes {
+		keys = append(keys, fmt.Sprintf("%s=%d", attributeName, accessorIndex))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// com3d2GLTFAccessor 按属性名取访问器
+// com3d2GLTFAccessor gets an accessor by attribute name
+func com3d2GLTFAccessor(document *gltf.Document, attributes gltf.PrimitiveAttributes, name string) (*gltf.Accessor, bool, error) {
+	accessorIndex, ok := attributes[name]
+	if !ok {
+		return nil, false, nil
+	}
+	if accessorIndex < 0 || accessorIndex >= len(document.Accessors) {
+		return nil, false, fmt.Errorf("attribute %s references accessor %d out of range", name, accessorIndex)
+	}
+	return document.Accessors[accessorIndex], true, nil
+}
+
+// readCOM3D2GLTFVector2Attribute 读取一个二维浮点属性，属性不存在时返回 nil
+// readCOM3D2GLTFVector2Attribute reads a two-component float attribute and returns nil when the attribute is absent
+func readCOM3D2GLTFVector2Attribute(document *gltf.Document, attributes gltf.PrimitiveAttributes, name string, vertexCount int) ([][2]float32, error) {
+	accessor, ok, err := com3d2GLTFAccessor(document, attributes, name)
+	if err != nil || !ok {
+		return nil, err
+	}
+	values, err := modeler.ReadTextureCoord(document, accessor, nil)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	if len(values) != vertexCount {
+		return nil, fmt.Errorf("%s count %d does not match POSITION count %d", name, len(values), vertexCount)
+	}
+	return values, nil
+}
+
+// appendPrimitive 读取一个图元的顶点属性并镜像追加到几何中，返回该图元的顶点数
+// TEXCOORD 与 _COM3D2_ 原始 UV 在翻转后一致时采用原始值，否则以编辑后的 TEXCOORD 为准
+// appendPrimitive reads one primitive's vertex attributes, mirrors them into the geometry, and returns the primitive's vertex count
+// The _COM3D2_ raw UV is used when it still flips to the TEXCOORD value; otherwise the edited TEXCOORD wins
+func (g *com3d2GLTFGeometry) appendPrimitive(document *gltf.Document, primitive *gltf.Primitive, uvChannels []string) (int, error) {
+	positionAccessor, hasPositions, err := com3d2GLTFAccessor(document, primitive.Attributes, gltf.POSITION)
+	if err != nil {
+		return 0, err
+	}
+	if !hasPositions {
+		return 0, fmt.Errorf("primitive has no POSITION attribute")
+	}
+	positions, err := modeler.ReadPosition(document, positionAccessor, nil)
+	if err != nil {
+		return 0, fmt.Errorf("read POSITION: %w", err)
+	}
+	vertexCount := len(positions)
+	if vertexCount == 0 {
+		return 0, fmt.Errorf("primitive POSITION accessor is empty")
+	}
+	base := len(g.vertices)
+	g.vertices = append(g.vertices, make([]COM3D2.Vertex, vertexCount)...)
+	vertices := g.vertices[base:]
+	for vertexIndex, position := range positions {
+		vertices[vertexIndex].Position = COM3D2.Vector3{X: -position[0], Y: position[1], Z: position[2]}
+	}
+
+	if accessor, ok, err := com3d2GLTFAccessor(document, primitive.Attributes, gltf.NORMAL); err != nil {
+		return 0, err
+	} else if ok {
+		normals, err := modeler.ReadNormal(document, accessor, nil)
+		if err != nil {
+			return 0, fmt.Errorf("read NORMAL: %w", err)
+		}
+		if len(normals) != vertexCount {
+			return 0, fmt.Errorf("NORMAL count %d does not match POSITION count %d", len(normals), vertexCount)
+		}
+		for vertexIndex, normal := range normals {
+			vertices[vertexIndex].Normal = COM3D2.Vector3{X: -normal[0], Y: normal[1], Z: normal[2]}
+		}
+	}
+
+	if accessor, ok, err := com3d2GLTFAccessor(document, primitive.Attributes, gltf.TANGENT); err != nil {
+		return 0, err
+	} else if ok {
+		tangents, err := modeler.ReadTangent(document, accessor, nil)
+		if err != nil {
+			return 0, fmt.Errorf("read TANGENT: %w", err)
+		}
+		if len(tangents) != vertexCount {
+			return 0, fmt.Errorf("TANGENT count %d does not match POSITION count %d", len(tangents), vertexCount)
+		}
+		for _, tangent := range tangents {
+			g.tangents = append(g.tangents, COM3D2.Quaternion{X: -tangent[0], Y: tangent[1], Z: tangent[2], W: -tangent[3]})
+		}
+	}
+
+	channels := append([]string{"UV"}, uvChannels...)
+	for setIndex, channel := range channels {
+		coords, err := readCOM3D2GLTFVector2Attribute(document, primitive.Attributes, fmt.Sprintf("TEXCOORD_%d", setIndex), vertexCount)
+		if err != nil {
+			return 0, err
+		}
+		if coords == nil {
+			if setIndex == 0 {
+				continue
+			}
+			return 0, fmt.Errorf("TEXCOORD_%d carrying %s is missing", setIndex, channel)
+		}
+		raw, err := readCOM3D2GLTFVector2Attribute(document, primitive.Attributes, "_COM3D2_"+strings.ToUpper(channel), vertexCount)
+		if err != nil {
+			return 0, err
+		}
+		for vertexIndex, coordinate := range coords {
+			value := COM3D2.Vector2{X: coordinate[0], Y: 1 - coordinate[1]}
+			if raw != nil && raw[vertexIndex][0] == coordinate[0] && 1-raw[vertexIndex][1] == coordinate[1] {
+				value = COM3D2.Vector2{X: raw[vertexIndex][0], Y: raw[vertexIndex][1]}
+			}
+			setCOM3D2VertexChannel(&vertices[vertexIndex], channel, value)
+		}
+	}
+	for _, channel := range com3d2UnknownChannels {
+		values, err := readCOM3D2GLTFVector2Attribute(document, primitive.Attributes, "_COM3D2_"+strings.ToUpper(channel), vertexCount)
+		if err != nil {
+			return 0, err
+		}
+		for vertexIndex, value := range values {
+			setCOM3D2VertexChannel(&vertices[vertexIndex], channel, COM3D2.Vector2{X: value[0], Y: value[1]})
+		}
+	}
+
+	return vertexCount, g.appendPrimitiveSkin(document, primitive, vertexCount)
+}
+
+// appendPrimitiveSkin 读取图元的 JOINTS 和 WEIGHTS 集合；只有一组时原样保留，多组时取权重最大的四个并归一化
+// appendPrimitiveSkin reads a primitive's JOINTS and WEIGHTS sets, keeping a single set verbatim and reducing several sets to the four largest normalized weights
+func (g *com3d2GLTFGeometry) appendPrimitiveSkin(document *gltf.Document, primitive *gltf.Primitive, vertexCount int) error {
+	var joints [][][4]uint16
+	var weights [][][4]float32
+	for setIndex := 0; ; setIndex++ {
+		jointAccessor, hasJoints, err := com3d2GLTFAccessor(document, primitive.Attributes, fmt.Sprintf("JOINTS_%d", setIndex))
+		if err != nil {
+			return err
+		}
+		weightAccessor, hasWeights, err := com3d2GLTFAccessor(document, primitive.Attributes, fmt.Sprintf("WEIGHTS_%d", setIndex))
+		if err != nil {
+			return err
+		}
+		if !hasJoints && !hasWeights {
+			break
+		}
+		if hasJoints != hasWeights {
+			return fmt.Errorf("JOINTS_%d and WEIGHTS_%d must be provided together", setIndex, setIndex)
+		}
+		setJoints, err := modeler.ReadJoints(document, jointAccessor, nil)
+		if err != nil {
+			return fmt.Errorf("read JOINTS_%d: %w", setIndex, err)
+		}
+		setWeights, err := modeler.ReadWeights(document, weightAccessor, nil)
+		if err != nil {
+			return fmt.Errorf("read WEIGHTS_%d: %w", setIndex, err)
+		}
+		if len(setJoints) != vertexCount || len(setWeights) != vertexCount {
+			return fmt.Errorf("JOINTS_%d and WEIGHTS_%d counts %d and %d do not match POSITION count %d", setIndex, setIndex, len(setJoints), len(setWeights), vertexCount)
+		}
+		joints = append(joints, setJoints)
+		weights = append(weights, setWeights)
+	}
+	if len(joints) == 0 {
+		return nil
+	}
+
+	type influence struct {
+		bone   uint16
+		weight float32
+	}
+	for vertexIndex := 0; vertexIndex < vertexCount; vertexIndex++ {
+		influences := make([]influence, 0, 4*len(joints))
+		for setIndex := range joints {
+			for component := 0; component < 4; component++ {
+				weight := weights[setIndex][vertexIndex][component]
+				if weight != weight || weight < 0 {
+					return fmt.Errorf("vertex %d has invalid bone weight %f", vertexIndex, weight)
+				}
+				influences = append(influences, influence{bone: joints[setIndex][vertexIndex][component], weight: weight})
+			}
+		}
+		if len(joints) > 1 {
+			sort.SliceStable(influences, func(left, right int) bool {
+				return influences[left].weight > influences[right].weight
+			})
+			influences = influences[:4]
+			var sum float32
+			for _, entry := range influences {
+				sum += entry.weight
+			}
+			if sum <= 0 {
+				return fmt.Errorf("vertex %d has no bone influences; every vertex of a skinned mesh needs at least one weight", vertexIndex)
+			}
+			for entryIndex := range influences {
+				influences[entryIndex].weight /= sum
+			}
+		}
+		g.weights = append(g.weights, COM3D2.BoneWeight{
+			BoneIndex0: influences[0].bone, BoneIndex1: influences[1].bone, BoneIndex2: influences[2].bone, BoneIndex3: influences[3].bone,
+			Weight0: influences[0].weight, Weight1: influences[1].weight, Weight2: influences[2].weight, Weight3: influences[3].weight,
+		})
+	}
+	return nil
+}
+
+// readCOM3D2GLTFPrimitiveIndices 读取图元索引，未提供索引时生成顺序索引
+// readCOM3D2GLTFPrimitiveIndices reads primitive indices and generates sequential indices when none are provided
+func readCOM3D2GLTFPrimitiveIndices(document *gltf.Document, primitive *gltf.Primitive, vertexCount int) ([]uint32, error) {
+	if primitive.Indices == nil {
+		if vertexCount%3 != 0 {
+			return nil, fmt.Errorf("non-indexed primitive vertex count %d is not divisible by three", vertexCount)
+		}
+		indices := make([]uint32, vertexCount)
+		for indexIndex := range indices {
+			indices[indexIndex] = uint32(indexIndex)
+		}
+		return indices, nil
+	}
+	if *primitive.Indices < 0 || *primitive.Indices >= len(document.Accessors) {
+		return nil, fmt.Errorf("index accessor %d out of range", *primitive.Indices)
+	}
+	indices, err := modeler.ReadIndices(document, document.Accessors[*primitive.Indices], nil)
+	if err != nil {
+		return nil, fmt.Errorf("read indices: %w", err)
+	}
+	if len(indices) == 0 || len(indices)%3 != 0 {
+		return nil, fmt.Errorf("index count %d is not a positive multiple of three", len(indices))
+	}
+	for indexIndex, index := range indices {
+		if int64(index) >= int64(vertexCount) {
+			return nil, fmt.Errorf("index %d targets vertex %d outside %d vertices", indexIndex, index, vertexCount)
+		}
+	}
+	return indices, nil
+}
+
+// decodeCOM3D2GLTFMaterials 按图元顺序还原材质列表，随后追加未被图元引用但带 com3d2Material extras 的材质
+// 缺少 extras 的材质改用同名且带 extras 的 glTF 材质，两者都没有时报错
+// decodeCOM3D2GLTFMaterials restores the material list in primitive order and then appends materials that carry com3d2Material extras but no primitive references
+// A material without extras falls back to a same-named glTF material that has them, and conversion fails when neither exists
+func decodeCOM3D2GLTFMaterials(document *gltf.Document, references []int) ([]*COM3D2.Material, error) {
+	decoded := make([]*COM3D2.Material, len(document.Materials))
+	for materialIndex, material := range document.Materials {
+		if material == nil {
+			continue
+		}
+		value := &COM3D2.Material{}
+		found, err := decodeCOM3D2GLTFExtras(material.Extras, com3d2MaterialExtrasKey, value)
+		if err != nil {
+			return nil, fmt.Errorf("material %q: %w", material.Name, err)
+		}
+		if found {
+			decoded[materialIndex] = value
+		}
+	}
+
+	used := make([]bool, len(document.Materials))
+	var materials []*COM3D2.Material
+	for primitiveIndex, materialIndex := range references {
+		if materialIndex < 0 {
+			for _, later := range references[primitiveIndex:] {
+				if later >= 0 {
+					return nil, fmt.Errorf("primitive %d has no material but a later primitive does; COM3D2 pairs materials with submeshes by index", primitiveIndex)
+				}
+			}
+			break
+		}
+		used[materialIndex] = true
+		material := decoded[materialIndex]
+		if material == nil {
+			name := document.Materials[materialIndex].Name
+			for candidateIndex, candidate := range document.Materials {
+				if candidate != nil && decoded[candidateIndex] != nil && com3d2GLTFMaterialBaseName(candidate.Name) == com3d2GLTFMaterialBaseName(name) {
+					material = decoded[candidateIndex]
+					break
+				}
+			}
+		}
+		if material == nil {
+			return nil, fmt.Errorf("primitive %d material %q carries no com3d2Material extras and no same-named material does; export the source .model with convert2gltf or reuse one of its materials", primitiveIndex, document.Materials[materialIndex].Name)
+		}
+		materials = append(materials, material)
+	}
+	for materialIndex, material := range decoded {
+		if material != nil && !used[materialIndex] {
+			materials = append(materials, material)
+		}
+	}
+	return materials, nil
+}
+
+// com3d2GLTFMaterialBaseName 去掉 Blender 为重名材质追加的 .001 形式后缀
+// com3d2GLTFMaterialBaseName strips the .001 style suffix Blender appends to duplicated material names
+func com3d2GLTFMaterialBaseName(name string) string {
+	dot := strings.LastIndexByte(name, '.')
+	if dot < 0 || len(name)-dot != 4 {
+		return name
+	}
+	for _, digit := range name[dot+1:] {
+		if digit < '0' || digit > '9' {
+			return name
+		}
+	}
+	return name[:dot]
+}
+
+// decodeCOM3D2GLTFMorphTargets 将稠密变形目标稀疏化为 MorphData
+// extras 中的原索引仍覆盖全部非零差分时沿用原索引和切线 W，否则按升序收集非零差分
+// 切线差分只在 keepTangents 为真时保留，因为版本 2102 以下无法写出
+// decodeCOM3D2GLTFMorphTargets sparsifies dense morph targets into MorphData
+// The original indices and tangent W values from extras are reused while they still cover every nonzero delta; otherwise nonzero deltas are collected in ascending order
+// Tangent deltas are kept only when keepTangents is true because versions below 2102 cannot write them
+func decodeCOM3D2GLTFMorphTargets(document *gltf.Document, mesh *gltf.Mesh, vertexCount int, primitivePools []*com3d2GLTFPrimitivePool, keepTangents bool) ([]*COM3D2.MorphData, error) {
+	targetCount := len(mesh.Primitives[0].Targets)
+	for primitiveIndex, primitive := range mesh.Primitives {
+		if len(primitive.Targets) != targetCount {
+			return nil, fmt.Errorf("primitive %d has %d morph targets while primitive 0 has %d", primitiveIndex, len(primitive.Targets), targetCount)
+		}
+	}
+	if targetCount == 0 {
+		return nil, nil
+	}
+	names := com3d2GLTFMorphTargetNames(mesh, targetCount)
+	var morphExtras []com3d2MorphExtras
+	if _, err := decodeCOM3D2GLTFExtras(mesh.Extras, com3d2MorphsExtrasKey, &morphExtras); err != nil {
+		return nil, err
+	}
+
+	morphs := make([]*COM3D2.MorphData, targetCount)
+	for targetIndex := 0; targetIndex < targetCount; targetIndex++ {
+		deltaPositions := make([][3]float32, vertexCount)
+		deltaNormals := make([][3]float32, vertexCount)
+		var deltaTangents [][3]float32
+		for primitiveIndex, primitive := range mesh.Primitives {
+			pool := primitivePools[primitiveIndex]
+			if !pool.First {
+				continue
+			}
+			target := primitive.Targets[targetIndex]
+			positions, err := readCOM3D2GLTFMorphAttribute(document, target, gltf.POSITION, pool.VertexCount)
+			if err != nil {
+				return nil, fmt.Errorf("morph target %d primitive %d: %w", targetIndex, primitiveIndex, err)
+			}
+			copy(deltaPositions[pool.Base:], positions)
+			normals, err := readCOM3D2GLTFMorphAttribute(document, target, gltf.NORMAL, pool.VertexCount)
+			if err != nil {
+				return nil, fmt.Errorf("morph target %d primitive %d: %w", targetIndex, primitiveIndex, err)
+			}
+			copy(deltaNormals[pool.Base:], normals)
+			if !keepTangents {
+				continue
+			}
+			tangents, err := readCOM3D2GLTFMorphAttribute(document, target, gltf.TANGENT, pool.VertexCount)
+			if err != nil {
+				return nil, fmt.Errorf("morph target %d primitive %d: %w", targetIndex, primitiveIndex, err)
+			}
+			if tangents != nil {
+				if deltaTangents == nil {
+					deltaTangents = make([][3]float32, vertexCount)
+				}
+				copy(deltaTangents[pool.Base:], tangents)
+			}
+		}
+		tangentAt := func(vertexIndex int) [3]float32 {
+			if deltaTangents == nil {
+				return [3]float32{}
+			}
+			return deltaTangents[vertexIndex]
+		}
+
+		var indices []int32
+		var tangentW []float32
+		if targetIndex < len(morphExtras) && morphExtras[targetIndex].Indices != nil {
+			indices = morphExtras[targetIndex].Indices
+			listed := make([]bool, vertexCount)
+			for _, vertexIndex := range indices {
+				if vertexIndex < 0 || int(vertexIndex) >= vertexCount || listed[vertexIndex] {
+					indices = nil
+					break
+				}
+				listed[vertexIndex] = true
+			}
+			for vertexIndex := 0; vertexIndex < vertexCount && indices != nil; vertexIndex++ {
+				if !listed[vertexIndex] && !com3d2MorphDeltaIsZero(deltaPositions[vertexIndex], deltaNormals[vertexIndex], tangentAt(vertexIndex)) {
+					indices = nil
+				}
+			}
+			if indices != nil && len(morphExtras[targetIndex].TangentW) == len(indices) {
+				tangentW = morphExtras[targetIndex].TangentW
+			}
+		}
+		if indices == nil {
+			for vertexIndex := 0; vertexIndex < vertexCount; vertexIndex++ {
+				if !com3d2MorphDeltaIsZero(deltaPositions[vertexIndex], deltaNormals[vertexIndex], tangentAt(vertexIndex)) {
+					indices = append(indices, int32(vertexIndex))
+				}
+			}
+		}
+
+		morph := &COM3D2.MorphData{
+			Name:    names[targetIndex],
+			Indices: make([]int32, 0, len(indices)),
+			Vertex:  make([]COM3D2.Vector3, 0, len(indices)),
+			Normals: make([]COM3D2.Vector3, 0, len(indices)),
+		}
+		if deltaTangents != nil {
+			morph.Tangents = make([]COM3D2.Quaternion, 0, len(indices))
+		}
+		for entryIndex, vertexIndex := range indices {
+			position, normal := deltaPositions[vertexIndex], deltaNormals[vertexIndex]
+			morph.Indices = append(morph.Indices, vertexIndex)
+			morph.Vertex = append(morph.Vertex, COM3D2.Vector3{X: -position[0], Y: position[1], Z: position[2]})
+			morph.Normals = append(morph.Normals, COM3D2.Vector3{X: -normal[0], Y: normal[1], Z: normal[2]})
+			if deltaTangents != nil {
+				tangent := deltaTangents[vertexIndex]
+				value := COM3D2.Quaternion{X: -tangent[0], Y: tangent[1], Z: tangent[2]}
+				if tangentW != nil {
+					value.W = tangentW[entryIndex]
+				}
+				morph.Tangents = append(morph.Tangents, value)
+			}
+		}
+		morphs[targetIndex] = morph
+	}
+	return morphs, nil
+}
+
+// readCOM3D2GLTFMorphAttribute 读取一个变形目标属性的三维差分数组，属性不存在时返回 nil
+// readCOM3D2GLTFMorphAttribute reads one morph-target attribute's three-component delta array and returns nil when the attribute is absent
+func readCOM3D2GLTFMorphAttribute(document *gltf.Document, target gltf.PrimitiveAttributes, name string, vertexCount int) ([][3]float32, error) {
+	accessor, ok, err := com3d2GLTFAccessor(document, target, name)
+	if err != nil || !ok {
+		return nil, err
+	}
+	raw, err := modeler.ReadAccessor(document, accessor, nil)
+	if err != nil {
+		return nil, fmt.Errorf("read morph attribute %s: %w", name, err)
+	}
+	values, ok := raw.([][3]float32)
+	if !ok {
+		return nil, fmt.Errorf("morph attribute %s type %T is unsupported", name, raw)
+	}
+	if len(values) != vertexCount {
+		return nil, fmt.Errorf("morph attribute %s count %d does not match vertex count %d", name, len(values), vertexCount)
+	}
+	return values, nil
+}
+
+// com3d2GLTFMorphTargetNames 从网格 extras 的 targetNames 惯例提取变形目标名称
+// com3d2GLTFMorphTargetNames extracts morph-target names from the targetNames convention in mesh extras
+func com3d2GLTFMorphTargetNames(mesh *gltf.Mesh, targetCount int) []string {
+	names := make([]string, targetCount)
+	for targetIndex := range names {
+		names[targetIndex] = fmt.Sprintf("morph%d", targetIndex)
+	}
+	var extrasNames []string
+	if found, err := decodeCOM3D2GLTFExtras(mesh.Extras, "targetNames", &extrasNames); err != nil || !found {
+		return names
+	}
+	for nameIndex, name := range extrasNames {
+		if nameIndex < targetCount && name != "" {
+			names[nameIndex] = name
+		}
+	}
+	return names
+}
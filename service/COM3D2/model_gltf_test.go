@@ -0,0 +1,200 @@
+package COM3D2
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/qmuntal/gltf"
+)
+
+// newGLTFTestModel 构建覆盖缩放骨骼、扩展通道、切线、变形和皮肤厚度的合成 Model
+// newGLTFTestModel builds a synthetic Model covering scale bones, extended channels, tangents, morphs, and skin thickness
+func newGLTFTestModel() *COM3D2.Model {
+	shadow := "On"
+	vector2 := func(x, y float32) *COM3D2.Vector2 { return &COM3D2.Vector2{X: x, Y: y} }
+	model := &COM3D2.Model{
+		Signature:         COM3D2.ModelSignature,
+		Version:           2104,
+		Name:              "crc_skirt",
+		RootBoneName:      "Bip01",
+		ShadowCastingMode: &shadow,
+		Bones: []*COM3D2.Bone{
+			{Name: "Bip01", ParentIndex: -1, Position: COM3D2.Vector3{X: 0.1, Y: 1.2, Z: -0.3}, Rotation: COM3D2.Quaternion{X: 0.1, Y: 0.2, Z: 0.3, W: 0.9273618}},
+			{Name: "Skirt_R", HasScale: true, ParentIndex: 2, Position: COM3D2.Vector3{X: 0.07, Y: -0.2}, Rotation: COM3D2.Quaternion{W: 1}, Scale: &COM3D2.Vector3{X: 1.1, Y: 0.9, Z: 1}},
+			{Name: "Bip01 Pelvis", ParentIndex: 0, Position: COM3D2.Vector3{Y: 0.05}, Rotation: COM3D2.Quaternion{X: -0.5, Y: 0.5, Z: -0.5, W: 0.5}},
+		},
+		BoneNames: []string{"Skirt_R", "Bip01 Pelvis", "Mune_L"},
+		BindPoses: []COM3D2.Matrix4x4{
+			{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0.1, -1.3, 0.2, 1},
+			{0, 1, 0, 0, -1, 0, 0, 0, 0, 0, 1, 0, 0.4, 0.05, -0.7, 1},
+			{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1},
+		},
+		Vertices: []COM3D2.Vertex{
+			{Position: COM3D2.Vector3{X: 0.1, Y: 1.0, Z: 0.2}, Normal: COM3D2.Vector3{X: 0, Y: 0, Z: 1}, UV: COM3D2.Vector2{X: 0.1, Y: 0.3}, UV2: vector2(0.7, 0.123), Unknown1: vector2(5, -6)},
+			{Position: COM3D2.Vector3{X: -0.1, Y: 1.1, Z: 0.2}, Normal: COM3D2.Vector3{X: 0, Y: 1, Z: 0}, UV: COM3D2.Vector2{X: 0.9, Y: 0.7}, UV2: vector2(0.2, 0.01), Unknown1: vector2(1, 2)},
+			{Position: COM3D2.Vector3{X: 0, Y: 0.9, Z: 0.25}, Normal: COM3D2.Vector3{X: 1, Y: 0, Z: 0}, UV: COM3D2.Vector2{X: 0.33, Y: 0.011}, UV2: vector2(0.3, 0.4), Unknown1: vector2(0, 0)},
+			{Position: COM3D2.Vector3{X: 0.2, Y: 0.8, Z: 0.3}, Normal: COM3D2.Vector3{X: 0.6, Y: 0.8, Z: 0}, UV: COM3D2.Vector2{X: 0.5, Y: 0.1}, UV2: vector2(0.9, 0.99), Unknown1: vector2(-1, 3)},
+		},
+		Tangents: []COM3D2.Quaternion{{X: 1, W: 1}, {X: 1, W: -1}, {Z: 1, W: 1}, {Y: 1, W: -1}},
+		BoneWeights: []COM3D2.BoneWeight{
+			{BoneIndex0: 0, BoneIndex1: 1, Weight0: 0.75, Weight1: 0.25},
+			{BoneIndex0: 1, Weight0: 1},
+			{BoneIndex0: 2, BoneIndex1: 0, BoneIndex2: 1, Weight0: 0.5, Weight1: 0.3, Weight2: 0.2},
+			{BoneIndex0: 0, Weight0: 1},
+		},
+		SubMeshes: [][]int32{{0, 1, 2}, {1, 3, 2}},
+		Materials: []*COM3D2.Material{
+			{Name: "skirt_body", ShaderName: "CM3D2/Toony_Lighted", ShaderFilename: "cm3d2_toony_lighted", Properties: []COM3D2.Property{
+				&COM3D2.TexProperty{TypeName: "tex", PropName: "_MainTex", SubTag: "tex2d", Tex2D: &COM3D2.Tex2DSubProperty{Name: "skirt", Path: "Assets/skirt.png", Scale: [2]float32{1, 1}}},
+				&COM3D2.FProperty{TypeName: "f", PropName: "_Shininess", Number: 0.25},
+			}},
+			{Name: "skirt_lace", ShaderName: "CM3D2/Toony_Lighted_Trans", ShaderFilename: "cm3d2_toony_lighted_trans"},
+			{Name: "skirt_spare", ShaderName: "CM3D2/Toony_Lighted", ShaderFilename: "cm3d2_toony_lighted"},
+		},
+		MorphData: []*COM3D2.MorphData{
+			{
+				Name:     "open",
+				Indices:  []int32{3, 0},
+				Vertex:   []COM3D2.Vector3{{X: 0.01, Y: 0.02}, {Z: -0.03}},
+				Normals:  []COM3D2.Vector3{{}, {X: 0.1}},
+				Tangents: []COM3D2.Quaternion{{W: 0.5}, {}},
+			},
+			{
+				Name:     "lift",
+				Indices:  []int32{1, 2},
+				Vertex:   []COM3D2.Vector3{{Y: 0.1}, {Y: 0.2}},
+				Normals:  []COM3D2.Vector3{{}, {}},
+				Tangents: []COM3D2.Quaternion{{}, {X: 0.01}},
+			},
+		},
+		SkinThickness: &COM3D2.SkinThickness{
+			Signature: "SkinThickness",
+			Version:   100,
+			Use:       true,
+			Groups: map[string]*COM3D2.ThickGroup{
+				"hip": {GroupName: "hip", StartBoneName: "Bip01 Pelvis", EndBoneName: "Skirt_R", StepAngleDegree: 45, Points: []*COM3D2.ThickPoint{{
+					TargetBoneName:         "Skirt_R",
+					RatioSegmentStartToEnd: 0.5,
+					DistanceParAngle:       []*COM3D2.ThickDefPerAngle{{AngleDegree: 0, VertexIndex: 2, DefaultDistance: 0.04}},
+				}}},
+			},
+			GroupOrder: []string{"hip"},
+		},
+	}
+	return model
+}
+
+// TestModelGLTFRoundTripIsByteIdentical 校验 .model 经 GLB 和 glTF 往返后 Dump 逐字节一致
+// TestModelGLTFRoundTripIsByteIdentical verifies a .model dumps byte-identically after a GLB and a glTF round trip
+func TestModelGLTFRoundTripIsByteIdentical(t *testing.T) {
+	source := newGLTFTestModel()
+	var want bytes.Buffer
+	if err := source.Dump(&want); err != nil {
+		t.Fatalf("dump source model: %v", err)
+	}
+
+	service := &ModelService{}
+	for _, format := range []string{"glb", "gltf"} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			inputPath := filepath.Join(dir, "crc_skirt.model")
+			if err := service.WriteModelFile(inputPath, source); err != nil {
+				t.Fatalf("write source model: %v", err)
+			}
+			gltfPath := filepath.Join(dir, "crc_skirt."+format)
+			if err := service.ConvertModelToGLTF(TestConversionContext, inputPath, gltfPath, format, TestConversionMaxOutput); err != nil {
+				t.Fatalf("ConvertModelToGLTF: %v", err)
+			}
+			if !IsCOM3D2ModelGLTFDocument(gltfPath) {
+				t.Fatal("exported glTF is not recognized as a COM3D2 model document")
+			}
+
+			document, err := gltf.Open(gltfPath)
+			if err != nil {
+				t.Fatalf("open exported glTF: %v", err)
+			}
+			if len(document.Skins) != 1 || len(document.Skins[0].Joints) != 3 {
+				t.Fatalf("skins = %+v, want one skin with three joints", document.Skins)
+			}
+			if got := document.Nodes[document.Skins[0].Joints[2]].Name; got != "Mune_L" {
+				t.Fatalf("synthesized joint = %q, want Mune_L", got)
+			}
+			if got := len(document.Meshes[0].Primitives); got != 2 {
+				t.Fatalf("primitive count = %d, want one per submesh", got)
+			}
+			if got := document.Nodes[0].Translation; got != [3]float64{float64(float32(-0.1)), float64(float32(1.2)), float64(float32(-0.3))} {
+				t.Fatalf("root bone translation = %v, want X mirrored", got)
+			}
+
+			outputPath := filepath.Join(dir, "back.model")
+			if err := service.ConvertGLTFToModel(TestConversionContext, gltfPath, outputPath, TestConversionMaxOutput); err != nil {
+				t.Fatalf("ConvertGLTFToModel: %v", err)
+			}
+			back, err := service.ReadModelFile(outputPath)
+			if err != nil {
+				t.Fatalf("read converted model: %v", err)
+			}
+			var got bytes.Buffer
+			if err := back.Dump(&got); err != nil {
+				t.Fatalf("dump converted model: %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Fatalf("round-tripped model differs: %d bytes, want %d", got.Len(), want.Len())
+			}
+		})
+	}
+}
+
+// TestModelGLTFImportWithoutExtrasUsesDefaults 校验丢失场景 extras 的 glTF 按默认版本、根骨骼和同名材质导入
+// TestModelGLTFImportWithoutExtrasUsesDefaults verifies a glTF that lost its scene extras imports with the default version, root bone, and same-named materials
+func TestModelGLTFImportWithoutExtrasUsesDefaults(t *testing.T) {
+	source := newGLTFTestModel()
+	source.Version = 2001
+	source.ShadowCastingMode = nil
+	source.SkinThickness = nil
+	for index := range source.Vertices {
+		source.Vertices[index].UV2 = nil
+		source.Vertices[index].Unknown1 = nil
+	}
+	for _, morph := range source.MorphData {
+		morph.Tangents = nil
+	}
+	document, err := encodeCOM3D2ModelGLTFDocument(source)
+	if err != nil {
+		t.Fatalf("encode glTF document: %v", err)
+	}
+	document.Scenes[0].Extras = nil
+	// Blender 会把重名材质改为 .001 后缀并丢弃第二个材质的 extras
+	// Blender renames a duplicated material with a .001 suffix and drops the second material's extras
+	document.Materials[1].Name = "skirt_body.001"
+	document.Materials[1].Extras = nil
+
+	dir := t.TempDir()
+	gltfPath := filepath.Join(dir, "edited.glb")
+	if err := gltf.SaveBinary(document, gltfPath); err != nil {
+		t.Fatalf("save edited glTF: %v", err)
+	}
+	outputPath := filepath.Join(dir, "edited.model")
+	service := &ModelService{}
+	if err := service.ConvertGLTFToModel(TestConversionContext, gltfPath, outputPath, TestConversionMaxOutput); err != nil {
+		t.Fatalf("ConvertGLTFToModel: %v", err)
+	}
+	model, err := service.ReadModelFile(outputPath)
+	if err != nil {
+		t.Fatalf("read converted model: %v", err)
+	}
+	if model.Version != 2001 || model.Name != "crc_skirt" || model.RootBoneName != "Bip01" {
+		t.Fatalf("header = %d %q %q, want 2001 crc_skirt Bip01", model.Version, model.Name, model.RootBoneName)
+	}
+	if len(model.Bones) != 3 || model.Bones[1].Scale == nil || model.Bones[1].ParentIndex != 2 {
+		t.Fatalf("bones = %+v, want the scaled Skirt_R under Bip01 Pelvis", model.Bones)
+	}
+	if len(model.Materials) != 3 || model.Materials[1].Name != "skirt_body" || model.Materials[2].Name != "skirt_spare" {
+		t.Fatalf("materials = %+v, want the renamed slot resolved to skirt_body followed by the unreferenced spare", model.Materials)
+	}
+	if len(model.MorphData) != 2 || len(model.MorphData[0].Indices) != 2 || model.MorphData[0].Indices[0] != 3 {
+		t.Fatalf("morphs = %+v, want the original index order kept from the mesh extras", model.MorphData)
+	}
+}
@@ -2,9 +2,16 @@ package COM3D2
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"strings"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/internal/conversionio"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
@@ -176,3 +183,80 @@ func (s *NeiService) NeiFileToCSV(inputPath string) (csvData [][]string, err err
 
 	return csvData, nil
 }
+
+// neiCSVSuffix 是 .nei 编辑 JSON 旁受管理的 CSV 附件后缀
+const neiCSVSuffix = ".csv"
+
+// ConvertNeiToJson 将 .nei 文件转换为编辑 JSON 和同名 UTF-8 BOM CSV 附件（例如 foo.nei.json 对应 foo.nei.json.csv）
+// JSON 保存探测到的单元格编码，写回时沿用；JSON 与 CSV 附件共享同一个输出上限
+func (s *NeiService) ConvertNeiToJson(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	if strings.HasSuffix(outputPath, ".nei") {
+		outputPath += ".json"
+	}
+
+	neiData, err := s.ReadNeiFile(inputPath)
+	if err != nil {
+		return err
+	}
+	info, records, err := COM3D2.ConvertNeiToCSVInfo(neiData)
+	if err != nil {
+		return fmt.Errorf("failed to convert Nei to CSV: %w", err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	var csvData bytes.Buffer
+	if err := tools.WriteCSVWithUTF8BOM(&csvData, records); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	budget, err := conversionio.NewBudget(ctx, maxOutputBytes)
+	if err != nil {
+		return err
+	}
+	if err := budget.WriteFile(outputPath, data, 0644); err != nil {
+		return conversionOutputError("nei JSON", err)
+	}
+	if err := budget.WriteFile(outputPath+neiCSVSuffix, csvData.Bytes(), 0644); err != nil {
+		return conversionOutputError("nei CSV", err)
+	}
+	return nil
+}
+
+// ConvertJsonToNei 将编辑 JSON 及其同名 .csv 附件转换回 .nei 文件
+// 单元格按 JSON 中的 TextEncoding 编码，省略时使用 Shift-JIS
+func (s *NeiService) ConvertJsonToNei(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	if strings.HasSuffix(outputPath, ".json") {
+		outputPath = strings.TrimSuffix(outputPath, ".json")
+		if !strings.HasSuffix(outputPath, ".nei") {
+			outputPath += ".nei"
+		}
+	}
+
+	var info COM3D2.NeiCSVInfo
+	if err := readConversionJSON(ctx, inputPath, &info); err != nil {
+		return fmt.Errorf("parsing the nei.json file failed: %w", err)
+	}
+	csvData, err := conversionio.ReadFile(ctx, inputPath+neiCSVSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("nei CSV %s is required", inputPath+neiCSVSuffix)
+		}
+		return fmt.Errorf("cannot read nei CSV: %w", err)
+	}
+	records, err := tools.NewCSVReaderSkipUTF8BOM(bytes.NewReader(csvData), 0).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	neiData, err := COM3D2.ConvertCSVInfoToNei(info, records)
+	if err != nil {
+		return fmt.Errorf("failed to convert CSV to Nei: %w", err)
+	}
+	if err := writeConversionBinary(ctx, outputPath, maxOutputBytes, neiData.Dump); err != nil {
+		return conversionOutputError("nei", err)
+	}
+	return nil
+}
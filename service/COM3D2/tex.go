@@ -2,12 +2,19 @@ package COM3D2
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/internal/conversionio"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 	"github.com/emmansun/base64" // use faster base64 implementation
 )
@@ -58,7 +65,7 @@ type CovertTexToImageResult struct {
 }
 
 // CovertTexToImage 将 .tex 文件转换为图像文件，但不写出
-// 依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
+// 使用内置编解码器，不需要 ImageMagick
 // 如果 forcePNG 为 false 那么如果图像数据位是 JPG 或 PNG 则直接返回数据为，否则根据有没有透明通道保存为 JPG 或 PNG
 // 如果 forcePNG 为 true 则强制保存为 PNG，不考虑图像格式和透明通道
 // 如果是 1011 版本的 tex（纹理图集），则还会返回 rects
@@ -72,7 +79,6 @@ func (t *TexService) CovertTexToImage(inputPath string, forcePng bool) (covertTe
 	if err != nil {
 		return covertTexToImageResult, err
 	}
-	fmt.Println("imageData", imageData)
 
 	covertTexToImageResult.Base64EncodedImageData = base64.StdEncoding.EncodeToString(imageData)
 	covertTexToImageResult.Format = format
@@ -82,7 +88,7 @@ func (t *TexService) CovertTexToImage(inputPath string, forcePng bool) (covertTe
 }
 
 // ConvertTexToImageAndWrite 将 .tex 文件转换为图像文件，并写出
-// 依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
+// PNG、JPG 与 DDS 输出使用内置编解码器，其他输出格式依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
 // 如果 forcePNG 为 false 那么如果图像是有损格式且没有透明通道，则保存为 JPG，否则保存为 PNG
 // 如果 forcePNG 为 true 则强制保存为 PNG，不考虑图像格式和透明通道
 // 如果是 1011 版本的 tex（纹理图集），则还会生成一个 .uv.csv 文件（例如 foo.png 对应 foo.png.uv.csv），文件内容为矩形数组 x, y, w, h 一行一组
@@ -98,8 +104,8 @@ func (t *TexService) ConvertTexToImageAndWrite(inputPath string, outputPath stri
 	return nil
 }
 
-// ConvertImageToTex 将任意 ImageMagick 支持的文件格式转换为 tex 格式，但不写出
-// 依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
+// ConvertImageToTex 将图像文件转换为 tex 格式，但不写出
+// PNG、JPEG、GIF 与 DXT1/DXT5 DDS 使用内置编解码器，其他格式依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
 // 如果 forcePNG 为 true，且 compress 为 false，则 tex 的数据位是原始 PNG 数据或转换为 PNG
 // 如果 forcePNG 为 false，且 compress 为 false，那么检查输入格式是否是 PNG 或 JPG，如果是则数据位直接使用原始图片，否则如果原始格式有损且无透明通道则转换为 JPG，否则转换为 PNG
 // 如果 forcePNG 为 true，且 compress 为 true，那么 compress 标识会被忽略，结果同 forcePNG 为 true，且 compress 为 false
@@ -114,8 +120,8 @@ func (t *TexService) ConvertImageToTex(inputPath string, texName string, compres
 	return tex, nil
 }
 
-// ConvertImageToTexAndWrite 将任意 ImageMagick 支持的文件格式转换为 tex 格式，但不写出
-// 依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
+// ConvertImageToTexAndWrite 将图像文件转换为 tex 格式，并写出
+// PNG、JPEG、GIF 与 DXT1/DXT5 DDS 使用内置编解码器，其他格式依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
 // 如果 forcePNG 为 true，且 compress 为 false，则 tex 的数据位是原始 PNG 数据或转换为 PNG
 // 如果 forcePNG 为 false，且 compress 为 false，那么检查输入格式是否是 PNG 或 JPG，如果是则数据位直接使用原始图片，否则如果原始格式有损且无透明通道则转换为 JPG，否则转换为 PNG
 // 如果 forcePNG 为 true，且 compress 为 true，那么 compress 标识会被忽略，结果同 forcePNG 为 true，且 compress 为 false
@@ -130,8 +136,104 @@ func (t *TexService) ConvertImageToTexAndWrite(inputPath string, texName string,
 	return nil
 }
 
+// ConvertImageToTexWithOptionsAndWrite 按 TexEncodeOptions 将图像文件转换为 tex 格式，并写出
+// 可选择 DXT 编码质量，或在 UseImageMagick 为 true 时改用 ImageMagick 处理输入
+func (t *TexService) ConvertImageToTexWithOptionsAndWrite(inputPath string, texName string, opts COM3D2.TexEncodeOptions, outputPath string) error {
+	return COM3D2.ConvertImageToTexWithOptionsAndWrite(inputPath, texName, opts, outputPath)
+}
+
+// texImageSuffixes 是编辑 JSON 旁受管理的图像附件后缀，每次转换只会存在其中一个
+var texImageSuffixes = []string{".png", ".dds"}
+
+// ConvertTexToJson 将 .tex 文件转换为编辑 JSON 和同名图像附件（例如 foo.tex.json 对应 foo.tex.json.png）
+// DXT1/DXT5 纹理导出为保留原始块的 .dds，其余纹理导出为 .png；JSON 保存 TextureName、版本、TexRect 和写回时使用的 TextureFormat
+// JSON 与图像附件共享同一个输出上限
+func (t *TexService) ConvertTexToJson(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	if err := checkConversionContext(ctx); err != nil {
+		return err
+	}
+	if strings.HasSuffix(outputPath, ".tex") {
+		outputPath += ".json"
+	}
+
+	tex, err := t.ReadTexFile(inputPath)
+	if err != nil {
+		return err
+	}
+	info, imageData, suffix, err := COM3D2.ConvertTexToImageInfo(tex)
+	if err != nil {
+		return fmt.Errorf("failed to convert tex to image: %w", err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	budget, err := conversionio.NewBudget(ctx, maxOutputBytes)
+	if err != nil {
+		return err
+	}
+	if err := budget.WriteFile(outputPath, data, 0644); err != nil {
+		return conversionOutputError("tex JSON", err)
+	}
+	if err := budget.WriteFile(outputPath+suffix, imageData, 0644); err != nil {
+		return conversionOutputError("tex image", err)
+	}
+	// 删除另一种后缀的旧附件，避免写回时无法确定使用哪张图像
+	for _, stale := range texImageSuffixes {
+		if stale == suffix {
+			continue
+		}
+		if err := os.Remove(outputPath + stale); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale tex image: %w", err)
+		}
+	}
+	return nil
+}
+
+// ConvertJsonToTex 将编辑 JSON 及其同名 .png 或 .dds 图像附件转换回 .tex 文件
+// 宽高取自图像，TextureFormat 决定载荷：DXT1/DXT5 在 DDS 块格式一致时直接复用块，否则重新压缩；ARGB32 保存 PNG；RGB24 保存 JPG
+func (t *TexService) ConvertJsonToTex(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	if strings.HasSuffix(outputPath, ".json") {
+		outputPath = strings.TrimSuffix(outputPath, ".json")
+		if !strings.HasSuffix(outputPath, ".tex") {
+			outputPath += ".tex"
+		}
+	}
+
+	var info COM3D2.TexImageInfo
+	if err := readConversionJSON(ctx, inputPath, &info); err != nil {
+		return fmt.Errorf("parsing the tex.json file failed: %w", err)
+	}
+	imagePath := ""
+	for _, suffix := range texImageSuffixes {
+		if _, err := os.Stat(inputPath + suffix); err == nil {
+			if imagePath != "" {
+				return fmt.Errorf("both %s and %s exist, keep only one tex image", imagePath, inputPath+suffix)
+			}
+			imagePath = inputPath + suffix
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot inspect tex image: %w", err)
+		}
+	}
+	if imagePath == "" {
+		return fmt.Errorf("tex image %s.png or %s.dds is required", inputPath, inputPath)
+	}
+	imageData, err := conversionio.ReadFile(ctx, imagePath)
+	if err != nil {
+		return fmt.Errorf("cannot read tex image: %w", err)
+	}
+	tex, err := COM3D2.ConvertImageInfoToTex(info, imageData, bcn.QualityNormal)
+	if err != nil {
+		return fmt.Errorf("failed to convert image to tex: %w", err)
+	}
+	if err := writeConversionBinary(ctx, outputPath, maxOutputBytes, tex.Dump); err != nil {
+		return conversionOutputError("tex", err)
+	}
+	return nil
+}
+
 // ConvertAnyToPng 任意 ImageMagick 支持的格式转换为 PNG，包括 .tex
-// 依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
+// .tex、PNG、JPEG、GIF 与 DXT1/DXT5 DDS 使用内置编解码器，其他格式依赖外部库 ImageMagick，且有 Path 环境变量可以直接调用 magick 命令
 // 输出为 base64 编码的 PNG 数据
 func (t *TexService) ConvertAnyToPng(inputPath string) (Base64EncodedPngData string, err error) {
 	if strings.HasSuffix(strings.ToLower(inputPath), ".tex") {
@@ -141,6 +243,13 @@ func (t *TexService) ConvertAnyToPng(inputPath string) (Base64EncodedPngData str
 		}
 		return covertTexToImageResult.Base64EncodedImageData, nil
 	}
+	if tools.IsNativeImageType(inputPath) {
+		imageData, err := convertNativeImageToPng(inputPath)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(imageData), nil
+	}
 	err = tools.IsSupportedImageType(inputPath)
 	if err != nil {
 		return "", err
@@ -193,6 +302,40 @@ func (t *TexService) ConvertAnyToAnyAndWrite(inputPath string, texName string, c
 	return nil
 }
 
+// convertNativeImageToPng 使用内置编解码器把 PNG、JPEG、GIF 或 DDS 文件转换为 PNG 数据，PNG 输入原样返回
+func convertNativeImageToPng(inputPath string) ([]byte, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	var img image.Image
+	switch tools.SniffNativeImageFormat(data) {
+	case tools.NativeImagePNG:
+		return data, nil
+	case tools.NativeImageDDS:
+		dds, err := bcn.ReadDDS(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DDS image: %w", err)
+		}
+		img, err = bcn.Decode(dds.Format, dds.FirstLevel(), dds.Width, dds.Height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode DDS image: %w", err)
+		}
+	default:
+		img, _, err = tools.DecodeNativeImage(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // CheckImageMagick 检查是否安装了 ImageMagick
 func (t *TexService) CheckImageMagick() bool {
 	err := tools.CheckMagick()
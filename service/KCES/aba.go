@@ -3,6 +3,7 @@ package KCES
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -74,6 +75,127 @@ func (s *AbaService) UnpackAba(abaPath string, outDir string) error {
 	return unpackUnityFSBundlePureDirectory(abaPath, outDir, s.ReadAba)
 }
 
+// RecompressAba 以指定的压缩类型和级别重写 ABA，逐字节保留所有条目，outputPath 为空时原地替换 abaPath
+// 新文件先写入目标旁的临时文件，完成后才替换目标，因此失败时不会留下不完整的 .aba
+// RecompressAba rewrites an ABA with the given compression kind and level, keeping every entry byte for byte, and replaces abaPath in place when outputPath is empty
+// The new file is written to a temporary file beside the target and replaces it only when complete, so a failure never leaves a partial .aba behind
+func (s *AbaService) RecompressAba(abaPath string, outputPath string, compression aba.AbaCompression, level int) error {
+	return s.rewriteAba(abaPath, outputPath, func(out io.Writer, source *aba.Aba) error {
+		if err := aba.RecompressAba(out, source, &aba.AbaWriteOptions{Compression: compression, CompressionLevel: level}); err != nil {
+			return fmt.Errorf("recompress .aba file failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReplaceAbaObject 将 ABA 中按 PathID 或容器加载名选中的一个对象替换为 objectPath 的内容，保留其他对象、PathID 和数据块压缩类型，outputPath 为空时原地替换 abaPath
+// objectPath 可以是独立 Unity 对象文件（unpackAba、convert2texture2d 的输出），也可以是按包内 TypeTree 编码的原始对象正文；streamPath 非空时其内容写入 .resS 条目并成为新对象的 m_StreamData
+// 独立 Texture2D 使用流式数据且未指定 streamPath 时，自动读取 convert2texture2d --stream 写出的 <objectPath>.resS 伴随文件
+// ReplaceAbaObject replaces one object selected by PathID or container load name inside an ABA with the content of objectPath, keeping other objects, PathIDs, and the data-block compression, and replaces abaPath in place when outputPath is empty
+// objectPath may be a standalone Unity object file (as written by unpackAba or convert2texture2d) or a raw object payload encoded with the in-bundle TypeTree; when streamPath is non-empty its content is written to the .resS entry and becomes the new object's m_StreamData
+// When a standalone Texture2D uses stream data and streamPath is empty, the <objectPath>.resS companion written by convert2texture2d --stream is read automatically
+func (s *AbaService) ReplaceAbaObject(abaPath string, outputPath string, pathID int64, name string, objectPath string, streamPath string) (*aba.AbaReplaceResult, error) {
+	data, err := os.ReadFile(objectPath)
+	if err != nil {
+		return nil, fmt.Errorf("read replacement object failed: %w", err)
+	}
+	replacement := &aba.AbaObjectReplacement{PathID: pathID, Name: name, Data: data}
+	if aba.IsNativeUnityObjectData(data) {
+		object, err := aba.ReadNativeUnityObject(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse replacement object failed: %w", err)
+		}
+		replacement.ClassID = object.ClassID
+		replacement.Data = object.Data
+		if streamPath == "" && object.ClassID == aba.ClassIDTexture2D {
+			stream, err := object.Texture2DStreamData()
+			if err != nil {
+				return nil, err
+			}
+			if stream.Size > 0 {
+				replacement.StreamData, err = readStreamCompanionRange(objectPath+".resS", stream)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if streamPath != "" {
+		replacement.StreamData, err = os.ReadFile(streamPath)
+		if err != nil {
+			return nil, fmt.Errorf("read replacement stream data failed: %w", err)
+		}
+	}
+
+	var result *aba.AbaReplaceResult
+	err = s.rewriteAba(abaPath, outputPath, func(out io.Writer, source *aba.Aba) error {
+		result, err = aba.ReplaceAbaObject(out, source, replacement, nil)
+		if err != nil {
+			return fmt.Errorf("replace .aba object failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readStreamCompanionRange 读取 m_StreamData 在伴随 .resS 文件中指向的范围
+// readStreamCompanionRange reads the range that m_StreamData points at inside a companion .resS file
+func readStreamCompanionRange(companionPath string, stream aba.StreamingInfo) ([]byte, error) {
+	data, err := os.ReadFile(companionPath)
+	if err != nil {
+		return nil, fmt.Errorf("Texture2D uses stream data but its companion cannot be read: %w", err)
+	}
+	if stream.Offset < 0 || stream.Size > uint64(len(data)) || stream.Offset > int64(len(data))-int64(stream.Size) {
+		return nil, fmt.Errorf("Texture2D stream range [%d,+%d) exceeds companion %q size %d", stream.Offset, stream.Size, companionPath, len(data))
+	}
+	return data[stream.Offset : stream.Offset+int64(stream.Size)], nil
+}
+
+// rewriteAba 解析 abaPath 并通过 write 生成新的 .aba，先写入目标旁的临时文件，完成后再替换 outputPath，outputPath 为空时替换 abaPath
+// rewriteAba parses abaPath and produces a new .aba through write, writing to a temporary file beside the target first and replacing outputPath only when complete, or abaPath when outputPath is empty
+func (s *AbaService) rewriteAba(abaPath string, outputPath string, write func(out io.Writer, source *aba.Aba) error) (err error) {
+	if outputPath == "" {
+		outputPath = abaPath
+	}
+	abaFile, f, err := s.ReadAba(abaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+
+	temp, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create .aba output failed: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = temp.Close()
+			_ = os.Remove(temp.Name())
+		}
+	}()
+	if err = write(temp, abaFile); err != nil {
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return fmt.Errorf("close .aba output failed: %w", err)
+	}
+	// Windows 不能替换仍被打开的文件，因此在重命名前关闭源文件
+	// Windows cannot replace a file that is still open, so the source is closed before the rename
+	_ = f.Close()
+	f = nil
+	if err = os.Rename(temp.Name(), outputPath); err != nil {
+		return fmt.Errorf("replace .aba output failed: %w", err)
+	}
+	return nil
+}
+
 // claimExtractionPaths 原子登记规范输出路径并拒绝大小写不敏感的冲突
 // claimExtractionPaths atomically claims normalized output paths and rejects case-insensitive conflicts
 func claimExtractionPaths(claims map[string]string, owner string, paths ...string) error {
@@ -127,7 +127,7 @@ func TestCanonicalAbaAllReadableSamplesPureDirectoryRoundTrip(t *testing.T) {
 			}
 			assertPureDirectoryFileSet(t, firstFiles)
 
-			if err := (&PackService{}).packToAbaAndCt(first, "roundtrip", false); err != nil {
+			if err := (&PackService{}).packToAbaAndCt(first, "roundtrip", modPackOptions{}); err != nil {
 				t.Fatalf("pack: %v", err)
 			}
 			abaPath := filepath.Join(work, "roundtrip.aba")
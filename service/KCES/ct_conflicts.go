@@ -0,0 +1,67 @@
+package KCES
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/ct"
+)
+
+// CatalogConflicts 读取 paths 中的全部 .ct 文件（目录会递归查找）并分析 MOD 之间的资源冲突和哈希碰撞；无法读取的 .ct 记录在报告中而不会中止分析
+// CatalogConflicts reads every .ct file in paths, searching directories recursively, and analyzes resource conflicts and hash collisions between mods; unreadable .ct files are recorded in the report instead of aborting the analysis
+func (s *CtService) CatalogConflicts(paths []string) (*ct.CatalogConflictReport, error) {
+	var ctPaths []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			ctPaths = append(ctPaths, path)
+			continue
+		}
+		var found []string
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && IsKCESCtFile(p) {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan %s: %w", path, err)
+		}
+		sort.Strings(found)
+		ctPaths = append(ctPaths, found...)
+	}
+	if len(ctPaths) == 0 {
+		return nil, fmt.Errorf("no .ct files found")
+	}
+
+	var sources []ct.CatalogConflictSource
+	var loadErrors []ct.CatalogLoadError
+	for _, path := range ctPaths {
+		table, err := s.ReadCt(path)
+		if err != nil {
+			loadErrors = append(loadErrors, ct.CatalogLoadError{Source: path, Message: err.Error()})
+			continue
+		}
+		catalog, err := ct.DecodeCatalogFromCt(table)
+		if err != nil {
+			loadErrors = append(loadErrors, ct.CatalogLoadError{Source: path, Message: fmt.Sprintf("decode catalog failed: %v", err)})
+			continue
+		}
+		sources = append(sources, ct.CatalogConflictSource{Name: path, Catalog: catalog})
+	}
+	report, err := ct.AnalyzeCatalogConflicts(sources)
+	if err != nil {
+		return nil, err
+	}
+	report.Errors = loadErrors
+	return report, nil
+}
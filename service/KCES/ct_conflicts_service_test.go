@@ -0,0 +1,57 @@
+package KCES
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/ct"
+)
+
+func TestCatalogConflictsScansDirectories(t *testing.T) {
+	root := t.TempDir()
+	service := &CtService{}
+	writeCatalog := func(relPath string, packageType ct.CatalogPackageType, priority int32, names ...string) {
+		t.Helper()
+		var entries []catalogEntry
+		for _, name := range names {
+			entries = append(entries, catalogEntry{name: name, ext: filepath.Ext(name)})
+		}
+		base := filepath.Base(relPath)
+		table, err := buildKcesModContentTable(base[:len(base)-len(filepath.Ext(base))], "", ct.CatalogTypeParts, packageType, priority, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(root, relPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.WriteCtFile(path, table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCatalog("mod_a/outfit.ct", ct.PackageTypePlugin, 0, "outfit.menuassets", "shared.tex")
+	writeCatalog("mod_b/nested/override.ct", ct.PackageTypePluginPatch, 0, "shared.tex")
+	if err := os.WriteFile(filepath.Join(root, "broken.ct"), []byte("not a content table"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.CatalogConflicts([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Catalogs) != 2 || len(report.Errors) != 1 || report.Errors[0].Source != filepath.Join(root, "broken.ct") {
+		t.Fatalf("catalogs = %+v, errors = %+v", report.Catalogs, report.Errors)
+	}
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("conflicts = %+v", report.Conflicts)
+	}
+	conflict := report.Conflicts[0]
+	if conflict.Name != "shared.tex" || conflict.Winner != filepath.Join(root, "mod_b", "nested", "override.ct") || conflict.Ambiguous {
+		t.Errorf("conflict = %+v", conflict)
+	}
+
+	if _, err := service.CatalogConflicts([]string{t.TempDir()}); err == nil {
+		t.Error("directory without .ct files was accepted")
+	}
+}
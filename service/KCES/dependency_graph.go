@@ -0,0 +1,74 @@
+package KCES
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
+)
+
+// IsUnityBundleFile 判断路径是否为依赖图可读取的 UnityFS 包：.aba、.asset_bg 或 .asset_scene
+// IsUnityBundleFile reports whether a path names a UnityFS bundle the dependency graph can read: .aba, .asset_bg, or .asset_scene
+func IsUnityBundleFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".aba" || ext == assetBGExtension || ext == aba.AssetSceneExtension
+}
+
+// DependencyGraph 读取 paths 中的全部 UnityFS 包（目录会递归查找 .aba、.asset_bg 和 .asset_scene）并构建跨包 PPtr 依赖图
+// DependencyGraph reads every UnityFS bundle in paths, searching directories recursively for .aba, .asset_bg, and .asset_scene files, and builds the cross-bundle PPtr dependency graph
+func (s *AbaService) DependencyGraph(paths []string) (*aba.DependencyGraph, error) {
+	var bundlePaths []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			bundlePaths = append(bundlePaths, path)
+			continue
+		}
+		var found []string
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && IsUnityBundleFile(p) {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan %s: %w", path, err)
+		}
+		sort.Strings(found)
+		bundlePaths = append(bundlePaths, found...)
+	}
+	if len(bundlePaths) == 0 {
+		return nil, fmt.Errorf("no .aba, .asset_bg, or .asset_scene files found")
+	}
+
+	sources := make([]aba.DependencyGraphSource, 0, len(bundlePaths))
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, path := range bundlePaths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open bundle %s failed: %w", path, err)
+		}
+		files = append(files, f)
+		bundle, err := aba.ReadAba(f)
+		if err != nil {
+			return nil, fmt.Errorf("parse bundle %s failed: %w", path, err)
+		}
+		sources = append(sources, aba.DependencyGraphSource{Name: path, Bundle: bundle})
+	}
+	return aba.BuildDependencyGraph(sources)
+}
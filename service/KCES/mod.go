@@ -13,6 +13,7 @@ import (
 	"io"
 	"math"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -24,14 +25,19 @@ import (
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/msgpack"
 )
 
-// ModManifest 描述一次 KCES MOD 打包的内部输入，由纯目录扫描自动构建 / ModManifest describes the internal input of one KCES MOD packing run and is built automatically by pure-directory scanning
+// ModManifest 描述一次 KCES MOD 打包的输入，可由纯目录扫描自动构建，也可作为 JSON 清单文件手写
+// ModManifest describes the input of one KCES MOD packing run, either built automatically by pure-directory scanning or authored as a JSON manifest file
 type ModManifest struct {
-	Name        string     // MOD 名称，同时作为输出文件名 name.ct 和 name.aba / MOD name, also used for output file names name.ct and name.aba
-	SubName     string     // PluginPatch/ExtraPatch 的依赖子名称 / Dependency sub-name for PluginPatch and ExtraPatch
-	CatalogType string     // 资源分类，可用 | 组合 Flags，如 Parts|PartsMeta / Resource category flags, combinable with |
-	PackageType string     // 包类型，如 Plugin=1 / Package type such as Plugin=1
-	Priority    int32      // 加载优先级 / Load priority
-	Assets      []ModAsset // 资源列表 / Asset list
+	Schema           string     `json:"$schema,omitempty"`          // 编辑器使用的模式地址，打包时忽略 / Schema location used by editors, ignored when packing
+	Name             string     `json:"name"`                       // MOD 名称，同时作为输出文件名 name.ct 和 name.aba / MOD name, also used for output file names name.ct and name.aba
+	SubName          string     `json:"subName,omitempty"`          // PluginPatch/ExtraPatch 的依赖子名称 / Dependency sub-name for PluginPatch and ExtraPatch
+	CatalogType      string     `json:"catalogType,omitempty"`      // 资源分类，可用 | 组合 Flags，如 Parts|PartsMeta / Resource category flags, combinable with |
+	PackageType      string     `json:"packageType,omitempty"`      // 包类型，如 Plugin=1 / Package type such as Plugin=1
+	Priority         int32      `json:"priority,omitempty"`         // 加载优先级 / Load priority
+	Compression      string     `json:"compression,omitempty"`      // ABA 数据块压缩：none、lz4、lz4hc 或 lzma / ABA data-block compression: none, lz4, lz4hc, or lzma
+	CompressionLevel int32      `json:"compressionLevel,omitempty"` // lz4hc 或 lzma 的 1 到 9 级，0 使用默认级别 / lz4hc or lzma level from 1 to 9, with 0 selecting the default
+	UnityVersion     string     `json:"unityVersion,omitempty"`     // 写入 SerializedFile 和 UnityFS 头的 Unity 版本，为空时使用 2022.3.35f1 / Unity version written to the SerializedFile and UnityFS header, 2022.3.35f1 when empty
+	Assets           []ModAsset `json:"assets"`                     // 资源列表 / Asset list
 }
 
 // Kind 决定资源在 .aba 中的 Unity 对象类型 / Kind controls the Unity object type written into .aba:
@@ -48,9 +54,9 @@ type ModManifest struct {
 //
 // ModAsset 描述 MOD 中的单个资源文件 / ModAsset describes one asset file in a MOD
 type ModAsset struct {
-	Name             string // 资源短名称，同时作为 m_Name、m_Container 键和 CT 名称 / Short resource name used as m_Name, the m_Container key, and the CT name
-	Path             string // 源文件路径，相对于扫描根目录 / Source file path relative to the scan root directory
-	Kind             string // 资源类型，如 textasset、texture2d、mesh、sprite / Asset kind such as textasset, texture2d, mesh, or sprite
+	Name             string `json:"name,omitempty"` // 资源短名称，同时作为 m_Name、m_Container 键和 CT 名称 / Short resource name used as m_Name, the m_Container key, and the CT name
+	Path             string `json:"path"`           // 源文件路径，相对于扫描根目录 / Source file path relative to the scan root directory
+	Kind             string `json:"kind,omitempty"` // 资源类型，如 textasset、texture2d、mesh、sprite / Asset kind such as textasset, texture2d, mesh, or sprite
 	preserveRawData  bool   // 纯目录打包时是否严格保留原始对象字节 / Whether pure-directory packing must preserve raw object bytes exactly
 	nativeObjectFile bool   // 输入是否为内嵌 TypeTree 的独立 Unity 对象文件 / Whether the input is a standalone Unity object file with an embedded TypeTree
 }
@@ -69,7 +75,10 @@ func packModManifest(manifest ModManifest, baseDir string, outputDir string) err
 
 // modPackOptions 控制不属于公开清单格式的内部打包行为 / modPackOptions controls internal packing behavior that is not part of the public manifest format
 type modPackOptions struct {
-	CompressAba bool // 是否压缩 ABA 数据块 / Whether ABA data blocks are compressed
+	CompressAba      bool               // Compression 为空时是否使用 LZ4 压缩 ABA 数据块 / Whether ABA data blocks are compressed with LZ4 when Compression is empty
+	Compression      aba.AbaCompression // ABA 数据块压缩类型，为空时由 CompressAba 决定 / ABA data-block compression kind, decided by CompressAba when empty
+	CompressionLevel int                // LZ4HC 或 LZMA 的压缩级别，0 使用默认级别 / LZ4HC or LZMA compression level, with 0 selecting the default
+	DryRun           bool               // 完整生成两个输出但丢弃结果，用于校验清单 / Generate both outputs completely but discard them, used to validate a manifest
 }
 
 // packModManifestWithOptions 根据清单和内部选项构建固定 Unity 2022.3.35f1 的 ABA 和对应 CT
@@ -111,7 +120,10 @@ func packModManifestWithOptions(manifest ModManifest, baseDir string, outputDir
 	if err != nil {
 		return fmt.Errorf("build canonical AssetBundle load names: %w", err)
 	}
-	versionSettings := resolveUnityPackSettings()
+	versionSettings, err := resolveUnityPackSettingsForVersion(manifest.UnityVersion)
+	if err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
 
 	// 所有对象共享一个 SerializedFile，原始 Unity 对象必须使用同一版本上下文
 	// All objects share one SerializedFile, so raw Unity objects must use the same version context
@@ -140,6 +152,13 @@ func packModManifestWithOptions(manifest ModManifest, baseDir string, outputDir
 		return fmt.Errorf("build canonical PathIDs: %w", err)
 	}
 
+	// convert2texture2d --stream 输出的 <贴图>.resS 伴随文件不单独打包，而是并入 AssetBundle 的 CAB-<名称>.resS 流条目
+	// <texture>.resS companions written by convert2texture2d --stream are not packed on their own but merged into the AssetBundle's CAB-<name>.resS stream entry
+	streamCompanions := findTexture2DStreamCompanions(manifest.Assets, assetPaths)
+	consumedCompanions := make(map[string]bool, len(streamCompanions))
+	streamEntryName := "CAB-" + manifest.Name + ".resS"
+	var streamEntry packStreamEntry
+
 	for assetIndex, a := range manifest.Assets {
 		relPath := assetPaths[assetIndex]
 		name := a.Name
@@ -156,6 +175,9 @@ func packModManifestWithOptions(manifest ModManifest, baseDir string, outputDir
 			kind = inferKindForPack(name, a.Path)
 		}
 		if kind == "abaraw" {
+			if isTexture2DStreamCompanion(streamCompanions, relPath) {
+				continue
+			}
 			return fmt.Errorf("asset %q: .resS/.resource sidecars are not packable; stream payloads must be inlined into their Unity objects", a.Path)
 		}
 		canonicalPath, err := canonicalAssetPathForID(filepath.ToSlash(relPath))
@@ -185,7 +207,14 @@ func packModManifestWithOptions(manifest ModManifest, baseDir string, outputDir
 			assetNames[nameHash] = catalogName
 		}
 		if classID, ok := unityRawClassIDForKind(kind); ok {
-			if a.nativeObjectFile {
+			if companionPath, streamed := streamCompanions[relPath]; streamed && a.nativeObjectFile && classID == aba.ClassIDTexture2D {
+				object, err := relocateTexture2DStreamCompanion(sourceRoot, relPath, companionPath, &streamEntry, "archive:/CAB-"+manifest.Name+"/"+streamEntryName)
+				if err != nil {
+					return fmt.Errorf("asset %q: %w", a.Path, err)
+				}
+				consumedCompanions[companionPath] = true
+				sfWriter.AddNativeUnityObjectWithLoadNameAndPathID(object, name, loadName, pathID)
+			} else if a.nativeObjectFile {
 				header, source, err := newPackRootNativeUnityObjectSource(sourceRoot, relPath)
 				if err != nil {
 					return fmt.Errorf("open native Unity asset %q: %w", a.Path, err)
@@ -242,14 +271,24 @@ func packModManifestWithOptions(manifest ModManifest, baseDir string, outputDir
 	if err != nil {
 		return fmt.Errorf("calculate SerializedFile size: %w", err)
 	}
+	for _, companionPath := range streamCompanions {
+		if !consumedCompanions[companionPath] {
+			return fmt.Errorf("asset %q: .resS companion has no matching native Texture2D; stream payloads must belong to a texture", companionPath)
+		}
+	}
 	abaEntries := []aba.AbaFileEntry{
 		{Name: "CAB-" + manifest.Name, WriteTo: sfWriter.Write, Size: serializedSize, IsSerialized: true},
 	}
+	if streamEntry.size > 0 {
+		abaEntries = append(abaEntries, aba.AbaFileEntry{Name: streamEntryName, WriteTo: streamEntry.writeTo(sourceRoot), Size: streamEntry.size})
+	}
 	abaOptions := &aba.AbaWriteOptions{
 		EngineVersion:     versionSettings.EngineVersion,
 		GenerationVersion: versionSettings.GenerationVersion,
 		Version:           versionSettings.AbaVersion,
 		Compress:          options.CompressAba,
+		Compression:       options.Compression,
+		CompressionLevel:  options.CompressionLevel,
 	}
 
 	table, err := buildKcesModContentTable(manifest.Name, manifest.SubName, catalogType, packageType, manifest.Priority, entries)
@@ -257,6 +296,16 @@ func packModManifestWithOptions(manifest ModManifest, baseDir string, outputDir
 		return err
 	}
 
+	if options.DryRun {
+		if err := ct.WriteContentTable(io.Discard, table); err != nil {
+			return fmt.Errorf("write .ct: %w", err)
+		}
+		if err := aba.WriteAba(io.Discard, abaEntries, abaOptions); err != nil {
+			return fmt.Errorf("write .aba file: %w", err)
+		}
+		return nil
+	}
+
 	// 成对提交器将两个最终文件直接写入同目录临时文件，再备份旧目标并逐一原子重命名，任何提交错误都会删除本次新目标并恢复旧目标
 	// The paired committer writes both final files directly to same-directory temporary files, then backs up old targets, atomically renames each file, and restores the old targets after any commit failure
 	if err := writePackOutputPairWithWriters(
@@ -613,6 +662,122 @@ func newPackRootNativeUnityObjectSource(root *os.Root, relPath string) (*aba.Nat
 	return header, source, nil
 }
 
+// packStreamSegment 记录一个伴随 .resS 文件范围在 AssetBundle 流条目中的位置
+// packStreamSegment records where one companion .resS file range lands inside the AssetBundle stream entry
+type packStreamSegment struct {
+	relPath      string      // 伴随文件相对路径 / Companion file relative path
+	info         os.FileInfo // 打开前检查的文件信息 / File information checked before opening
+	sourceOffset int64       // 伴随文件内偏移 / Offset inside the companion file
+	size         int64       // 范围字节数 / Range byte length
+	entryOffset  int64       // 流条目内偏移 / Offset inside the stream entry
+}
+
+// packStreamEntry 按 16 字节对齐依次拼接各贴图的流式载荷，写出时逐段从源文件复制以避免整体载入内存
+// packStreamEntry concatenates the streamed payloads of each texture at 16-byte alignment and copies each segment from its source file when written so the whole entry is never held in memory
+type packStreamEntry struct {
+	segments []packStreamSegment
+	size     int64
+}
+
+// add 追加一段载荷并返回其在流条目中的偏移 / add appends one payload range and returns its offset inside the stream entry
+func (e *packStreamEntry) add(relPath string, info os.FileInfo, sourceOffset int64, size int64) int64 {
+	offset := (e.size + 15) &^ 15
+	e.segments = append(e.segments, packStreamSegment{relPath: relPath, info: info, sourceOffset: sourceOffset, size: size, entryOffset: offset})
+	e.size = offset + size
+	return offset
+}
+
+// writeTo 返回可重复调用的流条目生成器，段间对齐填充写零
+// writeTo returns a repeatable stream-entry generator that writes zeros for the alignment padding between segments
+func (e *packStreamEntry) writeTo(root *os.Root) aba.AbaEntryWriteFunc {
+	return func(out io.Writer) error {
+		var written int64
+		for _, segment := range e.segments {
+			if padding := segment.entryOffset - written; padding > 0 {
+				if _, err := out.Write(make([]byte, padding)); err != nil {
+					return fmt.Errorf("write stream padding: %w", err)
+				}
+			}
+			if err := writeVerifiedPackRootFileRange(root, segment.relPath, segment.info, segment.sourceOffset, segment.size, out); err != nil {
+				return err
+			}
+			written = segment.entryOffset + segment.size
+		}
+		return nil
+	}
+}
+
+// findTexture2DStreamCompanions 将每个名为 <路径>.resS 的伴随文件映射到同名主文件路径，只有主文件也在清单中时才视为伴随文件
+// findTexture2DStreamCompanions maps every companion named <path>.resS to its primary file path, treating it as a companion only when the primary file is also in the manifest
+func findTexture2DStreamCompanions(assets []ModAsset, assetPaths []string) map[string]string {
+	known := make(map[string]bool, len(assetPaths))
+	for _, relPath := range assetPaths {
+		known[relPath] = true
+	}
+	companions := make(map[string]string)
+	for i, relPath := range assetPaths {
+		if !strings.EqualFold(filepath.Ext(relPath), ".resS") {
+			continue
+		}
+		if kind := strings.ToLower(assets[i].Kind); kind != "" && kind != "abaraw" {
+			continue
+		}
+		primary := relPath[:len(relPath)-len(".resS")]
+		if known[primary] {
+			companions[primary] = relPath
+		}
+	}
+	return companions
+}
+
+// isTexture2DStreamCompanion 判断路径是否为某个主文件的 .resS 伴随文件 / isTexture2DStreamCompanion reports whether a path is the .resS companion of some primary file
+func isTexture2DStreamCompanion(companions map[string]string, relPath string) bool {
+	for _, companionPath := range companions {
+		if companionPath == relPath {
+			return true
+		}
+	}
+	return false
+}
+
+// relocateTexture2DStreamCompanion 读取流式 Texture2D 主文件，校验其 m_StreamData 指向伴随文件，再把该范围登记到流条目并改写为 AssetBundle 内路径
+// relocateTexture2DStreamCompanion reads a streamed Texture2D primary file, checks that its m_StreamData points at the companion, then registers that range in the stream entry and rewrites it to the in-bundle path
+func relocateTexture2DStreamCompanion(root *os.Root, relPath string, companionPath string, entry *packStreamEntry, streamPath string) (*aba.NativeUnityObject, error) {
+	data, err := readPackRootRegularFile(root, relPath)
+	if err != nil {
+		return nil, fmt.Errorf("read streamed Texture2D: %w", err)
+	}
+	object, err := aba.ReadTexture2D(data)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := object.Texture2DStreamData()
+	if err != nil {
+		return nil, err
+	}
+	if stream.Size == 0 {
+		return nil, fmt.Errorf("Texture2D stores inline image data but companion %q exists; delete the stale companion", companionPath)
+	}
+	if !strings.EqualFold(pathpkg.Base(strings.ReplaceAll(stream.Path, "\\", "/")), pathpkg.Base(companionPath)) {
+		return nil, fmt.Errorf("Texture2D m_StreamData path %q does not name companion %q", stream.Path, companionPath)
+	}
+	info, err := root.Lstat(companionPath)
+	if err != nil {
+		return nil, err
+	}
+	if isLinkOrReparse(info) || !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("companion %q is not a regular file", companionPath)
+	}
+	if stream.Offset < 0 || stream.Size > uint64(info.Size()) || stream.Offset > info.Size()-int64(stream.Size) {
+		return nil, fmt.Errorf("Texture2D stream range [%d,+%d) exceeds companion %q size %d", stream.Offset, stream.Size, companionPath, info.Size())
+	}
+	offset := entry.add(companionPath, info, stream.Offset, int64(stream.Size))
+	if err := object.SetTexture2DStreamData(aba.StreamingInfo{Offset: offset, Size: stream.Size, Path: streamPath}); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
 // readVerifiedPackRootFileRange 读取已检查普通文件中的确定范围并复查文件身份
 // readVerifiedPackRootFileRange reads an exact range from a checked regular file and verifies its identity afterward
 func readVerifiedPackRootFileRange(root *os.Root, relPath string, expected os.FileInfo, offset int64, size int64) ([]byte, error) {
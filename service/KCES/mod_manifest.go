@@ -0,0 +1,94 @@
+package KCES
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/internal/strictjson"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
+)
+
+const (
+	// defaultManifestCatalogType 是清单省略 catalogType 时使用的资源分类，与纯目录打包一致
+	// defaultManifestCatalogType is the catalog type used when a manifest omits catalogType, matching pure-directory packing
+	defaultManifestCatalogType = "Parts"
+	// defaultManifestPackageType 是清单省略 packageType 时使用的包类型，与纯目录打包一致
+	// defaultManifestPackageType is the package type used when a manifest omits packageType, matching pure-directory packing
+	defaultManifestPackageType = "Plugin"
+)
+
+// ReadModManifest 严格读取 JSON MOD 清单文件，未知字段、错误 null 和多余内容都会被拒绝
+// ReadModManifest strictly reads a JSON MOD manifest file, rejecting unknown fields, invalid null values, and trailing content
+func ReadModManifest(path string) (*ModManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest %q: %w", path, err)
+	}
+	var manifest ModManifest
+	if err := decodeStrictJSON(data, &manifest, "manifest"); err != nil {
+		return nil, err
+	}
+	if err := strictjson.RequireObjectFields(trimJSONUTF8BOM(data), "manifest", "name", "assets"); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// ValidateModManifest 按打包时的完整流程校验清单及其资源文件，但不写出任何文件；资源路径相对于 baseDir
+// ValidateModManifest validates a manifest and its asset files through the complete packing flow without writing any file; asset paths are relative to baseDir
+func (s *PackService) ValidateModManifest(manifest *ModManifest, baseDir string) error {
+	resolved, options, err := resolveModManifest(manifest, baseDir)
+	if err != nil {
+		return err
+	}
+	options.DryRun = true
+	return packModManifestWithOptions(resolved, baseDir, "", options)
+}
+
+// PackModManifest 按清单在 outputDir 生成 name.aba 和 name.ct；资源路径相对于 baseDir，省略的名称和类型按纯目录打包规则推断
+// PackModManifest writes name.aba and name.ct to outputDir from a manifest; asset paths are relative to baseDir, and omitted names and kinds are inferred with the pure-directory packing rules
+func (s *PackService) PackModManifest(manifest *ModManifest, baseDir string, outputDir string) error {
+	resolved, options, err := resolveModManifest(manifest, baseDir)
+	if err != nil {
+		return err
+	}
+	for _, warning := range packGameLoadWarnings(resolved, baseDir) {
+		fmt.Fprintln(os.Stderr, "warning: "+warning)
+	}
+	return packModManifestWithOptions(resolved, baseDir, outputDir, options)
+}
+
+// resolveModManifest 补全清单默认值、解析压缩设置，并按纯目录规则补全每个资源的名称、类型和对象文件标记
+// resolveModManifest fills manifest defaults, parses the compression settings, and completes each asset's name, kind, and object-file flag with the pure-directory rules
+func resolveModManifest(manifest *ModManifest, baseDir string) (ModManifest, modPackOptions, error) {
+	if manifest == nil {
+		return ModManifest{}, modPackOptions{}, fmt.Errorf("manifest is nil")
+	}
+	resolved := *manifest
+	if resolved.CatalogType == "" {
+		resolved.CatalogType = defaultManifestCatalogType
+	}
+	if resolved.PackageType == "" {
+		resolved.PackageType = defaultManifestPackageType
+	}
+	compression, err := aba.ParseAbaCompression(resolved.Compression)
+	if err != nil {
+		return ModManifest{}, modPackOptions{}, fmt.Errorf("manifest: %w", err)
+	}
+	options := modPackOptions{
+		CompressAba:      true,
+		Compression:      compression,
+		CompressionLevel: int(resolved.CompressionLevel),
+	}
+
+	resolved.Assets = make([]ModAsset, len(manifest.Assets))
+	for i, asset := range manifest.Assets {
+		relPath, err := normalizeModAssetPath(asset.Path)
+		if err != nil {
+			return ModManifest{}, modPackOptions{}, fmt.Errorf("asset %q: unsafe source path: %w", asset.Path, err)
+		}
+		resolved.Assets[i] = newPackModAsset(filepath.Join(baseDir, relPath), filepath.ToSlash(relPath), asset.Name, asset.Kind)
+	}
+	return resolved, options, nil
+}
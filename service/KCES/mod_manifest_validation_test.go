@@ -269,3 +269,137 @@ func TestPackModManifestIgnoresLegacyPreferredPathID(t *testing.T) {
 		t.Fatalf("TextAsset PathIDs = %v, want canonical IDs %v and no legacy ID 42", seen, want)
 	}
 }
+
+func TestPackModManifestReadsAuthoredJSONManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "src", "menu.bin"), []byte("menu"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "src", "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifestPath := filepath.Join(tmpDir, "mod.json")
+	manifestJSON := `{
+  "$schema": "kces.mod_manifest.schema.json",
+  "name": "authored",
+  "packageType": "ExtraBase",
+  "priority": 7,
+  "compression": "lzma",
+  "unityVersion": "2022.3.40f1",
+  "assets": [
+    {"name": "authored.menuassets", "path": "src/menu.bin", "kind": "textasset"},
+    {"path": "src/notes.txt"}
+  ]
+}`
+	if err := os.WriteFile(manifestPath, []byte("\xef\xbb\xbf"+manifestJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ReadModManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("ReadModManifest: %v", err)
+	}
+	service := &PackService{}
+	if err := service.ValidateModManifest(manifest, tmpDir); err != nil {
+		t.Fatalf("ValidateModManifest: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "authored.aba")); !os.IsNotExist(err) {
+		t.Fatalf("ValidateModManifest wrote output: %v", err)
+	}
+	if err := service.PackModManifest(manifest, tmpDir, tmpDir); err != nil {
+		t.Fatalf("PackModManifest: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(tmpDir, "authored.ct"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	table, err := ct.ReadContentTable(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := ct.DecodeCatalogFromCt(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalog.PackageType != ct.PackageTypeExtraBase || catalog.Priority != 7 || catalog.CatalogType != ct.CatalogTypeParts {
+		t.Fatalf("catalog got package=%d priority=%d type=%d", catalog.PackageType, catalog.Priority, catalog.CatalogType)
+	}
+	abaBytes, err := os.ReadFile(filepath.Join(tmpDir, "authored.aba"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abaFile, err := aba.ReadAba(bytes.NewReader(abaBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if abaFile.DataCompression() != aba.AbaCompressionLZMA || abaFile.Header.EngineVersion != "2022.3.40f1" {
+		t.Fatalf(".aba got compression=%q engine=%q", abaFile.DataCompression(), abaFile.Header.EngineVersion)
+	}
+	serialized, err := abaFile.GetFileData(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	af, err := aba.ReadAssetsFile(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for i := range af.Metadata.AssetInfos {
+		root, err := af.ReadAssetValue(&af.Metadata.AssetInfos[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name, ok := root.Field("m_Name").String(); ok {
+			found[name] = true
+		}
+	}
+	if !found["authored.menuassets"] || !found["notes.txt"] {
+		t.Fatalf("packed object names = %v, want the explicit and inferred names", found)
+	}
+}
+
+func TestReadModManifestRejectsInvalidDocuments(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := map[string]string{
+		"unknown_field":  `{"name": "bad", "assets": [{"path": "a.bin"}], "priorty": 3}`,
+		"missing_assets": `{"name": "bad"}`,
+		"trailing_value": `{"name": "bad", "assets": [{"path": "a.bin"}]} {}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, name+".json")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadModManifest(path); err == nil {
+				t.Fatal("ReadModManifest unexpectedly succeeded")
+			}
+		})
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.bin"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []struct {
+		name     string
+		manifest ModManifest
+		want     string
+	}{
+		{"compression", ModManifest{Name: "bad", Compression: "zstd", Assets: []ModAsset{{Path: "a.bin"}}}, "unknown .aba compression"},
+		{"unity_version", ModManifest{Name: "bad", UnityVersion: "2019.4.1f1", Assets: []ModAsset{{Path: "a.bin"}}}, "unsupported unityVersion"},
+		{"escaping_path", ModManifest{Name: "bad", Assets: []ModAsset{{Path: "../a.bin"}}}, "unsafe source path"},
+		{"missing_file", ModManifest{Name: "bad", Assets: []ModAsset{{Path: "missing.bin"}}}, "missing.bin"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&PackService{}).ValidateModManifest(&tt.manifest, tmpDir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ValidateModManifest err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,447 @@
+package KCES
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	serializationCOM3D2 "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	serializationKCES "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+)
+
+// ModelPortReport 汇总 COM3D2 模型移植到 KCES 时无法表达而被丢弃或改写的内容 / ModelPortReport summarizes what a COM3D2 model port to KCES could not represent and therefore dropped or rewrote
+type ModelPortReport struct {
+	Unrepresentable []string `json:"unrepresentable"` // 每项描述一处丢弃或改写 / Each entry describes one dropped or rewritten item
+}
+
+// addf 追加一条格式化的报告项
+// addf appends one formatted report entry
+func (r *ModelPortReport) addf(format string, args ...interface{}) {
+	r.Unrepresentable = append(r.Unrepresentable, fmt.Sprintf(format, args...))
+}
+
+// ConvertCOM3D2ModelToModel 将 COM3D2 .model 或 .model.json 移植为 KCES .model 与 .mmesh 文件并写入输出目录
+// 文件名从输入文件名小写派生，boneNameMap 非空时按旧名到新名重命名骨骼，返回的报告列出无法在 KCES 中表达的内容
+// ConvertCOM3D2ModelToModel ports a COM3D2 .model or .model.json into KCES .model and .mmesh files written to the output directory
+// File names derive from the lowercased input file name, a non-empty boneNameMap renames bones from old to new names, and the returned report lists what KCES cannot represent
+func (s *ModelService) ConvertCOM3D2ModelToModel(ctx context.Context, inputPath string, outputDir string, boneNameMap map[string]string, maxOutputBytes int64) (*ModelPortReport, error) {
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	source, err := (&COM3D2Service.ModelService{}).ReadModelFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read COM3D2 model %q: %w", inputPath, err)
+	}
+	model, geometry, report, err := PortCOM3D2Model(source, boneNameMap)
+	if err != nil {
+		return nil, fmt.Errorf("port COM3D2 model %q: %w", inputPath, err)
+	}
+
+	stem := strings.ToLower(filepath.Base(inputPath))
+	stem = strings.TrimSuffix(stem, ".json")
+	stem = strings.TrimSuffix(stem, filepath.Ext(stem))
+	fileName := stem + ".model"
+	meshFileName := stem + ".mmesh"
+	model.FileName = &fileName
+	model.MeshFileName = &meshFileName
+
+	meshObject, err := aba.NewNativeMeshObject(meshFileName, geometry)
+	if err != nil {
+		return nil, fmt.Errorf("build native Mesh for %q: %w", inputPath, err)
+	}
+	meshBytes, err := aba.WriteMMesh(meshObject)
+	if err != nil {
+		return nil, fmt.Errorf("encode native Mesh for %q: %w", inputPath, err)
+	}
+	modelBytes, err := serializationKCES.EncodeModel(model)
+	if err != nil {
+		return nil, fmt.Errorf("encode Model for %q: %w", inputPath, err)
+	}
+
+	modelPath := filepath.Join(outputDir, fileName)
+	if inputAbs, err := filepath.Abs(inputPath); err == nil {
+		if outputAbs, err := filepath.Abs(modelPath); err == nil && strings.EqualFold(inputAbs, outputAbs) {
+			return nil, fmt.Errorf("KCES model output %q would overwrite the COM3D2 input; choose another output directory", modelPath)
+		}
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("create output directory %q: %w", outputDir, err)
+	}
+	if err := writeNativeUnityGLTFOutput(ctx, filepath.Join(outputDir, meshFileName), meshBytes, maxOutputBytes); err != nil {
+		return nil, err
+	}
+	if err := writeNativeUnityGLTFOutput(ctx, modelPath, modelBytes, maxOutputBytes); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// PortCOM3D2Model 将 COM3D2 模型映射为 KCES Model 与网格几何，文件名字段留空由调用方填写
+// 两个游戏都使用 Unity 左手坐标，因此骨骼变换、顶点、绑定姿势和变形差分均按原值复制；boneNameMap 同时作用于骨架、蒙皮骨骼名、modelName 和皮肤厚度骨骼名
+// PortCOM3D2Model maps a COM3D2 model onto a KCES Model and mesh geometry, leaving the file-name fields for the caller to fill in
+// Both games use Unity's left-handed coordinates, so bone transforms, vertices, bind poses, and morph deltas are copied as-is; boneNameMap applies to the skeleton, skin bone names, modelName, and skin-thickness bone names alike
+func PortCOM3D2Model(source *serializationCOM3D2.Model, boneNameMap map[string]string) (*serializationKCES.Model, *aba.MeshGeometry, *ModelPortReport, error) {
+	if source == nil {
+		return nil, nil, nil, fmt.Errorf("COM3D2 model is nil")
+	}
+	if len(source.Bones) == 0 {
+		return nil, nil, nil, fmt.Errorf("COM3D2 model has no bones")
+	}
+	if len(source.Vertices) == 0 {
+		return nil, nil, nil, fmt.Errorf("COM3D2 model has no vertices")
+	}
+	report := &ModelPortReport{}
+	usedMappings := make(map[string]bool, len(boneNameMap))
+	rename := func(name string) string {
+		if mapped, ok := boneNameMap[name]; ok {
+			usedMappings[name] = true
+			return mapped
+		}
+		return name
+	}
+
+	model := serializationKCES.NewModel()
+	boneCount := int32(len(source.Bones))
+	transIndexByName := make(map[string]int, len(source.Bones))
+	model.TransData = make([]*serializationKCES.TransData, len(source.Bones))
+	for boneIndex, bone := range source.Bones {
+		if bone == nil {
+			return nil, nil, nil, fmt.Errorf("bone %d is null", boneIndex)
+		}
+		name := rename(bone.Name)
+		if name == "" {
+			return nil, nil, nil, fmt.Errorf("bone %d has no name", boneIndex)
+		}
+		if _, exists := transIndexByName[name]; exists {
+			return nil, nil, nil, fmt.Errorf("bone name %q is duplicated after remapping", name)
+		}
+		transIndexByName[name] = boneIndex
+		parentNo := bone.ParentIndex
+		if parentNo < 0 {
+			parentNo = -1
+		} else if parentNo >= boneCount || parentNo == int32(boneIndex) {
+			return nil, nil, nil, fmt.Errorf("bone %q has invalid parent index %d", bone.Name, bone.ParentIndex)
+		}
+		scale := serializationKCES.Vector3{X: 1, Y: 1, Z: 1}
+		if bone.Scale != nil {
+			scale = serializationKCES.Vector3{X: bone.Scale.X, Y: bone.Scale.Y, Z: bone.Scale.Z}
+		}
+		model.TransData[boneIndex] = &serializationKCES.TransData{
+			Name:     &name,
+			ParentNo: parentNo,
+			IsSCL:    bone.HasScale,
+			Pos:      serializationKCES.Vector3{X: bone.Position.X, Y: bone.Position.Y, Z: bone.Position.Z},
+			Rot:      serializationKCES.Vector4{X: bone.Rotation.X, Y: bone.Rotation.Y, Z: bone.Rotation.Z, W: bone.Rotation.W},
+			Scale:    scale,
+		}
+	}
+
+	modelName := rename(source.Name)
+	if _, ok := transIndexByName[modelName]; !ok {
+		return nil, nil, nil, fmt.Errorf("model name %q does not match any bone; KCES attaches the mesh to the bone named by modelName", modelName)
+	}
+	model.ModelName = &modelName
+	if rootBoneName := rename(source.RootBoneName); rootBoneName != "" {
+		if rootIndex, ok := transIndexByName[rootBoneName]; !ok || model.TransData[rootIndex].ParentNo >= 0 {
+			report.addf("root bone %q is not a hierarchy root; KCES has no root-bone field and roots the skeleton at every parentless bone", rootBoneName)
+		}
+	}
+
+	if len(source.BoneNames) == 0 {
+		return nil, nil, nil, fmt.Errorf("COM3D2 model references no skin bones")
+	}
+	if len(source.BoneNames) != len(source.BindPoses) {
+		return nil, nil, nil, fmt.Errorf("COM3D2 model has %d BoneNames but %d BindPoses", len(source.BoneNames), len(source.BindPoses))
+	}
+	model.BoneNames = make([]*string, len(source.BoneNames))
+	for boneIndex, boneName := range source.BoneNames {
+		// 衣装的蒙皮骨骼可以引用只存在于身体骨架中的骨骼，因此不要求其出现在 TransData 中
+		// Skin bones of clothing may reference bones that exist only in the body skeleton, so they need not appear in TransData
+		name := rename(boneName)
+		model.BoneNames[boneIndex] = &name
+	}
+
+	geometry, err := portCOM3D2ModelGeometry(source, report)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	geometry.Name = modelName
+
+	model.Morphs = make([]*serializationKCES.BlendData, 0, len(source.MorphData))
+	for morphIndex, morph := range source.MorphData {
+		blend, err := portCOM3D2Morph(morph, int64(len(source.Vertices)))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("morph %d: %w", morphIndex, err)
+		}
+		model.Morphs = append(model.Morphs, blend)
+	}
+	if len(model.Morphs) == 0 {
+		model.Morphs = nil
+	}
+
+	if len(source.Materials) != len(source.SubMeshes) {
+		report.addf("%d materials for %d submeshes; KCES pairs materialFileName[i] with SubMesh i", len(source.Materials), len(source.SubMeshes))
+	}
+	model.MaterialFileName = make([]*string, len(source.Materials))
+	for materialIndex, material := range source.Materials {
+		if material == nil || material.Name == "" {
+			return nil, nil, nil, fmt.Errorf("material %d has no name", materialIndex)
+		}
+		name := material.Name
+		model.MaterialFileName[materialIndex] = &name
+	}
+	if len(source.Materials) != 0 {
+		report.addf("%d materials keep only their names; COM3D2 shaders and properties are not part of a KCES .model, so each name must match an entry in the .materialassets container", len(source.Materials))
+	}
+
+	model.ShadowModeFlags = portCOM3D2ShadowMode(source.ShadowCastingMode, report)
+	if source.SkinThickness != nil {
+		model.SkinThick = portCOM3D2SkinThickness(source.SkinThickness, rename)
+	}
+
+	for _, oldName := range sortedBoneNameMapKeys(boneNameMap) {
+		if !usedMappings[oldName] {
+			report.addf("bone map entry %q -> %q matched no bone", oldName, boneNameMap[oldName])
+		}
+	}
+	return model, geometry, report, nil
+}
+
+// portCOM3D2ModelGeometry 将 COM3D2 顶点、切线、蒙皮权重、绑定姿势和子网格复制为网格几何
+// portCOM3D2ModelGeometry copies COM3D2 vertices, tangents, skin weights, bind poses, and submeshes into mesh geometry
+func portCOM3D2ModelGeometry(source *serializationCOM3D2.Model, report *ModelPortReport) (*aba.MeshGeometry, error) {
+	vertexCount := len(source.Vertices)
+	geometry := &aba.MeshGeometry{
+		Positions: make([][3]float32, vertexCount),
+		Normals:   make([][3]float32, vertexCount),
+	}
+	geometry.TexCoords[0] = make([][2]float32, vertexCount)
+	extraUVs := [3]string{"UV2", "UV3", "UV4"}
+	unknownChannels := 0
+	for vertexIndex, vertex := range source.Vertices {
+		geometry.Positions[vertexIndex] = [3]float32{vertex.Position.X, vertex.Position.Y, vertex.Position.Z}
+		geometry.Normals[vertexIndex] = [3]float32{vertex.Normal.X, vertex.Normal.Y, vertex.Normal.Z}
+		geometry.TexCoords[0][vertexIndex] = [2]float32{vertex.UV.X, vertex.UV.Y}
+		for channelIndex, uv := range [3]*serializationCOM3D2.Vector2{vertex.UV2, vertex.UV3, vertex.UV4} {
+			set := &geometry.TexCoords[channelIndex+1]
+			if vertexIndex == 0 && uv != nil {
+				*set = make([][2]float32, vertexCount)
+			}
+			if (uv != nil) != (*set != nil) {
+				return nil, fmt.Errorf("vertex %d does not match vertex 0 in carrying %s", vertexIndex, extraUVs[channelIndex])
+			}
+			if uv != nil {
+				(*set)[vertexIndex] = [2]float32{uv.X, uv.Y}
+			}
+		}
+		if vertexIndex == 0 {
+			for _, unknown := range [4]*serializationCOM3D2.Vector2{vertex.Unknown1, vertex.Unknown2, vertex.Unknown3, vertex.Unknown4} {
+				if unknown != nil {
+					unknownChannels++
+				}
+			}
+		}
+	}
+	if unknownChannels != 0 {
+		report.addf("%d unknown vertex channels were dropped; the game reads them without using them", unknownChannels)
+	}
+
+	if len(source.Tangents) != 0 {
+		if len(source.Tangents) != vertexCount {
+			report.addf("%d tangents for %d vertices were dropped", len(source.Tangents), vertexCount)
+		} else {
+			geometry.Tangents = make([][4]float32, vertexCount)
+			for vertexIndex, tangent := range source.Tangents {
+				geometry.Tangents[vertexIndex] = [4]float32{tangent.X, tangent.Y, tangent.Z, tangent.W}
+			}
+		}
+	}
+
+	if len(source.BoneWeights) != vertexCount {
+		return nil, fmt.Errorf("COM3D2 model has %d bone weights for %d vertices", len(source.BoneWeights), vertexCount)
+	}
+	boneCount := len(source.BoneNames)
+	geometry.SkinCounts = make([]uint8, vertexCount)
+	type influence struct {
+		bone   uint16
+		weight float32
+	}
+	for vertexIndex, boneWeight := range source.BoneWeights {
+		candidates := [4]influence{
+			{boneWeight.BoneIndex0, boneWeight.Weight0},
+			{boneWeight.BoneIndex1, boneWeight.Weight1},
+			{boneWeight.BoneIndex2, boneWeight.Weight2},
+			{boneWeight.BoneIndex3, boneWeight.Weight3},
+		}
+		influences := make([]influence, 0, 4)
+		var sum float64
+		for _, candidate := range candidates {
+			if candidate.weight != candidate.weight || candidate.weight < 0 {
+				return nil, fmt.Errorf("vertex %d has invalid bone weight %f", vertexIndex, candidate.weight)
+			}
+			if candidate.weight == 0 {
+				continue
+			}
+			if int(candidate.bone) >= boneCount {
+				return nil, fmt.Errorf("vertex %d references bone %d outside %d skin bones", vertexIndex, candidate.bone, boneCount)
+			}
+			influences = append(influences, candidate)
+			sum += float64(candidate.weight)
+		}
+		if len(influences) == 0 || sum <= 0 {
+			return nil, fmt.Errorf("vertex %d has no bone influences", vertexIndex)
+		}
+		sort.SliceStable(influences, func(left, right int) bool {
+			return influences[left].weight > influences[right].weight
+		})
+		geometry.SkinCounts[vertexIndex] = uint8(len(influences))
+		for _, entry := range influences {
+			geometry.SkinIndices = append(geometry.SkinIndices, uint32(entry.bone))
+			geometry.SkinWeights = append(geometry.SkinWeights, float32(float64(entry.weight)/sum))
+		}
+	}
+	// COM3D2 与 aba 的 16 元素矩阵按相同内存顺序映射到 glTF 矩阵，因此可逐元素复制
+	// COM3D2 and aba 16-element matrices map to glTF matrices in the same memory order, so they copy element by element
+	geometry.BindPoses = make([][16]float32, len(source.BindPoses))
+	for matrixIndex, matrix := range source.BindPoses {
+		geometry.BindPoses[matrixIndex] = [16]float32(matrix)
+	}
+
+	if len(source.SubMeshes) == 0 {
+		return nil, fmt.Errorf("COM3D2 model has no submeshes")
+	}
+	geometry.Primitives = make([]aba.MeshPrimitive, len(source.SubMeshes))
+	for subMeshIndex, subMesh := range source.SubMeshes {
+		if len(subMesh)%3 != 0 {
+			return nil, fmt.Errorf("submesh %d index count %d is not divisible by three", subMeshIndex, len(subMesh))
+		}
+		indices := make([]uint32, len(subMesh))
+		for indexIndex, index := range subMesh {
+			if index < 0 || int64(index) >= int64(vertexCount) {
+				return nil, fmt.Errorf("submesh %d index %d references vertex %d outside %d vertices", subMeshIndex, indexIndex, index, vertexCount)
+			}
+			indices[indexIndex] = uint32(index)
+		}
+		geometry.Primitives[subMeshIndex] = aba.MeshPrimitive{Mode: aba.MeshPrimitiveModeTriangles, Indices: indices}
+	}
+	return geometry, nil
+}
+
+// portCOM3D2Morph 将一个 COM3D2 形态复制为 KCES BlendData
+// portCOM3D2Morph copies one COM3D2 morph into KCES BlendData
+func portCOM3D2Morph(morph *serializationCOM3D2.MorphData, vertexCount int64) (*serializationKCES.BlendData, error) {
+	if morph == nil {
+		return nil, fmt.Errorf("morph is null")
+	}
+	if len(morph.Vertex) != len(morph.Indices) || len(morph.Normals) != len(morph.Indices) {
+		return nil, fmt.Errorf("morph %q has %d indices, %d position deltas, and %d normal deltas", morph.Name, len(morph.Indices), len(morph.Vertex), len(morph.Normals))
+	}
+	if len(morph.Tangents) != 0 && len(morph.Tangents) != len(morph.Indices) {
+		return nil, fmt.Errorf("morph %q has %d tangent deltas for %d indices", morph.Name, len(morph.Tangents), len(morph.Indices))
+	}
+	name := morph.Name
+	blend := &serializationKCES.BlendData{
+		Name:   &name,
+		VIndex: make([]int32, len(morph.Indices)),
+		Vert:   make([]serializationKCES.Vector3, len(morph.Indices)),
+		Norm:   make([]serializationKCES.Vector3, len(morph.Indices)),
+	}
+	for entryIndex, vertexIndex := range morph.Indices {
+		if vertexIndex < 0 || int64(vertexIndex) >= vertexCount {
+			return nil, fmt.Errorf("morph %q references vertex %d outside %d vertices", morph.Name, vertexIndex, vertexCount)
+		}
+		blend.VIndex[entryIndex] = vertexIndex
+		delta := morph.Vertex[entryIndex]
+		blend.Vert[entryIndex] = serializationKCES.Vector3{X: delta.X, Y: delta.Y, Z: delta.Z}
+		normal := morph.Normals[entryIndex]
+		blend.Norm[entryIndex] = serializationKCES.Vector3{X: normal.X, Y: normal.Y, Z: normal.Z}
+	}
+	if len(morph.Tangents) != 0 {
+		blend.Tan = make([]serializationKCES.Vector4, len(morph.Tangents))
+		for entryIndex, tangent := range morph.Tangents {
+			blend.Tan[entryIndex] = serializationKCES.Vector4{X: tangent.X, Y: tangent.Y, Z: tangent.Z, W: tangent.W}
+		}
+	}
+	return blend, nil
+}
+
+// portCOM3D2ShadowMode 将 COM3D2 ShadowCastingMode 映射为 KCES 阴影模式标志，KCES 无对应值的模式按投射阴影处理并写入报告
+// portCOM3D2ShadowMode maps a COM3D2 ShadowCastingMode onto KCES shadow-mode flags, treating modes without a KCES value as shadow casting and reporting them
+func portCOM3D2ShadowMode(mode *string, report *ModelPortReport) int32 {
+	if mode == nil {
+		return 0
+	}
+	switch *mode {
+	case serializationCOM3D2.ShadowCastingModeOn:
+		return 1
+	case serializationCOM3D2.ShadowCastingModeOff:
+		return 2
+	default:
+		report.addf("shadow casting mode %q has no KCES equivalent and became CastShadow", *mode)
+		return 1
+	}
+}
+
+// portCOM3D2SkinThickness 复制皮肤厚度组，组内骨骼名按 rename 重命名；COM3D2 的签名、版本和组顺序在 KCES 中没有对应字段
+// portCOM3D2SkinThickness copies skin-thickness groups and renames their bone names through rename; the COM3D2 signature, version, and group order have no KCES fields
+func portCOM3D2SkinThickness(source *serializationCOM3D2.SkinThickness, rename func(string) string) *serializationKCES.SkinThickness {
+	thickness := &serializationKCES.SkinThickness{Use: source.Use, Groups: make(map[string]*serializationKCES.ThicknessGroup, len(source.Groups))}
+	for key, group := range source.Groups {
+		if group == nil {
+			thickness.Groups[key] = nil
+			continue
+		}
+		groupName := group.GroupName
+		startBoneName := rename(group.StartBoneName)
+		endBoneName := rename(group.EndBoneName)
+		ported := &serializationKCES.ThicknessGroup{
+			GroupName:       &groupName,
+			StartBoneName:   &startBoneName,
+			EndBoneName:     &endBoneName,
+			StepAngleDegree: group.StepAngleDegree,
+			Points:          make([]*serializationKCES.ThicknessPoint, len(group.Points)),
+		}
+		for pointIndex, point := range group.Points {
+			if point == nil {
+				continue
+			}
+			targetBoneName := rename(point.TargetBoneName)
+			portedPoint := &serializationKCES.ThicknessPoint{
+				TargetBoneName:         &targetBoneName,
+				RatioSegmentStartToEnd: point.RatioSegmentStartToEnd,
+				DistanceParAngle:       make([]*serializationKCES.ThicknessDefPerAngle, len(point.DistanceParAngle)),
+			}
+			for angleIndex, angle := range point.DistanceParAngle {
+				if angle == nil {
+					continue
+				}
+				portedPoint.DistanceParAngle[angleIndex] = &serializationKCES.ThicknessDefPerAngle{
+					AngleDegree:     angle.AngleDegree,
+					VertexIndex:     angle.VertexIndex,
+					DefaultDistance: angle.DefaultDistance,
+				}
+			}
+			ported.Points[pointIndex] = portedPoint
+		}
+		thickness.Groups[key] = ported
+	}
+	return thickness
+}
+
+// sortedBoneNameMapKeys 按字典序返回骨骼名映射表的旧名，使报告顺序稳定
+// sortedBoneNameMapKeys returns the old names of a bone-name map in lexical order so the report order is stable
+func sortedBoneNameMapKeys(boneNameMap map[string]string) []string {
+	keys := make([]string, 0, len(boneNameMap))
+	for key := range boneNameMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+package KCES
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	serializationCOM3D2 "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+)
+
+func newPortTestCOM3D2Model() *serializationCOM3D2.Model {
+	shadow := serializationCOM3D2.ShadowCastingModeTwoSided
+	uv2 := func(x, y float32) *serializationCOM3D2.Vector2 { return &serializationCOM3D2.Vector2{X: x, Y: y} }
+	return &serializationCOM3D2.Model{
+		Signature:         serializationCOM3D2.ModelSignature,
+		Version:           2104,
+		Name:              "skirt",
+		RootBoneName:      "Bip01",
+		ShadowCastingMode: &shadow,
+		Bones: []*serializationCOM3D2.Bone{
+			{Name: "Bip01", ParentIndex: -1, Position: serializationCOM3D2.Vector3{Y: 1}, Rotation: serializationCOM3D2.Quaternion{W: 1}},
+			{Name: "Bip01 Pelvis", ParentIndex: 0, Position: serializationCOM3D2.Vector3{Y: 0.05}, Rotation: serializationCOM3D2.Quaternion{X: -0.5, Y: 0.5, Z: -0.5, W: 0.5}},
+			{Name: "skirt", ParentIndex: 0, Rotation: serializationCOM3D2.Quaternion{W: 1}},
+			{Name: "Skirt_R", HasScale: true, ParentIndex: 1, Position: serializationCOM3D2.Vector3{X: 0.07}, Rotation: serializationCOM3D2.Quaternion{W: 1}, Scale: &serializationCOM3D2.Vector3{X: 1.1, Y: 0.9, Z: 1}},
+		},
+		BoneNames: []string{"Skirt_R", "Bip01 Pelvis", "Mune_L"},
+		BindPoses: []serializationCOM3D2.Matrix4x4{
+			{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0.1, -1.3, 0.2, 1},
+			{0, 1, 0, 0, -1, 0, 0, 0, 0, 0, 1, 0, 0.4, 0.05, -0.7, 1},
+			{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1},
+		},
+		Vertices: []serializationCOM3D2.Vertex{
+			{Position: serializationCOM3D2.Vector3{X: 0.1, Y: 1}, Normal: serializationCOM3D2.Vector3{Z: 1}, UV: serializationCOM3D2.Vector2{X: 0.1, Y: 0.3}, UV2: uv2(0.7, 0.1), Unknown1: uv2(5, -6)},
+			{Position: serializationCOM3D2.Vector3{X: -0.1, Y: 1.1}, Normal: serializationCOM3D2.Vector3{Y: 1}, UV: serializationCOM3D2.Vector2{X: 0.9, Y: 0.7}, UV2: uv2(0.2, 0.01), Unknown1: uv2(1, 2)},
+			{Position: serializationCOM3D2.Vector3{Y: 0.9, Z: 0.25}, Normal: serializationCOM3D2.Vector3{X: 1}, UV: serializationCOM3D2.Vector2{X: 0.33, Y: 0.01}, UV2: uv2(0.3, 0.4), Unknown1: uv2(0, 0)},
+			{Position: serializationCOM3D2.Vector3{X: 0.2, Y: 0.8, Z: 0.3}, Normal: serializationCOM3D2.Vector3{X: 0.6, Y: 0.8}, UV: serializationCOM3D2.Vector2{X: 0.5, Y: 0.1}, UV2: uv2(0.9, 0.99), Unknown1: uv2(-1, 3)},
+		},
+		Tangents: []serializationCOM3D2.Quaternion{{X: 1, W: 1}, {X: 1, W: -1}, {Z: 1, W: 1}, {Y: 1, W: -1}},
+		BoneWeights: []serializationCOM3D2.BoneWeight{
+			{BoneIndex0: 1, BoneIndex1: 0, Weight0: 0.25, Weight1: 0.75},
+			{BoneIndex0: 1, Weight0: 1},
+			{BoneIndex0: 2, BoneIndex1: 0, BoneIndex2: 1, Weight0: 0.5, Weight1: 0.3, Weight2: 0.2},
+			{BoneIndex0: 0, Weight0: 1},
+		},
+		SubMeshes: [][]int32{{0, 1, 2}, {1, 3, 2}},
+		Materials: []*serializationCOM3D2.Material{
+			{Name: "skirt_body", ShaderName: "CM3D2/Toony_Lighted", ShaderFilename: "cm3d2_toony_lighted"},
+			{Name: "skirt_lace", ShaderName: "CM3D2/Toony_Lighted_Trans", ShaderFilename: "cm3d2_toony_lighted_trans"},
+		},
+		MorphData: []*serializationCOM3D2.MorphData{{
+			Name:     "open",
+			Indices:  []int32{3, 0},
+			Vertex:   []serializationCOM3D2.Vector3{{X: 0.01, Y: 0.02}, {Z: -0.03}},
+			Normals:  []serializationCOM3D2.Vector3{{}, {X: 0.1}},
+			Tangents: []serializationCOM3D2.Quaternion{{W: 0.5}, {}},
+		}},
+		SkinThickness: &serializationCOM3D2.SkinThickness{
+			Signature: "SkinThickness",
+			Version:   100,
+			Use:       true,
+			Groups: map[string]*serializationCOM3D2.ThickGroup{
+				"hip": {GroupName: "hip", StartBoneName: "Bip01 Pelvis", EndBoneName: "Skirt_R", StepAngleDegree: 45, Points: []*serializationCOM3D2.ThickPoint{{
+					TargetBoneName:         "Skirt_R",
+					RatioSegmentStartToEnd: 0.5,
+					DistanceParAngle:       []*serializationCOM3D2.ThickDefPerAngle{{AngleDegree: 0, VertexIndex: 2, DefaultDistance: 0.04}},
+				}}},
+			},
+			GroupOrder: []string{"hip"},
+		},
+	}
+}
+
+func TestPortCOM3D2ModelMapsSkeletonSkinAndMorphs(t *testing.T) {
+	source := newPortTestCOM3D2Model()
+	model, geometry, report, err := PortCOM3D2Model(source, map[string]string{"Skirt_R": "Skirt_R_kces", "Hair_F": "Hair_F_kces"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.TransData) != 4 || *model.TransData[3].Name != "Skirt_R_kces" || model.TransData[3].ParentNo != 1 || !model.TransData[3].IsSCL {
+		t.Fatalf("skeleton = %+v", model.TransData[3])
+	}
+	if scale := model.TransData[0].Scale; scale.X != 1 || scale.Y != 1 || scale.Z != 1 {
+		t.Fatalf("default bone scale = %+v", scale)
+	}
+	if *model.BoneNames[0] != "Skirt_R_kces" || *model.BoneNames[2] != "Mune_L" || *model.ModelName != "skirt" {
+		t.Fatalf("bone names %q %q, model name %q", *model.BoneNames[0], *model.BoneNames[2], *model.ModelName)
+	}
+	if model.ShadowModeFlags != 1 {
+		t.Fatalf("shadow mode flags = %d, want 1", model.ShadowModeFlags)
+	}
+	group := model.SkinThick.Groups["hip"]
+	if *group.EndBoneName != "Skirt_R_kces" || *group.Points[0].TargetBoneName != "Skirt_R_kces" || group.Points[0].DistanceParAngle[0].VertexIndex != 2 {
+		t.Fatalf("skin thickness group = %+v", group)
+	}
+	if len(model.Morphs) != 1 || *model.Morphs[0].Name != "open" || model.Morphs[0].VIndex[0] != 3 || model.Morphs[0].Tan[0].W != 0.5 {
+		t.Fatalf("morphs = %+v", model.Morphs)
+	}
+	if len(model.MaterialFileName) != 2 || *model.MaterialFileName[1] != "skirt_lace" {
+		t.Fatalf("materials = %+v", model.MaterialFileName)
+	}
+
+	if geometry.SkinCounts[0] != 2 || geometry.SkinIndices[0] != 0 || geometry.SkinWeights[0] != 0.75 {
+		t.Fatalf("vertex 0 skin = %d %v %v", geometry.SkinCounts[0], geometry.SkinIndices[:2], geometry.SkinWeights[:2])
+	}
+	if geometry.BindPoses[0][12] != 0.1 || len(geometry.TexCoords[1]) != 4 || geometry.Tangents[1][3] != -1 {
+		t.Fatal("bind poses, UV2, or tangents were not copied")
+	}
+	if len(geometry.Primitives) != 2 || geometry.Primitives[1].Indices[1] != 3 {
+		t.Fatalf("primitives = %+v", geometry.Primitives)
+	}
+	if _, err := encodeModelGLTFDocument(model, geometry); err == nil {
+		t.Fatal("expected the glTF exporter to reject a skin bone outside the skeleton")
+	}
+
+	joined := strings.Join(report.Unrepresentable, "\n")
+	for _, want := range []string{"TwoSided", "unknown vertex channels", "keep only their names", `"Hair_F"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("report lacks %q:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "root bone") {
+		t.Errorf("report flags the hierarchy root:\n%s", joined)
+	}
+}
+
+func TestPortCOM3D2ModelRejectsDuplicateRemappedBones(t *testing.T) {
+	if _, _, _, err := PortCOM3D2Model(newPortTestCOM3D2Model(), map[string]string{"Skirt_R": "Bip01"}); err == nil {
+		t.Fatal("expected an error for bones that collide after remapping")
+	}
+}
+
+func TestConvertCOM3D2ModelToModelWritesModelAndMesh(t *testing.T) {
+	source := newPortTestCOM3D2Model()
+	source.BoneNames = source.BoneNames[:2]
+	source.BindPoses = source.BindPoses[:2]
+	source.BoneWeights[2] = serializationCOM3D2.BoneWeight{BoneIndex0: 0, Weight0: 1}
+	inputPath := filepath.Join(t.TempDir(), "Skirt_A.model")
+	if err := (&COM3D2Service.ModelService{}).WriteModelFile(inputPath, source); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := t.TempDir()
+	report, err := (&ModelService{}).ConvertCOM3D2ModelToModel(context.Background(), inputPath, outputDir, nil, TestConversionMaxOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Unrepresentable) == 0 {
+		t.Fatal("report is empty")
+	}
+	model, geometry := decodeModelAndMesh(t, filepath.Join(outputDir, "skirt_a.model"))
+	if *model.MeshFileName != "skirt_a.mmesh" || len(geometry.Positions) != 4 || len(geometry.BindPoses) != 2 {
+		t.Fatalf("written model %q with %d vertices and %d bind poses", *model.MeshFileName, len(geometry.Positions), len(geometry.BindPoses))
+	}
+	if _, err := encodeModelGLTFDocument(model, geometry); err != nil {
+		t.Fatalf("ported model does not export to glTF: %v", err)
+	}
+}
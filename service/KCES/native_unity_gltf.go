@@ -239,7 +239,9 @@ func encodeAnimationGLTF(clip *aba.AnimationClipCurves, binaryOutput bool) ([]by
 	document := gltf.NewDocument()
 	document.Asset.Generator = "MeidoSerialization"
 	nodeByPath := make(map[string]int)
-	animation := &gltf.Animation{Name: clip.Name}
+	animation := &gltf.Animation{Name: clip.Name, Extras: map[string]interface{}{
+		KCESAnimationClipExtrasKey: kcesAnimationClipExtras{SampleRate: clip.SampleRate, WrapMode: clip.WrapMode},
+	}}
 	for curveIndex, curve := range clip.Curves {
 		if len(curve.Keyframes) == 0 {
 			continue
@@ -324,8 +326,8 @@ func encodeAnimationGLTF(clip *aba.AnimationClipCurves, binaryOutput bool) ([]by
 	return output.Bytes(), nil
 }
 
-// ensureGLTFAnimationNode 为 Transform 路径建立缺失的 glTF 节点并返回叶节点索引
-// ensureGLTFAnimationNode creates missing glTF nodes for a Transform path and returns the leaf node index
+// ensureGLTFAnimationNode 为 Transform 路径建立缺失的 glTF 节点并返回叶节点索引，新节点在 extras 中记录其 Transform 路径
+// ensureGLTFAnimationNode creates missing glTF nodes for a Transform path and returns the leaf node index, recording each new node's Transform path in its extras
 func ensureGLTFAnimationNode(document *gltf.Document, nodeByPath map[string]int, transformPath string, fallbackName string) (int, error) {
 	if document == nil {
 		return 0, fmt.Errorf("nil glTF document")
@@ -340,7 +342,7 @@ func ensureGLTFAnimationNode(document *gltf.Document, nodeByPath map[string]int,
 			name = "AnimationRoot"
 		}
 		nodeIndex := len(document.Nodes)
-		document.Nodes = append(document.Nodes, &gltf.Node{Name: name})
+		document.Nodes = append(document.Nodes, &gltf.Node{Name: name, Extras: map[string]interface{}{KCESTransformPathExtrasKey: ""}})
 		document.Scenes[0].Nodes = append(document.Scenes[0].Nodes, nodeIndex)
 		nodeByPath[""] = nodeIndex
 		return nodeIndex, nil
@@ -362,7 +364,7 @@ func ensureGLTFAnimationNode(document *gltf.Document, nodeByPath map[string]int,
 			continue
 		}
 		nodeIndex := len(document.Nodes)
-		document.Nodes = append(document.Nodes, &gltf.Node{Name: part})
+		document.Nodes = append(document.Nodes, &gltf.Node{Name: part, Extras: map[string]interface{}{KCESTransformPathExtrasKey: currentPath}})
 		nodeByPath[currentPath] = nodeIndex
 		if parentIndex >= 0 {
 			document.Nodes[parentIndex].Children = append(document.Nodes[parentIndex].Children, nodeIndex)
@@ -388,6 +390,52 @@ func unityEulerQuaternion(xDegrees float32, yDegrees float32, zDegrees float32)
 	return [4]float32{float32(result[0]), float32(result[1]), float32(result[2]), float32(result[3])}
 }
 
+// unityQuaternionEuler 按 Unity 的 ZXY 欧拉角应用顺序将四元数分解为角度，是 unityEulerQuaternion 的逆运算
+// unityQuaternionEuler decomposes a quaternion into degrees using Unity's ZXY Euler-angle application order and inverts unityEulerQuaternion
+func unityQuaternionEuler(quaternion [4]float32) [3]float32 {
+	x, y, z, w := float64(quaternion[0]), float64(quaternion[1]), float64(quaternion[2]), float64(quaternion[3])
+	lengthSquared := x*x + y*y + z*z + w*w
+	if lengthSquared <= 0 {
+		return [3]float32{}
+	}
+	scale := 2 / lengthSquared
+	m12 := scale * (y*z - x*w)
+	sinX := math.Max(-1, math.Min(1, -m12))
+	const radiansToDegrees = 180 / math.Pi
+	var ex, ey, ez float64
+	ex = math.Asin(sinX)
+	if math.Abs(sinX) < 0.9999999 {
+		ey = math.Atan2(scale*(x*z+y*w), 1-scale*(x*x+y*y))
+		ez = math.Atan2(scale*(x*y+z*w), 1-scale*(x*x+z*z))
+	} else {
+		// 万向锁时 Y 与 Z 共轴，把全部偏航放到 Y 并令 Z 为零
+		// At gimbal lock Y and Z share an axis, so all yaw goes to Y and Z is zero
+		ey = math.Atan2(-scale*(x*z-y*w), 1-scale*(y*y+z*z))
+	}
+	return [3]float32{float32(ex * radiansToDegrees), float32(ey * radiansToDegrees), float32(ez * radiansToDegrees)}
+}
+
+// nearestUnityEuler 在等价欧拉角组合中选择最接近上一关键帧的一组，避免曲线在 ±180 度处跳变
+// nearestUnityEuler picks the equivalent Euler-angle triple closest to the previous keyframe so the curve does not jump at ±180 degrees
+func nearestUnityEuler(angles [3]float32, previous [3]float32) [3]float32 {
+	unwrap := func(candidate [3]float64) ([3]float32, float64) {
+		var result [3]float32
+		distance := 0.0
+		for axis := range candidate {
+			value := candidate[axis] + 360*math.Round((float64(previous[axis])-candidate[axis])/360)
+			result[axis] = float32(value)
+			distance += math.Abs(value - float64(previous[axis]))
+		}
+		return result, distance
+	}
+	direct, directDistance := unwrap([3]float64{float64(angles[0]), float64(angles[1]), float64(angles[2])})
+	flipped, flippedDistance := unwrap([3]float64{180 - float64(angles[0]), float64(angles[1]) + 180, float64(angles[2]) + 180})
+	if flippedDistance < directDistance {
+		return flipped
+	}
+	return direct
+}
+
 // multiplyQuaternion 将两个 XYZW 四元数相乘
 // multiplyQuaternion multiplies two XYZW quaternions
 func multiplyQuaternion(left [4]float64, right [4]float64) [4]float64 {
@@ -0,0 +1,337 @@
+package KCES
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// KCESAnimationClipExtrasKey 是 glTF 动画 extras 中承载 AnimationClip 剪辑设置的键名
+// KCESAnimationClipExtrasKey is the key in glTF animation extras that carries AnimationClip clip settings
+const KCESAnimationClipExtrasKey = "kcesAnimationClip"
+
+// KCESTransformPathExtrasKey 是 glTF 节点 extras 中记录 Unity Transform 路径的键名
+// KCESTransformPathExtrasKey is the key in glTF node extras that records the Unity Transform path
+const KCESTransformPathExtrasKey = "kcesTransformPath"
+
+// kcesAnimationClipExtras 保存 glTF 动画无法表达的 AnimationClip 剪辑设置 / kcesAnimationClipExtras stores AnimationClip clip settings that glTF animations cannot express
+type kcesAnimationClipExtras struct {
+	SampleRate float32 `json:"sampleRate,omitempty"` // Unity m_SampleRate / Unity m_SampleRate
+	WrapMode   int32   `json:"wrapMode,omitempty"`   // Unity m_WrapMode / Unity m_WrapMode
+}
+
+// AnimationClipImportOptions 描述 glTF 转 AnimationClip 时的剪辑设置 / AnimationClipImportOptions describes clip settings for glTF-to-AnimationClip conversion
+type AnimationClipImportOptions struct {
+	Animation  string  // 要转换的 glTF 动画名，为空时取第一个动画 / glTF animation name to convert; empty selects the first animation
+	SampleRate float32 // 采样率，为零时取 extras 中的值或 Unity 默认的 60 / Sample rate; zero uses the extras value or Unity's default of 60
+	Loop       bool    // 是否强制写入 Loop 循环方式，否则取 extras 中的值 / Whether to force the Loop wrap mode; otherwise the extras value is used
+	Euler      bool    // 是否将旋转写成 Unity ZXY 欧拉角曲线而非四元数曲线 / Whether to write rotations as Unity ZXY Euler-angle curves instead of quaternion curves
+}
+
+// ConvertGLTFToAnimationClip 将 glTF 或 GLB 中的一个动画转换为携带 Unity 2022.3 TypeTree 的独立旧版 AnimationClip 主文件
+// 节点的 Transform 路径优先取 kcesTransformPath extras，否则按场景根节点向下拼接节点名称；变形权重通道被忽略
+// ConvertGLTFToAnimationClip converts one animation in a glTF or GLB into a standalone legacy AnimationClip primary file carrying a Unity 2022.3 TypeTree
+// A node's Transform path prefers its kcesTransformPath extras and otherwise joins node names downward from the scene root; morph-weight channels are ignored
+func (s *NativeUnityMediaService) ConvertGLTFToAnimationClip(ctx context.Context, inputPath string, outputPath string, opts AnimationClipImportOptions, maxOutputBytes int64) error {
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	document, err := gltf.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("open glTF %q: %w", inputPath, err)
+	}
+	fallbackName := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	clip, err := decodeGLTFAnimationClip(document, opts, fallbackName)
+	if err != nil {
+		return fmt.Errorf("convert glTF %q to AnimationClip: %w", inputPath, err)
+	}
+	object, err := aba.NewNativeAnimationClipObject(clip)
+	if err != nil {
+		return fmt.Errorf("build native AnimationClip for %q: %w", inputPath, err)
+	}
+	data, err := aba.WriteAnimationClip(object)
+	if err != nil {
+		return fmt.Errorf("encode native AnimationClip for %q: %w", inputPath, err)
+	}
+	return writeNativeUnityGLTFOutput(ctx, outputPath, data, maxOutputBytes)
+}
+
+// decodeGLTFAnimationClip 将选中的 glTF 动画通道还原为左手坐标的 Unity TRS 曲线
+// decodeGLTFAnimationClip restores the selected glTF animation's channels as left-handed Unity TRS curves
+func decodeGLTFAnimationClip(document *gltf.Document, opts AnimationClipImportOptions, fallbackName string) (*aba.AnimationClipCurves, error) {
+	var animation *gltf.Animation
+	for _, candidate := range document.Animations {
+		if candidate != nil && (opts.Animation == "" || candidate.Name == opts.Animation) {
+			animation = candidate
+			break
+		}
+	}
+	if animation == nil {
+		if opts.Animation != "" {
+			return nil, fmt.Errorf("document has no animation named %q", opts.Animation)
+		}
+		return nil, fmt.Errorf("document has no animation")
+	}
+	extras, err := parseKCESAnimationClipExtras(animation)
+	if err != nil {
+		return nil, err
+	}
+	clip := &aba.AnimationClipCurves{Name: animation.Name, SampleRate: extras.SampleRate, WrapMode: extras.WrapMode}
+	if clip.Name == "" {
+		clip.Name = fallbackName
+	}
+	if opts.SampleRate > 0 {
+		clip.SampleRate = opts.SampleRate
+	}
+	if opts.Loop {
+		clip.WrapMode = aba.AnimationWrapModeLoop
+	}
+
+	order, parents, err := collectGLTFSceneNodes(document)
+	if err != nil {
+		return nil, err
+	}
+	inScene := make(map[int]bool, len(order))
+	for _, nodeIndex := range order {
+		inScene[nodeIndex] = true
+	}
+	for channelIndex, channel := range animation.Channels {
+		if channel == nil || channel.Target.Path == gltf.TRSWeights {
+			continue
+		}
+		if channel.Target.Node == nil {
+			return nil, fmt.Errorf("channel %d has no target node", channelIndex)
+		}
+		transformPath, err := gltfAnimationTransformPath(document, parents, inScene, *channel.Target.Node)
+		if err != nil {
+			return nil, fmt.Errorf("channel %d: %w", channelIndex, err)
+		}
+		if channel.Sampler < 0 || channel.Sampler >= len(animation.Samplers) || animation.Samplers[channel.Sampler] == nil {
+			return nil, fmt.Errorf("channel %d sampler %d out of range", channelIndex, channel.Sampler)
+		}
+		curve, err := readGLTFAnimationClipCurve(document, animation.Samplers[channel.Sampler], channel.Target.Path, opts.Euler)
+		if err != nil {
+			return nil, fmt.Errorf("channel %d targeting %q: %w", channelIndex, transformPath, err)
+		}
+		curve.Path = transformPath
+		clip.Curves = append(clip.Curves, *curve)
+	}
+	if len(clip.Curves) == 0 {
+		return nil, fmt.Errorf("animation %q has no translation, rotation, or scale channels", animation.Name)
+	}
+	return clip, nil
+}
+
+// parseKCESAnimationClipExtras 读取动画 extras 中的 kcesAnimationClip 剪辑设置，缺失时返回零值
+// parseKCESAnimationClipExtras reads the kcesAnimationClip clip settings from animation extras and returns zero values when absent
+func parseKCESAnimationClipExtras(animation *gltf.Animation) (kcesAnimationClipExtras, error) {
+	var extras kcesAnimationClipExtras
+	extrasMap, ok := animation.Extras.(map[string]interface{})
+	if !ok {
+		return extras, nil
+	}
+	raw, ok := extrasMap[KCESAnimationClipExtrasKey]
+	if !ok {
+		return extras, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return extras, fmt.Errorf("encode %s extras: %w", KCESAnimationClipExtrasKey, err)
+	}
+	if err := json.Unmarshal(encoded, &extras); err != nil {
+		return extras, fmt.Errorf("parse %s extras: %w", KCESAnimationClipExtrasKey, err)
+	}
+	return extras, nil
+}
+
+// gltfAnimationTransformPath 返回节点 extras 记录的 Transform 路径，否则从最近的带路径祖先或场景根节点拼接节点名称
+// gltfAnimationTransformPath returns the Transform path recorded in node extras, or otherwise joins node names from the nearest ancestor with a path or the scene root
+func gltfAnimationTransformPath(document *gltf.Document, parents map[int]int, inScene map[int]bool, nodeIndex int) (string, error) {
+	if !inScene[nodeIndex] {
+		return "", fmt.Errorf("target node %d is not part of the default scene", nodeIndex)
+	}
+	var names []string
+	prefix := ""
+	for current := nodeIndex; ; {
+		node := document.Nodes[current]
+		if extrasMap, ok := node.Extras.(map[string]interface{}); ok {
+			if recorded, ok := extrasMap[KCESTransformPathExtrasKey].(string); ok {
+				prefix = strings.Trim(strings.ReplaceAll(recorded, "\\", "/"), "/")
+				break
+			}
+		}
+		if node.Name == "" || strings.ContainsAny(node.Name, "/\x00") {
+			return "", fmt.Errorf("node %d has name %q, which cannot form a Transform path", current, node.Name)
+		}
+		names = append(names, node.Name)
+		parent, ok := parents[current]
+		if !ok {
+			break
+		}
+		current = parent
+	}
+	parts := make([]string, 0, len(names)+1)
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	for nameIndex := len(names) - 1; nameIndex >= 0; nameIndex-- {
+		parts = append(parts, names[nameIndex])
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// readGLTFAnimationClipCurve 读取一个采样器并转换为 Unity 左手坐标的 TRS 曲线
+// CUBICSPLINE 切线直接成为关键帧斜率，LINEAR 取相邻段斜率，STEP 写成无穷斜率；欧拉角模式按转换后的角度重新计算线段斜率
+// readGLTFAnimationClipCurve reads one sampler and converts it into a TRS curve in Unity's left-handed coordinates
+// CUBICSPLINE tangents become keyframe slopes directly, LINEAR takes adjacent segment slopes, and STEP writes infinite slopes; Euler mode recomputes segment slopes from the converted angles
+func readGLTFAnimationClipCurve(document *gltf.Document, sampler *gltf.AnimationSampler, target gltf.TRSProperty, euler bool) (*aba.AnimationTRSCurve, error) {
+	if sampler.Input < 0 || sampler.Input >= len(document.Accessors) || sampler.Output < 0 || sampler.Output >= len(document.Accessors) {
+		return nil, fmt.Errorf("sampler accessor out of range")
+	}
+	rawTimes, err := modeler.ReadAccessor(document, document.Accessors[sampler.Input], nil)
+	if err != nil {
+		return nil, fmt.Errorf("read sampler input: %w", err)
+	}
+	times, ok := rawTimes.([]float32)
+	if !ok {
+		return nil, fmt.Errorf("sampler input type %T is unsupported", rawTimes)
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("sampler has no keyframes")
+	}
+	rawOutput, err := modeler.ReadAccessor(document, document.Accessors[sampler.Output], nil)
+	if err != nil {
+		return nil, fmt.Errorf("read sampler output: %w", err)
+	}
+
+	curve := &aba.AnimationTRSCurve{Dimension: 3}
+	var output [][4]float32
+	switch values := rawOutput.(type) {
+	case [][3]float32:
+		if target == gltf.TRSRotation {
+			return nil, fmt.Errorf("rotation sampler output has three components")
+		}
+		for _, value := range values {
+			output = append(output, [4]float32{value[0], value[1], value[2]})
+		}
+	case [][4]float32:
+		if target != gltf.TRSRotation {
+			return nil, fmt.Errorf("%s sampler output has four components", target)
+		}
+		output = values
+		curve.Dimension = 4
+	default:
+		return nil, fmt.Errorf("sampler output type %T is unsupported", rawOutput)
+	}
+	switch target {
+	case gltf.TRSTranslation:
+		curve.Property = aba.AnimationCurveTranslation
+	case gltf.TRSScale:
+		curve.Property = aba.AnimationCurveScale
+	case gltf.TRSRotation:
+		curve.Property = aba.AnimationCurveRotation
+	default:
+		return nil, fmt.Errorf("target path %s is unsupported", target)
+	}
+
+	// glTF 右手坐标通过 X 镜像变回 Unity 左手坐标，切线与数值使用相同映射
+	// Right-handed glTF coordinates return to Unity's left-handed space through the X mirror, and tangents use the same mapping as values
+	mirror := func(value [4]float32) [4]float32 {
+		switch target {
+		case gltf.TRSTranslation:
+			value[0] = -value[0]
+		case gltf.TRSRotation:
+			value[1], value[2] = -value[1], -value[2]
+		}
+		return value
+	}
+	keyCount := len(times)
+	curve.Keyframes = make([]aba.AnimationTRSKeyframe, keyCount)
+	switch sampler.Interpolation {
+	case gltf.InterpolationCubicSpline:
+		if len(output) != 3*keyCount {
+			return nil, fmt.Errorf("CUBICSPLINE sampler has %d outputs for %d keyframes", len(output), keyCount)
+		}
+		for keyIndex, time := range times {
+			curve.Keyframes[keyIndex] = aba.AnimationTRSKeyframe{
+				Time:     time,
+				InSlope:  mirror(output[3*keyIndex]),
+				Value:    mirror(output[3*keyIndex+1]),
+				OutSlope: mirror(output[3*keyIndex+2]),
+			}
+		}
+	case gltf.InterpolationLinear, gltf.InterpolationStep:
+		if len(output) != keyCount {
+			return nil, fmt.Errorf("sampler has %d outputs for %d keyframes", len(output), keyCount)
+		}
+		for keyIndex, time := range times {
+			value := mirror(output[keyIndex])
+			// glTF 以最短路径球面插值旋转，而 Unity 逐分量插值，因此相邻四元数保持在同一半球
+			// glTF slerps rotations along the shortest path while Unity interpolates per component, so adjacent quaternions stay in the same hemisphere
+			if target == gltf.TRSRotation && keyIndex != 0 && quaternionDot(curve.Keyframes[keyIndex-1].Value, value) < 0 {
+				for componentIndex := range value {
+					value[componentIndex] = -value[componentIndex]
+				}
+			}
+			curve.Keyframes[keyIndex] = aba.AnimationTRSKeyframe{Time: time, Value: value}
+		}
+		setGLTFAnimationClipSegmentSlopes(curve, sampler.Interpolation == gltf.InterpolationStep)
+	default:
+		return nil, fmt.Errorf("sampler interpolation %v is unsupported", sampler.Interpolation)
+	}
+
+	if euler && target == gltf.TRSRotation {
+		curve.Property = aba.AnimationCurveEuler
+		curve.Dimension = 3
+		var previous [3]float32
+		for keyIndex := range curve.Keyframes {
+			angles := unityQuaternionEuler(curve.Keyframes[keyIndex].Value)
+			if keyIndex != 0 {
+				angles = nearestUnityEuler(angles, previous)
+			}
+			curve.Keyframes[keyIndex].Value = [4]float32{angles[0], angles[1], angles[2]}
+			previous = angles
+		}
+		setGLTFAnimationClipSegmentSlopes(curve, sampler.Interpolation == gltf.InterpolationStep)
+	}
+	return curve, nil
+}
+
+// setGLTFAnimationClipSegmentSlopes 按相邻线段斜率设置关键帧切线，stepped 为真时写入表示阶跃的无穷斜率
+// setGLTFAnimationClipSegmentSlopes sets keyframe tangents from adjacent segment slopes, writing infinite step slopes when stepped is true
+func setGLTFAnimationClipSegmentSlopes(curve *aba.AnimationTRSCurve, stepped bool) {
+	keys := curve.Keyframes
+	slope := func(start int, componentIndex uint8) float32 {
+		return (keys[start+1].Value[componentIndex] - keys[start].Value[componentIndex]) / (keys[start+1].Time - keys[start].Time)
+	}
+	for keyIndex := range keys {
+		keys[keyIndex].InSlope = [4]float32{}
+		keys[keyIndex].OutSlope = [4]float32{}
+		for componentIndex := uint8(0); componentIndex < curve.Dimension; componentIndex++ {
+			switch {
+			case stepped:
+				keys[keyIndex].InSlope[componentIndex] = float32(math.Inf(1))
+				keys[keyIndex].OutSlope[componentIndex] = float32(math.Inf(1))
+			case len(keys) == 1:
+			case keyIndex == 0:
+				keys[keyIndex].InSlope[componentIndex] = slope(0, componentIndex)
+				keys[keyIndex].OutSlope[componentIndex] = slope(0, componentIndex)
+			case keyIndex == len(keys)-1:
+				keys[keyIndex].InSlope[componentIndex] = slope(keyIndex-1, componentIndex)
+				keys[keyIndex].OutSlope[componentIndex] = slope(keyIndex-1, componentIndex)
+			default:
+				keys[keyIndex].InSlope[componentIndex] = slope(keyIndex-1, componentIndex)
+				keys[keyIndex].OutSlope[componentIndex] = slope(keyIndex, componentIndex)
+			}
+		}
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"bytes"
 	"context"
 	"image/png"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
-	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
 )
 
 func TestEncodeMeshGLB(t *testing.T) {
@@ -64,9 +65,6 @@ func TestNativeUnityMediaServiceExportsSampleMeshAndSprite(t *testing.T) {
 	}
 
 	t.Run("Sprite", func(t *testing.T) {
-		if err := tools.CheckMagick(); err != nil {
-			t.Skipf("ImageMagick unavailable: %v", err)
-		}
 		spriteFiles, err := filepath.Glob(filepath.Join(root, "Sprite", "*.sprite"))
 		if err != nil || len(spriteFiles) == 0 {
 			t.Fatalf("find sample Sprite: %v", err)
@@ -90,9 +88,6 @@ func TestNativeUnityMediaServiceExportsSampleMeshAndSprite(t *testing.T) {
 }
 
 func TestNativeUnityMediaServiceExportsSpriteViaSiblingAtlas(t *testing.T) {
-	if err := tools.CheckMagick(); err != nil {
-		t.Skipf("ImageMagick unavailable: %v", err)
-	}
 	sample := filepath.Join("..", "..", "testdata", "KCES", "parts_personal_om015_gp003.aba")
 	if _, err := os.Stat(sample); err != nil {
 		t.Skipf("sample not available: %v", err)
@@ -199,3 +194,199 @@ func TestNativeUnityMediaServiceExtractsSampleAudio(t *testing.T) {
 		t.Fatalf("extracted AudioClip has invalid payload for %s", extension)
 	}
 }
+
+func TestNativeUnityMediaServiceAnimationClipGLTFRoundTrip(t *testing.T) {
+	source := &aba.AnimationClipCurves{
+		Name:       "custom_motion",
+		SampleRate: 30,
+		WrapMode:   aba.AnimationWrapModeLoop,
+		Curves: []aba.AnimationTRSCurve{
+			{Path: "", Property: aba.AnimationCurveTranslation, Dimension: 3, Keyframes: []aba.AnimationTRSKeyframe{
+				{Time: 0, Value: [4]float32{0.25, 0, 0}},
+				{Time: 1, Value: [4]float32{0.25, 0, 1}},
+			}},
+			{Path: "Bip01/Bip01 Spine", Property: aba.AnimationCurveRotation, Dimension: 4, Keyframes: []aba.AnimationTRSKeyframe{
+				{Time: 0, Value: [4]float32{0, 0, 0, 1}},
+				{Time: 0.5, Value: [4]float32{0.2, -0.3, 0.1, 0.92736185}},
+				{Time: 1, Value: [4]float32{0, 0.7071068, 0, 0.7071068}},
+			}},
+		},
+	}
+	object, err := aba.NewNativeAnimationClipObject(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := aba.WriteAnimationClip(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	clipPath := filepath.Join(dir, "custom_motion.anm")
+	if err := os.WriteFile(clipPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	service := &NativeUnityMediaService{}
+	gltfPath := filepath.Join(dir, "custom_motion.glb")
+	if err := service.ConvertAnimationClipToGLTF(context.Background(), clipPath, gltfPath, "glb", TestConversionMaxOutput); err != nil {
+		t.Fatal(err)
+	}
+	backPath := filepath.Join(dir, "back.anm")
+	if err := service.ConvertGLTFToAnimationClip(context.Background(), gltfPath, backPath, AnimationClipImportOptions{}, TestConversionMaxOutput); err != nil {
+		t.Fatal(err)
+	}
+	backData, err := os.ReadFile(backPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backObject, err := aba.ReadAnimationClip(backData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := backObject.DecodeAnimationClipCurves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Name != source.Name || back.SampleRate != source.SampleRate || back.WrapMode != source.WrapMode || len(back.Curves) != len(source.Curves) {
+		t.Fatalf("round-tripped clip = %q %g %d with %d curves, want %q %g %d with %d curves", back.Name, back.SampleRate, back.WrapMode, len(back.Curves), source.Name, source.SampleRate, source.WrapMode, len(source.Curves))
+	}
+	byProperty := make(map[aba.AnimationCurveProperty]aba.AnimationTRSCurve)
+	for _, curve := range back.Curves {
+		byProperty[curve.Property] = curve
+	}
+	for curveIndex, want := range source.Curves {
+		got := byProperty[want.Property]
+		if got.Path != want.Path || got.Property != want.Property || len(got.Keyframes) != len(want.Keyframes) {
+			t.Fatalf("curve %d = %q %d with %d keys, want %q %d with %d keys", curveIndex, got.Path, got.Property, len(got.Keyframes), want.Path, want.Property, len(want.Keyframes))
+		}
+		for keyIndex, keyframe := range want.Keyframes {
+			for componentIndex := uint8(0); componentIndex < want.Dimension; componentIndex++ {
+				if math.Abs(float64(got.Keyframes[keyIndex].Value[componentIndex]-keyframe.Value[componentIndex])) > 1e-6 {
+					t.Fatalf("curve %d keyframe %d = %v, want %v", curveIndex, keyIndex, got.Keyframes[keyIndex].Value, keyframe.Value)
+				}
+			}
+		}
+	}
+	translationKeys := byProperty[aba.AnimationCurveTranslation].Keyframes
+	if translationKeys[0].OutSlope != [4]float32{0, 0, 1} || translationKeys[1].InSlope != [4]float32{0, 0, 1} {
+		t.Fatalf("translation slopes = %v %v, want the LINEAR segment slope", translationKeys[0].OutSlope, translationKeys[1].InSlope)
+	}
+}
+
+func TestNativeUnityMediaServiceImportsGLTFAnimationClipOptions(t *testing.T) {
+	document := gltf.NewDocument()
+	document.Nodes = []*gltf.Node{
+		{Name: "Bip01", Children: []int{1}},
+		{Name: "Bip01 Spine"},
+	}
+	document.Scenes[0].Nodes = []int{0}
+	input := modeler.WriteAccessor(document, gltf.TargetNone, []float32{0, 0.5})
+	translation := modeler.WriteAccessor(document, gltf.TargetNone, [][3]float32{{1, 0, 0}, {2, 0, 0}})
+	rotation := modeler.WriteAccessor(document, gltf.TargetNone, [][4]float32{{0, 0, 0, 1}, {0, -0.7071068, 0, 0.7071068}})
+	document.Animations = []*gltf.Animation{
+		{Name: "Idle"},
+		{
+			Name: "Action",
+			Samplers: []*gltf.AnimationSampler{
+				{Input: input, Output: translation, Interpolation: gltf.InterpolationStep},
+				{Input: input, Output: rotation, Interpolation: gltf.InterpolationLinear},
+			},
+			Channels: []*gltf.AnimationChannel{
+				{Sampler: 0, Target: gltf.AnimationChannelTarget{Node: gltf.Index(1), Path: gltf.TRSTranslation}},
+				{Sampler: 1, Target: gltf.AnimationChannelTarget{Node: gltf.Index(1), Path: gltf.TRSRotation}},
+			},
+		},
+	}
+	dir := t.TempDir()
+	gltfPath := filepath.Join(dir, "action.glb")
+	if err := gltf.SaveBinary(document, gltfPath); err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(dir, "action.anm")
+	opts := AnimationClipImportOptions{Animation: "Action", SampleRate: 24, Loop: true, Euler: true}
+	if err := (&NativeUnityMediaService{}).ConvertGLTFToAnimationClip(context.Background(), gltfPath, outputPath, opts, TestConversionMaxOutput); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := aba.ReadAnimationClip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clip, err := object.DecodeAnimationClipCurves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clip.Name != "Action" || clip.SampleRate != 24 || clip.WrapMode != aba.AnimationWrapModeLoop || len(clip.Curves) != 2 {
+		t.Fatalf("clip = %q %g %d with %d curves, want Action at 24 fps looping with two curves", clip.Name, clip.SampleRate, clip.WrapMode, len(clip.Curves))
+	}
+	position, euler := clip.Curves[0], clip.Curves[1]
+	if position.Path != "Bip01/Bip01 Spine" || position.Keyframes[1].Value != [4]float32{-2, 0, 0} || !math.IsInf(float64(position.Keyframes[0].OutSlope[0]), 1) {
+		t.Fatalf("position curve = %+v, want mirrored stepped keys on Bip01/Bip01 Spine", position)
+	}
+	if euler.Property != aba.AnimationCurveEuler || math.Abs(float64(euler.Keyframes[1].Value[1]-90)) > 1e-3 {
+		t.Fatalf("rotation curve = %+v, want a 90 degree Unity Y Euler key", euler)
+	}
+}
+
+func TestUnityQuaternionEulerInvertsUnityEulerQuaternion(t *testing.T) {
+	for _, angles := range [][3]float32{{0, 0, 0}, {30, 45, 60}, {-80, 170, -120}, {90, 20, 0}, {-90, -45, 0}, {10, -179, 179}} {
+		quaternion := unityEulerQuaternion(angles[0], angles[1], angles[2])
+		recovered := unityQuaternionEuler(quaternion)
+		again := unityEulerQuaternion(recovered[0], recovered[1], recovered[2])
+		if math.Abs(float64(quaternionDot(quaternion, again))) < 1-1e-5 {
+			t.Fatalf("angles %v decomposed to %v, which is a different rotation", angles, recovered)
+		}
+	}
+	if got := nearestUnityEuler([3]float32{0, 0, -170}, [3]float32{0, 0, 175}); got != [3]float32{0, 0, 190} {
+		t.Fatalf("nearestUnityEuler = %v, want an unwrapped 190 degree Z angle", got)
+	}
+}
+
+func TestPackServicePacksBuiltAnimationClip(t *testing.T) {
+	object, err := aba.NewNativeAnimationClipObject(&aba.AnimationClipCurves{
+		Name: "custom_motion",
+		Curves: []aba.AnimationTRSCurve{{Path: "Bip01", Property: aba.AnimationCurveScale, Dimension: 3, Keyframes: []aba.AnimationTRSKeyframe{
+			{Time: 0, Value: [4]float32{1, 1, 1}},
+			{Time: 2, Value: [4]float32{1, 2, 1}},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := aba.WriteAnimationClip(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := t.TempDir()
+	input := filepath.Join(parent, "input")
+	if err := os.MkdirAll(filepath.Join(input, "AnimationClip"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(input, "AnimationClip", "custom_motion.anm"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&PackService{}).PackToAbaAndCt(input, "custom_motion"); err != nil {
+		t.Fatal(err)
+	}
+	unpacked := filepath.Join(parent, "unpacked")
+	if err := (&AbaService{}).UnpackAba(filepath.Join(parent, "custom_motion.aba"), unpacked); err != nil {
+		t.Fatal(err)
+	}
+	unpackedData, err := os.ReadFile(filepath.Join(unpacked, "AnimationClip", "custom_motion.anm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reread, err := aba.ReadAnimationClip(unpackedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clip, err := reread.DecodeAnimationClipCurves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clip.Name != "custom_motion" || len(clip.Curves) != 1 || clip.Curves[0].Keyframes[1].Value != [4]float32{1, 2, 1} {
+		t.Fatalf("unpacked clip = %+v", clip)
+	}
+}
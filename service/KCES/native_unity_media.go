@@ -1,13 +1,16 @@
 package KCES
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/common/bcn"
 )
 
 // NativeUnityMediaService 提供独立 Unity 媒体对象到通用查看格式的转换 / NativeUnityMediaService converts standalone Unity media objects to common viewing formats
@@ -43,9 +46,21 @@ func IsKCESNativeAudioClipFile(path string) bool {
 	return isKCESNativeUnityClassFile(path, aba.ClassIDAudioClip)
 }
 
+// Texture2DImageOptions 控制独立 Texture2D 的图像导出 / Texture2DImageOptions controls standalone Texture2D image export
+type Texture2DImageOptions struct {
+	Format        string // 输出格式 png 或 dds，为空时按输出扩展名推断 / Output format png or dds, inferred from the output extension when empty
+	HalfFloatBC6H bool   // DDS 输出时将 BC6H 解码为正立的半精度浮点 RGBA，而不是透传原始块 / For DDS output, decode BC6H into upright half-float RGBA instead of passing the raw blocks through
+}
+
 // ConvertTexture2DToImage 将独立 Texture2D 主文件导出为 PNG 或 DDS，不修改主文件
 // ConvertTexture2DToImage exports a standalone Texture2D primary file as PNG or DDS without modifying the primary file
 func (s *NativeUnityMediaService) ConvertTexture2DToImage(ctx context.Context, inputPath string, outputPath string, format string, maxOutputBytes int64) error {
+	return s.ConvertTexture2DToImageWithOptions(ctx, inputPath, outputPath, Texture2DImageOptions{Format: format}, maxOutputBytes)
+}
+
+// ConvertTexture2DToImageWithOptions 按导出选项将独立 Texture2D 主文件导出为 PNG 或 DDS，PNG 由纯 Go 解码器生成且 BC6H 经色调映射
+// ConvertTexture2DToImageWithOptions exports a standalone Texture2D primary file as PNG or DDS using export options; PNG comes from the pure-Go decoders with BC6H tone-mapped
+func (s *NativeUnityMediaService) ConvertTexture2DToImageWithOptions(ctx context.Context, inputPath string, outputPath string, opts Texture2DImageOptions, maxOutputBytes int64) error {
 	if ctx != nil {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -63,19 +78,21 @@ func (s *NativeUnityMediaService) ConvertTexture2DToImage(ctx context.Context, i
 	if err != nil {
 		return err
 	}
-	texture, err := af.GetTexture2DDataRange(info, nil)
+	texture, err := af.GetTexture2DDataRange(info, texture2DCompanionResolver(inputPath))
 	if err != nil {
 		return fmt.Errorf("decode native Texture2D %q: %w", inputPath, err)
 	}
-	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	format := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(opts.Format), "."))
 	if format == "" {
 		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
 	}
 	var output []byte
-	switch format {
-	case "png":
+	switch {
+	case format == "png":
 		output, err = aba.TexturePNGBytes(texture)
-	case "dds":
+	case format == "dds" && opts.HalfFloatBC6H && texture.TextureFormat == aba.TextureFormatBC6H:
+		output, err = aba.TextureHalfFloatDDSBytes(texture)
+	case format == "dds":
 		output, err = aba.TextureDDSBytes(texture)
 	default:
 		return fmt.Errorf("native Texture2D output format %q is unsupported; use png or dds", format)
@@ -86,24 +103,65 @@ func (s *NativeUnityMediaService) ConvertTexture2DToImage(ctx context.Context, i
 	return writeNativeUnityMediaOutput(ctx, outputPath, output, maxOutputBytes)
 }
 
+// Texture2DEncodeOptions 控制图像重建为独立 Texture2D 时的编码 / Texture2DEncodeOptions controls encoding when rebuilding an image into a standalone Texture2D
+type Texture2DEncodeOptions struct {
+	Format    string // 目标 TextureFormat：RGBA32、RGB24、Alpha8、DXT1、DXT5、BC4、BC5 或 BC7，为空时为 RGBA32 / Target TextureFormat: RGBA32, RGB24, Alpha8, DXT1, DXT5, BC4, BC5, or BC7, RGBA32 when empty
+	MipMaps   bool   // 是否生成完整 mip 链 / Whether to generate a full mip chain
+	MipFilter string // mip 滤波器 box、triangle 或 kaiser，为空时为 box / Mip filter box, triangle, or kaiser, box when empty
+	Linear    bool   // 是否作为线性数据写入，法线和遮罩应开启 / Whether to write linear data, which normal maps and masks should enable
+	Quality   string // 块压缩质量 fast、normal 或 best，为空时为 normal / Block-compression quality fast, normal, or best, normal when empty
+	Stream    bool   // 是否将图像数据写入 <输出>.resS 伴随文件，由 packAba 放入 AssetBundle 流条目 / Whether to write image data into an <output>.resS companion that packAba places in the AssetBundle stream entry
+}
+
 // ConvertImageToTexture2D 将 PNG 或 JPEG 图像重建为内联 RGBA32 单 mip 的独立 Texture2D 主文件，资源名按输出文件名推断并与纯目录打包规则一致
 // ConvertImageToTexture2D rebuilds a PNG or JPEG image into a standalone Texture2D primary file with inline single-mip RGBA32 data, and the resource name inferred from the output file name matches the pure-directory packing rules
 func (s *NativeUnityMediaService) ConvertImageToTexture2D(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
+	return s.ConvertImageToTexture2DWithOptions(ctx, inputPath, outputPath, Texture2DEncodeOptions{}, maxOutputBytes)
+}
+
+// ConvertImageToTexture2DWithOptions 按编码选项将 PNG 或 JPEG 图像重建为独立 Texture2D 主文件
+// 开启 Stream 时图像数据写入 <输出>.resS 伴随文件，否则删除上次流式输出遗留的伴随文件，以免打包时被误用；输出上限按两个文件的总大小计算
+// ConvertImageToTexture2DWithOptions rebuilds a PNG or JPEG image into a standalone Texture2D primary file using the encoding options
+// With Stream enabled the image data goes to an <output>.resS companion; otherwise a companion left by an earlier streamed conversion is removed so packing does not pick it up, and the output limit covers both files together
+func (s *NativeUnityMediaService) ConvertImageToTexture2DWithOptions(ctx context.Context, inputPath string, outputPath string, opts Texture2DEncodeOptions, maxOutputBytes int64) error {
 	if ctx != nil {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
 	}
+	format, err := aba.ParseTexture2DEncodeFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	filter, err := aba.ParseMipFilter(opts.MipFilter)
+	if err != nil {
+		return err
+	}
+	quality, err := bcn.ParseQuality(opts.Quality)
+	if err != nil {
+		return err
+	}
 	data, err := os.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("read %q: %w", inputPath, err)
 	}
-	width, height, rgba, err := decodeImageToRGBA32(data)
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("decode image %q: %w", inputPath, err)
 	}
+	companionPath := outputPath + ".resS"
+	encodeOptions := aba.Texture2DEncodeOptions{
+		Format:    format,
+		MipMaps:   opts.MipMaps,
+		MipFilter: filter,
+		Linear:    opts.Linear,
+		Quality:   quality,
+	}
+	if opts.Stream {
+		encodeOptions.StreamPath = filepath.Base(companionPath)
+	}
 	name := inferAssetNameForPack(filepath.Base(outputPath))
-	object, err := aba.NewNativeTexture2DObject(name, width, height, rgba)
+	object, streamData, err := aba.NewNativeTexture2DObjectWithOptions(name, img, encodeOptions)
 	if err != nil {
 		return fmt.Errorf("build native Texture2D %q: %w", name, err)
 	}
@@ -111,9 +169,39 @@ func (s *NativeUnityMediaService) ConvertImageToTexture2D(ctx context.Context, i
 	if err != nil {
 		return fmt.Errorf("encode native Texture2D %q: %w", name, err)
 	}
+	if !opts.Stream {
+		if err := os.Remove(companionPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove stale stream companion %q: %w", companionPath, err)
+		}
+		return writeNativeUnityMediaOutput(ctx, outputPath, output, maxOutputBytes)
+	}
+	if int64(len(streamData)) > maxOutputBytes-int64(len(output)) {
+		return fmt.Errorf("%w: native Texture2D and its stream companion need %d bytes but the limit is %d", ErrConversionOutputLimitExceeded, int64(len(output))+int64(len(streamData)), maxOutputBytes)
+	}
+	if err := writeNativeUnityMediaOutput(ctx, companionPath, streamData, maxOutputBytes); err != nil {
+		return err
+	}
 	return writeNativeUnityMediaOutput(ctx, outputPath, output, maxOutputBytes)
 }
 
+// texture2DCompanionResolver 在主文件所在目录中按名称解析 .resS 伴随文件的字节范围
+// texture2DCompanionResolver resolves byte ranges of .resS companions by name in the directory of the primary file
+func texture2DCompanionResolver(primaryPath string) aba.AbaFileRangeResolver {
+	directory := filepath.Dir(primaryPath)
+	return func(name string, offset int64, size int64) ([]byte, error) {
+		f, err := os.Open(filepath.Join(directory, filepath.Base(name)))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		data := make([]byte, size)
+		if _, err := f.ReadAt(data, offset); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
 // ExtractAudioClip 将独立 AudioClip 中已内联的原始音频载荷写出，不进行有损转码
 // ExtractAudioClip writes the inline encoded payload from a standalone AudioClip without lossy transcoding
 func (s *NativeUnityMediaService) ExtractAudioClip(ctx context.Context, inputPath string, outputPath string, maxOutputBytes int64) error {
@@ -11,7 +11,6 @@ import (
 	"testing"
 
 	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/aba"
-	"github.com/MeidoPromotionAssociation/MeidoSerialization/tools"
 )
 
 func writeTestPNG(t *testing.T, path string, width int32, height int32) []byte {
@@ -178,9 +177,6 @@ func TestPackDirectoryRecognizesNativeTexture2DOutsideTypeDirectory(t *testing.T
 }
 
 func TestConvertImageToTexture2DAndBackPreservesOrientation(t *testing.T) {
-	if err := tools.CheckMagick(); err != nil {
-		t.Skipf("ImageMagick unavailable: %v", err)
-	}
 	tmpDir := t.TempDir()
 	sourcePath := filepath.Join(tmpDir, "orientation.png")
 	source := image.NewNRGBA(image.Rect(0, 0, 2, 2))
@@ -227,3 +223,166 @@ func TestConvertImageToTexture2DAndBackPreservesOrientation(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertImageToTexture2DWithOptionsStreamsCompressedMips(t *testing.T) {
+	tmpDir := t.TempDir()
+	pngPath := filepath.Join(tmpDir, "normal.png")
+	texPath := filepath.Join(tmpDir, "normal.tex")
+	writeTestPNG(t, pngPath, 8, 4)
+
+	service := &NativeUnityMediaService{}
+	opts := Texture2DEncodeOptions{Format: "BC7", MipMaps: true, MipFilter: "kaiser", Linear: true, Stream: true}
+	if err := service.ConvertImageToTexture2DWithOptions(context.Background(), pngPath, texPath, opts, 1<<20); err != nil {
+		t.Fatalf("ConvertImageToTexture2DWithOptions: %v", err)
+	}
+	companion, err := os.ReadFile(texPath + ".resS")
+	if err != nil {
+		t.Fatalf("read stream companion: %v", err)
+	}
+	data, err := os.ReadFile(texPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := aba.ReadTexture2D(data)
+	if err != nil {
+		t.Fatalf("ReadTexture2D: %v", err)
+	}
+	stream, err := object.Texture2DStreamData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.Path != "normal.tex.resS" || stream.Size != uint64(len(companion)) {
+		t.Fatalf("stream = %+v, want the whole %d-byte normal.tex.resS companion", stream, len(companion))
+	}
+	af, info, err := object.AssetsFileView()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex, err := af.GetTexture2DDataRange(info, texture2DCompanionResolver(texPath))
+	if err != nil {
+		t.Fatalf("GetTexture2DDataRange: %v", err)
+	}
+	if tex.TextureFormat != aba.TextureFormatBC7 || tex.MipCount != 4 {
+		t.Fatalf("format/mips = %d/%d, want BC7 with 4 levels", tex.TextureFormat, tex.MipCount)
+	}
+
+	if err := service.ConvertTexture2DToImage(context.Background(), texPath, filepath.Join(tmpDir, "normal_roundtrip.png"), "png", 1<<20); err != nil {
+		t.Fatalf("ConvertTexture2DToImage through companion: %v", err)
+	}
+
+	// 改回内联输出时必须删除旧伴随文件 / Switching back to inline output must remove the old companion
+	if err := service.ConvertImageToTexture2DWithOptions(context.Background(), pngPath, texPath, Texture2DEncodeOptions{Format: "DXT1"}, 1<<20); err != nil {
+		t.Fatalf("inline ConvertImageToTexture2DWithOptions: %v", err)
+	}
+	if _, err := os.Stat(texPath + ".resS"); !os.IsNotExist(err) {
+		t.Fatalf("stale companion still present: %v", err)
+	}
+}
+
+func TestConvertImageToTexture2DWithOptionsRejectsUnknownOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	pngPath := filepath.Join(tmpDir, "icon.png")
+	writeTestPNG(t, pngPath, 2, 2)
+	service := &NativeUnityMediaService{}
+	for _, opts := range []Texture2DEncodeOptions{{Format: "BC6H"}, {MipFilter: "lanczos"}, {Quality: "ultra"}} {
+		if err := service.ConvertImageToTexture2DWithOptions(context.Background(), pngPath, filepath.Join(tmpDir, "icon.tex"), opts, 1<<20); err == nil {
+			t.Fatalf("options %+v were accepted", opts)
+		}
+	}
+}
+
+func TestPackDirectoryMovesTexture2DStreamCompanionIntoBundle(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "stream_pack")
+	if err := os.MkdirAll(filepath.Join(contentDir, "Texture2D"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pngPath := filepath.Join(tmpDir, "source.png")
+	writeTestPNG(t, pngPath, 8, 8)
+	service := &NativeUnityMediaService{}
+	var companions [][]byte
+	for _, name := range []string{"first.tex", "second.tex"} {
+		texPath := filepath.Join(contentDir, "Texture2D", name)
+		opts := Texture2DEncodeOptions{Format: "DXT5", MipMaps: true, Stream: true}
+		if err := service.ConvertImageToTexture2DWithOptions(context.Background(), pngPath, texPath, opts, 1<<20); err != nil {
+			t.Fatalf("ConvertImageToTexture2DWithOptions: %v", err)
+		}
+		companion, err := os.ReadFile(texPath + ".resS")
+		if err != nil {
+			t.Fatal(err)
+		}
+		companions = append(companions, companion)
+	}
+
+	if err := (&PackService{}).PackToAbaAndCt(contentDir, "stream_pack"); err != nil {
+		t.Fatalf("PackToAbaAndCt: %v", err)
+	}
+	abaData, err := os.ReadFile(filepath.Join(tmpDir, "stream_pack.aba"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := aba.ReadAba(bytes.NewReader(abaData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := bundle.GetFileData(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	af, err := aba.ReadAssetsFile(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directories := bundle.BlockInfo.DirectoryInfos
+	if len(directories) != 2 || directories[1].Name != "CAB-stream_pack.resS" || directories[1].IsSerialized() {
+		t.Fatalf("bundle entries = %+v, want the serialized file and CAB-stream_pack.resS", directories)
+	}
+	resS, err := bundle.GetFileData(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := func(name string, offset int64, size int64) ([]byte, error) {
+		if name != "CAB-stream_pack.resS" {
+			t.Fatalf("resolver name = %q", name)
+		}
+		return resS[offset : offset+size], nil
+	}
+	textures := af.GetAssetsByType(aba.ClassIDTexture2D)
+	if len(textures) != 2 {
+		t.Fatalf("found %d Texture2D objects, want 2", len(textures))
+	}
+	for i := range textures {
+		tex, err := af.GetTexture2DDataRange(&textures[i], resolver)
+		if err != nil {
+			t.Fatalf("GetTexture2DDataRange: %v", err)
+		}
+		want := companions[0]
+		if tex.Name == "second.tex" {
+			want = companions[1]
+		}
+		if !bytes.Equal(tex.ImageData, want) {
+			t.Fatalf("%s stream data does not match its companion", tex.Name)
+		}
+	}
+}
+
+func TestPackDirectoryRejectsOrphanTexture2DStreamCompanion(t *testing.T) {
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, "orphan_pack")
+	if err := os.MkdirAll(contentDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pngPath := filepath.Join(tmpDir, "source.png")
+	writeTestPNG(t, pngPath, 4, 4)
+	texPath := filepath.Join(contentDir, "inline.tex")
+	if err := (&NativeUnityMediaService{}).ConvertImageToTexture2D(context.Background(), pngPath, texPath, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(texPath+".resS", []byte("stale stream"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&PackService{}).PackToAbaAndCt(contentDir, "orphan_pack"); err == nil {
+		t.Fatal("inline Texture2D with a leftover .resS companion was packed")
+	}
+	assertPackPairAbsent(t, tmpDir, "orphan_pack")
+}
@@ -19,12 +19,18 @@ type PackService struct{}
 // PackToAbaAndCt 扫描纯资源目录并在其父目录生成固定 Unity 2022.3.35f1 的 ABA 和 CT
 // PackToAbaAndCt scans a plain resource directory and emits fixed Unity 2022.3.35f1 ABA and CT files in its parent directory
 func (s *PackService) PackToAbaAndCt(dirPath string, outputBaseName string) error {
-	return s.packToAbaAndCt(dirPath, outputBaseName, true)
+	return s.packToAbaAndCt(dirPath, outputBaseName, modPackOptions{CompressAba: true})
 }
 
-// packToAbaAndCt 扫描纯资源目录，并允许包内测试选择是否压缩 ABA 数据块
-// packToAbaAndCt scans a plain resource directory and lets in-package tests choose whether ABA data blocks are compressed
-func (s *PackService) packToAbaAndCt(dirPath string, outputBaseName string, compressAba bool) error {
+// PackToAbaAndCtWithCompression 与 PackToAbaAndCt 相同，但以指定的压缩类型和级别写入 ABA 数据块，级别为 0 时使用默认级别
+// PackToAbaAndCtWithCompression is PackToAbaAndCt writing the ABA data blocks with the given compression kind and level, with level 0 selecting the default
+func (s *PackService) PackToAbaAndCtWithCompression(dirPath string, outputBaseName string, compression aba.AbaCompression, level int) error {
+	return s.packToAbaAndCt(dirPath, outputBaseName, modPackOptions{CompressAba: true, Compression: compression, CompressionLevel: level})
+}
+
+// packToAbaAndCt 扫描纯资源目录，并允许包内测试选择 ABA 数据块的压缩方式
+// packToAbaAndCt scans a plain resource directory and lets in-package tests choose how ABA data blocks are compressed
+func (s *PackService) packToAbaAndCt(dirPath string, outputBaseName string, options modPackOptions) error {
 	if outputBaseName == "" {
 		// 默认输出名剥掉 unpackAba 输出目录的 .aba_unpacked 后缀，因为游戏只从名为 <包名>.menuassets 的文件读取部件定义，包名带解包后缀会使 MOD 在游戏内不显示
 		// The default output name strips the .aba_unpacked suffix of unpackAba output directories, because the game reads parts definitions only from a file named <bundle name>.menuassets and a bundle name carrying the unpack suffix makes the MOD invisible in game
@@ -58,27 +64,7 @@ func (s *PackService) packToAbaAndCt(dirPath string, outputBaseName string, comp
 		if err != nil {
 			return fmt.Errorf("get relative path for %q: %w", filePath, err)
 		}
-		relPath = filepath.ToSlash(relPath)
-		name := inferAssetNameForPack(relPath)
-		kind := inferKindForPack(name, relPath)
-		nativeObjectFile := isNativeUnityObjectPackPath(relPath, kind)
-		// 独立 Unity 对象文件按文件头识别，因此 convert2texture2d 等工具的产物放在类型目录之外也不会被当作图像重新编码
-		// Standalone Unity object files are recognized by their header, so output from tools such as convert2texture2d is not re-encoded as an image when stored outside a type directory
-		if !nativeObjectFile && kind != "abaraw" {
-			if header, headerErr := readNativeUnityObjectFileHeader(filePath); headerErr == nil {
-				if detectedKind, ok := unityRawKindForClassID(header.ClassID); ok {
-					kind = detectedKind
-					nativeObjectFile = true
-				}
-			}
-		}
-		manifest.Assets = append(manifest.Assets, ModAsset{
-			Name:             name,
-			Path:             relPath,
-			Kind:             kind,
-			preserveRawData:  true,
-			nativeObjectFile: nativeObjectFile,
-		})
+		manifest.Assets = append(manifest.Assets, newPackModAsset(filePath, filepath.ToSlash(relPath), "", ""))
 		return nil
 	})
 	if err != nil {
@@ -90,9 +76,43 @@ func (s *PackService) packToAbaAndCt(dirPath string, outputBaseName string, comp
 	for _, warning := range packGameLoadWarnings(manifest, dirPath) {
 		fmt.Fprintln(os.Stderr, "warning: "+warning)
 	}
-	return packModManifestWithOptions(manifest, dirPath, filepath.Dir(dirPath), modPackOptions{
-		CompressAba: compressAba,
-	})
+	return packModManifestWithOptions(manifest, dirPath, filepath.Dir(dirPath), options)
+}
+
+// newPackModAsset 按纯目录打包规则补全资源的名称、类型和独立 Unity 对象标记，name 或 kind 非空时保留显式值
+// newPackModAsset completes an asset's name, kind, and standalone Unity object flag with the pure-directory packing rules, keeping name and kind when they are given explicitly
+func newPackModAsset(filePath string, relPath string, name string, kind string) ModAsset {
+	explicitKind := kind != ""
+	if name == "" {
+		name = inferAssetNameForPack(relPath)
+	}
+	if explicitKind {
+		kind = strings.ToLower(kind)
+	} else {
+		kind = inferKindForPack(name, relPath)
+	}
+	nativeObjectFile := isNativeUnityObjectPackPath(relPath, kind)
+	// 独立 Unity 对象文件按文件头识别，因此 convert2texture2d 等工具的产物放在类型目录之外也不会被当作图像重新编码
+	// Standalone Unity object files are recognized by their header, so output from tools such as convert2texture2d is not re-encoded as an image when stored outside a type directory
+	if !nativeObjectFile && kind != "abaraw" {
+		if header, headerErr := readNativeUnityObjectFileHeader(filePath); headerErr == nil {
+			if detectedKind, ok := unityRawKindForClassID(header.ClassID); ok {
+				if !explicitKind {
+					kind = detectedKind
+					nativeObjectFile = true
+				} else if _, raw := unityRawClassIDForKind(kind); raw {
+					nativeObjectFile = true
+				}
+			}
+		}
+	}
+	return ModAsset{
+		Name:             name,
+		Path:             relPath,
+		Kind:             kind,
+		preserveRawData:  true,
+		nativeObjectFile: nativeObjectFile,
+	}
 }
 
 // partsAssetsContainer 描述一种游戏按 <包名>.<扩展名> 读取的部件容器及其提示用词 / partsAssetsContainer describes one parts container the game reads as <bundle name>.<extension> together with its hint wording
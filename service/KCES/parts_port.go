@@ -0,0 +1,399 @@
+package KCES
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	serializationCOM3D2 "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	serializationKCES "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/ct"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+)
+
+// PartsPortMap 给出 COM3D2 命令关键字、材质属性名和着色器关键字到 KCES 枚举值的映射
+// 仓库中只有少数枚举值经过核实，其余映射需由调用方提供，未映射的内容会被丢弃并写入报告
+// PartsPortMap maps COM3D2 command keywords, material property names, and shader keywords to KCES enum values
+// Only a few enum values are verified in this repository, so callers supply the rest, and unmapped items are dropped and reported
+type PartsPortMap struct {
+	Commands   map[string]int32 `json:"commands"`   // 菜单命令关键字到 Command.Type / Menu command keyword to Command.Type
+	Properties map[string]int32 `json:"properties"` // 纹理、颜色、向量和浮点属性名到属性类型 / Texture, color, vector, and float property name to property type
+	Keywords   map[string]int32 `json:"keywords"`   // 着色器关键字到 KeywordProp.Type / Shader keyword to KeywordProp.Type
+}
+
+// DefaultPartsPortMap 返回只含已知材质属性类型的映射，命令和关键字没有默认值
+// DefaultPartsPortMap returns a map holding only the known material property types, with no default commands or keywords
+func DefaultPartsPortMap() *PartsPortMap {
+	return &PartsPortMap{
+		Commands: map[string]int32{},
+		Properties: map[string]int32{
+			"_MainTex":      0,
+			"_BumpMap":      1,
+			"_Color":        100,
+			"_ShadowColor":  101,
+			"_Shininess":    200,
+			"_OutlineWidth": 202,
+		},
+		Keywords: map[string]int32{},
+	}
+}
+
+// PartsPortReport 汇总 COM3D2 部件移植到 KCES 资源容器的输出和无法表达的内容 / PartsPortReport summarizes the outputs of a COM3D2 parts port to KCES asset containers and what it could not represent
+type PartsPortReport struct {
+	Outputs         []string `json:"outputs"`         // 写出的容器路径 / Container paths written
+	Unrepresentable []string `json:"unrepresentable"` // 每项描述一处丢弃或改写 / Each entry describes one dropped or rewritten item
+}
+
+// addf 追加一条格式化的报告项
+// addf appends one formatted report entry
+func (r *PartsPortReport) addf(format string, args ...interface{}) {
+	r.Unrepresentable = append(r.Unrepresentable, fmt.Sprintf(format, args...))
+}
+
+// ConvertCOM3D2PartsToAssets 将 COM3D2 .menu、.mate 和 .pmat（或对应 .json）移植为 KCES 资源容器并写入输出目录
+// 容器命名为 <name>.menuassets、<name>.materialassets 和 <name>.pmatassets，没有对应输入的容器不写出；条目文件名从输入文件名小写派生
+// Menu 与 Material 的 ID 在写出时通过 LookupHashOptions 重算，Menu.GUID 因此取得新的随机值；返回的报告列出无法在 KCES 中表达的内容
+// ConvertCOM3D2PartsToAssets ports COM3D2 .menu, .mate, and .pmat files (or their .json forms) into KCES asset containers written to the output directory
+// Containers are named <name>.menuassets, <name>.materialassets, and <name>.pmatassets, a container without inputs is not written, and entry file names derive from the lowercased input file names
+// Menu and Material IDs are recalculated through LookupHashOptions during encoding, so Menu.GUID receives a new random value; the returned report lists what KCES cannot represent
+func (s *PartsService) ConvertCOM3D2PartsToAssets(ctx context.Context, inputPaths []string, outputDir string, name string, portMap *PartsPortMap, maxOutputBytes int64) (*PartsPortReport, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid container name %q", name)
+	}
+	if portMap == nil {
+		portMap = DefaultPartsPortMap()
+	}
+	report := &PartsPortReport{Outputs: []string{}, Unrepresentable: []string{}}
+	menus := &serializationKCES.MenuAssets{}
+	materials := &serializationKCES.MaterialAssets{}
+	priorityMaterials := &serializationKCES.PriorityMaterialAssets{}
+	seen := make(map[string]string, len(inputPaths))
+	for _, inputPath := range inputPaths {
+		if ctx != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		kind, stem := com3D2PartsKind(inputPath)
+		if kind == "" {
+			return nil, fmt.Errorf("unsupported COM3D2 parts file %q; expected .menu, .mate, or .pmat", inputPath)
+		}
+		fileName := stem + kind
+		if previous, ok := seen[fileName]; ok {
+			return nil, fmt.Errorf("%q and %q both port to %s", previous, inputPath, fileName)
+		}
+		seen[fileName] = inputPath
+
+		var itemReport *PartsPortReport
+		switch kind {
+		case serializationKCES.MenuExtension:
+			source, err := (&COM3D2Service.MenuService{}).ReadMenuFile(inputPath)
+			if err != nil {
+				return nil, fmt.Errorf("read COM3D2 menu %q: %w", inputPath, err)
+			}
+			menu, menuReport, err := PortCOM3D2Menu(source, fileName, portMap)
+			if err != nil {
+				return nil, fmt.Errorf("port COM3D2 menu %q: %w", inputPath, err)
+			}
+			menus.Assets = append(menus.Assets, menu)
+			itemReport = menuReport
+		case serializationKCES.MaterialExtension:
+			source, err := (&COM3D2Service.MateService{}).ReadMateFile(inputPath)
+			if err != nil {
+				return nil, fmt.Errorf("read COM3D2 material %q: %w", inputPath, err)
+			}
+			material, materialReport, err := PortCOM3D2Mate(source, fileName, portMap)
+			if err != nil {
+				return nil, fmt.Errorf("port COM3D2 material %q: %w", inputPath, err)
+			}
+			materials.Assets = append(materials.Assets, material)
+			itemReport = materialReport
+		default:
+			source, err := (&COM3D2Service.PMatService{}).ReadPMatFile(inputPath)
+			if err != nil {
+				return nil, fmt.Errorf("read COM3D2 pmat %q: %w", inputPath, err)
+			}
+			priorityMaterial, err := PortCOM3D2PMat(source, fileName)
+			if err != nil {
+				return nil, fmt.Errorf("port COM3D2 pmat %q: %w", inputPath, err)
+			}
+			priorityMaterials.Assets = append(priorityMaterials.Assets, priorityMaterial)
+		}
+		if itemReport != nil {
+			for _, item := range itemReport.Unrepresentable {
+				report.addf("%s: %s", filepath.Base(inputPath), item)
+			}
+		}
+	}
+	if len(menus.Assets) == 0 && len(materials.Assets) == 0 && len(priorityMaterials.Assets) == 0 {
+		return nil, fmt.Errorf("no COM3D2 .menu, .mate, or .pmat files to port")
+	}
+
+	options := &serializationKCES.LookupHashOptions{RecalculateHash: true}
+	type output struct {
+		path  string
+		data  []byte
+		write func(context.Context, string, []byte, int64) error
+	}
+	var outputs []output
+	if len(menus.Assets) > 0 {
+		containerName := name + menuAssetsExtension
+		menus.FileName = &containerName
+		data, err := serializationKCES.EncodeMenuAssetsWithOptions(menus, options)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", containerName, err)
+		}
+		outputs = append(outputs, output{filepath.Join(outputDir, containerName), data, writeMenuAssetsConversionOutput})
+	}
+	if len(materials.Assets) > 0 {
+		containerName := name + materialAssetsExtension
+		materials.FileName = &containerName
+		data, err := serializationKCES.EncodeMaterialAssetsWithOptions(materials, options)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", containerName, err)
+		}
+		outputs = append(outputs, output{filepath.Join(outputDir, containerName), data, writeMaterialAssetsConversionOutput})
+	}
+	if len(priorityMaterials.Assets) > 0 {
+		containerName := name + priorityMaterialAssetsExtension
+		priorityMaterials.FileName = &containerName
+		data, err := serializationKCES.EncodePriorityMaterialAssets(priorityMaterials)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", containerName, err)
+		}
+		outputs = append(outputs, output{filepath.Join(outputDir, containerName), data, writePriorityMaterialAssetsConversionOutput})
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("create output directory %q: %w", outputDir, err)
+	}
+	for _, item := range outputs {
+		if err := item.write(ctx, item.path, item.data, maxOutputBytes); err != nil {
+			return nil, err
+		}
+		report.Outputs = append(report.Outputs, item.path)
+	}
+	return report, nil
+}
+
+// com3D2PartsKind 返回输入路径的 KCES 条目扩展名和小写文件名主干，不受支持的路径返回空扩展名
+// com3D2PartsKind returns the KCES entry extension and lowercase file-name stem of an input path, with an empty extension for unsupported paths
+func com3D2PartsKind(path string) (string, string) {
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(path)), ".json")
+	extension := filepath.Ext(base)
+	switch extension {
+	case serializationKCES.MenuExtension, serializationKCES.MaterialExtension, ".pmat":
+		return extension, strings.TrimSuffix(base, extension)
+	default:
+		return "", ""
+	}
+}
+
+// PortCOM3D2Menu 将 COM3D2 菜单映射为 KCES Menu，fileName 为写入的菜单文件名
+// name、setumei、category、color_set、icon/icons 和 priority 写入对应字段并回退到文件头，其余命令按 portMap.Commands 映射类型并原样复制参数
+// 未映射的命令被丢弃并写入报告；ID 与 GUID 留给编码时重算
+// PortCOM3D2Menu maps a COM3D2 menu onto a KCES Menu, with fileName being the menu file name to write
+// name, setumei, category, color_set, icon/icons, and priority fill their fields with the file header as a fallback, and every other command maps its type through portMap.Commands and copies its arguments verbatim
+// Unmapped commands are dropped and reported; ID and GUID are left for recalculation during encoding
+func PortCOM3D2Menu(source *serializationCOM3D2.Menu, fileName string, portMap *PartsPortMap) (*serializationKCES.Menu, *PartsPortReport, error) {
+	if source == nil {
+		return nil, nil, fmt.Errorf("COM3D2 menu is nil")
+	}
+	if !strings.HasSuffix(strings.ToLower(fileName), serializationKCES.MenuExtension) {
+		return nil, nil, fmt.Errorf("menu file name %q must end in %s", fileName, serializationKCES.MenuExtension)
+	}
+	if portMap == nil {
+		portMap = DefaultPartsPortMap()
+	}
+	report := &PartsPortReport{}
+	menu := serializationKCES.NewMenu()
+	menu.FileName = &fileName
+	itemName, infoText, category := source.ItemName, source.InfoText, source.Category
+	var icon, colorSet string
+	dropped := map[string]int{}
+	for _, command := range source.Commands {
+		first := ""
+		if len(command.Args) > 0 {
+			first = command.Args[0]
+		}
+		switch command.Command {
+		case "end", "ver":
+		case "name":
+			itemName = first
+		case "setumei":
+			infoText = first
+		case "category":
+			category = first
+		case "color_set":
+			colorSet = first
+		case "icon", "icons":
+			icon = first
+		case "priority":
+			value, err := strconv.ParseFloat(first, 32)
+			if err != nil || value < math.MinInt32 || value > math.MaxInt32 {
+				report.addf("priority %q is not a number and was dropped", first)
+				continue
+			}
+			menu.Priority = int32(math.Round(value))
+			if float64(menu.Priority) != value {
+				report.addf("priority %s was rounded to %d; KCES stores an integer priority", first, menu.Priority)
+			}
+		default:
+			commandType, ok := portMap.Commands[command.Command]
+			if !ok {
+				dropped[command.Command]++
+				continue
+			}
+			args := make([]*string, len(command.Args))
+			for index := range command.Args {
+				arg := command.Args[index]
+				args[index] = &arg
+			}
+			menu.Commands = append(menu.Commands, &serializationKCES.Command{Type: commandType, Args: args})
+		}
+	}
+	menu.ItemName = &itemName
+	menu.InfoText = &infoText
+	if category != "" {
+		menu.CategoryText = &category
+	}
+	if colorSet != "" {
+		menu.ColorSetText = &colorSet
+	}
+	if icon != "" {
+		menu.IconFileName = &icon
+	}
+	keywords := make([]string, 0, len(dropped))
+	for keyword := range dropped {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		report.addf("%d %q commands were dropped; the port map gives the command no KCES command type", dropped[keyword], keyword)
+	}
+	return menu, report, nil
+}
+
+// PortCOM3D2Mate 将 COM3D2 材质映射为 KCES Material，fileName 为写入的材质文件名
+// 纹理、颜色、向量、浮点和 range 属性按 portMap.Properties 映射类型，tex_offset 与 tex_scale 合并到同名纹理，关键字按 portMap.Keywords 映射
+// 纹理名保留 COM3D2 资源名，纹理本身需另行转换；ID 留给编码时重算
+// PortCOM3D2Mate maps a COM3D2 material onto a KCES Material, with fileName being the material file name to write
+// Texture, color, vector, float, and range properties map their type through portMap.Properties, tex_offset and tex_scale merge into the texture of the same name, and keywords map through portMap.Keywords
+// Texture names keep the COM3D2 resource name and the textures themselves must be converted separately; the ID is left for recalculation during encoding
+func PortCOM3D2Mate(source *serializationCOM3D2.Mate, fileName string, portMap *PartsPortMap) (*serializationKCES.Material, *PartsPortReport, error) {
+	if source == nil || source.Material == nil {
+		return nil, nil, fmt.Errorf("COM3D2 material is nil")
+	}
+	if !strings.HasSuffix(strings.ToLower(fileName), serializationKCES.MaterialExtension) {
+		return nil, nil, fmt.Errorf("material file name %q must end in %s", fileName, serializationKCES.MaterialExtension)
+	}
+	if portMap == nil {
+		portMap = DefaultPartsPortMap()
+	}
+	report := &PartsPortReport{}
+	material := serializationKCES.NewMaterial()
+	material.FileName = &fileName
+	shaderName := source.Material.ShaderName
+	material.ShaderName = &shaderName
+	if stem := strings.TrimSuffix(fileName, filepath.Ext(fileName)); !strings.EqualFold(source.Material.Name, stem) {
+		report.addf("material name %q differs from the file name; KCES finds materials by file name only", source.Material.Name)
+	}
+
+	textures := map[string]*serializationKCES.TextureProp{}
+	propertyType := func(kind, name string) (int32, bool) {
+		value, ok := portMap.Properties[name]
+		if !ok {
+			report.addf("%s property %q was dropped; the port map gives it no KCES property type", kind, name)
+		}
+		return value, ok
+	}
+	for _, property := range source.Material.Properties {
+		switch p := property.(type) {
+		case *serializationCOM3D2.TexProperty:
+			if p.SubTag == "texRT" {
+				report.addf("texture property %q was dropped; KCES has no render-texture slots", p.PropName)
+				continue
+			}
+			value, ok := propertyType("texture", p.PropName)
+			if !ok {
+				continue
+			}
+			texture := &serializationKCES.TextureProp{Type: value, Sx: 1, Sy: 1}
+			if p.Tex2D != nil {
+				textureName := p.Tex2D.Name
+				texture.FileName = &textureName
+				texture.Ox, texture.Oy = p.Tex2D.Offset[0], p.Tex2D.Offset[1]
+				texture.Sx, texture.Sy = p.Tex2D.Scale[0], p.Tex2D.Scale[1]
+			}
+			textures[p.PropName] = texture
+			material.TextureProps = append(material.TextureProps, texture)
+		case *serializationCOM3D2.TexOffsetProperty:
+			if texture, ok := textures[p.PropName]; ok {
+				texture.Ox, texture.Oy = p.OffsetX, p.OffsetY
+			} else {
+				report.addf("tex_offset %q was dropped; no ported texture has that name", p.PropName)
+			}
+		case *serializationCOM3D2.TexScaleProperty:
+			if texture, ok := textures[p.PropName]; ok {
+				texture.Sx, texture.Sy = p.ScaleX, p.ScaleY
+			} else {
+				report.addf("tex_scale %q was dropped; no ported texture has that name", p.PropName)
+			}
+		case *serializationCOM3D2.ColProperty:
+			if value, ok := propertyType("color", p.PropName); ok {
+				material.ColorProps = append(material.ColorProps, &serializationKCES.ColorProp{Type: value, R: p.Color[0], G: p.Color[1], B: p.Color[2], A: p.Color[3]})
+			}
+		case *serializationCOM3D2.VecProperty:
+			if value, ok := propertyType("vector", p.PropName); ok {
+				material.VectorProps = append(material.VectorProps, &serializationKCES.VectorProp{Type: value, X: p.Vector[0], Y: p.Vector[1], Z: p.Vector[2], W: p.Vector[3]})
+			}
+		case *serializationCOM3D2.FProperty:
+			if value, ok := propertyType("float", p.PropName); ok {
+				material.FloatProps = append(material.FloatProps, &serializationKCES.FloatProp{Type: value, V: p.Number})
+			}
+		case *serializationCOM3D2.RangeProperty:
+			if value, ok := propertyType("float", p.PropName); ok {
+				material.FloatProps = append(material.FloatProps, &serializationKCES.FloatProp{Type: value, V: p.Number})
+			}
+		case *serializationCOM3D2.KeywordProperty:
+			for _, keyword := range p.Keywords {
+				value, ok := portMap.Keywords[keyword.Key]
+				if !ok {
+					report.addf("shader keyword %q was dropped; the port map gives it no KCES keyword type", keyword.Key)
+					continue
+				}
+				material.KeywordProps = append(material.KeywordProps, &serializationKCES.KeywordProp{Type: value, Value: keyword.Value})
+			}
+		default:
+			report.addf("%s property was dropped; KCES has no equivalent", property.GetTypeName())
+		}
+	}
+	if len(material.TextureProps) > 0 {
+		report.addf("%d textures keep their COM3D2 resource names; the textures themselves must be converted and packed separately", len(material.TextureProps))
+	}
+	return material, report, nil
+}
+
+// PortCOM3D2PMat 将 COM3D2 .pmat 映射为 KCES PriorityMaterial，fileName 为写入的 .pmat 文件名
+// 容器编码不重算优先级材质的哈希，因此这里直接计算：ID 为去扩展名小写文件名的 FNV-1a 哈希，TargetID 与目标材质 <MaterialName>.mate 编码时重算出的 ID 相同
+// PortCOM3D2PMat maps a COM3D2 .pmat onto a KCES PriorityMaterial, with fileName being the .pmat file name to write
+// Container encoding does not recalculate priority-material hashes, so they are computed here: ID is the FNV-1a hash of the lowercase extensionless file name, and TargetID equals the ID recalculated for the target material <MaterialName>.mate during encoding
+func PortCOM3D2PMat(source *serializationCOM3D2.PMat, fileName string) (*serializationKCES.PriorityMaterial, error) {
+	if source == nil {
+		return nil, fmt.Errorf("COM3D2 pmat is nil")
+	}
+	if source.MaterialName == "" {
+		return nil, fmt.Errorf("COM3D2 pmat has no material name")
+	}
+	priorityMaterial := serializationKCES.NewPriorityMaterial()
+	priorityMaterial.FileName = &fileName
+	priorityMaterial.ID = ct.HashString(strings.ToLower(strings.TrimSuffix(fileName, filepath.Ext(fileName))))
+	priorityMaterial.RenderQueue = source.RenderQueue
+	priorityMaterial.TargetID = ct.HashString(strings.ToLower(source.MaterialName) + serializationKCES.MaterialExtension)
+	return priorityMaterial, nil
+}
@@ -0,0 +1,144 @@
+package KCES
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	serializationCOM3D2 "github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/COM3D2"
+	"github.com/MeidoPromotionAssociation/MeidoSerialization/serialization/KCES/ct"
+	COM3D2Service "github.com/MeidoPromotionAssociation/MeidoSerialization/service/COM3D2"
+)
+
+func TestPortCOM3D2MenuMapsMetadataAndCommands(t *testing.T) {
+	source := &serializationCOM3D2.Menu{Signature: serializationCOM3D2.MenuSignature, Version: 1000, ItemName: "header", Category: "wear", Commands: []serializationCOM3D2.Command{
+		{Command: "name", Args: []string{"Dress"}},
+		{Command: "setumei", Args: []string{"A dress"}},
+		{Command: "icons", Args: []string{"dress_i_.tex"}},
+		{Command: "priority", Args: []string{"100.5"}},
+		{Command: "additem", Args: []string{"dress.model", "wear"}},
+		{Command: "tex", Args: []string{"wear", "0", "_MainTex", "dress.tex"}},
+		{Command: "tex", Args: []string{"wear", "1", "_MainTex", "dress2.tex"}},
+	}}
+	portMap := DefaultPartsPortMap()
+	portMap.Commands["additem"] = 7
+
+	menu, report, err := PortCOM3D2Menu(source, "dress.menu", portMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *menu.ItemName != "Dress" || *menu.InfoText != "A dress" || *menu.CategoryText != "wear" || *menu.ColorSetText != "null_mpn" ||
+		*menu.IconFileName != "dress_i_.tex" || menu.Priority != 101 {
+		t.Fatalf("menu metadata = %+v", menu)
+	}
+	if len(menu.Commands) != 1 || menu.Commands[0].Type != 7 || len(menu.Commands[0].Args) != 2 || *menu.Commands[0].Args[1] != "wear" {
+		t.Fatalf("commands = %+v", menu.Commands)
+	}
+	if len(report.Unrepresentable) != 2 || !strings.Contains(report.Unrepresentable[1], `2 "tex" commands`) {
+		t.Fatalf("report = %q", report.Unrepresentable)
+	}
+	if _, _, err := PortCOM3D2Menu(source, "dress", portMap); err == nil {
+		t.Error("extensionless menu file name was accepted")
+	}
+
+	menu, report, err = PortCOM3D2Menu(source, "dress.menu", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(menu.Commands) != 0 || len(report.Unrepresentable) != 3 || !strings.Contains(report.Unrepresentable[1], `1 "additem" commands`) {
+		t.Fatalf("nil map commands = %+v, report = %q", menu.Commands, report.Unrepresentable)
+	}
+	metadataOnly := &serializationCOM3D2.Menu{Signature: serializationCOM3D2.MenuSignature, Version: 1000, Commands: source.Commands[:3]}
+	if _, report, err = PortCOM3D2Menu(metadataOnly, "dress.menu", nil); err != nil || len(report.Unrepresentable) != 0 {
+		t.Fatalf("metadata-only menu: report = %q, err = %v", report.Unrepresentable, err)
+	}
+}
+
+func TestPortCOM3D2MateMapsPropertiesAndReportsTheRest(t *testing.T) {
+	source := &serializationCOM3D2.Mate{Signature: serializationCOM3D2.MateSignature, Version: 1000, Name: "dress", Material: &serializationCOM3D2.Material{
+		Name:       "dress",
+		ShaderName: "CM3D2/Toony_Lighted",
+		Properties: []serializationCOM3D2.Property{
+			&serializationCOM3D2.TexProperty{PropName: "_MainTex", SubTag: "tex2d", Tex2D: &serializationCOM3D2.Tex2DSubProperty{Name: "dress", Scale: [2]float32{1, 1}}},
+			&serializationCOM3D2.TexOffsetProperty{PropName: "_MainTex", OffsetX: 0.5, OffsetY: 0.25},
+			&serializationCOM3D2.TexProperty{PropName: "_ToonRamp", SubTag: "texRT", TexRT: &serializationCOM3D2.TexRTSubProperty{}},
+			&serializationCOM3D2.ColProperty{PropName: "_Color", Color: [4]float32{1, 0.5, 0.25, 1}},
+			&serializationCOM3D2.FProperty{PropName: "_Shininess", Number: 0.3},
+			&serializationCOM3D2.RangeProperty{PropName: "_Cutoff", Number: 0.5},
+			&serializationCOM3D2.KeywordProperty{PropName: "keyword", Count: 1, Keywords: []serializationCOM3D2.Keyword{{Key: "_ALPHATEST_ON", Value: true}}},
+		},
+	}}
+
+	material, report, err := PortCOM3D2Mate(source, "dress.mate", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *material.ShaderName != "CM3D2/Toony_Lighted" || len(material.TextureProps) != 1 || len(material.ColorProps) != 1 || len(material.FloatProps) != 1 || material.KeywordProps != nil {
+		t.Fatalf("material = %+v", material)
+	}
+	texture := material.TextureProps[0]
+	if texture.Type != 0 || *texture.FileName != "dress" || texture.Ox != 0.5 || texture.Oy != 0.25 || texture.Sx != 1 {
+		t.Errorf("texture = %+v", texture)
+	}
+	if material.ColorProps[0].Type != 100 || material.ColorProps[0].G != 0.5 || material.FloatProps[0].Type != 200 {
+		t.Errorf("color = %+v, float = %+v", material.ColorProps[0], material.FloatProps[0])
+	}
+	if len(report.Unrepresentable) != 4 {
+		t.Errorf("report = %q", report.Unrepresentable)
+	}
+}
+
+func TestConvertCOM3D2PartsToAssetsRecalculatesIDs(t *testing.T) {
+	inputDir := t.TempDir()
+	menuPath := filepath.Join(inputDir, "Dress.menu")
+	matePath := filepath.Join(inputDir, "Dress.mate")
+	pmatPath := filepath.Join(inputDir, "Dress.pmat")
+	menu := &serializationCOM3D2.Menu{Signature: serializationCOM3D2.MenuSignature, Version: 1000, Commands: []serializationCOM3D2.Command{{Command: "name", Args: []string{"Dress"}}}}
+	if err := (&COM3D2Service.MenuService{}).WriteMenuFile(menuPath, menu); err != nil {
+		t.Fatal(err)
+	}
+	mate := &serializationCOM3D2.Mate{Signature: serializationCOM3D2.MateSignature, Version: 1000, Name: "Dress", Material: &serializationCOM3D2.Material{Name: "Dress", ShaderName: "CM3D2/Toony_Lighted", ShaderFilename: "cm3d2_toony_lighted"}}
+	if err := (&COM3D2Service.MateService{}).WriteMateFile(matePath, mate); err != nil {
+		t.Fatal(err)
+	}
+	pmat := &serializationCOM3D2.PMat{Signature: serializationCOM3D2.PMatSignature, Version: 1000, MaterialName: "Dress", RenderQueue: 3100}
+	if err := (&COM3D2Service.PMatService{}).WritePMatFile(pmatPath, pmat); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := t.TempDir()
+	report, err := (&PartsService{}).ConvertCOM3D2PartsToAssets(context.Background(), []string{menuPath, matePath, pmatPath}, outputDir, "dress", nil, TestConversionMaxOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Outputs) != 3 {
+		t.Fatalf("outputs = %v", report.Outputs)
+	}
+	menus, err := (&MenuAssetsService{}).ReadMenuAssetsFile(filepath.Join(outputDir, "dress.menuassets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(menus.Assets) != 1 || *menus.Assets[0].FileName != "dress.menu" || menus.Assets[0].ID != ct.HashStringIgnoreCase("dress.menu") {
+		t.Errorf("menus = %+v", menus.Assets)
+	}
+	materials, err := (&MaterialAssetsService{}).ReadMaterialAssetsFile(filepath.Join(outputDir, "dress.materialassets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(materials.Assets) != 1 || materials.Assets[0].ID != ct.HashString("dress.mate") {
+		t.Fatalf("materials = %+v", materials.Assets)
+	}
+	priorityMaterials, err := (&PriorityMaterialAssetsService{}).ReadPriorityMaterialAssetsFile(filepath.Join(outputDir, "dress.pmatassets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(priorityMaterials.Assets) != 1 || priorityMaterials.Assets[0].TargetID != materials.Assets[0].ID ||
+		priorityMaterials.Assets[0].RenderQueue != 3100 || *priorityMaterials.Assets[0].FileName != "dress.pmat" {
+		t.Errorf("priority materials = %+v", priorityMaterials.Assets)
+	}
+
+	if _, err := (&PartsService{}).ConvertCOM3D2PartsToAssets(context.Background(), []string{menuPath, menuPath}, outputDir, "dress", nil, TestConversionMaxOutput); err == nil {
+		t.Error("duplicate inputs were accepted")
+	}
+}
@@ -43,6 +43,28 @@ func resolveUnityPackSettings() unityPackSettings {
 	}
 }
 
+// resolveUnityPackSettingsForVersion 返回以清单 Unity 版本替换 SerializedFile 与 UnityFS 引擎版本后的打包契约，为空时与 resolveUnityPackSettings 相同
+// resolveUnityPackSettingsForVersion returns the packing contract with the SerializedFile and UnityFS engine versions replaced by a manifest Unity version, matching resolveUnityPackSettings when empty
+func resolveUnityPackSettingsForVersion(version string) (unityPackSettings, error) {
+	settings := resolveUnityPackSettings()
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return settings, nil
+	}
+	// 写入器只生成 SerializedFile 22 布局，因此版本仍需落在 KCES 使用的 2020.2 及以后版本线内
+	// The writer only produces the SerializedFile 22 layout, so the version must still fall within the 2020.2-and-later line used by KCES
+	major, minor, err := parseUnityMajorMinor(version)
+	if err != nil {
+		return unityPackSettings{}, fmt.Errorf("invalid unityVersion %q: %w", version, err)
+	}
+	if major < minimumCanonicalUnityMajor || major == minimumCanonicalUnityMajor && minor < minimumCanonicalUnityMinor {
+		return unityPackSettings{}, fmt.Errorf("unsupported unityVersion %q: packing requires Unity %d.%d or later", version, minimumCanonicalUnityMajor, minimumCanonicalUnityMinor)
+	}
+	settings.UnityVersion = version
+	settings.EngineVersion = version
+	return settings, nil
+}
+
 // validateCanonicalSourceUnityVersion 确认源 Unity 版本不早于 KCES 已知使用的 2020.2 版本线并对后续版本保持开放
 // validateCanonicalSourceUnityVersion confirms that the source Unity version is no earlier than the 2020.2 line known to be used by KCES while remaining open to later versions
 func validateCanonicalSourceUnityVersion(version string) error {
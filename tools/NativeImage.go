@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器
+	_ "image/jpeg" // 注册 JPEG 解码器
+	_ "image/png"  // 注册 PNG 解码器
+	"io"
+	"os"
+)
+
+// NativeImageFormat 表示无需 ImageMagick 即可处理的图像格式
+type NativeImageFormat string
+
+const (
+	NativeImagePNG  NativeImageFormat = "png"
+	NativeImageJPEG NativeImageFormat = "jpeg"
+	NativeImageGIF  NativeImageFormat = "gif"
+	NativeImageDDS  NativeImageFormat = "dds"
+)
+
+// SniffNativeImageFormat 根据文件头魔数判断数据是否为标准库或内置 DDS 解析器可处理的格式
+// 返回空字符串表示需要 ImageMagick
+func SniffNativeImageFormat(header []byte) NativeImageFormat {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return NativeImagePNG
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		return NativeImageJPEG
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return NativeImageGIF
+	case bytes.HasPrefix(header, []byte("DDS ")):
+		return NativeImageDDS
+	default:
+		return ""
+	}
+}
+
+// DetectNativeImageFile 读取文件头并返回原生支持的图像格式，无法识别时返回空字符串
+func DetectNativeImageFile(path string) NativeImageFormat {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	header := make([]byte, 8)
+	n, _ := io.ReadFull(f, header)
+	return SniffNativeImageFormat(header[:n])
+}
+
+// IsNativeImageType 判断文件是否为无需 ImageMagick 即可读取的图像
+func IsNativeImageType(path string) bool {
+	return DetectNativeImageFile(path) != ""
+}
+
+// DecodeNativeImage 使用标准库解码 PNG、JPEG 或 GIF 数据，DDS 需由调用方使用块压缩解码器处理
+func DecodeNativeImage(data []byte) (image.Image, string, error) {
+	return image.Decode(bytes.NewReader(data))
+}
@@ -772,17 +772,32 @@ func cliOnlyOperations() []map[string]any {
 		{
 			"game": "COM3D2", "file_type": "nei", "native_suffixes": []string{".nei"},
 			"cli_commands": []string{"convert2csv", "convert2nei"},
-			"detail":       "No MCP format is registered for .nei because it converts to CSV rather than to editing JSON. The command line converts .nei to .csv and .csv back to .nei.",
+			"detail":       "MCP converts com3d2.nei to editing JSON whose table travels as a UTF-8 BOM .csv attachment beside it, with TextEncoding selecting Shift-JIS for COM3D2 or UTF-8 for KCES. Converting a standalone .csv that has no editing JSON beside it into a .nei, and writing a standalone .csv from a .nei, are command line only.",
 		},
 		{
 			"game": "COM3D2", "file_type": "tex", "native_suffixes": []string{".tex"},
 			"cli_commands": []string{"convert2image", "convert2tex"},
-			"detail":       "The registered com3d2.tex format is detect only because a texture converts to an image rather than to editing JSON. The command line converts .tex to PNG or DDS and an image back to .tex.",
+			"detail":       "MCP converts com3d2.tex to editing JSON whose pixels travel as a .png or .dds attachment beside it. Writing a standalone image with a .uv.csv atlas sidecar, and building a .tex from JPEG, GIF, or an ImageMagick-only format with a chosen compression, are command line only.",
+		},
+		{
+			"game": "COM3D2", "file_type": "model", "native_suffixes": []string{".model"},
+			"cli_commands": []string{"convert2gltf", "gltf2model", "model2kces"},
+			"detail":       "MCP converts com3d2.model to editing JSON. Exporting a .model to a skinned glTF or GLB with its bones, submeshes, morph targets, and materials, and importing glTF back to a .model, are command line only. Fields without a glTF equivalent travel in the com3d2Model, com3d2Bone, com3d2Material, and com3d2Morphs extras so an unedited file converts back byte-identically; gltf2model selects COM3D2 from those extras or from --game COM3D2. Porting a .model to a KCES .model and .mmesh pair with an optional --bone-map JSON rename table is also command line only; model2kces prints what KCES cannot represent, such as material shaders and properties, as warnings.",
+		},
+		{
+			"game": "COM3D2", "file_type": "anm", "native_suffixes": []string{".anm"},
+			"cli_commands": []string{"convert2gltf", "gltf2anm"},
+			"detail":       "MCP converts com3d2.anm to editing JSON. Exporting an .anm to a glTF or GLB CUBICSPLINE animation, optionally over a .model rest pose passed with --model, and fitting a glTF animation back into .anm keyframes are command line only. The com3d2Anm animation extras keep the bust-animation switches, curve order, and keyframe times so an unedited file converts back byte-identically; gltf2anm selects COM3D2 from those extras or from --game COM3D2.",
+		},
+		{
+			"game": "COM3D2", "file_type": "menu", "native_suffixes": []string{".menu"},
+			"cli_commands": []string{"depsMenu", "exportMenu", "renameAssets", "parts2kces"},
+			"detail":       "MCP converts com3d2.menu to editing JSON. Resolving the files the game loads for a .menu, following its models, materials, textures, animations, and nested menus through the .mate, .pmat, .phy, .psk, and .col files across layered .arc files and mod folders, and exporting that closure into a clean folder or a new .arc with an optional name prefix that rewrites every reference, and renaming assets across a mod folder with a dry-run diff, are command line only. Porting .menu, .mate, and .pmat files into KCES .menuassets, .materialassets, and .pmatassets containers with recalculated IDs and an optional --map JSON enum table is also command line only; parts2kces prints unmapped commands, properties, and keywords as warnings.",
 		},
 		{
 			"game": "KCES", "file_type": "texture2d", "native_suffixes": []string{".tex", ".texture2d"},
 			"cli_commands": []string{"convert2image", "convert2texture2d"},
-			"detail":       "A native Unity Texture2D primary file is recognized by its class ID rather than by a suffix and has no MCP format. The command line converts it to PNG or DDS and an image back to a native Texture2D. PNG output is upright while DDS passes the Unity block payload through in bottom-up order. convert2texture2d has to keep the original file name and pixel dimensions, because PathIDs are hashed from the canonical file path and atlas textureRect values are absolute pixels, and it always rebuilds inline single-mip RGBA32, so a block-compressed texture grows and loses its mipmaps.",
+			"detail":       "A native Unity Texture2D primary file is recognized by its class ID rather than by a suffix and has no MCP format. The command line converts it to PNG or DDS and an image back to a native Texture2D. PNG output is upright while DDS passes the Unity block payload through in bottom-up order. convert2texture2d has to keep the original file name and pixel dimensions, because PathIDs are hashed from the canonical file path and atlas textureRect values are absolute pixels, and without --format, --mipmaps, --linear, or --stream it rebuilds inline single-mip RGBA32, so pass --format BC7 --mipmaps (or the original format) to keep a block-compressed texture small and mipmapped.",
 		},
 		{
 			"game": "KCES", "file_type": "sprite", "native_suffixes": []string{".sprite"},
@@ -796,8 +811,8 @@ func cliOnlyOperations() []map[string]any {
 		},
 		{
 			"game": "KCES", "file_type": "animation_clip", "native_suffixes": []string{".anm"},
-			"cli_commands": []string{"convert2gltf"},
-			"detail":       "A native Unity AnimationClip primary file is recognized by its class ID rather than by a suffix and has no MCP format. The command line exports it to glTF. The registered com3d2.anm format is the unrelated CM3D2_ANIM binary.",
+			"cli_commands": []string{"convert2gltf", "gltf2anm"},
+			"detail":       "A native Unity AnimationClip primary file is recognized by its class ID rather than by a suffix and has no MCP format. The command line exports it to glTF, and gltf2anm builds a new legacy AnimationClip with an embedded Unity 2022.3 TypeTree from a glTF animation so packAba can ship custom motions; gltf2anm selects KCES unless the animation carries com3d2Anm extras or --game COM3D2 is passed. The registered com3d2.anm format is the unrelated CM3D2_ANIM binary.",
 		},
 		{
 			"game": "KCES", "file_type": "audio_clip", "native_suffixes": []string{".audioclip"},
@@ -806,8 +821,8 @@ func cliOnlyOperations() []map[string]any {
 		},
 		{
 			"game": "COM3D2 and KCES", "file_type": "archive", "native_suffixes": []string{".arc", ".aba", ".ct"},
-			"cli_commands": []string{"packArc", "unpackArc", "packAba", "unpackAba", "genCt"},
-			"detail":       "MCP lists container entries and extracts one exact entry at a time. Creating a container or unpacking a whole container in one call is command line only.",
+			"cli_commands": []string{"packArc", "unpackArc", "updateArc", "diffArc", "resolveArc", "verifyArc", "packAba", "unpackAba", "recompressAba", "replaceAbaObject", "graphAba", "genCt", "conflictCt"},
+			"detail":       "MCP lists container entries and extracts one exact entry at a time. Creating or recompressing a container, replacing an object inside an ABA, graphing bundle dependencies, analyzing catalog conflicts, updating, diffing, layering, or verifying ARC files, or unpacking a whole container in one call is command line only.",
 		},
 	}
 }